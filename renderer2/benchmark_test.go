@@ -50,7 +50,10 @@ func BenchmarkRenderExamples(b *testing.B) {
 		"prod": prodSettings,
 	}
 
-	stages := generateStages(componentDef)
+	stages, err := generateStages(componentDef, addons)
+	if err != nil {
+		b.Fatalf("failed to order addons: %v", err)
+	}
 
 	b.ResetTimer()
 
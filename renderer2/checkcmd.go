@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/baseline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/platform"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// environmentFindings is the -json report of one environment.
+type environmentFindings struct {
+	Environment string             `json:"environment"`
+	Baseline    string             `json:"baseline"`
+	Findings    []baseline.Finding `json:"findings"`
+}
+
+// runCheck renders the -baseline environment and every other -env, and reports the deltas the
+// baseline rules flag in each of them. Error findings fail the command.
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	var envs envFlag
+	fs.Var(&envs, "env", "EnvSettings to render as name=path; repeatable")
+	baselineName := fs.String("baseline", "", "the -env every other environment is compared with, usually prod")
+	failOnWarnings := fs.Bool("fail-on-warnings", false, "fail on warning findings as well as errors")
+	jsonReport := fs.Bool("json", false, "print the findings as JSON")
+	configPath := fs.String("config", "", "platform configuration file; defaults to $"+config.EnvVar+", then every "+config.FileName+" up to the repository root")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	platformConfig, err := config.Resolve(*configPath, ".")
+	if err != nil {
+		return fmt.Errorf("failed to load platform configuration: %w", err)
+	}
+	if err := applyConfigFlags(fs, platformConfig); err != nil {
+		return err
+	}
+	if *baselineName == "" {
+		return fmt.Errorf("-baseline is required, or baseline.environment in %s", config.FileName)
+	}
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	componentDef, err := inputs.loadComponent()
+	if err != nil {
+		return fmt.Errorf("failed to load component: %w", err)
+	}
+	addons, err := inputs.loadAddons(componentDef)
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+	additionalCtx := inputs.loadAdditionalContext()
+
+	hooks := append([]pipeline.Hooks{platform.Availability{}}, platformConfig.Hooks()...)
+	renderer := component.NewRenderer(template.NewEngine(observability.EngineOptions()...), nil,
+		component.WithEventSink(warningLogger{}),
+		component.WithStabilityPolicy(platformConfig.StabilityPolicy()),
+		component.WithHooks(hooks...),
+	)
+	render := func(env namedEnv) ([]map[string]any, error) {
+		settings, err := parser.LoadEnvSettings(env.path, inputs.parseOptions()...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load env settings %s: %w", env.name, err)
+		}
+		resources, err := renderer.RenderAll(ctd, componentDef, settings, addons, additionalCtx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render environment %s: %w", env.name, err)
+		}
+		return resources, nil
+	}
+
+	var reference []map[string]any
+	found := false
+	for _, env := range envs {
+		if env.name == *baselineName {
+			if reference, err = render(env); err != nil {
+				return err
+			}
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("baseline environment %s is not one of the -env flags", *baselineName)
+	}
+
+	rules := platformConfig.BaselineRules()
+	var report []environmentFindings
+	failures := 0
+	for _, env := range envs {
+		if env.name == *baselineName {
+			continue
+		}
+		resources, err := render(env)
+		if err != nil {
+			return err
+		}
+		findings := baseline.Compare(reference, resources, rules)
+		for _, finding := range findings {
+			if finding.Severity == baseline.SeverityError || *failOnWarnings {
+				failures++
+			}
+		}
+		report = append(report, environmentFindings{Environment: env.name, Baseline: *baselineName, Findings: findings})
+	}
+
+	if *jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, env := range report {
+			writeCheckReport(os.Stdout, env)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d finding(s) weaken %s", failures, *baselineName)
+	}
+	return nil
+}
+
+// writeCheckReport prints the findings of one environment.
+func writeCheckReport(w io.Writer, env environmentFindings) {
+	if len(env.Findings) == 0 {
+		fmt.Fprintf(w, "%s against %s: no findings\n", env.Environment, env.Baseline)
+		return
+	}
+	fmt.Fprintf(w, "%s against %s: %d finding(s)\n", env.Environment, env.Baseline, len(env.Findings))
+	for _, finding := range env.Findings {
+		fmt.Fprintf(w, "  %-7s %s: %s (%s)\n", finding.Severity, finding.Resource, finding.Message, finding.Rule)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/baseline"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteCheckReport(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	writeCheckReport(&b, environmentFindings{Environment: "staging", Baseline: "prod", Findings: []baseline.Finding{
+		{Rule: "replica-reduction", Severity: baseline.SeverityError, Resource: "Deployment web", Message: "spec.replicas decreased from 3 to 1"},
+		{Rule: "lower-limits-cpu", Severity: baseline.SeverityWarning, Resource: "Deployment web", Message: "spec.template.spec.containers[app].resources.limits.cpu decreased from 2 to 500m"},
+	}})
+	writeCheckReport(&b, environmentFindings{Environment: "dev", Baseline: "prod"})
+
+	want := `staging against prod: 2 finding(s)
+  error   Deployment web: spec.replicas decreased from 3 to 1 (replica-reduction)
+  warning Deployment web: spec.template.spec.containers[app].resources.limits.cpu decreased from 2 to 500m (lower-limits-cpu)
+dev against prod: no findings
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+}
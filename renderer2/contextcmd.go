@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	rendercontext "github.com/chathurangada/cel_playground/renderer2/pkg/context"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// runContext prints the variables and functions expressions at a site can use, or the sites when
+// none is given.
+func runContext(args []string) error {
+	fs := flag.NewFlagSet("context", flag.ExitOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	functions := fs.Bool("functions", true, "list the functions as well as the variables")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: renderer2 context [flags] [site]\n\nsites:")
+		for _, site := range rendercontext.Sites() {
+			fmt.Fprintf(fs.Output(), "  %-26s %s\n", site.Site, site.Description)
+		}
+		fmt.Fprintln(fs.Output(), "\nflags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.SetOutput(os.Stdout)
+		fs.Usage()
+		return nil
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("expected one site, got %s", strings.Join(fs.Args(), " "))
+	}
+
+	description, err := rendercontext.Describe(template.NewEngine(observability.EngineOptions()...), rendercontext.Site(fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	if !*functions {
+		description.Functions = nil
+	}
+
+	switch *format {
+	case "text":
+		fmt.Print(contextText(description))
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(description)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return nil
+}
+
+func contextText(description *rendercontext.Description) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n\nvariables:\n", description.Site, description.Description)
+	for _, variable := range description.Variables {
+		fmt.Fprintf(&b, "  %-16s %-18s %s", variable.Name, variable.Type, variable.Description)
+		if variable.Condition != "" {
+			fmt.Fprintf(&b, " (when %s)", variable.Condition)
+		}
+		b.WriteByte('\n')
+	}
+	if len(description.Functions) == 0 {
+		return b.String()
+	}
+	b.WriteString("\nfunctions:\n")
+	for _, function := range description.Functions {
+		for _, signature := range function.Signatures {
+			fmt.Fprintf(&b, "  %s\n", signature)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/diff"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/images"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/lock"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/platform"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/transport"
+	"gopkg.in/yaml.v3"
+)
+
+// stageNumber extracts N from the stage-N-<addon>.yaml files render writes.
+var stageNumber = regexp.MustCompile(`^stage-(\d+)-.*\.yaml$`)
+
+// serverFields are set by the API server on every object and never by an apply, so live diffs
+// ignore them.
+var serverFields = []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp"}
+
+// environmentDiff is the -json report of one environment.
+type environmentDiff struct {
+	Environment string              `json:"environment"`
+	Resources   []diff.ResourceDiff `json:"resources"`
+	// Drift lists, with -against-cluster, the resources of the previous render the cluster no
+	// longer matches.
+	Drift []diff.ResourceDiff `json:"drift,omitempty"`
+}
+
+// runDiff renders the complete output of every environment and compares it, resource by resource,
+// with the last stage of a previous render under -output-dir or, with -live, with the cluster.
+// -against-cluster also compares the previous render with the cluster to report drift.
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	var envs envFlag
+	fs.Var(&envs, "env", "EnvSettings to render as name=path; repeatable")
+	outputDir := fs.String("output-dir", "output", "previous render to compare against, one subdirectory per environment")
+	live := fs.Bool("live", false, "compare the -env environments against the cluster with a server-side dry-run apply instead of -output-dir")
+	againstCluster := fs.Bool("against-cluster", false, "also compare the previous render under -output-dir with the cluster and report drift made outside the platform")
+	kubectl := fs.String("kubectl", "kubectl", "kubectl binary used by -live and -against-cluster")
+	kubeContext := fs.String("context", "", "kubeconfig context used by -live and -against-cluster; defaults to the current context")
+	hardenSecurity := fs.Bool("harden-security", false, "enforce baseline Pod Security Standards on rendered pod templates")
+	ownerRefs := fs.String("owner-refs", "none", "attach the EnvSettings owner and componentRef: none, annotations or references")
+	lockPath := fs.String("lockfile", "", "pin container images to the digests recorded in this lockfile")
+	jsonReport := fs.Bool("json", false, "print the differences as JSON")
+	exitCode := fs.Bool("exit-code", false, "fail when any resource differs, for use in CI")
+	configPath := fs.String("config", "", "platform configuration file; defaults to $"+config.EnvVar+", then every "+config.FileName+" up to the repository root")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	platformConfig, err := config.Resolve(*configPath, ".")
+	if err != nil {
+		return fmt.Errorf("failed to load platform configuration: %w", err)
+	}
+	if err := applyConfigFlags(fs, platformConfig); err != nil {
+		return err
+	}
+	if *live && *againstCluster {
+		return fmt.Errorf("-live and -against-cluster cannot be combined")
+	}
+	if (*live || *againstCluster) && len(envs) == 0 {
+		return fmt.Errorf("-live and -against-cluster need at least one -env to compare with the cluster")
+	}
+	ownerMode, err := pipeline.ParseOwnerMode(*ownerRefs)
+	if err != nil {
+		return fmt.Errorf("invalid -owner-refs: %w", err)
+	}
+
+	hooks := []pipeline.Hooks{platform.Availability{}}
+	if *hardenSecurity {
+		hooks = append(hooks, platform.Security{Events: warningLogger{}})
+	}
+	if *lockPath != "" {
+		platformLock, err := lock.Load(*lockPath)
+		switch {
+		case lock.IsNotExist(err):
+		case err != nil:
+			return fmt.Errorf("failed to load lockfile: %w", err)
+		default:
+			registryResolver := &images.RegistryResolver{
+				Client:    transport.NewClient(platformConfig.Remote),
+				PlainHTTP: platformConfig.Registries.PlainHTTP,
+			}
+			resolver := images.NewLockedResolver(&images.Lockfile{Images: platformLock.Images}, registryResolver)
+			hooks = append(hooks, images.Pinner{Resolver: resolver})
+		}
+	}
+	hooks = append(hooks, platformConfig.Hooks()...)
+	renderer := component.NewRenderer(template.NewEngine(observability.EngineOptions()...), nil,
+		component.WithEventSink(warningLogger{}),
+		component.WithOwnerMode(ownerMode),
+		component.WithStabilityPolicy(platformConfig.StabilityPolicy()),
+		component.WithHooks(hooks...),
+	)
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	componentDef, err := inputs.loadComponent()
+	if err != nil {
+		return fmt.Errorf("failed to load component: %w", err)
+	}
+	addons, err := inputs.loadAddons(componentDef)
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+	additionalCtx := inputs.loadAdditionalContext()
+
+	var envConfigs []envConfig
+	if !*live && !*againstCluster {
+		envConfigs = append(envConfigs, envConfig{name: "no-env"})
+	}
+	for _, env := range envs {
+		settings, err := parser.LoadEnvSettings(env.path, inputs.parseOptions()...)
+		if err != nil {
+			return fmt.Errorf("failed to load env settings %s: %w", env.name, err)
+		}
+		envConfigs = append(envConfigs, envConfig{name: env.name, settings: settings})
+	}
+
+	var report []environmentDiff
+	differences := 0
+	for _, env := range envConfigs {
+		rendered, err := renderer.RenderAll(ctd, componentDef, env.settings, addons, additionalCtx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to render environment %s: %w", env.name, err)
+		}
+		var before, after []map[string]any
+		if *live {
+			before, after, err = liveResources(*kubectl, *kubeContext, rendered)
+		} else {
+			before, after, err = previousResources(filepath.Join(*outputDir, env.name), rendered)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to compare environment %s: %w", env.name, err)
+		}
+		envDiff := environmentDiff{Environment: env.name, Resources: diff.Resources(before, after)}
+		if *againstCluster {
+			current, err := clusterResources(*kubectl, *kubeContext, before, after)
+			if err != nil {
+				return fmt.Errorf("failed to compare environment %s with the cluster: %w", env.name, err)
+			}
+			envDiff.Drift = diff.Drift(before, current)
+		}
+		differences += len(envDiff.Resources) + len(envDiff.Drift)
+		report = append(report, envDiff)
+	}
+
+	if *jsonReport {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		for _, env := range report {
+			writeDiffReport(os.Stdout, env)
+		}
+	}
+	if *exitCode && differences > 0 {
+		return fmt.Errorf("%d resource(s) differ", differences)
+	}
+	return nil
+}
+
+// previousResources returns the last stage render wrote to dir, the environment's complete
+// output, and rendered as it would be written there. A missing directory is an empty render.
+func previousResources(dir string, rendered []map[string]any) ([]map[string]any, []map[string]any, error) {
+	var written bytes.Buffer
+	if err := encodeOutput(&written, rendered, outputOptions{format: "yaml"}); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode rendered resources: %w", err)
+	}
+	after, err := decodeDocuments(&written)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path, err := lastStageFile(dir)
+	if err != nil || path == "" {
+		return nil, after, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	before, err := decodeDocuments(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return before, after, nil
+}
+
+// lastStageFile returns the highest-numbered stage file in dir, or "" when there is none.
+func lastStageFile(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	last, lastNumber := "", -1
+	for _, entry := range entries {
+		match := stageNumber.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		if n, err := strconv.Atoi(match[1]); err == nil && n > lastNumber {
+			last, lastNumber = entry.Name(), n
+		}
+	}
+	if last == "" {
+		return "", nil
+	}
+	return filepath.Join(dir, last), nil
+}
+
+// decodeDocuments reads every resource of a multi-document YAML stream.
+func decodeDocuments(r io.Reader) ([]map[string]any, error) {
+	decoder := yaml.NewDecoder(r)
+	var resources []map[string]any
+	for {
+		var resource map[string]any
+		err := decoder.Decode(&resource)
+		if errors.Is(err, io.EOF) {
+			return resources, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resource != nil {
+			resources = append(resources, resource)
+		}
+	}
+}
+
+// liveResources returns the cluster's current version of the rendered resources and the result of
+// applying them with a server-side dry run, so both sides carry the same server defaults.
+func liveResources(kubectl, kubeContext string, rendered []map[string]any) ([]map[string]any, []map[string]any, error) {
+	list, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": rendered})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode rendered resources: %w", err)
+	}
+	before, err := runKubectl(kubectl, kubeContext, list, "get", "--ignore-not-found", "-o", "json", "-f", "-")
+	if err != nil {
+		return nil, nil, err
+	}
+	after, err := runKubectl(kubectl, kubeContext, list, "apply", "--server-side", "--dry-run=server", "--field-manager=renderer2", "-o", "json", "-f", "-")
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, after, nil
+}
+
+// clusterResources returns the live version of every resource of the previous and the new render
+// that exists in the cluster.
+func clusterResources(kubectl, kubeContext string, previous, rendered []map[string]any) ([]map[string]any, error) {
+	items := append(append([]map[string]any{}, previous...), rendered...)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	list, err := json.Marshal(map[string]any{"apiVersion": "v1", "kind": "List", "items": items})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rendered resources: %w", err)
+	}
+	return runKubectl(kubectl, kubeContext, list, "get", "--ignore-not-found", "-o", "json", "-f", "-")
+}
+
+// runKubectl runs kubectl with input on stdin and returns the objects it prints, without the
+// fields the API server manages.
+func runKubectl(kubectl, kubeContext string, input []byte, args ...string) ([]map[string]any, error) {
+	if kubeContext != "" {
+		args = append([]string{"--context", kubeContext}, args...)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(kubectl, args...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("kubectl %s failed: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return kubectlObjects(stdout.Bytes())
+}
+
+// kubectlObjects decodes kubectl -o json output: nothing, one object, or a List of objects.
+func kubectlObjects(data []byte) ([]map[string]any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	var objects []map[string]any
+	for {
+		var object map[string]any
+		err := decoder.Decode(&object)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode kubectl output: %w", err)
+		}
+		if object["kind"] == "List" {
+			items, _ := object["items"].([]any)
+			for _, item := range items {
+				if item, ok := item.(map[string]any); ok {
+					objects = append(objects, item)
+				}
+			}
+			continue
+		}
+		objects = append(objects, object)
+	}
+	for _, object := range objects {
+		delete(object, "status")
+		if metadata, ok := object["metadata"].(map[string]any); ok {
+			for _, field := range serverFields {
+				delete(metadata, field)
+			}
+		}
+	}
+	return objects, nil
+}
+
+// writeDiffReport prints the resources of env that differ, then those that drifted in the cluster,
+// with their changed fields.
+func writeDiffReport(w io.Writer, env environmentDiff) {
+	switch {
+	case len(env.Resources) == 0 && len(env.Drift) == 0:
+		fmt.Fprintf(w, "%s: no changes\n", env.Environment)
+		return
+	case len(env.Resources) == 0:
+		fmt.Fprintf(w, "%s: no changes\n", env.Environment)
+	default:
+		fmt.Fprintf(w, "%s: %d resource(s) differ\n", env.Environment, len(env.Resources))
+		writeResourceDiffs(w, env.Resources)
+	}
+	if len(env.Drift) > 0 {
+		fmt.Fprintf(w, "%s: %d resource(s) drifted in the cluster\n", env.Environment, len(env.Drift))
+		writeResourceDiffs(w, env.Drift)
+	}
+}
+
+// writeResourceDiffs prints resources with their changed fields.
+func writeResourceDiffs(w io.Writer, resources []diff.ResourceDiff) {
+	markers := map[diff.Status]string{diff.Added: "+", diff.Removed: "-", diff.Changed: "~"}
+	for _, resource := range resources {
+		fmt.Fprintf(w, "  %s %s\n", markers[resource.Status], resource.ID())
+		for _, change := range resource.Changes {
+			switch {
+			case change.Before == nil:
+				fmt.Fprintf(w, "      + %s: %s\n", change.Path, compactJSON(change.After))
+			case change.After == nil:
+				fmt.Fprintf(w, "      - %s: %s\n", change.Path, compactJSON(change.Before))
+			default:
+				fmt.Fprintf(w, "      ~ %s: %s → %s\n", change.Path, compactJSON(change.Before), compactJSON(change.After))
+			}
+		}
+	}
+}
+
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/diff"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLastStageFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, name := range []string{"stage-1-base.yaml", "stage-2-with-pvc.yaml", "stage-10-with-sidecar.yaml", "notes.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := lastStageFile(dir)
+	if err != nil {
+		t.Fatalf("lastStageFile() error = %v", err)
+	}
+	if want := filepath.Join(dir, "stage-10-with-sidecar.yaml"); got != want {
+		t.Errorf("lastStageFile() = %q, want %q", got, want)
+	}
+	if got, err := lastStageFile(filepath.Join(dir, "missing")); err != nil || got != "" {
+		t.Errorf("lastStageFile() of a missing directory = %q, %v, want no file", got, err)
+	}
+}
+
+func TestKubectlObjects(t *testing.T) {
+	t.Parallel()
+
+	output := `{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "uid": "1", "resourceVersion": "7", "managedFields": []}, "spec": {"type": "ClusterIP"}, "status": {}}
+]}
+{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "web", "generation": 2}}`
+
+	got, err := kubectlObjects([]byte(output))
+	if err != nil {
+		t.Fatalf("kubectlObjects() error = %v", err)
+	}
+	want := []map[string]any{
+		{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "web"}, "spec": map[string]any{"type": "ClusterIP"}},
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "web"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("kubectlObjects() mismatch (-want +got):\n%s", diff)
+	}
+	if got, err := kubectlObjects(nil); err != nil || len(got) != 0 {
+		t.Errorf("kubectlObjects() of empty output = %v, %v, want none", got, err)
+	}
+}
+
+func TestWriteDiffReport(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	writeDiffReport(&b, environmentDiff{Environment: "dev", Resources: []diff.ResourceDiff{
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web", Status: diff.Changed, Changes: []diff.Change{
+			{Path: "metadata.labels.tier", Before: "web"},
+			{Path: "spec.replicas", Before: 2, After: 3},
+			{Path: "spec.template.spec.containers[1]", After: map[string]any{"name": "sidecar"}},
+		}},
+		{APIVersion: "v1", Kind: "Service", Name: "web", Status: diff.Added},
+	}})
+	writeDiffReport(&b, environmentDiff{Environment: "prod"})
+	writeDiffReport(&b, environmentDiff{Environment: "staging", Drift: []diff.ResourceDiff{
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Status: diff.Changed, Changes: []diff.Change{
+			{Path: "spec.replicas", Before: 2, After: 5},
+		}},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "web", Status: diff.Removed},
+	}})
+
+	want := strings.Join([]string{
+		"dev: 2 resource(s) differ",
+		"  ~ Deployment shop/web (apps/v1)",
+		`      - metadata.labels.tier: "web"`,
+		"      ~ spec.replicas: 2 → 3",
+		`      + spec.template.spec.containers[1]: {"name":"sidecar"}`,
+		"  + Service web (v1)",
+		"prod: no changes",
+		"staging: no changes",
+		"staging: 2 resource(s) drifted in the cluster",
+		"  ~ Deployment web (apps/v1)",
+		"      ~ spec.replicas: 2 → 5",
+		"  - ConfigMap web (v1)",
+		"",
+	}, "\n")
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+}
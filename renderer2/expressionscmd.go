@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// runExpressions lists the CEL expressions of the definition's resources and of every addon in
+// -addons-dir.
+func runExpressions(args []string) error {
+	fs := flag.NewFlagSet("expressions", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	out := fs.String("out", "", "write the expressions here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	addons, err := inputs.loadReferencedAddons()
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+
+	exprOutput := collectCELExpressions(ctd, addons)
+	if *out == "" {
+		return yaml.NewEncoder(os.Stdout).Encode(exprOutput)
+	}
+	if err := writeYAML(*out, exprOutput); err != nil {
+		return fmt.Errorf("failed to write CEL expressions file: %w", err)
+	}
+	fmt.Printf("Collected CEL expressions written to %s\n", *out)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/legacy"
+)
+
+// runFix rewrites renderer v1 constructs in every definition and addon under a directory tree.
+func runFix(args []string) error {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory to scan for ComponentTypeDefinition and Addon manifests")
+	dryRun := fs.Bool("dry-run", false, "report deprecations without rewriting files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fixed, manual := 0, 0
+	err := filepath.WalkDir(*dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		updated, deprecations, err := legacy.Fix(content)
+		if err != nil {
+			// Not every YAML file in a repo parses as a single document; skip what we cannot read.
+			return nil
+		}
+		for _, deprecation := range deprecations {
+			status := "fixed"
+			if !deprecation.Fixable {
+				status = "manual"
+				manual++
+			} else {
+				fixed++
+			}
+			fmt.Printf("%s: [%s] %s\n", path, status, deprecation)
+		}
+		if *dryRun || bytes.Equal(updated, content) {
+			return nil
+		}
+		return os.WriteFile(path, updated, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	verb := "fixed"
+	if *dryRun {
+		verb = "would fix"
+	}
+	fmt.Printf("\n%s %d deprecation(s), %d need manual changes\n", verb, fixed, manual)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/diff"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/format"
+)
+
+// runFmt normalizes definition, addon, component and env settings YAML under a directory tree.
+func runFmt(args []string) error {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	dir := fs.String("dir", ".", "directory to scan for manifests")
+	check := fs.Bool("check", false, "print a diff for every file that is not formatted and fail instead of rewriting")
+	width := fs.Int("width", format.DefaultWidth, "wrap standalone expressions after && and || past this column")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var unformatted []string
+	err := filepath.WalkDir(*dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || (!strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml")) {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		formatted, err := format.Format(content, format.Options{Width: *width})
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if bytes.Equal(formatted, content) {
+			return nil
+		}
+
+		unformatted = append(unformatted, path)
+		if *check {
+			fmt.Printf("--- %s\n+++ %s (formatted)\n%s", path, path, diff.Lines(string(content), string(formatted), 3))
+			return nil
+		}
+		fmt.Println(path)
+		return os.WriteFile(path, formatted, 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	if *check && len(unformatted) > 0 {
+		return fmt.Errorf("%d file(s) are not formatted; run `fmt -dir %s`", len(unformatted), *dir)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/export"
+	"gopkg.in/yaml.v3"
+)
+
+// generators are the generate subcommands.
+var generators = map[string]func(args []string) error{
+	"crd": runGenerateCRD,
+}
+
+// runGenerate dispatches generate <kind>.
+func runGenerate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: renderer2 generate crd [flags]")
+	}
+	generate, ok := generators[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown generator %q (want crd)", args[0])
+	}
+	return generate(args[1:])
+}
+
+// runGenerateCRD writes a CustomResourceDefinition serving the definition as its own API.
+func runGenerateCRD(args []string) error {
+	fs := flag.NewFlagSet("generate crd", flag.ExitOnError)
+	definition := fs.String("definition", "", "path to the ComponentTypeDefinition")
+	group := fs.String("group", "", "API group of the generated kind; defaults to platform.openchoreo.dev")
+	version := fs.String("version", "", "API version of an unversioned definition; defaults to v1alpha1")
+	plural := fs.String("plural", "", "resource name of the generated kind; defaults to the lowercase kind pluralized")
+	out := fs.String("out", "", "write the CustomResourceDefinition here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *definition == "" {
+		return fmt.Errorf("-definition is required")
+	}
+	ctd, err := loadDefinitionFor(*definition, "")
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+
+	crd, err := export.ToCRD(ctd, export.CRDOptions{Group: *group, Version: *version, Plural: *plural})
+	if err != nil {
+		return fmt.Errorf("failed to generate CRD: %w", err)
+	}
+	if *out != "" {
+		return writeYAML(*out, crd)
+	}
+	encoded, err := yaml.Marshal(crd)
+	if err != nil {
+		return fmt.Errorf("failed to encode CRD: %w", err)
+	}
+	_, err = os.Stdout.Write(encoded)
+	return err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// version is the renderer version written to output headers; release builds set it with
+// -ldflags "-X main.version=v1.2.3". Without it the module version from the build info is used.
+var version string
+
+// headerMode controls the provenance comment written at the top of every output file.
+type headerMode string
+
+const (
+	headerNone        headerMode = "none"
+	headerTimestamped headerMode = "timestamped"
+	// headerDeterministic takes the render time from $SOURCE_DATE_EPOCH, or the Unix epoch, so
+	// re-rendering unchanged inputs produces identical files.
+	headerDeterministic headerMode = "deterministic"
+)
+
+func parseHeaderMode(value string) (headerMode, error) {
+	switch mode := headerMode(value); mode {
+	case headerNone, headerTimestamped, headerDeterministic:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown header mode %q (want none, timestamped or deterministic)", value)
+	}
+}
+
+// renderTime returns the time written to headers in mode.
+func (m headerMode) renderTime() (time.Time, error) {
+	if m != headerDeterministic {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// outputHeader records where an output file came from.
+type outputHeader struct {
+	Definition        string
+	DefinitionVersion string
+	Component         string
+	Environment       string
+	Stage             string
+	RenderedAt        time.Time
+}
+
+// comment renders the header as YAML (and HCL) comment lines.
+func (h outputHeader) comment() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by renderer2 %s. Do not edit.\n", rendererVersion())
+	definition := h.Definition
+	if h.DefinitionVersion != "" {
+		definition += " (version " + h.DefinitionVersion + ")"
+	}
+	fmt.Fprintf(&b, "# definition: %s\n", definition)
+	fmt.Fprintf(&b, "# component: %s\n", h.Component)
+	if h.Environment != "" {
+		fmt.Fprintf(&b, "# environment: %s\n", h.Environment)
+	}
+	if h.Stage != "" {
+		fmt.Fprintf(&b, "# stage: %s\n", h.Stage)
+	}
+	fmt.Fprintf(&b, "# rendered at: %s\n", h.RenderedAt.Format(time.RFC3339))
+	return b.String()
+}
+
+func rendererVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	return "(devel)"
+}
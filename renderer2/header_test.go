@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutputHeaderComment(t *testing.T) {
+	renderedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header outputHeader
+		want   string
+	}{
+		{
+			name: "versioned definition and environment",
+			header: outputHeader{Definition: "web-app", DefinitionVersion: "v2", Component: "checkout",
+				Environment: "dev", Stage: "stage-1-base", RenderedAt: renderedAt},
+			want: "# Generated by renderer2 v1.2.3. Do not edit.\n" +
+				"# definition: web-app (version v2)\n" +
+				"# component: checkout\n" +
+				"# environment: dev\n" +
+				"# stage: stage-1-base\n" +
+				"# rendered at: 2024-05-01T12:00:00Z\n",
+		},
+		{
+			name:   "unversioned definition without environment",
+			header: outputHeader{Definition: "web-app", Component: "checkout", Stage: "stage-1-base", RenderedAt: renderedAt},
+			want: "# Generated by renderer2 v1.2.3. Do not edit.\n" +
+				"# definition: web-app\n" +
+				"# component: checkout\n" +
+				"# stage: stage-1-base\n" +
+				"# rendered at: 2024-05-01T12:00:00Z\n",
+		},
+	}
+
+	version = "v1.2.3"
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.header.comment(); got != tt.want {
+				t.Errorf("comment() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeterministicRenderTime(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1714564800")
+
+	got, err := headerDeterministic.renderTime()
+	if err != nil {
+		t.Fatalf("renderTime() error = %v", err)
+	}
+	if want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("renderTime() = %v, want %v", got, want)
+	}
+
+	if _, err := parseHeaderMode("sometimes"); err == nil {
+		t.Errorf("parseHeaderMode(\"sometimes\") succeeded, want error")
+	}
+}
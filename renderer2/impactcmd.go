@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/impact"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// runImpact prints which resource templates and fields every parameter of a definition influences.
+func runImpact(args []string) error {
+	fs := flag.NewFlagSet("impact", flag.ExitOnError)
+	definitionPath := fs.String("definition", "", "path to the ComponentTypeDefinition to analyze")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *definitionPath == "" {
+		return fmt.Errorf("-definition is required")
+	}
+
+	ctd, err := parser.LoadComponentTypeDefinition(*definitionPath)
+	if err != nil {
+		return err
+	}
+	m, err := impact.Build(template.NewEngine(), ctd)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "text":
+		fmt.Print(m.Text())
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(m)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/importer"
+	"gopkg.in/yaml.v3"
+)
+
+// runImportHelm converts a Helm chart's values into a Component and a suggested definition schema.
+func runImportHelm(args []string) error {
+	fs := flag.NewFlagSet("import-helm", flag.ExitOnError)
+	chartDir := fs.String("chart", "", "path to the Helm chart directory")
+	name := fs.String("name", "", "Component name (defaults to the chart name)")
+	componentType := fs.String("component-type", "", "ComponentTypeDefinition the Component should reference")
+	out := fs.String("out", "", "write the Component and schema here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *chartDir == "" {
+		return fmt.Errorf("-chart is required")
+	}
+
+	result, err := importer.FromHelmChart(*chartDir, importer.HelmOptions{ComponentName: *name, ComponentType: *componentType})
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(result.Component); err != nil {
+		return fmt.Errorf("failed to encode component: %w", err)
+	}
+	// The schema is a suggestion for the definition, not a manifest; emit it as a separate document.
+	if err := encoder.Encode(map[string]any{"schema": result.Schema}); err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*out, buf.Bytes(), 0644)
+}
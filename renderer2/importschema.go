@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/importer"
+	"gopkg.in/yaml.v3"
+)
+
+// runImportSchema converts a JSON Schema (or a CUE definition exported as OpenAPI) into a simple schema.
+func runImportSchema(args []string) error {
+	fs := flag.NewFlagSet("import-schema", flag.ExitOnError)
+	in := fs.String("in", "", "path to a JSON Schema or OpenAPI document (JSON or YAML)")
+	root := fs.String("root", "", "definition to import instead of the document root")
+	out := fs.String("out", "", "write the simple schema here instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	result, err := importer.FromJSONSchema(data, importer.Options{Root: *root})
+	if err != nil {
+		return err
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	encoded, err := yaml.Marshal(map[string]any{"schema": result.Schema})
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(encoded)
+		return err
+	}
+	return os.WriteFile(*out, encoded, 0644)
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/bundle"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/codec"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// inputFlags locate the definition, Component and addons a command works on.
+type inputFlags struct {
+	definition        *string
+	component         *string
+	addonsDir         *string
+	additionalContext *string
+	bundle            *string
+	yaml              *codecFlag
+}
+
+func registerInputFlags(fs *flag.FlagSet) *inputFlags {
+	yaml := &codecFlag{}
+	fs.Var(yaml, "yaml", "YAML library decoding inputs: kubernetes (sigs.k8s.io/yaml semantics, as kubectl) or yaml.v3")
+	return &inputFlags{
+		definition:        fs.String("definition", "", "ComponentTypeDefinition file, directory or glob; with several definitions, the Component's componentType picks one"),
+		component:         fs.String("component", "", "path to the Component"),
+		addonsDir:         fs.String("addons-dir", "", "directory holding the addons, one manifest per addon"),
+		additionalContext: fs.String("additional-context", "", "JSON file with the platform context (build image, configurations, secrets)"),
+		bundle:            fs.String("bundle", "", "bundle directory (with "+bundle.FileName+") providing the definition and its addons instead of -definition and -addons-dir"),
+		yaml:              yaml,
+	}
+}
+
+// parseOptions are the options inputs are decoded with.
+func (f *inputFlags) parseOptions() []parser.Option {
+	return []parser.Option{parser.WithCodec(f.yaml.codec)}
+}
+
+func (f *inputFlags) loadDefinition() (*types.ComponentTypeDefinition, error) {
+	if *f.bundle != "" {
+		b, err := f.loadBundle()
+		if err != nil {
+			return nil, err
+		}
+		if b.Definition == nil {
+			return nil, fmt.Errorf("bundle %s packages an addon, not a definition", b.Manifest.Metadata.Name)
+		}
+		return b.Definition, nil
+	}
+	if *f.definition == "" {
+		return nil, fmt.Errorf("-definition is required")
+	}
+	return loadDefinitionFor(*f.definition, *f.component, f.parseOptions()...)
+}
+
+// loadDefinitionFor loads the definitions of path, a file, directory or glob. When there are
+// several, the componentType and componentTypeVersion of the Component at componentPath pick one.
+func loadDefinitionFor(path, componentPath string, opts ...parser.Option) (*types.ComponentTypeDefinition, error) {
+	defs, err := parser.LoadDefinitions([]string{path}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	all := defs.All()
+	switch {
+	case len(all) == 0:
+		return nil, fmt.Errorf("%s holds no component type definition", path)
+	case len(all) == 1:
+		return all[0], nil
+	case componentPath == "":
+		return nil, fmt.Errorf("%s holds %d component type definitions; pass -component to pick one by its componentType", path, len(all))
+	}
+	component, err := parser.LoadComponent(componentPath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return defs.ForComponent(component)
+}
+
+// loadBundle resolves and verifies the bundle graph of -bundle.
+func (f *inputFlags) loadBundle() (*bundle.Bundle, error) {
+	if *f.definition != "" || *f.addonsDir != "" {
+		return nil, fmt.Errorf("-bundle cannot be combined with -definition or -addons-dir")
+	}
+	return bundle.Load(*f.bundle, bundle.Options{RendererVersion: rendererVersion()})
+}
+
+func (f *inputFlags) loadComponent() (*types.Component, error) {
+	if *f.component == "" {
+		return nil, fmt.Errorf("-component is required")
+	}
+	return parser.LoadComponent(*f.component, f.parseOptions()...)
+}
+
+// loadAddons loads the addons component references, or every addon in -addons-dir when component
+// is nil. The built-in observability addon needs no manifest.
+func (f *inputFlags) loadAddons(component *types.Component) (map[string]*types.Addon, error) {
+	var names []string
+	var instances []types.AddonInstance
+	builtinObservability := false
+	if component != nil {
+		for _, addon := range component.Spec.Addons {
+			if addon.Name == observability.AddonName {
+				builtinObservability = true
+				continue
+			}
+			names = append(names, addon.Name)
+			instances = append(instances, addon)
+		}
+	}
+
+	addons := map[string]*types.Addon{}
+	if *f.bundle != "" {
+		b, err := f.loadBundle()
+		if err != nil {
+			return nil, err
+		}
+		addons = b.Addons
+		if component != nil {
+			addons = make(map[string]*types.Addon, len(names))
+			for _, name := range names {
+				addon, ok := b.Addons[name]
+				if !ok {
+					return nil, fmt.Errorf("addon %s is not part of bundle %s", name, b.Manifest.Metadata.Name)
+				}
+				addons[name] = addon
+			}
+		}
+	} else if *f.addonsDir != "" && (component == nil || len(names) > 0) {
+		var loaded map[string]*types.Addon
+		var err error
+		if component == nil {
+			loaded, err = parser.LoadAddons(*f.addonsDir, nil, f.parseOptions()...)
+		} else {
+			loaded, err = parser.LoadAddonsFor(*f.addonsDir, instances, f.parseOptions()...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		addons = loaded
+	} else if len(names) > 0 {
+		return nil, fmt.Errorf("-addons-dir is required for addons %s", strings.Join(names, ", "))
+	}
+	if builtinObservability {
+		addons[observability.AddonName] = observability.Addon()
+	}
+	return addons, nil
+}
+
+// loadReferencedAddons loads the addons -component references, or every addon in -addons-dir
+// without -component.
+func (f *inputFlags) loadReferencedAddons() (map[string]*types.Addon, error) {
+	var component *types.Component
+	if *f.component != "" {
+		var err error
+		if component, err = f.loadComponent(); err != nil {
+			return nil, err
+		}
+	}
+	return f.loadAddons(component)
+}
+
+// loadAdditionalContext returns nil without -additional-context. A context that fails to load is
+// reported and ignored so renders still succeed with the component's own build image.
+func (f *inputFlags) loadAdditionalContext() *types.AdditionalContext {
+	if *f.additionalContext == "" {
+		return nil
+	}
+	additionalCtx, err := parser.LoadAdditionalContext(*f.additionalContext)
+	if err != nil {
+		log.Printf("warning: failed to load additional context: %v", err)
+		return nil
+	}
+	return additionalCtx
+}
+
+// applyConfigFlags sets flags the command line left unset to the platform configuration's values.
+func applyConfigFlags(fs *flag.FlagSet, cfg *config.Config) error {
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for name, value := range cfg.Flags() {
+		if explicit[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("invalid %s value %q in %s: %w", name, value, strings.Join(cfg.Sources, ", "), err)
+		}
+	}
+	return nil
+}
+
+// loadScopes returns the built-in resource scopes extended with the CustomResourceDefinitions of
+// path, a YAML file or a directory of them. Other documents are ignored. An empty path returns the
+// built-in scopes alone.
+func loadScopes(path string, c codec.Codec) (*pipeline.Scopes, error) {
+	scopes := pipeline.NewScopes()
+	if path == "" {
+		return scopes, nil
+	}
+	files := []string{path}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRD directory %s: %w", path, err)
+		}
+		files = nil
+		for _, entry := range entries {
+			if name := entry.Name(); !entry.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+				files = append(files, filepath.Join(path, name))
+			}
+		}
+	}
+	c = codec.Or(c)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRDs: %w", err)
+		}
+		docs, err := c.Documents(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		for _, doc := range docs {
+			var object map[string]any
+			if err := c.Unmarshal(doc, &object); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			if object["kind"] != "CustomResourceDefinition" {
+				continue
+			}
+			if err := scopes.AddCRD(object); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+	}
+	return scopes, nil
+}
+
+// codecFlag selects the YAML library of -yaml; a nil codec is codec.Default.
+type codecFlag struct {
+	codec codec.Codec
+}
+
+func (f *codecFlag) String() string {
+	return codec.Or(f.codec).Name()
+}
+
+func (f *codecFlag) Set(value string) error {
+	c, err := codec.ByName(value)
+	if err != nil {
+		return err
+	}
+	f.codec = c
+	return nil
+}
+
+// envFlag collects repeated -env name=path flags.
+type envFlag []namedEnv
+
+type namedEnv struct {
+	name string
+	path string
+}
+
+func (f *envFlag) String() string {
+	parts := make([]string, len(*f))
+	for i, env := range *f {
+		parts[i] = env.name + "=" + env.path
+	}
+	return strings.Join(parts, ",")
+}
+
+func (f *envFlag) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected name=path, got %q", value)
+	}
+	*f = append(*f, namedEnv{name: name, path: path})
+	return nil
+}
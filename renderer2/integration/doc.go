@@ -0,0 +1,9 @@
+// Package integration runs the operator against a real API server. The control plane is the etcd
+// and kube-apiserver pair that setup-envtest installs for controller-runtime's envtest; it is
+// started directly, since renderer2 talks plain REST and does not depend on controller-runtime:
+//
+//	export KUBEBUILDER_ASSETS=$(setup-envtest use -p path 1.31.x)
+//	go test -tags integration ./integration/...
+//
+// Tests skip when KUBEBUILDER_ASSETS is unset.
+package integration
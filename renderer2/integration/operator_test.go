@@ -0,0 +1,370 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/export"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/operator"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	definition = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      image: string
+      port: integer | default=8080
+    envOverrides:
+      replicas: integer | default=1 minimum=0
+  resources:
+    - id: deployment
+      template:
+        apiVersion: apps/v1
+        kind: Deployment
+        metadata:
+          name: ${metadata.name}
+        spec:
+          replicas: ${spec.replicas}
+          selector:
+            matchLabels:
+              app: ${metadata.name}
+          template:
+            metadata:
+              labels:
+                app: ${metadata.name}
+            spec:
+              containers:
+                - name: app
+                  image: ${spec.image}
+                  ports:
+                    - containerPort: ${spec.port}
+    - id: service
+      template:
+        apiVersion: v1
+        kind: Service
+        metadata:
+          name: ${metadata.name}
+        spec:
+          selector:
+            app: ${metadata.name}
+          ports:
+            - port: 80
+              targetPort: ${spec.port}
+`
+	addon = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Addon
+metadata:
+  name: team-label
+spec:
+  schema:
+    parameters:
+      team: string
+  patches:
+    - target:
+        kind: Deployment
+      operations:
+        - op: add
+          path: /metadata/labels
+          value:
+            team: ${spec.team}
+`
+	webComponent = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Component
+metadata:
+  name: checkout
+  namespace: default
+spec:
+  componentType: web-app
+  parameters:
+    image: nginx:1.27
+  addons:
+    - name: team-label
+      instanceId: owner
+      config:
+        team: payments
+`
+	devSettings = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: EnvSettings
+metadata:
+  name: checkout-dev
+  namespace: default
+spec:
+  environment: dev
+  componentRef:
+    name: checkout
+  overrides:
+    replicas: 2
+`
+)
+
+func TestOperator(t *testing.T) {
+	env := startTestEnv(t)
+	ctx := context.Background()
+
+	installCRDs(t, env)
+	for _, document := range []string{definition, addon, webComponent, devSettings} {
+		apply(t, env, document)
+	}
+
+	op := operator.New(env.Client, component.NewRenderer(template.NewEngine(), nil))
+	results, err := op.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil || results[0].Applied != 2 {
+		t.Fatalf("Sync() = %+v, want one result applying 2 resources", results)
+	}
+
+	components := list(t, env, operator.APIVersion, "Component")
+	uid := nested(components["default/checkout"], "metadata", "uid")
+	deployment := list(t, env, "apps/v1", "Deployment")["default/checkout"]
+	if deployment == nil {
+		t.Fatal("Deployment default/checkout was not applied")
+	}
+	for _, check := range []struct {
+		field string
+		got   any
+		want  any
+	}{
+		{"spec.replicas", nested(deployment, "spec", "replicas"), float64(2)},
+		{"metadata.labels.team", nested(deployment, "metadata", "labels", "team"), "payments"},
+		{"image", nested(deployment, "spec", "template", "spec", "containers", 0, "image"), "nginx:1.27"},
+		{"ownerReferences[0].uid", nested(deployment, "metadata", "ownerReferences", 0, "uid"), uid},
+		{"ownerReferences[0].controller", nested(deployment, "metadata", "ownerReferences", 0, "controller"), true},
+		{"managedFields[0].manager", nested(deployment, "metadata", "managedFields", 0, "manager"), operator.DefaultFieldManager},
+		{"managedFields[0].operation", nested(deployment, "metadata", "managedFields", 0, "operation"), "Apply"},
+	} {
+		if check.got != check.want {
+			t.Errorf("Deployment %s = %v, want %v", check.field, check.got, check.want)
+		}
+	}
+	if service := list(t, env, "v1", "Service")["default/checkout"]; nested(service, "spec", "ports", 0, "targetPort") != float64(8080) {
+		t.Errorf("Service targetPort = %v, want 8080", nested(service, "spec", "ports", 0, "targetPort"))
+	}
+
+	results, err = op.Sync(ctx)
+	if err != nil || len(results) != 1 || !results[0].Unchanged {
+		t.Errorf("second Sync() = %+v, %v, want the Component unchanged", results, err)
+	}
+
+	apply(t, env, strings.Replace(devSettings, "replicas: 2", "replicas: 3", 1))
+	if results, err = op.Sync(ctx); err != nil || len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("Sync() after changing EnvSettings = %+v, %v", results, err)
+	}
+	deployment = list(t, env, "apps/v1", "Deployment")["default/checkout"]
+	if got := nested(deployment, "spec", "replicas"); got != float64(3) {
+		t.Errorf("Deployment spec.replicas after the override changed = %v, want 3", got)
+	}
+
+	t.Run("webhook", func(t *testing.T) {
+		server := httptest.NewTLSServer(op.Webhook())
+		defer server.Close()
+		caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		applyObject(t, env, map[string]any{
+			"apiVersion": "admissionregistration.k8s.io/v1",
+			"kind":       "ValidatingWebhookConfiguration",
+			"metadata":   map[string]any{"name": "renderer2"},
+			"webhooks": []any{map[string]any{
+				"name":                    "components.openchoreo.dev",
+				"admissionReviewVersions": []any{"v1"},
+				"sideEffects":             "None",
+				"failurePolicy":           "Fail",
+				"clientConfig": map[string]any{
+					"url":      server.URL,
+					"caBundle": base64.StdEncoding.EncodeToString(caBundle),
+				},
+				"rules": []any{map[string]any{
+					"apiGroups":   []any{"openchoreo.dev"},
+					"apiVersions": []any{"v1alpha1"},
+					"operations":  []any{"CREATE", "UPDATE"},
+					"resources":   []any{"components", "envsettings"},
+				}},
+			}},
+		})
+
+		// The API server picks up webhook configurations asynchronously.
+		invalid := strings.Replace(devSettings, "replicas: 2", "replicas: -1", 1)
+		waitFor(t, 30*time.Second, "the webhook to deny an invalid override", func() error {
+			err := applyDocument(env, invalid)
+			if err == nil {
+				return errors.New("EnvSettings with replicas -1 was admitted")
+			}
+			if !strings.Contains(err.Error(), "replicas: must be greater than or equal to 0") {
+				t.Fatalf("apply error = %v, want a schema violation", err)
+			}
+			return nil
+		})
+		if err := applyDocument(env, devSettings); err != nil {
+			t.Errorf("applying valid EnvSettings error = %v", err)
+		}
+	})
+}
+
+// TestGeneratedCRD installs the CRD `generate crd` produces for a definition and creates an
+// object of it, so the generated schema is checked by a real API server.
+func TestGeneratedCRD(t *testing.T) {
+	env := startTestEnv(t)
+
+	ctd, err := parser.ParseComponentTypeDefinition("definition", []byte(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crd, err := export.ToCRD(ctd, export.CRDOptions{Group: "apps.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyObject(t, env, crd)
+	waitFor(t, 30*time.Second, "the WebApp CRD to be served", func() error {
+		_, err := env.Client.List(context.Background(), "apps.example.com/v1alpha1", "WebApp")
+		return err
+	})
+
+	apply(t, env, `
+apiVersion: apps.example.com/v1alpha1
+kind: WebApp
+metadata:
+  name: checkout
+  namespace: default
+spec:
+  image: nginx:1.27
+  replicas: 2
+`)
+	err = applyDocument(env, `
+apiVersion: apps.example.com/v1alpha1
+kind: WebApp
+metadata:
+  name: broken
+  namespace: default
+spec:
+  image: nginx:1.27
+  replicas: -1
+`)
+	if err == nil || !strings.Contains(err.Error(), "spec.replicas") {
+		t.Errorf("applying a WebApp with replicas -1 error = %v, want a validation error", err)
+	}
+}
+
+// installCRDs installs schemaless CRDs for the four kinds the operator reads; the renderer checks
+// their specs itself.
+func installCRDs(t *testing.T, env *testEnv) {
+	t.Helper()
+	for _, crd := range []struct {
+		kind, plural string
+		namespaced   bool
+	}{
+		{"ComponentTypeDefinition", "componenttypedefinitions", false},
+		{"Addon", "addons", false},
+		{"Component", "components", true},
+		{"EnvSettings", "envsettings", true},
+	} {
+		scope := "Cluster"
+		if crd.namespaced {
+			scope = "Namespaced"
+		}
+		applyObject(t, env, map[string]any{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]any{"name": crd.plural + ".openchoreo.dev"},
+			"spec": map[string]any{
+				"group": "openchoreo.dev",
+				"scope": scope,
+				"names": map[string]any{"kind": crd.kind, "plural": crd.plural, "singular": strings.ToLower(crd.kind)},
+				"versions": []any{map[string]any{
+					"name":    "v1alpha1",
+					"served":  true,
+					"storage": true,
+					"schema": map[string]any{"openAPIV3Schema": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"spec": map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+						},
+					}},
+				}},
+			},
+		})
+		waitFor(t, 30*time.Second, crd.kind+" to be served", func() error {
+			_, err := env.Client.List(context.Background(), operator.APIVersion, crd.kind)
+			return err
+		})
+	}
+}
+
+func apply(t *testing.T, env *testEnv, document string) {
+	t.Helper()
+	if err := applyDocument(env, document); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func applyDocument(env *testEnv, document string) error {
+	var object map[string]any
+	if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+		return err
+	}
+	return env.Client.Apply(context.Background(), object, "integration-test", false)
+}
+
+func applyObject(t *testing.T, env *testEnv, object map[string]any) {
+	t.Helper()
+	if err := env.Client.Apply(context.Background(), object, "integration-test", false); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// list returns the objects of kind by namespace/name.
+func list(t *testing.T, env *testEnv, apiVersion, kind string) map[string]map[string]any {
+	t.Helper()
+	items, err := env.Client.List(context.Background(), apiVersion, kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := make(map[string]map[string]any, len(items))
+	for _, item := range items {
+		namespace, _ := nested(item, "metadata", "namespace").(string)
+		name, _ := nested(item, "metadata", "name").(string)
+		objects[namespace+"/"+name] = item
+	}
+	return objects
+}
+
+// nested reads a field by map keys and list indexes, returning nil when any step is missing.
+func nested(object any, path ...any) any {
+	current := object
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			m, ok := current.(map[string]any)
+			if !ok {
+				return nil
+			}
+			current = m[key]
+		case int:
+			l, ok := current.([]any)
+			if !ok || key >= len(l) {
+				return nil
+			}
+			current = l[key]
+		}
+	}
+	return current
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -9,139 +10,213 @@ import (
 	"strings"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/export"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/format"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/legacy"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
-	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
 	"gopkg.in/yaml.v3"
 )
 
-func main() {
-	examplesDir := "examples"
-	outputDir := filepath.Join(examplesDir, "expected-output")
-
-	engine := template.NewEngine()
-	renderer := component.NewRenderer(engine, nil)
-
-	ctdPath := filepath.Join(examplesDir, "component-type-definitions", "deployment-component.yaml")
-	ctd, err := parser.LoadComponentTypeDefinition(ctdPath)
-	if err != nil {
-		log.Fatalf("failed to load component type definition: %v", err)
-	}
+// command is a renderer2 subcommand.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
 
-	componentPath := filepath.Join(examplesDir, "components", "example-component.yaml")
-	componentDef, err := parser.LoadComponent(componentPath)
-	if err != nil {
-		log.Fatalf("failed to load component: %v", err)
-	}
+var commands = []command{
+	{name: "render", summary: "render a Component per environment and addon stage", run: runRender},
+	{name: "validate", summary: "check definition and addon schemas and, with -component, that every environment renders", run: runValidate},
+	{name: "schema", summary: "write the JSON Schema of a definition and its addons, or compare two revisions: schema diff", run: runSchema},
+	{name: "preview", summary: "render a definition once, optionally with a Component synthesized from schema examples", run: runPreview},
+	{name: "expressions", summary: "list the CEL expressions of a definition and its addons", run: runExpressions},
+	{name: "context", summary: "list the variables and functions available to expressions at a site", run: runContext},
+	{name: "fmt", summary: "normalize definition, addon, component and env settings YAML", run: runFmt},
+	{name: "fix", summary: "rewrite renderer v1 syntax in definitions and addons", run: runFix},
+	{name: "check", summary: "flag environments whose overrides weaken a baseline environment", run: runCheck},
+	{name: "diff", summary: "compare rendered resources with a previous render or, with -live, the cluster", run: runDiff},
+	{name: "operator", summary: "reconcile Component custom resources in the cluster the process runs in", run: runOperator},
+	{name: "webhook", summary: "validate Components and EnvSettings at admission by schema and a dry render", run: runWebhook},
+	{name: "generate", summary: "generate cluster manifests from a definition: generate crd", run: runGenerate},
+	{name: "impact", summary: "show which resources every parameter of a definition influences", run: runImpact},
+	{name: "migrate", summary: "move Components to another definition version", run: runMigrate},
+	{name: "import-schema", summary: "convert a JSON Schema or OpenAPI document to a simple schema", run: runImportSchema},
+	{name: "import-helm", summary: "generate a Component and schema from a Helm chart", run: runImportHelm},
+}
 
-	addonDir := filepath.Join(examplesDir, "addons")
-	addonNames := make([]string, 0, len(componentDef.Spec.Addons))
-	for _, addon := range componentDef.Spec.Addons {
-		addonNames = append(addonNames, addon.Name)
-	}
-	addons, err := parser.LoadAddons(addonDir, addonNames)
-	if err != nil {
-		log.Fatalf("failed to load addons: %v", err)
+func main() {
+	parser.OnDeprecation = func(path string, deprecation legacy.Deprecation) {
+		log.Printf("warning: %s: %s", path, deprecation)
 	}
 
-	additionalCtxPath := filepath.Join(examplesDir, "additional_context.json")
-	additionalCtx, err := parser.LoadAdditionalContext(additionalCtxPath)
-	if err != nil {
-		log.Printf("warning: failed to load additional context: %v", err)
+	// Without a subcommand the CLI keeps regenerating the examples, passing any flags to render.
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		if err := runExamples(os.Args[1:]); err != nil {
+			log.Fatalf("render failed: %v", err)
+		}
+		return
 	}
 
-	// Validate schemas before rendering
-	schemaOutputDir := filepath.Join(examplesDir, "schemas")
-	if err := os.RemoveAll(schemaOutputDir); err != nil {
-		log.Fatalf("failed to clean schema directory: %v", err)
+	name := os.Args[1]
+	if name == "help" {
+		usage()
+		return
 	}
-	if err := parser.ValidateSchemas(ctd, addons, schemaOutputDir); err != nil {
-		log.Fatalf("schema validation failed: %v", err)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				log.Fatalf("%s failed: %v", cmd.name, err)
+			}
+			return
+		}
 	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage()
+	os.Exit(2)
+}
 
-	// Extract CEL expressions and write to file
-	exprOutput := collectCELExpressions(ctd, addons)
-	exprPath := filepath.Join(examplesDir, "cel-expressions.yaml")
-	if err := writeYAML(exprPath, exprOutput); err != nil {
-		log.Fatalf("failed to write CEL expressions file: %v", err)
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: renderer2 <command> [flags]\n\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.summary)
 	}
-	fmt.Printf("\nCollected CEL expressions written to %s\n", exprPath)
+	fmt.Fprintln(os.Stderr, "\nRun `renderer2 <command> -h` for the flags of a command.")
+}
 
-	envDir := filepath.Join(examplesDir, "env-settings")
-	envConfigs := []struct {
-		name     string
-		settings *types.EnvSettings
-	}{
-		{name: "no-env", settings: nil},
+// runExamples regenerates the schemas, expression listing and expected output under examples/.
+func runExamples(renderArgs []string) error {
+	examplesDir := "examples"
+	// Schemas and expressions cover the addons the example Component references, like the render.
+	inputArgs := []string{
+		"-definition", filepath.Join(examplesDir, "component-type-definitions", "deployment-component.yaml"),
+		"-addons-dir", filepath.Join(examplesDir, "addons"),
+		"-component", filepath.Join(examplesDir, "components", "example-component.yaml"),
 	}
 
-	if devEnv, err := parser.LoadEnvSettings(filepath.Join(envDir, "dev-env.yaml")); err != nil {
-		log.Printf("warning: could not load dev env settings: %v", err)
-	} else {
-		envConfigs = append(envConfigs, struct {
-			name     string
-			settings *types.EnvSettings
-		}{name: "dev", settings: devEnv})
+	schemaDir := filepath.Join(examplesDir, "schemas")
+	if err := os.RemoveAll(schemaDir); err != nil {
+		return fmt.Errorf("failed to clean schema directory: %w", err)
 	}
-
-	if prodEnv, err := parser.LoadEnvSettings(filepath.Join(envDir, "prod-env.yaml")); err != nil {
-		log.Printf("warning: could not load prod env settings: %v", err)
-	} else {
-		envConfigs = append(envConfigs, struct {
-			name     string
-			settings *types.EnvSettings
-		}{name: "prod", settings: prodEnv})
+	if err := runSchema(append(inputArgs, "-output-dir", schemaDir)); err != nil {
+		return err
 	}
-
-	if err := os.RemoveAll(outputDir); err != nil {
-		log.Fatalf("failed to clean output dir: %v", err)
+	if err := runExpressions(append(inputArgs, "-out", filepath.Join(examplesDir, "cel-expressions.yaml"))); err != nil {
+		return err
 	}
 
-	stages := generateStages(componentDef)
-
-	for _, env := range envConfigs {
-		envOutput := filepath.Join(outputDir, env.name)
-		if err := os.MkdirAll(envOutput, 0755); err != nil {
-			log.Fatalf("failed to create output dir %s: %v", envOutput, err)
-		}
-
-		fmt.Printf("\nRendering for environment: %s\n", env.name)
-		for _, stage := range stages {
-			resources, err := renderer.RenderWithAddonLimit(ctd, componentDef, env.settings, addons, additionalCtx, nil, stage.AddonCount)
-			if err != nil {
-				log.Fatalf("failed to render stage %s: %v", stage.Name, err)
-			}
+	args := append(inputArgs,
+		"-additional-context", filepath.Join(examplesDir, "additional_context.json"),
+		"-env", "dev="+filepath.Join(examplesDir, "env-settings", "dev-env.yaml"),
+		"-env", "prod="+filepath.Join(examplesDir, "env-settings", "prod-env.yaml"),
+		"-output-dir", filepath.Join(examplesDir, "expected-output"),
+	)
+	return runRender(append(args, renderArgs...))
+}
 
-			outputFile := filepath.Join(envOutput, stage.Name+".yaml")
-			if err := writeOutput(resources, outputFile); err != nil {
-				log.Fatalf("failed to write output: %v", err)
-			}
-			fmt.Printf("  wrote %s (%d resources)\n", outputFile, len(resources))
-		}
+func outputExtension(format string) string {
+	switch format {
+	case "terraform":
+		return ".tf"
+	case "terraform-json":
+		return ".tf.json"
+	default:
+		return ".yaml"
 	}
+}
 
-	fmt.Println("\n✅ rendering complete using renderer2")
+// outputOptions controls writeOutput.
+type outputOptions struct {
+	// format is yaml, terraform (HCL) or terraform-json.
+	format string
+	// header is a comment written before the resources, except to terraform-json, which has no
+	// comment syntax.
+	header string
+	// keyOrder orders YAML mapping keys; the Terraform formats always sort them.
+	keyOrder format.KeyOrder
 }
 
-func writeOutput(resources []map[string]any, path string) error {
+// writeOutput writes resources normalized (see pkg/normalize), so golden files are identical on
+// every platform.
+func writeOutput(resources []map[string]any, path string, opts outputOptions) error {
 	file, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	return encodeOutput(file, resources, opts)
+}
+
+// encodeOutput is writeOutput for any writer.
+func encodeOutput(w io.Writer, resources []map[string]any, opts outputOptions) error {
+	resources = normalize.Resources(resources)
+	switch opts.format {
+	case "yaml":
+	case "terraform":
+		data, err := export.ToTerraformHCL(resources)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append([]byte(opts.header), data...))
+		return err
+	case "terraform-json":
+		data, err := export.ToTerraformJSON(resources)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.format)
+	}
 
-	encoder := yaml.NewEncoder(file)
+	if _, err := io.WriteString(w, opts.header); err != nil {
+		return err
+	}
+	encoder := yaml.NewEncoder(w)
 	defer encoder.Close()
 
 	for _, resource := range resources {
-		if err := encoder.Encode(resource); err != nil {
+		node, err := format.ResourceNode(resource, opts.keyOrder)
+		if err != nil {
 			return err
 		}
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCrossplane(ctd *types.ComponentTypeDefinition, addons []*types.Addon, dir string, progress io.Writer) error {
+	result, err := export.ToCrossplane(ctd, addons, export.CrossplaneOptions{})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeYAML(filepath.Join(dir, "xrd.yaml"), result.XRD); err != nil {
+		return err
+	}
+	if err := writeYAML(filepath.Join(dir, "composition.yaml"), result.Composition); err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "\nCrossplane XRD and Composition written to %s\n", dir)
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(progress, "  ⚠ %s\n", warning)
 	}
 	return nil
 }
 
-func generateStages(component *types.Component) []types.Stage {
+// generateStages names one stage per addon in the order the renderer applies them.
+func generateStages(comp *types.Component, addons map[string]*types.Addon) ([]types.Stage, error) {
+	instances, err := component.OrderAddons(comp, addons)
+	if err != nil {
+		return nil, err
+	}
 	stages := []types.Stage{{Name: "stage-1-base", AddonCount: 0}}
 	shortNames := map[string]string{
 		"persistent-volume-claim": "pvc",
@@ -149,8 +224,8 @@ func generateStages(component *types.Component) []types.Stage {
 		"emptydir-volume":         "emptydir",
 	}
 
-	for i := range component.Spec.Addons {
-		name := component.Spec.Addons[i].Name
+	for i, instance := range instances {
+		name := instance.Name
 		short := shortNames[name]
 		if short == "" {
 			short = name
@@ -161,7 +236,7 @@ func generateStages(component *types.Component) []types.Stage {
 		})
 	}
 
-	return stages
+	return stages, nil
 }
 
 type celExpressionsOutput struct {
@@ -175,11 +250,30 @@ func collectCELExpressions(ctd *types.ComponentTypeDefinition, addons map[string
 		Addons:                  make(map[string][]string),
 	}
 
+	if tmpl := ctd.Spec.CommonMetadata.Template(); tmpl != nil {
+		set := make(map[string]struct{})
+		collectExpressionsFromValue(tmpl, set)
+		if len(set) > 0 {
+			output.ComponentTypeDefinition["commonMetadata"] = setToSortedSlice(set)
+		}
+	}
+
+	for name, body := range ctd.Spec.Fragments {
+		set := make(map[string]struct{})
+		collectExpressionsFromValue(body, set)
+		if len(set) > 0 {
+			output.ComponentTypeDefinition[fmt.Sprintf("fragment:%s", name)] = setToSortedSlice(set)
+		}
+	}
+
 	for _, res := range ctd.Spec.Resources {
 		key := fmt.Sprintf("resource:%s", res.ID)
 		set := make(map[string]struct{})
 		addStringExpression(set, res.IncludeWhen)
 		addStringExpression(set, res.ForEach)
+		for _, condition := range res.FieldsWhen {
+			addStringExpression(set, condition)
+		}
 		collectExpressionsFromValue(res.Template, set)
 		if len(set) > 0 {
 			output.ComponentTypeDefinition[key] = setToSortedSlice(set)
@@ -189,13 +283,21 @@ func collectCELExpressions(ctd *types.ComponentTypeDefinition, addons map[string
 	for name, addon := range addons {
 		set := make(map[string]struct{})
 		for _, create := range addon.Spec.Creates {
-			collectExpressionsFromValue(create, set)
+			addStringExpression(set, create.IncludeWhen)
+			addStringExpression(set, create.ForEach)
+			collectExpressionsFromValue(create.Template, set)
 		}
 		for _, patchSpec := range addon.Spec.Patches {
 			addStringExpression(set, patchSpec.ForEach)
 			addStringExpression(set, patchSpec.Target.Where)
 			for _, op := range patchSpec.Operations {
 				addStringExpression(set, op.Path)
+				for _, segment := range op.Segments {
+					addStringExpression(set, segment.Key)
+					if segment.Filter != nil {
+						addStringExpression(set, segment.Filter.Equals)
+					}
+				}
 				collectExpressionsFromValue(op.Value, set)
 			}
 		}
@@ -251,3 +353,19 @@ func writeYAML(path string, v any) error {
 	}
 	return os.WriteFile(path, data, 0644)
 }
+
+// warningLogger prints Warning events, and Normal events such as hardened security contexts when
+// verbose. Staged addon skips are expected here, since every stage but the last produces them, and
+// are never printed.
+type warningLogger struct {
+	verbose bool
+}
+
+func (l warningLogger) Emit(event events.Event) {
+	switch {
+	case event.Type == events.TypeWarning:
+		log.Printf("warning: %s: %s", event.Reason, event.Message)
+	case l.verbose && event.Reason != events.ReasonAddonSkipped:
+		log.Printf("info: %s: %s", event.Reason, event.Message)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/migrate"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// runMigrate rewrites Component parameters across a directory tree to a new definition version.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	definitionPath := fs.String("definition", "", "path to the ComponentTypeDefinition declaring the versions")
+	to := fs.String("to", "", "target definition version")
+	dir := fs.String("dir", ".", "directory to scan for Component manifests")
+	dryRun := fs.Bool("dry-run", false, "report changes without rewriting files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *definitionPath == "" || *to == "" {
+		return fmt.Errorf("-definition and -to are required")
+	}
+
+	ctd, err := parser.LoadComponentTypeDefinition(*definitionPath)
+	if err != nil {
+		return err
+	}
+
+	reports, err := migrate.MigrateDir(template.NewEngine(), ctd, *dir, *to, migrate.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+
+	for _, report := range reports {
+		fmt.Printf("%s (%s): %s → %s\n", report.Path, report.Component, report.From, report.To)
+		if len(report.Unmapped) > 0 {
+			fmt.Printf("  unmapped: %s\n", strings.Join(report.Unmapped, ", "))
+		}
+		if len(report.Unknown) > 0 {
+			fmt.Printf("  not in target schema: %s\n", strings.Join(report.Unknown, ", "))
+		}
+	}
+	verb := "migrated"
+	if *dryRun {
+		verb = "would migrate"
+	}
+	fmt.Printf("\n%s %d component(s)\n", verb, len(reports))
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/operator"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/platform"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// runOperator reconciles Component custom resources in the cluster the process runs in until it
+// is interrupted or terminated.
+func runOperator(args []string) error {
+	fs := flag.NewFlagSet("operator", flag.ExitOnError)
+	interval := fs.Duration("interval", operator.DefaultInterval, "how often to reconcile")
+	resync := fs.Duration("resync", operator.DefaultResync, "how long unchanged Components go without being applied again")
+	fieldManager := fs.String("field-manager", operator.DefaultFieldManager, "server-side apply field manager")
+	applyPolicy := fs.String("apply-policy", string(pipeline.ApplyForceConflicts), "how to apply resources that do not request an apply policy: server-side, force-conflicts, replace or skip-if-exists")
+	hardenSecurity := fs.Bool("harden-security", false, "enforce baseline Pod Security Standards on rendered pod templates")
+	configPath := fs.String("config", "", "platform configuration file; defaults to $"+config.EnvVar+", then every "+config.FileName+" up to the repository root")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	platformConfig, err := config.Resolve(*configPath, ".")
+	if err != nil {
+		return fmt.Errorf("failed to load platform configuration: %w", err)
+	}
+	if err := applyConfigFlags(fs, platformConfig); err != nil {
+		return err
+	}
+
+	defaultPolicy, err := pipeline.ParseApplyPolicy(*applyPolicy)
+	if err != nil {
+		return fmt.Errorf("invalid -apply-policy: %w", err)
+	}
+
+	client, err := operator.InCluster(platformConfig.Remote)
+	if err != nil {
+		return err
+	}
+	op := operator.New(client, clusterRenderer(platformConfig, *hardenSecurity),
+		operator.WithInterval(*interval),
+		operator.WithResync(*resync),
+		operator.WithFieldManager(*fieldManager),
+		operator.WithApplyPolicy(defaultPolicy),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Printf("reconciling %s Components every %s", operator.APIVersion, *interval)
+	return op.Run(ctx, func(result operator.Result) {
+		name := result.Component
+		if result.Environment != "" {
+			name += " (" + result.Environment + ")"
+		}
+		switch {
+		case result.Err != nil && result.Component == "":
+			log.Printf("error: %v", result.Err)
+		case result.Err != nil:
+			log.Printf("error: %s: %v", name, result.Err)
+		case result.Skipped > 0:
+			log.Printf("%s: applied %d resource(s), skipped %d existing", name, result.Applied, result.Skipped)
+		case !result.Unchanged:
+			log.Printf("%s: applied %d resource(s)", name, result.Applied)
+		}
+	})
+}
+
+// clusterRenderer builds the renderer the operator and the admission webhook share, so the webhook
+// renders Components exactly as the operator will.
+func clusterRenderer(platformConfig *config.Config, hardenSecurity bool) *component.Renderer {
+	hooks := []pipeline.Hooks{platform.Availability{}}
+	if hardenSecurity {
+		hooks = append(hooks, platform.Security{Events: warningLogger{}})
+	}
+	hooks = append(hooks, platformConfig.Hooks()...)
+	return component.NewRenderer(template.NewEngine(observability.EngineOptions()...), nil,
+		component.WithEventSink(warningLogger{}),
+		component.WithStabilityPolicy(platformConfig.StabilityPolicy()),
+		component.WithHooks(hooks...),
+	)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+)
+
+// singleFileName is the file -single-file writes under the output directory.
+const singleFileName = "manifests.yaml"
+
+// outputSink receives the resources of every rendered environment and stage.
+type outputSink interface {
+	// write stores one stage and returns where it went, for progress output.
+	write(env, stage, header string, resources []map[string]any) (string, error)
+	// close flushes buffered output.
+	close() error
+}
+
+// dirSink writes one file per stage to <dir>/<env>/<stage><ext>.
+type dirSink struct {
+	dir  string
+	opts outputOptions
+}
+
+func (s dirSink) write(env, stage, header string, resources []map[string]any) (string, error) {
+	path := filepath.Join(s.dir, env, stage+outputExtension(s.opts.format))
+	opts := s.opts
+	opts.header = header
+	if err := writeOutput(resources, path, opts); err != nil {
+		return "", err
+	}
+	return normalize.Path(path), nil
+}
+
+func (dirSink) close() error { return nil }
+
+// streamSink concatenates every stage into one multi-document YAML stream, each introduced by a
+// "# Source: <env>/<stage>" comment.
+type streamSink struct {
+	w io.Writer
+	// file is closed on close; nil for stdout.
+	file io.Closer
+	name string
+	opts outputOptions
+	// wrote records whether a document separator is needed before the next stage.
+	wrote bool
+}
+
+func (s *streamSink) write(env, stage, header string, resources []map[string]any) (string, error) {
+	if s.wrote {
+		if _, err := io.WriteString(s.w, "---\n"); err != nil {
+			return "", err
+		}
+	}
+	opts := s.opts
+	opts.header = fmt.Sprintf("# Source: %s/%s\n%s", env, stage, header)
+	if err := encodeOutput(s.w, resources, opts); err != nil {
+		return "", err
+	}
+	s.wrote = true
+	return s.name, nil
+}
+
+func (s *streamSink) close() error {
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
+
+// jsonSink collects every stage into a single v1 List written on close, the form kubectl accepts
+// from stdin.
+type jsonSink struct {
+	w     io.Writer
+	items []map[string]any
+}
+
+func (s *jsonSink) write(_, _, _ string, resources []map[string]any) (string, error) {
+	s.items = append(s.items, normalize.Resources(resources)...)
+	return "stdout", nil
+}
+
+func (s *jsonSink) close() error {
+	items := s.items
+	if items == nil {
+		items = []map[string]any{}
+	}
+	data, err := json.MarshalIndent(map[string]any{"apiVersion": "v1", "kind": "List", "items": items}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal output: %w", err)
+	}
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
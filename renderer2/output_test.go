@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStreamSink(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	sink := &streamSink{w: &b, name: "stdout", opts: outputOptions{format: "yaml"}}
+	stages := []struct {
+		stage     string
+		resources []map[string]any
+	}{
+		{stage: "stage-1-base", resources: []map[string]any{{"kind": "Service", "metadata": map[string]any{"name": "web"}}}},
+		{stage: "stage-2-with-pvc", resources: []map[string]any{{"kind": "Service"}, {"kind": "PersistentVolumeClaim"}}},
+	}
+	for _, s := range stages {
+		if _, err := sink.write("dev", s.stage, "", s.resources); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	if err := sink.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	want := `# Source: dev/stage-1-base
+kind: Service
+metadata:
+    name: web
+---
+# Source: dev/stage-2-with-pvc
+kind: Service
+---
+kind: PersistentVolumeClaim
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestJSONSink(t *testing.T) {
+	t.Parallel()
+
+	var b bytes.Buffer
+	sink := &jsonSink{w: &b}
+	if _, err := sink.write("dev", "stage-1-base", "", []map[string]any{{"kind": "Service", "spec": map[string]any{"port": 80.0}}}); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if err := sink.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, b.String())
+	}
+	want := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      []any{map[string]any{"kind": "Service", "spec": map[string]any{"port": 80.0}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,121 @@
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+)
+
+// Artifact is a rendered non-Kubernetes file, such as an nginx.conf or a dashboard JSON.
+type Artifact struct {
+	// Path is slash-separated and relative to the sink root.
+	Path    string
+	Content []byte
+	// Source names the template that produced the artifact, e.g. "definition/nginx-conf".
+	Source string
+}
+
+// Sink receives rendered artifacts.
+type Sink interface {
+	Write(artifact Artifact) error
+}
+
+// CleanPath validates an artifact path and returns it in canonical form. Paths must be relative and
+// stay inside the sink root. Backslashes are treated as separators so paths built on Windows land
+// in the same place everywhere.
+func CleanPath(p string) (string, error) {
+	p = normalize.Path(p)
+	if p == "" {
+		return "", fmt.Errorf("artifact path is empty")
+	}
+	if strings.HasPrefix(p, "/") || filepath.IsAbs(p) {
+		return "", fmt.Errorf("artifact path %q must be relative", p)
+	}
+	cleaned := path.Clean(p)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("artifact path %q escapes the output directory", p)
+	}
+	return cleaned, nil
+}
+
+// DirSink writes artifacts as files below Root, creating directories as needed.
+type DirSink struct {
+	Root string
+}
+
+// Write implements Sink.
+func (s DirSink) Write(artifact Artifact) error {
+	cleaned, err := CleanPath(artifact.Path)
+	if err != nil {
+		return err
+	}
+	target := filepath.Join(s.Root, filepath.FromSlash(cleaned))
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for artifact %s: %w", cleaned, err)
+	}
+	if err := os.WriteFile(target, artifact.Content, 0644); err != nil {
+		return fmt.Errorf("failed to write artifact %s: %w", cleaned, err)
+	}
+	return nil
+}
+
+// MemorySink keeps artifacts in memory keyed by path; later writes to a path replace earlier ones.
+type MemorySink struct {
+	mu        sync.Mutex
+	artifacts map[string]Artifact
+}
+
+// Write implements Sink.
+func (s *MemorySink) Write(artifact Artifact) error {
+	cleaned, err := CleanPath(artifact.Path)
+	if err != nil {
+		return err
+	}
+	artifact.Path = cleaned
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.artifacts == nil {
+		s.artifacts = map[string]Artifact{}
+	}
+	s.artifacts[cleaned] = artifact
+	return nil
+}
+
+// Artifacts returns the stored artifacts sorted by path.
+func (s *MemorySink) Artifacts() []Artifact {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Artifact, 0, len(s.artifacts))
+	for _, artifact := range s.artifacts {
+		result = append(result, artifact)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result
+}
+
+// WriteAll writes every artifact to sink, rejecting duplicate paths.
+func WriteAll(sink Sink, items []Artifact) error {
+	seen := make(map[string]string, len(items))
+	for _, artifact := range items {
+		cleaned, err := CleanPath(artifact.Path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", artifact.Source, err)
+		}
+		if previous, ok := seen[cleaned]; ok {
+			return fmt.Errorf("artifact path %s produced by both %s and %s", cleaned, previous, artifact.Source)
+		}
+		seen[cleaned] = artifact.Source
+		if err := sink.Write(artifact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package artifacts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr string
+	}{
+		{path: "nginx/nginx.conf", want: "nginx/nginx.conf"},
+		{path: "./dashboards//app.json", want: "dashboards/app.json"},
+		{path: "", wantErr: "empty"},
+		{path: "/etc/passwd", wantErr: "must be relative"},
+		{path: "../outside.txt", wantErr: "escapes"},
+		{path: "a/../../b", wantErr: "escapes"},
+		{path: `dashboards\app.json`, want: "dashboards/app.json"},
+		{path: `..\outside.txt`, wantErr: "escapes"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := CleanPath(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CleanPath(%q) error = %v, want %q", tt.path, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("CleanPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	items := []Artifact{
+		{Path: "nginx/nginx.conf", Content: []byte("worker_processes 1;\n"), Source: "definition/nginx"},
+		{Path: "dashboards/app.json", Content: []byte("{}"), Source: "definition/dashboard"},
+	}
+	if err := WriteAll(DirSink{Root: root}, items); err != nil {
+		t.Fatalf("WriteAll() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(root, "nginx", "nginx.conf"))
+	if err != nil || string(content) != "worker_processes 1;\n" {
+		t.Fatalf("nginx.conf = %q, %v", content, err)
+	}
+
+	duplicate := append(items, Artifact{Path: "./nginx/nginx.conf", Source: "addon/proxy"})
+	if err := WriteAll(&MemorySink{}, duplicate); err == nil || !strings.Contains(err.Error(), "definition/nginx and addon/proxy") {
+		t.Fatalf("WriteAll() error = %v, want duplicate path error", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Package audit records render invocations as JSON lines (who rendered what, from which inputs,
+// with which result) for compliance when the renderer runs in shared pipelines.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Record describes one invocation.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Args    []string  `json:"args,omitempty"`
+	Actor   Actor     `json:"actor"`
+	// Inputs maps input names (definition, component, addon/<name>, env/<name>) to sha256 digests
+	// of their content.
+	Inputs       map[string]string `json:"inputs,omitempty"`
+	Resources    int               `json:"resources"`
+	OutputDigest string            `json:"outputDigest,omitempty"`
+	DurationMS   int64             `json:"durationMs"`
+	Error        string            `json:"error,omitempty"`
+}
+
+// Actor identifies who ran the renderer: a CI system and run, or a local user.
+type Actor struct {
+	User   string `json:"user,omitempty"`
+	CI     string `json:"ci,omitempty"`
+	RunID  string `json:"runId,omitempty"`
+	Commit string `json:"commit,omitempty"`
+}
+
+// CurrentActor reads the identity from the environment variables set by GitHub Actions, GitLab CI
+// and Jenkins, falling back to $USER.
+func CurrentActor() Actor {
+	actor := Actor{
+		User:   firstEnv("GITHUB_ACTOR", "GITLAB_USER_LOGIN", "BUILD_USER_ID", "USER", "USERNAME"),
+		RunID:  firstEnv("GITHUB_RUN_ID", "CI_PIPELINE_ID", "BUILD_TAG"),
+		Commit: firstEnv("GITHUB_SHA", "CI_COMMIT_SHA", "GIT_COMMIT"),
+	}
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		actor.CI = "github-actions"
+	case os.Getenv("GITLAB_CI") == "true":
+		actor.CI = "gitlab-ci"
+	case os.Getenv("JENKINS_URL") != "":
+		actor.CI = "jenkins"
+	case os.Getenv("CI") != "":
+		actor.CI = "unknown"
+	}
+	return actor
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// Digest returns the sha256 of the JSON encoding of v, which sorts map keys and is therefore
+// stable.
+func Digest(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// Sink stores records.
+type Sink interface {
+	Write(record Record) error
+}
+
+// FileSink appends records to a JSONL file. It is safe for concurrent use within one process;
+// each record is written with a single append so concurrent processes do not interleave lines.
+type FileSink struct {
+	Path string
+	mu   sync.Mutex
+}
+
+var _ Sink = (*FileSink)(nil)
+
+func (s *FileSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// Invocation collects a Record while a command runs. A nil *Invocation ignores every call, so
+// callers do not need to check whether auditing is enabled.
+type Invocation struct {
+	sink   Sink
+	start  time.Time
+	record Record
+}
+
+// Start begins recording an invocation of command with args, or returns nil when sink is nil.
+func Start(sink Sink, command string, args []string) *Invocation {
+	if sink == nil {
+		return nil
+	}
+	now := time.Now()
+	return &Invocation{
+		sink:  sink,
+		start: now,
+		record: Record{
+			Time:    now.UTC(),
+			Command: command,
+			Args:    args,
+			Actor:   CurrentActor(),
+			Inputs:  map[string]string{},
+		},
+	}
+}
+
+// Input records the digest of an input under name.
+func (i *Invocation) Input(name string, content any) error {
+	if i == nil {
+		return nil
+	}
+	digest, err := Digest(content)
+	if err != nil {
+		return fmt.Errorf("failed to digest %s: %w", name, err)
+	}
+	i.record.Inputs[name] = digest
+	return nil
+}
+
+// Output records the number of rendered resources and a digest over all of them.
+func (i *Invocation) Output(resources int, digest string) {
+	if i == nil {
+		return
+	}
+	i.record.Resources = resources
+	i.record.OutputDigest = digest
+}
+
+// Finish writes the record with the invocation's duration and err, if any.
+func (i *Invocation) Finish(err error) error {
+	if i == nil {
+		return nil
+	}
+	i.record.DurationMS = time.Since(i.start).Milliseconds()
+	if err != nil {
+		i.record.Error = err.Error()
+	}
+	return i.sink.Write(i.record)
+}
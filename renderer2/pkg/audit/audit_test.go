@@ -0,0 +1,88 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInvocationAppendsRecords(t *testing.T) {
+	t.Parallel()
+
+	sink := &FileSink{Path: filepath.Join(t.TempDir(), "audit.jsonl")}
+
+	ok := Start(sink, "render", []string{"-component", "web.yaml"})
+	if err := ok.Input("component", map[string]any{"name": "web"}); err != nil {
+		t.Fatalf("Input() error = %v", err)
+	}
+	ok.Output(3, "sha256:abc")
+	if err := ok.Finish(nil); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if err := Start(sink, "render", nil).Finish(errors.New("boom")); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	file, err := os.Open(sink.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var got []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q is not a record: %v", scanner.Text(), err)
+		}
+		if record.Time.IsZero() {
+			t.Errorf("record %q has no time", scanner.Text())
+		}
+		// Time, identity and duration depend on the environment running the test.
+		record.Time, record.Actor, record.DurationMS = time.Time{}, Actor{}, 0
+		got = append(got, record)
+	}
+
+	digest, _ := Digest(map[string]any{"name": "web"})
+	want := []Record{
+		{Command: "render", Args: []string{"-component", "web.yaml"}, Inputs: map[string]string{"component": digest}, Resources: 3, OutputDigest: "sha256:abc"},
+		{Command: "render", Error: "boom"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("records mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNilInvocation(t *testing.T) {
+	t.Parallel()
+
+	invocation := Start(nil, "render", nil)
+	if invocation != nil {
+		t.Fatalf("Start(nil) = %v, want nil", invocation)
+	}
+	if err := invocation.Input("component", func() {}); err != nil {
+		t.Errorf("Input() error = %v", err)
+	}
+	invocation.Output(1, "sha256:abc")
+	if err := invocation.Finish(errors.New("boom")); err != nil {
+		t.Errorf("Finish() error = %v", err)
+	}
+}
+
+func TestCurrentActor(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_ACTOR", "octocat")
+	t.Setenv("GITHUB_RUN_ID", "42")
+	t.Setenv("GITHUB_SHA", "deadbeef")
+
+	want := Actor{User: "octocat", CI: "github-actions", RunID: "42", Commit: "deadbeef"}
+	if diff := cmp.Diff(want, CurrentActor()); diff != "" {
+		t.Errorf("CurrentActor() mismatch (-want +got):\n%s", diff)
+	}
+}
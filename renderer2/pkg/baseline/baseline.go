@@ -0,0 +1,221 @@
+// Package baseline compares the rendered output of an environment with a baseline environment,
+// usually production, and flags overrides that weaken it: fewer replicas, removed probes, smaller
+// resource requests and the like. What counts as risky is a list of Rules.
+package baseline
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Check is the kind of delta a Rule flags.
+type Check string
+
+const (
+	// CheckDecrease flags a number or Kubernetes quantity lower than in the baseline.
+	CheckDecrease Check = "decrease"
+	// CheckRemoved flags a field, or with an empty path a resource, present only in the baseline.
+	CheckRemoved Check = "removed"
+	// CheckChanged flags any difference from the baseline.
+	CheckChanged Check = "changed"
+)
+
+// Severity says whether a finding fails the check.
+type Severity string
+
+const (
+	// SeverityError findings fail the check.
+	SeverityError Severity = "error"
+	// SeverityWarning findings are reported only.
+	SeverityWarning Severity = "warning"
+)
+
+// Rule flags a risky delta in one field of the resources of the given kinds.
+type Rule struct {
+	Name string `yaml:"name" json:"name"`
+	// Kinds limits the rule to these resource kinds; empty means every kind.
+	Kinds []string `yaml:"kinds,omitempty" json:"kinds,omitempty"`
+	// Path is a dotted field path where a "[*]" suffix visits every list item, e.g.
+	// spec.template.spec.containers[*].livenessProbe. Items with a name are paired by name.
+	// An empty path checks the resource itself.
+	Path  string `yaml:"path,omitempty" json:"path,omitempty"`
+	Check Check  `yaml:"check" json:"check"`
+	// Severity defaults to error.
+	Severity Severity `yaml:"severity,omitempty" json:"severity,omitempty"`
+}
+
+// Finding is a delta a rule flagged.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	// Resource is "Kind name".
+	Resource string `json:"resource"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// podTemplates are the kinds whose spec.template holds a pod template.
+var podTemplates = []string{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job"}
+
+// DefaultRules flags replica reductions, removed liveness and readiness probes, lower container
+// resource requests and limits, and removed PodDisruptionBudgets.
+func DefaultRules() []Rule {
+	rules := []Rule{
+		{Name: "replica-reduction", Kinds: []string{"Deployment", "StatefulSet", "ReplicaSet"}, Path: "spec.replicas", Check: CheckDecrease},
+		{Name: "autoscaling-reduction", Kinds: []string{"HorizontalPodAutoscaler"}, Path: "spec.minReplicas", Check: CheckDecrease},
+		{Name: "removed-liveness-probe", Kinds: podTemplates, Path: "spec.template.spec.containers[*].livenessProbe", Check: CheckRemoved},
+		{Name: "removed-readiness-probe", Kinds: podTemplates, Path: "spec.template.spec.containers[*].readinessProbe", Check: CheckRemoved},
+		{Name: "removed-disruption-budget", Kinds: []string{"PodDisruptionBudget"}, Check: CheckRemoved},
+	}
+	for _, field := range []string{"requests.cpu", "requests.memory", "limits.cpu", "limits.memory"} {
+		rules = append(rules, Rule{
+			Name:     "lower-" + strings.ReplaceAll(field, ".", "-"),
+			Kinds:    podTemplates,
+			Path:     "spec.template.spec.containers[*].resources." + field,
+			Check:    CheckDecrease,
+			Severity: SeverityWarning,
+		})
+	}
+	return rules
+}
+
+// Rules returns DefaultRules without the disabled names, with custom rules replacing same-named
+// defaults and the rest appended.
+func Rules(custom []Rule, disabled []string) []Rule {
+	skip := map[string]bool{}
+	for _, name := range disabled {
+		skip[name] = true
+	}
+	var rules []Rule
+	for _, rule := range append(DefaultRules(), custom...) {
+		replaced := false
+		for i := range rules {
+			if rules[i].Name == rule.Name {
+				rules[i], replaced = rule, true
+			}
+		}
+		if !replaced {
+			rules = append(rules, rule)
+		}
+	}
+	result := rules[:0]
+	for _, rule := range rules {
+		if !skip[rule.Name] {
+			result = append(result, rule)
+		}
+	}
+	return result
+}
+
+// Validate reports the first rule with a missing name or an unknown check or severity.
+func Validate(rules []Rule) error {
+	for i, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("baseline rule %d needs a name", i)
+		}
+		switch rule.Check {
+		case CheckDecrease, CheckRemoved, CheckChanged:
+		default:
+			return fmt.Errorf("baseline rule %s: unknown check %q (want decrease, removed or changed)", rule.Name, rule.Check)
+		}
+		switch rule.Severity {
+		case "", SeverityError, SeverityWarning:
+		default:
+			return fmt.Errorf("baseline rule %s: unknown severity %q (want error or warning)", rule.Name, rule.Severity)
+		}
+		if rule.Path == "" && rule.Check != CheckRemoved {
+			return fmt.Errorf("baseline rule %s: only removed checks may omit the path", rule.Name)
+		}
+	}
+	return nil
+}
+
+// Compare applies rules to every baseline resource and its counterpart in candidate, matched by
+// kind and name since namespaces usually differ between environments.
+func Compare(baseline, candidate []map[string]any, rules []Rule) []Finding {
+	counterparts := map[string]map[string]any{}
+	for _, resource := range candidate {
+		counterparts[resourceID(resource)] = resource
+	}
+	var findings []Finding
+	for _, resource := range baseline {
+		id := resourceID(resource)
+		kind, _ := resource["kind"].(string)
+		counterpart, found := counterparts[id]
+		for _, rule := range rules {
+			if !appliesTo(rule, kind) {
+				continue
+			}
+			finding := Finding{Rule: rule.Name, Severity: rule.Severity, Resource: id}
+			if finding.Severity == "" {
+				finding.Severity = SeverityError
+			}
+			if rule.Path == "" {
+				if !found && rule.Check == CheckRemoved {
+					finding.Message = "removed"
+					findings = append(findings, finding)
+				}
+				continue
+			}
+			if !found {
+				continue
+			}
+			for _, m := range matchPath(parsePath(rule.Path), resource, counterpart) {
+				if message, flagged := check(rule.Check, m); flagged {
+					finding.Path, finding.Message = m.path, m.path+" "+message
+					findings = append(findings, finding)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+func appliesTo(rule Rule, kind string) bool {
+	if len(rule.Kinds) == 0 {
+		return true
+	}
+	for _, k := range rule.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func check(kind Check, m match) (string, bool) {
+	switch kind {
+	case CheckRemoved:
+		return "removed", m.inBaseline && !m.inCandidate
+	case CheckDecrease:
+		if !m.inBaseline || !m.inCandidate {
+			return "", false
+		}
+		before, okBefore := parseQuantity(m.baseline)
+		after, okAfter := parseQuantity(m.candidate)
+		if !okBefore || !okAfter || after >= before {
+			return "", false
+		}
+		return fmt.Sprintf("decreased from %v to %v", m.baseline, m.candidate), true
+	case CheckChanged:
+		switch {
+		case m.inBaseline && !m.inCandidate:
+			return "removed", true
+		case !m.inBaseline && m.inCandidate:
+			return fmt.Sprintf("set to %v", m.candidate), true
+		case m.inBaseline && !reflect.DeepEqual(m.baseline, m.candidate):
+			return fmt.Sprintf("changed from %v to %v", m.baseline, m.candidate), true
+		}
+	}
+	return "", false
+}
+
+func resourceID(resource map[string]any) string {
+	kind, _ := resource["kind"].(string)
+	var name string
+	if metadata, ok := resource["metadata"].(map[string]any); ok {
+		name, _ = metadata["name"].(string)
+	}
+	return kind + " " + name
+}
@@ -0,0 +1,140 @@
+package baseline
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func deployment(replicas any, containers ...any) map[string]any {
+	return map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web"},
+		"spec": map[string]any{
+			"replicas": replicas,
+			"template": map[string]any{"spec": map[string]any{"containers": containers}},
+		},
+	}
+}
+
+func container(name, memory string, probe bool) map[string]any {
+	c := map[string]any{"name": name, "resources": map[string]any{"requests": map[string]any{"memory": memory}}}
+	if probe {
+		c["livenessProbe"] = map[string]any{"httpGet": map[string]any{"path": "/healthz", "port": 8080}}
+	}
+	return c
+}
+
+func TestCompare(t *testing.T) {
+	t.Parallel()
+
+	pdb := map[string]any{"apiVersion": "policy/v1", "kind": "PodDisruptionBudget", "metadata": map[string]any{"name": "web"}}
+	prod := []map[string]any{
+		deployment(3, container("app", "1Gi", true), container("proxy", "128Mi", true)),
+		pdb,
+	}
+
+	tests := []struct {
+		name      string
+		candidate []map[string]any
+		rules     []Rule
+		want      []Finding
+	}{
+		{
+			name:      "same as baseline",
+			candidate: []map[string]any{deployment(int64(3), container("app", "1024Mi", true), container("proxy", "128Mi", true)), pdb},
+			rules:     DefaultRules(),
+		},
+		{
+			name:      "weakened overrides",
+			candidate: []map[string]any{deployment(1, container("proxy", "64Mi", true), container("app", "512Mi", false))},
+			rules:     DefaultRules(),
+			want: []Finding{
+				{Rule: "replica-reduction", Severity: SeverityError, Resource: "Deployment web", Path: "spec.replicas", Message: "spec.replicas decreased from 3 to 1"},
+				{
+					Rule: "removed-liveness-probe", Severity: SeverityError, Resource: "Deployment web",
+					Path:    "spec.template.spec.containers[app].livenessProbe",
+					Message: "spec.template.spec.containers[app].livenessProbe removed",
+				},
+				{
+					Rule: "lower-requests-memory", Severity: SeverityWarning, Resource: "Deployment web",
+					Path:    "spec.template.spec.containers[app].resources.requests.memory",
+					Message: "spec.template.spec.containers[app].resources.requests.memory decreased from 1Gi to 512Mi",
+				},
+				{
+					Rule: "lower-requests-memory", Severity: SeverityWarning, Resource: "Deployment web",
+					Path:    "spec.template.spec.containers[proxy].resources.requests.memory",
+					Message: "spec.template.spec.containers[proxy].resources.requests.memory decreased from 128Mi to 64Mi",
+				},
+				{Rule: "removed-disruption-budget", Severity: SeverityError, Resource: "PodDisruptionBudget web", Message: "removed"},
+			},
+		},
+		{
+			name:      "custom rules",
+			candidate: []map[string]any{deployment(5, container("app", "1Gi", true)), pdb},
+			rules: Rules([]Rule{
+				{Name: "container-set", Kinds: []string{"Deployment"}, Path: "spec.template.spec.containers[*].name", Check: CheckChanged, Severity: SeverityWarning},
+			}, []string{"removed-liveness-probe", "removed-readiness-probe"}),
+			want: []Finding{{
+				Rule: "container-set", Severity: SeverityWarning, Resource: "Deployment web",
+				Path:    "spec.template.spec.containers[proxy].name",
+				Message: "spec.template.spec.containers[proxy].name removed",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, Compare(prod, tt.candidate, tt.rules)); diff != "" {
+				t.Errorf("Compare() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value any
+		want  float64
+	}{
+		{value: "500m", want: 0.5},
+		{value: "1.5Gi", want: 1.5 * (1 << 30)},
+		{value: "2k", want: 2000},
+		{value: "2e3", want: 2000},
+		{value: "3", want: 3},
+		{value: int64(4), want: 4},
+	}
+	for _, tt := range tests {
+		got, ok := parseQuantity(tt.value)
+		if !ok || got != tt.want {
+			t.Errorf("parseQuantity(%v) = %v, %v, want %v", tt.value, got, ok, tt.want)
+		}
+	}
+	if _, ok := parseQuantity("lots"); ok {
+		t.Errorf("parseQuantity(lots) succeeded, want failure")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	if err := Validate(DefaultRules()); err != nil {
+		t.Errorf("Validate(DefaultRules()) error = %v", err)
+	}
+	for _, rule := range []Rule{
+		{Check: CheckRemoved},
+		{Name: "typo", Path: "spec.replicas", Check: "decreases"},
+		{Name: "severity", Path: "spec.replicas", Check: CheckDecrease, Severity: "fatal"},
+		{Name: "no-path", Check: CheckDecrease},
+	} {
+		if err := Validate([]Rule{rule}); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", rule)
+		}
+	}
+}
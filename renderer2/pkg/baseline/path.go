@@ -0,0 +1,157 @@
+package baseline
+
+import (
+	"strconv"
+	"strings"
+)
+
+// segment is one step of a rule path: a map key, then every list item when each is set.
+type segment struct {
+	key  string
+	each bool
+}
+
+// match is the value a rule path reaches in a baseline resource and its counterpart.
+type match struct {
+	path                    string
+	baseline, candidate     any
+	inBaseline, inCandidate bool
+}
+
+func parsePath(path string) []segment {
+	var segments []segment
+	for _, part := range strings.Split(path, ".") {
+		key, each := strings.CutSuffix(part, "[*]")
+		segments = append(segments, segment{key: key, each: each})
+	}
+	return segments
+}
+
+// matchPath follows segments through both values, pairing list items by name when they have one
+// and by index otherwise.
+func matchPath(segments []segment, baseline, candidate any) []match {
+	var matches []match
+	var walk func(i int, path string, m match)
+	walk = func(i int, path string, m match) {
+		if i == len(segments) {
+			m.path = path
+			matches = append(matches, m)
+			return
+		}
+		seg := segments[i]
+		if path != "" {
+			path += "."
+		}
+		path += seg.key
+		m.baseline, m.inBaseline = field(m.baseline, m.inBaseline, seg.key)
+		m.candidate, m.inCandidate = field(m.candidate, m.inCandidate, seg.key)
+		if !m.inBaseline && !m.inCandidate {
+			return
+		}
+		if !seg.each {
+			walk(i+1, path, m)
+			return
+		}
+		for _, pair := range pairItems(m.baseline, m.candidate) {
+			walk(i+1, path+"["+pair.label+"]", match{
+				baseline: pair.baseline, inBaseline: pair.inBaseline,
+				candidate: pair.candidate, inCandidate: pair.inCandidate,
+			})
+		}
+	}
+	walk(0, "", match{baseline: baseline, candidate: candidate, inBaseline: true, inCandidate: true})
+	return matches
+}
+
+func field(value any, present bool, key string) (any, bool) {
+	if !present {
+		return nil, false
+	}
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	child, ok := object[key]
+	return child, ok && child != nil
+}
+
+type itemPair struct {
+	label                   string
+	baseline, candidate     any
+	inBaseline, inCandidate bool
+}
+
+func pairItems(baseline, candidate any) []itemPair {
+	before, _ := baseline.([]any)
+	after, _ := candidate.([]any)
+	var pairs []itemPair
+	index := map[string]int{}
+	add := func(label string, item any, inBaseline bool) {
+		i, ok := index[label]
+		if !ok {
+			i = len(pairs)
+			index[label] = i
+			pairs = append(pairs, itemPair{label: label})
+		}
+		if inBaseline {
+			pairs[i].baseline, pairs[i].inBaseline = item, true
+		} else {
+			pairs[i].candidate, pairs[i].inCandidate = item, true
+		}
+	}
+	for i, item := range before {
+		add(itemLabel(item, i), item, true)
+	}
+	for i, item := range after {
+		add(itemLabel(item, i), item, false)
+	}
+	return pairs
+}
+
+// itemLabel identifies a list item by its name, or by its index when it has none.
+func itemLabel(item any, i int) string {
+	if name := itemName(item); name != "" {
+		return name
+	}
+	return strconv.Itoa(i)
+}
+
+func itemName(item any) string {
+	object, _ := item.(map[string]any)
+	name, _ := object["name"].(string)
+	return name
+}
+
+// quantitySuffixes are the Kubernetes resource quantity suffixes.
+var quantitySuffixes = map[string]float64{
+	"n": 1e-9, "u": 1e-6, "m": 1e-3,
+	"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+}
+
+// parseQuantity reads a number or a Kubernetes quantity such as 500m, 1.5Gi or 2e3.
+func parseQuantity(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		for _, length := range []int{2, 1} {
+			if len(s) <= length {
+				continue
+			}
+			if multiplier, ok := quantitySuffixes[s[len(s)-length:]]; ok {
+				n, err := strconv.ParseFloat(s[:len(s)-length], 64)
+				return n * multiplier, err == nil
+			}
+		}
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
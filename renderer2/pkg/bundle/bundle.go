@@ -0,0 +1,306 @@
+// Package bundle loads definitions and addons packaged with a manifest that declares their
+// version, the addon bundles they depend on, shared type libraries and the renderer versions they
+// support. Loading resolves the whole bundle graph and verifies it before anything renders.
+package bundle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the manifest at the root of every bundle directory.
+const FileName = "bundle.yaml"
+
+const (
+	apiVersion = "platform.io/v1alpha1"
+	kind       = "Bundle"
+)
+
+// Manifest is the content of bundle.yaml.
+type Manifest struct {
+	APIVersion string   `yaml:"apiVersion"`
+	Kind       string   `yaml:"kind"`
+	Metadata   Metadata `yaml:"metadata"`
+	Spec       Spec     `yaml:"spec"`
+}
+
+// Metadata names and versions a bundle. Version is a semantic version.
+type Metadata struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+}
+
+// Spec declares the content of a bundle: exactly one of Definition and Addon, both file paths
+// relative to the bundle directory.
+type Spec struct {
+	Definition string `yaml:"definition,omitempty"`
+	Addon      string `yaml:"addon,omitempty"`
+	// Types lists type library files, YAML documents with a top-level types map, whose types are
+	// added to the schema types of the definition or addon.
+	Types []string `yaml:"types,omitempty"`
+	// Renderer is a version constraint the renderer must satisfy, e.g. ">=0.5.0".
+	Renderer     string       `yaml:"renderer,omitempty"`
+	Dependencies []Dependency `yaml:"dependencies,omitempty"`
+}
+
+// Dependency requires an addon bundle at Path, relative to the declaring bundle, whose version
+// satisfies the constraint Version, e.g. ">=1.2.0 <2.0.0". An empty constraint accepts any version.
+type Dependency struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version,omitempty"`
+	Path    string `yaml:"path"`
+}
+
+// Options configure Load.
+type Options struct {
+	// RendererVersion is checked against the renderer constraint of every bundle. Development
+	// builds, whose version is not a semantic version, skip the check.
+	RendererVersion string
+}
+
+// Bundle is a resolved bundle graph.
+type Bundle struct {
+	Manifest Manifest
+	Dir      string
+	// Definition is set when the root bundle packages a definition.
+	Definition *types.ComponentTypeDefinition
+	// Addons holds the addon of every bundle in the graph, keyed by addon name.
+	Addons map[string]*types.Addon
+}
+
+// Load reads the bundle in dir and every bundle it depends on. It fails when a manifest is
+// invalid, a dependency's name or version does not match, the same bundle is required at two
+// versions, bundles depend on each other in a cycle, a bundle requires a newer renderer, a type
+// library redefines a type differently, or an addon depends on an addon missing from the graph.
+func Load(dir string, opts Options) (*Bundle, error) {
+	var renderer *semver.Version
+	if version, err := semver.ParseTolerant(opts.RendererVersion); err == nil {
+		renderer = &version
+	}
+	l := &loader{renderer: renderer, loaded: map[string]*loadedBundle{}, active: map[string]bool{}}
+	root, err := l.load(dir, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Bundle{Manifest: root.manifest, Dir: root.dir, Definition: root.definition, Addons: map[string]*types.Addon{}}
+	sources := map[string]string{}
+	for _, name := range sortedKeys(l.loaded) {
+		b := l.loaded[name]
+		if b.addon == nil {
+			continue
+		}
+		if other, ok := sources[b.addon.Metadata.Name]; ok {
+			return nil, fmt.Errorf("bundles %s and %s both package addon %s", other, name, b.addon.Metadata.Name)
+		}
+		sources[b.addon.Metadata.Name] = name
+		result.Addons[b.addon.Metadata.Name] = b.addon
+	}
+	for _, name := range sortedKeys(result.Addons) {
+		for _, dep := range result.Addons[name].Spec.DependsOn {
+			if _, ok := result.Addons[dep]; !ok {
+				return nil, fmt.Errorf("addon %s depends on addon %s, which no bundle in the graph packages", name, dep)
+			}
+		}
+	}
+	return result, nil
+}
+
+type loader struct {
+	renderer *semver.Version
+	loaded   map[string]*loadedBundle
+	// active holds the bundles being loaded, to detect cycles.
+	active map[string]bool
+	path   []string
+}
+
+type loadedBundle struct {
+	manifest   Manifest
+	dir        string
+	version    semver.Version
+	requiredBy string
+	definition *types.ComponentTypeDefinition
+	addon      *types.Addon
+}
+
+// load reads the bundle in dir, verifying it against dep when another bundle requires it.
+func (l *loader) load(dir string, dep *Dependency) (*loadedBundle, error) {
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	name := manifest.Metadata.Name
+	version, err := semver.ParseTolerant(manifest.Metadata.Version)
+	if err != nil {
+		return nil, fmt.Errorf("bundle %s: invalid version %q: %w", name, manifest.Metadata.Version, err)
+	}
+	requiredBy := "the root bundle"
+	if len(l.path) > 0 {
+		requiredBy = l.path[len(l.path)-1]
+	}
+	if dep != nil {
+		if dep.Name != name {
+			return nil, fmt.Errorf("bundle %s requires %s from %s, which contains bundle %s", requiredBy, dep.Name, dir, name)
+		}
+		if dep.Version != "" {
+			constraint, err := semver.ParseRange(dep.Version)
+			if err != nil {
+				return nil, fmt.Errorf("bundle %s: invalid version constraint %q for %s: %w", requiredBy, dep.Version, name, err)
+			}
+			if !constraint(version) {
+				return nil, fmt.Errorf("bundle %s requires %s %s, found %s in %s", requiredBy, name, dep.Version, version, dir)
+			}
+		}
+		if manifest.Spec.Addon == "" {
+			return nil, fmt.Errorf("bundle %s depends on %s, which does not package an addon", requiredBy, name)
+		}
+	}
+
+	if l.active[name] {
+		return nil, fmt.Errorf("bundles depend on each other in a cycle: %s -> %s", strings.Join(l.path, " -> "), name)
+	}
+	if loaded, ok := l.loaded[name]; ok {
+		if !loaded.version.EQ(version) {
+			return nil, fmt.Errorf("bundle %s is required at version %s by %s and at version %s by %s", name, loaded.version, loaded.requiredBy, version, requiredBy)
+		}
+		return loaded, nil
+	}
+
+	if manifest.Spec.Renderer != "" {
+		constraint, err := semver.ParseRange(manifest.Spec.Renderer)
+		if err != nil {
+			return nil, fmt.Errorf("bundle %s: invalid renderer constraint %q: %w", name, manifest.Spec.Renderer, err)
+		}
+		if l.renderer != nil && !constraint(*l.renderer) {
+			return nil, fmt.Errorf("bundle %s requires renderer %s, running %s", name, manifest.Spec.Renderer, l.renderer)
+		}
+	}
+
+	b := &loadedBundle{manifest: manifest, dir: dir, version: version, requiredBy: requiredBy}
+	if err := b.loadContent(); err != nil {
+		return nil, fmt.Errorf("bundle %s: %w", name, err)
+	}
+
+	l.active[name] = true
+	l.path = append(l.path, name)
+	for i := range manifest.Spec.Dependencies {
+		dependency := manifest.Spec.Dependencies[i]
+		if dependency.Name == "" || dependency.Path == "" {
+			return nil, fmt.Errorf("bundle %s: dependency %d needs a name and a path", name, i)
+		}
+		if _, err := l.load(filepath.Join(dir, dependency.Path), &dependency); err != nil {
+			return nil, err
+		}
+	}
+	l.path = l.path[:len(l.path)-1]
+	delete(l.active, name)
+
+	l.loaded[name] = b
+	return b, nil
+}
+
+func readManifest(dir string) (Manifest, error) {
+	var manifest Manifest
+	path := filepath.Join(dir, FileName)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&manifest); err != nil {
+		return manifest, fmt.Errorf("failed to parse bundle manifest %s: %w", path, err)
+	}
+	switch {
+	case manifest.APIVersion != apiVersion || manifest.Kind != kind:
+		return manifest, fmt.Errorf("bundle manifest %s must be a %s %s", path, apiVersion, kind)
+	case manifest.Metadata.Name == "":
+		return manifest, fmt.Errorf("bundle manifest %s missing metadata.name", path)
+	case (manifest.Spec.Definition == "") == (manifest.Spec.Addon == ""):
+		return manifest, fmt.Errorf("bundle manifest %s must declare exactly one of spec.definition and spec.addon", path)
+	}
+	return manifest, nil
+}
+
+// loadContent parses the definition or addon of the bundle and adds its type libraries.
+func (b *loadedBundle) loadContent() error {
+	var schema *types.Schema
+	if b.manifest.Spec.Definition != "" {
+		definition, err := parser.LoadComponentTypeDefinition(filepath.Join(b.dir, b.manifest.Spec.Definition))
+		if err != nil {
+			return err
+		}
+		b.definition, schema = definition, &definition.Spec.Schema
+	} else {
+		path := filepath.Join(b.dir, b.manifest.Spec.Addon)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read addon file %s: %w", path, err)
+		}
+		addon, err := parser.ParseAddon(path, content)
+		if err != nil {
+			return err
+		}
+		b.addon, schema = addon, &addon.Spec.Schema
+	}
+
+	var errs []error
+	for _, library := range b.manifest.Spec.Types {
+		errs = append(errs, addTypes(schema, filepath.Join(b.dir, library)))
+	}
+	return errors.Join(errs...)
+}
+
+// addTypes adds the types of the library at path to schema. A type the schema already declares
+// must be declared identically.
+func addTypes(schema *types.Schema, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read type library: %w", err)
+	}
+	var library struct {
+		Types map[string]any `yaml:"types"`
+	}
+	if err := yaml.Unmarshal(content, &library); err != nil {
+		return fmt.Errorf("failed to parse type library %s: %w", path, err)
+	}
+	if schema.Types == nil {
+		schema.Types = map[string]any{}
+	}
+	for _, name := range sortedKeys(library.Types) {
+		declared, ok := schema.Types[name]
+		if ok && !sameYAML(declared, library.Types[name]) {
+			return fmt.Errorf("type library %s redefines type %s", path, name)
+		}
+		schema.Types[name] = library.Types[name]
+	}
+	return nil
+}
+
+func sameYAML(a, b any) bool {
+	left, err := yaml.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := yaml.Marshal(b)
+	return err == nil && bytes.Equal(left, right)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func manifest(name, version, spec string) string {
+	return `apiVersion: platform.io/v1alpha1
+kind: Bundle
+metadata:
+  name: ` + name + `
+  version: ` + version + `
+spec:
+` + spec
+}
+
+func addon(name string, dependsOn ...string) string {
+	content := "apiVersion: platform.io/v1alpha1\nkind: Addon\nmetadata:\n  name: " + name + "\nspec:\n  schema: {}\n"
+	if len(dependsOn) > 0 {
+		content += "  dependsOn: [" + strings.Join(dependsOn, ", ") + "]\n"
+	}
+	return content
+}
+
+const definition = `apiVersion: platform.io/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web-app
+spec:
+  workloadType: deployment
+  schema:
+    types:
+      Port: 'integer | minimum=1'
+    parameters:
+      port: Port
+      probe: Probe
+  resources: []
+`
+
+func validFiles() map[string]string {
+	return map[string]string{
+		"web/bundle.yaml": manifest("web", "1.4.0", `  definition: definition.yaml
+  types: [../types/probes.yaml]
+  renderer: ">=0.5.0"
+  dependencies:
+    - name: pvc
+      version: ">=1.0.0 <2.0.0"
+      path: ../pvc
+    - name: logging
+      path: ../logging
+`),
+		"web/definition.yaml": definition,
+		"types/probes.yaml": `types:
+  Port: 'integer | minimum=1'
+  Probe:
+    path: string
+`,
+		"pvc/bundle.yaml":     manifest("pvc", "1.2.0", "  addon: addon.yaml\n  dependencies:\n    - {name: logging, version: '>=2.0.0 <3.0.0', path: ../logging}\n"),
+		"pvc/addon.yaml":      addon("persistent-volume", "log-sidecar"),
+		"logging/bundle.yaml": manifest("logging", "2.1.0", "  addon: addon.yaml\n"),
+		"logging/addon.yaml":  addon("log-sidecar"),
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	root := writeFiles(t, validFiles())
+	b, err := Load(filepath.Join(root, "web"), Options{RendererVersion: "v0.6.1"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if b.Definition == nil || b.Definition.Metadata.Name != "web-app" {
+		t.Fatalf("Definition = %v, want web-app", b.Definition)
+	}
+	wantTypes := map[string]any{"Port": "integer | minimum=1", "Probe": map[string]any{"path": "string"}}
+	if diff := cmp.Diff(wantTypes, b.Definition.Spec.Schema.Types); diff != "" {
+		t.Errorf("schema types mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"log-sidecar", "persistent-volume"}, sortedKeys(b.Addons)); diff != "" {
+		t.Errorf("addons mismatch (-want +got):\n%s", diff)
+	}
+
+	// Development builds cannot be compared and skip the renderer check.
+	if _, err := Load(filepath.Join(root, "web"), Options{RendererVersion: "(devel)"}); err != nil {
+		t.Errorf("Load() of a development build error = %v", err)
+	}
+}
+
+func TestLoadRejectsIncompatibleGraphs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "dependency version outside the constraint",
+			files:   map[string]string{"pvc/bundle.yaml": manifest("pvc", "2.0.0", "  addon: addon.yaml\n")},
+			wantErr: "bundle web requires pvc >=1.0.0 <2.0.0, found 2.0.0",
+		},
+		{
+			name:    "dependency at the path is another bundle",
+			files:   map[string]string{"logging/bundle.yaml": manifest("log", "2.1.0", "  addon: addon.yaml\n")},
+			wantErr: "bundle pvc requires logging from",
+		},
+		{
+			name: "same bundle required at two versions",
+			files: map[string]string{
+				"pvc/bundle.yaml":        manifest("pvc", "1.2.0", "  addon: addon.yaml\n  dependencies:\n    - {name: logging, path: ../logging-v1}\n"),
+				"logging-v1/bundle.yaml": manifest("logging", "1.0.0", "  addon: addon.yaml\n"),
+				"logging-v1/addon.yaml":  addon("log-sidecar"),
+			},
+			wantErr: "bundle logging is required at version 1.0.0 by pvc and at version 2.1.0 by web",
+		},
+		{
+			name:    "cycle",
+			files:   map[string]string{"logging/bundle.yaml": manifest("logging", "2.1.0", "  addon: addon.yaml\n  dependencies:\n    - {name: pvc, path: ../pvc}\n")},
+			wantErr: "bundles depend on each other in a cycle: web -> pvc -> logging -> pvc",
+		},
+		{
+			name:    "renderer too old",
+			files:   map[string]string{"logging/bundle.yaml": manifest("logging", "2.1.0", "  addon: addon.yaml\n  renderer: '>=1.0.0'\n")},
+			wantErr: "bundle logging requires renderer >=1.0.0, running 0.6.1",
+		},
+		{
+			name:    "type library redefining a type",
+			files:   map[string]string{"types/probes.yaml": "types:\n  Port: string\n"},
+			wantErr: "redefines type Port",
+		},
+		{
+			name:    "addon dependency missing from the graph",
+			files:   map[string]string{"pvc/addon.yaml": addon("persistent-volume", "log-sidecar", "backup")},
+			wantErr: "addon persistent-volume depends on addon backup, which no bundle in the graph packages",
+		},
+		{
+			name:    "dependency on a definition bundle",
+			files:   map[string]string{"logging/bundle.yaml": manifest("logging", "2.1.0", "  definition: ../web/definition.yaml\n")},
+			wantErr: "bundle pvc depends on logging, which does not package an addon",
+		},
+		{
+			name:    "unknown manifest field",
+			files:   map[string]string{"logging/bundle.yaml": manifest("logging", "2.1.0", "  addon: addon.yaml\n  addons: [x]\n")},
+			wantErr: "field addons not found",
+		},
+		{
+			name:    "manifest without content",
+			files:   map[string]string{"logging/bundle.yaml": manifest("logging", "2.1.0", "  types: []\n")},
+			wantErr: "must declare exactly one of spec.definition and spec.addon",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			files := validFiles()
+			for name, content := range tt.files {
+				files[name] = content
+			}
+			root := writeFiles(t, files)
+			_, err := Load(filepath.Join(root, "web"), Options{RendererVersion: "0.6.1"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
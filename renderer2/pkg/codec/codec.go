@@ -0,0 +1,154 @@
+// Package codec decodes and encodes the YAML manifests the renderer reads, with a library each
+// caller selects. Kubernetes, the default, follows the API server and kubectl, which convert YAML
+// to JSON before decoding it; YAMLv3 decodes YAML 1.2 directly, as the renderer did before.
+//
+// Both decode into the yaml struct tags and UnmarshalYAML methods of the types package, so the
+// choice only changes how scalars and keys are read:
+//
+//	input             Kubernetes        YAMLv3
+//	enabled: yes      true              "yes"
+//	ratio: 1.0        1 (integer)       1.0 (float)
+//	1: one            key "1"           integer key 1
+//	date: 2024-01-01  "2024-01-01"      time.Time
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+)
+
+// Codec decodes and encodes YAML documents.
+type Codec interface {
+	// Name identifies the codec, as accepted by ByName.
+	Name() string
+	// Unmarshal decodes the single YAML or JSON document data into v.
+	Unmarshal(data []byte, v any) error
+	// Marshal encodes v as a YAML document.
+	Marshal(v any) ([]byte, error)
+	// Documents splits a multi-document YAML stream into its documents, leaving out empty ones.
+	Documents(data []byte) ([][]byte, error)
+}
+
+var (
+	// Kubernetes decodes like sigs.k8s.io/yaml: YAML 1.1 scalars, string keys and JSON numbers,
+	// and splits streams like kubectl. It encodes canonical Kubernetes YAML with sorted keys.
+	Kubernetes Codec = kubernetesCodec{}
+	// YAMLv3 decodes and encodes with gopkg.in/yaml.v3.
+	YAMLv3 Codec = yamlV3Codec{}
+	// Default is the codec of callers that do not pick one.
+	Default = Kubernetes
+)
+
+// ByName returns the codec called name; the empty name is Default.
+func ByName(name string) (Codec, error) {
+	switch name {
+	case "":
+		return Default, nil
+	case Kubernetes.Name():
+		return Kubernetes, nil
+	case YAMLv3.Name():
+		return YAMLv3, nil
+	default:
+		return nil, fmt.Errorf("unknown YAML library %q (want %s or %s)", name, Kubernetes.Name(), YAMLv3.Name())
+	}
+}
+
+// Or returns c, or Default when c is nil.
+func Or(c Codec) Codec {
+	if c == nil {
+		return Default
+	}
+	return c
+}
+
+type kubernetesCodec struct{}
+
+func (kubernetesCodec) Name() string { return "kubernetes" }
+
+// Unmarshal converts data to JSON as sigs.k8s.io/yaml does, then decodes the JSON, which is also
+// YAML, with yaml.v3, so the yaml tags and UnmarshalYAML methods of the target still apply.
+func (kubernetesCodec) Unmarshal(data []byte, v any) error {
+	converted, err := sigsyaml.YAMLToJSON(data)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(converted, v)
+}
+
+// Marshal encodes v with yaml.v3, which quotes the strings YAML 1.1 would read as other scalars,
+// and converts the result through JSON as sigs.k8s.io/yaml.Marshal does.
+func (kubernetesCodec) Marshal(v any) ([]byte, error) {
+	encoded, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := sigsyaml.YAMLToJSON(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return sigsyaml.JSONToYAML(converted)
+}
+
+func (kubernetesCodec) Documents(data []byte) ([][]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(data)))
+	var docs [][]byte
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		converted, err := sigsyaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", len(docs)+1, err)
+		}
+		if strings.TrimSpace(string(converted)) != "null" {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+type yamlV3Codec struct{}
+
+func (yamlV3Codec) Name() string { return "yaml.v3" }
+
+func (yamlV3Codec) Unmarshal(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+func (yamlV3Codec) Marshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlV3Codec) Documents(data []byte) ([][]byte, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	var docs [][]byte
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 || doc.Content[0].Tag == "!!null" {
+			continue
+		}
+		encoded, err := yaml.Marshal(&doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, encoded)
+	}
+}
@@ -0,0 +1,147 @@
+package codec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+const scalars = `enabled: yes
+ratio: 1.0
+1: one
+date: 2024-01-01
+`
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		codec Codec
+		want  any
+	}{
+		{
+			codec: Kubernetes,
+			want:  map[string]any{"enabled": true, "ratio": 1, "1": "one", "date": "2024-01-01"},
+		},
+		{
+			codec: YAMLv3,
+			want: map[any]any{"enabled": "yes", "ratio": 1.0, 1: "one",
+				"date": time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec.Name(), func(t *testing.T) {
+			t.Parallel()
+
+			var got any
+			if err := tt.codec.Unmarshal([]byte(scalars), &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Unmarshal() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalTypes(t *testing.T) {
+	t.Parallel()
+
+	// Typed targets keep their yaml tags and UnmarshalYAML methods with either codec.
+	const addon = `metadata:
+  name: sidecar
+spec:
+  creates:
+    - apiVersion: v1
+      kind: ConfigMap
+  patches:
+    - target: {kind: Deployment}
+      operations:
+        - op: add
+          path: /spec/template/spec/containers/-
+          value: {name: sidecar, tty: on}
+`
+	for _, codec := range []Codec{Kubernetes, YAMLv3} {
+		var got types.Addon
+		if err := codec.Unmarshal([]byte(addon), &got); err != nil {
+			t.Fatalf("%s: Unmarshal() error = %v", codec.Name(), err)
+		}
+		if got.Metadata.Name != "sidecar" || len(got.Spec.Creates) != 1 || got.Spec.Creates[0].Template.(map[string]any)["kind"] != "ConfigMap" {
+			t.Errorf("%s: Unmarshal() = %+v", codec.Name(), got)
+		}
+		tty := got.Spec.Patches[0].Operations[0].Value.(map[string]any)["tty"]
+		if want := map[string]any{"kubernetes": true, "yaml.v3": "on"}[codec.Name()]; tty != want {
+			t.Errorf("%s: tty = %#v, want %#v", codec.Name(), tty, want)
+		}
+	}
+}
+
+func TestDocuments(t *testing.T) {
+	t.Parallel()
+
+	const stream = `---
+# leading comment only
+---
+kind: A
+---
+---
+kind: B
+...
+`
+	for _, codec := range []Codec{Kubernetes, YAMLv3} {
+		docs, err := codec.Documents([]byte(stream))
+		if err != nil {
+			t.Fatalf("%s: Documents() error = %v", codec.Name(), err)
+		}
+		var kinds []string
+		for _, doc := range docs {
+			var header struct {
+				Kind string `yaml:"kind"`
+			}
+			if err := codec.Unmarshal(doc, &header); err != nil {
+				t.Fatalf("%s: Unmarshal() error = %v", codec.Name(), err)
+			}
+			kinds = append(kinds, header.Kind)
+		}
+		if diff := cmp.Diff([]string{"A", "B"}, kinds); diff != "" {
+			t.Errorf("%s: Documents() mismatch (-want +got):\n%s", codec.Name(), diff)
+		}
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	value := map[string]any{"enabled": "yes", "ports": []any{map[string]any{"port": 80}}}
+	tests := []struct {
+		codec Codec
+		want  string
+	}{
+		{codec: Kubernetes, want: "enabled: \"yes\"\nports:\n- port: 80\n"},
+		{codec: YAMLv3, want: "enabled: \"yes\"\nports:\n    - port: 80\n"},
+	}
+	for _, tt := range tests {
+		got, err := tt.codec.Marshal(value)
+		if err != nil {
+			t.Fatalf("%s: Marshal() error = %v", tt.codec.Name(), err)
+		}
+		if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+			t.Errorf("%s: Marshal() mismatch (-want +got):\n%s", tt.codec.Name(), diff)
+		}
+	}
+}
+
+func TestByName(t *testing.T) {
+	t.Parallel()
+
+	for name, want := range map[string]Codec{"": Default, "kubernetes": Kubernetes, "yaml.v3": YAMLv3} {
+		if got, err := ByName(name); err != nil || got != want {
+			t.Errorf("ByName(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	if _, err := ByName("yaml.v2"); err == nil || err.Error() != `unknown YAML library "yaml.v2" (want kubernetes or yaml.v3)` {
+		t.Errorf("ByName(yaml.v2) error = %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package component
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// OrderAddons returns the component's addon instances in application order: every instance of an
+// addon comes after all instances of the addons it dependsOn, and instances keep their
+// Spec.Addons order otherwise. It fails when an addon is missing from addonMap, depends on an
+// addon the component does not attach, or dependencies form a cycle.
+func OrderAddons(component *types.Component, addonMap map[string]*types.Addon) ([]types.AddonInstance, error) {
+	instances := component.Spec.Addons
+	attached := map[string]bool{}
+	for _, instance := range instances {
+		attached[instance.Name] = true
+	}
+
+	dependencies := map[string][]string{}
+	for name := range attached {
+		addon, ok := addonMap[name]
+		if !ok {
+			return nil, fmt.Errorf("addon %s not found", name)
+		}
+		for _, dep := range addon.Spec.DependsOn {
+			if !attached[dep] {
+				return nil, fmt.Errorf("addon %s depends on %s, which component %s does not attach", name, dep, component.Metadata.Name)
+			}
+			dependencies[name] = append(dependencies[name], dep)
+		}
+	}
+
+	ordered := make([]types.AddonInstance, 0, len(instances))
+	placed := map[string]bool{}
+	remaining := map[string]int{}
+	for _, instance := range instances {
+		remaining[instance.Name]++
+	}
+	done := make([]bool, len(instances))
+	for len(ordered) < len(instances) {
+		progressed := false
+		for i, instance := range instances {
+			if done[i] || !ready(dependencies[instance.Name], placed) {
+				continue
+			}
+			ordered = append(ordered, instance)
+			done[i] = true
+			progressed = true
+			if remaining[instance.Name]--; remaining[instance.Name] == 0 {
+				placed[instance.Name] = true
+			}
+			// Restart so an unblocked earlier instance goes before later ones.
+			break
+		}
+		if !progressed {
+			return nil, fmt.Errorf("addon dependency cycle: %s", dependencyCycle(dependencies, placed))
+		}
+	}
+	return ordered, nil
+}
+
+func ready(dependencies []string, placed map[string]bool) bool {
+	for _, dep := range dependencies {
+		if !placed[dep] {
+			return false
+		}
+	}
+	return true
+}
+
+// dependencyCycle describes one cycle among the addons not yet placed, e.g. "a -> b -> a".
+func dependencyCycle(dependencies map[string][]string, placed map[string]bool) string {
+	var start string
+	for name := range dependencies {
+		if !placed[name] && (start == "" || name < start) {
+			start = name
+		}
+	}
+
+	path := []string{}
+	index := map[string]int{}
+	for name := start; ; {
+		if i, seen := index[name]; seen {
+			return strings.Join(append(path[i:], name), " -> ")
+		}
+		index[name] = len(path)
+		path = append(path, name)
+		next := ""
+		for _, dep := range dependencies[name] {
+			if !placed[dep] {
+				next = dep
+				break
+			}
+		}
+		name = next
+	}
+}
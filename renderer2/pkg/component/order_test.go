@@ -0,0 +1,88 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOrderAddons(t *testing.T) {
+	t.Parallel()
+
+	addon := func(name string, dependsOn ...string) *types.Addon {
+		return &types.Addon{Metadata: types.Metadata{Name: name}, Spec: types.AddonSpec{DependsOn: dependsOn}}
+	}
+	tests := []struct {
+		name      string
+		addons    []*types.Addon
+		instances []string
+		want      []string
+		wantErr   string
+	}{
+		{
+			name:      "keeps component order without dependencies",
+			addons:    []*types.Addon{addon("pvc"), addon("sidecar")},
+			instances: []string{"sidecar", "pvc"},
+			want:      []string{"sidecar", "pvc"},
+		},
+		{
+			name:      "moves dependencies first",
+			addons:    []*types.Addon{addon("sidecar", "emptydir"), addon("emptydir"), addon("pvc")},
+			instances: []string{"sidecar", "pvc", "emptydir"},
+			want:      []string{"pvc", "emptydir", "sidecar"},
+		},
+		{
+			name:      "waits for every instance of a dependency",
+			addons:    []*types.Addon{addon("sidecar", "emptydir"), addon("emptydir")},
+			instances: []string{"emptydir", "sidecar", "emptydir"},
+			want:      []string{"emptydir", "emptydir", "sidecar"},
+		},
+		{
+			name:      "dependency not attached",
+			addons:    []*types.Addon{addon("sidecar", "emptydir"), addon("emptydir")},
+			instances: []string{"sidecar"},
+			wantErr:   "addon sidecar depends on emptydir, which component web does not attach",
+		},
+		{
+			name:      "cycle",
+			addons:    []*types.Addon{addon("a", "b"), addon("b", "c"), addon("c", "a"), addon("d")},
+			instances: []string{"d", "c", "b", "a"},
+			wantErr:   "addon dependency cycle: a -> b -> c -> a",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			addonMap := map[string]*types.Addon{}
+			for _, addon := range tt.addons {
+				addonMap[addon.Metadata.Name] = addon
+			}
+			comp := &types.Component{Metadata: types.Metadata{Name: "web"}}
+			for i, name := range tt.instances {
+				comp.Spec.Addons = append(comp.Spec.Addons, types.AddonInstance{Name: name, InstanceID: string(rune('a' + i))})
+			}
+
+			ordered, err := OrderAddons(comp, addonMap)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("OrderAddons() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("OrderAddons() error = %v", err)
+			}
+			got := make([]string, len(ordered))
+			for i, instance := range ordered {
+				got[i] = instance.Name
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("OrderAddons() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,26 +1,110 @@
 package component
 
 import (
-	"fmt"
+	"errors"
 
+	"github.com/chathurangada/cel_playground/renderer2/pkg/artifacts"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/patch"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
 )
 
-// Renderer exposes high-level rendering for ComponentTypeDefinitions plus addons.
+// Renderer exposes high-level rendering for ComponentTypeDefinitions plus addons. It is safe for
+// concurrent use provided the registered hooks and event sink are; definitions, components and
+// addons passed to it are only read.
 type Renderer struct {
-	base    *pipeline.RendererCoordinates
-	matcher patch.Matcher
+	base      *pipeline.RendererCoordinates
+	matcher   patch.Matcher
+	events    events.Sink
+	hooks     []pipeline.Hooks
+	owners    pipeline.OwnerMode
+	conflicts pipeline.ConflictMode
+	stability *StabilityPolicy
+	schemas   *schema.Cache
+	waves     string
+	scopes    *pipeline.Scopes
+	progress  ProgressFunc
+}
+
+// Option configures a Renderer.
+type Option func(*Renderer)
+
+// WithEventSink routes render events (skipped addons, empty patches, rejected overrides) to sink.
+func WithEventSink(sink events.Sink) Option {
+	return func(r *Renderer) {
+		r.events = sink
+	}
+}
+
+// WithHooks registers compiled-in hooks that run before and after every render, in order.
+func WithHooks(hooks ...pipeline.Hooks) Option {
+	return func(r *Renderer) {
+		r.hooks = append(r.hooks, hooks...)
+	}
+}
+
+// WithOwnerMode attaches the owner and componentRef from EnvSettings to every rendered resource
+// (see pipeline.ApplyOwners). The default, pipeline.OwnerNone, leaves resources untouched.
+func WithOwnerMode(mode pipeline.OwnerMode) Option {
+	return func(r *Renderer) {
+		r.owners = mode
+	}
+}
+
+// WithConflictMode reports addons that overwrite values earlier addons patched (see
+// pipeline.Provenance). The default, pipeline.ConflictIgnore, lets the last addon win silently.
+func WithConflictMode(mode pipeline.ConflictMode) Option {
+	return func(r *Renderer) {
+		r.conflicts = mode
+	}
+}
+
+// WithSchemaCache shares a schema cache between renderers, e.g. registry.Registry.SchemaCache in
+// a render service. By default each renderer caches the schemas it converts itself.
+func WithSchemaCache(cache *schema.Cache) Option {
+	return func(r *Renderer) {
+		r.schemas = cache
+	}
+}
+
+// WithApplyWaveAnnotation orders rendered resources by apply wave (see pipeline.ApplyWaves) and
+// records each wave in annotation, e.g. "argocd.argoproj.io/sync-wave". By default resources keep
+// their render order and the waves are only reported by RenderResources.
+func WithApplyWaveAnnotation(annotation string) Option {
+	return func(r *Renderer) {
+		r.waves = annotation
+	}
+}
+
+// WithScopes checks rendered resources against the scopes of their kinds and puts cluster-scoped
+// resources first (see pipeline.ApplyScopes). By default the renderer knows the built-in
+// Kubernetes kinds; pass scopes with the CustomResourceDefinitions of the cluster added for custom
+// resources, or nil to skip the checks.
+func WithScopes(scopes *pipeline.Scopes) Option {
+	return func(r *Renderer) {
+		r.scopes = scopes
+	}
 }
 
 // NewRenderer builds a component-aware renderer from the shared template engine.
-func NewRenderer(engine *template.Engine, matcher patch.Matcher) *Renderer {
-	return &Renderer{
+func NewRenderer(engine *template.Engine, matcher patch.Matcher, opts ...Option) *Renderer {
+	r := &Renderer{
 		base:    pipeline.NewRenderer(engine),
 		matcher: matcher,
+		schemas: schema.NewCache(0),
+		scopes:  pipeline.NewScopes(),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	r.base.Events = r.events
+	r.base.Hooks = r.hooks
+	r.base.Schemas = r.schemas
+	return r
 }
 
 // RenderAll renders base resources and sequentially applies addon instances.
@@ -35,7 +119,80 @@ func (r *Renderer) RenderAll(
 	return r.RenderWithAddonLimit(definition, component, envSettings, addonMap, additionalCtx, workload, len(component.Spec.Addons))
 }
 
-// RenderWithAddonLimit renders base resources and applies addons up to addonLimit (count from component.Spec.Addons).
+// Validate checks the spec of component and of each of its addon instances against their
+// schemas, as they would be rendered in envSettings (see pipeline.RendererCoordinates.
+// ValidateComponent). It reports every violation rather than stopping at the first one.
+func (r *Renderer) Validate(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	addonMap map[string]*types.Addon,
+) error {
+	return r.ValidateUpdate(definition, nil, nil, component, envSettings, addonMap)
+}
+
+// ValidateUpdate is Validate for component and envSettings replacing oldComponent and
+// oldEnvSettings, e.g. in an admission webhook. Specs are also checked against their previous
+// revision, so changes to immutable fields are rejected; addon instances are matched to their
+// previous revision by instance ID and addon. A nil oldComponent validates component as new.
+func (r *Renderer) ValidateUpdate(
+	definition *types.ComponentTypeDefinition,
+	oldComponent *types.Component,
+	oldEnvSettings *types.EnvSettings,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	addonMap map[string]*types.Addon,
+) error {
+	errs := []error{r.base.ValidateComponentUpdate(definition, oldComponent, oldEnvSettings, component, envSettings)}
+	instances, err := r.resolveAddons(component, envSettings, addonMap)
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	for _, instance := range instances {
+		var oldInstance *types.AddonInstance
+		if oldComponent != nil {
+			for i, candidate := range oldComponent.Spec.Addons {
+				if candidate.InstanceID == instance.InstanceID && candidate.Name == instance.Name {
+					oldInstance = &oldComponent.Spec.Addons[i]
+				}
+			}
+		}
+		errs = append(errs, r.base.ValidateAddonUpdate(addonMap[instance.Name], oldInstance, oldComponent, oldEnvSettings, instance, component, envSettings))
+	}
+	return errors.Join(errs...)
+}
+
+// RenderArtifacts renders the non-Kubernetes files declared by the definition and by every addon
+// instance attached to the component.
+func (r *Renderer) RenderArtifacts(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	addonMap map[string]*types.Addon,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+) ([]artifacts.Artifact, error) {
+	result, err := r.base.RenderArtifacts(definition, component, envSettings, additionalCtx, workload)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := r.resolveAddons(component, envSettings, addonMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		rendered, err := r.base.RenderAddonArtifacts(addonMap[instance.Name], instance, component, envSettings, additionalCtx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rendered...)
+	}
+	return result, nil
+}
+
+// RenderWithAddonLimit renders base resources and applies the first addonLimit addons in
+// dependency order (see OrderAddons).
 func (r *Renderer) RenderWithAddonLimit(
 	definition *types.ComponentTypeDefinition,
 	component *types.Component,
@@ -45,27 +202,117 @@ func (r *Renderer) RenderWithAddonLimit(
 	workload map[string]any,
 	addonLimit int,
 ) ([]map[string]any, error) {
-	resources, err := r.base.RenderComponentResources(definition, component, envSettings, additionalCtx, workload)
+	rendered, err := r.RenderResources(definition, component, envSettings, addonMap, additionalCtx, workload, addonLimit)
 	if err != nil {
 		return nil, err
 	}
 
-	if addonLimit < 0 || addonLimit > len(component.Spec.Addons) {
-		addonLimit = len(component.Spec.Addons)
+	resources := make([]map[string]any, len(rendered))
+	for i, resource := range rendered {
+		resources[i] = resource.Object
 	}
+	return resources, nil
+}
 
-	for i := 0; i < addonLimit; i++ {
-		instance := component.Spec.Addons[i]
-		addon, ok := addonMap[instance.Name]
-		if !ok {
-			return nil, fmt.Errorf("addon %s not found", instance.Name)
-		}
+// RenderResources behaves like RenderWithAddonLimit but also returns the per-resource options
+// requested through recognized annotations (see pipeline.ExtractResourceOptions).
+func (r *Renderer) RenderResources(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	addonMap map[string]*types.Addon,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+	addonLimit int,
+) ([]pipeline.RenderedResource, error) {
+	instances, err := r.resolveAddons(component, envSettings, addonMap)
+	if err != nil {
+		return nil, err
+	}
+	r.report(ProgressEvent{Type: ProgressStageStarted, Stage: StageBase})
+	resources, err := r.base.RenderComponentResources(definition, component, envSettings, additionalCtx, workload)
+	if err != nil {
+		return nil, err
+	}
+	r.reportResources(StageBase, resources)
+
+	if addonLimit < 0 || addonLimit > len(instances) {
+		addonLimit = len(instances)
+	}
+	for _, instance := range instances[addonLimit:] {
+		events.Normalf(r.events, component, events.ReasonAddonSkipped,
+			"addon %s (instance %s) skipped: render limited to the first %d addons", instance.Name, instance.InstanceID, addonLimit)
+	}
 
-		resources, err = r.base.ApplyAddon(resources, addon, instance, component, envSettings, additionalCtx, r.matcher)
+	var provenance *pipeline.Provenance
+	if r.conflicts != pipeline.ConflictIgnore {
+		provenance = pipeline.NewProvenance()
+	}
+	for _, instance := range instances[:addonLimit] {
+		stage := "addon " + instance.Name + "/" + instance.InstanceID
+		r.report(ProgressEvent{Type: ProgressStageStarted, Stage: stage})
+		created := len(resources)
+		resources, err = r.base.ApplyAddonTracked(resources, addonMap[instance.Name], instance, component, envSettings, additionalCtx, r.matcher, provenance)
 		if err != nil {
 			return nil, err
 		}
+		r.reportResources(stage, resources[created:])
+		r.report(ProgressEvent{Type: ProgressAddonApplied, Stage: stage})
+	}
+	if err := provenance.Report(r.conflicts, r.events, component); err != nil {
+		return nil, err
 	}
 
-	return resources, nil
+	if len(r.hooks) > 0 {
+		r.report(ProgressEvent{Type: ProgressStageStarted, Stage: StagePostRender})
+	}
+	resources, err = r.base.RunPostRenderHooks(definition, component, envSettings, resources)
+	if err != nil {
+		return nil, err
+	}
+	rendered, err := pipeline.ExtractAll(resources)
+	if err != nil {
+		return nil, err
+	}
+	if err := pipeline.ApplyScopes(rendered, r.scopes, r.events, component); err != nil {
+		return nil, err
+	}
+	if err := pipeline.ApplyOwners(rendered, envSettings, r.owners); err != nil {
+		return nil, err
+	}
+	if r.waves != "" {
+		pipeline.AnnotateApplyWaves(rendered, r.waves)
+	}
+	return rendered, nil
+}
+
+// resolveAddons orders the component's addon instances, checks their release pins and applies the
+// stability policy for the environment being rendered.
+func (r *Renderer) resolveAddons(
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	addonMap map[string]*types.Addon,
+) ([]types.AddonInstance, error) {
+	instances, err := OrderAddons(component, addonMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		if err := versioning.CheckAddonRelease(addonMap[instance.Name], instance); err != nil {
+			return nil, err
+		}
+	}
+	if r.stability == nil {
+		return instances, nil
+	}
+	environment := ""
+	if envSettings != nil {
+		environment = envSettings.Spec.Environment
+	}
+	for _, instance := range instances {
+		if err := r.stability.Check(environment, addonMap[instance.Name]); err != nil {
+			return nil, err
+		}
+	}
+	return instances, nil
 }
@@ -0,0 +1,87 @@
+package component
+
+import (
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+)
+
+// ProgressType tells what a ProgressEvent reports.
+type ProgressType string
+
+const (
+	// ProgressStageStarted starts a stage: the base resources, an addon instance or the
+	// post-render hooks.
+	ProgressStageStarted ProgressType = "StageStarted"
+	// ProgressResourceRendered reports a resource the current stage created.
+	ProgressResourceRendered ProgressType = "ResourceRendered"
+	// ProgressAddonApplied reports an addon instance whose creates and patches were applied.
+	ProgressAddonApplied ProgressType = "AddonApplied"
+	// ProgressWarning reports a Warning event of the render, such as a rejected override.
+	ProgressWarning ProgressType = "Warning"
+)
+
+// Stages of a render, as reported in ProgressEvent.Stage. Addon stages are "addon
+// <name>/<instance>".
+const (
+	StageBase       = "base"
+	StagePostRender = "post-render"
+)
+
+// ProgressEvent is a step of a render in progress.
+type ProgressEvent struct {
+	Type  ProgressType `json:"type"`
+	Stage string       `json:"stage,omitempty"`
+	// Resource is the Kind/name of a rendered resource.
+	Resource string `json:"resource,omitempty"`
+	// Reason and Message describe a warning.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ProgressFunc receives the steps of a render in order, on the goroutine rendering.
+type ProgressFunc func(ProgressEvent)
+
+// WithProgress returns a renderer that reports the steps of its renders to progress, e.g. to
+// stream them to a UI, and otherwise behaves like r. Warnings still reach r's event sink. r is
+// not modified, so a shared renderer can report each call to its own caller.
+func (r *Renderer) WithProgress(progress ProgressFunc) *Renderer {
+	observed := *r
+	base := *r.base
+	observed.events = &progressSink{next: r.events, progress: progress}
+	base.Events = observed.events
+	observed.base = &base
+	observed.progress = progress
+	return &observed
+}
+
+// report sends event to the progress function, if any.
+func (r *Renderer) report(event ProgressEvent) {
+	if r.progress != nil {
+		r.progress(event)
+	}
+}
+
+// reportResources reports each of resources as rendered by stage.
+func (r *Renderer) reportResources(stage string, resources []map[string]any) {
+	if r.progress == nil {
+		return
+	}
+	for _, resource := range resources {
+		r.progress(ProgressEvent{Type: ProgressResourceRendered, Stage: stage, Resource: pipeline.ResourceName(resource)})
+	}
+}
+
+// progressSink forwards events to next and reports warnings as progress.
+type progressSink struct {
+	next     events.Sink
+	progress ProgressFunc
+}
+
+func (s *progressSink) Emit(event events.Event) {
+	if s.next != nil {
+		s.next.Emit(event)
+	}
+	if event.Type == events.TypeWarning {
+		s.progress(ProgressEvent{Type: ProgressWarning, Reason: event.Reason, Message: event.Message})
+	}
+}
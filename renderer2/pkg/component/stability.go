@@ -0,0 +1,85 @@
+package component
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// AnyEnvironment keys StabilityPolicy entries that apply to every environment.
+const AnyEnvironment = "*"
+
+// StabilityPolicy gates addons by release channel per environment, keyed by
+// EnvSettings.spec.environment. Renders without EnvSettings are not gated.
+type StabilityPolicy struct {
+	// Minimum is the lowest stability accepted in an environment. The AnyEnvironment entry applies
+	// to environments without their own.
+	Minimum map[string]types.Stability
+	// Allow lists addons accepted in an environment regardless of their stability. AnyEnvironment
+	// entries apply everywhere.
+	Allow map[string][]string
+}
+
+// DefaultStabilityPolicy keeps alpha addons out of production.
+func DefaultStabilityPolicy() StabilityPolicy {
+	return StabilityPolicy{Minimum: map[string]types.Stability{"production": types.StabilityBeta}}
+}
+
+// WithStabilityPolicy rejects addons below the environment's minimum stability when resolving a
+// Component's addons. Without it every addon is accepted.
+func WithStabilityPolicy(policy StabilityPolicy) Option {
+	return func(r *Renderer) {
+		r.stability = &policy
+	}
+}
+
+// Check returns an error when environment does not accept addon.
+func (p StabilityPolicy) Check(environment string, addon *types.Addon) error {
+	stability := addon.Spec.Stability
+	if stability == "" {
+		stability = types.StabilityStable
+	}
+	rank, err := stabilityRank(stability)
+	if err != nil {
+		return fmt.Errorf("addon %s: %w", addon.Metadata.Name, err)
+	}
+	if environment == "" {
+		return nil
+	}
+
+	minimum, ok := p.Minimum[environment]
+	if !ok {
+		minimum, ok = p.Minimum[AnyEnvironment]
+	}
+	if !ok {
+		return nil
+	}
+	minimumRank, err := stabilityRank(minimum)
+	if err != nil {
+		return fmt.Errorf("stability policy for %s: %w", environment, err)
+	}
+	if rank >= minimumRank {
+		return nil
+	}
+	for _, key := range []string{environment, AnyEnvironment} {
+		for _, name := range p.Allow[key] {
+			if name == addon.Metadata.Name {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("addon %s is %s but environment %s requires %s or an explicit allow", addon.Metadata.Name, stability, environment, minimum)
+}
+
+func stabilityRank(stability types.Stability) (int, error) {
+	switch stability {
+	case types.StabilityAlpha:
+		return 0, nil
+	case types.StabilityBeta:
+		return 1, nil
+	case types.StabilityStable:
+		return 2, nil
+	default:
+		return 0, fmt.Errorf("unknown stability %q (want alpha, beta or stable)", stability)
+	}
+}
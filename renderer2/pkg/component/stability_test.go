@@ -0,0 +1,54 @@
+package component
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+func TestStabilityPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := StabilityPolicy{
+		Minimum: map[string]types.Stability{"production": types.StabilityBeta, AnyEnvironment: types.StabilityAlpha},
+		Allow:   map[string][]string{"production": {"trusted"}},
+	}
+	addon := func(name string, stability types.Stability) *types.Addon {
+		return &types.Addon{Metadata: types.Metadata{Name: name}, Spec: types.AddonSpec{Stability: stability}}
+	}
+
+	tests := []struct {
+		name        string
+		environment string
+		addon       *types.Addon
+		wantErr     string
+	}{
+		{name: "alpha blocked in production", environment: "production", addon: addon("tracing", types.StabilityAlpha),
+			wantErr: "addon tracing is alpha but environment production requires beta or an explicit allow"},
+		{name: "beta accepted in production", environment: "production", addon: addon("tracing", types.StabilityBeta)},
+		{name: "unset means stable", environment: "production", addon: addon("pvc", "")},
+		{name: "allowed alpha", environment: "production", addon: addon("trusted", types.StabilityAlpha)},
+		{name: "wildcard minimum", environment: "dev", addon: addon("tracing", types.StabilityAlpha)},
+		{name: "no environment", addon: addon("tracing", types.StabilityAlpha)},
+		{name: "unknown stability", environment: "dev", addon: addon("tracing", "experimental"),
+			wantErr: `addon tracing: unknown stability "experimental" (want alpha, beta or stable)`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := policy.Check(tt.environment, tt.addon)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Check() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Check() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
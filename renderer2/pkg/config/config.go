@@ -0,0 +1,377 @@
+// Package config loads platform.yaml, the file carrying renderer defaults shared by a team
+// (output layout, strict modes, common labels, registries and policy bundles) so they do not have
+// to be passed as flags on every invocation.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/baseline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/execplugin"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/platform"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/transport"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the platform configuration file looked up from the working directory
+// to the repository root.
+const FileName = "platform.yaml"
+
+// EnvVar names the environment variable pointing at a platform configuration file.
+const EnvVar = "PLATFORM_CONFIG"
+
+// Config is the content of platform.yaml.
+type Config struct {
+	Output       Output            `yaml:"output,omitempty"`
+	Strict       Strict            `yaml:"strict,omitempty"`
+	Lockfile     string            `yaml:"lockfile,omitempty"`
+	CommonLabels map[string]string `yaml:"commonLabels,omitempty"`
+	Registries   Registries        `yaml:"registries,omitempty"`
+	Policies     []Policy          `yaml:"policies,omitempty"`
+	// AddonStability overrides the default addon stability gating (see component.StabilityPolicy).
+	AddonStability AddonStability `yaml:"addonStability,omitempty"`
+	// Remote configures retries and timeouts of registry and other remote requests.
+	Remote transport.Options `yaml:"remote,omitempty"`
+	// AuditLog appends a JSON line per render invocation to this file (see -audit-log).
+	AuditLog string `yaml:"auditLog,omitempty"`
+	// Baseline configures the check command.
+	Baseline Baseline `yaml:"baseline,omitempty"`
+
+	// Sources lists the files the configuration was read from, lowest precedence first.
+	Sources []string `yaml:"-"`
+}
+
+// Output controls where and how rendered resources are written.
+type Output struct {
+	Dir       string `yaml:"dir,omitempty"`
+	Format    string `yaml:"format,omitempty"`
+	OwnerRefs string `yaml:"ownerRefs,omitempty"`
+	Header    string `yaml:"header,omitempty"`
+	KeyOrder  string `yaml:"keyOrder,omitempty"`
+}
+
+// Strict turns on checks that make renders fail instead of degrade.
+type Strict struct {
+	HardenSecurity bool `yaml:"hardenSecurity,omitempty"`
+	Frozen         bool `yaml:"frozen,omitempty"`
+	VerifyRuns     int  `yaml:"verifyRuns,omitempty"`
+	VerifyShuffle  bool `yaml:"verifyShuffle,omitempty"`
+	// AddonConflicts is ignore, warn or error (see -addon-conflicts).
+	AddonConflicts string `yaml:"addonConflicts,omitempty"`
+}
+
+// Registries configures how image tags are resolved to digests.
+type Registries struct {
+	// PlainHTTP lists registry hosts reached over http instead of https.
+	PlainHTTP []string `yaml:"plainHTTP,omitempty"`
+	// Offline fails on images missing from the lockfile instead of contacting registries.
+	Offline bool `yaml:"offline,omitempty"`
+}
+
+// Baseline names the environment other environments are checked against and adjusts the rules
+// flagging risky deltas (see baseline.DefaultRules).
+type Baseline struct {
+	Environment string `yaml:"environment,omitempty"`
+	// Rules are added to the default rules, replacing defaults with the same name.
+	Rules []baseline.Rule `yaml:"rules,omitempty"`
+	// Disable lists rules, default or not, that are not applied.
+	Disable []string `yaml:"disable,omitempty"`
+}
+
+// AddonStability sets the minimum addon stability and the addons allowed regardless of it, both
+// keyed by environment name or "*" for every environment.
+type AddonStability struct {
+	Minimum map[string]types.Stability `yaml:"minimum,omitempty"`
+	Allow   map[string][]string        `yaml:"allow,omitempty"`
+}
+
+// Policy is an exec plugin run as a transformer after every render. It rejects a render by
+// answering with an error and may also rewrite the resources.
+type Policy struct {
+	Name    string         `yaml:"name"`
+	Command []string       `yaml:"command"`
+	Config  map[string]any `yaml:"config,omitempty"`
+	// Env lists environment variables passed through to the plugin.
+	Env     []string      `yaml:"env,omitempty"`
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Find returns the configuration files to load, lowest precedence first: explicit when set, else
+// the file named by $PLATFORM_CONFIG, else every platform.yaml from the repository root (the
+// nearest directory holding .git) down to dir.
+func Find(explicit, dir string) ([]string, error) {
+	if explicit != "" {
+		return []string{explicit}, nil
+	}
+	if path := os.Getenv(EnvVar); path != "" {
+		return []string{path}, nil
+	}
+
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+	var dirs []string
+	for current := dir; ; {
+		dirs = append(dirs, current)
+		if _, err := os.Stat(filepath.Join(current, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			// Outside a repository only the starting directory is searched.
+			dirs = dirs[:1]
+			break
+		}
+		current = parent
+	}
+
+	var files []string
+	for i := len(dirs) - 1; i >= 0; i-- {
+		path := filepath.Join(dirs[i], FileName)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+	}
+	return files, nil
+}
+
+// Resolve loads and merges the files Find returns. Without any file it returns an empty Config.
+func Resolve(explicit, dir string) (*Config, error) {
+	files, err := Find(explicit, dir)
+	if err != nil {
+		return nil, err
+	}
+	result := &Config{}
+	for _, path := range files {
+		cfg, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		result.merge(cfg)
+	}
+	return result, nil
+}
+
+// Load reads a single configuration file. Unknown fields are rejected, and relative paths are
+// resolved against the file's directory.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	cfg := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for i, policy := range cfg.Policies {
+		if policy.Name == "" || len(policy.Command) == 0 {
+			return nil, fmt.Errorf("%s: policy %d needs a name and a command", path, i)
+		}
+	}
+	if err := baseline.Validate(cfg.Baseline.Rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	base := filepath.Dir(path)
+	cfg.Output.Dir = resolvePath(base, cfg.Output.Dir)
+	cfg.Lockfile = resolvePath(base, cfg.Lockfile)
+	cfg.AuditLog = resolvePath(base, cfg.AuditLog)
+	for i := range cfg.Policies {
+		// Bare command names are looked up in PATH; only explicit paths are relative to the file.
+		if command := cfg.Policies[i].Command[0]; strings.ContainsRune(command, filepath.Separator) {
+			cfg.Policies[i].Command[0] = resolvePath(base, command)
+		}
+	}
+	cfg.Sources = []string{path}
+	return cfg, nil
+}
+
+// merge layers other over c: set scalars override, strict modes can only be turned on, labels
+// and minimum stabilities merge by key, registry hosts, allowed addons and disabled baseline rules
+// accumulate, and same-named policies and baseline rules are replaced.
+func (c *Config) merge(other *Config) {
+	if other.Output.Dir != "" {
+		c.Output.Dir = other.Output.Dir
+	}
+	if other.Output.Format != "" {
+		c.Output.Format = other.Output.Format
+	}
+	if other.Output.OwnerRefs != "" {
+		c.Output.OwnerRefs = other.Output.OwnerRefs
+	}
+	if other.Output.Header != "" {
+		c.Output.Header = other.Output.Header
+	}
+	if other.Output.KeyOrder != "" {
+		c.Output.KeyOrder = other.Output.KeyOrder
+	}
+	c.Strict.HardenSecurity = c.Strict.HardenSecurity || other.Strict.HardenSecurity
+	c.Strict.Frozen = c.Strict.Frozen || other.Strict.Frozen
+	c.Strict.VerifyShuffle = c.Strict.VerifyShuffle || other.Strict.VerifyShuffle
+	if other.Strict.VerifyRuns != 0 {
+		c.Strict.VerifyRuns = other.Strict.VerifyRuns
+	}
+	if other.Strict.AddonConflicts != "" {
+		c.Strict.AddonConflicts = other.Strict.AddonConflicts
+	}
+	if other.Lockfile != "" {
+		c.Lockfile = other.Lockfile
+	}
+	if other.AuditLog != "" {
+		c.AuditLog = other.AuditLog
+	}
+	if other.Remote.Attempts != 0 {
+		c.Remote.Attempts = other.Remote.Attempts
+	}
+	if other.Remote.InitialBackoff != 0 {
+		c.Remote.InitialBackoff = other.Remote.InitialBackoff
+	}
+	if other.Remote.MaxBackoff != 0 {
+		c.Remote.MaxBackoff = other.Remote.MaxBackoff
+	}
+	if other.Remote.Timeout != 0 {
+		c.Remote.Timeout = other.Remote.Timeout
+	}
+	for key, value := range other.CommonLabels {
+		if c.CommonLabels == nil {
+			c.CommonLabels = map[string]string{}
+		}
+		c.CommonLabels[key] = value
+	}
+	c.Registries.PlainHTTP = append(c.Registries.PlainHTTP, other.Registries.PlainHTTP...)
+	c.Registries.Offline = c.Registries.Offline || other.Registries.Offline
+	for _, policy := range other.Policies {
+		replaced := false
+		for i := range c.Policies {
+			if c.Policies[i].Name == policy.Name {
+				c.Policies[i], replaced = policy, true
+			}
+		}
+		if !replaced {
+			c.Policies = append(c.Policies, policy)
+		}
+	}
+	if other.Baseline.Environment != "" {
+		c.Baseline.Environment = other.Baseline.Environment
+	}
+	for _, rule := range other.Baseline.Rules {
+		replaced := false
+		for i := range c.Baseline.Rules {
+			if c.Baseline.Rules[i].Name == rule.Name {
+				c.Baseline.Rules[i], replaced = rule, true
+			}
+		}
+		if !replaced {
+			c.Baseline.Rules = append(c.Baseline.Rules, rule)
+		}
+	}
+	c.Baseline.Disable = append(c.Baseline.Disable, other.Baseline.Disable...)
+	for environment, minimum := range other.AddonStability.Minimum {
+		if c.AddonStability.Minimum == nil {
+			c.AddonStability.Minimum = map[string]types.Stability{}
+		}
+		c.AddonStability.Minimum[environment] = minimum
+	}
+	for environment, names := range other.AddonStability.Allow {
+		if c.AddonStability.Allow == nil {
+			c.AddonStability.Allow = map[string][]string{}
+		}
+		c.AddonStability.Allow[environment] = append(c.AddonStability.Allow[environment], names...)
+	}
+	c.Sources = append(c.Sources, other.Sources...)
+}
+
+// StabilityPolicy layers the configured addon stability settings over
+// component.DefaultStabilityPolicy.
+func (c *Config) StabilityPolicy() component.StabilityPolicy {
+	policy := component.DefaultStabilityPolicy()
+	policy.Allow = map[string][]string{}
+	for environment, minimum := range c.AddonStability.Minimum {
+		policy.Minimum[environment] = minimum
+	}
+	for environment, names := range c.AddonStability.Allow {
+		policy.Allow[environment] = append([]string(nil), names...)
+	}
+	return policy
+}
+
+// BaselineRules returns the rules the check command applies.
+func (c *Config) BaselineRules() []baseline.Rule {
+	return baseline.Rules(c.Baseline.Rules, c.Baseline.Disable)
+}
+
+// Flags returns the configured values of render command-line flags, keyed by flag name. Callers
+// apply them to flags not given explicitly, so the command line always wins.
+func (c *Config) Flags() map[string]string {
+	flags := map[string]string{}
+	set := func(name, value string) {
+		if value != "" {
+			flags[name] = value
+		}
+	}
+	setBool := func(name string, value bool) {
+		if value {
+			flags[name] = "true"
+		}
+	}
+	set("output-dir", c.Output.Dir)
+	set("format", c.Output.Format)
+	set("owner-refs", c.Output.OwnerRefs)
+	set("header", c.Output.Header)
+	set("key-order", c.Output.KeyOrder)
+	set("lockfile", c.Lockfile)
+	set("audit-log", c.AuditLog)
+	set("addon-conflicts", c.Strict.AddonConflicts)
+	set("baseline", c.Baseline.Environment)
+	setBool("harden-security", c.Strict.HardenSecurity)
+	setBool("frozen", c.Strict.Frozen)
+	setBool("verify-shuffle", c.Strict.VerifyShuffle)
+	setBool("image-offline", c.Registries.Offline)
+	if c.Strict.VerifyRuns != 0 {
+		flags["verify-runs"] = strconv.Itoa(c.Strict.VerifyRuns)
+	}
+	return flags
+}
+
+// Hooks returns the post-render hooks the configuration asks for: common labels, then policies in
+// declaration order.
+func (c *Config) Hooks() []pipeline.Hooks {
+	var hooks []pipeline.Hooks
+	if len(c.CommonLabels) > 0 {
+		hooks = append(hooks, platform.CommonLabels{Labels: c.CommonLabels})
+	}
+	for _, policy := range c.Policies {
+		hooks = append(hooks, &execplugin.Plugin{
+			PluginName: policy.Name,
+			Mode:       execplugin.ModeTransformer,
+			Command:    policy.Command,
+			Config:     policy.Config,
+			Timeout:    policy.Timeout,
+			Sandbox:    execplugin.Sandbox{Env: policy.Env},
+		})
+	}
+	return hooks
+}
+
+func resolvePath(base, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
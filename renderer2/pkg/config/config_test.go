@@ -0,0 +1,119 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveHierarchy(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	team := filepath.Join(root, "teams", "payments")
+	writeFile(t, filepath.Join(root, FileName), `
+output:
+  dir: out
+  format: yaml
+strict:
+  hardenSecurity: true
+commonLabels:
+  managed-by: renderer2
+  team: platform
+policies:
+  - name: no-latest
+    command: [./policies/no-latest]
+baseline:
+  environment: prod
+  rules:
+    - name: replica-reduction
+      path: spec.replicas
+      check: decrease
+      severity: warning
+`)
+	writeFile(t, filepath.Join(team, FileName), `
+output:
+  format: terraform
+strict:
+  verifyRuns: 3
+commonLabels:
+  team: payments
+policies:
+  - name: cost
+    command: [cost-policy, --strict]
+baseline:
+  disable: [removed-readiness-probe]
+`)
+
+	files, err := Find("", team)
+	if err != nil {
+		t.Fatalf("Find() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{filepath.Join(root, FileName), filepath.Join(team, FileName)}, files); diff != "" {
+		t.Errorf("Find() mismatch (-want +got):\n%s", diff)
+	}
+
+	cfg, err := Resolve("", team)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	wantFlags := map[string]string{
+		"output-dir":      filepath.Join(root, "out"),
+		"format":          "terraform",
+		"harden-security": "true",
+		"verify-runs":     "3",
+		"baseline":        "prod",
+	}
+	if diff := cmp.Diff(wantFlags, cfg.Flags()); diff != "" {
+		t.Errorf("Flags() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"managed-by": "renderer2", "team": "payments"}, cfg.CommonLabels); diff != "" {
+		t.Errorf("CommonLabels mismatch (-want +got):\n%s", diff)
+	}
+	commands := [][]string{}
+	for _, policy := range cfg.Policies {
+		commands = append(commands, policy.Command)
+	}
+	wantCommands := [][]string{{filepath.Join(root, "policies", "no-latest")}, {"cost-policy", "--strict"}}
+	if diff := cmp.Diff(wantCommands, commands); diff != "" {
+		t.Errorf("policy commands mismatch (-want +got):\n%s", diff)
+	}
+	rules := map[string]string{}
+	for _, rule := range cfg.BaselineRules() {
+		rules[rule.Name] = string(rule.Severity)
+	}
+	if _, ok := rules["removed-readiness-probe"]; ok {
+		t.Errorf("BaselineRules() kept disabled rule removed-readiness-probe")
+	}
+	if got := rules["replica-reduction"]; got != "warning" {
+		t.Errorf("BaselineRules() replica-reduction severity = %q, want the configured warning", got)
+	}
+	if got := len(cfg.Hooks()); got != 3 {
+		t.Errorf("Hooks() returned %d hooks, want 3", got)
+	}
+}
+
+func TestLoadRejectsUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), FileName)
+	writeFile(t, path, "output:\n  directory: out\n")
+	if _, err := Load(path); err == nil {
+		t.Fatalf("Load() accepted an unknown field")
+	}
+}
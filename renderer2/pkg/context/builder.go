@@ -24,9 +24,12 @@ func BuildComponentContext(
 	}
 
 	ctx := map[string]any{
-		"metadata": buildMetadata(component.Metadata),
-		"spec":     spec,
-		"build":    buildFromComponent(component.Spec.Build, additionalCtx),
+		"metadata":      buildMetadata(component.Metadata),
+		"spec":          spec,
+		"build":         buildFromComponent(component.Spec.Build, additionalCtx),
+		"componentType": component.Spec.ComponentType,
+		"environment":   buildEnvironment(envSettings),
+		"platform":      buildPlatform(envSettings),
 	}
 
 	if workload != nil {
@@ -64,10 +67,13 @@ func BuildAddonContext(
 	}
 
 	ctx := map[string]any{
-		"metadata":   buildMetadata(component.Metadata),
-		"spec":       config,
-		"instanceId": addonInstance.InstanceID,
-		"build":      buildFromComponent(component.Spec.Build, additionalCtx),
+		"metadata":      buildMetadata(component.Metadata),
+		"spec":          config,
+		"instanceId":    addonInstance.InstanceID,
+		"build":         buildFromComponent(component.Spec.Build, additionalCtx),
+		"componentType": component.Spec.ComponentType,
+		"environment":   buildEnvironment(envSettings),
+		"platform":      buildPlatform(envSettings),
 	}
 
 	if additionalCtx != nil {
@@ -79,6 +85,33 @@ func BuildAddonContext(
 	return ctx
 }
 
+// buildEnvironment describes the environment being rendered so templates can branch on it. Renders
+// without EnvSettings get an empty name and no labels.
+func buildEnvironment(envSettings *types.EnvSettings) map[string]any {
+	if envSettings == nil {
+		return map[string]any{
+			"name":        "",
+			"labels":      map[string]string{},
+			"annotations": map[string]string{},
+		}
+	}
+	return map[string]any{
+		"name":        envSettings.Spec.Environment,
+		"labels":      cloneStringMap(envSettings.Metadata.Labels),
+		"annotations": cloneStringMap(envSettings.Metadata.Annotations),
+	}
+}
+
+// buildPlatform exposes the target OS and architecture, empty when EnvSettings does not set them.
+func buildPlatform(envSettings *types.EnvSettings) map[string]any {
+	platform := map[string]any{"os": "", "arch": ""}
+	if envSettings != nil && envSettings.Spec.Platform != nil {
+		platform["os"] = envSettings.Spec.Platform.OS
+		platform["arch"] = envSettings.Spec.Platform.Arch
+	}
+	return platform
+}
+
 func buildMetadata(md types.Metadata) map[string]any {
 	return map[string]any{
 		"name":        md.Name,
@@ -0,0 +1,68 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuildEnvironment(t *testing.T) {
+	t.Parallel()
+
+	component := &types.Component{Metadata: types.Metadata{Name: "web"}}
+	settings := func() *types.EnvSettings {
+		return &types.EnvSettings{
+			Metadata: types.Metadata{
+				Name:        "web-prod",
+				Labels:      map[string]string{"tier": "production"},
+				Annotations: map[string]string{"owner": "payments"},
+			},
+			Spec: types.EnvSettingsSpec{Environment: "prod"},
+		}
+	}
+	builders := map[string]func(*types.EnvSettings) map[string]any{
+		"component": func(env *types.EnvSettings) map[string]any {
+			return BuildComponentContext(component, env, nil, nil, nil)
+		},
+		"addon": func(env *types.EnvSettings) map[string]any {
+			return BuildAddonContext(component, types.AddonInstance{InstanceID: "one"}, env, nil, nil)
+		},
+	}
+
+	for name, build := range builders {
+		name, build := name, build
+		t.Run(name+" with EnvSettings", func(t *testing.T) {
+			t.Parallel()
+
+			env := settings()
+			got := build(env)["environment"]
+			want := map[string]any{
+				"name":        "prod",
+				"labels":      map[string]string{"tier": "production"},
+				"annotations": map[string]string{"owner": "payments"},
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("environment mismatch (-want +got):\n%s", diff)
+			}
+
+			// The maps are copies, so templates cannot reach back into the EnvSettings.
+			got.(map[string]any)["labels"].(map[string]string)["tier"] = "changed"
+			if env.Metadata.Labels["tier"] != "production" {
+				t.Errorf("EnvSettings labels were modified through the context")
+			}
+		})
+		t.Run(name+" without EnvSettings", func(t *testing.T) {
+			t.Parallel()
+
+			want := map[string]any{
+				"name":        "",
+				"labels":      map[string]string{},
+				"annotations": map[string]string{},
+			}
+			if diff := cmp.Diff(want, build(nil)["environment"]); diff != "" {
+				t.Errorf("environment mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package context
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+)
+
+// Site names a place in a definition or addon where expressions are evaluated. Sites differ in the
+// variables they see: addon templates see instanceId instead of workload, and patches add the
+// resource being patched.
+type Site string
+
+const (
+	// SiteTemplate is a base resource template of a ComponentTypeDefinition.
+	SiteTemplate Site = "template"
+	// SiteTemplateIncludeWhen is the includeWhen of a base resource.
+	SiteTemplateIncludeWhen Site = "template-include-when"
+	// SiteTemplateForEach is the forEach of a base resource.
+	SiteTemplateForEach Site = "template-for-each"
+	// SiteTemplateFieldsWhen is a fieldsWhen condition of a base resource.
+	SiteTemplateFieldsWhen Site = "template-fields-when"
+	// SiteCommonMetadata is a label or annotation of a definition's commonMetadata.
+	SiteCommonMetadata Site = "common-metadata"
+	// SiteFragment is the body of a definition fragment, rendered by fragment(name, args).
+	SiteFragment Site = "fragment"
+	// SiteAddonCreate is the template of an addon create.
+	SiteAddonCreate Site = "addon-create"
+	// SiteAddonCreateIncludeWhen is the includeWhen of an addon create.
+	SiteAddonCreateIncludeWhen Site = "addon-create-include-when"
+	// SiteAddonCreateForEach is the forEach of an addon create.
+	SiteAddonCreateForEach Site = "addon-create-for-each"
+	// SiteAddonPatch is a patch operation: its path, segments and value.
+	SiteAddonPatch Site = "addon-patch"
+	// SiteAddonPatchForEach is the forEach of an addon patch.
+	SiteAddonPatchForEach Site = "addon-patch-for-each"
+	// SiteAddonPatchWhere is the target.where of an addon patch.
+	SiteAddonPatchWhere Site = "addon-patch-where"
+	// SiteAddonPatchFilter is a CEL array filter in a patch path, e.g. [?(${item.name == "app"})].
+	SiteAddonPatchFilter Site = "addon-patch-filter"
+)
+
+// Variable is an input variable visible at a site.
+type Variable struct {
+	Name string `json:"name" yaml:"name"`
+	// Type is the CEL type of the variable. spec is checked against the definition or addon
+	// schema (see template.Engine.Typed); everything else is a plain map or dyn.
+	Type        string `json:"type" yaml:"type"`
+	Description string `json:"description" yaml:"description"`
+	// Condition tells when the variable is bound; empty when it always is.
+	Condition string `json:"condition,omitempty" yaml:"condition,omitempty"`
+}
+
+// SiteInfo lists the variables expressions at a site can reference.
+type SiteInfo struct {
+	Site        Site       `json:"site" yaml:"site"`
+	Description string     `json:"description" yaml:"description"`
+	Variables   []Variable `json:"variables" yaml:"variables"`
+}
+
+// Description is a SiteInfo together with the functions of the engine rendering it.
+type Description struct {
+	SiteInfo  `yaml:",inline"`
+	Functions []template.Function `json:"functions" yaml:"functions"`
+}
+
+// The variables below mirror BuildComponentContext, BuildAddonContext and the variables the
+// pipeline and patch packages bind per item and per target.
+var (
+	metadataVar = Variable{Name: "metadata", Type: "map(string, dyn)",
+		Description: "Component metadata: name, namespace, labels and annotations"}
+	componentSpecVar = Variable{Name: "spec", Type: "object",
+		Description: "Component parameters merged over schema defaults, then EnvSettings overrides; typed by the definition schema"}
+	addonSpecVar = Variable{Name: "spec", Type: "object",
+		Description: "addon instance config merged over schema defaults, then EnvSettings addonOverrides; typed by the addon schema"}
+	instanceIDVar = Variable{Name: "instanceId", Type: "string",
+		Description: "instanceId of the addon instance being applied"}
+	buildVar = Variable{Name: "build", Type: "map(string, dyn)",
+		Description: "build.image from the additional context or the Component; empty when neither sets it"}
+	componentTypeVar = Variable{Name: "componentType", Type: "string",
+		Description: "componentType of the Component"}
+	environmentVar = Variable{Name: "environment", Type: "map(string, dyn)",
+		Description: "name, labels and annotations of the EnvSettings; empty without EnvSettings"}
+	platformVar = Variable{Name: "platform", Type: "map(string, dyn)",
+		Description: "target os and arch from EnvSettings or -os/-arch; empty when unset"}
+	workloadVar = Variable{Name: "workload", Type: "map(string, dyn)",
+		Description: "workload descriptor of the Component", Condition: "the render is given a workload"}
+	podSelectorsVar = Variable{Name: "podSelectors", Type: "map(string, dyn)",
+		Description: "pod selector labels", Condition: "additional context is given"}
+	configurationsVar = Variable{Name: "configurations", Type: "map(string, dyn)",
+		Description: "configuration envs (name, value) and files (name, mountPath, content)", Condition: "additional context is given"}
+	secretsVar = Variable{Name: "secrets", Type: "map(string, dyn)",
+		Description: "secret envs (name, valueRef) and files (name, mountPath, valueRef)", Condition: "additional context is given"}
+	renderedResourcesVar = Variable{Name: "resources", Type: "map(string, dyn)",
+		Description: "resources other base templates rendered, by template id: the resource, or the list of instances of a forEach template; templates it reads render first",
+		Condition:   "a base template of the definition reads resources"}
+	forEachItemVar = Variable{Name: "item", Type: "dyn",
+		Description: "current element of the forEach list; named by var when set", Condition: "forEach is set"}
+	resourceVar = Variable{Name: "resource", Type: "map(string, dyn)",
+		Description: "resource being matched or patched"}
+	allResourcesVar = Variable{Name: "allResources", Type: "list(dyn)",
+		Description: "every resource rendered so far, including earlier addons' creates"}
+	fragmentArgsVar = Variable{Name: "args", Type: "dyn",
+		Description: "second argument of the fragment() call; an empty map when it is omitted"}
+	filterItemVar = Variable{Name: "item", Type: "dyn",
+		Description: "array element the filter tests; a patch forEach variable is visible too unless it is also named item"}
+)
+
+func componentVariables() []Variable {
+	return []Variable{metadataVar, componentSpecVar, buildVar, componentTypeVar, environmentVar, platformVar,
+		workloadVar, podSelectorsVar, configurationsVar, secretsVar}
+}
+
+func addonVariables() []Variable {
+	return []Variable{metadataVar, addonSpecVar, instanceIDVar, buildVar, componentTypeVar, environmentVar, platformVar,
+		podSelectorsVar, configurationsVar, secretsVar}
+}
+
+func with(vars []Variable, extra ...Variable) []Variable {
+	return append(vars, extra...)
+}
+
+var sites = []SiteInfo{
+	{Site: SiteTemplate, Description: "base resource template of a ComponentTypeDefinition",
+		Variables: with(componentVariables(), renderedResourcesVar, forEachItemVar)},
+	{Site: SiteTemplateIncludeWhen, Description: "includeWhen of a base resource, evaluated before forEach",
+		Variables: with(componentVariables(), renderedResourcesVar)},
+	{Site: SiteTemplateForEach, Description: "forEach of a base resource",
+		Variables: with(componentVariables(), renderedResourcesVar)},
+	{Site: SiteTemplateFieldsWhen, Description: "fieldsWhen condition of a base resource, evaluated per rendered instance",
+		Variables: with(componentVariables(), renderedResourcesVar, forEachItemVar)},
+	{Site: SiteCommonMetadata, Description: "label or annotation of commonMetadata, rendered once for all base resources",
+		Variables: componentVariables()},
+	{Site: SiteFragment, Description: "body of a fragment, rendered by fragment(name, args) without the inputs of the calling expression",
+		Variables: []Variable{fragmentArgsVar}},
+	{Site: SiteAddonCreate, Description: "template of an addon create",
+		Variables: with(addonVariables(), forEachItemVar)},
+	{Site: SiteAddonCreateIncludeWhen, Description: "includeWhen of an addon create, evaluated before forEach",
+		Variables: addonVariables()},
+	{Site: SiteAddonCreateForEach, Description: "forEach of an addon create",
+		Variables: addonVariables()},
+	{Site: SiteAddonPatch, Description: "path, segments and value of an addon patch operation",
+		Variables: with(addonVariables(), forEachItemVar, resourceVar)},
+	{Site: SiteAddonPatchForEach, Description: "forEach of an addon patch, evaluated once before targets are matched",
+		Variables: addonVariables()},
+	{Site: SiteAddonPatchWhere, Description: "target.where of an addon patch, evaluated per target resource",
+		Variables: with(addonVariables(), forEachItemVar, resourceVar, allResourcesVar)},
+	{Site: SiteAddonPatchFilter, Description: "CEL array filter in an addon patch path, evaluated per array element",
+		Variables: with(addonVariables(), resourceVar, filterItemVar)},
+}
+
+// Sites lists every expression site.
+func Sites() []SiteInfo {
+	result := make([]SiteInfo, len(sites))
+	for i, site := range sites {
+		result[i] = site
+		result[i].Variables = append([]Variable(nil), site.Variables...)
+	}
+	return result
+}
+
+// LookupSite returns the variables of a site.
+func LookupSite(site Site) (SiteInfo, bool) {
+	for _, info := range Sites() {
+		if info.Site == site {
+			return info, true
+		}
+	}
+	return SiteInfo{}, false
+}
+
+// Describe lists the variables and functions valid at site for expressions rendered by engine.
+// Variables registered on the engine (WithVariable, WithContextValue) are added as dyn unless an
+// input of the same name hides them.
+func Describe(engine *template.Engine, site Site) (*Description, error) {
+	info, ok := LookupSite(site)
+	if !ok {
+		return nil, fmt.Errorf("unknown expression site %q", site)
+	}
+	bound := map[string]bool{}
+	for _, variable := range info.Variables {
+		bound[variable.Name] = true
+	}
+	for _, name := range engine.VariableNames() {
+		if !bound[name] {
+			info.Variables = append(info.Variables, Variable{Name: name, Type: "dyn", Description: "registered with the engine"})
+		}
+	}
+	sort.SliceStable(info.Variables, func(i, j int) bool { return info.Variables[i].Name < info.Variables[j].Name })
+
+	functions, err := engine.Functions()
+	if err != nil {
+		return nil, err
+	}
+	return &Description{SiteInfo: info, Functions: functions}, nil
+}
@@ -0,0 +1,80 @@
+package context
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestSitesMatchBuilders keeps the site catalogue in sync with the inputs the builders assemble.
+func TestSitesMatchBuilders(t *testing.T) {
+	component := &types.Component{}
+	additional := &types.AdditionalContext{}
+
+	tests := []struct {
+		site   Site
+		inputs map[string]any
+	}{
+		// The pipeline binds resources next to the built inputs when templates reference each other.
+		{SiteTemplateForEach, withInput(BuildComponentContext(component, nil, additional, map[string]any{}, nil), "resources")},
+		{SiteAddonCreateForEach, BuildAddonContext(component, types.AddonInstance{}, nil, additional, nil)},
+		{SiteAddonPatchForEach, BuildAddonContext(component, types.AddonInstance{}, nil, additional, nil)},
+	}
+	for _, tt := range tests {
+		info, ok := LookupSite(tt.site)
+		if !ok {
+			t.Fatalf("site %s not found", tt.site)
+		}
+		var want []string
+		for name := range tt.inputs {
+			want = append(want, name)
+		}
+		sort.Strings(want)
+		if diff := cmp.Diff(want, variableNames(info)); diff != "" {
+			t.Errorf("variables of %s differ from the built inputs (-built +listed):\n%s", tt.site, diff)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	engine := template.NewEngine(template.WithVariable("cluster", "prod-eu-1"), template.WithVariable("resource", "hidden"))
+
+	description, err := Describe(engine, SiteAddonPatchWhere)
+	if err != nil {
+		t.Fatalf("Describe: %v", err)
+	}
+	want := []string{"allResources", "build", "cluster", "componentType", "configurations", "environment", "instanceId",
+		"item", "metadata", "platform", "podSelectors", "resource", "secrets", "spec"}
+	if diff := cmp.Diff(want, variableNames(description.SiteInfo)); diff != "" {
+		t.Errorf("variables differ (-want +got):\n%s", diff)
+	}
+	for _, variable := range description.Variables {
+		if variable.Name == "resource" && variable.Type != "map(string, dyn)" {
+			t.Errorf("engine variable replaced the bound resource: %+v", variable)
+		}
+	}
+	if len(description.Functions) == 0 {
+		t.Error("no functions listed")
+	}
+
+	if _, err := Describe(engine, "patch"); err == nil {
+		t.Error("expected an error for an unknown site")
+	}
+}
+
+func withInput(inputs map[string]any, name string) map[string]any {
+	inputs[name] = nil
+	return inputs
+}
+
+func variableNames(info SiteInfo) []string {
+	names := make([]string, 0, len(info.Variables))
+	for _, variable := range info.Variables {
+		names = append(names, variable.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Drift compares rendered resources with their live versions and returns those changed outside
+// the platform: Changed resources whose live fields no longer hold the rendered values, and
+// Removed resources missing from the cluster. Only the fields a resource renders are compared, so
+// server defaults and fields other managers own are not drift. In Changes, Before is the rendered
+// value and After the live one.
+func Drift(rendered, live []map[string]any) []ResourceDiff {
+	byKey := map[string]map[string]any{}
+	for _, resource := range live {
+		byKey[resourceKey(resource)] = resource
+	}
+	var diffs []ResourceDiff
+	for _, resource := range rendered {
+		object, ok := byKey[resourceKey(resource)]
+		if !ok {
+			diffs = append(diffs, describe(resource, Removed))
+			continue
+		}
+		var changes []Change
+		compareRendered("", resource, object, &changes)
+		if len(changes) > 0 {
+			d := describe(resource, Changed)
+			d.Changes = changes
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// compareRendered is compareFields for a live value that may carry fields rendered does not set.
+// List items are compared index by index, and items the live list adds are reported.
+func compareRendered(path string, rendered, live any, changes *[]Change) {
+	renderedMap, renderedIsMap := rendered.(map[string]any)
+	liveMap, liveIsMap := live.(map[string]any)
+	if renderedIsMap && liveIsMap {
+		keys := make([]string, 0, len(renderedMap))
+		for key := range renderedMap {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := fieldPath(path, key)
+			value, ok := liveMap[key]
+			if !ok {
+				*changes = append(*changes, Change{Path: child, Before: renderedMap[key]})
+				continue
+			}
+			compareRendered(child, renderedMap[key], value, changes)
+		}
+		return
+	}
+
+	renderedList, renderedIsList := rendered.([]any)
+	liveList, liveIsList := live.([]any)
+	if renderedIsList && liveIsList {
+		for i := 0; i < max(len(renderedList), len(liveList)); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(renderedList):
+				*changes = append(*changes, Change{Path: child, After: liveList[i]})
+			case i >= len(liveList):
+				*changes = append(*changes, Change{Path: child, Before: renderedList[i]})
+			default:
+				compareRendered(child, renderedList[i], liveList[i], changes)
+			}
+		}
+		return
+	}
+
+	if !equalScalars(rendered, live) {
+		*changes = append(*changes, Change{Path: path, Before: rendered, After: live})
+	}
+}
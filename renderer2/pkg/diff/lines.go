@@ -0,0 +1,107 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lines returns a unified-style line diff between want and got. An empty string means the inputs
+// are identical. Only changed hunks are printed, each with up to `context` unchanged lines around it.
+func Lines(want, got string, context int) string {
+	if want == got {
+		return ""
+	}
+
+	a := splitLines(want)
+	b := splitLines(got)
+	ops := lcsOps(a, b)
+
+	var out strings.Builder
+	for start := 0; start < len(ops); {
+		// Find next change.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start >= len(ops) {
+			break
+		}
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			// Extend over short runs of unchanged lines so nearby changes share a hunk.
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run < len(ops) && run-end <= 2*context {
+				end = run
+				continue
+			}
+			break
+		}
+
+		from := max(start-context, 0)
+		to := min(end+context, len(ops))
+		fmt.Fprintf(&out, "@@ -%d +%d @@\n", ops[from].aLine+1, ops[from].bLine+1)
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+		start = to
+	}
+	return out.String()
+}
+
+type lineOp struct {
+	kind  byte
+	text  string
+	aLine int
+	bLine int
+}
+
+func lcsOps(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]lineOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			ops = append(ops, lineOp{kind: ' ', text: a[i], aLine: i, bLine: j})
+			i++
+			j++
+		case i < n && (j == m || table[i+1][j] >= table[i][j+1]):
+			ops = append(ops, lineOp{kind: '-', text: a[i], aLine: i, bLine: j})
+			i++
+		default:
+			ops = append(ops, lineOp{kind: '+', text: b[j], aLine: i, bLine: j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
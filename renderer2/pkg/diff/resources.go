@@ -0,0 +1,202 @@
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Status says how a resource differs between two sets of resources.
+type Status string
+
+const (
+	// Added resources exist only in the current set.
+	Added Status = "added"
+	// Removed resources exist only in the previous set.
+	Removed Status = "removed"
+	// Changed resources exist in both sets with different fields.
+	Changed Status = "changed"
+)
+
+// Change is a field whose value differs. Before is nil for added fields and After is nil for
+// removed ones.
+type Change struct {
+	Path   string `json:"path"`
+	Before any    `json:"before,omitempty"`
+	After  any    `json:"after,omitempty"`
+}
+
+// ResourceDiff describes one resource that differs.
+type ResourceDiff struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Namespace  string   `json:"namespace,omitempty"`
+	Name       string   `json:"name"`
+	Status     Status   `json:"status"`
+	Changes    []Change `json:"changes,omitempty"`
+}
+
+// ID names the resource as kind namespace/name (apiVersion).
+func (d ResourceDiff) ID() string {
+	name := d.Name
+	if d.Namespace != "" {
+		name = d.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s (%s)", d.Kind, name, d.APIVersion)
+}
+
+// Resources matches previous and current resources by API group, kind, namespace and name and
+// returns those that differ: changed and added resources in the order of current, then removed
+// ones in the order of previous.
+func Resources(previous, current []map[string]any) []ResourceDiff {
+	byKey := map[string]map[string]any{}
+	for _, resource := range previous {
+		byKey[resourceKey(resource)] = resource
+	}
+	var diffs []ResourceDiff
+	seen := map[string]bool{}
+	for _, resource := range current {
+		key := resourceKey(resource)
+		seen[key] = true
+		before, ok := byKey[key]
+		if !ok {
+			diffs = append(diffs, describe(resource, Added))
+			continue
+		}
+		if changes := Fields(before, resource); len(changes) > 0 {
+			d := describe(resource, Changed)
+			d.Changes = changes
+			diffs = append(diffs, d)
+		}
+	}
+	for _, resource := range previous {
+		if !seen[resourceKey(resource)] {
+			diffs = append(diffs, describe(resource, Removed))
+		}
+	}
+	return diffs
+}
+
+// Fields returns the fields that differ between two values, in path order. Maps are compared key
+// by key and lists index by index; numbers compare by value, so YAML integers equal JSON floats.
+func Fields(before, after any) []Change {
+	var changes []Change
+	compareFields("", before, after, &changes)
+	return changes
+}
+
+func compareFields(path string, before, after any, changes *[]Change) {
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if beforeIsMap && afterIsMap {
+		keys := make([]string, 0, len(beforeMap)+len(afterMap))
+		for key := range beforeMap {
+			keys = append(keys, key)
+		}
+		for key := range afterMap {
+			if _, ok := beforeMap[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			b, inBefore := beforeMap[key]
+			a, inAfter := afterMap[key]
+			child := fieldPath(path, key)
+			switch {
+			case !inBefore:
+				*changes = append(*changes, Change{Path: child, After: a})
+			case !inAfter:
+				*changes = append(*changes, Change{Path: child, Before: b})
+			default:
+				compareFields(child, b, a, changes)
+			}
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]any)
+	afterList, afterIsList := after.([]any)
+	if beforeIsList && afterIsList {
+		for i := 0; i < max(len(beforeList), len(afterList)); i++ {
+			child := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(beforeList):
+				*changes = append(*changes, Change{Path: child, After: afterList[i]})
+			case i >= len(afterList):
+				*changes = append(*changes, Change{Path: child, Before: beforeList[i]})
+			default:
+				compareFields(child, beforeList[i], afterList[i], changes)
+			}
+		}
+		return
+	}
+
+	if !equalScalars(before, after) {
+		*changes = append(*changes, Change{Path: path, Before: before, After: after})
+	}
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// fieldPath appends key to path, quoting keys that are not plain identifiers, e.g.
+// metadata.labels["app.kubernetes.io/name"].
+func fieldPath(path, key string) string {
+	if !plainKey.MatchString(key) {
+		return path + "[" + strconv.Quote(key) + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func equalScalars(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+func resourceKey(resource map[string]any) string {
+	d := describe(resource, "")
+	group, _, found := strings.Cut(d.APIVersion, "/")
+	if !found {
+		group = ""
+	}
+	return strings.Join([]string{group, d.Kind, d.Namespace, d.Name}, "\x00")
+}
+
+func describe(resource map[string]any, status Status) ResourceDiff {
+	d := ResourceDiff{Status: status}
+	d.APIVersion, _ = resource["apiVersion"].(string)
+	d.Kind, _ = resource["kind"].(string)
+	if metadata, ok := resource["metadata"].(map[string]any); ok {
+		d.Namespace, _ = metadata["namespace"].(string)
+		d.Name, _ = metadata["name"].(string)
+	}
+	return d
+}
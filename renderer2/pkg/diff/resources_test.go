@@ -0,0 +1,99 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	deployment := func(replicas any, labels map[string]any) map[string]any {
+		return map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "shop", "labels": labels},
+			"spec":       map[string]any{"replicas": replicas, "template": map[string]any{"spec": map[string]any{"containers": []any{"app"}}}},
+		}
+	}
+	service := map[string]any{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "web"}}
+	configMap := map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "web"}}
+
+	previous := []map[string]any{
+		deployment(2, map[string]any{"app.kubernetes.io/name": "web", "tier": "web"}),
+		configMap,
+	}
+	current := []map[string]any{
+		deployment(float64(3), map[string]any{"app.kubernetes.io/name": "shop"}),
+		service,
+	}
+	current[0]["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)["containers"] = []any{"app", "sidecar"}
+
+	want := []ResourceDiff{
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web", Status: Changed,
+			Changes: []Change{
+				{Path: `metadata.labels["app.kubernetes.io/name"]`, Before: "web", After: "shop"},
+				{Path: "metadata.labels.tier", Before: "web"},
+				{Path: "spec.replicas", Before: 2, After: float64(3)},
+				{Path: "spec.template.spec.containers[1]", After: "sidecar"},
+			},
+		},
+		{APIVersion: "v1", Kind: "Service", Name: "web", Status: Added},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "web", Status: Removed},
+	}
+	if diff := cmp.Diff(want, Resources(previous, current)); diff != "" {
+		t.Errorf("Resources() mismatch (-want +got):\n%s", diff)
+	}
+
+	if got := Resources(current, current); len(got) != 0 {
+		t.Errorf("Resources() of identical sets = %v, want none", got)
+	}
+	if got := Fields(map[string]any{"replicas": 3}, map[string]any{"replicas": float64(3)}); len(got) != 0 {
+		t.Errorf("Fields() = %v, want numbers of different types to be equal", got)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	rendered := []map[string]any{
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "shop", "labels": map[string]any{"tier": "web"}},
+			"spec":       map[string]any{"replicas": 2, "template": map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "app"}}}}},
+		},
+		{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "web"}, "spec": map[string]any{"type": "ClusterIP"}},
+		{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "web"}},
+	}
+	live := []map[string]any{
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web", "namespace": "shop", "uid": "1234"},
+			"spec": map[string]any{"replicas": float64(5), "strategy": "RollingUpdate", "template": map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "app", "imagePullPolicy": "Always"},
+				map[string]any{"name": "debug"},
+			}}}},
+		},
+		{"apiVersion": "v1", "kind": "Service", "metadata": map[string]any{"name": "web"}, "spec": map[string]any{"type": "ClusterIP", "clusterIP": "10.0.0.1"}},
+	}
+
+	// Server defaults and fields the platform does not render are not drift.
+	want := []ResourceDiff{
+		{
+			APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web", Status: Changed,
+			Changes: []Change{
+				{Path: "metadata.labels", Before: map[string]any{"tier": "web"}},
+				{Path: "spec.replicas", Before: 2, After: float64(5)},
+				{Path: "spec.template.spec.containers[1]", After: map[string]any{"name": "debug"}},
+			},
+		},
+		{APIVersion: "v1", Kind: "ConfigMap", Name: "web", Status: Removed},
+	}
+	if diff := cmp.Diff(want, Drift(rendered, live)); diff != "" {
+		t.Errorf("Drift() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,189 @@
+// Package engine is the entry point for services embedding renderer2. It wires the template
+// engine, addon pipeline and built-in addons together behind a single Renderer so callers do not
+// need to assemble the parser, pipeline and component packages themselves.
+package engine
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/artifacts"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/cel-go/cel"
+)
+
+// CELFunction is a custom function made available to every template expression.
+type CELFunction struct {
+	Name      string
+	Overloads []cel.FunctionOpt
+}
+
+type settings struct {
+	addons            map[string]*types.Addon
+	envSettings       *types.EnvSettings
+	additionalContext *types.AdditionalContext
+	workload          map[string]any
+	functions         []CELFunction
+	engineOptions     []template.EngineOption
+	rendererOptions   []component.Option
+}
+
+// Option configures a Renderer, or a single Render call when passed to one of its methods.
+type Option func(*settings)
+
+// WithAddons makes addons available to Components that attach them by name. The built-in
+// observability addon is always available.
+func WithAddons(addons ...*types.Addon) Option {
+	return func(s *settings) {
+		for _, addon := range addons {
+			s.addons[addon.Metadata.Name] = addon
+		}
+	}
+}
+
+// WithEnvSettings renders for the environment described by envSettings.
+func WithEnvSettings(envSettings *types.EnvSettings) Option {
+	return func(s *settings) {
+		s.envSettings = envSettings
+	}
+}
+
+// WithAdditionalContext supplies the platform context: build image, configurations and secrets.
+func WithAdditionalContext(additionalCtx *types.AdditionalContext) Option {
+	return func(s *settings) {
+		s.additionalContext = additionalCtx
+	}
+}
+
+// WithWorkload supplies the workload descriptor exposed to templates as `workload`.
+func WithWorkload(workload map[string]any) Option {
+	return func(s *settings) {
+		s.workload = workload
+	}
+}
+
+// WithCustomCELFunctions registers functions with the template engine. It applies to New only,
+// since compiled expressions are shared across calls.
+func WithCustomCELFunctions(functions ...CELFunction) Option {
+	return func(s *settings) {
+		s.functions = append(s.functions, functions...)
+	}
+}
+
+// WithFunctionRegistry registers every function of registry with the template engine, as
+// WithCustomCELFunctions does. It applies to New only.
+func WithFunctionRegistry(registry *template.Registry) Option {
+	return func(s *settings) {
+		s.engineOptions = append(s.engineOptions, template.WithRegistry(registry))
+	}
+}
+
+// WithEventSink routes render events to sink. It applies to New only.
+func WithEventSink(sink events.Sink) Option {
+	return func(s *settings) {
+		s.rendererOptions = append(s.rendererOptions, component.WithEventSink(sink))
+	}
+}
+
+// WithHooks registers render hooks, run in order around every render. It applies to New only.
+func WithHooks(hooks ...pipeline.Hooks) Option {
+	return func(s *settings) {
+		s.rendererOptions = append(s.rendererOptions, component.WithHooks(hooks...))
+	}
+}
+
+// Renderer renders Components into Kubernetes resources. It is safe for concurrent use provided
+// the registered hooks and event sink are. Options given to New are defaults that options passed
+// to Render and RenderArtifacts extend or override for that call.
+type Renderer struct {
+	renderer *component.Renderer
+	defaults settings
+}
+
+// New builds a Renderer.
+func New(opts ...Option) *Renderer {
+	s := settings{addons: map[string]*types.Addon{observability.AddonName: observability.Addon()}}
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	engineOpts := observability.EngineOptions()
+	for _, fn := range s.functions {
+		engineOpts = append(engineOpts, template.WithFunction(fn.Name, fn.Overloads...))
+	}
+	engineOpts = append(engineOpts, s.engineOptions...)
+	renderer := component.NewRenderer(template.NewEngine(engineOpts...), nil, s.rendererOptions...)
+
+	s.functions, s.engineOptions, s.rendererOptions = nil, nil, nil
+	return &Renderer{renderer: renderer, defaults: s}
+}
+
+// Render renders the definition for the component, applying every addon it attaches in order.
+func (r *Renderer) Render(ctx context.Context, definition *types.ComponentTypeDefinition, comp *types.Component, opts ...Option) ([]map[string]any, error) {
+	s, err := r.settings(opts)
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, func() ([]map[string]any, error) {
+		return r.renderer.RenderAll(definition, comp, s.envSettings, s.addons, s.additionalContext, s.workload)
+	})
+}
+
+// RenderArtifacts renders the non-Kubernetes files declared by the definition and the addons the
+// component attaches.
+func (r *Renderer) RenderArtifacts(ctx context.Context, definition *types.ComponentTypeDefinition, comp *types.Component, opts ...Option) ([]artifacts.Artifact, error) {
+	s, err := r.settings(opts)
+	if err != nil {
+		return nil, err
+	}
+	return run(ctx, func() ([]artifacts.Artifact, error) {
+		return r.renderer.RenderArtifacts(definition, comp, s.envSettings, s.addons, s.additionalContext, s.workload)
+	})
+}
+
+// settings layers per-call options over the defaults given to New.
+func (r *Renderer) settings(opts []Option) (settings, error) {
+	s := r.defaults
+	s.addons = make(map[string]*types.Addon, len(r.defaults.addons))
+	for name, addon := range r.defaults.addons {
+		s.addons[name] = addon
+	}
+	for _, opt := range opts {
+		opt(&s)
+	}
+	if len(s.functions) > 0 || len(s.rendererOptions) > 0 {
+		return settings{}, fmt.Errorf("custom CEL functions, event sinks and hooks can only be passed to New")
+	}
+	return s, nil
+}
+
+// run returns as soon as ctx is done. Rendering is CPU-bound and not interruptible, so an
+// abandoned render finishes in the background and its result is discarded.
+func run[T any](ctx context.Context, render func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := render()
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-done:
+		return res.value, res.err
+	}
+}
@@ -0,0 +1,101 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/cel-go/cel"
+	celtypes "github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testDefinition() *types.ComponentTypeDefinition {
+	return &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Schema: types.Schema{
+				Parameters: map[string]any{"replicas": "integer | default=1"},
+			},
+			Resources: []types.ResourceTemplate{{
+				ID: "config",
+				Template: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata":   map[string]any{"name": "${shout(metadata.name)}"},
+					"data":       map[string]any{"replicas": "${string(spec.replicas)}"},
+				},
+			}},
+		},
+	}
+}
+
+func testComponent() *types.Component {
+	return &types.Component{
+		Metadata: types.Metadata{Name: "app"},
+		Spec: types.ComponentSpec{
+			ComponentType: "web",
+			Parameters:    map[string]any{"replicas": 3},
+		},
+	}
+}
+
+var shout = CELFunction{
+	Name: "shout",
+	Overloads: []cel.FunctionOpt{
+		cel.Overload("shout_string", []*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(value ref.Val) ref.Val {
+				return celtypes.String(strings.ToUpper(string(value.(celtypes.String))))
+			}),
+		),
+	},
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	renderer := New(WithCustomCELFunctions(shout))
+	resources, err := renderer.Render(context.Background(), testDefinition(), testComponent())
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if len(resources) != 1 {
+		t.Fatalf("Render() returned %d resources, want 1", len(resources))
+	}
+	got := map[string]any{
+		"name":     resources[0]["metadata"].(map[string]any)["name"],
+		"replicas": resources[0]["data"].(map[string]any)["replicas"],
+	}
+	if diff := cmp.Diff(map[string]any{"name": "APP", "replicas": "3"}, got); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	t.Parallel()
+
+	renderer := New(WithCustomCELFunctions(shout))
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := renderer.Render(cancelled, testDefinition(), testComponent()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Render() with cancelled context error = %v, want %v", err, context.Canceled)
+	}
+
+	if _, err := renderer.Render(context.Background(), testDefinition(), testComponent(), WithCustomCELFunctions(shout)); err == nil {
+		t.Errorf("Render() accepted WithCustomCELFunctions per call")
+	}
+
+	comp := testComponent()
+	comp.Spec.Addons = []types.AddonInstance{{Name: "sidecar", InstanceID: "sidecar"}}
+	if _, err := renderer.Render(context.Background(), testDefinition(), comp); err == nil {
+		t.Errorf("Render() succeeded without the attached addon")
+	}
+	sidecar := &types.Addon{Metadata: types.Metadata{Name: "sidecar"}}
+	if _, err := renderer.Render(context.Background(), testDefinition(), comp, WithAddons(sidecar)); err != nil {
+		t.Errorf("Render() with per-call addon error = %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// Event types match corev1.EventTypeNormal and corev1.EventTypeWarning.
+const (
+	TypeNormal  = "Normal"
+	TypeWarning = "Warning"
+)
+
+// Reasons emitted by the renderer. They are CamelCase as required for Kubernetes Event reasons.
+const (
+	ReasonAddonSkipped        = "AddonSkipped"
+	ReasonPatchMatchedNothing = "PatchMatchedNothing"
+	ReasonEnvOverrideRejected = "EnvOverrideRejected"
+	ReasonSecurityHardened    = "SecurityContextHardened"
+	ReasonAddonConflict       = "AddonConflict"
+	ReasonDeprecatedField     = "DeprecatedField"
+	ReasonMissingNamespace    = "MissingNamespace"
+)
+
+// ObjectReference identifies the object an event is about.
+type ObjectReference struct {
+	APIVersion string
+	Kind       string
+	Name       string
+	Namespace  string
+}
+
+// Event is a Kubernetes Event-ready record of something notable that happened during rendering.
+type Event struct {
+	Type           string
+	Reason         string
+	Message        string
+	InvolvedObject ObjectReference
+}
+
+// Sink receives events as they occur. Implementations must be safe for concurrent use; a
+// controller would typically forward to its client-go EventRecorder.
+type Sink interface {
+	Emit(event Event)
+}
+
+// Discard is a Sink that drops every event.
+var Discard Sink = discard{}
+
+type discard struct{}
+
+func (discard) Emit(Event) {}
+
+// Recorder is the default Sink. It keeps every event in memory in emission order.
+type Recorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// NewRecorder creates an empty Recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Emit records the event.
+func (r *Recorder) Emit(event Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// Events returns a copy of the recorded events.
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// ComponentRef builds the involved-object reference for a Component.
+func ComponentRef(component *types.Component) ObjectReference {
+	return ObjectReference{
+		APIVersion: component.APIVersion,
+		Kind:       component.Kind,
+		Name:       component.Metadata.Name,
+		Namespace:  component.Metadata.Namespace,
+	}
+}
+
+// Warningf emits a Warning event about the Component to sink, which may be nil.
+func Warningf(sink Sink, component *types.Component, reason, format string, args ...any) {
+	if sink == nil {
+		return
+	}
+	sink.Emit(Event{
+		Type:           TypeWarning,
+		Reason:         reason,
+		Message:        fmt.Sprintf(format, args...),
+		InvolvedObject: ComponentRef(component),
+	})
+}
+
+// Normalf emits a Normal event about the Component to sink, which may be nil.
+func Normalf(sink Sink, component *types.Component, reason, format string, args ...any) {
+	if sink == nil {
+		return
+	}
+	sink.Emit(Event{
+		Type:           TypeNormal,
+		Reason:         reason,
+		Message:        fmt.Sprintf(format, args...),
+		InvolvedObject: ComponentRef(component),
+	})
+}
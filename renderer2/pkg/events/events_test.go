@@ -0,0 +1,32 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	component := &types.Component{
+		APIVersion: "openchoreo.dev/v1alpha1",
+		Kind:       "Component",
+		Metadata:   types.Metadata{Name: "checkout", Namespace: "shop"},
+	}
+	recorder := NewRecorder()
+
+	Warningf(recorder, component, ReasonPatchMatchedNothing, "patch of addon %s matched no resources", "pvc")
+	Normalf(recorder, component, ReasonAddonSkipped, "addon %s skipped", "logger")
+	Warningf(nil, component, ReasonEnvOverrideRejected, "ignored")
+
+	ref := ObjectReference{APIVersion: "openchoreo.dev/v1alpha1", Kind: "Component", Name: "checkout", Namespace: "shop"}
+	want := []Event{
+		{Type: TypeWarning, Reason: ReasonPatchMatchedNothing, Message: "patch of addon pvc matched no resources", InvolvedObject: ref},
+		{Type: TypeNormal, Reason: ReasonAddonSkipped, Message: "addon logger skipped", InvolvedObject: ref},
+	}
+	if diff := cmp.Diff(want, recorder.Events()); diff != "" {
+		t.Fatalf("Events() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,223 @@
+// Package execplugin runs external binaries as render hooks over a stdin/stdout JSON protocol,
+// similar to kustomize exec plugins.
+//
+// The plugin receives a single Request document on stdin and must write a single Response
+// document to stdout before exiting with status 0. Anything written to stderr is included in the
+// error when the plugin fails.
+package execplugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// APIVersion identifies the protocol version exchanged with plugins.
+const APIVersion = "renderer.openchoreo.dev/v1alpha1"
+
+// Mode selects which render stage a plugin takes part in.
+type Mode string
+
+const (
+	// ModeContextProvider plugins run before rendering and return values merged into the inputs.
+	ModeContextProvider Mode = "ContextProvider"
+	// ModeTransformer plugins run after rendering and return the replacement resource list.
+	ModeTransformer Mode = "Transformer"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultMaxOutput = 16 << 20
+)
+
+// Request is written to the plugin's stdin.
+type Request struct {
+	APIVersion string           `json:"apiVersion"`
+	Kind       Mode             `json:"kind"`
+	Component  *types.Component `json:"component,omitempty"`
+	// Inputs is set for context providers.
+	Inputs map[string]any `json:"inputs,omitempty"`
+	// Resources is set for transformers.
+	Resources []map[string]any `json:"resources,omitempty"`
+	// Config is the plugin's static configuration.
+	Config map[string]any `json:"config,omitempty"`
+}
+
+// Response is read from the plugin's stdout.
+type Response struct {
+	// Context is merged into the top level of the render inputs (context providers only).
+	Context map[string]any `json:"context,omitempty"`
+	// Resources replaces the rendered resources (transformers only).
+	Resources []map[string]any `json:"resources,omitempty"`
+	// Error aborts the render with the given message.
+	Error string `json:"error,omitempty"`
+}
+
+// Plugin is an external binary registered as a render hook.
+type Plugin struct {
+	PluginName string
+	Mode       Mode
+	// Command is the binary and its arguments.
+	Command []string
+	Config  map[string]any
+	// Timeout bounds each invocation; zero uses 10s.
+	Timeout time.Duration
+	// Sandbox restricts what the plugin process can see.
+	Sandbox Sandbox
+}
+
+// Sandbox limits a plugin process. The zero value runs the plugin with an empty environment in a
+// fresh temporary directory.
+type Sandbox struct {
+	// Env lists the environment variables passed through from the renderer's environment.
+	Env []string
+	// Dir is the working directory; empty uses a temporary directory removed after each run.
+	Dir string
+	// MaxOutputBytes caps the size of stdout; zero uses 16 MiB.
+	MaxOutputBytes int64
+}
+
+var _ pipeline.Hooks = (*Plugin)(nil)
+
+// Name implements pipeline.Hooks.
+func (p *Plugin) Name() string {
+	return p.PluginName
+}
+
+// PreRender implements pipeline.Hooks for context providers. Keys returned by the plugin overwrite
+// existing inputs.
+func (p *Plugin) PreRender(ctx *pipeline.HookContext) error {
+	if p.Mode != ModeContextProvider {
+		return nil
+	}
+	resp, err := p.Run(context.Background(), &Request{Component: ctx.Component, Inputs: ctx.Inputs})
+	if err != nil {
+		return err
+	}
+	for key, value := range resp.Context {
+		ctx.Inputs[key] = value
+	}
+	return nil
+}
+
+// PostRender implements pipeline.Hooks for transformers.
+func (p *Plugin) PostRender(ctx *pipeline.HookContext, resources []map[string]any) ([]map[string]any, error) {
+	if p.Mode != ModeTransformer {
+		return resources, nil
+	}
+	resp, err := p.Run(context.Background(), &Request{Component: ctx.Component, Resources: resources})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Resources, nil
+}
+
+// Run invokes the plugin once with req, filling in the protocol fields.
+func (p *Plugin) Run(ctx context.Context, req *Request) (*Response, error) {
+	if len(p.Command) == 0 {
+		return nil, fmt.Errorf("plugin %s has no command", p.PluginName)
+	}
+	req.APIVersion = APIVersion
+	req.Kind = p.Mode
+	req.Config = p.Config
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	dir := p.Sandbox.Dir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "execplugin-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create plugin working directory: %w", err)
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	maxOutput := p.Sandbox.MaxOutputBytes
+	if maxOutput <= 0 {
+		maxOutput = defaultMaxOutput
+	}
+	stdout := &limitedBuffer{limit: maxOutput}
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = sandboxEnv(p.Sandbox.Env)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	// Children of the plugin may keep stdout open after it is killed; do not wait for them.
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		switch {
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, fmt.Errorf("plugin %s timed out after %s", p.PluginName, timeout)
+		case stdout.exceeded:
+			return nil, fmt.Errorf("plugin %s wrote more than %d bytes to stdout", p.PluginName, maxOutput)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w: %s", p.PluginName, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.exceeded {
+		return nil, fmt.Errorf("plugin %s wrote more than %d bytes to stdout", p.PluginName, maxOutput)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response of plugin %s: %w", p.PluginName, err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("plugin %s: %s", p.PluginName, resp.Error)
+	}
+	return &resp, nil
+}
+
+func sandboxEnv(allowed []string) []string {
+	env := []string{}
+	for _, name := range allowed {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	return env
+}
+
+// limitedBuffer stops accepting writes past limit so a misbehaving plugin cannot make the renderer
+// buffer unbounded output. It deliberately does not expose bytes.Buffer's ReadFrom, which would
+// bypass Write.
+type limitedBuffer struct {
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if int64(b.buf.Len()+len(p)) > b.limit {
+		b.exceeded = true
+		return 0, fmt.Errorf("output limit of %d bytes exceeded", b.limit)
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
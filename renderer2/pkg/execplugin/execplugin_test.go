@@ -0,0 +1,135 @@
+package execplugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plugin.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestContextProvider(t *testing.T) {
+	t.Parallel()
+
+	script := writeScript(t, `cat > /dev/null
+echo '{"context": {"cluster": {"region": "eu-west-1"}}}'
+`)
+	plugin := &Plugin{PluginName: "inventory", Mode: ModeContextProvider, Command: []string{"/bin/sh", script}, Sandbox: Sandbox{Env: []string{"PATH"}}}
+
+	ctx := &pipeline.HookContext{Component: &types.Component{}, Inputs: map[string]any{"metadata": map[string]any{"name": "app"}}}
+	if err := plugin.PreRender(ctx); err != nil {
+		t.Fatalf("PreRender() error = %v", err)
+	}
+	want := map[string]any{
+		"metadata": map[string]any{"name": "app"},
+		"cluster":  map[string]any{"region": "eu-west-1"},
+	}
+	if diff := cmp.Diff(want, ctx.Inputs); diff != "" {
+		t.Errorf("inputs mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTransformerReceivesRequest(t *testing.T) {
+	t.Parallel()
+
+	// Echo the request back as the resource list so the test can inspect what the plugin saw.
+	script := writeScript(t, `printf '{"resources": [%s]}' "$(cat)"
+`)
+	plugin := &Plugin{
+		PluginName: "echo",
+		Mode:       ModeTransformer,
+		Command:    []string{"/bin/sh", script},
+		Config:     map[string]any{"team": "payments"},
+		Sandbox:    Sandbox{Env: []string{"PATH"}},
+	}
+
+	got, err := plugin.PostRender(&pipeline.HookContext{}, []map[string]any{{"kind": "Service"}})
+	if err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+	want := []map[string]any{{
+		"apiVersion": APIVersion,
+		"kind":       string(ModeTransformer),
+		"resources":  []any{map[string]any{"kind": "Service"}},
+		"config":     map[string]any{"team": "payments"},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunFailures(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		body    string
+		plugin  Plugin
+		wantErr string
+	}{
+		{
+			name:    "non-zero exit includes stderr",
+			body:    "echo 'bad input' >&2\nexit 3\n",
+			wantErr: "bad input",
+		},
+		{
+			name:    "error field",
+			body:    `echo '{"error": "quota exceeded"}'` + "\n",
+			wantErr: "quota exceeded",
+		},
+		{
+			name:    "invalid json",
+			body:    "echo not-json\n",
+			wantErr: "failed to decode response",
+		},
+		{
+			name:    "timeout",
+			body:    "sleep 5\n",
+			plugin:  Plugin{Timeout: 50 * time.Millisecond},
+			wantErr: "timed out",
+		},
+		{
+			name:    "output limit",
+			body:    "echo '{\"resources\": []}'\n",
+			plugin:  Plugin{Sandbox: Sandbox{MaxOutputBytes: 4}},
+			wantErr: "more than 4 bytes",
+		},
+		{
+			name:    "environment is not inherited",
+			body:    `echo "{\"error\": \"home=[$HOME]\"}"` + "\n",
+			wantErr: "home=[]",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			plugin := tt.plugin
+			plugin.PluginName = "test"
+			plugin.Mode = ModeTransformer
+			plugin.Command = []string{"/bin/sh", writeScript(t, tt.body)}
+			plugin.Sandbox.Env = []string{"PATH"}
+
+			_, err := plugin.Run(context.Background(), &Request{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+const (
+	backstageAPIVersion        = "backstage.io/v1alpha1"
+	defaultBackstageOwner      = "unknown"
+	defaultBackstageLifecycle  = "production"
+	backstageComponentTypeAnno = "openchoreo.dev/component-type"
+)
+
+// backstageResourceTypes maps rendered kinds that represent infrastructure the component depends on
+// to Backstage Resource types.
+var backstageResourceTypes = map[string]string{
+	"PersistentVolumeClaim": "persistent-volume",
+	"ExternalSecret":        "secret",
+	"Secret":                "secret",
+}
+
+// BackstageOptions supplies catalog fields that the Component itself does not carry.
+type BackstageOptions struct {
+	Owner     string
+	System    string
+	Lifecycle string
+}
+
+// ToBackstage builds catalog-info entities for a Component: one Component entity, one API entity per
+// rendered Service (listing its ports and any Ingress hosts routed to it), and one Resource entity
+// per rendered volume claim or secret. The Component entity provides the APIs and depends on the
+// Resources.
+func ToBackstage(ctd *types.ComponentTypeDefinition, component *types.Component, resources []map[string]any, opts BackstageOptions) []map[string]any {
+	if opts.Owner == "" {
+		opts.Owner = defaultBackstageOwner
+	}
+	if opts.Lifecycle == "" {
+		opts.Lifecycle = defaultBackstageLifecycle
+	}
+
+	name := component.Metadata.Name
+	ingressHosts := ingressHostsByService(resources)
+
+	var apis, deps []map[string]any
+	var apiRefs, depRefs []string
+	var links []any
+	for _, resource := range resources {
+		kind, _ := resource["kind"].(string)
+		resourceName := nestedString(resource, "metadata", "name")
+		if resourceName == "" {
+			continue
+		}
+
+		if kind == "Service" {
+			entity, hosts := backstageAPI(name, resourceName, resource, ingressHosts[resourceName], opts)
+			apis = append(apis, entity)
+			apiRefs = append(apiRefs, "api:"+entityName(entity))
+			for _, host := range hosts {
+				links = append(links, map[string]any{"url": host, "title": resourceName})
+			}
+			continue
+		}
+
+		if resourceType, ok := backstageResourceTypes[kind]; ok {
+			entity := map[string]any{
+				"apiVersion": backstageAPIVersion,
+				"kind":       "Resource",
+				"metadata": map[string]any{
+					"name":        resourceName,
+					"description": fmt.Sprintf("%s %s used by %s", kind, resourceName, name),
+				},
+				"spec": backstageSpec(opts, map[string]any{"type": resourceType}, false),
+			}
+			deps = append(deps, entity)
+			depRefs = append(depRefs, "resource:"+resourceName)
+		}
+	}
+
+	annotations := map[string]any{
+		backstageComponentTypeAnno:               ctd.Metadata.Name,
+		"backstage.io/kubernetes-id":             name,
+		"backstage.io/kubernetes-label-selector": "app.kubernetes.io/name=" + name,
+	}
+	if component.Metadata.Namespace != "" {
+		annotations["backstage.io/kubernetes-namespace"] = component.Metadata.Namespace
+	}
+	metadata := map[string]any{"name": name, "annotations": annotations}
+	if ctd.Spec.WorkloadType != "" {
+		metadata["tags"] = []any{strings.ToLower(ctd.Spec.WorkloadType)}
+	}
+	if len(links) > 0 {
+		metadata["links"] = links
+	}
+
+	componentSpec := map[string]any{"type": backstageComponentType(ctd, apis)}
+	if len(apiRefs) > 0 {
+		componentSpec["providesApis"] = toAnySlice(apiRefs)
+	}
+	if len(depRefs) > 0 {
+		componentSpec["dependsOn"] = toAnySlice(depRefs)
+	}
+
+	entities := []map[string]any{{
+		"apiVersion": backstageAPIVersion,
+		"kind":       "Component",
+		"metadata":   metadata,
+		"spec":       backstageSpec(opts, componentSpec, true),
+	}}
+	entities = append(entities, apis...)
+	entities = append(entities, deps...)
+	return entities
+}
+
+func backstageAPI(componentName, serviceName string, service map[string]any, hosts []string, opts BackstageOptions) (map[string]any, []string) {
+	apiType := "openapi"
+	var lines []string
+	ports, _ := nestedValue(service, "spec", "ports").([]any)
+	for _, raw := range ports {
+		port, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		portName, _ := port["name"].(string)
+		if strings.Contains(strings.ToLower(portName), "grpc") {
+			apiType = "grpc"
+		}
+		protocol := "TCP"
+		if p, ok := port["protocol"].(string); ok && p != "" {
+			protocol = p
+		}
+		label := protocol
+		if portName != "" {
+			label = portName + " " + protocol
+		}
+		lines = append(lines, fmt.Sprintf("port %v (%s)", port["port"], label))
+	}
+
+	urls := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		urls = append(urls, "https://"+host)
+		lines = append(lines, "https://"+host)
+	}
+
+	entity := map[string]any{
+		"apiVersion": backstageAPIVersion,
+		"kind":       "API",
+		"metadata": map[string]any{
+			"name":        serviceName,
+			"description": fmt.Sprintf("Endpoints exposed by %s", componentName),
+		},
+		"spec": backstageSpec(opts, map[string]any{
+			"type":       apiType,
+			"definition": strings.Join(lines, "\n") + "\n",
+		}, true),
+	}
+	return entity, urls
+}
+
+func backstageSpec(opts BackstageOptions, spec map[string]any, withLifecycle bool) map[string]any {
+	spec["owner"] = opts.Owner
+	if withLifecycle {
+		spec["lifecycle"] = opts.Lifecycle
+	}
+	if opts.System != "" {
+		spec["system"] = opts.System
+	}
+	return spec
+}
+
+func backstageComponentType(ctd *types.ComponentTypeDefinition, apis []map[string]any) string {
+	if len(apis) > 0 {
+		return "service"
+	}
+	switch strings.ToLower(ctd.Spec.WorkloadType) {
+	case "cronjob", "job":
+		return "job"
+	default:
+		return "worker"
+	}
+}
+
+// ingressHostsByService collects Ingress hosts keyed by the backend Service they route to.
+func ingressHostsByService(resources []map[string]any) map[string][]string {
+	hosts := map[string][]string{}
+	for _, resource := range resources {
+		if kind, _ := resource["kind"].(string); kind != "Ingress" {
+			continue
+		}
+		rules, _ := nestedValue(resource, "spec", "rules").([]any)
+		for _, raw := range rules {
+			rule, ok := raw.(map[string]any)
+			if !ok {
+				continue
+			}
+			host, _ := rule["host"].(string)
+			if host == "" {
+				continue
+			}
+			paths, _ := nestedValue(rule, "http", "paths").([]any)
+			for _, rawPath := range paths {
+				path, ok := rawPath.(map[string]any)
+				if !ok {
+					continue
+				}
+				service := nestedString(path, "backend", "service", "name")
+				if service == "" {
+					continue
+				}
+				url := host
+				if p, _ := path["path"].(string); p != "" && p != "/" {
+					url += p
+				}
+				hosts[service] = appendUnique(hosts[service], url)
+			}
+		}
+	}
+	for service := range hosts {
+		sort.Strings(hosts[service])
+	}
+	return hosts
+}
+
+func nestedValue(m map[string]any, keys ...string) any {
+	var current any = m
+	for _, key := range keys {
+		next, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+		current = next[key]
+	}
+	return current
+}
+
+func nestedString(m map[string]any, keys ...string) string {
+	s, _ := nestedValue(m, keys...).(string)
+	return s
+}
+
+func entityName(entity map[string]any) string {
+	return nestedString(entity, "metadata", "name")
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
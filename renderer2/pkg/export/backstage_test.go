@@ -0,0 +1,98 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToBackstage(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "deployment-component"},
+		Spec:     types.ComponentTypeDefinitionSpec{WorkloadType: "Deployment"},
+	}
+	component := &types.Component{Metadata: types.Metadata{Name: "checkout", Namespace: "shop"}}
+	resources := []map[string]any{
+		{"kind": "Deployment", "metadata": map[string]any{"name": "checkout"}},
+		{
+			"kind":     "Service",
+			"metadata": map[string]any{"name": "checkout"},
+			"spec": map[string]any{
+				"ports": []any{map[string]any{"name": "http", "port": int64(80)}},
+			},
+		},
+		{
+			"kind":     "Ingress",
+			"metadata": map[string]any{"name": "checkout"},
+			"spec": map[string]any{
+				"rules": []any{map[string]any{
+					"host": "shop.example.com",
+					"http": map[string]any{"paths": []any{map[string]any{
+						"path":    "/checkout",
+						"backend": map[string]any{"service": map[string]any{"name": "checkout"}},
+					}}},
+				}},
+			},
+		},
+		{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "checkout-data"}},
+	}
+
+	got := ToBackstage(ctd, component, resources, BackstageOptions{Owner: "team-payments", System: "storefront"})
+
+	want := []map[string]any{
+		{
+			"apiVersion": "backstage.io/v1alpha1",
+			"kind":       "Component",
+			"metadata": map[string]any{
+				"name": "checkout",
+				"annotations": map[string]any{
+					"openchoreo.dev/component-type":          "deployment-component",
+					"backstage.io/kubernetes-id":             "checkout",
+					"backstage.io/kubernetes-label-selector": "app.kubernetes.io/name=checkout",
+					"backstage.io/kubernetes-namespace":      "shop",
+				},
+				"tags":  []any{"deployment"},
+				"links": []any{map[string]any{"url": "https://shop.example.com/checkout", "title": "checkout"}},
+			},
+			"spec": map[string]any{
+				"type":         "service",
+				"owner":        "team-payments",
+				"lifecycle":    "production",
+				"system":       "storefront",
+				"providesApis": []any{"api:checkout"},
+				"dependsOn":    []any{"resource:checkout-data"},
+			},
+		},
+		{
+			"apiVersion": "backstage.io/v1alpha1",
+			"kind":       "API",
+			"metadata":   map[string]any{"name": "checkout", "description": "Endpoints exposed by checkout"},
+			"spec": map[string]any{
+				"type":       "openapi",
+				"owner":      "team-payments",
+				"lifecycle":  "production",
+				"system":     "storefront",
+				"definition": "port 80 (http TCP)\nhttps://shop.example.com/checkout\n",
+			},
+		},
+		{
+			"apiVersion": "backstage.io/v1alpha1",
+			"kind":       "Resource",
+			"metadata": map[string]any{
+				"name":        "checkout-data",
+				"description": "PersistentVolumeClaim checkout-data used by checkout",
+			},
+			"spec": map[string]any{
+				"type":   "persistent-volume",
+				"owner":  "team-payments",
+				"system": "storefront",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ToBackstage() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,165 @@
+package export
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+const (
+	crdAPIVersion = "apiextensions.k8s.io/v1"
+	// maxPrinterColumns caps the parameters shown by kubectl get; Age is always added.
+	maxPrinterColumns = 6
+)
+
+// kubeVersion matches the version names Kubernetes accepts for CRDs, e.g. v1, v2beta1.
+var kubeVersion = regexp.MustCompile(`^v[1-9][0-9]*((alpha|beta)[1-9][0-9]*)?$`)
+
+// resourceName matches the lowercase DNS labels Kubernetes accepts as resource plurals.
+var resourceName = regexp.MustCompile(`^[a-z]([-a-z0-9]*[a-z0-9])?$`)
+
+// CRDOptions configures the generated CustomResourceDefinition.
+type CRDOptions struct {
+	// Group defaults to platform.openchoreo.dev.
+	Group string
+	// Version names the single version of an unversioned definition; defaults to v1alpha1.
+	Version string
+	// Plural is the resource name, for kinds English rules get wrong (e.g. "cacti"); defaults to
+	// the lowercase kind pluralized by Pluralize.
+	Plural string
+}
+
+// ToCRD converts a ComponentTypeDefinition into a namespaced CustomResourceDefinition whose spec
+// is the definition's parameters and envOverrides, so each component type can be installed as its
+// own API. Definitions with versions get one CRD version each, with the storage version marked.
+// Top-level scalar parameters become printer columns.
+func ToCRD(ctd *types.ComponentTypeDefinition, opts CRDOptions) (map[string]any, error) {
+	if opts.Group == "" {
+		opts.Group = defaultCrossplaneGroup
+	}
+	if opts.Version == "" {
+		opts.Version = defaultCrossplaneVersion
+	}
+
+	kind := kindFromName(ctd.Metadata.Name)
+	if kind == "" {
+		return nil, fmt.Errorf("definition has no metadata.name")
+	}
+	singular := strings.ToLower(kind)
+	plural := opts.Plural
+	if plural == "" {
+		plural = Pluralize(singular)
+	}
+	if !resourceName.MatchString(plural) {
+		return nil, fmt.Errorf("plural %q is not a lowercase resource name", plural)
+	}
+
+	definitionVersions := ctd.Spec.Versions
+	if len(definitionVersions) == 0 {
+		definitionVersions = []types.DefinitionVersion{{Name: opts.Version, Served: true, Storage: true, Schema: ctd.Spec.Schema}}
+	}
+	versions := make([]any, 0, len(definitionVersions))
+	storage := 0
+	for _, version := range definitionVersions {
+		if !kubeVersion.MatchString(version.Name) {
+			return nil, fmt.Errorf("version %q of %s is not a Kubernetes version name such as v1 or v1beta1", version.Name, ctd.Metadata.Name)
+		}
+		if version.Storage {
+			storage++
+		}
+		specSchema, err := definitionSchemaMap(version.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema of %s version %s: %w", ctd.Metadata.Name, version.Name, err)
+		}
+		versions = append(versions, map[string]any{
+			"name":    version.Name,
+			"served":  version.Served,
+			"storage": version.Storage,
+			"schema": map[string]any{
+				"openAPIV3Schema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"spec":   specSchema,
+						"status": map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+					},
+				},
+			},
+			"subresources":             map[string]any{"status": map[string]any{}},
+			"additionalPrinterColumns": printerColumns(specSchema, version.Schema.Parameters),
+		})
+	}
+	if storage != 1 {
+		return nil, fmt.Errorf("%s needs exactly one storage version, got %d", ctd.Metadata.Name, storage)
+	}
+
+	return map[string]any{
+		"apiVersion": crdAPIVersion,
+		"kind":       "CustomResourceDefinition",
+		"metadata": map[string]any{
+			"name": plural + "." + opts.Group,
+			"labels": map[string]any{
+				"platform.openchoreo.dev/component-type": ctd.Metadata.Name,
+			},
+		},
+		"spec": map[string]any{
+			"group": opts.Group,
+			"scope": "Namespaced",
+			"names": map[string]any{
+				"kind":     kind,
+				"listKind": kind + "List",
+				"plural":   plural,
+				"singular": singular,
+			},
+			"versions": versions,
+		},
+	}, nil
+}
+
+// printerColumns lists the top-level scalar parameters in name order, then Age.
+func printerColumns(specSchema map[string]any, parameters map[string]any) []any {
+	properties, _ := specSchema["properties"].(map[string]any)
+	names := make([]string, 0, len(parameters))
+	for name := range parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var columns []any
+	for _, name := range names {
+		if len(columns) == maxPrinterColumns {
+			break
+		}
+		property, _ := properties[name].(map[string]any)
+		switch columnType := property["type"]; columnType {
+		case "string", "integer", "number", "boolean":
+			columns = append(columns, map[string]any{
+				"name":     kindFromName(name),
+				"type":     columnType,
+				"jsonPath": ".spec." + name,
+			})
+		}
+	}
+	return append(columns, map[string]any{
+		"name":     "Age",
+		"type":     "date",
+		"jsonPath": ".metadata.creationTimestamp",
+	})
+}
+
+// Pluralize returns the English plural of a lowercase kind the way Kubernetes names resources:
+// "es" after s, x, z, ch and sh ("ingresses", "boxes"), "ies" for a consonant followed by y
+// ("policies"), and "s" otherwise ("gateways").
+func Pluralize(singular string) string {
+	switch {
+	case strings.HasSuffix(singular, "s"), strings.HasSuffix(singular, "x"), strings.HasSuffix(singular, "z"),
+		strings.HasSuffix(singular, "ch"), strings.HasSuffix(singular, "sh"):
+		return singular + "es"
+	case len(singular) > 1 && strings.HasSuffix(singular, "y") && !strings.ContainsRune("aeiou", rune(singular[len(singular)-2])):
+		return singular[:len(singular)-1] + "ies"
+	default:
+		return singular + "s"
+	}
+}
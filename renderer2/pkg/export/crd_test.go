@@ -0,0 +1,216 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+)
+
+func TestToCRD(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web-app"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Schema: types.Schema{
+				Parameters: map[string]any{
+					"replicas": "integer | default=1",
+					"image":    "string",
+					"tags":     "[]string",
+					"probe":    map[string]any{"path": "string | default=/healthz"},
+				},
+				EnvOverrides: map[string]any{"debug": "boolean | default=false"},
+			},
+		},
+	}
+
+	crd, err := ToCRD(ctd, CRDOptions{})
+	if err != nil {
+		t.Fatalf("ToCRD() error = %v", err)
+	}
+	spec := crd["spec"].(map[string]any)
+	if name := crd["metadata"].(map[string]any)["name"]; name != "webapps.platform.openchoreo.dev" {
+		t.Errorf("metadata.name = %v, want webapps.platform.openchoreo.dev", name)
+	}
+	wantNames := map[string]any{"kind": "WebApp", "listKind": "WebAppList", "plural": "webapps", "singular": "webapp"}
+	if diff := cmp.Diff(wantNames, spec["names"]); diff != "" {
+		t.Errorf("names mismatch (-want +got):\n%s", diff)
+	}
+
+	versions := spec["versions"].([]any)
+	if len(versions) != 1 {
+		t.Fatalf("got %d versions, want 1", len(versions))
+	}
+	version := versions[0].(map[string]any)
+	if version["name"] != "v1alpha1" || version["storage"] != true || version["served"] != true {
+		t.Errorf("version = %v %v %v, want served storage v1alpha1", version["name"], version["served"], version["storage"])
+	}
+	wantColumns := []any{
+		map[string]any{"name": "Image", "type": "string", "jsonPath": ".spec.image"},
+		map[string]any{"name": "Replicas", "type": "integer", "jsonPath": ".spec.replicas"},
+		map[string]any{"name": "Age", "type": "date", "jsonPath": ".metadata.creationTimestamp"},
+	}
+	if diff := cmp.Diff(wantColumns, version["additionalPrinterColumns"]); diff != "" {
+		t.Errorf("printer columns mismatch (-want +got):\n%s", diff)
+	}
+	properties := version["schema"].(map[string]any)["openAPIV3Schema"].(map[string]any)["properties"].(map[string]any)
+	specProperties := properties["spec"].(map[string]any)["properties"].(map[string]any)
+	for _, field := range []string{"replicas", "image", "tags", "probe", "debug"} {
+		if _, ok := specProperties[field]; !ok {
+			t.Errorf("spec schema misses %s", field)
+		}
+	}
+}
+
+func TestToCRDVersions(t *testing.T) {
+	t.Parallel()
+
+	schema := types.Schema{Parameters: map[string]any{"replicas": "integer | default=1"}}
+	tests := []struct {
+		name     string
+		versions []types.DefinitionVersion
+		want     []string
+		wantErr  string
+	}{
+		{
+			name: "served and storage versions",
+			versions: []types.DefinitionVersion{
+				{Name: "v1", Served: true, Schema: schema},
+				{Name: "v2", Served: true, Storage: true, Schema: schema},
+			},
+			want: []string{"v1", "v2"},
+		},
+		{
+			name:     "invalid version name",
+			versions: []types.DefinitionVersion{{Name: "2024-01", Served: true, Storage: true, Schema: schema}},
+			wantErr:  `version "2024-01" of web is not a Kubernetes version name`,
+		},
+		{
+			name:     "no storage version",
+			versions: []types.DefinitionVersion{{Name: "v1", Served: true, Schema: schema}},
+			wantErr:  "web needs exactly one storage version, got 0",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctd := &types.ComponentTypeDefinition{
+				Metadata: types.Metadata{Name: "web"},
+				Spec:     types.ComponentTypeDefinitionSpec{Versions: tt.versions},
+			}
+			crd, err := ToCRD(ctd, CRDOptions{Group: "apps.example.com"})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ToCRD() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ToCRD() error = %v", err)
+			}
+			var got []string
+			for _, version := range crd["spec"].(map[string]any)["versions"].([]any) {
+				got = append(got, version.(map[string]any)["name"].(string))
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("versions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestToCRDPlural(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		plural string
+		want   string
+	}{
+		{name: "gateway", want: "gateways"},
+		{name: "ingress", want: "ingresses"},
+		{name: "sandbox", want: "sandboxes"},
+		{name: "batch", want: "batches"},
+		{name: "cron-policy", want: "cronpolicies"},
+		{name: "cactus", plural: "cacti", want: "cacti"},
+	}
+	for _, tt := range tests {
+		ctd := &types.ComponentTypeDefinition{Metadata: types.Metadata{Name: tt.name}}
+		crd, err := ToCRD(ctd, CRDOptions{Plural: tt.plural})
+		if err != nil {
+			t.Fatalf("ToCRD(%s) error = %v", tt.name, err)
+		}
+		names := crd["spec"].(map[string]any)["names"].(map[string]any)
+		if names["plural"] != tt.want {
+			t.Errorf("ToCRD(%s) plural = %v, want %s", tt.name, names["plural"], tt.want)
+		}
+		if name := crd["metadata"].(map[string]any)["name"]; name != tt.want+".platform.openchoreo.dev" {
+			t.Errorf("ToCRD(%s) metadata.name = %v", tt.name, name)
+		}
+	}
+
+	if _, err := ToCRD(&types.ComponentTypeDefinition{Metadata: types.Metadata{Name: "web"}}, CRDOptions{Plural: "Webs"}); err == nil {
+		t.Error("ToCRD() accepted an uppercase plural")
+	}
+}
+
+func TestToCRDUnionIsStructural(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web-app"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Schema: types.Schema{
+				Types: map[string]any{
+					"S3Config":  map[string]any{"type": "string | enum=s3", "bucket": "string", "region": "string | default=us-east-1"},
+					"GCSConfig": map[string]any{"type": "string | enum=gcs", "bucket": "string", "project": "string"},
+				},
+				Parameters: map[string]any{"storage": "oneOf<S3Config, GCSConfig> | discriminator=type"},
+			},
+		},
+	}
+	crd, err := ToCRD(ctd, CRDOptions{})
+	if err != nil {
+		t.Fatalf("ToCRD() error = %v", err)
+	}
+	version := crd["spec"].(map[string]any)["versions"].([]any)[0].(map[string]any)
+	openAPI := version["schema"].(map[string]any)["openAPIV3Schema"]
+
+	data, err := json.Marshal(openAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var props extv1.JSONSchemaProps
+	if err := json.Unmarshal(data, &props); err != nil {
+		t.Fatal(err)
+	}
+	internal := new(apiext.JSONSchemaProps)
+	if err := extv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(&props, internal, nil); err != nil {
+		t.Fatalf("failed to convert schema: %v", err)
+	}
+	structural, err := apiextschema.NewStructural(internal)
+	if err != nil {
+		t.Fatalf("NewStructural() error = %v", err)
+	}
+	if errs := apiextschema.ValidateStructural(nil, structural); len(errs) > 0 {
+		t.Errorf("schema is not structural: %v", errs.ToAggregate())
+	}
+
+	storage := props.Properties["spec"].Properties["storage"]
+	wantBranches := []extv1.JSONSchemaProps{
+		{Required: []string{"bucket", "type"}, Properties: map[string]extv1.JSONSchemaProps{"type": {Enum: []extv1.JSON{{Raw: []byte(`"s3"`)}}}}},
+		{Required: []string{"bucket", "project", "type"}, Properties: map[string]extv1.JSONSchemaProps{"type": {Enum: []extv1.JSON{{Raw: []byte(`"gcs"`)}}}}},
+	}
+	if diff := cmp.Diff(wantBranches, storage.OneOf); diff != "" {
+		t.Errorf("oneOf mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,404 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	crossplaneAPIVersion     = "apiextensions.crossplane.io/v1"
+	defaultCrossplaneGroup   = "platform.openchoreo.dev"
+	defaultCrossplaneVersion = "v1alpha1"
+)
+
+var (
+	simpleFieldPath = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*$`)
+	plainKey        = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+)
+
+// CrossplaneOptions configures the generated XRD and Composition.
+type CrossplaneOptions struct {
+	Group   string
+	Version string
+}
+
+// CrossplaneResult holds the generated Crossplane objects and any expressions that could not be mapped.
+type CrossplaneResult struct {
+	XRD         map[string]any
+	Composition map[string]any
+	Warnings    []string
+}
+
+// ToCrossplane converts a ComponentTypeDefinition plus addons into a CompositeResourceDefinition and
+// Composition pair. CEL expressions that are plain field references (or string interpolations of them)
+// become FromCompositeFieldPath/CombineFromComposite patches; anything else is dropped and reported.
+func ToCrossplane(ctd *types.ComponentTypeDefinition, addons []*types.Addon, opts CrossplaneOptions) (*CrossplaneResult, error) {
+	if opts.Group == "" {
+		opts.Group = defaultCrossplaneGroup
+	}
+	if opts.Version == "" {
+		opts.Version = defaultCrossplaneVersion
+	}
+
+	claimKind := kindFromName(ctd.Metadata.Name)
+	kind := "X" + claimKind
+	plural := Pluralize(strings.ToLower(kind))
+
+	specSchema, err := definitionSchemaMap(ctd.Spec.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert schema for %s: %w", ctd.Metadata.Name, err)
+	}
+
+	sortedAddons := make([]*types.Addon, len(addons))
+	copy(sortedAddons, addons)
+	sort.Slice(sortedAddons, func(i, j int) bool {
+		return sortedAddons[i].Metadata.Name < sortedAddons[j].Metadata.Name
+	})
+
+	addonProps := map[string]any{}
+	for _, addon := range sortedAddons {
+		addonSchema, err := definitionSchemaMap(addon.Spec.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema for addon %s: %w", addon.Metadata.Name, err)
+		}
+		addonProps[addon.Metadata.Name] = addonSchema
+	}
+	if len(addonProps) > 0 {
+		props, _ := specSchema["properties"].(map[string]any)
+		if props == nil {
+			props = map[string]any{}
+			specSchema["properties"] = props
+		}
+		props["addons"] = map[string]any{
+			"type":       "object",
+			"properties": addonProps,
+		}
+	}
+
+	result := &CrossplaneResult{}
+	result.XRD = map[string]any{
+		"apiVersion": crossplaneAPIVersion,
+		"kind":       "CompositeResourceDefinition",
+		"metadata": map[string]any{
+			"name": plural + "." + opts.Group,
+		},
+		"spec": map[string]any{
+			"group": opts.Group,
+			"names": map[string]any{
+				"kind":   kind,
+				"plural": plural,
+			},
+			"claimNames": map[string]any{
+				"kind":   claimKind,
+				"plural": Pluralize(strings.ToLower(claimKind)),
+			},
+			"versions": []any{
+				map[string]any{
+					"name":          opts.Version,
+					"served":        true,
+					"referenceable": true,
+					"schema": map[string]any{
+						"openAPIV3Schema": map[string]any{
+							"type": "object",
+							"properties": map[string]any{
+								"spec": specSchema,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var composed []any
+	if ctd.Spec.CommonMetadata.Template() != nil {
+		result.Warnings = append(result.Warnings, "commonMetadata is not supported; resources are composed with their own metadata only")
+	}
+	if len(ctd.Spec.Fragments) > 0 {
+		result.Warnings = append(result.Warnings, "fragments are not supported; fields calling fragment() are composed as written")
+	}
+	for _, res := range ctd.Spec.Resources {
+		if res.IncludeWhen != "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("resource %s: includeWhen %q is not supported and was ignored", res.ID, res.IncludeWhen))
+		}
+		if res.ForEach != "" {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("resource %s: forEach %q is not supported; a single instance is composed", res.ID, res.ForEach))
+		}
+		if len(res.FieldsWhen) > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("resource %s: fieldsWhen is not supported; every field is composed", res.ID))
+		}
+		composed = append(composed, composeResource(res.ID, res.Template, "", result))
+	}
+
+	for _, addon := range sortedAddons {
+		for i, create := range addon.Spec.Creates {
+			if create.IncludeWhen != "" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("addon %s: create %d includeWhen %q is not supported and was ignored", addon.Metadata.Name, i, create.IncludeWhen))
+			}
+			if create.ForEach != "" {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("addon %s: create %d forEach %q is not supported; a single instance is composed", addon.Metadata.Name, i, create.ForEach))
+			}
+			createMap, ok := create.Template.(map[string]any)
+			if !ok {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("addon %s: create %d is not an object and was skipped", addon.Metadata.Name, i))
+				continue
+			}
+			name := fmt.Sprintf("%s-create-%d", addon.Metadata.Name, i)
+			composed = append(composed, composeResource(name, createMap, "spec.addons."+addon.Metadata.Name, result))
+		}
+		if len(addon.Spec.Patches) > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("addon %s: %d patch(es) cannot be expressed as composition patches and were skipped", addon.Metadata.Name, len(addon.Spec.Patches)))
+		}
+	}
+
+	result.Composition = map[string]any{
+		"apiVersion": crossplaneAPIVersion,
+		"kind":       "Composition",
+		"metadata": map[string]any{
+			"name": ctd.Metadata.Name,
+			"labels": map[string]any{
+				"platform.openchoreo.dev/component-type": ctd.Metadata.Name,
+			},
+		},
+		"spec": map[string]any{
+			"compositeTypeRef": map[string]any{
+				"apiVersion": opts.Group + "/" + opts.Version,
+				"kind":       kind,
+			},
+			"resources": composed,
+		},
+	}
+
+	return result, nil
+}
+
+func composeResource(name string, template map[string]any, specRoot string, result *CrossplaneResult) map[string]any {
+	var patches []any
+	base := stripExpressions(template, "", specRoot, name, &patches, result)
+
+	entry := map[string]any{
+		"name": name,
+		"base": base,
+	}
+	if len(patches) > 0 {
+		entry["patches"] = patches
+	}
+	return entry
+}
+
+func stripExpressions(value any, path, specRoot, resource string, patches *[]any, result *CrossplaneResult) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(typed))
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if strings.Contains(k, "${") {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("resource %s: templated key %q at %s is not supported and was dropped", resource, k, displayPath(path)))
+				continue
+			}
+			childPath := joinFieldPath(path, k)
+			child := stripExpressions(typed[k], childPath, specRoot, resource, patches, result)
+			if child == nil && typed[k] != nil {
+				continue
+			}
+			out[k] = child
+		}
+		return out
+	case []any:
+		// Items are addressed by their index in the output so that patches of later items still
+		// target them after an item that cannot be mapped is dropped.
+		out := make([]any, 0, len(typed))
+		for _, item := range typed {
+			patched := len(*patches)
+			child := stripExpressions(item, path+"["+strconv.Itoa(len(out))+"]", specRoot, resource, patches, result)
+			if child == nil && item != nil {
+				if len(*patches) == patched {
+					continue
+				}
+				// The patch fills in the string item; keep its place in the base.
+				child = ""
+			}
+			out = append(out, child)
+		}
+		return out
+	case string:
+		if !strings.Contains(typed, "${") {
+			return typed
+		}
+		patch, ok := patchForString(typed, path, specRoot)
+		if !ok {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("resource %s: expression at %s cannot be mapped to a patch: %s", resource, displayPath(path), strings.TrimSpace(typed)))
+			return nil
+		}
+		*patches = append(*patches, patch)
+		return nil
+	default:
+		return typed
+	}
+}
+
+// patchForString maps a template string to a Crossplane patch when every embedded expression is a
+// plain field reference.
+func patchForString(str, toFieldPath, specRoot string) (map[string]any, bool) {
+	trimmed := strings.TrimSpace(str)
+	var (
+		format    strings.Builder
+		variables []any
+		rest      = trimmed
+	)
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			format.WriteString(strings.ReplaceAll(rest, "%", "%%"))
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			return nil, false
+		}
+		end += start
+
+		fromPath, ok := compositeFieldPath(strings.TrimSpace(rest[start+2:end]), specRoot)
+		if !ok {
+			return nil, false
+		}
+		format.WriteString(strings.ReplaceAll(rest[:start], "%", "%%"))
+		format.WriteString("%s")
+		variables = append(variables, map[string]any{"fromFieldPath": fromPath})
+		rest = rest[end+1:]
+	}
+
+	if len(variables) == 1 && format.String() == "%s" {
+		return map[string]any{
+			"type":          "FromCompositeFieldPath",
+			"fromFieldPath": variables[0].(map[string]any)["fromFieldPath"],
+			"toFieldPath":   toFieldPath,
+		}, true
+	}
+
+	return map[string]any{
+		"type": "CombineFromComposite",
+		"combine": map[string]any{
+			"variables": variables,
+			"strategy":  "string",
+			"string": map[string]any{
+				"fmt": format.String(),
+			},
+		},
+		"toFieldPath": toFieldPath,
+	}, true
+}
+
+func compositeFieldPath(expr, specRoot string) (string, bool) {
+	if !simpleFieldPath.MatchString(expr) {
+		return "", false
+	}
+	switch {
+	case expr == "metadata.namespace":
+		return "spec.claimRef.namespace", true
+	case strings.HasPrefix(expr, "metadata."):
+		return expr, true
+	case expr == "spec" || strings.HasPrefix(expr, "spec."):
+		if specRoot != "" {
+			return specRoot + strings.TrimPrefix(expr, "spec"), true
+		}
+		return expr, true
+	default:
+		return "", false
+	}
+}
+
+func joinFieldPath(base, key string) string {
+	if !plainKey.MatchString(key) {
+		return base + "[" + key + "]"
+	}
+	if base == "" {
+		return key
+	}
+	return base + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "<root>"
+	}
+	return path
+}
+
+func definitionSchemaMap(s types.Schema) (map[string]any, error) {
+	jsonSchema, err := schema.ToJSONSchema(schema.Definition{
+		Types:   s.Types,
+		Schemas: []map[string]any{s.Parameters, s.EnvOverrides},
+	})
+	if err != nil {
+		return nil, err
+	}
+	dropBranchDefaults(jsonSchema)
+
+	data, err := json.Marshal(jsonSchema)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]any
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// dropBranchDefaults removes the defaults union variants keep in their oneOf branches, which
+// structural schemas do not allow. The renderer applies them; the API server cannot.
+func dropBranchDefaults(s *extv1.JSONSchemaProps) {
+	for i := range s.OneOf {
+		branch := &s.OneOf[i]
+		for name, prop := range branch.Properties {
+			prop.Default = nil
+			if reflect.DeepEqual(prop, extv1.JSONSchemaProps{}) {
+				delete(branch.Properties, name)
+			} else {
+				branch.Properties[name] = prop
+			}
+		}
+	}
+	for name, prop := range s.Properties {
+		dropBranchDefaults(&prop)
+		s.Properties[name] = prop
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		dropBranchDefaults(s.Items.Schema)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		dropBranchDefaults(s.AdditionalProperties.Schema)
+	}
+}
+
+func kindFromName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if r == '-' || r == '_' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
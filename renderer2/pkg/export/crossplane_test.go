@@ -0,0 +1,143 @@
+package export
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestToCrossplaneMapsFieldReferences(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web-app"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Schema: types.Schema{
+				Parameters: map[string]any{"replicas": "integer | default=1"},
+			},
+			Resources: []types.ResourceTemplate{
+				{
+					ID: "deployment",
+					Template: map[string]any{
+						"apiVersion": "apps/v1",
+						"kind":       "Deployment",
+						"metadata": map[string]any{
+							"name":      "${metadata.name}-web",
+							"namespace": "${metadata.namespace}",
+						},
+						"spec": map[string]any{
+							"replicas": "${spec.replicas}",
+							"paused":   "${spec.replicas == 0}",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := ToCrossplane(ctd, nil, CrossplaneOptions{})
+	if err != nil {
+		t.Fatalf("ToCrossplane() error = %v", err)
+	}
+
+	resources := result.Composition["spec"].(map[string]any)["resources"].([]any)
+	if len(resources) != 1 {
+		t.Fatalf("expected 1 composed resource, got %d", len(resources))
+	}
+	entry := resources[0].(map[string]any)
+
+	wantBase := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{},
+		"spec":       map[string]any{},
+	}
+	if diff := cmp.Diff(wantBase, entry["base"]); diff != "" {
+		t.Fatalf("base mismatch (-want +got):\n%s", diff)
+	}
+
+	wantPatches := []any{
+		map[string]any{
+			"type": "CombineFromComposite",
+			"combine": map[string]any{
+				"variables": []any{map[string]any{"fromFieldPath": "metadata.name"}},
+				"strategy":  "string",
+				"string":    map[string]any{"fmt": "%s-web"},
+			},
+			"toFieldPath": "metadata.name",
+		},
+		map[string]any{
+			"type":          "FromCompositeFieldPath",
+			"fromFieldPath": "spec.claimRef.namespace",
+			"toFieldPath":   "metadata.namespace",
+		},
+		map[string]any{
+			"type":          "FromCompositeFieldPath",
+			"fromFieldPath": "spec.replicas",
+			"toFieldPath":   "spec.replicas",
+		},
+	}
+	if diff := cmp.Diff(wantPatches, entry["patches"]); diff != "" {
+		t.Fatalf("patches mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(result.Warnings) != 1 {
+		t.Fatalf("expected one warning for the unmappable expression, got %v", result.Warnings)
+	}
+	if kind := result.XRD["spec"].(map[string]any)["names"].(map[string]any)["kind"]; kind != "XWebApp" {
+		t.Fatalf("XRD kind = %v, want XWebApp", kind)
+	}
+}
+
+func TestToCrossplaneListItems(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "worker"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Resources: []types.ResourceTemplate{
+				{
+					ID: "config",
+					Template: map[string]any{
+						"kind": "ConfigMap",
+						"args": []any{"--name", "${metadata.name}", "${size(spec.args)}", "${spec.replicas}", map[string]any{"port": 8080}},
+					},
+				},
+			},
+		},
+	}
+
+	result, err := ToCrossplane(ctd, nil, CrossplaneOptions{})
+	if err != nil {
+		t.Fatalf("ToCrossplane() error = %v", err)
+	}
+	entry := result.Composition["spec"].(map[string]any)["resources"].([]any)[0].(map[string]any)
+
+	// The unmappable item is dropped and the items after it move up, patches included.
+	wantBase := map[string]any{
+		"kind": "ConfigMap",
+		"args": []any{"--name", "", "", map[string]any{"port": 8080}},
+	}
+	if diff := cmp.Diff(wantBase, entry["base"]); diff != "" {
+		t.Errorf("base mismatch (-want +got):\n%s", diff)
+	}
+	wantPatches := []any{
+		map[string]any{
+			"type":          "FromCompositeFieldPath",
+			"fromFieldPath": "metadata.name",
+			"toFieldPath":   "args[1]",
+		},
+		map[string]any{
+			"type":          "FromCompositeFieldPath",
+			"fromFieldPath": "spec.replicas",
+			"toFieldPath":   "args[2]",
+		},
+	}
+	if diff := cmp.Diff(wantPatches, entry["patches"]); diff != "" {
+		t.Errorf("patches mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Warnings) != 1 {
+		t.Errorf("expected one warning for the unmappable item, got %v", result.Warnings)
+	}
+}
@@ -0,0 +1,208 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+)
+
+var terraformNameSanitizer = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// ToTerraformJSON converts rendered resources into a Terraform JSON configuration (.tf.json)
+// declaring one kubernetes_manifest resource per rendered object.
+func ToTerraformJSON(resources []map[string]any) ([]byte, error) {
+	manifests := make(map[string]any, len(resources))
+	for i, name := range TerraformResourceNames(resources) {
+		manifests[name] = map[string]any{
+			"manifest": escapeTerraformValue(resources[i]),
+		}
+	}
+
+	doc := map[string]any{
+		"resource": map[string]any{
+			"kubernetes_manifest": manifests,
+		},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal terraform JSON: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// ToTerraformHCL converts rendered resources into HCL kubernetes_manifest blocks.
+func ToTerraformHCL(resources []map[string]any) ([]byte, error) {
+	var b strings.Builder
+	for i, name := range TerraformResourceNames(resources) {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "resource \"kubernetes_manifest\" %q {\n", name)
+		b.WriteString("  manifest = ")
+		if err := writeHCLValue(&b, resources[i], 1); err != nil {
+			return nil, fmt.Errorf("resource %s: %w", name, err)
+		}
+		b.WriteString("\n}\n")
+	}
+	return []byte(b.String()), nil
+}
+
+// TerraformResourceNames derives stable, unique Terraform resource names (kind_namespace_name)
+// for the given resources, preserving input order.
+func TerraformResourceNames(resources []map[string]any) []string {
+	names := make([]string, len(resources))
+	seen := map[string]int{}
+	for i, resource := range resources {
+		parts := []string{}
+		if kind, ok := resource["kind"].(string); ok && kind != "" {
+			parts = append(parts, kind)
+		}
+		if metadata, ok := resource["metadata"].(map[string]any); ok {
+			if ns, ok := metadata["namespace"].(string); ok && ns != "" {
+				parts = append(parts, ns)
+			}
+			if name, ok := metadata["name"].(string); ok && name != "" {
+				parts = append(parts, name)
+			}
+		}
+
+		base := terraformNameSanitizer.ReplaceAllString(strings.ToLower(strings.Join(parts, "_")), "_")
+		base = strings.Trim(base, "_")
+		if base == "" {
+			base = "manifest"
+		}
+		if base[0] >= '0' && base[0] <= '9' {
+			base = "r_" + base
+		}
+
+		name := base
+		if count := seen[base]; count > 0 {
+			name = fmt.Sprintf("%s_%d", base, count+1)
+		}
+		seen[base]++
+		names[i] = name
+	}
+	return names
+}
+
+// escapeTerraformValue escapes template sequences so Terraform treats string values literally.
+func escapeTerraformValue(v any) any {
+	switch typed := v.(type) {
+	case string:
+		return escapeTerraformString(typed)
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for k, item := range typed {
+			result[k] = escapeTerraformValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			result[i] = escapeTerraformValue(item)
+		}
+		return result
+	default:
+		return typed
+	}
+}
+
+func escapeTerraformString(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	s = strings.ReplaceAll(s, "%{", "%%{")
+	return s
+}
+
+// quoteHCL returns s as an HCL quoted template string that evaluates to s. Unlike strconv.Quote it
+// only uses the escapes HCL defines and doubles the $ and % of template sequences; invalid UTF-8
+// is replaced because HCL files must be valid UTF-8.
+func quoteHCL(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case (r == '$' || r == '%') && strings.HasPrefix(s[i+1:], "{"):
+			b.WriteRune(r)
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func writeHCLValue(b *strings.Builder, v any, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	switch typed := v.(type) {
+	case nil:
+		b.WriteString("null")
+	case string:
+		b.WriteString(quoteHCL(typed))
+	case bool:
+		b.WriteString(strconv.FormatBool(typed))
+	case int:
+		b.WriteString(strconv.Itoa(typed))
+	case int64:
+		b.WriteString(strconv.FormatInt(typed, 10))
+	case uint64:
+		b.WriteString(strconv.FormatUint(typed, 10))
+	case float64:
+		b.WriteString(normalize.FormatFloat(typed))
+	case map[string]any:
+		if len(typed) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("{\n")
+		for _, k := range keys {
+			fmt.Fprintf(b, "%s  %s = ", indent, quoteHCL(k))
+			if err := writeHCLValue(b, typed[k], depth+1); err != nil {
+				return err
+			}
+			b.WriteByte('\n')
+		}
+		b.WriteString(indent + "}")
+	case []any:
+		if len(typed) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for _, item := range typed {
+			b.WriteString(indent + "  ")
+			if err := writeHCLValue(b, item, depth+1); err != nil {
+				return err
+			}
+			b.WriteString(",\n")
+		}
+		b.WriteString(indent + "]")
+	default:
+		return fmt.Errorf("unsupported value type %T", v)
+	}
+	return nil
+}
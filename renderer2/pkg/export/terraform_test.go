@@ -0,0 +1,118 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTerraformResourceNames(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{
+		{"kind": "Deployment", "metadata": map[string]any{"name": "web-service", "namespace": "default"}},
+		{"kind": "Deployment", "metadata": map[string]any{"name": "web-service", "namespace": "default"}},
+		{"kind": "ConfigMap", "metadata": map[string]any{"name": "web.config"}},
+	}
+
+	got := TerraformResourceNames(resources)
+	want := []string{"deployment_default_web_service", "deployment_default_web_service_2", "configmap_web_config"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("name[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestToTerraformJSONEscapesTemplates(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{
+		{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]any{"name": "cfg"},
+			"data":       map[string]any{"script": "echo ${HOME}"},
+		},
+	}
+
+	data, err := ToTerraformJSON(resources)
+	if err != nil {
+		t.Fatalf("ToTerraformJSON() error = %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+	manifest := doc["resource"].(map[string]any)["kubernetes_manifest"].(map[string]any)["configmap_cfg"].(map[string]any)["manifest"].(map[string]any)
+	if got := manifest["data"].(map[string]any)["script"]; got != "echo $${HOME}" {
+		t.Fatalf("script = %q, want escaped template sequence", got)
+	}
+}
+
+func TestToTerraformHCL(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{
+		{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]any{"name": "web"},
+			"spec": map[string]any{
+				"replicas": int64(2),
+				"paused":   false,
+				"ports":    []any{int64(80)},
+			},
+		},
+	}
+
+	data, err := ToTerraformHCL(resources)
+	if err != nil {
+		t.Fatalf("ToTerraformHCL() error = %v", err)
+	}
+
+	want := `resource "kubernetes_manifest" "deployment_web" {
+  manifest = {
+    "apiVersion" = "apps/v1"
+    "kind" = "Deployment"
+    "metadata" = {
+      "name" = "web"
+    }
+    "spec" = {
+      "paused" = false
+      "ports" = [
+        80,
+      ]
+      "replicas" = 2
+    }
+  }
+}
+`
+	if got := string(data); got != want {
+		t.Fatalf("unexpected HCL:\n%s\nwant:\n%s", got, strings.TrimSpace(want))
+	}
+}
+
+func TestQuoteHCL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "plain", want: `"plain"`},
+		{in: `say "hi" \ bye`, want: `"say \"hi\" \\ bye"`},
+		{in: "line\nfeed\r\ttab", want: `"line\nfeed\r\ttab"`},
+		{in: "bell\a vtab\v nul\x00 del\x7f", want: `"bell\u0007 vtab\u000B nul\u0000 del\u007F"`},
+		{in: "echo ${HOME} %{if x}y%{endif}", want: `"echo $${HOME} %%{if x}y%%{endif}"`},
+		{in: "$5 and 50% {ok}", want: `"$5 and 50% {ok}"`},
+		{in: "ünïcode 😀  ", want: "\"ünïcode 😀  \""},
+		{in: "bad \xff byte", want: "\"bad � byte\""},
+	}
+	for _, tt := range tests {
+		if got := quoteHCL(tt.in); got != tt.want {
+			t.Errorf("quoteHCL(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package format normalizes ComponentTypeDefinition, Addon, Component and EnvSettings YAML so
+// reviews only show meaningful changes.
+package format
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultWidth is the column after which standalone expressions are wrapped.
+const DefaultWidth = 100
+
+// kinds maps the formatted document kinds to the types whose field order is canonical.
+var kinds = map[string]reflect.Type{
+	"ComponentTypeDefinition": reflect.TypeOf(types.ComponentTypeDefinition{}),
+	"Addon":                   reflect.TypeOf(types.Addon{}),
+	"Component":               reflect.TypeOf(types.Component{}),
+	"EnvSettings":             reflect.TypeOf(types.EnvSettings{}),
+}
+
+// Options controls Format.
+type Options struct {
+	// Width wraps standalone expressions after && and || past this column; zero uses DefaultWidth.
+	Width int
+}
+
+// Format returns content with keys in the order the types declare them, every ${...} expression
+// in canonical CEL spelling and two-space indentation. Unknown keys keep their relative order after
+// the known ones, and free-form maps (templates, parameters) keep theirs entirely. Documents of
+// other kinds pass through unchanged. Comments are preserved.
+func Format(content []byte, opts Options) ([]byte, error) {
+	if opts.Width <= 0 {
+		opts.Width = DefaultWidth
+	}
+
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	recognized := false
+	for i, doc := range docs {
+		root := doc
+		if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+			root = doc.Content[0]
+		}
+		typ, ok := kinds[kindOf(root)]
+		if !ok {
+			continue
+		}
+		recognized = true
+		orderKeys(root, typ)
+		if err := formatExpressions(root, opts.Width); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+	}
+	if !recognized {
+		return content, nil
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+func kindOf(root *yaml.Node) string {
+	if root.Kind != yaml.MappingNode {
+		return ""
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "kind" {
+			return root.Content[i+1].Value
+		}
+	}
+	return ""
+}
+
+// orderKeys sorts mapping keys by the declaration order of the matching struct fields and recurses
+// into fields, slices and maps whose element types are structs.
+func orderKeys(node *yaml.Node, typ reflect.Type) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch typ.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		fields := structFields(typ)
+		type pair struct {
+			key, value *yaml.Node
+			rank       int
+		}
+		pairs := make([]pair, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			rank := len(fields) + i
+			if field, ok := fields[key.Value]; ok {
+				rank = field.rank
+				orderKeys(value, field.typ)
+			}
+			pairs = append(pairs, pair{key: key, value: value, rank: rank})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].rank < pairs[j].rank })
+		node.Content = node.Content[:0]
+		for _, p := range pairs {
+			node.Content = append(node.Content, p.key, p.value)
+		}
+	case reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			return
+		}
+		for _, item := range node.Content {
+			orderKeys(item, typ.Elem())
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 1; i < len(node.Content); i += 2 {
+			orderKeys(node.Content[i], typ.Elem())
+		}
+	}
+}
+
+type structField struct {
+	rank int
+	typ  reflect.Type
+}
+
+func structFields(typ reflect.Type) map[string]structField {
+	fields := make(map[string]structField, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = structField{rank: i, typ: field.Type}
+	}
+	return fields
+}
+
+// formatExpressions rewrites every string scalar that contains an expression. Values that wrap
+// onto several lines become literal blocks.
+func formatExpressions(node *yaml.Node, width int) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag != "!!str" || !strings.Contains(node.Value, "${") {
+			return nil
+		}
+		formatted, err := template.FormatString(node.Value, width)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		if formatted == node.Value {
+			return nil
+		}
+		node.Value = formatted
+		if strings.Contains(formatted, "\n") {
+			node.Style = yaml.LiteralStyle
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if err := formatExpressions(child, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
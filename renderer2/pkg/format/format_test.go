@@ -0,0 +1,122 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "keys follow the type order and templates keep theirs",
+			input: `spec:
+  resources:
+    - template:
+        kind: Service
+        apiVersion: v1
+      id: service
+  workloadType: deployment
+metadata:
+  name: web
+kind: ComponentTypeDefinition
+apiVersion: openchoreo.dev/v1alpha1
+`,
+			want: `apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  workloadType: deployment
+  resources:
+    - id: service
+      template:
+        kind: Service
+        apiVersion: v1
+`,
+		},
+		{
+			name: "expressions are respaced",
+			input: `kind: Component
+spec:
+  parameters:
+    # replicas per zone
+    replicas: ${ spec.zones*2 }
+    name: ${metadata.name}-${ 'svc' }
+`,
+			want: `kind: Component
+spec:
+  parameters:
+    # replicas per zone
+    replicas: ${spec.zones * 2}
+    name: ${metadata.name}-${"svc"}
+`,
+		},
+		{
+			name: "other kinds pass through",
+			input: `kind: Deployment
+b: ${ x }
+a: 1
+`,
+			want: `kind: Deployment
+b: ${ x }
+a: 1
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Format([]byte(tt.input), Options{})
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Format() mismatch (-want +got):\n%s", diff)
+			}
+
+			again, err := Format(got, Options{})
+			if err != nil {
+				t.Fatalf("Format() of formatted output error = %v", err)
+			}
+			if diff := cmp.Diff(string(got), string(again)); diff != "" {
+				t.Errorf("Format() is not idempotent (-first +second):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFormatWrapsLongExpressions(t *testing.T) {
+	t.Parallel()
+
+	input := `kind: ComponentTypeDefinition
+spec:
+  resources:
+    - id: ingress
+      includeWhen: ${has(spec.ingress) && spec.ingress.enabled && spec.ingress.host != "" && spec.ingress.port > 0}
+`
+	want := `kind: ComponentTypeDefinition
+spec:
+  resources:
+    - id: ingress
+      includeWhen: |-
+        ${has(spec.ingress) && spec.ingress.enabled &&
+        spec.ingress.host != "" && spec.ingress.port > 0}
+`
+	got, err := Format([]byte(input), Options{Width: 40})
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("Format() mismatch (-want +got):\n%s", diff)
+	}
+}
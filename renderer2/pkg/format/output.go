@@ -0,0 +1,110 @@
+package format
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+	"gopkg.in/yaml.v3"
+)
+
+// KeyOrder selects how rendered resources order their mapping keys.
+type KeyOrder string
+
+const (
+	// KeyOrderSorted orders every mapping alphabetically.
+	KeyOrderSorted KeyOrder = "sorted"
+	// KeyOrderKubernetes puts conventional Kubernetes fields (apiVersion, kind, metadata, name,
+	// namespace, ...) first, in that order, and sorts the remaining keys alphabetically.
+	KeyOrderKubernetes KeyOrder = "kubernetes"
+)
+
+// kubernetesKeys ranks the keys KeyOrderKubernetes moves to the front of any mapping.
+var kubernetesKeys = map[string]int{
+	"apiVersion":   0,
+	"kind":         1,
+	"metadata":     2,
+	"name":         3,
+	"generateName": 4,
+	"namespace":    5,
+	"labels":       6,
+	"annotations":  7,
+	"spec":         8,
+	"data":         9,
+	"stringData":   10,
+	"binaryData":   11,
+}
+
+// ParseKeyOrder validates a key order; the empty string is KeyOrderSorted.
+func ParseKeyOrder(value string) (KeyOrder, error) {
+	switch order := KeyOrder(value); order {
+	case "":
+		return KeyOrderSorted, nil
+	case KeyOrderSorted, KeyOrderKubernetes:
+		return order, nil
+	default:
+		return "", fmt.Errorf("unknown key order %q (want sorted or kubernetes)", value)
+	}
+}
+
+// ResourceNode returns resource as a YAML node whose mapping keys follow order, so encoding it
+// produces the same bytes on every run.
+func ResourceNode(resource map[string]any, order KeyOrder) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(resource); err != nil {
+		return nil, fmt.Errorf("failed to encode resource: %w", err)
+	}
+	sortKeys(&node, order)
+	formatFloats(&node)
+	return &node, nil
+}
+
+// formatFloats rewrites every float scalar under node with normalize.FormatFloat, so YAML output
+// writes floats like the JSON and Terraform outputs. .nan and .inf are left as they are.
+func formatFloats(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!float" {
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil && !math.IsInf(f, 0) {
+			node.Value = normalize.FormatFloat(f)
+		}
+	}
+	for _, child := range node.Content {
+		formatFloats(child)
+	}
+}
+
+// sortKeys orders the keys of every mapping under node.
+func sortKeys(node *yaml.Node, order KeyOrder) {
+	if node.Kind == yaml.MappingNode {
+		type pair struct{ key, value *yaml.Node }
+		pairs := make([]pair, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, pair{key: node.Content[i], value: node.Content[i+1]})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool {
+			return keyLess(pairs[i].key.Value, pairs[j].key.Value, order)
+		})
+		node.Content = node.Content[:0]
+		for _, p := range pairs {
+			node.Content = append(node.Content, p.key, p.value)
+		}
+	}
+	for _, child := range node.Content {
+		sortKeys(child, order)
+	}
+}
+
+func keyLess(a, b string, order KeyOrder) bool {
+	if order == KeyOrderKubernetes {
+		rankA, knownA := kubernetesKeys[a]
+		rankB, knownB := kubernetesKeys[b]
+		switch {
+		case knownA && knownB:
+			return rankA < rankB
+		case knownA != knownB:
+			return knownA
+		}
+	}
+	return a < b
+}
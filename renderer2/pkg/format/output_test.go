@@ -0,0 +1,138 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResourceNode(t *testing.T) {
+	t.Parallel()
+
+	resource := map[string]any{
+		"spec": map[string]any{
+			"template": map[string]any{
+				"spec": map[string]any{
+					"containers": []any{map[string]any{"image": "app:v1", "name": "app", "env": []any{}}},
+				},
+			},
+			"replicas": 2,
+		},
+		"metadata":   map[string]any{"labels": map[string]any{"tier": "web", "app": "web"}, "name": "web", "namespace": "shop"},
+		"kind":       "Deployment",
+		"apiVersion": "apps/v1",
+		"status":     map[string]any{},
+	}
+
+	tests := []struct {
+		order KeyOrder
+		want  string
+	}{
+		{
+			order: KeyOrderSorted,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+    labels:
+        app: web
+        tier: web
+    name: web
+    namespace: shop
+spec:
+    replicas: 2
+    template:
+        spec:
+            containers:
+                - env: []
+                  image: app:v1
+                  name: app
+status: {}
+`,
+		},
+		{
+			order: KeyOrderKubernetes,
+			want: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+    name: web
+    namespace: shop
+    labels:
+        app: web
+        tier: web
+spec:
+    replicas: 2
+    template:
+        spec:
+            containers:
+                - name: app
+                  env: []
+                  image: app:v1
+status: {}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(string(tt.order), func(t *testing.T) {
+			t.Parallel()
+
+			node, err := ResourceNode(resource, tt.order)
+			if err != nil {
+				t.Fatalf("ResourceNode() error = %v", err)
+			}
+			got, err := yaml.Marshal(node)
+			if err != nil {
+				t.Fatalf("failed to marshal node: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("ResourceNode() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseKeyOrder(t *testing.T) {
+	t.Parallel()
+
+	if got, err := ParseKeyOrder(""); err != nil || got != KeyOrderSorted {
+		t.Errorf("ParseKeyOrder(\"\") = %q, %v; want sorted", got, err)
+	}
+	if _, err := ParseKeyOrder("source"); err == nil {
+		t.Errorf("ParseKeyOrder(\"source\") succeeded, want error")
+	}
+}
+
+func TestResourceNodeFormatsFloats(t *testing.T) {
+	t.Parallel()
+
+	node, err := ResourceNode(map[string]any{"data": map[string]any{
+		"ratio":   0.00001,
+		"half":    0.5,
+		"huge":    1e21,
+		"tiny":    1.5e-7,
+		"whole":   3.0,
+		"text":    "1e-05",
+		"integer": 7,
+	}}, KeyOrderSorted)
+	if err != nil {
+		t.Fatalf("ResourceNode() error = %v", err)
+	}
+	encoded, err := yaml.Marshal(node)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error = %v", err)
+	}
+	want := `data:
+    half: 0.5
+    huge: 1e+21
+    integer: 7
+    ratio: 0.00001
+    text: "1e-05"
+    tiny: 1.5e-7
+    whole: 3
+`
+	if diff := cmp.Diff(want, string(encoded)); diff != "" {
+		t.Errorf("encoded mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,165 @@
+// Package images pins floating container image tags to digests so renders are reproducible.
+package images
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+)
+
+const defaultResolveTimeout = 30 * time.Second
+
+// Resolver returns the content digest (sha256:...) an image reference currently points to.
+type Resolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// Reference is a parsed container image reference.
+type Reference struct {
+	// Registry is the registry host, e.g. docker.io or ghcr.io.
+	Registry string
+	// Repository is the path within the registry, e.g. library/nginx.
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference splits an image reference. References without a registry host default to
+// docker.io (with the library/ namespace for single-segment names) and an untagged reference
+// without digest gets the implicit "latest" tag.
+func ParseReference(image string) (Reference, error) {
+	if image == "" || strings.ContainsAny(image, " \t\n") {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	var ref Reference
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		ref.Digest = name[at+1:]
+		name = name[:at]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid digest in image reference %q", image)
+		}
+	}
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		ref.Tag = name[colon+1:]
+		name = name[:colon]
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	ref.Registry = "docker.io"
+	ref.Repository = name
+	if slash := strings.Index(name, "/"); slash >= 0 {
+		host := name[:slash]
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			ref.Repository = name[slash+1:]
+		}
+	}
+	if ref.Registry == "docker.io" && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// Pinned reports whether the reference already names a digest.
+func (r Reference) Pinned() bool {
+	return r.Digest != ""
+}
+
+// Pin returns image with digest appended, keeping the tag for readability (name:tag@sha256:...).
+func Pin(image, digest string) string {
+	if at := strings.Index(image, "@"); at >= 0 {
+		image = image[:at]
+	}
+	return image + "@" + digest
+}
+
+// Pinner is a post-render hook that rewrites every container image in the rendered pod templates
+// to its digest. Images that already carry a digest are left as written. Because it works on the
+// rendered output, it covers build.image as well as images contributed by addons.
+type Pinner struct {
+	pipeline.NopHooks
+	Resolver Resolver
+	// Timeout bounds each resolution; zero uses 30 seconds.
+	Timeout time.Duration
+}
+
+var _ pipeline.Hooks = Pinner{}
+
+func (Pinner) Name() string { return "image-pinner" }
+
+func (p Pinner) PostRender(_ *pipeline.HookContext, resources []map[string]any) ([]map[string]any, error) {
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultResolveTimeout
+	}
+
+	for _, resource := range resources {
+		for _, container := range containers(resource) {
+			image, ok := container["image"].(string)
+			if !ok || image == "" {
+				continue
+			}
+			ref, err := ParseReference(image)
+			if err != nil {
+				return nil, err
+			}
+			if ref.Pinned() {
+				continue
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			digest, err := p.Resolver.Resolve(ctx, image)
+			cancel()
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve image %s: %w", image, err)
+			}
+			container["image"] = Pin(image, digest)
+		}
+	}
+	return resources, nil
+}
+
+// containers returns the containers and init containers of Pods and pod templates.
+func containers(resource map[string]any) []map[string]any {
+	var path []string
+	switch resource["kind"] {
+	case "Pod":
+		path = []string{"spec"}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		path = []string{"spec", "template", "spec"}
+	case "CronJob":
+		path = []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return nil
+	}
+
+	podSpec := resource
+	for _, key := range path {
+		next, ok := podSpec[key].(map[string]any)
+		if !ok {
+			return nil
+		}
+		podSpec = next
+	}
+
+	var result []map[string]any
+	for _, field := range []string{"initContainers", "containers"} {
+		items, _ := podSpec[field].([]any)
+		for _, item := range items {
+			if container, ok := item.(map[string]any); ok {
+				result = append(result, container)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,141 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{"nginx", Reference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{"fluent/fluent-bit:2.1", Reference{Registry: "docker.io", Repository: "fluent/fluent-bit", Tag: "2.1"}},
+		{"ghcr.io/acme/app:v1", Reference{Registry: "ghcr.io", Repository: "acme/app", Tag: "v1"}},
+		{"localhost:5000/app", Reference{Registry: "localhost:5000", Repository: "app", Tag: "latest"}},
+		{"app:v1@sha256:abc", Reference{Registry: "docker.io", Repository: "library/app", Tag: "v1", Digest: "sha256:abc"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.image, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseReference(tt.image)
+			if err != nil {
+				t.Fatalf("ParseReference() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseReference() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRegistryResolverTokenFlow(t *testing.T) {
+	t.Parallel()
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if got := r.URL.Query().Get("scope"); got != "repository:acme/app:pull" {
+				t.Errorf("token scope = %q", got)
+			}
+			_, _ = w.Write([]byte(`{"token":"secret"}`))
+		case r.URL.Path == "/v2/acme/app/manifests/v1":
+			if r.Header.Get("Authorization") != "Bearer secret" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:feed")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &RegistryResolver{Client: server.Client()}
+
+	got, err := resolver.Resolve(context.Background(), host+"/acme/app:v1")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got != "sha256:feed" {
+		t.Errorf("Resolve() = %q, want sha256:feed", got)
+	}
+	if _, err := resolver.Resolve(context.Background(), host+"/acme/missing:v1"); err == nil {
+		t.Errorf("Resolve() of a missing image succeeded, want error")
+	}
+}
+
+type staticResolver map[string]string
+
+func (s staticResolver) Resolve(_ context.Context, image string) (string, error) {
+	if digest, ok := s[image]; ok {
+		return digest, nil
+	}
+	return "", errors.New("unknown image")
+}
+
+func TestPinnerWithLockfile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "images.lock")
+	lock, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile() error = %v", err)
+	}
+	lock.Images["app:v1"] = "sha256:aaa"
+	resolver := NewLockedResolver(lock, staticResolver{"fluent/fluent-bit:2.1": "sha256:bbb"})
+
+	deployment := map[string]any{
+		"kind": "Deployment",
+		"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+			"initContainers": []any{map[string]any{"name": "init", "image": "busybox@sha256:ccc"}},
+			"containers": []any{
+				map[string]any{"name": "app", "image": "app:v1"},
+				map[string]any{"name": "logger", "image": "fluent/fluent-bit:2.1"},
+			},
+		}}},
+	}
+	if _, err := (Pinner{Resolver: resolver}).PostRender(nil, []map[string]any{deployment}); err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+
+	var got []string
+	for _, container := range containers(deployment) {
+		got = append(got, container["image"].(string))
+	}
+	want := []string{"busybox@sha256:ccc", "app:v1@sha256:aaa", "fluent/fluent-bit:2.1@sha256:bbb"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("images mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := LoadLockfile(path)
+	if err != nil {
+		t.Fatalf("LoadLockfile() error = %v", err)
+	}
+	offline := NewLockedResolver(reloaded, nil)
+	if digest, err := offline.Resolve(context.Background(), "fluent/fluent-bit:2.1"); err != nil || digest != "sha256:bbb" {
+		t.Errorf("offline Resolve() = %q, %v; want sha256:bbb", digest, err)
+	}
+	if _, err := offline.Resolve(context.Background(), "other:v1"); err == nil {
+		t.Errorf("offline Resolve() of an unlocked image succeeded, want error")
+	}
+}
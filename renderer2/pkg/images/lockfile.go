@@ -0,0 +1,89 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Lockfile records the digest each image reference resolved to.
+type Lockfile struct {
+	Images map[string]string `yaml:"images"`
+}
+
+// LoadLockfile reads a lockfile. A missing file yields an empty lockfile so the first render can
+// create it.
+func LoadLockfile(path string) (*Lockfile, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Lockfile{Images: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image lockfile: %w", err)
+	}
+
+	var lock Lockfile
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse image lockfile: %w", err)
+	}
+	if lock.Images == nil {
+		lock.Images = map[string]string{}
+	}
+	return &lock, nil
+}
+
+// Save writes the lockfile; yaml.v3 sorts map keys, so the output is stable.
+func (l *Lockfile) Save(path string) error {
+	content, err := yaml.Marshal(l)
+	if err != nil {
+		return fmt.Errorf("failed to encode image lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write image lockfile: %w", err)
+	}
+	return nil
+}
+
+// LockedResolver answers from the lockfile and falls back to another resolver for images the
+// lockfile does not know yet, recording what it resolved. Without a fallback, unknown images are
+// an error, which gives fully offline renders.
+type LockedResolver struct {
+	mu       sync.Mutex
+	lock     *Lockfile
+	fallback Resolver
+}
+
+var _ Resolver = (*LockedResolver)(nil)
+
+// NewLockedResolver wraps lock; fallback may be nil.
+func NewLockedResolver(lock *Lockfile, fallback Resolver) *LockedResolver {
+	if lock.Images == nil {
+		lock.Images = map[string]string{}
+	}
+	return &LockedResolver{lock: lock, fallback: fallback}
+}
+
+func (r *LockedResolver) Resolve(ctx context.Context, image string) (string, error) {
+	r.mu.Lock()
+	digest, ok := r.lock.Images[image]
+	r.mu.Unlock()
+	if ok {
+		return digest, nil
+	}
+	if r.fallback == nil {
+		return "", fmt.Errorf("image %s is not in the lockfile", image)
+	}
+
+	digest, err := r.fallback.Resolve(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.lock.Images[image] = digest
+	r.mu.Unlock()
+	return digest, nil
+}
@@ -0,0 +1,184 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/transport"
+)
+
+// manifestAccept lists the manifest media types a registry may answer with; multi-arch indexes
+// come first so the digest pins the whole image rather than one platform.
+var manifestAccept = strings.Join([]string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}, ", ")
+
+// RegistryResolver resolves tags against the OCI distribution API. It supports anonymous access
+// and the bearer token flow used by Docker Hub, GHCR and most public registries.
+type RegistryResolver struct {
+	// Client performs the requests; nil uses a client retrying with transport.DefaultOptions.
+	Client *http.Client
+	// PlainHTTP lists registry hosts reached over http instead of https (e.g. local registries).
+	PlainHTTP []string
+}
+
+var _ Resolver = (*RegistryResolver)(nil)
+
+// Resolve issues a HEAD request for the tag's manifest and returns its Docker-Content-Digest.
+func (r *RegistryResolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Pinned() {
+		return ref.Digest, nil
+	}
+
+	manifestURL := fmt.Sprintf("%s://%s/v2/%s/manifests/%s", r.scheme(ref.Registry), registryHost(ref.Registry), ref.Repository, ref.Tag)
+	resp, err := r.head(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := r.token(ctx, resp.Header.Get("WWW-Authenticate"), ref)
+		if err != nil {
+			return "", err
+		}
+		if resp, err = r.head(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s for %s", resp.Status, manifestURL)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return a digest for %s", image)
+	}
+	return digest, nil
+}
+
+func (r *RegistryResolver) head(ctx context.Context, url, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build manifest request: %w", err)
+	}
+	req.Header.Set("Accept", manifestAccept)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// token performs the anonymous bearer token exchange described by a WWW-Authenticate challenge.
+func (r *RegistryResolver) token(ctx context.Context, challenge string, ref Reference) (string, error) {
+	params, ok := parseBearerChallenge(challenge)
+	if !ok || params["realm"] == "" {
+		return "", fmt.Errorf("registry %s requires unsupported authentication %q", ref.Registry, challenge)
+	}
+
+	query := url.Values{}
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + ref.Repository + ":pull"
+	}
+	query.Set("scope", scope)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build token request: %w", err)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token endpoint returned no token")
+}
+
+var defaultClient = transport.NewClient(transport.DefaultOptions())
+
+func (r *RegistryResolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return defaultClient
+}
+
+func (r *RegistryResolver) scheme(registry string) string {
+	for _, host := range r.PlainHTTP {
+		if host == registry {
+			return "http"
+		}
+	}
+	return "https"
+}
+
+// registryHost maps the docker.io alias to the host that serves its API.
+func registryHost(registry string) string {
+	if registry == "docker.io" {
+		return "registry-1.docker.io"
+	}
+	return registry
+}
+
+// parseBearerChallenge parses `Bearer realm="...",service="...",scope="..."`.
+func parseBearerChallenge(challenge string) (map[string]string, bool) {
+	scheme, rest, ok := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for rest != "" {
+		var key, value string
+		key, rest, ok = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				return nil, false
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		params[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return params, true
+}
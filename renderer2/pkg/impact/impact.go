@@ -0,0 +1,190 @@
+package impact
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+)
+
+// Usage locates an expression that reads a parameter.
+type Usage struct {
+	// Resource is the resource template ID.
+	Resource string `json:"resource" yaml:"resource"`
+	// Field is the path inside the rendered resource (e.g. spec.template.spec.containers[0].image),
+	// or "includeWhen" / "forEach" / "fieldsWhen <pointer>" for the template's control expressions.
+	Field string `json:"field" yaml:"field"`
+}
+
+// Parameter lists every place a schema parameter influences.
+type Parameter struct {
+	// Path is the parameter's input path, e.g. spec.resources.limits.cpu.
+	Path   string  `json:"path" yaml:"path"`
+	Usages []Usage `json:"usages" yaml:"usages"`
+}
+
+// Map is the parameter-to-resource impact map of a definition. Parameters are sorted by path;
+// parameters nothing reads have no usages.
+type Map struct {
+	Definition string      `json:"definition" yaml:"definition"`
+	Parameters []Parameter `json:"parameters" yaml:"parameters"`
+}
+
+// Build analyzes the resource templates of a definition's storage version and reports, for every
+// parameter declared in its schema (parameters and envOverrides), the templates and fields whose
+// expressions read it. Reads of a parent object (e.g. `${spec.resources}`) count as a usage of all
+// parameters below it.
+func Build(engine *template.Engine, ctd *types.ComponentTypeDefinition) (*Map, error) {
+	resolved, err := versioning.ResolveVersion(ctd, "")
+	if err != nil {
+		return nil, err
+	}
+
+	params := declaredParameters(resolved.Spec.Schema)
+	usages := make(map[string][]Usage, len(params))
+
+	record := func(resource, field string, data any) error {
+		references, err := engine.References(data)
+		if err != nil {
+			return fmt.Errorf("resource %s, field %s: %w", resource, displayField(field), err)
+		}
+		for _, param := range params {
+			for _, ref := range references {
+				if !template.PathsOverlap(ref, param) {
+					continue
+				}
+				usage := Usage{Resource: resource, Field: displayField(field)}
+				if existing := usages[param]; len(existing) == 0 || existing[len(existing)-1] != usage {
+					usages[param] = append(existing, usage)
+				}
+				break
+			}
+		}
+		return nil
+	}
+
+	// commonMetadata is merged into every resource, so its expressions count for each template.
+	common := resolved.Spec.CommonMetadata.Template()
+	for _, tmpl := range resolved.Spec.Resources {
+		if err := record(tmpl.ID, "includeWhen", tmpl.IncludeWhen); err != nil {
+			return nil, err
+		}
+		if err := record(tmpl.ID, "forEach", tmpl.ForEach); err != nil {
+			return nil, err
+		}
+		pointers := make([]string, 0, len(tmpl.FieldsWhen))
+		for pointer := range tmpl.FieldsWhen {
+			pointers = append(pointers, pointer)
+		}
+		sort.Strings(pointers)
+		for _, pointer := range pointers {
+			if err := record(tmpl.ID, "fieldsWhen "+pointer, tmpl.FieldsWhen[pointer]); err != nil {
+				return nil, err
+			}
+		}
+		if err := walkFields(tmpl.Template, "", func(field string, value any) error {
+			return record(tmpl.ID, field, value)
+		}); err != nil {
+			return nil, err
+		}
+		if err := walkFields(common, "metadata", func(field string, value any) error {
+			return record(tmpl.ID, field, value)
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &Map{Definition: ctd.Metadata.Name}
+	for _, param := range params {
+		result.Parameters = append(result.Parameters, Parameter{Path: param, Usages: usages[param]})
+	}
+	return result, nil
+}
+
+// walkFields calls visit for every string in a template (map keys included) with the field path
+// it appears at.
+func walkFields(data any, path string, visit func(field string, value any) error) error {
+	switch v := data.(type) {
+	case string:
+		return visit(path, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			field := key
+			if path != "" {
+				field = path + "." + key
+			}
+			if err := visit(field, key); err != nil {
+				return err
+			}
+			if err := walkFields(v[key], field, visit); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := walkFields(item, path+"["+strconv.Itoa(i)+"]", visit); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// declaredParameters returns the sorted input paths of every leaf declared in a simple schema.
+// Custom-typed and collection fields are leaves.
+func declaredParameters(s types.Schema) []string {
+	seen := map[string]bool{}
+	var walk func(map[string]any, string)
+	walk = func(fields map[string]any, prefix string) {
+		for name, value := range fields {
+			path := prefix + "." + name
+			if nested, ok := value.(map[string]any); ok {
+				walk(nested, path)
+				continue
+			}
+			seen[path] = true
+		}
+	}
+	walk(s.Parameters, "spec")
+	walk(s.EnvOverrides, "spec")
+
+	paths := make([]string, 0, len(seen))
+	for path := range seen {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func displayField(field string) string {
+	if field == "" {
+		return "(root)"
+	}
+	return field
+}
+
+// Text renders the map for humans: one block per parameter with its usages indented below.
+func (m *Map) Text() string {
+	var b strings.Builder
+	for _, param := range m.Parameters {
+		b.WriteString(param.Path)
+		b.WriteByte('\n')
+		if len(param.Usages) == 0 {
+			b.WriteString("  (not referenced)\n")
+			continue
+		}
+		for _, usage := range param.Usages {
+			fmt.Fprintf(&b, "  %s: %s\n", usage.Resource, usage.Field)
+		}
+	}
+	return b.String()
+}
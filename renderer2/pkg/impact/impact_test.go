@@ -0,0 +1,92 @@
+package impact
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuild(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Schema: types.Schema{
+				Parameters: map[string]any{
+					"replicas": "integer | default=1",
+					"expose":   "boolean | default=false",
+					"unused":   "string | default=x",
+				},
+				EnvOverrides: map[string]any{
+					"resources": map[string]any{
+						"limits": map[string]any{"cpu": "string | default=500m"},
+					},
+				},
+			},
+			Resources: []types.ResourceTemplate{
+				{
+					ID: "deployment",
+					Template: map[string]any{
+						"spec": map[string]any{
+							"replicas": "${spec.replicas}",
+							"template": map[string]any{
+								"spec": map[string]any{
+									"containers": []any{
+										map[string]any{"resources": "${spec.resources}"},
+									},
+								},
+							},
+						},
+					},
+				},
+				{
+					ID:          "service",
+					IncludeWhen: "${spec.expose}",
+					Template: map[string]any{
+						"metadata": map[string]any{"name": "${metadata.name}-${spec.replicas > 1 ? 'ha' : 'single'}"},
+					},
+				},
+			},
+		},
+	}
+
+	got, err := Build(template.NewEngine(), ctd)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	want := &Map{
+		Definition: "web",
+		Parameters: []Parameter{
+			{Path: "spec.expose", Usages: []Usage{{Resource: "service", Field: "includeWhen"}}},
+			{Path: "spec.replicas", Usages: []Usage{
+				{Resource: "deployment", Field: "spec.replicas"},
+				{Resource: "service", Field: "metadata.name"},
+			}},
+			{Path: "spec.resources.limits.cpu", Usages: []Usage{
+				{Resource: "deployment", Field: "spec.template.spec.containers[0].resources"},
+			}},
+			{Path: "spec.unused"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Build() mismatch (-want +got):\n%s", diff)
+	}
+
+	wantText := `spec.expose
+  service: includeWhen
+spec.replicas
+  deployment: spec.replicas
+  service: metadata.name
+spec.resources.limits.cpu
+  deployment: spec.template.spec.containers[0].resources
+spec.unused
+  (not referenced)
+`
+	if diff := cmp.Diff(wantText, got.Text()); diff != "" {
+		t.Errorf("Text() mismatch (-want +got):\n%s", diff)
+	}
+}
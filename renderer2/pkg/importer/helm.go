@@ -0,0 +1,213 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// HelmOptions configures a Helm chart import.
+type HelmOptions struct {
+	// ComponentName names the generated Component; defaults to the chart name.
+	ComponentName string
+	// ComponentType is the definition the Component will reference.
+	ComponentType string
+}
+
+// HelmResult is a Component carrying the chart's values as parameters plus a suggested schema
+// skeleton for the ComponentTypeDefinition that will replace the chart.
+type HelmResult struct {
+	Component *types.Component
+	Schema    types.Schema
+	Warnings  []string
+}
+
+// FromHelmChart converts a chart directory's values.yaml into Component parameters. When the chart
+// ships a values.schema.json it is imported with FromJSONSchema; otherwise the schema is inferred
+// from the values, with every value becoming the field's default.
+func FromHelmChart(chartDir string, opts HelmOptions) (*HelmResult, error) {
+	var chart struct {
+		Name string `yaml:"name"`
+	}
+	if err := readYAML(filepath.Join(chartDir, "Chart.yaml"), &chart); err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := readYAML(filepath.Join(chartDir, "values.yaml"), &values); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	schemaJSON, err := os.ReadFile(filepath.Join(chartDir, "values.schema.json"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	result, err := FromHelmValues(values, schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	name := opts.ComponentName
+	if name == "" {
+		name = chart.Name
+	}
+	result.Component.Metadata.Name = name
+	result.Component.Spec.ComponentType = opts.ComponentType
+	return result, nil
+}
+
+// FromHelmValues converts already-parsed Helm values. schemaJSON may be nil, in which case the
+// schema is inferred from the values.
+func FromHelmValues(values map[string]any, schemaJSON []byte) (*HelmResult, error) {
+	inf := &valuesInferrer{}
+	parameters := inf.prune("", values)
+
+	result := &HelmResult{
+		Component: &types.Component{
+			APIVersion: "openchoreo.dev/v1alpha1",
+			Kind:       "Component",
+			Spec:       types.ComponentSpec{Parameters: parameters},
+		},
+	}
+
+	if schemaJSON != nil {
+		imported, err := FromJSONSchema(schemaJSON, Options{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to import values schema: %w", err)
+		}
+		result.Schema = imported.Schema
+		result.Warnings = append(inf.warnings, imported.Warnings...)
+		return result, nil
+	}
+
+	result.Schema = types.Schema{Parameters: inf.fields("", parameters)}
+	if len(inf.types) > 0 {
+		result.Schema.Types = inf.types
+	}
+	result.Warnings = inf.warnings
+	return result, nil
+}
+
+type valuesInferrer struct {
+	types    map[string]any
+	warnings []string
+}
+
+func (inf *valuesInferrer) warnf(path, format string, args ...any) {
+	inf.warnings = append(inf.warnings, fmt.Sprintf("%s: %s", displayPath(path), fmt.Sprintf(format, args...)))
+}
+
+// prune drops null values and empty collections, which Helm charts commonly use as placeholders
+// but which carry no type information.
+func (inf *valuesInferrer) prune(path string, values map[string]any) map[string]any {
+	result := make(map[string]any, len(values))
+	for _, key := range sortedKeys(values) {
+		keyPath := joinPath(path, key)
+		switch v := values[key].(type) {
+		case nil:
+			inf.warnf(keyPath, "null value has no type; dropped")
+		case map[string]any:
+			if len(v) == 0 {
+				inf.warnf(keyPath, "empty map has no type; dropped")
+				continue
+			}
+			result[key] = inf.prune(keyPath, v)
+		case []any:
+			if len(v) == 0 {
+				inf.warnf(keyPath, "empty list has no item type; dropped")
+				continue
+			}
+			result[key] = v
+		default:
+			result[key] = v
+		}
+	}
+	return result
+}
+
+func (inf *valuesInferrer) fields(path string, values map[string]any) map[string]any {
+	fields := make(map[string]any, len(values))
+	for _, key := range sortedKeys(values) {
+		keyPath := joinPath(path, key)
+		if nested, ok := values[key].(map[string]any); ok {
+			fields[key] = inf.fields(keyPath, nested)
+			continue
+		}
+		typeExpr := inf.typeOf(keyPath, values[key])
+		if typeExpr == "" {
+			continue
+		}
+		if def, ok := values[key].(string); ok && (def == "" || strings.ContainsFunc(def, unicode.IsSpace)) {
+			// Defaults cannot contain whitespace; keep the field optional instead.
+			fields[key] = typeExpr + " | required=false"
+			continue
+		}
+		if _, isList := values[key].([]any); isList {
+			// List defaults stay in the Component; the definition only declares the shape.
+			fields[key] = typeExpr + " | required=false"
+			continue
+		}
+		fields[key] = typeExpr + " | default=" + formatConstraint(values[key])
+	}
+	return fields
+}
+
+func (inf *valuesInferrer) typeOf(path string, value any) string {
+	switch v := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []any:
+		if len(v) == 0 {
+			inf.warnf(path, "empty list has no item type; skipped")
+			return ""
+		}
+		itemType := inf.typeOf(path+"[]", v[0])
+		if itemType == "" {
+			return ""
+		}
+		return "[]" + itemType
+	case map[string]any:
+		// Objects inside lists become named types; their values are examples, not defaults.
+		if inf.types == nil {
+			inf.types = map[string]any{}
+		}
+		name := pascalCase(strings.TrimSuffix(path, "[]"))
+		if _, exists := inf.types[name]; !exists {
+			shape := make(map[string]any, len(v))
+			for _, key := range sortedKeys(v) {
+				if t := inf.typeOf(joinPath(path, key), v[key]); t != "" {
+					shape[key] = t + " | required=false"
+				}
+			}
+			inf.types[name] = shape
+		}
+		return name
+	default:
+		inf.warnf(path, "cannot infer a type from %T; skipped", value)
+		return ""
+	}
+}
+
+func readYAML(path string, out any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeChart(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	return dir
+}
+
+func TestFromHelmChartInfersSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml": "name: checkout\nversion: 1.2.0\n",
+		"values.yaml": `
+replicaCount: 2
+image:
+  repository: nginx
+  pullPolicy: IfNotPresent
+service:
+  port: 80
+  annotations: {}
+cpuLimit: 0.5
+motd: "hello world"
+nodeSelector:
+tolerations: []
+env:
+  - name: LOG_LEVEL
+    value: info
+`,
+	})
+
+	got, err := FromHelmChart(dir, HelmOptions{ComponentType: "deployment-component"})
+	if err != nil {
+		t.Fatalf("FromHelmChart() error = %v", err)
+	}
+
+	if got.Component.Metadata.Name != "checkout" || got.Component.Spec.ComponentType != "deployment-component" {
+		t.Errorf("component = %s/%s, want checkout/deployment-component", got.Component.Metadata.Name, got.Component.Spec.ComponentType)
+	}
+
+	wantParams := map[string]any{
+		"replicaCount": 2,
+		"image":        map[string]any{"repository": "nginx", "pullPolicy": "IfNotPresent"},
+		"service":      map[string]any{"port": 80},
+		"cpuLimit":     0.5,
+		"motd":         "hello world",
+		"env":          []any{map[string]any{"name": "LOG_LEVEL", "value": "info"}},
+	}
+	if diff := cmp.Diff(wantParams, got.Component.Spec.Parameters); diff != "" {
+		t.Errorf("parameters mismatch (-want +got):\n%s", diff)
+	}
+
+	wantSchema := types.Schema{
+		Types: map[string]any{
+			"Env": map[string]any{"name": "string | required=false", "value": "string | required=false"},
+		},
+		Parameters: map[string]any{
+			"replicaCount": "integer | default=2",
+			"image":        map[string]any{"repository": "string | default=nginx", "pullPolicy": "string | default=IfNotPresent"},
+			"service":      map[string]any{"port": "integer | default=80"},
+			"cpuLimit":     "number | default=0.5",
+			"motd":         "string | required=false",
+			"env":          "[]Env | required=false",
+		},
+	}
+	if diff := cmp.Diff(wantSchema, got.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	wantWarnings := []string{
+		"nodeSelector: null value has no type; dropped",
+		"service.annotations: empty map has no type; dropped",
+		"tolerations: empty list has no item type; dropped",
+	}
+	if diff := cmp.Diff(wantWarnings, got.Warnings); diff != "" {
+		t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestFromHelmChartUsesValuesSchema(t *testing.T) {
+	t.Parallel()
+
+	dir := writeChart(t, map[string]string{
+		"Chart.yaml":  "name: checkout\n",
+		"values.yaml": "replicaCount: 2\n",
+		"values.schema.json": `{
+  "type": "object",
+  "properties": {"replicaCount": {"type": "integer", "minimum": 1, "default": 1}}
+}`,
+	})
+
+	got, err := FromHelmChart(dir, HelmOptions{ComponentName: "shop"})
+	if err != nil {
+		t.Fatalf("FromHelmChart() error = %v", err)
+	}
+	if got.Component.Metadata.Name != "shop" {
+		t.Errorf("component name = %q, want shop", got.Component.Metadata.Name)
+	}
+	want := types.Schema{Parameters: map[string]any{"replicaCount": "integer | default=1 minimum=1"}}
+	if diff := cmp.Diff(want, got.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
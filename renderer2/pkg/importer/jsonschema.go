@@ -0,0 +1,417 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// unsupportedKeywords cannot be expressed in the simple schema syntax and are reported when seen.
+var unsupportedKeywords = []string{"allOf", "anyOf", "oneOf", "not", "if", "patternProperties", "dependentRequired", "dependentSchemas"}
+
+// Options configures a schema import.
+type Options struct {
+	// Root names a definition (under $defs, definitions, or OpenAPI components.schemas) to use as
+	// the parameters object. Empty uses the document root. This is how CUE definitions are imported:
+	// `cue def schema.cue --out openapi` and pick the definition by name.
+	Root string
+}
+
+// Result is an imported simple schema plus anything that could not be carried over.
+type Result struct {
+	Schema   types.Schema
+	Warnings []string
+}
+
+// FromJSONSchema converts a JSON Schema (JSON or YAML encoded) into the simple schema syntax used by
+// ComponentTypeDefinitions. Referenced and nested object schemas become named types; optional
+// fields without defaults get `required=false` since simple schema fields are required by default.
+func FromJSONSchema(data []byte, opts Options) (*Result, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	imp := &jsonSchemaImporter{
+		definitions: collectDefinitions(doc),
+		types:       map[string]any{},
+		refTypes:    map[string]string{},
+	}
+
+	root := doc
+	if opts.Root != "" {
+		def, ok := imp.definitions[opts.Root]
+		if !ok {
+			return nil, fmt.Errorf("schema has no definition %q", opts.Root)
+		}
+		root = def
+	}
+	if t := schemaType(root); t != "object" {
+		return nil, fmt.Errorf("root schema must be an object, got %q", t)
+	}
+
+	parameters, err := imp.objectFields("", root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Schema:   types.Schema{Parameters: parameters},
+		Warnings: imp.warnings,
+	}
+	if len(imp.types) > 0 {
+		result.Schema.Types = imp.types
+	}
+	return result, nil
+}
+
+type jsonSchemaImporter struct {
+	definitions map[string]map[string]any
+	types       map[string]any
+	// refTypes maps definition names to the simple schema type created for them.
+	refTypes map[string]string
+	warnings []string
+}
+
+func (imp *jsonSchemaImporter) warnf(path, format string, args ...any) {
+	imp.warnings = append(imp.warnings, fmt.Sprintf("%s: %s", displayPath(path), fmt.Sprintf(format, args...)))
+}
+
+func (imp *jsonSchemaImporter) objectFields(path string, schema map[string]any) (map[string]any, error) {
+	properties, _ := schema["properties"].(map[string]any)
+	required := map[string]bool{}
+	for _, name := range stringList(schema["required"]) {
+		required[name] = true
+	}
+
+	fields := make(map[string]any, len(properties))
+	for _, name := range sortedKeys(properties) {
+		prop, ok := properties[name].(map[string]any)
+		if !ok {
+			imp.warnf(joinPath(path, name), "property schema is not an object; skipped")
+			continue
+		}
+		field, err := imp.field(joinPath(path, name), prop, required[name])
+		if err != nil {
+			return nil, err
+		}
+		if field != nil {
+			fields[name] = field
+		}
+	}
+	return fields, nil
+}
+
+// field returns either a nested map (required inline object) or a simple schema expression string.
+func (imp *jsonSchemaImporter) field(path string, schema map[string]any, required bool) (any, error) {
+	imp.reportUnsupported(path, schema)
+
+	if _, hasRef := schema["$ref"]; !hasRef && isInlineObject(schema) && required && schema["default"] == nil {
+		return imp.objectFields(path, schema)
+	}
+
+	typeExpr, err := imp.typeExpr(path, schema)
+	if err != nil {
+		return nil, err
+	}
+	if typeExpr == "" {
+		return nil, nil
+	}
+
+	constraints := imp.constraints(path, schema)
+	// A dropped default still made the field optional in the source schema.
+	hasDefault := len(constraints) > 0 && strings.HasPrefix(constraints[0], "default=")
+	if !required && !hasDefault {
+		constraints = append([]string{"required=false"}, constraints...)
+	}
+	if len(constraints) == 0 {
+		return typeExpr, nil
+	}
+	return typeExpr + " | " + strings.Join(constraints, " "), nil
+}
+
+func (imp *jsonSchemaImporter) typeExpr(path string, schema map[string]any) (string, error) {
+	if ref, ok := schema["$ref"].(string); ok {
+		return imp.refType(path, ref)
+	}
+
+	switch t := schemaType(schema); t {
+	case "string", "integer", "number", "boolean":
+		return t, nil
+	case "array":
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			imp.warnf(path, "array without a single items schema imported as []object")
+			return "[]object", nil
+		}
+		itemType, err := imp.typeExpr(path+"[]", items)
+		if err != nil || itemType == "" {
+			return "", err
+		}
+		return "[]" + itemType, nil
+	case "object":
+		properties, _ := schema["properties"].(map[string]any)
+		if additional, ok := schema["additionalProperties"].(map[string]any); ok && len(properties) == 0 {
+			valueType, err := imp.typeExpr(path+"{}", additional)
+			if err != nil || valueType == "" {
+				return "", err
+			}
+			return "map[string]" + valueType, nil
+		}
+		if !isInlineObject(schema) {
+			return "object", nil
+		}
+		return imp.namedType(path, "", schema)
+	case "":
+		imp.warnf(path, "schema has no type; skipped")
+		return "", nil
+	default:
+		imp.warnf(path, "unsupported type %q; skipped", t)
+		return "", nil
+	}
+}
+
+func (imp *jsonSchemaImporter) refType(path, ref string) (string, error) {
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if !strings.HasPrefix(ref, "#/") {
+		return "", fmt.Errorf("%s: external reference %q is not supported", displayPath(path), ref)
+	}
+	if typeName, ok := imp.refTypes[name]; ok {
+		return typeName, nil
+	}
+	def, ok := imp.definitions[name]
+	if !ok {
+		return "", fmt.Errorf("%s: unresolved reference %q", displayPath(path), ref)
+	}
+	if !isInlineObject(def) {
+		// Scalar and array definitions are inlined at each use.
+		return imp.typeExpr(path, def)
+	}
+	return imp.namedType(path, name, def)
+}
+
+// namedType registers an object schema as a custom type. Definitions keep their own name; anonymous
+// objects are named after their property path.
+func (imp *jsonSchemaImporter) namedType(path, definition string, schema map[string]any) (string, error) {
+	base := definition
+	if base == "" {
+		base = path
+	}
+	typeName := imp.uniqueTypeName(pascalCase(base))
+	if definition != "" {
+		imp.refTypes[definition] = typeName
+	}
+	imp.types[typeName] = map[string]any{} // reserve the name for recursive references
+
+	fields, err := imp.objectFields(typeName, schema)
+	if err != nil {
+		return "", err
+	}
+	imp.types[typeName] = fields
+	return typeName, nil
+}
+
+func (imp *jsonSchemaImporter) uniqueTypeName(name string) string {
+	if name == "" {
+		name = "Object"
+	}
+	candidate := name
+	for i := 2; ; i++ {
+		if _, taken := imp.types[candidate]; !taken {
+			return candidate
+		}
+		candidate = name + strconv.Itoa(i)
+	}
+}
+
+func (imp *jsonSchemaImporter) constraints(path string, schema map[string]any) []string {
+	var constraints []string
+	add := func(key string, value any) {
+		constraints = append(constraints, key+"="+formatConstraint(value))
+	}
+
+	if value, ok := schema["default"]; ok && value != nil {
+		if s, isString := value.(string); isString && (s == "" || strings.ContainsFunc(s, unicode.IsSpace)) {
+			imp.warnf(path, "default %q contains whitespace or is empty and cannot be expressed; dropped", s)
+		} else {
+			add("default", value)
+		}
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		parts := make([]string, 0, len(values))
+		expressible := true
+		for _, v := range values {
+			part := formatConstraint(v)
+			if strings.ContainsAny(part, ", \t") || part == "" {
+				expressible = false
+				break
+			}
+			parts = append(parts, part)
+		}
+		if expressible {
+			constraints = append(constraints, "enum="+strings.Join(parts, ","))
+		} else {
+			imp.warnf(path, "enum values contain commas or whitespace and cannot be expressed; dropped")
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if strings.ContainsFunc(pattern, unicode.IsSpace) {
+			imp.warnf(path, "pattern contains whitespace and cannot be expressed; dropped")
+		} else {
+			add("pattern", pattern)
+		}
+	}
+
+	for _, key := range []string{"minimum", "maximum", "minLength", "maxLength", "minItems", "maxItems", "minProperties", "maxProperties", "multipleOf", "uniqueItems", "format"} {
+		if value, ok := schema[key]; ok {
+			add(key, value)
+		}
+	}
+	// Draft 4 uses booleans for exclusive bounds; later drafts use the bound itself.
+	for _, bound := range []struct{ exclusive, inclusive string }{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+		switch value := schema[bound.exclusive].(type) {
+		case bool:
+			add(bound.exclusive, value)
+		case float64, int, int64:
+			if _, hasInclusive := schema[bound.inclusive]; !hasInclusive {
+				add(bound.inclusive, value)
+			}
+			add(bound.exclusive, true)
+		}
+	}
+	if nullable, ok := schema["nullable"].(bool); ok && nullable {
+		add("nullable", true)
+	}
+	if types, ok := schema["type"].([]any); ok {
+		for _, t := range types {
+			if t == "null" {
+				add("nullable", true)
+			}
+		}
+	}
+	return constraints
+}
+
+func (imp *jsonSchemaImporter) reportUnsupported(path string, schema map[string]any) {
+	for _, keyword := range unsupportedKeywords {
+		if _, ok := schema[keyword]; ok {
+			imp.warnf(path, "%s is not supported and was ignored", keyword)
+		}
+	}
+}
+
+func collectDefinitions(doc map[string]any) map[string]map[string]any {
+	definitions := map[string]map[string]any{}
+	sources := []any{doc["definitions"], doc["$defs"]}
+	if components, ok := doc["components"].(map[string]any); ok {
+		sources = append(sources, components["schemas"])
+	}
+	for _, source := range sources {
+		defs, _ := source.(map[string]any)
+		for name, raw := range defs {
+			if def, ok := raw.(map[string]any); ok {
+				definitions[name] = def
+			}
+		}
+	}
+	return definitions
+}
+
+// schemaType returns the schema's type, ignoring "null" in type unions and inferring object for
+// schemas that only declare properties.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+func isInlineObject(schema map[string]any) bool {
+	if schemaType(schema) != "object" {
+		return false
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	return len(properties) > 0
+}
+
+func formatConstraint(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
+
+func pascalCase(path string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func stringList(value any) []string {
+	items, _ := value.([]any)
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "(root)"
+	}
+	return path
+}
@@ -0,0 +1,171 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		schema       string
+		opts         Options
+		want         types.Schema
+		wantWarnings []string
+	}{
+		{
+			name: "primitives and constraints",
+			schema: `{
+  "type": "object",
+  "required": ["image", "port"],
+  "properties": {
+    "image": {"type": "string", "pattern": "^[a-z/:.-]+$"},
+    "port": {"type": "integer", "minimum": 1, "maximum": 65535},
+    "replicas": {"type": "integer", "default": 1},
+    "debug": {"type": "boolean"},
+    "tier": {"type": "string", "enum": ["gold", "silver"], "default": "silver"},
+    "ratio": {"type": "number", "exclusiveMinimum": 0}
+  }
+}`,
+			want: types.Schema{Parameters: map[string]any{
+				"image":    "string | pattern=^[a-z/:.-]+$",
+				"port":     "integer | minimum=1 maximum=65535",
+				"replicas": "integer | default=1",
+				"debug":    "boolean | required=false",
+				"tier":     "string | default=silver enum=gold,silver",
+				"ratio":    "number | required=false minimum=0 exclusiveMinimum=true",
+			}},
+		},
+		{
+			name: "nested objects, arrays and maps",
+			schema: `
+type: object
+required: [resources]
+properties:
+  resources:
+    type: object
+    required: [cpu]
+    properties:
+      cpu: {type: string}
+  probe:
+    type: object
+    properties:
+      path: {type: string, default: /healthz}
+  ports:
+    type: array
+    minItems: 1
+    items: {type: integer}
+  labels:
+    type: object
+    additionalProperties: {type: string}
+`,
+			want: types.Schema{
+				Types: map[string]any{
+					"Probe": map[string]any{"path": "string | default=/healthz"},
+				},
+				Parameters: map[string]any{
+					"resources": map[string]any{"cpu": "string"},
+					"probe":     "Probe | required=false",
+					"ports":     "[]integer | required=false minItems=1",
+					"labels":    "map[string]string | required=false",
+				},
+			},
+		},
+		{
+			name: "refs into definitions with a selected root",
+			opts: Options{Root: "Service"},
+			schema: `{
+  "components": {"schemas": {
+    "Service": {
+      "type": "object",
+      "required": ["ports"],
+      "properties": {"ports": {"type": "array", "items": {"$ref": "#/components/schemas/Port"}}}
+    },
+    "Port": {
+      "type": "object",
+      "required": ["port"],
+      "properties": {"port": {"type": "integer"}, "name": {"type": ["string", "null"]}}
+    }
+  }}
+}`,
+			want: types.Schema{
+				Types: map[string]any{
+					"Port": map[string]any{
+						"port": "integer",
+						"name": "string | required=false nullable=true",
+					},
+				},
+				Parameters: map[string]any{"ports": "[]Port"},
+			},
+		},
+		{
+			name: "inexpressible keywords are reported",
+			schema: `{
+  "type": "object",
+  "properties": {
+    "greeting": {"type": "string", "default": "hello world"},
+    "mode": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+  }
+}`,
+			want: types.Schema{Parameters: map[string]any{
+				"greeting": "string | required=false",
+			}},
+			wantWarnings: []string{
+				`greeting: default "hello world" contains whitespace or is empty and cannot be expressed; dropped`,
+				"mode: oneOf is not supported and was ignored",
+				"mode: schema has no type; skipped",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := FromJSONSchema([]byte(tt.schema), tt.opts)
+			if err != nil {
+				t.Fatalf("FromJSONSchema() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got.Schema); diff != "" {
+				t.Errorf("schema mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantWarnings, got.Warnings); diff != "" {
+				t.Errorf("warnings mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFromJSONSchemaErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		schema  string
+		opts    Options
+		wantErr string
+	}{
+		{name: "missing root", schema: `{"type": "object"}`, opts: Options{Root: "Missing"}, wantErr: `no definition "Missing"`},
+		{name: "non-object root", schema: `{"type": "string"}`, wantErr: "root schema must be an object"},
+		{name: "external ref", schema: `{"type": "object", "properties": {"a": {"$ref": "other.json#/A"}}}`, wantErr: "external reference"},
+		{name: "unresolved ref", schema: `{"type": "object", "properties": {"a": {"$ref": "#/$defs/A"}}}`, wantErr: "unresolved reference"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromJSONSchema([]byte(tt.schema), tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("FromJSONSchema() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
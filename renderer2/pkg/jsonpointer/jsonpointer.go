@@ -0,0 +1,233 @@
+// Package jsonpointer implements RFC 6901 JSON Pointers over documents decoded from YAML or JSON,
+// i.e. trees of map[string]any and []any. The patch package resolves every path it writes through
+// it, and provenance tracking reports the fields addons change as pointers built by it.
+package jsonpointer
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotFound is wrapped by the errors of Resolve and Delete when the pointer names a member or
+// element that does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Parse splits pointer into its unescaped reference tokens. The empty pointer refers to the whole
+// document and yields no tokens; any other pointer must start with "/".
+func Parse(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("JSON pointer %q must start with /", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		unescaped, err := Unescape(token)
+		if err != nil {
+			return nil, fmt.Errorf("JSON pointer %q: %w", pointer, err)
+		}
+		tokens[i] = unescaped
+	}
+	return tokens, nil
+}
+
+// Format joins reference tokens into a pointer, escaping each of them. No tokens format as the
+// empty pointer.
+func Format(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteByte('/')
+		b.WriteString(Escape(token))
+	}
+	return b.String()
+}
+
+// Escape encodes a reference token: "~" becomes "~0" and "/" becomes "~1".
+func Escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// Unescape decodes a reference token. A "~" not followed by 0 or 1 is an error.
+func Unescape(token string) (string, error) {
+	if !strings.Contains(token, "~") {
+		return token, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(token); i++ {
+		if token[i] != '~' {
+			b.WriteByte(token[i])
+			continue
+		}
+		if i+1 == len(token) || (token[i+1] != '0' && token[i+1] != '1') {
+			return "", fmt.Errorf("invalid escape in token %q", token)
+		}
+		if token[i+1] == '0' {
+			b.WriteByte('~')
+		} else {
+			b.WriteByte('/')
+		}
+		i++
+	}
+	return b.String(), nil
+}
+
+// Index parses token as an index into array. Indexes are decimal without leading zeros; insert
+// allows the position just past the last element.
+func Index(array []any, token string, insert bool) (int, error) {
+	index := 0
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("expected array index at segment %s", token)
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("expected array index at segment %s", token)
+		}
+		index = index*10 + int(c-'0')
+		if index > len(array) {
+			break
+		}
+	}
+	limit := len(array)
+	if insert {
+		limit++
+	}
+	if index >= limit {
+		return 0, fmt.Errorf("array index %s out of bounds: %w", token, ErrNotFound)
+	}
+	return index, nil
+}
+
+// Resolve returns the value pointer refers to in doc.
+func Resolve(doc any, pointer string) (any, error) {
+	tokens, err := Parse(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range tokens {
+		switch node := current.(type) {
+		case map[string]any:
+			child, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("missing path at segment %s: %w", token, ErrNotFound)
+			}
+			current = child
+		case []any:
+			index, err := Index(node, token, false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("cannot traverse segment %s on type %T", token, node)
+		}
+	}
+	return current, nil
+}
+
+// Update walks doc to the container holding the last of tokens, which must not be empty, and
+// replaces that container with what update returns for it. Containers on the way are stored back,
+// so update may return a new slice when it grows or shrinks an array. The returned document is doc
+// unless doc itself is the array update replaced.
+func Update(doc any, tokens []string, update func(parent any, last string) (any, error)) (any, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("cannot update the whole document")
+	}
+	if len(tokens) == 1 {
+		return update(doc, tokens[0])
+	}
+	token, rest := tokens[0], tokens[1:]
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[token]
+		if !ok {
+			return nil, fmt.Errorf("missing path at segment %s: %w", token, ErrNotFound)
+		}
+		updated, err := Update(child, rest, update)
+		if err != nil {
+			return nil, err
+		}
+		container[token] = updated
+		return container, nil
+	case []any:
+		index, err := Index(container, token, false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := Update(container[index], rest, update)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = updated
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot traverse segment %s on type %T", token, doc)
+	}
+}
+
+// Set stores value at pointer: it adds or replaces an object member, replaces an array element,
+// and appends to an array for the final token "-". The parent must exist.
+func Set(doc map[string]any, pointer string, value any) error {
+	tokens, err := Parse(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot set the whole document")
+	}
+	_, err = Update(doc, tokens, func(parent any, last string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[last] = value
+			return container, nil
+		case []any:
+			if last == "-" {
+				return append(container, value), nil
+			}
+			index, err := Index(container, last, false)
+			if err != nil {
+				return nil, err
+			}
+			container[index] = value
+			return container, nil
+		default:
+			return nil, fmt.Errorf("cannot set %s on type %T", last, parent)
+		}
+	})
+	return err
+}
+
+// Delete removes the object member or array element pointer refers to; later array elements
+// shift down.
+func Delete(doc map[string]any, pointer string) error {
+	tokens, err := Parse(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("cannot delete the whole document")
+	}
+	_, err = Update(doc, tokens, func(parent any, last string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			if _, ok := container[last]; !ok {
+				return nil, fmt.Errorf("remove target %s does not exist: %w", last, ErrNotFound)
+			}
+			delete(container, last)
+			return container, nil
+		case []any:
+			index, err := Index(container, last, false)
+			if err != nil {
+				return nil, err
+			}
+			removed := make([]any, 0, len(container)-1)
+			removed = append(removed, container[:index]...)
+			return append(removed, container[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %s from type %T", last, parent)
+		}
+	})
+	return err
+}
@@ -0,0 +1,279 @@
+package jsonpointer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseAndFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pointer string
+		tokens  []string
+		wantErr bool
+	}{
+		{pointer: "", tokens: nil},
+		{pointer: "/", tokens: []string{""}},
+		{pointer: "/spec/replicas", tokens: []string{"spec", "replicas"}},
+		{pointer: "/containers/0/env/-", tokens: []string{"containers", "0", "env", "-"}},
+		{pointer: "/annotations/prometheus.io~1scrape", tokens: []string{"annotations", "prometheus.io/scrape"}},
+		{pointer: "/a~0b", tokens: []string{"a~b"}},
+		// ~1 is decoded before ~0, so ~01 is "~1" and not "/".
+		{pointer: "/~01", tokens: []string{"~1"}},
+		{pointer: "/a//b", tokens: []string{"a", "", "b"}},
+		{pointer: "spec/replicas", wantErr: true},
+		{pointer: "/a~2b", wantErr: true},
+		{pointer: "/trailing~", wantErr: true},
+	}
+	for _, tt := range tests {
+		tokens, err := Parse(tt.pointer)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Parse(%q) = %q, want an error", tt.pointer, tokens)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.pointer, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.tokens, tokens); diff != "" {
+			t.Errorf("Parse(%q) mismatch (-want +got):\n%s", tt.pointer, diff)
+		}
+		if got := Format(tokens); got != tt.pointer {
+			t.Errorf("Format(%q) = %q, want %q", tokens, got, tt.pointer)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	t.Parallel()
+
+	for token, want := range map[string]string{
+		"plain":       "plain",
+		"a/b":         "a~1b",
+		"a~b":         "a~0b",
+		"~/":          "~0~1",
+		"example.com": "example.com",
+	} {
+		if got := Escape(token); got != want {
+			t.Errorf("Escape(%q) = %q, want %q", token, got, want)
+		}
+		if got, err := Unescape(want); err != nil || got != token {
+			t.Errorf("Unescape(%q) = %q, %v, want %q", want, got, err, token)
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	t.Parallel()
+
+	array := []any{"a", "b"}
+	tests := []struct {
+		token    string
+		insert   bool
+		want     int
+		wantErr  bool
+		notFound bool
+	}{
+		{token: "0", want: 0},
+		{token: "1", want: 1},
+		{token: "2", wantErr: true, notFound: true},
+		{token: "2", insert: true, want: 2},
+		{token: "3", insert: true, wantErr: true, notFound: true},
+		{token: "99999999999999999999999", wantErr: true, notFound: true},
+		{token: "01", wantErr: true},
+		{token: "-1", wantErr: true},
+		{token: "+1", wantErr: true},
+		{token: "-", wantErr: true},
+		{token: "", wantErr: true},
+		{token: "x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Index(array, tt.token, tt.insert)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Index(%q, %v) = %d, want an error", tt.token, tt.insert, got)
+			} else if errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("Index(%q, %v) error = %v, ErrNotFound %v", tt.token, tt.insert, err, tt.notFound)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Index(%q, %v) = %d, %v, want %d", tt.token, tt.insert, got, err, tt.want)
+		}
+	}
+}
+
+func document() map[string]any {
+	return map[string]any{
+		"metadata": map[string]any{
+			"annotations": map[string]any{"example.com/owner": "platform"},
+		},
+		"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "app", "env": []any{"A"}},
+				map[string]any{"name": "sidecar"},
+			},
+			"": "empty key",
+		},
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pointer  string
+		want     any
+		wantErr  bool
+		notFound bool
+	}{
+		{pointer: "", want: document()},
+		{pointer: "/spec/containers/1/name", want: "sidecar"},
+		{pointer: "/spec/containers/0/env", want: []any{"A"}},
+		{pointer: "/metadata/annotations/example.com~1owner", want: "platform"},
+		{pointer: "/spec/", want: "empty key"},
+		{pointer: "/spec/missing", wantErr: true, notFound: true},
+		{pointer: "/spec/containers/2", wantErr: true, notFound: true},
+		{pointer: "/spec/containers/-", wantErr: true},
+		{pointer: "/spec/containers/0/name/first", wantErr: true},
+		{pointer: "spec", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Resolve(document(), tt.pointer)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Resolve(%q) = %v, want an error", tt.pointer, got)
+			} else if errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("Resolve(%q) error = %v, ErrNotFound %v", tt.pointer, err, tt.notFound)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Resolve(%q) error = %v", tt.pointer, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("Resolve(%q) mismatch (-want +got):\n%s", tt.pointer, diff)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		pointer string
+		value   any
+		check   string
+		want    any
+		wantErr bool
+	}{
+		{name: "new member", pointer: "/spec/replicas", value: 3, check: "/spec/replicas", want: 3},
+		{name: "existing member", pointer: "/spec/containers/1/name", value: "proxy", check: "/spec/containers/1/name", want: "proxy"},
+		{name: "escaped member", pointer: "/metadata/annotations/a~1b", value: "x", check: "/metadata/annotations/a~1b", want: "x"},
+		{name: "array element", pointer: "/spec/containers/0/env/0", value: "B", check: "/spec/containers/0/env", want: []any{"B"}},
+		{name: "append", pointer: "/spec/containers/0/env/-", value: "B", check: "/spec/containers/0/env", want: []any{"A", "B"}},
+		{name: "index past the end", pointer: "/spec/containers/0/env/1", value: "B", wantErr: true},
+		{name: "missing parent", pointer: "/status/phase", value: "Ready", wantErr: true},
+		{name: "scalar parent", pointer: "/spec/containers/1/name/first", value: "x", wantErr: true},
+		{name: "whole document", pointer: "", value: map[string]any{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		doc := document()
+		err := Set(doc, tt.pointer, tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Set(%q) succeeded, want an error", tt.name, tt.pointer)
+			}
+			if diff := cmp.Diff(document(), doc); diff != "" {
+				t.Errorf("%s: failed Set changed the document (-want +got):\n%s", tt.name, diff)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Set(%q) error = %v", tt.name, tt.pointer, err)
+			continue
+		}
+		got, err := Resolve(doc, tt.check)
+		if err != nil {
+			t.Errorf("%s: Resolve(%q) error = %v", tt.name, tt.check, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: value mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		pointer  string
+		check    string
+		want     any
+		wantErr  bool
+		notFound bool
+	}{
+		{name: "member", pointer: "/metadata/annotations/example.com~1owner", check: "/metadata/annotations", want: map[string]any{}},
+		{name: "array element shifts the rest", pointer: "/spec/containers/0", check: "/spec/containers/0/name", want: "sidecar"},
+		{name: "last array element", pointer: "/spec/containers/0/env/0", check: "/spec/containers/0/env", want: []any{}},
+		{name: "missing member", pointer: "/spec/replicas", wantErr: true, notFound: true},
+		{name: "missing element", pointer: "/spec/containers/5", wantErr: true, notFound: true},
+		{name: "append position", pointer: "/spec/containers/-", wantErr: true},
+		{name: "whole document", pointer: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		doc := document()
+		err := Delete(doc, tt.pointer)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: Delete(%q) succeeded, want an error", tt.name, tt.pointer)
+			} else if errors.Is(err, ErrNotFound) != tt.notFound {
+				t.Errorf("%s: Delete(%q) error = %v, ErrNotFound %v", tt.name, tt.pointer, err, tt.notFound)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Delete(%q) error = %v", tt.name, tt.pointer, err)
+			continue
+		}
+		got, err := Resolve(doc, tt.check)
+		if err != nil {
+			t.Errorf("%s: Resolve(%q) error = %v", tt.name, tt.check, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: value mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
+
+func TestUpdateStoresNewSlices(t *testing.T) {
+	t.Parallel()
+
+	doc := document()
+	tokens, err := Parse("/spec/containers/-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Update(doc, tokens, func(parent any, last string) (any, error) {
+		return append(parent.([]any), map[string]any{"name": last}), nil
+	})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got, err := Resolve(doc, "/spec/containers/2/name"); err != nil || got != "-" {
+		t.Errorf("appended container name = %v, %v, want -", got, err)
+	}
+	if _, err := Update(doc, nil, nil); err == nil {
+		t.Error("Update() of the whole document succeeded, want an error")
+	}
+}
@@ -0,0 +1,311 @@
+// Package legacy detects renderer v1 constructs in definition and addon YAML and rewrites them to
+// their renderer2 equivalents.
+package legacy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Deprecation describes one legacy construct found in a document.
+type Deprecation struct {
+	// Path locates the construct, e.g. spec.patches[0].target.selector.
+	Path string
+	Line int
+	// Construct is the legacy field name and Replacement what to use instead.
+	Construct   string
+	Replacement string
+	Message     string
+	// Fixable reports whether Fix rewrites the construct automatically.
+	Fixable bool
+}
+
+func (d Deprecation) String() string {
+	return fmt.Sprintf("line %d: %s is deprecated, use %s: %s", d.Line, d.Path, d.Replacement, d.Message)
+}
+
+// Detect reports the legacy constructs in content without changing it.
+func Detect(content []byte) ([]Deprecation, error) {
+	_, deprecations, err := process(content, false)
+	return deprecations, err
+}
+
+// Fix rewrites every fixable legacy construct and returns the new content with all deprecations
+// found, including the ones it could not fix. Content without fixable constructs is returned
+// unchanged; otherwise comments are kept but every document of the file is re-encoded with
+// two-space indent.
+func Fix(content []byte) ([]byte, []Deprecation, error) {
+	return process(content, true)
+}
+
+func process(content []byte, apply bool) ([]byte, []Deprecation, error) {
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		docs = append(docs, &doc)
+	}
+
+	f := &fixer{apply: apply}
+	for _, doc := range docs {
+		root := doc
+		if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+			root = doc.Content[0]
+		}
+		spec := mappingValue(root, "spec")
+		switch scalarValue(mappingValue(root, "kind")) {
+		case "ComponentTypeDefinition":
+			f.resources(mappingValue(spec, "resources"), "spec.resources")
+			if versions := mappingValue(spec, "versions"); versions != nil && versions.Kind == yaml.SequenceNode {
+				for i, version := range versions.Content {
+					f.resources(mappingValue(version, "resources"), fmt.Sprintf("spec.versions[%d].resources", i))
+				}
+			}
+		case "Addon":
+			f.patches(mappingValue(spec, "patches"), "spec.patches")
+		}
+	}
+
+	if !f.changed {
+		return content, f.deprecations, nil
+	}
+	var b strings.Builder
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return []byte(b.String()), f.deprecations, nil
+}
+
+type fixer struct {
+	apply        bool
+	changed      bool
+	deprecations []Deprecation
+}
+
+func (f *fixer) report(d Deprecation, fix func()) {
+	f.deprecations = append(f.deprecations, d)
+	if f.apply && d.Fixable {
+		fix()
+		f.changed = true
+	}
+}
+
+// resources handles resource templates: condition became includeWhen.
+func (f *fixer) resources(resources *yaml.Node, path string) {
+	if resources == nil || resources.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, resource := range resources.Content {
+		key := mappingKey(resource, "condition")
+		if key == nil {
+			continue
+		}
+		conflict := mappingKey(resource, "includeWhen") != nil
+		d := Deprecation{
+			Path:        fmt.Sprintf("%s[%d].condition", path, i),
+			Line:        key.Line,
+			Construct:   "condition",
+			Replacement: "includeWhen",
+			Message:     "rename the field; the expression is unchanged",
+			Fixable:     !conflict,
+		}
+		if conflict {
+			d.Message = "both condition and includeWhen are set; merge them by hand"
+		}
+		f.report(d, func() { key.Value = "includeWhen" })
+	}
+}
+
+// patches handles addon patches: the single patch became an operations list and the target
+// fields were replaced by kind and where.
+func (f *fixer) patches(patches *yaml.Node, path string) {
+	if patches == nil || patches.Kind != yaml.SequenceNode {
+		return
+	}
+	for i, spec := range patches.Content {
+		specPath := fmt.Sprintf("%s[%d]", path, i)
+		if target := mappingValue(spec, "target"); target != nil {
+			f.target(target, specPath+".target")
+		}
+
+		if key := mappingKey(spec, "patch"); key != nil {
+			value := mappingValue(spec, "patch")
+			conflict := mappingKey(spec, "operations") != nil
+			d := Deprecation{
+				Path:        specPath + ".patch",
+				Line:        key.Line,
+				Construct:   "patch",
+				Replacement: "operations",
+				Message:     "wrap the operation in an operations list",
+				Fixable:     !conflict && value.Kind == yaml.MappingNode,
+			}
+			if conflict {
+				d.Message = "both patch and operations are set; move the patch into operations by hand"
+			}
+			f.report(d, func() {
+				key.Value = "operations"
+				setMappingValue(spec, "operations", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}})
+			})
+		}
+	}
+}
+
+func (f *fixer) target(target *yaml.Node, path string) {
+	if key := mappingKey(target, "resourceType"); key != nil {
+		conflict := mappingKey(target, "kind") != nil
+		d := Deprecation{
+			Path:        path + ".resourceType",
+			Line:        key.Line,
+			Construct:   "resourceType",
+			Replacement: "kind",
+			Message:     "rename the field; renderer2 ignores resourceType and would patch every resource",
+			Fixable:     !conflict,
+		}
+		if conflict {
+			d.Message = "both resourceType and kind are set; remove resourceType"
+		}
+		f.report(d, func() { key.Value = "kind" })
+	}
+
+	if key := mappingKey(target, "resourceId"); key != nil {
+		f.report(Deprecation{
+			Path:        path + ".resourceId",
+			Line:        key.Line,
+			Construct:   "resourceId",
+			Replacement: "name or where",
+			Message:     "renderer v1 never matched on resourceId; the field is removed",
+			Fixable:     true,
+		}, func() { deleteMappingKey(target, "resourceId") })
+	}
+
+	if key := mappingKey(target, "selector"); key != nil {
+		selector := scalarValue(mappingValue(target, "selector"))
+		where, err := selectorToWhere(selector)
+		d := Deprecation{
+			Path:        path + ".selector",
+			Line:        key.Line,
+			Construct:   "selector",
+			Replacement: "where",
+			Fixable:     err == nil && mappingKey(target, "where") == nil,
+		}
+		switch {
+		case err != nil:
+			d.Message = fmt.Sprintf("rewrite the label selector as a where expression by hand: %v", err)
+		case !d.Fixable:
+			d.Message = "both selector and where are set; fold the selector into where by hand"
+		default:
+			d.Message = "use " + where
+		}
+		f.report(d, func() {
+			key.Value = "where"
+			setMappingValue(target, "where", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: where})
+		})
+	}
+}
+
+// selectorToWhere converts an equality-based label selector ("app=web,tier!=db") into a where
+// expression. Set-based selectors (in, notin, exists) are rejected.
+func selectorToWhere(selector string) (string, error) {
+	if strings.TrimSpace(selector) == "" {
+		return "", fmt.Errorf("empty selector")
+	}
+
+	var clauses []string
+	for _, requirement := range strings.Split(selector, ",") {
+		requirement = strings.TrimSpace(requirement)
+		negate := false
+		var key, value string
+		var ok bool
+		switch {
+		case strings.Contains(requirement, "!="):
+			key, value, _ = strings.Cut(requirement, "!=")
+			negate, ok = true, true
+		case strings.Contains(requirement, "=="):
+			key, value, ok = strings.Cut(requirement, "==")
+		default:
+			key, value, ok = strings.Cut(requirement, "=")
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || strings.ContainsAny(key+value, " ()!=\"") {
+			return "", fmt.Errorf("unsupported requirement %q", requirement)
+		}
+
+		clause := fmt.Sprintf(`has(resource.metadata.labels) && %q in resource.metadata.labels && resource.metadata.labels[%q] == %q`, key, key, value)
+		if negate {
+			clause = "!(" + clause + ")"
+		} else if strings.Contains(selector, ",") {
+			clause = "(" + clause + ")"
+		}
+		clauses = append(clauses, clause)
+	}
+	return "${" + strings.Join(clauses, " && ") + "}", nil
+}
+
+func mappingKey(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i]
+		}
+	}
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+func deleteMappingKey(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func scalarValue(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
@@ -0,0 +1,163 @@
+package legacy
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantPaths []string
+	}{
+		{
+			name: "definition condition becomes includeWhen",
+			input: `kind: ComponentTypeDefinition
+spec:
+  resources:
+    # the service is optional
+    - id: service
+      condition: ${spec.expose}
+`,
+			want: `kind: ComponentTypeDefinition
+spec:
+  resources:
+    # the service is optional
+    - id: service
+      includeWhen: ${spec.expose}
+`,
+			wantPaths: []string{"spec.resources[0].condition"},
+		},
+		{
+			name: "addon patch and target are modernized",
+			input: `kind: Addon
+spec:
+  patches:
+    - target:
+        resourceType: Deployment
+        resourceId: deployment
+        selector: app=web
+      patch:
+        op: add
+        path: /spec/template/spec/volumes/-
+        value: {}
+`,
+			want: `kind: Addon
+spec:
+  patches:
+    - target:
+        kind: Deployment
+        where: ${has(resource.metadata.labels) && "app" in resource.metadata.labels && resource.metadata.labels["app"] == "web"}
+      operations:
+        - op: add
+          path: /spec/template/spec/volumes/-
+          value: {}
+`,
+			wantPaths: []string{
+				"spec.patches[0].target.resourceType",
+				"spec.patches[0].target.resourceId",
+				"spec.patches[0].target.selector",
+				"spec.patches[0].patch",
+			},
+		},
+		{
+			name: "every document of a multi-document file is kept",
+			input: `kind: ComponentTypeDefinition
+spec:
+  resources:
+    - id: service
+      condition: ${spec.expose}
+---
+kind: Addon
+spec:
+  patches:
+    - target:
+        resourceType: Service
+      operations: []
+`,
+			want: `kind: ComponentTypeDefinition
+spec:
+  resources:
+    - id: service
+      includeWhen: ${spec.expose}
+---
+kind: Addon
+spec:
+  patches:
+    - target:
+        kind: Service
+      operations: []
+`,
+			wantPaths: []string{"spec.resources[0].condition", "spec.patches[0].target.resourceType"},
+		},
+		{
+			name: "current syntax is returned unchanged",
+			input: `kind: Addon
+spec:
+  patches:
+    - target: {kind: Deployment}
+      operations: []
+`,
+			want: `kind: Addon
+spec:
+  patches:
+    - target: {kind: Deployment}
+      operations: []
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, deprecations, err := Fix([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Fix() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Errorf("Fix() output mismatch (-want +got):\n%s", diff)
+			}
+			var paths []string
+			for _, d := range deprecations {
+				paths = append(paths, d.Path)
+			}
+			if diff := cmp.Diff(tt.wantPaths, paths); diff != "" {
+				t.Errorf("deprecations mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDetectUnfixableSelector(t *testing.T) {
+	t.Parallel()
+
+	input := `kind: Addon
+spec:
+  patches:
+    - target:
+        selector: env in (dev, prod)
+      operations: []
+`
+	deprecations, err := Detect([]byte(input))
+	if err != nil {
+		t.Fatalf("Detect() error = %v", err)
+	}
+	if len(deprecations) != 1 || deprecations[0].Fixable || deprecations[0].Line != 5 {
+		t.Fatalf("Detect() = %+v, want one unfixable selector deprecation on line 5", deprecations)
+	}
+
+	fixed, _, err := Fix([]byte(input))
+	if err != nil {
+		t.Fatalf("Fix() error = %v", err)
+	}
+	if string(fixed) != input {
+		t.Errorf("Fix() rewrote an unfixable document:\n%s", fixed)
+	}
+}
@@ -0,0 +1,189 @@
+// Package lock captures everything a render depends on beyond the Component itself, so a render
+// can be reproduced later or refused when its inputs drifted.
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the conventional name of the lockfile.
+const FileName = "platform.lock"
+
+const celModule = "github.com/google/cel-go"
+
+// File is the content of platform.lock. Its images section has the same layout as an
+// images.Lockfile, so image pinning can read and record digests in it directly.
+type File struct {
+	Definitions map[string]Entry  `yaml:"definitions,omitempty"`
+	Addons      map[string]Entry  `yaml:"addons,omitempty"`
+	Images      map[string]string `yaml:"images,omitempty"`
+	Functions   Functions         `yaml:"functions"`
+}
+
+// Entry pins one definition or addon. Digest hashes its spec; Release is its spec.version and
+// Version the storage version of versioned definitions.
+type Entry struct {
+	Release string `yaml:"release,omitempty"`
+	Version string `yaml:"version,omitempty"`
+	Digest  string `yaml:"digest"`
+}
+
+// Functions pins the CEL function library: the cel-go release providing the built-ins and the
+// names of the custom functions registered with the engine.
+type Functions struct {
+	CEL    string   `yaml:"cel,omitempty"`
+	Custom []string `yaml:"custom,omitempty"`
+}
+
+// Build describes the current inputs. Images start empty and are filled in by image pinning.
+func Build(definition *types.ComponentTypeDefinition, addons map[string]*types.Addon, functionNames []string) (*File, error) {
+	file := &File{
+		Definitions: map[string]Entry{},
+		Addons:      map[string]Entry{},
+		Images:      map[string]string{},
+		Functions:   Functions{CEL: moduleVersion(celModule), Custom: functionNames},
+	}
+
+	entry, err := definitionEntry(definition)
+	if err != nil {
+		return nil, err
+	}
+	file.Definitions[definition.Metadata.Name] = entry
+
+	for name, addon := range addons {
+		digest, err := digestOf(addon.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash addon %s: %w", name, err)
+		}
+		file.Addons[name] = Entry{Release: addon.Spec.Version, Digest: digest}
+	}
+	return file, nil
+}
+
+func definitionEntry(definition *types.ComponentTypeDefinition) (Entry, error) {
+	entry := Entry{Release: definition.Spec.Version}
+	if len(definition.Spec.Versions) > 0 {
+		storage, err := versioning.StorageVersion(definition)
+		if err != nil {
+			return entry, err
+		}
+		entry.Version = storage.Name
+	}
+	digest, err := digestOf(definition.Spec)
+	if err != nil {
+		return entry, fmt.Errorf("failed to hash definition %s: %w", definition.Metadata.Name, err)
+	}
+	entry.Digest = digest
+	return entry, nil
+}
+
+// digestOf hashes the JSON encoding of v; encoding/json sorts map keys, so equal specs hash equally.
+func digestOf(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+func moduleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// Drift lists the differences between the locked inputs and the current ones, sorted. Images are
+// not compared: frozen renders resolve them from the lockfile only, so an unlocked image fails
+// the render instead.
+func Drift(locked, current *File) []string {
+	var drift []string
+	drift = append(drift, entryDrift("definition", locked.Definitions, current.Definitions)...)
+	drift = append(drift, entryDrift("addon", locked.Addons, current.Addons)...)
+
+	if locked.Functions.CEL != current.Functions.CEL {
+		drift = append(drift, fmt.Sprintf("cel-go version changed from %q to %q", locked.Functions.CEL, current.Functions.CEL))
+	}
+	if fmt.Sprint(locked.Functions.Custom) != fmt.Sprint(current.Functions.Custom) {
+		drift = append(drift, fmt.Sprintf("custom functions changed from %v to %v", locked.Functions.Custom, current.Functions.Custom))
+	}
+	sort.Strings(drift)
+	return drift
+}
+
+func entryDrift(kind string, locked, current map[string]Entry) []string {
+	var drift []string
+	for name, want := range locked {
+		got, ok := current[name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("%s %s is locked but no longer used", kind, name))
+		case got.Release != want.Release:
+			drift = append(drift, fmt.Sprintf("%s %s release changed from %q to %q", kind, name, want.Release, got.Release))
+		case got.Version != want.Version:
+			drift = append(drift, fmt.Sprintf("%s %s version changed from %q to %q", kind, name, want.Version, got.Version))
+		case got.Digest != want.Digest:
+			drift = append(drift, fmt.Sprintf("%s %s changed (digest %s, locked %s)", kind, name, got.Digest, want.Digest))
+		}
+	}
+	for name := range current {
+		if _, ok := locked[name]; !ok {
+			drift = append(drift, fmt.Sprintf("%s %s is not in the lockfile", kind, name))
+		}
+	}
+	return drift
+}
+
+// Load reads a lockfile. A missing file is reported as an error matching os.ErrNotExist.
+func Load(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+	var file File
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	if file.Images == nil {
+		file.Images = map[string]string{}
+	}
+	return &file, nil
+}
+
+// Save writes the lockfile with sorted keys.
+func (f *File) Save(path string) error {
+	content, err := yaml.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+// IsNotExist reports whether err means the lockfile does not exist yet.
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
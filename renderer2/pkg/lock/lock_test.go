@@ -0,0 +1,82 @@
+package lock
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testInputs() (*types.ComponentTypeDefinition, map[string]*types.Addon) {
+	definition := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Version: "1.2.0",
+			Versions: []types.DefinitionVersion{
+				{Name: "v1", Served: true},
+				{Name: "v2", Served: true, Storage: true},
+			},
+		},
+	}
+	addons := map[string]*types.Addon{
+		"sidecar": {Metadata: types.Metadata{Name: "sidecar"}, Spec: types.AddonSpec{DisplayName: "Sidecar"}},
+	}
+	return definition, addons
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	definition, addons := testInputs()
+	locked, err := Build(definition, addons, []string{"prometheusRule"})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if got := locked.Definitions["web"]; got.Version != "v2" || got.Release != "1.2.0" {
+		t.Errorf("definition release and version = %q, %q, want 1.2.0, v2", got.Release, got.Version)
+	}
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := locked.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	current, err := Build(definition, addons, []string{"prometheusRule"})
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if drift := Drift(reloaded, current); len(drift) != 0 {
+		t.Errorf("Drift() of unchanged inputs = %v, want none", drift)
+	}
+
+	addons["sidecar"].Spec.DisplayName = "Logging sidecar"
+	addons["emptydir"] = &types.Addon{Metadata: types.Metadata{Name: "emptydir"}}
+	definition.Spec.Version = "1.3.0"
+	current, err = Build(definition, addons, nil)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	drift := Drift(reloaded, current)
+	want := []string{
+		"addon emptydir is not in the lockfile",
+		"addon sidecar changed (digest " + current.Addons["sidecar"].Digest + ", locked " + reloaded.Addons["sidecar"].Digest + ")",
+		"custom functions changed from [prometheusRule] to []",
+		`definition web release changed from "1.2.0" to "1.3.0"`,
+	}
+	if diff := cmp.Diff(want, drift); diff != "" {
+		t.Errorf("Drift() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Load(filepath.Join(t.TempDir(), FileName)); !IsNotExist(err) {
+		t.Errorf("Load() of a missing file error = %v, want not-exist", err)
+	}
+}
@@ -0,0 +1,366 @@
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+	"gopkg.in/yaml.v3"
+)
+
+// Report describes the outcome of migrating a single Component.
+type Report struct {
+	Component string
+	Path      string
+	From      string
+	To        string
+	// Unmapped lists source parameter paths that no conversion expression references.
+	Unmapped []string
+	// Unknown lists converted parameter paths that the target version's schema does not declare.
+	Unknown []string
+	Changed bool
+}
+
+// Options controls bulk migration.
+type Options struct {
+	// DryRun reports what would change without rewriting files.
+	DryRun bool
+}
+
+// MigrateComponent converts a Component's parameters into the target definition version and
+// reports source fields that were not carried over.
+func MigrateComponent(engine *template.Engine, ctd *types.ComponentTypeDefinition, component *types.Component, to string) (*types.Component, Report, error) {
+	from := component.Spec.ComponentTypeVersion
+	report := Report{Component: component.Metadata.Name, From: from, To: to}
+
+	if from == "" {
+		return nil, report, fmt.Errorf("component %s does not pin componentTypeVersion", component.Metadata.Name)
+	}
+	target, ok := versioning.FindVersion(ctd, to)
+	if !ok {
+		return nil, report, fmt.Errorf("definition %s has no version %s", ctd.Metadata.Name, to)
+	}
+	if from == to {
+		return component, report, nil
+	}
+
+	converted, err := versioning.ConvertParameters(engine, ctd, from, to, component.Spec.Parameters)
+	if err != nil {
+		return nil, report, err
+	}
+
+	if hop, ok := versioning.FirstHop(ctd, from, to); ok {
+		referenced := referencedSpecPaths(hop.Parameters)
+		for _, path := range leafPaths(component.Spec.Parameters, "") {
+			if !isReferenced(path, referenced) {
+				report.Unmapped = append(report.Unmapped, path)
+			}
+		}
+	}
+
+	declared := declaredPaths(target.Schema)
+	for _, path := range leafPaths(converted, "") {
+		if !isDeclared(path, declared) {
+			report.Unknown = append(report.Unknown, path)
+		}
+	}
+
+	result := *component
+	result.Spec.Parameters = converted
+	result.Spec.ComponentTypeVersion = to
+	report.Changed = true
+	return &result, report, nil
+}
+
+// MigrateDir walks dir and migrates every Component of the given definition that pins a version
+// other than `to`, rewriting its parameters and componentTypeVersion in place. Every document of
+// a multi-document file is kept, and comments outside the rewritten fields are preserved. All
+// Components are migrated in memory before any file is written: when one fails, MigrateDir reports
+// every failure and leaves the tree untouched.
+func MigrateDir(engine *template.Engine, ctd *types.ComponentTypeDefinition, dir, to string, opts Options) ([]Report, error) {
+	var reports []Report
+	var rewrites []rewrite
+	var failures []error
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !strings.HasSuffix(path, ".yaml") && !strings.HasSuffix(path, ".yml") {
+			return nil
+		}
+
+		fileReports, content, err := migrateFile(engine, ctd, path, to)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", path, err))
+			return nil
+		}
+		reports = append(reports, fileReports...)
+		if content != nil {
+			rewrites = append(rewrites, rewrite{path: path, content: content})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(failures) > 0 {
+		return nil, errors.Join(failures...)
+	}
+	if opts.DryRun {
+		return reports, nil
+	}
+	for _, r := range rewrites {
+		if err := os.WriteFile(r.path, r.content, 0644); err != nil {
+			return nil, err
+		}
+	}
+	return reports, nil
+}
+
+// rewrite is the migrated content of one file.
+type rewrite struct {
+	path    string
+	content []byte
+}
+
+// migrateFile migrates the Components of the definition in every document of path. It returns a
+// report per migrated Component and the new file content, nil when nothing changed.
+func migrateFile(engine *template.Engine, ctd *types.ComponentTypeDefinition, path, to string) ([]Report, []byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var doc yaml.Node
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			// Not every YAML file in a repo is a manifest; skip anything we cannot parse.
+			return nil, nil, nil
+		}
+		docs = append(docs, &doc)
+	}
+
+	var reports []Report
+	changed := false
+	for _, doc := range docs {
+		var component types.Component
+		if err := doc.Decode(&component); err != nil {
+			// Not every document is a Component; skip anything we cannot decode.
+			continue
+		}
+		if component.Kind != "Component" || component.Spec.ComponentType != ctd.Metadata.Name {
+			continue
+		}
+		if component.Spec.ComponentTypeVersion == "" || component.Spec.ComponentTypeVersion == to {
+			continue
+		}
+
+		migrated, report, err := MigrateComponent(engine, ctd, &component, to)
+		if err != nil {
+			return nil, nil, err
+		}
+		report.Path = path
+		reports = append(reports, report)
+		if !report.Changed {
+			continue
+		}
+
+		spec := mappingValue(documentRoot(doc), "spec")
+		if spec == nil {
+			return nil, nil, fmt.Errorf("component %s has no spec mapping", component.Metadata.Name)
+		}
+		var params yaml.Node
+		if err := params.Encode(migrated.Spec.Parameters); err != nil {
+			return nil, nil, fmt.Errorf("failed to encode parameters of %s: %w", component.Metadata.Name, err)
+		}
+		setMappingValue(spec, "parameters", &params)
+		setMappingValue(spec, "componentTypeVersion", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: to})
+		changed = true
+	}
+	if !changed {
+		return reports, nil, nil
+	}
+
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, nil, err
+	}
+	return reports, b.Bytes(), nil
+}
+
+func documentRoot(doc *yaml.Node) *yaml.Node {
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		return doc.Content[0]
+	}
+	return doc
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value.HeadComment = node.Content[i+1].HeadComment
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+}
+
+// referencedSpecPaths returns every `spec.x.y` path mentioned inside expressions of a template.
+func referencedSpecPaths(v any) []string {
+	var paths []string
+	var walk func(any)
+	walk = func(node any) {
+		switch typed := node.(type) {
+		case string:
+			paths = append(paths, specPathsInString(typed)...)
+		case map[string]any:
+			for k, item := range typed {
+				paths = append(paths, specPathsInString(k)...)
+				walk(item)
+			}
+		case []any:
+			for _, item := range typed {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+	return paths
+}
+
+func specPathsInString(s string) []string {
+	var paths []string
+	for {
+		idx := strings.Index(s, "spec.")
+		if idx == -1 {
+			return paths
+		}
+		if idx > 0 && isIdentChar(s[idx-1]) {
+			s = s[idx+len("spec."):]
+			continue
+		}
+		end := idx + len("spec.")
+		for end < len(s) && (isIdentChar(s[end]) || s[end] == '.') {
+			end++
+		}
+		path := strings.TrimSuffix(s[idx+len("spec."):end], ".")
+		if end < len(s) && s[end] == '(' {
+			// Trailing segment is a method call such as spec.items.size().
+			if dot := strings.LastIndex(path, "."); dot != -1 {
+				path = path[:dot]
+			}
+		}
+		paths = append(paths, path)
+		s = s[end:]
+	}
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isReferenced reports whether a leaf path is covered by a referenced path (itself or an ancestor).
+func isReferenced(path string, referenced []string) bool {
+	for _, ref := range referenced {
+		if ref == path || strings.HasPrefix(path, ref+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func leafPaths(m map[string]any, prefix string) []string {
+	var paths []string
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if nested, ok := m[k].(map[string]any); ok && len(nested) > 0 {
+			paths = append(paths, leafPaths(nested, path)...)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// declaredPaths lists the field paths declared by a simple schema. Leaves (true) accept any nested
+// content since they may be maps, arrays, or custom types; intermediate objects (false) do not.
+func declaredPaths(s types.Schema) map[string]bool {
+	paths := map[string]bool{}
+	var walk func(map[string]any, string)
+	walk = func(fields map[string]any, prefix string) {
+		for k, v := range fields {
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			nested, ok := v.(map[string]any)
+			paths[path] = !ok
+			if ok {
+				walk(nested, path)
+			}
+		}
+	}
+	walk(s.Parameters, "")
+	walk(s.EnvOverrides, "")
+	return paths
+}
+
+func isDeclared(path string, declared map[string]bool) bool {
+	if _, ok := declared[path]; ok {
+		return true
+	}
+	for d, leaf := range declared {
+		if leaf && strings.HasPrefix(path, d+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func versionedDefinition() *types.ComponentTypeDefinition {
+	return &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Versions: []types.DefinitionVersion{
+				{
+					Name:   "v1",
+					Served: true,
+					Schema: types.Schema{Parameters: map[string]any{"count": "integer", "legacy": "string"}},
+				},
+				{
+					Name:    "v2",
+					Served:  true,
+					Storage: true,
+					Schema:  types.Schema{Parameters: map[string]any{"replicas": "integer"}},
+					Conversions: []types.VersionConversion{
+						{From: "v1", Parameters: map[string]any{"replicas": "${spec.count}", "extra": "fixed"}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMigrateComponent(t *testing.T) {
+	t.Parallel()
+
+	component := &types.Component{
+		Metadata: types.Metadata{Name: "checkout"},
+		Spec: types.ComponentSpec{
+			ComponentType:        "web",
+			ComponentTypeVersion: "v1",
+			Parameters:           map[string]any{"count": int64(3), "legacy": "x"},
+		},
+	}
+	migrated, report, err := MigrateComponent(template.NewEngine(), versionedDefinition(), component, "v2")
+	if err != nil {
+		t.Fatalf("MigrateComponent() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"replicas": int64(3), "extra": "fixed"}, migrated.Spec.Parameters); diff != "" {
+		t.Errorf("parameters mismatch (-want +got):\n%s", diff)
+	}
+	if migrated.Spec.ComponentTypeVersion != "v2" || component.Spec.ComponentTypeVersion != "v1" {
+		t.Errorf("versions: migrated %q, input %q", migrated.Spec.ComponentTypeVersion, component.Spec.ComponentTypeVersion)
+	}
+	want := Report{Component: "checkout", From: "v1", To: "v2", Unmapped: []string{"legacy"}, Unknown: []string{"extra"}, Changed: true}
+	if diff := cmp.Diff(want, report); diff != "" {
+		t.Errorf("report mismatch (-want +got):\n%s", diff)
+	}
+
+	for name, tc := range map[string]struct {
+		version, to string
+	}{
+		"unpinned":        {"", "v2"},
+		"unknown version": {"v1", "v9"},
+	} {
+		pinned := *component
+		pinned.Spec.ComponentTypeVersion = tc.version
+		if _, _, err := MigrateComponent(template.NewEngine(), versionedDefinition(), &pinned, tc.to); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+const multiDocument = `# checkout runs the storefront
+apiVersion: platform/v1alpha1
+kind: Component
+metadata:
+  name: checkout
+spec:
+  componentType: web
+  componentTypeVersion: v1
+  parameters:
+    count: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: checkout
+---
+apiVersion: platform/v1alpha1
+kind: Component
+metadata:
+  name: worker
+spec:
+  componentType: batch
+  componentTypeVersion: v1
+`
+
+func TestMigrateDirKeepsEveryDocument(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "checkout.yaml")
+	if err := os.WriteFile(path, []byte(multiDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := MigrateDir(template.NewEngine(), versionedDefinition(), dir, "v2", Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("MigrateDir() dry run error = %v", err)
+	}
+	if len(reports) != 1 || reports[0].Component != "checkout" || reports[0].Path != path {
+		t.Fatalf("dry run reports = %+v", reports)
+	}
+	if got, _ := os.ReadFile(path); string(got) != multiDocument {
+		t.Fatalf("dry run rewrote the file:\n%s", got)
+	}
+
+	if _, err := MigrateDir(template.NewEngine(), versionedDefinition(), dir, "v2", Options{}); err != nil {
+		t.Fatalf("MigrateDir() error = %v", err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# checkout runs the storefront
+apiVersion: platform/v1alpha1
+kind: Component
+metadata:
+  name: checkout
+spec:
+  componentType: web
+  componentTypeVersion: v2
+  parameters:
+    extra: fixed
+    replicas: 2
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: checkout
+---
+apiVersion: platform/v1alpha1
+kind: Component
+metadata:
+  name: worker
+spec:
+  componentType: batch
+  componentTypeVersion: v1
+`
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("migrated file mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMigrateDirWritesNothingOnFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	good := filepath.Join(dir, "a.yaml")
+	if err := os.WriteFile(good, []byte(multiDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := strings.Replace(multiDocument, "componentTypeVersion: v1\n  parameters", "componentTypeVersion: v0\n  parameters", 1)
+	if err := os.WriteFile(filepath.Join(dir, "b.yaml"), []byte(broken), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := MigrateDir(template.NewEngine(), versionedDefinition(), dir, "v2", Options{})
+	if err == nil || !strings.Contains(err.Error(), "b.yaml") {
+		t.Fatalf("MigrateDir() error = %v, want a failure naming b.yaml", err)
+	}
+	if got, _ := os.ReadFile(good); string(got) != multiDocument {
+		t.Errorf("a.yaml was rewritten although another Component failed:\n%s", got)
+	}
+}
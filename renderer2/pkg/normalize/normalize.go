@@ -0,0 +1,86 @@
+// Package normalize rewrites rendered values into a platform-independent form so written output
+// and checksums are byte-for-byte identical on Linux, macOS and Windows.
+package normalize
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxExactInt is the largest integer every float64 below it represents exactly (2^53).
+const maxExactInt = 1 << 53
+
+// Resources returns normalized copies of resources (see Value).
+func Resources(resources []map[string]any) []map[string]any {
+	result := make([]map[string]any, len(resources))
+	for i, resource := range resources {
+		result[i] = Value(resource).(map[string]any)
+	}
+	return result
+}
+
+// Value returns a copy of v with CRLF and lone CR line endings in strings replaced by LF, and
+// integral floats (which JSON decoding and CEL doubles produce for whole numbers) turned into
+// int64 so they are written as 3 rather than 3.0 or 3e+00 by every encoder.
+func Value(v any) any {
+	switch typed := v.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			result[Text(key)] = Value(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			result[i] = Value(item)
+		}
+		return result
+	case string:
+		return Text(typed)
+	case float64:
+		if typed == math.Trunc(typed) && math.Abs(typed) < maxExactInt {
+			return int64(typed)
+		}
+		return typed
+	case float32:
+		return Value(float64(typed))
+	default:
+		return typed
+	}
+}
+
+// FormatFloat formats f the way every output format writes floats: in plain decimal notation
+// when 1e-6 <= |f| < 1e21, and in exponent notation with the shortest exponent otherwise, as
+// encoding/json and JavaScript do. YAML encoders would otherwise write 0.00001 as 1e-05 and
+// Terraform output would spell 1e300 out in full. The shortest representation that parses back
+// to f is used, so no precision is lost.
+func FormatFloat(f float64) string {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	if abs := math.Abs(f); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(f, 'e', -1, 64)
+	// Drop the leading zero of two-digit exponents: 1e-07 becomes 1e-7.
+	if n := len(s); n >= 4 && s[n-4] == 'e' && s[n-2] == '0' {
+		s = s[:n-2] + s[n-1:]
+	}
+	return s
+}
+
+// Text converts CRLF and lone CR line endings to LF.
+func Text(s string) string {
+	if !strings.Contains(s, "\r") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\r", "\n")
+}
+
+// Path returns p with backslash separators converted to forward slashes, the form used in output
+// names regardless of the platform that produced them.
+func Path(p string) string {
+	return strings.ReplaceAll(p, `\`, "/")
+}
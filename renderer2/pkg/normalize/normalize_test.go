@@ -0,0 +1,91 @@
+package normalize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "CRLF and CR become LF", value: "a\r\nb\rc\n", want: "a\nb\nc\n"},
+		{name: "integral floats become integers", value: []any{3.0, -0.0, 1e6, float32(2)}, want: []any{int64(3), int64(0), int64(1000000), int64(2)}},
+		{name: "fractional and huge floats stay", value: []any{0.5, 1e300}, want: []any{0.5, 1e300}},
+		{name: "nested maps and keys", value: map[string]any{"data": map[string]any{"app.conf\r\n": "listen 80;\r\n", "replicas": 2.0}},
+			want: map[string]any{"data": map[string]any{"app.conf\n": "listen 80;\n", "replicas": int64(2)}}},
+		{name: "other values pass through", value: []any{true, nil, 7}, want: []any{true, nil, 7}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, Value(tt.value)); diff != "" {
+				t.Errorf("Value() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValueKeepsNaN(t *testing.T) {
+	t.Parallel()
+
+	if got, ok := Value(math.NaN()).(float64); !ok || !math.IsNaN(got) {
+		t.Errorf("Value(NaN) = %v, want NaN", got)
+	}
+}
+
+func TestResourcesDoesNotModifyInput(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{{"data": map[string]any{"a": "x\r\n"}}}
+	got := Resources(resources)
+
+	if diff := cmp.Diff([]map[string]any{{"data": map[string]any{"a": "x\n"}}}, got); diff != "" {
+		t.Errorf("Resources() mismatch (-want +got):\n%s", diff)
+	}
+	if resources[0]["data"].(map[string]any)["a"] != "x\r\n" {
+		t.Errorf("Resources() modified its input")
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	t.Parallel()
+
+	for f, want := range map[float64]string{
+		0:                           "0",
+		0.5:                         "0.5",
+		-2.25:                       "-2.25",
+		0.00001:                     "0.00001",
+		0.000001:                    "0.000001",
+		0.0000001:                   "1e-7",
+		1.5e-10:                     "1.5e-10",
+		123456789.125:               "123456789.125",
+		9007199254740993:            "9007199254740992",
+		1e20:                        "100000000000000000000",
+		1e21:                        "1e+21",
+		-1e300:                      "-1e+300",
+		math.Inf(1):                 "+Inf",
+		math.SmallestNonzeroFloat64: "5e-324",
+	} {
+		if got := FormatFloat(f); got != want {
+			t.Errorf("FormatFloat(%v) = %q, want %q", f, got, want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	t.Parallel()
+
+	if got := Path(`output\dev\stage-1-base.yaml`); got != "output/dev/stage-1-base.yaml" {
+		t.Errorf("Path() = %q, want output/dev/stage-1-base.yaml", got)
+	}
+}
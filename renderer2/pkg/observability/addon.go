@@ -0,0 +1,159 @@
+package observability
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/cel-go/cel"
+	celtypes "github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// AddonName is the name under which the built-in observability addon is registered.
+const AddonName = "observability"
+
+// EngineOptions registers the CEL functions used by the observability addon:
+//
+//	prometheusRule(metadata, spec) -> PrometheusRule object
+//	grafanaDashboard(metadata, spec) -> dashboard JSON string
+func EngineOptions() []template.EngineOption {
+	return []template.EngineOption{
+		template.WithFunction("prometheusRule",
+			cel.Overload("prometheus_rule_dyn_dyn", []*cel.Type{cel.DynType, cel.DynType}, cel.MapType(cel.StringType, cel.DynType),
+				cel.BinaryBinding(func(metadata, params ref.Val) ref.Val {
+					name, namespace, spec, err := bindingInputs(metadata, params)
+					if err != nil {
+						return celtypes.NewErr("prometheusRule: %v", err)
+					}
+					return celtypes.DefaultTypeAdapter.NativeToValue(PrometheusRule(name, namespace, spec))
+				}),
+			),
+		),
+		template.WithFunction("grafanaDashboard",
+			cel.Overload("grafana_dashboard_dyn_dyn", []*cel.Type{cel.DynType, cel.DynType}, cel.StringType,
+				cel.BinaryBinding(func(metadata, params ref.Val) ref.Val {
+					name, _, spec, err := bindingInputs(metadata, params)
+					if err != nil {
+						return celtypes.NewErr("grafanaDashboard: %v", err)
+					}
+					dashboard, err := DashboardJSON(name, spec)
+					if err != nil {
+						return celtypes.NewErr("grafanaDashboard: %v", err)
+					}
+					return celtypes.String(dashboard)
+				}),
+			),
+		),
+	}
+}
+
+// Addon returns the built-in observability addon. It creates a PrometheusRule and a Grafana
+// dashboard ConfigMap (picked up by the Grafana sidecar via the grafana_dashboard label) and emits
+// the dashboard JSON as an artifact. The engine must be created with EngineOptions.
+func Addon() *types.Addon {
+	return &types.Addon{
+		APIVersion: "openchoreo.dev/v1alpha1",
+		Kind:       "Addon",
+		Metadata:   types.Metadata{Name: AddonName},
+		Spec: types.AddonSpec{
+			DisplayName: "Observability",
+			Schema: types.Schema{
+				Types: map[string]any{
+					"SLO": map[string]any{
+						"name":       "string | required=true",
+						"objective":  "number | required=true minimum=0 maximum=100 exclusiveMinimum=true exclusiveMaximum=true",
+						"errorRatio": "string | required=true",
+					},
+					"Metric": map[string]any{
+						"name":  "string | required=true",
+						"query": "string | required=true",
+						"unit":  "string | default=short",
+					},
+				},
+				Parameters: map[string]any{
+					"slos":    "[]SLO",
+					"metrics": "[]Metric",
+				},
+			},
+			Creates: []types.CreateTemplate{
+				{Template: "${prometheusRule(metadata, spec)}"},
+				{Template: map[string]any{
+					"apiVersion": "v1",
+					"kind":       "ConfigMap",
+					"metadata": map[string]any{
+						"name":      "${metadata.name}-dashboard",
+						"namespace": "${metadata.namespace}",
+						"labels": map[string]any{
+							"grafana_dashboard": "1",
+						},
+					},
+					"data": map[string]any{
+						"${metadata.name}.json": "${grafanaDashboard(metadata, spec)}",
+					},
+				}},
+			},
+			Artifacts: []types.ArtifactTemplate{
+				{
+					ID:      "dashboard",
+					Path:    "dashboards/${metadata.name}.json",
+					Content: "${grafanaDashboard(metadata, spec)}",
+				},
+			},
+			Documentation: "Generates SLO recording rules, burn-rate alerts, and a Grafana dashboard from `slos` and `metrics`.",
+		},
+	}
+}
+
+func bindingInputs(metadata, params ref.Val) (string, string, Spec, error) {
+	md, ok := toNative(metadata).(map[string]any)
+	if !ok {
+		return "", "", Spec{}, fmt.Errorf("metadata must be a map")
+	}
+	paramMap, ok := toNative(params).(map[string]any)
+	if !ok {
+		return "", "", Spec{}, fmt.Errorf("spec must be a map")
+	}
+	spec, err := ParseSpec(paramMap)
+	if err != nil {
+		return "", "", Spec{}, err
+	}
+	name, _ := md["name"].(string)
+	namespace, _ := md["namespace"].(string)
+	return name, namespace, spec, nil
+}
+
+// toNative unwraps CEL values (including literals built inside expressions) into plain Go maps,
+// slices, and scalars.
+func toNative(value any) any {
+	switch typed := value.(type) {
+	case ref.Val:
+		return toNative(typed.Value())
+	case map[ref.Val]ref.Val:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[fmt.Sprint(k.Value())] = toNative(v)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[k] = toNative(v)
+		}
+		return result
+	case []ref.Val:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = toNative(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = toNative(v)
+		}
+		return result
+	default:
+		return typed
+	}
+}
@@ -0,0 +1,268 @@
+package observability
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fastBurnRate is the error budget burn rate that exhausts a 30 day budget in about two days,
+// the usual threshold for a paging alert.
+const fastBurnRate = 14.4
+
+// Spec is the `observability` parameter block: SLO targets plus key metrics to chart.
+type Spec struct {
+	SLOs    []SLO
+	Metrics []Metric
+}
+
+// SLO declares a service level objective backed by a PromQL error ratio.
+type SLO struct {
+	Name string
+	// Objective is the target success percentage, e.g. 99.9.
+	Objective float64
+	// ErrorRatio is a PromQL expression evaluating to the fraction of failed requests.
+	ErrorRatio string
+}
+
+// Metric is a PromQL query charted on the dashboard.
+type Metric struct {
+	Name  string
+	Query string
+	Unit  string
+}
+
+// ParseSpec reads a Spec from rendered parameters (`slos` and `metrics` lists).
+func ParseSpec(params map[string]any) (Spec, error) {
+	var spec Spec
+
+	slos, _ := params["slos"].([]any)
+	for i, raw := range slos {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			return Spec{}, fmt.Errorf("slos[%d] must be an object", i)
+		}
+		slo := SLO{
+			Name:       stringField(item, "name"),
+			ErrorRatio: stringField(item, "errorRatio"),
+		}
+		objective, err := numberField(item, "objective")
+		if err != nil {
+			return Spec{}, fmt.Errorf("slos[%d]: %w", i, err)
+		}
+		slo.Objective = objective
+		if slo.Name == "" || slo.ErrorRatio == "" {
+			return Spec{}, fmt.Errorf("slos[%d] requires name and errorRatio", i)
+		}
+		if slo.Objective <= 0 || slo.Objective >= 100 {
+			return Spec{}, fmt.Errorf("slos[%d] objective must be between 0 and 100, got %g", i, slo.Objective)
+		}
+		spec.SLOs = append(spec.SLOs, slo)
+	}
+
+	metrics, _ := params["metrics"].([]any)
+	for i, raw := range metrics {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			return Spec{}, fmt.Errorf("metrics[%d] must be an object", i)
+		}
+		metric := Metric{
+			Name:  stringField(item, "name"),
+			Query: stringField(item, "query"),
+			Unit:  stringField(item, "unit"),
+		}
+		if metric.Name == "" || metric.Query == "" {
+			return Spec{}, fmt.Errorf("metrics[%d] requires name and query", i)
+		}
+		if metric.Unit == "" {
+			metric.Unit = "short"
+		}
+		spec.Metrics = append(spec.Metrics, metric)
+	}
+
+	return spec, nil
+}
+
+// PrometheusRule builds a prometheus-operator PrometheusRule with one recording rule and one fast
+// burn alert per SLO.
+func PrometheusRule(name, namespace string, spec Spec) map[string]any {
+	rules := make([]any, 0, 2*len(spec.SLOs))
+	for _, slo := range spec.SLOs {
+		record := recordName(slo.Name)
+		labels := map[string]any{"service": name, "slo": slo.Name}
+		threshold := formatFloat(fastBurnRate * (1 - slo.Objective/100))
+
+		rules = append(rules,
+			map[string]any{
+				"record": record,
+				"expr":   slo.ErrorRatio,
+				"labels": labels,
+			},
+			map[string]any{
+				"alert": camelCase(name) + camelCase(slo.Name) + "ErrorBudgetBurn",
+				"expr":  fmt.Sprintf(`%s{service="%s",slo="%s"} > %s`, record, name, slo.Name, threshold),
+				"for":   "2m",
+				"labels": map[string]any{
+					"severity": "critical",
+					"service":  name,
+					"slo":      slo.Name,
+				},
+				"annotations": map[string]any{
+					"summary":     fmt.Sprintf("%s is burning its %s error budget too fast", name, slo.Name),
+					"description": fmt.Sprintf("Error ratio is above %sx the budget of the %s%% objective.", formatFloat(fastBurnRate), formatFloat(slo.Objective)),
+				},
+			},
+		)
+	}
+
+	metadata := map[string]any{
+		"name":   name + "-slos",
+		"labels": map[string]any{"app.kubernetes.io/name": name},
+	}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return map[string]any{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PrometheusRule",
+		"metadata":   metadata,
+		"spec": map[string]any{
+			"groups": []any{
+				map[string]any{
+					"name":  name + "-slos",
+					"rules": rules,
+				},
+			},
+		},
+	}
+}
+
+// Dashboard builds a Grafana dashboard model with an availability panel per SLO followed by one
+// panel per key metric, laid out two per row.
+func Dashboard(name string, spec Spec) map[string]any {
+	type panelSource struct {
+		title string
+		expr  string
+		unit  string
+	}
+	var sources []panelSource
+	for _, slo := range spec.SLOs {
+		sources = append(sources, panelSource{
+			title: fmt.Sprintf("%s (objective %s%%)", slo.Name, formatFloat(slo.Objective)),
+			expr:  fmt.Sprintf("1 - (%s)", slo.ErrorRatio),
+			unit:  "percentunit",
+		})
+	}
+	for _, metric := range spec.Metrics {
+		sources = append(sources, panelSource{title: metric.Name, expr: metric.Query, unit: metric.Unit})
+	}
+
+	datasource := map[string]any{"type": "prometheus", "uid": "$datasource"}
+	panels := make([]any, len(sources))
+	for i, source := range sources {
+		panels[i] = map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      source.title,
+			"datasource": datasource,
+			"gridPos":    map[string]any{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": source.unit},
+				"overrides": []any{},
+			},
+			"targets": []any{
+				map[string]any{"refId": "A", "expr": source.expr, "datasource": datasource},
+			},
+		}
+	}
+
+	return map[string]any{
+		"title":         name,
+		"uid":           dashboardUID(name),
+		"tags":          []any{"openchoreo"},
+		"schemaVersion": 39,
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"templating": map[string]any{
+			"list": []any{
+				map[string]any{"name": "datasource", "type": "datasource", "query": "prometheus"},
+			},
+		},
+		"panels": panels,
+	}
+}
+
+// DashboardJSON encodes Dashboard as indented JSON, ready for a ConfigMap entry or a file.
+func DashboardJSON(name string, spec Spec) (string, error) {
+	encoded, err := json.MarshalIndent(Dashboard(name, spec), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode dashboard: %w", err)
+	}
+	return string(encoded) + "\n", nil
+}
+
+func recordName(slo string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(slo) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return "slo:" + strings.Trim(b.String(), "_") + ":error_ratio"
+}
+
+func camelCase(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// dashboardUID derives a stable Grafana UID, which is limited to 40 characters.
+func dashboardUID(name string) string {
+	uid := strings.ToLower(name)
+	if len(uid) > 40 {
+		uid = uid[:40]
+	}
+	return uid
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*1e6)/1e6, 'f', -1, 64)
+}
+
+func stringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func numberField(m map[string]any, key string) (float64, error) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case nil:
+		return 0, fmt.Errorf("%s is required", key)
+	default:
+		return 0, fmt.Errorf("%s must be a number, got %T", key, v)
+	}
+}
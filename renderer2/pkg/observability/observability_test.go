@@ -0,0 +1,115 @@
+package observability
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSpec(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseSpec(map[string]any{
+		"slos": []any{
+			map[string]any{"name": "availability", "objective": 99.9, "errorRatio": "errors / total"},
+			map[string]any{"name": "latency", "objective": int64(99), "errorRatio": "slow / total"},
+		},
+		"metrics": []any{
+			map[string]any{"name": "Request rate", "query": "sum(rate(http_requests_total[5m]))"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+	want := Spec{
+		SLOs: []SLO{
+			{Name: "availability", Objective: 99.9, ErrorRatio: "errors / total"},
+			{Name: "latency", Objective: 99, ErrorRatio: "slow / total"},
+		},
+		Metrics: []Metric{{Name: "Request rate", Query: "sum(rate(http_requests_total[5m]))", Unit: "short"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ParseSpec() mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := ParseSpec(map[string]any{"slos": []any{map[string]any{"name": "a", "objective": 100.0, "errorRatio": "x"}}}); err == nil {
+		t.Fatalf("ParseSpec() accepted objective of 100")
+	}
+}
+
+func TestPrometheusRule(t *testing.T) {
+	t.Parallel()
+
+	rule := PrometheusRule("checkout", "shop", Spec{
+		SLOs: []SLO{{Name: "availability", Objective: 99.9, ErrorRatio: "errors / total"}},
+	})
+
+	groups := rule["spec"].(map[string]any)["groups"].([]any)
+	rules := groups[0].(map[string]any)["rules"].([]any)
+	want := []any{
+		map[string]any{
+			"record": "slo:availability:error_ratio",
+			"expr":   "errors / total",
+			"labels": map[string]any{"service": "checkout", "slo": "availability"},
+		},
+		map[string]any{
+			"alert": "CheckoutAvailabilityErrorBudgetBurn",
+			"expr":  `slo:availability:error_ratio{service="checkout",slo="availability"} > 0.0144`,
+			"for":   "2m",
+			"labels": map[string]any{
+				"severity": "critical",
+				"service":  "checkout",
+				"slo":      "availability",
+			},
+			"annotations": map[string]any{
+				"summary":     "checkout is burning its availability error budget too fast",
+				"description": "Error ratio is above 14.4x the budget of the 99.9% objective.",
+			},
+		},
+	}
+	if diff := cmp.Diff(want, rules); diff != "" {
+		t.Fatalf("rules mismatch (-want +got):\n%s", diff)
+	}
+	if ns := rule["metadata"].(map[string]any)["namespace"]; ns != "shop" {
+		t.Fatalf("namespace = %v, want shop", ns)
+	}
+}
+
+func TestDashboardJSON(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := DashboardJSON("checkout", Spec{
+		SLOs:    []SLO{{Name: "availability", Objective: 99.5, ErrorRatio: "errors / total"}},
+		Metrics: []Metric{{Name: "Latency p99", Query: "histogram_quantile(0.99, x)", Unit: "s"}},
+	})
+	if err != nil {
+		t.Fatalf("DashboardJSON() error = %v", err)
+	}
+
+	var dashboard struct {
+		UID    string `json:"uid"`
+		Panels []struct {
+			Title   string `json:"title"`
+			GridPos struct {
+				X int `json:"x"`
+			} `json:"gridPos"`
+			Targets []struct {
+				Expr string `json:"expr"`
+			} `json:"targets"`
+		} `json:"panels"`
+	}
+	if err := json.Unmarshal([]byte(encoded), &dashboard); err != nil {
+		t.Fatalf("dashboard is not valid JSON: %v", err)
+	}
+	if dashboard.UID != "checkout" || len(dashboard.Panels) != 2 {
+		t.Fatalf("dashboard = %+v", dashboard)
+	}
+	if got := dashboard.Panels[0].Targets[0].Expr; got != "1 - (errors / total)" {
+		t.Fatalf("SLO panel expr = %q", got)
+	}
+	if dashboard.Panels[1].GridPos.X != 12 || !strings.HasPrefix(dashboard.Panels[1].Title, "Latency") {
+		t.Fatalf("metric panel = %+v", dashboard.Panels[1])
+	}
+}
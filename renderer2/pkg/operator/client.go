@@ -0,0 +1,285 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/transport"
+)
+
+// serviceAccountDir holds the token and CA certificate Kubernetes mounts into every pod.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// Client is the part of the Kubernetes API the operator needs (discovery, cluster-wide lists,
+// server-side apply, replace and create), spoken over plain REST so the renderer does not depend on client-go.
+type Client struct {
+	server string
+	token  string
+	http   *http.Client
+
+	mu sync.Mutex
+	// resources caches discovery by apiVersion and kind.
+	resources map[string]apiResource
+}
+
+var _ Cluster = (*Client)(nil)
+
+type apiResource struct {
+	name       string
+	namespaced bool
+}
+
+// StatusError is an API response outside the 2xx range.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("kubernetes API returned %d: %s", e.Code, e.Message)
+}
+
+// NewClient returns a client for the API server at server, e.g. https://10.0.0.1:443, sending
+// token as a bearer token when it is set.
+func NewClient(server, token string, httpClient *http.Client) *Client {
+	return &Client{
+		server:    strings.TrimSuffix(server, "/"),
+		token:     token,
+		http:      httpClient,
+		resources: map[string]apiResource{},
+	}
+}
+
+// InCluster returns a client authenticated as the pod's service account. Requests are retried
+// according to opts.
+func InCluster(opts transport.Options) (*Client, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are unset")
+	}
+	token, err := os.ReadFile(filepath.Join(serviceAccountDir, "token"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s", filepath.Join(serviceAccountDir, "ca.crt"))
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.TLSClientConfig = &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}
+	httpClient := &http.Client{Transport: &transport.Retry{Base: base, Options: opts}}
+	return NewClient("https://"+net.JoinHostPort(host, port), strings.TrimSpace(string(token)), httpClient), nil
+}
+
+// List returns every object of kind in all namespaces.
+func (c *Client) List(ctx context.Context, apiVersion, kind string) ([]map[string]any, error) {
+	resource, err := c.resource(ctx, apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, apiPrefix(apiVersion)+"/"+resource.name, "", nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+	// List items omit their type.
+	for _, item := range list.Items {
+		item["apiVersion"], item["kind"] = apiVersion, kind
+	}
+	return list.Items, nil
+}
+
+// Apply server-side applies object as fieldManager. With force it takes over fields other managers
+// own; without, such conflicts fail the apply.
+func (c *Client) Apply(ctx context.Context, object map[string]any, fieldManager string, force bool) error {
+	target, err := c.target(ctx, object)
+	if err != nil {
+		return err
+	}
+	query := url.Values{"fieldManager": {fieldManager}}
+	if force {
+		query.Set("force", "true")
+	}
+	// JSON is YAML, so the apply content type accepts it.
+	if err := c.do(ctx, http.MethodPatch, target.object+"?"+query.Encode(), "application/apply-patch+yaml", target.body, nil); err != nil {
+		return fmt.Errorf("failed to apply %s: %w", target.name, err)
+	}
+	return nil
+}
+
+// Replace updates object as a whole as fieldManager, creating it when it does not exist.
+func (c *Client) Replace(ctx context.Context, object map[string]any, fieldManager string) error {
+	target, err := c.target(ctx, object)
+	if err != nil {
+		return err
+	}
+	query := "?" + url.Values{"fieldManager": {fieldManager}}.Encode()
+	err = c.do(ctx, http.MethodPut, target.object+query, "application/json", target.body, nil)
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		err = c.do(ctx, http.MethodPost, target.collection+query, "application/json", target.body, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to replace %s: %w", target.name, err)
+	}
+	return nil
+}
+
+// Create creates object as fieldManager. When the object exists it returns a *StatusError with
+// code 409 (see IsAlreadyExists).
+func (c *Client) Create(ctx context.Context, object map[string]any, fieldManager string) error {
+	target, err := c.target(ctx, object)
+	if err != nil {
+		return err
+	}
+	query := "?" + url.Values{"fieldManager": {fieldManager}}.Encode()
+	if err := c.do(ctx, http.MethodPost, target.collection+query, "application/json", target.body, nil); err != nil {
+		return fmt.Errorf("failed to create %s: %w", target.name, err)
+	}
+	return nil
+}
+
+// IsAlreadyExists reports whether err is the API server refusing to create an existing object.
+func IsAlreadyExists(err error) bool {
+	var status *StatusError
+	return errors.As(err, &status) && status.Code == http.StatusConflict
+}
+
+// writeTarget is where an object is written: its collection, the object itself, a name for
+// errors and the encoded object.
+type writeTarget struct {
+	collection string
+	object     string
+	name       string
+	body       []byte
+}
+
+func (c *Client) target(ctx context.Context, object map[string]any) (writeTarget, error) {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	if apiVersion == "" || kind == "" || name == "" {
+		return writeTarget{}, fmt.Errorf("cannot write an object without apiVersion, kind and metadata.name")
+	}
+	resource, err := c.resource(ctx, apiVersion, kind)
+	if err != nil {
+		return writeTarget{}, err
+	}
+
+	path := apiPrefix(apiVersion)
+	if resource.namespaced {
+		if namespace == "" {
+			return writeTarget{}, fmt.Errorf("%s %s needs metadata.namespace", kind, name)
+		}
+		path += "/namespaces/" + url.PathEscape(namespace)
+	}
+	path += "/" + resource.name
+
+	body, err := json.Marshal(object)
+	if err != nil {
+		return writeTarget{}, fmt.Errorf("failed to encode %s %s: %w", kind, name, err)
+	}
+	return writeTarget{
+		collection: path,
+		object:     path + "/" + url.PathEscape(name),
+		name:       kind + " " + name,
+		body:       body,
+	}, nil
+}
+
+// resource discovers the REST resource serving kind in apiVersion.
+func (c *Client) resource(ctx context.Context, apiVersion, kind string) (apiResource, error) {
+	key := apiVersion + "/" + kind
+	c.mu.Lock()
+	resource, ok := c.resources[key]
+	c.mu.Unlock()
+	if ok {
+		return resource, nil
+	}
+
+	var list struct {
+		Resources []struct {
+			Name       string `json:"name"`
+			Kind       string `json:"kind"`
+			Namespaced bool   `json:"namespaced"`
+		} `json:"resources"`
+	}
+	if err := c.do(ctx, http.MethodGet, apiPrefix(apiVersion), "", nil, &list); err != nil {
+		return apiResource{}, fmt.Errorf("failed to discover %s: %w", apiVersion, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range list.Resources {
+		// Subresources such as deployments/status share the kind of their parent.
+		if !strings.Contains(r.Name, "/") {
+			c.resources[apiVersion+"/"+r.Kind] = apiResource{name: r.Name, namespaced: r.Namespaced}
+		}
+	}
+	if resource, ok = c.resources[key]; !ok {
+		return apiResource{}, fmt.Errorf("the API server does not serve %s %s", apiVersion, kind)
+	}
+	return resource, nil
+}
+
+func apiPrefix(apiVersion string) string {
+	if strings.Contains(apiVersion, "/") {
+		return "/apis/" + apiVersion
+	}
+	return "/api/" + apiVersion
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.server+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(data, &status) != nil || status.Message == "" {
+			status.Message = strings.TrimSpace(string(data))
+		}
+		return &StatusError{Code: resp.StatusCode, Message: status.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
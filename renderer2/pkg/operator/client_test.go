@@ -0,0 +1,149 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func fakeAPIServer(t *testing.T, requests *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		*requests = append(*requests, r.Method+" "+r.URL.RequestURI())
+		switch r.URL.Path {
+		case "/apis/openchoreo.dev/v1alpha1":
+			io.WriteString(w, `{"resources": [
+				{"name": "components", "kind": "Component", "namespaced": true},
+				{"name": "components/status", "kind": "Component", "namespaced": true}
+			]}`)
+		case "/api/v1":
+			io.WriteString(w, `{"resources": [{"name": "configmaps", "kind": "ConfigMap", "namespaced": true}]}`)
+		case "/apis/openchoreo.dev/v1alpha1/components":
+			io.WriteString(w, `{"items": [{"metadata": {"name": "app", "namespace": "team-a"}}]}`)
+		case "/api/v1/namespaces/team-a/configmaps/app":
+			if got := r.Header.Get("Content-Type"); r.Method == http.MethodPatch && got != "application/apply-patch+yaml" {
+				t.Errorf("Content-Type = %q, want apply patch", got)
+			}
+			body, _ := io.ReadAll(r.Body)
+			if !json.Valid(body) {
+				t.Errorf("apply body %q is not JSON", body)
+			}
+			io.WriteString(w, `{}`)
+		case "/api/v1/namespaces/team-a/configmaps":
+			var object struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&object); err != nil {
+				t.Errorf("create body is not JSON: %v", err)
+			}
+			if object.Metadata.Name == "app" {
+				w.WriteHeader(http.StatusConflict)
+				io.WriteString(w, `{"kind": "Status", "message": "configmaps \"app\" already exists"}`)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"kind": "Status", "message": "not found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	server := fakeAPIServer(t, &requests)
+	client := NewClient(server.URL, "secret", server.Client())
+	ctx := context.Background()
+
+	items, err := client.List(ctx, APIVersion, "Component")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	wantItems := []map[string]any{{
+		"apiVersion": APIVersion,
+		"kind":       "Component",
+		"metadata":   map[string]any{"name": "app", "namespace": "team-a"},
+	}}
+	if diff := cmp.Diff(wantItems, items); diff != "" {
+		t.Errorf("List() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := client.List(ctx, APIVersion, "Component"); err != nil {
+		t.Fatalf("second List() error = %v", err)
+	}
+
+	configMap := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "app", "namespace": "team-a"},
+	}
+	if err := client.Apply(ctx, configMap, "renderer2", true); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if err := client.Apply(ctx, configMap, "renderer2", false); err != nil {
+		t.Fatalf("Apply() without force error = %v", err)
+	}
+	if err := client.Replace(ctx, configMap, "renderer2"); err != nil {
+		t.Fatalf("Replace() error = %v", err)
+	}
+	if err := client.Create(ctx, configMap, "renderer2"); !IsAlreadyExists(err) {
+		t.Errorf("Create() of an existing object error = %v, want already exists", err)
+	}
+	missing := map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "new", "namespace": "team-a"}}
+	if err := client.Replace(ctx, missing, "renderer2"); err != nil {
+		t.Fatalf("Replace() of a missing object error = %v", err)
+	}
+
+	wantRequests := []string{
+		"GET /apis/openchoreo.dev/v1alpha1",
+		"GET /apis/openchoreo.dev/v1alpha1/components",
+		"GET /apis/openchoreo.dev/v1alpha1/components",
+		"GET /api/v1",
+		"PATCH /api/v1/namespaces/team-a/configmaps/app?fieldManager=renderer2&force=true",
+		"PATCH /api/v1/namespaces/team-a/configmaps/app?fieldManager=renderer2",
+		"PUT /api/v1/namespaces/team-a/configmaps/app?fieldManager=renderer2",
+		"POST /api/v1/namespaces/team-a/configmaps?fieldManager=renderer2",
+		"PUT /api/v1/namespaces/team-a/configmaps/new?fieldManager=renderer2",
+		"POST /api/v1/namespaces/team-a/configmaps?fieldManager=renderer2",
+	}
+	if diff := cmp.Diff(wantRequests, requests); diff != "" {
+		t.Errorf("requests mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	var requests []string
+	server := fakeAPIServer(t, &requests)
+	client := NewClient(server.URL, "secret", server.Client())
+	ctx := context.Background()
+
+	if _, err := client.List(ctx, APIVersion, "Addon"); err == nil {
+		t.Errorf("List(Addon) succeeded, want an undiscovered kind error")
+	}
+	_, err := client.List(ctx, "example.com/v1", "Widget")
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != http.StatusNotFound || status.Message != "not found" {
+		t.Errorf("List(Widget) error = %v, want a 404 StatusError", err)
+	}
+	unnamespaced := map[string]any{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]any{"name": "app"}}
+	if err := client.Apply(ctx, unnamespaced, "renderer2", true); err == nil {
+		t.Errorf("Apply() without a namespace succeeded, want error")
+	}
+}
@@ -0,0 +1,472 @@
+// Package operator runs renderer2 inside a cluster. It reads ComponentTypeDefinitions, Addons,
+// Components and EnvSettings as custom resources, renders every Component for each EnvSettings that
+// references it, and server-side applies the output with ownerReferences back to the Component, so
+// deleting a Component garbage-collects what was rendered for it.
+package operator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the group and version the operator reads its custom resources from.
+const APIVersion = "openchoreo.dev/v1alpha1"
+
+const (
+	// DefaultFieldManager owns the fields the operator applies.
+	DefaultFieldManager = "renderer2"
+	// DefaultInterval is how often Run reconciles.
+	DefaultInterval = 10 * time.Second
+	// DefaultResync is how long unchanged inputs go without being applied again, which repairs
+	// drift introduced by other writers.
+	DefaultResync = 10 * time.Minute
+)
+
+// Cluster is the Kubernetes API the operator reconciles against. Client implements it.
+type Cluster interface {
+	// List returns every object of kind in all namespaces.
+	List(ctx context.Context, apiVersion, kind string) ([]map[string]any, error)
+	// Apply server-side applies object as fieldManager, taking over fields other managers own
+	// when force is set.
+	Apply(ctx context.Context, object map[string]any, fieldManager string, force bool) error
+	// Replace updates object as a whole, creating it when it does not exist.
+	Replace(ctx context.Context, object map[string]any, fieldManager string) error
+	// Create creates object and fails with an error IsAlreadyExists recognizes when it exists.
+	Create(ctx context.Context, object map[string]any, fieldManager string) error
+}
+
+// Result is the outcome of reconciling one Component in one environment, or of decoding one
+// custom resource that could not be read.
+type Result struct {
+	// Component is namespace/name.
+	Component string
+	// Environment is the EnvSettings name, or empty when no EnvSettings references the Component.
+	Environment string
+	// Object is the "kind namespace/name" of a custom resource that failed to decode; empty for
+	// reconcile results.
+	Object string
+	// Applied counts the resources applied; zero when Unchanged.
+	Applied int
+	// Skipped counts the skip-if-exists resources left alone because they already exist.
+	Skipped int
+	// Unchanged means the inputs matched the last successful apply within the resync period.
+	Unchanged bool
+	Err       error
+}
+
+// Operator reconciles Components into rendered resources.
+type Operator struct {
+	cluster      Cluster
+	renderer     *component.Renderer
+	fieldManager string
+	applyPolicy  pipeline.ApplyPolicy
+	interval     time.Duration
+	resync       time.Duration
+	now          func() time.Time
+
+	mu sync.Mutex
+	// applied records the digest of the inputs last applied for each Component and environment.
+	applied map[string]appliedState
+}
+
+type appliedState struct {
+	digest string
+	at     time.Time
+}
+
+// Option configures an Operator.
+type Option func(*Operator)
+
+// WithFieldManager sets the server-side apply field manager. Defaults to DefaultFieldManager.
+func WithFieldManager(name string) Option {
+	return func(o *Operator) { o.fieldManager = name }
+}
+
+// WithApplyPolicy sets how resources that do not request an apply policy are applied. Defaults to
+// pipeline.ApplyForceConflicts, so the operator takes over fields other writers changed.
+func WithApplyPolicy(policy pipeline.ApplyPolicy) Option {
+	return func(o *Operator) { o.applyPolicy = policy }
+}
+
+// WithInterval sets how often Run reconciles. Defaults to DefaultInterval.
+func WithInterval(d time.Duration) Option {
+	return func(o *Operator) { o.interval = d }
+}
+
+// WithResync sets how long unchanged inputs go without being applied again. Zero applies on every
+// reconcile. Defaults to DefaultResync.
+func WithResync(d time.Duration) Option {
+	return func(o *Operator) { o.resync = d }
+}
+
+// New creates an operator that renders with renderer and applies to cluster.
+func New(cluster Cluster, renderer *component.Renderer, opts ...Option) *Operator {
+	o := &Operator{
+		cluster:      cluster,
+		renderer:     renderer,
+		fieldManager: DefaultFieldManager,
+		applyPolicy:  pipeline.ApplyForceConflicts,
+		interval:     DefaultInterval,
+		resync:       DefaultResync,
+		now:          time.Now,
+		applied:      map[string]appliedState{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Run reconciles every interval until ctx is done, passing each result to report. A reconcile that
+// cannot read the custom resources is reported as a single Result and retried on the next tick.
+func (o *Operator) Run(ctx context.Context, report func(Result)) error {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	for {
+		results, err := o.Sync(ctx)
+		if err != nil {
+			results = []Result{{Err: err}}
+		}
+		for _, result := range results {
+			report(result)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// inputs are the custom resources one reconcile works from.
+type inputs struct {
+	definitions map[string]*types.ComponentTypeDefinition
+	addons      map[string]*types.Addon
+	components  []object[types.Component]
+	envs        []object[types.EnvSettings]
+	// invalid holds the decode error of each definition and addon that failed to decode, by
+	// "kind name", so Components using them report why they cannot render.
+	invalid map[string]error
+	// failures are the custom resources that failed to decode.
+	failures []decodeFailure
+}
+
+// decodeFailure is a custom resource that could not be decoded.
+type decodeFailure struct {
+	kind, namespace, name string
+	err                   error
+}
+
+// object is a decoded custom resource together with the UID the typed form does not carry.
+type object[T any] struct {
+	value *T
+	uid   string
+}
+
+// Sync reconciles every Component once. Failures decoding one custom resource, or rendering or
+// applying one Component, are recorded in its Result; only failing to list the custom resources
+// fails Sync.
+func (o *Operator) Sync(ctx context.Context) ([]Result, error) {
+	in, err := o.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	// live holds the applied keys still in use. A Component that fails to decode keeps its keys, so
+	// it is not applied again just because it was unreadable for a while.
+	live := map[string]bool{}
+	for _, failure := range in.failures {
+		result := Result{Object: failure.kind + " " + qualified(failure.namespace, failure.name), Err: failure.err}
+		if failure.kind == "Component" {
+			result.Component = qualified(failure.namespace, failure.name)
+			o.keep(live, result.Component)
+		}
+		results = append(results, result)
+	}
+	for _, comp := range in.components {
+		var matched []types.EnvSettings
+		for _, env := range in.envs {
+			if references(env.value, comp.value) {
+				matched = append(matched, *env.value)
+			}
+		}
+		if len(matched) == 0 {
+			matched = []types.EnvSettings{{}}
+		}
+		for _, settings := range matched {
+			result := o.reconcile(ctx, in, comp, settings)
+			live[result.Component+"/"+result.Environment] = true
+			results = append(results, result)
+		}
+	}
+	o.prune(live)
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Component != results[j].Component {
+			return results[i].Component < results[j].Component
+		}
+		if results[i].Environment != results[j].Environment {
+			return results[i].Environment < results[j].Environment
+		}
+		return results[i].Object < results[j].Object
+	})
+	return results, nil
+}
+
+// keep marks every applied key of component as live.
+func (o *Operator) keep(live map[string]bool, component string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for key := range o.applied {
+		if strings.HasPrefix(key, component+"/") {
+			live[key] = true
+		}
+	}
+}
+
+// prune forgets the applied state of Components and environments that no longer exist, so the map
+// does not grow with every Component ever reconciled and a recreated Component is applied at once.
+func (o *Operator) prune(live map[string]bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for key := range o.applied {
+		if !live[key] {
+			delete(o.applied, key)
+		}
+	}
+}
+
+func (o *Operator) load(ctx context.Context) (inputs, error) {
+	in := inputs{
+		definitions: map[string]*types.ComponentTypeDefinition{},
+		addons:      map[string]*types.Addon{observability.AddonName: observability.Addon()},
+		invalid:     map[string]error{},
+	}
+	kinds := []struct {
+		kind   string
+		decode func(name string, content []byte, uid string) error
+	}{
+		{"ComponentTypeDefinition", func(name string, content []byte, _ string) error {
+			ctd, err := parser.ParseComponentTypeDefinition(name, content)
+			if err == nil {
+				in.definitions[ctd.Metadata.Name] = ctd
+			}
+			return err
+		}},
+		{"Addon", func(name string, content []byte, _ string) error {
+			addon, err := parser.ParseAddon(name, content)
+			if err == nil {
+				in.addons[addon.Metadata.Name] = addon
+			}
+			return err
+		}},
+		{"Component", func(_ string, content []byte, uid string) error {
+			comp, err := parser.ParseComponent(content)
+			if err == nil {
+				in.components = append(in.components, object[types.Component]{value: comp, uid: uid})
+			}
+			return err
+		}},
+		{"EnvSettings", func(_ string, content []byte, uid string) error {
+			env, err := parser.ParseEnvSettings(content)
+			if err == nil {
+				in.envs = append(in.envs, object[types.EnvSettings]{value: env, uid: uid})
+			}
+			return err
+		}},
+	}
+	for _, k := range kinds {
+		failures, err := decodeAll(ctx, o.cluster, k.kind, k.decode)
+		if err != nil {
+			return inputs{}, err
+		}
+		for _, failure := range failures {
+			in.invalid[failure.kind+" "+failure.name] = failure.err
+		}
+		in.failures = append(in.failures, failures...)
+	}
+	return in, nil
+}
+
+// decodeAll lists kind and hands each object, re-encoded as YAML, to decode along with its
+// "kind namespace/name" and UID. Objects that fail to decode are returned rather than stopping
+// the list, so one bad custom resource does not hold up the others.
+func decodeAll(ctx context.Context, cluster Cluster, kind string, decode func(name string, content []byte, uid string) error) ([]decodeFailure, error) {
+	items, err := cluster.List(ctx, APIVersion, kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s resources: %w", kind, err)
+	}
+	var failures []decodeFailure
+	for _, item := range items {
+		metadata, _ := item["metadata"].(map[string]any)
+		namespace, _ := metadata["namespace"].(string)
+		name, _ := metadata["name"].(string)
+		uid, _ := metadata["uid"].(string)
+		id := kind + " " + qualified(namespace, name)
+		content, err := yaml.Marshal(item)
+		if err != nil {
+			failures = append(failures, decodeFailure{kind, namespace, name, fmt.Errorf("failed to encode %s: %w", id, err)})
+			continue
+		}
+		if err := decode(id, content, uid); err != nil {
+			failures = append(failures, decodeFailure{kind, namespace, name, fmt.Errorf("failed to decode %s: %w", id, err)})
+		}
+	}
+	return failures, nil
+}
+
+// references reports whether env renders comp. A componentRef without a namespace refers to the
+// EnvSettings' own namespace.
+func references(env *types.EnvSettings, comp *types.Component) bool {
+	ref := env.Spec.ComponentRef
+	if ref == nil || ref.Name != comp.Metadata.Name {
+		return false
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = env.Metadata.Namespace
+	}
+	return namespace == comp.Metadata.Namespace
+}
+
+// dependencies returns the definition and addons comp renders with, or why one of them cannot be
+// used.
+func (in inputs) dependencies(comp *types.Component) (*types.ComponentTypeDefinition, map[string]*types.Addon, error) {
+	ctd, ok := in.definitions[comp.Spec.ComponentType]
+	if err := in.invalid["ComponentTypeDefinition "+comp.Spec.ComponentType]; !ok && err != nil {
+		return nil, nil, fmt.Errorf("component type definition %s is invalid: %w", comp.Spec.ComponentType, err)
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("component type definition %s not found", comp.Spec.ComponentType)
+	}
+	addons := map[string]*types.Addon{}
+	for _, instance := range comp.Spec.Addons {
+		addon, ok := in.addons[instance.Name]
+		if err := in.invalid["Addon "+instance.Name]; !ok && err != nil {
+			return nil, nil, fmt.Errorf("addon %s is invalid: %w", instance.Name, err)
+		}
+		if !ok {
+			return nil, nil, fmt.Errorf("addon %s not found", instance.Name)
+		}
+		addons[instance.Name] = addon
+	}
+	return ctd, addons, nil
+}
+
+func (o *Operator) reconcile(ctx context.Context, in inputs, comp object[types.Component], settings types.EnvSettings) Result {
+	meta := comp.value.Metadata
+	result := Result{Component: qualified(meta.Namespace, meta.Name), Environment: settings.Metadata.Name}
+
+	ctd, addons, err := in.dependencies(comp.value)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// The rendered resources are owned by the Component custom resource itself.
+	settings.Spec.ComponentRef = &types.ComponentRef{
+		Name:       meta.Name,
+		Namespace:  meta.Namespace,
+		APIVersion: APIVersion,
+		Kind:       "Component",
+		UID:        comp.uid,
+	}
+
+	key := result.Component + "/" + result.Environment
+	digest, err := inputDigest(ctd, comp.value, &settings, addons)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	o.mu.Lock()
+	last, seen := o.applied[key]
+	o.mu.Unlock()
+	if seen && last.digest == digest && o.now().Sub(last.at) < o.resync {
+		result.Unchanged = true
+		return result
+	}
+
+	rendered, err := o.renderer.RenderResources(ctd, comp.value, &settings, addons, nil, nil, len(comp.value.Spec.Addons))
+	if err != nil {
+		result.Err = fmt.Errorf("failed to render: %w", err)
+		return result
+	}
+	if err := pipeline.ApplyOwners(rendered, &settings, pipeline.OwnerReferences); err != nil {
+		result.Err = err
+		return result
+	}
+	for _, resource := range rendered {
+		// ownerReferences only work within the owner's namespace. Cluster-scoped resources have
+		// none; ApplyOwners tracks them with the owner annotation instead.
+		if metadata, ok := resource.Object["metadata"].(map[string]any); ok && metadata["namespace"] == nil && meta.Namespace != "" &&
+			resource.Scope != pipeline.ScopeCluster {
+			metadata["namespace"] = meta.Namespace
+		}
+		skipped, err := o.write(ctx, resource)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		if skipped {
+			result.Skipped++
+		} else {
+			result.Applied++
+		}
+	}
+
+	o.mu.Lock()
+	o.applied[key] = appliedState{digest: digest, at: o.now()}
+	o.mu.Unlock()
+	return result
+}
+
+// write applies resource according to its apply policy, or the operator's when it requests none,
+// and reports whether a skip-if-exists resource was left alone.
+func (o *Operator) write(ctx context.Context, resource pipeline.RenderedResource) (bool, error) {
+	policy := resource.Options.ApplyPolicy
+	if policy == pipeline.ApplyDefault {
+		policy = o.applyPolicy
+	}
+	switch policy {
+	case pipeline.ApplyReplace:
+		return false, o.cluster.Replace(ctx, resource.Object, o.fieldManager)
+	case pipeline.ApplySkipIfExists:
+		err := o.cluster.Create(ctx, resource.Object, o.fieldManager)
+		if IsAlreadyExists(err) {
+			return true, nil
+		}
+		return false, err
+	default:
+		return false, o.cluster.Apply(ctx, resource.Object, o.fieldManager, policy != pipeline.ApplyServerSide)
+	}
+}
+
+// inputDigest hashes everything a render reads, so unchanged inputs can skip rendering.
+func inputDigest(ctd *types.ComponentTypeDefinition, comp *types.Component, settings *types.EnvSettings, addons map[string]*types.Addon) (string, error) {
+	content, err := yaml.Marshal([]any{ctd, comp, settings, addons})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash inputs: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func qualified(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
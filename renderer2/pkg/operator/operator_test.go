@@ -0,0 +1,457 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+// fakeCluster serves custom resources from YAML and records applied objects.
+type fakeCluster struct {
+	objects map[string][]map[string]any
+	applied []map[string]any
+	writes  []string
+	// existing names, as <kind>/<name>, the objects Create finds.
+	existing map[string]bool
+}
+
+func newFakeCluster(t *testing.T, documents ...string) *fakeCluster {
+	t.Helper()
+	c := &fakeCluster{objects: map[string][]map[string]any{}}
+	for _, document := range documents {
+		var object map[string]any
+		if err := yaml.Unmarshal([]byte(document), &object); err != nil {
+			t.Fatalf("yaml.Unmarshal() error = %v", err)
+		}
+		kind, _ := object["kind"].(string)
+		c.objects[kind] = append(c.objects[kind], object)
+	}
+	return c
+}
+
+func (c *fakeCluster) List(_ context.Context, apiVersion, kind string) ([]map[string]any, error) {
+	if apiVersion != APIVersion {
+		return nil, fmt.Errorf("unexpected apiVersion %s", apiVersion)
+	}
+	return c.objects[kind], nil
+}
+
+func (c *fakeCluster) Apply(_ context.Context, object map[string]any, fieldManager string, force bool) error {
+	return c.write("apply", object, fieldManager, force)
+}
+
+func (c *fakeCluster) Replace(_ context.Context, object map[string]any, fieldManager string) error {
+	return c.write("replace", object, fieldManager, false)
+}
+
+func (c *fakeCluster) Create(_ context.Context, object map[string]any, fieldManager string) error {
+	if name := objectName(object); c.existing[name] {
+		return &StatusError{Code: http.StatusConflict, Message: name + " already exists"}
+	}
+	return c.write("create", object, fieldManager, false)
+}
+
+// write records object, and how it was written in writes as "<verb>[ --force] <kind>/<name>".
+func (c *fakeCluster) write(verb string, object map[string]any, fieldManager string, force bool) error {
+	if fieldManager != DefaultFieldManager {
+		return fmt.Errorf("unexpected field manager %s", fieldManager)
+	}
+	if force {
+		verb += " --force"
+	}
+	c.applied = append(c.applied, object)
+	c.writes = append(c.writes, verb+" "+objectName(object))
+	return nil
+}
+
+func objectName(object map[string]any) string {
+	metadata, _ := object["metadata"].(map[string]any)
+	return fmt.Sprintf("%s/%s", object["kind"], metadata["name"])
+}
+
+const (
+	testDefinition = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  schema:
+    parameters:
+      replicas: integer | default=1
+  resources:
+    - id: config
+      template:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: ${metadata.name}
+        data:
+          replicas: ${string(spec.replicas)}
+`
+	testComponent = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Component
+metadata:
+  name: app
+  namespace: team-a
+  uid: "1234"
+spec:
+  componentType: web
+  parameters:
+    replicas: 3
+`
+)
+
+func TestSync(t *testing.T) {
+	t.Parallel()
+
+	cluster := newFakeCluster(t, testDefinition, testComponent)
+	op := New(cluster, component.NewRenderer(template.NewEngine(), nil))
+
+	results, err := op.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if diff := cmp.Diff([]Result{{Component: "team-a/app", Applied: 1}}, results); diff != "" {
+		t.Errorf("Sync() results mismatch (-want +got):\n%s", diff)
+	}
+
+	want := []map[string]any{{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]any{
+			"name":      "app",
+			"namespace": "team-a",
+			"ownerReferences": []any{map[string]any{
+				"apiVersion": APIVersion,
+				"kind":       "Component",
+				"name":       "app",
+				"uid":        "1234",
+				"controller": true,
+			}},
+		},
+		"data": map[string]any{"replicas": "3"},
+	}}
+	if diff := cmp.Diff(want, cluster.applied); diff != "" {
+		t.Errorf("applied mismatch (-want +got):\n%s", diff)
+	}
+
+	results, err = op.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("second Sync() error = %v", err)
+	}
+	if diff := cmp.Diff([]Result{{Component: "team-a/app", Unchanged: true}}, results); diff != "" {
+		t.Errorf("second Sync() results mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSyncApplyPolicies(t *testing.T) {
+	t.Parallel()
+
+	definition := `
+apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  resources:
+    - id: config
+      template:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: ${metadata.name}
+    - id: crd
+      applyPolicy: replace
+      template:
+        apiVersion: apiextensions.k8s.io/v1
+        kind: CustomResourceDefinition
+        metadata:
+          name: widgets.example.com
+    - id: migrate
+      applyPolicy: skip-if-exists
+      template:
+        apiVersion: batch/v1
+        kind: Job
+        metadata:
+          name: migrate
+    - id: seed
+      applyPolicy: skip-if-exists
+      template:
+        apiVersion: batch/v1
+        kind: Job
+        metadata:
+          name: seed
+    - id: shared
+      template:
+        apiVersion: v1
+        kind: Secret
+        metadata:
+          name: shared
+          annotations:
+            platform.io/apply-policy: server-side
+`
+	cluster := newFakeCluster(t, definition, testComponent)
+	cluster.existing = map[string]bool{"Job/migrate": true}
+	op := New(cluster, component.NewRenderer(template.NewEngine(), nil))
+
+	results, err := op.Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if diff := cmp.Diff([]Result{{Component: "team-a/app", Applied: 4, Skipped: 1}}, results); diff != "" {
+		t.Errorf("Sync() results mismatch (-want +got):\n%s", diff)
+	}
+	wantWrites := []string{
+		// Cluster-scoped resources are applied first.
+		"replace CustomResourceDefinition/widgets.example.com",
+		"apply --force ConfigMap/app",
+		"create Job/seed",
+		"apply Secret/shared",
+	}
+	if diff := cmp.Diff(wantWrites, cluster.writes); diff != "" {
+		t.Errorf("writes mismatch (-want +got):\n%s", diff)
+	}
+	// Policies are not written to the cluster.
+	for _, object := range cluster.applied {
+		annotations, _ := object["metadata"].(map[string]any)["annotations"].(map[string]any)
+		for key := range annotations {
+			if strings.HasPrefix(key, "platform.io/") {
+				t.Errorf("%s has annotation %s, want none", objectName(object), key)
+			}
+		}
+	}
+}
+
+func TestSyncClusterScopedResources(t *testing.T) {
+	t.Parallel()
+
+	definition := `
+apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  resources:
+    - id: config
+      template:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: ${metadata.name}
+    - id: reader
+      template:
+        apiVersion: rbac.authorization.k8s.io/v1
+        kind: ClusterRole
+        metadata:
+          name: ${metadata.name}-reader
+`
+	cluster := newFakeCluster(t, definition, testComponent)
+	if _, err := New(cluster, component.NewRenderer(template.NewEngine(), nil)).Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	got := map[string]any{}
+	for _, object := range cluster.applied {
+		got[objectName(object)] = object["metadata"]
+	}
+	want := map[string]any{
+		// A namespaced Component cannot own a cluster-scoped resource, which keeps no namespace and
+		// is tracked with the component annotation.
+		"ClusterRole/app-reader": map[string]any{
+			"name":        "app-reader",
+			"annotations": map[string]any{pipeline.AnnotationComponent: "team-a/app"},
+		},
+		"ConfigMap/app": map[string]any{
+			"name":      "app",
+			"namespace": "team-a",
+			"ownerReferences": []any{map[string]any{
+				"apiVersion": APIVersion,
+				"kind":       "Component",
+				"name":       "app",
+				"uid":        "1234",
+				"controller": true,
+			}},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("applied metadata mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSyncEnvironments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		settings []string
+		want     []string
+	}{
+		{
+			name: "same namespace",
+			settings: []string{`
+apiVersion: openchoreo.dev/v1alpha1
+kind: EnvSettings
+metadata:
+  name: dev
+  namespace: team-a
+spec:
+  componentRef:
+    name: app
+`},
+			want: []string{"dev"},
+		},
+		{
+			name: "other namespace",
+			settings: []string{`
+apiVersion: openchoreo.dev/v1alpha1
+kind: EnvSettings
+metadata:
+  name: prod
+  namespace: environments
+spec:
+  componentRef:
+    name: app
+    namespace: team-a
+`, `
+apiVersion: openchoreo.dev/v1alpha1
+kind: EnvSettings
+metadata:
+  name: elsewhere
+  namespace: team-b
+spec:
+  componentRef:
+    name: app
+`},
+			want: []string{"prod"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cluster := newFakeCluster(t, append([]string{testDefinition, testComponent}, tt.settings...)...)
+			results, err := New(cluster, component.NewRenderer(template.NewEngine(), nil)).Sync(context.Background())
+			if err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+			var got []string
+			for _, result := range results {
+				if result.Err != nil {
+					t.Errorf("Sync() result error = %v", result.Err)
+				}
+				got = append(got, result.Environment)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("environments mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSyncMissingDefinition(t *testing.T) {
+	t.Parallel()
+
+	cluster := newFakeCluster(t, testComponent)
+	results, err := New(cluster, component.NewRenderer(template.NewEngine(), nil)).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err == nil {
+		t.Fatalf("Sync() = %+v, want one failed result", results)
+	}
+	if len(cluster.applied) != 0 {
+		t.Errorf("applied %d resources, want none", len(cluster.applied))
+	}
+}
+
+func TestSyncDecodeFailures(t *testing.T) {
+	t.Parallel()
+
+	cluster := newFakeCluster(t, testDefinition, testComponent, `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Component
+metadata:
+  name: broken
+  namespace: team-a
+spec: not-a-map
+`, `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Addon
+metadata:
+  name: sidecar
+spec: not-a-map
+`, `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Component
+metadata:
+  name: with-sidecar
+  namespace: team-a
+spec:
+  componentType: web
+  addons:
+    - name: sidecar
+      instanceId: one
+`)
+	results, err := New(cluster, component.NewRenderer(template.NewEngine(), nil)).Sync(context.Background())
+	if err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	type summary struct {
+		Component, Object string
+		Applied           int
+		Failed            bool
+	}
+	var got []summary
+	for _, result := range results {
+		got = append(got, summary{result.Component, result.Object, result.Applied, result.Err != nil})
+	}
+	want := []summary{
+		{Object: "Addon sidecar", Failed: true},
+		{Component: "team-a/app", Applied: 1},
+		{Component: "team-a/broken", Object: "Component team-a/broken", Failed: true},
+		{Component: "team-a/with-sidecar", Failed: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Sync() results mismatch (-want +got):\n%s", diff)
+	}
+	if err := results[3].Err; err == nil || !strings.Contains(err.Error(), "addon sidecar is invalid") {
+		t.Errorf("with-sidecar error = %v, want the addon decode failure", err)
+	}
+	if len(cluster.applied) != 1 {
+		t.Errorf("applied %d resources, want 1", len(cluster.applied))
+	}
+}
+
+func TestSyncPrunesDeletedComponents(t *testing.T) {
+	t.Parallel()
+
+	cluster := newFakeCluster(t, testDefinition, testComponent)
+	op := New(cluster, component.NewRenderer(template.NewEngine(), nil))
+	if _, err := op.Sync(context.Background()); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if _, ok := op.applied["team-a/app/"]; !ok {
+		t.Fatalf("applied = %v, want an entry for team-a/app", op.applied)
+	}
+
+	cluster.objects["Component"] = nil
+	if _, err := op.Sync(context.Background()); err != nil {
+		t.Fatalf("second Sync() error = %v", err)
+	}
+	if len(op.applied) != 0 {
+		t.Errorf("applied = %v, want the deleted Component forgotten", op.applied)
+	}
+}
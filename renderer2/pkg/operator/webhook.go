@@ -0,0 +1,217 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// admissionReview is the admission.k8s.io/v1 AdmissionReview, reduced to the fields the webhook
+// reads and writes.
+type admissionReview struct {
+	APIVersion string             `json:"apiVersion"`
+	Kind       string             `json:"kind"`
+	Request    *admissionRequest  `json:"request,omitempty"`
+	Response   *admissionResponse `json:"response,omitempty"`
+}
+
+type admissionRequest struct {
+	UID  string `json:"uid"`
+	Kind struct {
+		Group   string `json:"group"`
+		Version string `json:"version"`
+		Kind    string `json:"kind"`
+	} `json:"kind"`
+	Namespace string         `json:"namespace,omitempty"`
+	Operation string         `json:"operation"`
+	Object    map[string]any `json:"object,omitempty"`
+	OldObject map[string]any `json:"oldObject,omitempty"`
+}
+
+type admissionResponse struct {
+	UID     string           `json:"uid"`
+	Allowed bool             `json:"allowed"`
+	Status  *admissionStatus `json:"status,omitempty"`
+}
+
+type admissionStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Webhook returns the handler of a validating admission webhook for Components and EnvSettings.
+// It admits an object when Review accepts it and denies it with Review's error otherwise, reviewing
+// updates against the object they replace; other kinds and deletions are always admitted.
+func (o *Operator) Webhook() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var review admissionReview
+		if err := json.NewDecoder(req.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode AdmissionReview: %v", err), http.StatusBadRequest)
+			return
+		}
+		if review.Request == nil {
+			http.Error(w, "AdmissionReview has no request", http.StatusBadRequest)
+			return
+		}
+
+		response := &admissionResponse{UID: review.Request.UID, Allowed: true}
+		if err := o.admit(req.Context(), review.Request); err != nil {
+			response.Allowed = false
+			response.Status = &admissionStatus{Code: http.StatusForbidden, Message: err.Error()}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(admissionReview{
+			APIVersion: "admission.k8s.io/v1",
+			Kind:       "AdmissionReview",
+			Response:   response,
+		})
+	})
+}
+
+func (o *Operator) admit(ctx context.Context, req *admissionRequest) error {
+	if req.Operation == "DELETE" || req.Object == nil {
+		return nil
+	}
+	switch req.Kind.Kind {
+	case "Component":
+		comp, err := decodeObject(req, req.Object, parser.ParseComponent)
+		if err != nil {
+			return err
+		}
+		defaultNamespace(&comp.Metadata, req.Namespace)
+		if req.Operation != "UPDATE" || req.OldObject == nil {
+			return o.ReviewComponent(ctx, comp)
+		}
+		old, err := decodeObject(req, req.OldObject, parser.ParseComponent)
+		if err != nil {
+			return fmt.Errorf("old object: %w", err)
+		}
+		defaultNamespace(&old.Metadata, req.Namespace)
+		return o.ReviewComponentUpdate(ctx, old, comp)
+	case "EnvSettings":
+		env, err := decodeObject(req, req.Object, parser.ParseEnvSettings)
+		if err != nil {
+			return err
+		}
+		defaultNamespace(&env.Metadata, req.Namespace)
+		if req.Operation != "UPDATE" || req.OldObject == nil {
+			return o.ReviewEnvSettings(ctx, env)
+		}
+		old, err := decodeObject(req, req.OldObject, parser.ParseEnvSettings)
+		if err != nil {
+			return fmt.Errorf("old object: %w", err)
+		}
+		defaultNamespace(&old.Metadata, req.Namespace)
+		return o.ReviewEnvSettingsUpdate(ctx, old, env)
+	default:
+		return nil
+	}
+}
+
+// defaultNamespace sets the namespace of objects that omit it to the namespace of the request.
+func defaultNamespace(metadata *types.Metadata, namespace string) {
+	if metadata.Namespace == "" {
+		metadata.Namespace = namespace
+	}
+}
+
+// decodeObject parses an object of the review with parse, which takes YAML.
+func decodeObject[T any](req *admissionRequest, object map[string]any, parse func([]byte, ...parser.Option) (T, error)) (T, error) {
+	content, err := yaml.Marshal(object)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to encode %s: %w", req.Kind.Kind, err)
+	}
+	return parse(content)
+}
+
+// ReviewComponent checks that comp can be rendered as it would be after admission: its definition
+// and addons exist and decode, its spec and the spec of each addon instance match their schemas, and
+// it renders for every EnvSettings in the cluster that references it, or without EnvSettings when
+// none does. Nothing is applied.
+func (o *Operator) ReviewComponent(ctx context.Context, comp *types.Component) error {
+	return o.ReviewComponentUpdate(ctx, nil, comp)
+}
+
+// ReviewComponentUpdate is ReviewComponent for comp replacing old, which may be nil. Specs are also
+// checked against the specs old renders with in the same environments, so changes to immutable
+// fields are rejected.
+func (o *Operator) ReviewComponentUpdate(ctx context.Context, old, comp *types.Component) error {
+	in, err := o.load(ctx)
+	if err != nil {
+		return err
+	}
+	var envs []*types.EnvSettings
+	for _, env := range in.envs {
+		if references(env.value, comp) {
+			envs = append(envs, env.value)
+		}
+	}
+	if len(envs) == 0 {
+		envs = []*types.EnvSettings{{}}
+	}
+	var errs []error
+	for _, env := range envs {
+		errs = append(errs, o.review(in, old, env, comp, env))
+	}
+	return errors.Join(errs...)
+}
+
+// ReviewEnvSettings checks that the Component env references renders with env, like
+// ReviewComponent. EnvSettings whose Component does not exist yet are accepted.
+func (o *Operator) ReviewEnvSettings(ctx context.Context, env *types.EnvSettings) error {
+	return o.ReviewEnvSettingsUpdate(ctx, nil, env)
+}
+
+// ReviewEnvSettingsUpdate is ReviewEnvSettings for env replacing old, which may be nil. When old
+// referenced the same Component, its spec is also checked against the spec the Component renders
+// with in old, so overrides cannot change immutable fields.
+func (o *Operator) ReviewEnvSettingsUpdate(ctx context.Context, old, env *types.EnvSettings) error {
+	in, err := o.load(ctx)
+	if err != nil {
+		return err
+	}
+	for _, comp := range in.components {
+		if !references(env, comp.value) {
+			continue
+		}
+		var oldComp *types.Component
+		if old != nil && references(old, comp.value) {
+			oldComp = comp.value
+		}
+		return o.review(in, oldComp, old, comp.value, env)
+	}
+	return nil
+}
+
+// review validates and dry-renders comp in env, prefixing errors with the environment. When
+// oldComp is set and of the same component type, specs are validated as an update of oldComp in
+// oldEnv.
+func (o *Operator) review(in inputs, oldComp *types.Component, oldEnv *types.EnvSettings, comp *types.Component, env *types.EnvSettings) error {
+	err := func() error {
+		ctd, addons, err := in.dependencies(comp)
+		if err != nil {
+			return err
+		}
+		if oldComp != nil && oldComp.Spec.ComponentType != comp.Spec.ComponentType {
+			oldComp = nil
+		}
+		if err := o.renderer.ValidateUpdate(ctd, oldComp, oldEnv, comp, env, addons); err != nil {
+			return err
+		}
+		if _, err := o.renderer.RenderAll(ctd, comp, env, addons, nil, nil); err != nil {
+			return fmt.Errorf("failed to render: %w", err)
+		}
+		return nil
+	}()
+	if err != nil && env.Metadata.Name != "" {
+		return fmt.Errorf("environment %s: %w", env.Metadata.Name, err)
+	}
+	return err
+}
@@ -0,0 +1,222 @@
+package operator
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"gopkg.in/yaml.v3"
+)
+
+const webhookDefinition = `
+apiVersion: openchoreo.dev/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  schema:
+    parameters:
+      image: string
+      region: string | default=eu immutable=true
+    envOverrides:
+      replicas: integer | default=1
+  resources:
+    - id: config
+      template:
+        apiVersion: v1
+        kind: ConfigMap
+        metadata:
+          name: ${metadata.name}
+        data:
+          share: ${string(100 / spec.replicas)}
+`
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+
+	existing := `
+apiVersion: openchoreo.dev/v1alpha1
+kind: Component
+metadata:
+  name: app
+  namespace: team-a
+spec:
+  componentType: web
+  parameters:
+    image: nginx
+`
+	tests := []struct {
+		name      string
+		kind      string
+		operation string
+		object    string
+		oldObject string
+		// wantDenied is a substring of the denial message; empty when the object is admitted.
+		wantDenied string
+	}{
+		{
+			name: "valid Component",
+			kind: "Component",
+			object: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx}}
+`,
+		},
+		{
+			name: "Component violating the schema",
+			kind: "Component",
+			object: `
+metadata: {name: other, namespace: team-a}
+spec: {componentType: web, parameters: {image: 1}}
+`,
+			wantDenied: "spec does not match the schema of web: image: expected string, got integer",
+		},
+		{
+			name: "Component failing to render in a referencing environment",
+			kind: "Component",
+			object: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx, replicas: 0}}
+`,
+			wantDenied: "failed to render",
+		},
+		{
+			name: "Component of an unknown definition",
+			kind: "Component",
+			object: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: worker}
+`,
+			wantDenied: "component type definition worker not found",
+		},
+		{
+			name: "EnvSettings whose override fails to render",
+			kind: "EnvSettings",
+			object: `
+metadata: {name: app-prod, namespace: team-a}
+spec: {environment: prod, componentRef: {name: app}, overrides: {replicas: 0}}
+`,
+			wantDenied: "environment app-prod: failed to render",
+		},
+		{
+			name: "EnvSettings of a Component that does not exist yet",
+			kind: "EnvSettings",
+			object: `
+metadata: {name: later-dev, namespace: team-a}
+spec: {environment: dev, componentRef: {name: later}, overrides: {replicas: 0}}
+`,
+		},
+		{
+			name:      "Component update keeping its immutable fields",
+			kind:      "Component",
+			operation: "UPDATE",
+			oldObject: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx}}
+`,
+			object: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx:2, region: eu}}
+`,
+		},
+		{
+			name:      "Component update changing an immutable field",
+			kind:      "Component",
+			operation: "UPDATE",
+			oldObject: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx}}
+`,
+			object: `
+metadata: {name: app, namespace: team-a}
+spec: {componentType: web, parameters: {image: nginx, region: us}}
+`,
+			wantDenied: "environment app-dev: spec does not match the schema of web: region: field is immutable",
+		},
+		{
+			name:      "deletion",
+			kind:      "Component",
+			operation: "DELETE",
+		},
+	}
+
+	cluster := newFakeCluster(t, webhookDefinition, existing, `
+apiVersion: openchoreo.dev/v1alpha1
+kind: EnvSettings
+metadata: {name: app-dev, namespace: team-a}
+spec: {environment: dev, componentRef: {name: app}}
+`)
+	server := httptest.NewServer(New(cluster, component.NewRenderer(template.NewEngine(), nil)).Webhook())
+	defer server.Close()
+
+	for _, tt := range tests {
+		request := map[string]any{
+			"uid":       "uid-" + tt.name,
+			"kind":      map[string]any{"group": "openchoreo.dev", "version": "v1alpha1", "kind": tt.kind},
+			"namespace": "team-a",
+			"operation": "CREATE",
+		}
+		if tt.operation != "" {
+			request["operation"] = tt.operation
+		}
+		if tt.object != "" {
+			var object map[string]any
+			if err := yaml.Unmarshal([]byte(tt.object), &object); err != nil {
+				t.Fatal(err)
+			}
+			request["object"] = object
+		}
+		if tt.oldObject != "" {
+			var object map[string]any
+			if err := yaml.Unmarshal([]byte(tt.oldObject), &object); err != nil {
+				t.Fatal(err)
+			}
+			request["oldObject"] = object
+		}
+		body, err := json.Marshal(map[string]any{"apiVersion": "admission.k8s.io/v1", "kind": "AdmissionReview", "request": request})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		resp, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: POST error = %v", tt.name, err)
+		}
+		var review admissionReview
+		err = json.NewDecoder(resp.Body).Decode(&review)
+		resp.Body.Close()
+		if err != nil || review.Response == nil {
+			t.Fatalf("%s: response %v, error = %v", tt.name, review, err)
+		}
+		if review.Response.UID != "uid-"+tt.name {
+			t.Errorf("%s: response uid = %q", tt.name, review.Response.UID)
+		}
+		if tt.wantDenied == "" {
+			if !review.Response.Allowed {
+				t.Errorf("%s: denied with %+v, want admitted", tt.name, review.Response.Status)
+			}
+			continue
+		}
+		if review.Response.Allowed || review.Response.Status == nil || !strings.Contains(review.Response.Status.Message, tt.wantDenied) {
+			t.Errorf("%s: response %+v, want denied with %q", tt.name, review.Response, tt.wantDenied)
+		}
+	}
+}
+
+func TestWebhookRejectsMalformedReviews(t *testing.T) {
+	t.Parallel()
+
+	handler := New(newFakeCluster(t), component.NewRenderer(template.NewEngine(), nil)).Webhook()
+	for _, body := range []string{"not json", `{"kind": "AdmissionReview"}`} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("body %q: status = %d, want %d", body, recorder.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -6,40 +6,73 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/chathurangada/cel_playground/renderer2/pkg/patch"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
-	"gopkg.in/yaml.v3"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
 )
 
 // LoadAddons loads addon definitions from the provided directory. If names are supplied,
 // the returned map only includes those addons; otherwise, all discovered addons are returned.
-func LoadAddons(dir string, names []string) (map[string]*types.Addon, error) {
-	discovered, err := loadAllAddons(dir)
+// When the directory holds several releases of an addon, the highest spec.version is returned.
+func LoadAddons(dir string, names []string, opts ...Option) (map[string]*types.Addon, error) {
+	instances := make([]types.AddonInstance, len(names))
+	for i, name := range names {
+		instances[i] = types.AddonInstance{Name: name}
+	}
+	return loadAddons(dir, instances, len(names) == 0, opts)
+}
+
+// LoadAddonsFor loads the addons instances reference from the provided directory, resolving each
+// instance's version pin to the highest matching release. Instances of one addon must resolve to
+// the same release, as the returned map holds one addon per name.
+func LoadAddonsFor(dir string, instances []types.AddonInstance, opts ...Option) (map[string]*types.Addon, error) {
+	return loadAddons(dir, instances, false, opts)
+}
+
+func loadAddons(dir string, instances []types.AddonInstance, all bool, opts []Option) (map[string]*types.Addon, error) {
+	discovered, err := loadAllAddons(dir, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(names) == 0 {
-		return discovered, nil
+	if all {
+		for name := range discovered {
+			instances = append(instances, types.AddonInstance{Name: name})
+		}
 	}
-
-	result := make(map[string]*types.Addon, len(names))
-	for _, name := range names {
-		addon, ok := discovered[name]
+	result := make(map[string]*types.Addon, len(instances))
+	for _, instance := range instances {
+		releases, ok := discovered[instance.Name]
 		if !ok {
-			return nil, fmt.Errorf("addon %s not found in %s", name, dir)
+			return nil, fmt.Errorf("addon %s not found in %s", instance.Name, dir)
+		}
+		versions := make([]string, len(releases))
+		for i, addon := range releases {
+			versions[i] = addon.Spec.Version
 		}
-		result[name] = addon
+		i, err := versioning.SelectRelease("addon "+instance.Name, instance.Version, versions)
+		if err != nil {
+			return nil, err
+		}
+		addon := releases[i]
+		if other, ok := result[instance.Name]; ok && other != addon {
+			return nil, fmt.Errorf("instances of addon %s resolve to releases %s and %s; a component renders one release of an addon",
+				instance.Name, other.Spec.Version, addon.Spec.Version)
+		}
+		result[instance.Name] = addon
 	}
 	return result, nil
 }
 
-func loadAllAddons(dir string) (map[string]*types.Addon, error) {
+// loadAllAddons reads every addon in dir, grouped by name into their releases.
+func loadAllAddons(dir string, opts []Option) (map[string][]*types.Addon, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read addon directory %s: %w", dir, err)
 	}
 
-	addons := make(map[string]*types.Addon)
+	addons := make(map[string][]*types.Addon)
+	sources := make(map[*types.Addon]string)
 	for _, entry := range entries {
 		if entry.IsDir() {
 			continue
@@ -54,18 +87,54 @@ func loadAllAddons(dir string) (map[string]*types.Addon, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to read addon file %s: %w", path, err)
 		}
-
-		var addon types.Addon
-		if err := yaml.Unmarshal(content, &addon); err != nil {
-			return nil, fmt.Errorf("failed to parse addon file %s: %w", path, err)
+		addon, err := ParseAddon(path, content, opts...)
+		if err != nil {
+			return nil, err
 		}
 
-		if addon.Metadata.Name == "" {
-			return nil, fmt.Errorf("addon file %s missing metadata.name", path)
+		for _, other := range addons[addon.Metadata.Name] {
+			if other.Spec.Version == addon.Spec.Version {
+				return nil, fmt.Errorf("addon %s is declared in both %s and %s; give each release its own spec.version", addon.Metadata.Name, sources[other], path)
+			}
 		}
-
-		addons[addon.Metadata.Name] = &addon
+		addons[addon.Metadata.Name] = append(addons[addon.Metadata.Name], addon)
+		sources[addon] = path
 	}
 
 	return addons, nil
 }
+
+// ParseAddon decodes an addon read from source, a file path or another name used in errors and
+// deprecation warnings, folding constant expressions and expanding patch anchors.
+func ParseAddon(source string, content []byte, opts ...Option) (*types.Addon, error) {
+	content, err := modernize(source, content)
+	if err != nil {
+		return nil, err
+	}
+
+	var addon types.Addon
+	if err := newOptions(opts).codec.Unmarshal(content, &addon); err != nil {
+		return nil, fmt.Errorf("failed to parse addon file %s: %w", source, err)
+	}
+
+	if addon.Metadata.Name == "" {
+		return nil, fmt.Errorf("addon file %s missing metadata.name", source)
+	}
+	if err := versioning.CheckRelease("addon file "+source, addon.Spec.Version); err != nil {
+		return nil, err
+	}
+
+	if err := foldAddonConstants(&addon); err != nil {
+		return nil, fmt.Errorf("failed to fold constant expressions in addon file %s: %w", source, err)
+	}
+
+	for i := range addon.Spec.Patches {
+		resolved, err := patch.ResolveAnchors(addon.Spec.Patches[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid patch %d in addon file %s: %w", i, source, err)
+		}
+		addon.Spec.Patches[i] = resolved
+	}
+
+	return &addon, nil
+}
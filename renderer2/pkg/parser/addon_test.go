@@ -0,0 +1,93 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+func addonRelease(name, version string) string {
+	return "apiVersion: platform/v1alpha1\nkind: Addon\nmetadata:\n  name: " + name + "\nspec:\n  version: " + version + "\n"
+}
+
+func TestLoadAddonsFor(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pvc-1.yaml"), addonRelease("pvc", "1.3.0"))
+	writeFile(t, filepath.Join(dir, "pvc-2.yaml"), addonRelease("pvc", "2.1.0"))
+	writeFile(t, filepath.Join(dir, "sidecar.yaml"), "metadata:\n  name: sidecar\n")
+
+	addons, err := LoadAddons(dir, nil)
+	if err != nil {
+		t.Fatalf("LoadAddons() error = %v", err)
+	}
+	if got := addons["pvc"].Spec.Version; got != "2.1.0" || addons["sidecar"] == nil {
+		t.Errorf("LoadAddons() = pvc %s and sidecar %v, want pvc 2.1.0 and sidecar", got, addons["sidecar"])
+	}
+
+	tests := []struct {
+		name      string
+		instances []types.AddonInstance
+		want      string
+		wantErr   string
+	}{
+		{
+			name:      "pinned range",
+			instances: []types.AddonInstance{{Name: "pvc", InstanceID: "data", Version: "<2.0.0"}, {Name: "sidecar", InstanceID: "logs"}},
+			want:      "1.3.0",
+		},
+		{
+			name:      "instances agree",
+			instances: []types.AddonInstance{{Name: "pvc", InstanceID: "data"}, {Name: "pvc", InstanceID: "cache", Version: ">=2.0.0"}},
+			want:      "2.1.0",
+		},
+		{
+			name:      "no match",
+			instances: []types.AddonInstance{{Name: "pvc", InstanceID: "data", Version: "3.0.0"}},
+			wantErr:   "addon pvc has no release matching 3.0.0 (available: 1.3.0, 2.1.0)",
+		},
+		{
+			name:      "unversioned addon pinned",
+			instances: []types.AddonInstance{{Name: "sidecar", InstanceID: "logs", Version: "1.0.0"}},
+			wantErr:   "addon sidecar has no release matching 1.0.0 (available: unversioned)",
+		},
+		{
+			name:      "instances disagree",
+			instances: []types.AddonInstance{{Name: "pvc", InstanceID: "data", Version: "1.3.0"}, {Name: "pvc", InstanceID: "cache"}},
+			wantErr:   "instances of addon pvc resolve to releases 1.3.0 and 2.1.0",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			addons, err := LoadAddonsFor(dir, tt.instances)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("LoadAddonsFor() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadAddonsFor() error = %v", err)
+			}
+			if got := addons["pvc"].Spec.Version; got != tt.want {
+				t.Errorf("LoadAddonsFor() pvc = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadAddonsRejectsDuplicateReleases(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), addonRelease("pvc", "1.3.0"))
+	writeFile(t, filepath.Join(dir, "b.yaml"), addonRelease("pvc", "1.3.0"))
+	if _, err := LoadAddons(dir, nil); err == nil || !strings.Contains(err.Error(), "addon pvc is declared in both") {
+		t.Errorf("LoadAddons() error = %v", err)
+	}
+}
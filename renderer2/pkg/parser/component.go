@@ -5,33 +5,83 @@ import (
 	"os"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
-	"gopkg.in/yaml.v3"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
 )
 
-// LoadComponentTypeDefinition reads a ComponentTypeDefinition YAML file.
-func LoadComponentTypeDefinition(path string) (*types.ComponentTypeDefinition, error) {
+// LoadComponentTypeDefinition reads a file holding one ComponentTypeDefinition. Use
+// LoadDefinitions for files, directories or globs holding several.
+func LoadComponentTypeDefinition(path string, opts ...Option) (*types.ComponentTypeDefinition, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read component type definition: %w", err)
 	}
+	return ParseComponentTypeDefinition(path, content, opts...)
+}
 
-	var ctd types.ComponentTypeDefinition
-	if err := yaml.Unmarshal(content, &ctd); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal component type definition: %w", err)
+// ParseComponentTypeDefinition decodes the one ComponentTypeDefinition read from source, a file
+// path or another name used in deprecation warnings.
+func ParseComponentTypeDefinition(source string, content []byte, opts ...Option) (*types.ComponentTypeDefinition, error) {
+	ctds, err := ParseComponentTypeDefinitions(source, content, opts...)
+	if err != nil {
+		return nil, err
+	}
+	switch len(ctds) {
+	case 0:
+		return nil, fmt.Errorf("%s holds no component type definition", source)
+	case 1:
+		return ctds[0], nil
+	default:
+		return nil, fmt.Errorf("%s holds %d component type definitions, expected one", source, len(ctds))
 	}
+}
 
-	return &ctd, nil
+// ParseComponentTypeDefinitions decodes every ComponentTypeDefinition of a multi-document YAML
+// stream read from source. Documents of other kinds, such as the Components of a definition kept
+// in the same file, and empty documents are skipped; documents without a kind are definitions.
+func ParseComponentTypeDefinitions(source string, content []byte, opts ...Option) ([]*types.ComponentTypeDefinition, error) {
+	content, err := modernize(source, content)
+	if err != nil {
+		return nil, err
+	}
+
+	codec := newOptions(opts).codec
+	docs, err := codec.Documents(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal component type definition: %w", err)
+	}
+	var ctds []*types.ComponentTypeDefinition
+	for i, doc := range docs {
+		var ctd types.ComponentTypeDefinition
+		if err := codec.Unmarshal(doc, &ctd); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal component type definition (document %d): %w", i+1, err)
+		}
+		if ctd.Kind != "" && ctd.Kind != "ComponentTypeDefinition" {
+			continue
+		}
+		if err := versioning.CheckRelease("component type definition "+ctd.Metadata.Name, ctd.Spec.Version); err != nil {
+			return nil, err
+		}
+		if err := foldDefinitionConstants(&ctd); err != nil {
+			return nil, fmt.Errorf("failed to fold constant expressions: %w", err)
+		}
+		ctds = append(ctds, &ctd)
+	}
+	return ctds, nil
 }
 
 // LoadComponent reads a Component YAML file.
-func LoadComponent(path string) (*types.Component, error) {
+func LoadComponent(path string, opts ...Option) (*types.Component, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read component: %w", err)
 	}
+	return ParseComponent(content, opts...)
+}
 
+// ParseComponent decodes a Component.
+func ParseComponent(content []byte, opts ...Option) (*types.Component, error) {
 	var component types.Component
-	if err := yaml.Unmarshal(content, &component); err != nil {
+	if err := newOptions(opts).codec.Unmarshal(content, &component); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal component: %w", err)
 	}
 
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+)
+
+// Definitions holds ComponentTypeDefinitions keyed by metadata.name and version, so Components can
+// be resolved by their componentType and componentTypeVersion instead of a path to one file.
+//
+// A definition is keyed by its release, spec.version, and by each of its spec.versions, or by the
+// empty version when it declares none. Definitions of distinct releases never conflict. Within a
+// release, several definitions may share a name as long as they declare distinct versions and at
+// most one of them marks a storage version. Versions that convert into each other must be declared
+// by one definition: conversions never cross definitions.
+type Definitions struct {
+	byName  map[string][]*types.ComponentTypeDefinition
+	sources map[*types.ComponentTypeDefinition]string
+}
+
+// NewDefinitions returns an empty set of definitions.
+func NewDefinitions() *Definitions {
+	return &Definitions{
+		byName:  map[string][]*types.ComponentTypeDefinition{},
+		sources: map[*types.ComponentTypeDefinition]string{},
+	}
+}
+
+// LoadDefinitions loads every ComponentTypeDefinition of paths. A path is a YAML file, which may
+// hold several documents, a directory, whose .yaml and .yml files are read, or a glob such as
+// definitions/*.yaml.
+func LoadDefinitions(paths []string, opts ...Option) (*Definitions, error) {
+	defs := NewDefinitions()
+	for _, path := range paths {
+		files, err := definitionFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read component type definition: %w", err)
+			}
+			ctds, err := ParseComponentTypeDefinitions(file, content, opts...)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			for _, ctd := range ctds {
+				if err := defs.Add(file, ctd); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return defs, nil
+}
+
+// definitionFiles expands a file, directory or glob into the files it names, in lexical order.
+func definitionFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	switch {
+	case err == nil && !info.IsDir():
+		return []string{path}, nil
+	case err == nil:
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read definition directory %s: %w", path, err)
+		}
+		var files []string
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+				files = append(files, filepath.Join(path, name))
+			}
+		}
+		return files, nil
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read component type definition: %w", err)
+	}
+
+	files, globErr := filepath.Glob(path)
+	if globErr != nil {
+		return nil, fmt.Errorf("invalid definition pattern %s: %w", path, globErr)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("failed to read component type definition: %w", err)
+	}
+	return files, nil
+}
+
+// Add registers ctd, read from source, under its name and versions.
+func (d *Definitions) Add(source string, ctd *types.ComponentTypeDefinition) error {
+	name := ctd.Metadata.Name
+	if name == "" {
+		return fmt.Errorf("component type definition in %s missing metadata.name", source)
+	}
+	for _, other := range d.byName[name] {
+		if !sameRelease(ctd, other) {
+			continue
+		}
+		if len(ctd.Spec.Versions) == 0 || len(other.Spec.Versions) == 0 {
+			return fmt.Errorf("definition %s is declared in both %s and %s", name, d.sources[other], source)
+		}
+		for _, version := range ctd.Spec.Versions {
+			if declares(other, version.Name) {
+				return fmt.Errorf("version %s of definition %s is declared in both %s and %s", version.Name, name, d.sources[other], source)
+			}
+		}
+		if storageVersion(ctd) != "" && storageVersion(other) != "" {
+			return fmt.Errorf("definition %s has storage versions in both %s and %s", name, d.sources[other], source)
+		}
+	}
+	d.byName[name] = append(d.byName[name], ctd)
+	d.sources[ctd] = source
+	return nil
+}
+
+// Lookup returns the definition named name that declares version, of the highest release that
+// satisfies the release pin. An empty version selects the unversioned definition or the one holding
+// the storage version, as for Components that do not pin a version; an empty release accepts every
+// release.
+func (d *Definitions) Lookup(name, release, version string) (*types.ComponentTypeDefinition, error) {
+	candidates, ok := d.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("component type definition %s not found", name)
+	}
+	var matches []*types.ComponentTypeDefinition
+	var releases []string
+	for _, ctd := range candidates {
+		if (version == "" && (len(ctd.Spec.Versions) == 0 || storageVersion(ctd) != "")) || (version != "" && declares(ctd, version)) {
+			matches = append(matches, ctd)
+			releases = append(releases, ctd.Spec.Version)
+		}
+	}
+	switch {
+	case len(matches) == 0 && version == "":
+		return nil, fmt.Errorf("definition %s declares versions but none is marked storage", name)
+	case len(matches) == 0:
+		return nil, fmt.Errorf("definition %s has no version %s", name, version)
+	}
+	i, err := versioning.SelectRelease("definition "+name, release, releases)
+	if err != nil {
+		return nil, err
+	}
+	return matches[i], nil
+}
+
+// ForComponent returns the definition component's componentType, componentTypeRelease and
+// componentTypeVersion name.
+func (d *Definitions) ForComponent(component *types.Component) (*types.ComponentTypeDefinition, error) {
+	if component.Spec.ComponentType == "" {
+		return nil, fmt.Errorf("component %s does not set spec.componentType", component.Metadata.Name)
+	}
+	return d.Lookup(component.Spec.ComponentType, component.Spec.ComponentTypeRelease, component.Spec.ComponentTypeVersion)
+}
+
+// All returns the definitions by name, each name's in the order they were added.
+func (d *Definitions) All() []*types.ComponentTypeDefinition {
+	names := make([]string, 0, len(d.byName))
+	for name := range d.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var all []*types.ComponentTypeDefinition
+	for _, name := range names {
+		all = append(all, d.byName[name]...)
+	}
+	return all
+}
+
+// Source returns the file ctd was loaded from.
+func (d *Definitions) Source(ctd *types.ComponentTypeDefinition) string {
+	return d.sources[ctd]
+}
+
+// definitionVersions returns the versions a definition is keyed by.
+func definitionVersions(ctd *types.ComponentTypeDefinition) []string {
+	if len(ctd.Spec.Versions) == 0 {
+		return []string{""}
+	}
+	versions := make([]string, len(ctd.Spec.Versions))
+	for i, version := range ctd.Spec.Versions {
+		versions[i] = version.Name
+	}
+	return versions
+}
+
+// sameRelease reports whether a and b are the same release, comparing spec.version semantically.
+func sameRelease(a, b *types.ComponentTypeDefinition) bool {
+	va, errA := versioning.ParseRelease(a.Spec.Version)
+	vb, errB := versioning.ParseRelease(b.Spec.Version)
+	if errA != nil || errB != nil {
+		return a.Spec.Version == b.Spec.Version
+	}
+	return va.EQ(vb) && (a.Spec.Version == "") == (b.Spec.Version == "")
+}
+
+func declares(ctd *types.ComponentTypeDefinition, version string) bool {
+	for _, declared := range definitionVersions(ctd) {
+		if declared == version {
+			return true
+		}
+	}
+	return false
+}
+
+func storageVersion(ctd *types.ComponentTypeDefinition) string {
+	for _, version := range ctd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
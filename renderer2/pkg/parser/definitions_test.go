@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+const multiDocumentDefinitions = `apiVersion: platform/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: web
+spec:
+  workloadType: deployment
+  schema:
+    parameters:
+      image: string
+---
+# The Components of a definition may live next to it.
+apiVersion: platform/v1alpha1
+kind: Component
+metadata:
+  name: frontend
+spec:
+  componentType: web
+---
+apiVersion: platform/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: worker
+spec:
+  workloadType: deployment
+  versions:
+    - name: v2
+      served: true
+      storage: true
+`
+
+const workerV1 = `apiVersion: platform/v1alpha1
+kind: ComponentTypeDefinition
+metadata:
+  name: worker
+spec:
+  workloadType: deployment
+  versions:
+    - name: v1
+      served: true
+`
+
+func TestLoadDefinitions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "definitions.yaml"), multiDocumentDefinitions)
+	writeFile(t, filepath.Join(dir, "worker-v1.yml"), workerV1)
+	writeFile(t, filepath.Join(dir, "README.md"), "not a definition")
+
+	for _, path := range []string{dir, filepath.Join(dir, "*.y*ml")} {
+		defs, err := LoadDefinitions([]string{path})
+		if err != nil {
+			t.Fatalf("LoadDefinitions(%s) error = %v", path, err)
+		}
+		if got := len(defs.All()); got != 3 {
+			t.Fatalf("LoadDefinitions(%s) loaded %d definitions, want 3", path, got)
+		}
+
+		tests := []struct {
+			componentType, version string
+			wantSource             string
+			wantErr                string
+		}{
+			{componentType: "web", wantSource: "definitions.yaml"},
+			{componentType: "worker", wantSource: "definitions.yaml"},
+			{componentType: "worker", version: "v2", wantSource: "definitions.yaml"},
+			{componentType: "worker", version: "v1", wantSource: "worker-v1.yml"},
+			{componentType: "worker", version: "v3", wantErr: "definition worker has no version v3"},
+			{componentType: "web", version: "v1", wantErr: "definition web has no version v1"},
+			{componentType: "api", wantErr: "component type definition api not found"},
+		}
+		for _, tt := range tests {
+			component := &types.Component{Spec: types.ComponentSpec{ComponentType: tt.componentType, ComponentTypeVersion: tt.version}}
+			ctd, err := defs.ForComponent(component)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("ForComponent(%s %s) error = %v, want %q", tt.componentType, tt.version, err, tt.wantErr)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("ForComponent(%s %s) error = %v", tt.componentType, tt.version, err)
+			}
+			if ctd.Metadata.Name != tt.componentType || filepath.Base(defs.Source(ctd)) != tt.wantSource {
+				t.Errorf("ForComponent(%s %s) = %s from %s, want %s", tt.componentType, tt.version, ctd.Metadata.Name, defs.Source(ctd), tt.wantSource)
+			}
+		}
+	}
+}
+
+func TestLoadDefinitionsConflicts(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		second  string
+		wantErr string
+	}{
+		{
+			name:    "unversioned twice",
+			second:  strings.Replace(multiDocumentDefinitions, "name: worker", "name: other", 1),
+			wantErr: "definition web is declared in both",
+		},
+		{
+			name:    "same version",
+			second:  strings.Replace(workerV1, "name: v1", "name: v2", 1),
+			wantErr: "version v2 of definition worker is declared in both",
+		},
+		{
+			name:    "two storage versions",
+			second:  strings.Replace(workerV1, "served: true", "served: true\n      storage: true", 1),
+			wantErr: "definition worker has storage versions in both",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			writeFile(t, filepath.Join(dir, "a.yaml"), multiDocumentDefinitions)
+			writeFile(t, filepath.Join(dir, "b.yaml"), tt.second)
+			_, err := LoadDefinitions([]string{dir})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadDefinitions() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDefinitionsReleases(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, release := range []string{"1.2.0", "1.10.0", "2.0.0"} {
+		writeFile(t, filepath.Join(dir, "worker-"+release+".yaml"),
+			strings.Replace(workerV1, "  workloadType:", "  version: "+release+"\n  workloadType:", 1))
+	}
+	defs, err := LoadDefinitions([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadDefinitions() error = %v", err)
+	}
+
+	tests := []struct {
+		release string
+		want    string
+		wantErr string
+	}{
+		{want: "2.0.0"},
+		{release: ">=1.0.0 <2.0.0", want: "1.10.0"},
+		{release: "1.2.0", want: "1.2.0"},
+		{release: ">=3.0.0", wantErr: "definition worker has no release matching >=3.0.0 (available: 1.2.0, 1.10.0, 2.0.0)"},
+	}
+	for _, tt := range tests {
+		ctd, err := defs.Lookup("worker", tt.release, "v1")
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Lookup(%q) error = %v, want %q", tt.release, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Lookup(%q) error = %v", tt.release, err)
+		}
+		if ctd.Spec.Version != tt.want {
+			t.Errorf("Lookup(%q) = %s, want %s", tt.release, ctd.Spec.Version, tt.want)
+		}
+	}
+
+	// Releases are checked when definitions are parsed.
+	_, err = ParseComponentTypeDefinition("worker.yaml", []byte(strings.Replace(workerV1, "  workloadType:", "  version: next\n  workloadType:", 1)))
+	if err == nil || !strings.HasPrefix(err.Error(), `component type definition worker has invalid spec.version "next"`) {
+		t.Errorf("ParseComponentTypeDefinition() error = %v", err)
+	}
+}
+
+func TestParseComponentTypeDefinitionWantsOne(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ParseComponentTypeDefinition("definitions.yaml", []byte(multiDocumentDefinitions)); err == nil ||
+		err.Error() != "definitions.yaml holds 2 component type definitions, expected one" {
+		t.Errorf("ParseComponentTypeDefinition() error = %v", err)
+	}
+	ctd, err := ParseComponentTypeDefinition("worker-v1.yaml", []byte("---\n"+workerV1+"---\n"))
+	if err != nil {
+		t.Fatalf("ParseComponentTypeDefinition() error = %v", err)
+	}
+	if ctd.Metadata.Name != "worker" {
+		t.Errorf("ParseComponentTypeDefinition() = %s, want worker", ctd.Metadata.Name)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,25 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/legacy"
+)
+
+// OnDeprecation, when set, receives every renderer v1 construct found while loading a definition or
+// addon file. Loaders rewrite fixable constructs in memory before decoding, so v1 files keep
+// rendering; `fix` rewrites them on disk.
+var OnDeprecation func(path string, deprecation legacy.Deprecation)
+
+func modernize(path string, content []byte) ([]byte, error) {
+	fixed, deprecations, err := legacy.Fix(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check %s for legacy syntax: %w", path, err)
+	}
+	if OnDeprecation != nil {
+		for _, deprecation := range deprecations {
+			OnDeprecation(path, deprecation)
+		}
+	}
+	return fixed, nil
+}
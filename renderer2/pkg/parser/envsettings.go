@@ -5,18 +5,21 @@ import (
 	"os"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
-	"gopkg.in/yaml.v3"
 )
 
 // LoadEnvSettings reads EnvSettings from YAML.
-func LoadEnvSettings(path string) (*types.EnvSettings, error) {
+func LoadEnvSettings(path string, opts ...Option) (*types.EnvSettings, error) {
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read env settings: %w", err)
 	}
+	return ParseEnvSettings(content, opts...)
+}
 
+// ParseEnvSettings decodes EnvSettings.
+func ParseEnvSettings(content []byte, opts ...Option) (*types.EnvSettings, error) {
 	var env types.EnvSettings
-	if err := yaml.Unmarshal(content, &env); err != nil {
+	if err := newOptions(opts).codec.Unmarshal(content, &env); err != nil {
 		return nil, fmt.Errorf("failed to parse env settings: %w", err)
 	}
 
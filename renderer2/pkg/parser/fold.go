@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// foldDefinitionConstants pre-evaluates variable-free expressions in every resource template and
+// fragment.
+func foldDefinitionConstants(ctd *types.ComponentTypeDefinition) error {
+	if err := foldResourceTemplates(ctd.Spec.Resources); err != nil {
+		return err
+	}
+	if ctd.Spec.Fragments != nil {
+		folded, err := template.FoldConstants(ctd.Spec.Fragments)
+		if err != nil {
+			return fmt.Errorf("fragments: %w", err)
+		}
+		ctd.Spec.Fragments = folded.(map[string]any)
+	}
+	for i := range ctd.Spec.Versions {
+		if err := foldResourceTemplates(ctd.Spec.Versions[i].Resources); err != nil {
+			return fmt.Errorf("version %s: %w", ctd.Spec.Versions[i].Name, err)
+		}
+	}
+	return nil
+}
+
+func foldResourceTemplates(resources []types.ResourceTemplate) error {
+	for i := range resources {
+		folded, err := template.FoldConstants(resources[i].Template)
+		if err != nil {
+			return fmt.Errorf("resource %s: %w", resources[i].ID, err)
+		}
+		resources[i].Template = folded.(map[string]any)
+	}
+	return nil
+}
+
+// foldAddonConstants pre-evaluates variable-free expressions in addon creates and patch values.
+func foldAddonConstants(addon *types.Addon) error {
+	for i := range addon.Spec.Creates {
+		folded, err := template.FoldConstants(addon.Spec.Creates[i].Template)
+		if err != nil {
+			return fmt.Errorf("create %d: %w", i, err)
+		}
+		addon.Spec.Creates[i].Template = folded
+	}
+	for i := range addon.Spec.Patches {
+		for j := range addon.Spec.Patches[i].Operations {
+			op := &addon.Spec.Patches[i].Operations[j]
+			folded, err := template.FoldConstants(op.Value)
+			if err != nil {
+				return fmt.Errorf("patch %d operation %d: %w", i, j, err)
+			}
+			op.Value = folded
+		}
+	}
+	return nil
+}
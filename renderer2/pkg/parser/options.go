@@ -0,0 +1,25 @@
+package parser
+
+import "github.com/chathurangada/cel_playground/renderer2/pkg/codec"
+
+// Option configures how a parser function decodes its input.
+type Option func(*options)
+
+type options struct {
+	codec codec.Codec
+}
+
+// WithCodec decodes with c instead of codec.Default, e.g. codec.YAMLv3 to read YAML 1.2 scalars
+// such as yes and on as strings.
+func WithCodec(c codec.Codec) Option {
+	return func(o *options) { o.codec = c }
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	o.codec = codec.Or(o.codec)
+	return o
+}
@@ -3,8 +3,10 @@ package parser
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
@@ -33,62 +35,85 @@ func GenerateAddonJSONSchema(addon *types.Addon) (*extv1.JSONSchemaProps, error)
 	})
 }
 
-// ValidateSchemas generates JSON Schemas for the component definition and addons and writes them to disk.
-func ValidateSchemas(ctd *types.ComponentTypeDefinition, addons map[string]*types.Addon, outputDir string) error {
-	fmt.Println("\n=== Generating JSON Schemas ===")
+// SchemaOutput receives what ValidateSchemas reports. Nil writers discard.
+type SchemaOutput struct {
+	// Progress receives a line per written file.
+	Progress io.Writer
+	// Schemas receives every generated schema as indented JSON.
+	Schemas io.Writer
+}
 
-	ctdSchema, err := GenerateJSONSchema(ctd)
-	if err != nil {
-		return err
+type namedSchema struct {
+	name   string
+	schema *extv1.JSONSchemaProps
+}
+
+// ValidateSchemas generates JSON Schemas for the component definition and addons and writes them to
+// disk, addons in name order.
+func ValidateSchemas(ctd *types.ComponentTypeDefinition, addons map[string]*types.Addon, outputDir string, out SchemaOutput) error {
+	progress := out.Progress
+	if progress == nil {
+		progress = io.Discard
 	}
+	fmt.Fprintln(progress, "\n=== Generating JSON Schemas ===")
 
-	if err := printSchema(ctd.Metadata.Name, ctdSchema); err != nil {
+	ctdSchema, err := GenerateJSONSchema(ctd)
+	if err != nil {
 		return err
 	}
-	if err := WriteSchemaToFile(ctdSchema, outputDir, ctd.Metadata.Name+"-schema.json"); err != nil {
-		return err
+	schemas := []namedSchema{{ctd.Metadata.Name, ctdSchema}}
+	names := make([]string, 0, len(addons))
+	for name := range addons {
+		names = append(names, name)
 	}
-
-	for name, addon := range addons {
-		addonSchema, err := GenerateAddonJSONSchema(addon)
+	sort.Strings(names)
+	for _, name := range names {
+		addonSchema, err := GenerateAddonJSONSchema(addons[name])
 		if err != nil {
 			return err
 		}
-		if err := printSchema(name, addonSchema); err != nil {
-			return err
+		schemas = append(schemas, namedSchema{name, addonSchema})
+	}
+
+	for _, s := range schemas {
+		if out.Schemas != nil {
+			if err := PrintSchema(out.Schemas, s.name, s.schema); err != nil {
+				return err
+			}
 		}
-		if err := WriteSchemaToFile(addonSchema, outputDir, name+"-schema.json"); err != nil {
+		path, err := WriteSchemaToFile(s.schema, outputDir, s.name+"-schema.json")
+		if err != nil {
 			return err
 		}
+		fmt.Fprintf(progress, "  → Written to %s\n", path)
 	}
-
 	return nil
 }
 
-// WriteSchemaToFile saves the given schema to the provided directory.
-func WriteSchemaToFile(schema *extv1.JSONSchemaProps, outputDir, filename string) error {
+// WriteSchemaToFile saves the given schema to the provided directory and returns its path.
+func WriteSchemaToFile(schema *extv1.JSONSchemaProps, outputDir, filename string) (string, error) {
 	data, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal schema: %w", err)
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
 	}
 
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return fmt.Errorf("failed to create schema directory: %w", err)
+		return "", fmt.Errorf("failed to create schema directory: %w", err)
 	}
 
 	path := filepath.Join(outputDir, filename)
 	if err := os.WriteFile(path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write schema file: %w", err)
+		return "", fmt.Errorf("failed to write schema file: %w", err)
 	}
-	fmt.Printf("  → Written to %s\n", path)
-	return nil
+	return path, nil
 }
 
-func printSchema(name string, schema *extv1.JSONSchemaProps) error {
+// PrintSchema writes schema to w as indented JSON, introduced by its name.
+func PrintSchema(w io.Writer, name string, schema *extv1.JSONSchemaProps) error {
 	data, err := json.MarshalIndent(schema, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal schema: %w", err)
 	}
-	fmt.Printf("%s JSON Schema:\n%s\n\n", name, string(data))
-	return nil
+	_, err = fmt.Fprintf(w, "%s JSON Schema:\n%s\n\n", name, string(data))
+	return err
 }
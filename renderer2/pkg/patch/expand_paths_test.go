@@ -33,6 +33,39 @@ spec:
 		path string
 		want []string
 	}{
+		{
+			name: "negative index",
+			root: baseRoot,
+			path: "/spec/template/spec/containers/[-1]/image",
+			want: []string{"/spec/template/spec/containers/1/image"},
+		},
+		{
+			name: "negative index without brackets",
+			root: baseRoot,
+			path: "/spec/template/spec/containers/0/env/-2",
+			want: []string{"/spec/template/spec/containers/0/env/0"},
+		},
+		{
+			name: "slice range",
+			root: baseRoot,
+			path: "/spec/template/spec/containers/[0:2]/name",
+			want: []string{
+				"/spec/template/spec/containers/0/name",
+				"/spec/template/spec/containers/1/name",
+			},
+		},
+		{
+			name: "open-ended slice clamps to the array",
+			root: baseRoot,
+			path: "/spec/template/spec/containers/0/env/[1:10]",
+			want: []string{"/spec/template/spec/containers/0/env/1"},
+		},
+		{
+			name: "negative slice start",
+			root: baseRoot,
+			path: "/spec/template/spec/containers/[-1:]/env",
+			want: []string{"/spec/template/spec/containers/1/env"},
+		},
 		{
 			name: "simple index",
 			root: baseRoot,
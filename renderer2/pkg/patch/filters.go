@@ -0,0 +1,112 @@
+package patch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// celFilterStart opens a CEL filter such as [?(${item.ports.size() > 0})].
+const celFilterStart = "[?(${"
+
+// celFilterRef replaces a CEL filter in a path while the rest of the path is rendered; the filter
+// itself is evaluated per array element during expansion.
+const celFilterRef = "@cel:"
+
+// celFilters evaluates the CEL filters extracted from a path. Each array element is bound to
+// item on top of the operation's inputs.
+type celFilters struct {
+	exprs  []string
+	inputs map[string]any
+	render func(any, map[string]any) (any, error)
+}
+
+// extractCELFilters replaces every [?(${...})] in path by [?(@cel:N)] and returns the expressions,
+// so rendering the path does not evaluate them without an item.
+func extractCELFilters(path string) (string, []string, error) {
+	var exprs []string
+	var b strings.Builder
+	for {
+		start := strings.Index(path, celFilterStart)
+		if start < 0 {
+			b.WriteString(path)
+			return b.String(), exprs, nil
+		}
+		body := path[start+len(celFilterStart):]
+		end, err := expressionEnd(body)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid filter in path %q: %w", path, err)
+		}
+		if !strings.HasPrefix(body[end+1:], ")]") {
+			return "", nil, fmt.Errorf("invalid filter in path %q: expected )] after the expression", path)
+		}
+		b.WriteString(path[:start])
+		fmt.Fprintf(&b, "[?(%s%d)]", celFilterRef, len(exprs))
+		exprs = append(exprs, body[:end])
+		path = body[end+len("})]"):]
+	}
+}
+
+// expressionEnd returns the index of the brace closing an expression whose opening "${" precedes
+// s, skipping braces inside string literals.
+func expressionEnd(s string) (int, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		}
+	}
+	return 0, fmt.Errorf("unterminated expression")
+}
+
+// match reports whether the filter referenced by ref selects item. Missing keys and fields count
+// as no match, as they do for includeWhen.
+func (f *celFilters) match(ref string, item any) (bool, error) {
+	index, err := strconv.Atoi(strings.TrimPrefix(ref, celFilterRef))
+	if f == nil || err != nil || index < 0 || index >= len(f.exprs) {
+		return false, fmt.Errorf("unsupported filter expression: %s", ref)
+	}
+	expr := f.exprs[index]
+
+	scope := make(map[string]any, len(f.inputs)+1)
+	for key, value := range f.inputs {
+		scope[key] = value
+	}
+	scope["item"] = item
+	result, err := f.render("${"+expr+"}", scope)
+	if err != nil {
+		if isMissingDataError(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to evaluate filter ${%s}: %w", expr, err)
+	}
+	match, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("filter ${%s} must evaluate to bool, got %T", expr, result)
+	}
+	return match, nil
+}
+
+// isMissingDataError mirrors the pipeline's check for expressions reading absent data.
+func isMissingDataError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "no such key") ||
+		strings.Contains(msg, "no such field") ||
+		strings.Contains(msg, "undefined variable")
+}
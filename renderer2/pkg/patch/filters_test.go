@@ -0,0 +1,116 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"sigs.k8s.io/yaml"
+)
+
+func TestExtractCELFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		path      string
+		wantPath  string
+		wantExprs []string
+		wantErr   bool
+	}{
+		{name: "no filters", path: "/spec/containers/[?(@.name=='app')]/image", wantPath: "/spec/containers/[?(@.name=='app')]/image"},
+		{
+			name:      "slashes, brackets and braces inside the expression",
+			path:      "/spec/containers/[?(${item.ports.exists(p, p.containerPort / 2 > 40) && {'a': 1}['a'] == 1})]/env/[?(${item.name == '}'})]/value",
+			wantPath:  "/spec/containers/[?(@cel:0)]/env/[?(@cel:1)]/value",
+			wantExprs: []string{"item.ports.exists(p, p.containerPort / 2 > 40) && {'a': 1}['a'] == 1", "item.name == '}'"},
+		},
+		{name: "unterminated", path: "/spec/containers/[?(${item.name == 'app')]", wantErr: true},
+		{name: "missing closing bracket", path: "/spec/containers/[?(${item.ready}/image", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			gotPath, gotExprs, err := extractCELFilters(tt.path)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("extractCELFilters(%q) succeeded, want error", tt.path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractCELFilters(%q) error = %v", tt.path, err)
+			}
+			if gotPath != tt.wantPath {
+				t.Errorf("path = %q, want %q", gotPath, tt.wantPath)
+			}
+			if diff := cmp.Diff(tt.wantExprs, gotExprs); diff != "" {
+				t.Errorf("expressions mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyOperationCELFilter(t *testing.T) {
+	t.Parallel()
+
+	engine := template.NewEngine()
+	initial := `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          resources:
+            limits:
+              cpu: 2
+        - name: sidecar
+          resources:
+            limits:
+              cpu: 0.5
+        - name: logger
+`
+	var resource map[string]any
+	if err := yaml.Unmarshal([]byte(initial), &resource); err != nil {
+		t.Fatalf("failed to unmarshal initial YAML: %v", err)
+	}
+
+	op := types.JSONPatchOperation{
+		Op:    "add",
+		Path:  "/spec/template/spec/containers/[?(${has(item.resources) && double(item.resources.limits.cpu) > spec.threshold})]/env",
+		Value: []any{map[string]any{"name": "GOMAXPROCS", "value": "${spec.procs}"}},
+	}
+	inputs := map[string]any{"spec": map[string]any{"threshold": 1.0, "procs": "2"}}
+	if err := ApplyOperation(resource, op, inputs, engine.Render); err != nil {
+		t.Fatalf("ApplyOperation() error = %v", err)
+	}
+
+	var want map[string]any
+	if err := yaml.Unmarshal([]byte(`
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          resources:
+            limits:
+              cpu: 2
+          env:
+            - name: GOMAXPROCS
+              value: "2"
+        - name: sidecar
+          resources:
+            limits:
+              cpu: 0.5
+        - name: logger
+`), &want); err != nil {
+		t.Fatalf("failed to unmarshal expected YAML: %v", err)
+	}
+	if diff := cmpDiff(want, resource); diff != "" {
+		t.Errorf("resource mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -1,60 +1,206 @@
 package patch
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
 
-	jsonpatch "github.com/evanphx/json-patch/v5"
-
+	"github.com/chathurangada/cel_playground/renderer2/pkg/jsonpointer"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
 )
 
 var filterExpr = regexp.MustCompile(`^@\.([A-Za-z0-9_.-]+)\s*==\s*['"](.*)['"]$`)
 
+// Values of JSONPatchOperation.IfMissing, governing array indices that are out of bounds and
+// paths whose parent does not exist.
+const (
+	// IfMissingError fails the patch (the default).
+	IfMissingError = "error"
+	// IfMissingSkip leaves the resource untouched for the missing location.
+	IfMissingSkip = "skip"
+	// IfMissingAppend turns an out-of-bounds final index into an append; other misses are skipped.
+	IfMissingAppend = "append"
+)
+
 // ApplyPatch applies a single patch operation against a target resource.
 func ApplyOperation(target map[string]any, operation types.JSONPatchOperation, inputs map[string]any, render func(any, map[string]any) (any, error)) error {
-	pathValue, err := render(operation.Path, inputs)
+	missing, err := missingPolicy(operation.IfMissing)
 	if err != nil {
-		return fmt.Errorf("failed to evaluate patch path: %w", err)
+		return err
 	}
-
-	pathStr, ok := pathValue.(string)
-	if !ok {
-		return fmt.Errorf("patch path must evaluate to a string, got %T", pathValue)
+	resolved, err := operationPointers(target, operation, missing, inputs, render)
+	if err != nil {
+		return err
 	}
 
+	op := strings.ToLower(operation.Op)
 	var value any
-	if operation.Op != "remove" {
+	switch op {
+	case "remove", "move", "copy":
+	default:
 		value, err = render(operation.Value, inputs)
 		if err != nil {
 			return fmt.Errorf("failed to evaluate patch value: %w", err)
 		}
 	}
 
-	op := strings.ToLower(operation.Op)
+	if missing == IfMissingAppend {
+		// Appended positions hold no element yet: writes become adds, reads and removals are skipped.
+		var appends []string
+		resolved, appends = splitAppends(resolved)
+		switch op {
+		case "add", "replace", "upsert", "merge":
+			if err := applyRFC6902(target, "add", appends, value); err != nil {
+				return err
+			}
+		}
+	}
+
 	switch op {
-	case "add", "replace", "remove", "test", "move", "copy":
-		return applyRFC6902(target, op, pathStr, value)
+	case "add", "replace", "remove", "test":
+		if missing != IfMissingError && op != "add" {
+			resolved = existingParents(target, resolved)
+		}
+		return applyRFC6902(target, op, resolved, value)
+	case "move", "copy":
+		from, err := render(operation.From, inputs)
+		if err != nil {
+			return fmt.Errorf("failed to evaluate patch from: %w", err)
+		}
+		fromStr, ok := from.(string)
+		if !ok {
+			return fmt.Errorf("patch from must evaluate to a string, got %T", from)
+		}
+		for _, pointer := range resolved {
+			if err := ensureParentExists(target, pointer); err != nil {
+				return err
+			}
+		}
+		return applyTransfer(target, op, fromStr, resolved)
+	case "upsert":
+		return applyUpsert(target, resolved, value)
 	case "merge":
-		return applyMerge(target, pathStr, value)
+		return applyMerge(target, operation.Op, resolved, value, deepMerge)
+	case "strategicmerge":
+		return applyMerge(target, operation.Op, resolved, value, StrategicMerge)
 	default:
 		return fmt.Errorf("unknown patch operation: %s", operation.Op)
 	}
 }
 
-func applyRFC6902(target map[string]any, op, rawPath string, value any) error {
-	resolved, err := expandPaths(target, rawPath)
+// applyUpsert replaces existing values and adds missing ones, creating missing parents. Unlike add,
+// an existing array element is replaced rather than shifted.
+func applyUpsert(target map[string]any, resolved []string, value any) error {
+	for _, pointer := range resolved {
+		op := "add"
+		if pointerExists(target, pointer) {
+			op = "replace"
+		} else if err := ensureParentExists(target, pointer); err != nil {
+			return err
+		}
+		if err := applyJSONPatch(target, op, pointer, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func pointerExists(root map[string]any, pointer string) bool {
+	parent, last, err := navigateToParent(root, pointer, false)
 	if err != nil {
-		return err
+		return false
+	}
+	switch container := parent.(type) {
+	case map[string]any:
+		_, ok := container[last]
+		return ok
+	case []any:
+		index, err := strconv.Atoi(last)
+		return err == nil && index >= 0 && index < len(container)
+	default:
+		return false
+	}
+}
+
+// splitAppends separates pointers ending in the append position "-" from the rest.
+func splitAppends(pointers []string) (rest, appends []string) {
+	for _, pointer := range pointers {
+		if strings.HasSuffix(pointer, "/-") {
+			appends = append(appends, pointer)
+		} else {
+			rest = append(rest, pointer)
+		}
+	}
+	return rest, appends
+}
+
+// missingPolicy validates an ifMissing value, defaulting to IfMissingError.
+func missingPolicy(value string) (string, error) {
+	switch value {
+	case "":
+		return IfMissingError, nil
+	case IfMissingError, IfMissingSkip, IfMissingAppend:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown ifMissing value %q (expected error, skip or append)", value)
+	}
+}
+
+// existingParents drops pointers whose parent does not exist in target.
+func existingParents(target map[string]any, pointers []string) []string {
+	kept := pointers[:0:0]
+	for _, pointer := range pointers {
+		if _, _, err := navigateToParent(target, pointer, false); err == nil {
+			kept = append(kept, pointer)
+		}
+	}
+	return kept
+}
+
+// operationPointers resolves an operation's string or structured path into JSON pointers.
+func operationPointers(target map[string]any, operation types.JSONPatchOperation, missing string, inputs map[string]any, render func(any, map[string]any) (any, error)) ([]string, error) {
+	if len(operation.Segments) > 0 {
+		return expandSegments(target, operation.Segments, missing, inputs, render)
+	}
+
+	path, exprs, err := extractCELFilters(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+	pathValue, err := render(path, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate patch path: %w", err)
+	}
+
+	pathStr, ok := pathValue.(string)
+	if !ok {
+		return nil, fmt.Errorf("patch path must evaluate to a string, got %T", pathValue)
+	}
+	var filters *celFilters
+	if len(exprs) > 0 {
+		filters = &celFilters{exprs: exprs, inputs: inputs, render: render}
 	}
+	return expandPathsMissing(target, pathStr, missing, filters)
+}
+
+func applyRFC6902(target map[string]any, op string, resolved []string, value any) error {
 	if len(resolved) == 0 {
 		// No matches (e.g., filter didn't match anything); treat as no-op.
 		return nil
 	}
 
+	if op == "remove" && len(resolved) > 1 {
+		// Remove from the back so earlier removals do not shift the indices of later pointers.
+		reversed := make([]string, len(resolved))
+		for i, pointer := range resolved {
+			reversed[len(resolved)-1-i] = pointer
+		}
+		resolved = reversed
+	}
+
 	for _, pointer := range resolved {
 		if op == "add" {
 			if err := ensureParentExists(target, pointer); err != nil {
@@ -68,23 +214,19 @@ func applyRFC6902(target map[string]any, op, rawPath string, value any) error {
 	return nil
 }
 
-func applyMerge(target map[string]any, rawPath string, value any) error {
+func applyMerge(target map[string]any, op string, resolved []string, value any, merge func(existing, value map[string]any) (map[string]any, error)) error {
 	valueMap, ok := value.(map[string]any)
 	if !ok {
-		return fmt.Errorf("merge value must be an object")
+		return fmt.Errorf("%s value must be an object", op)
 	}
 
-	resolved, err := expandPaths(target, rawPath)
-	if err != nil {
-		return err
-	}
 	if len(resolved) == 0 {
 		// Nothing to merge into.
 		return nil
 	}
 
 	for _, pointer := range resolved {
-		if err := mergeAtPointer(target, pointer, valueMap); err != nil {
+		if err := mergeAtPointer(target, pointer, valueMap, merge); err != nil {
 			return err
 		}
 	}
@@ -96,9 +238,15 @@ func applyMerge(target map[string]any, rawPath string, value any) error {
 type pathState struct {
 	pointer []string
 	value   any
+	// appended marks a state created by IfMissingAppend; it cannot be traversed further.
+	appended bool
 }
 
 func expandPaths(root map[string]any, rawPath string) ([]string, error) {
+	return expandPathsMissing(root, rawPath, IfMissingError, nil)
+}
+
+func expandPathsMissing(root map[string]any, rawPath, missing string, filters *celFilters) ([]string, error) {
 	if rawPath == "" {
 		return []string{""}, nil
 	}
@@ -107,13 +255,14 @@ func expandPaths(root map[string]any, rawPath string) ([]string, error) {
 	states := []pathState{{pointer: []string{}, value: root}}
 
 	for _, segment := range segments {
+		states = dropAppended(states)
 		if segment == "-" {
 			states = applyDash(states)
 			continue
 		}
 		nextStates := make([]pathState, 0, len(states))
 		for _, st := range states {
-			expanded, err := applySegment(st, segment)
+			expanded, err := applySegment(st, segment, missing, filters)
 			if err != nil {
 				return nil, err
 			}
@@ -127,16 +276,17 @@ func expandPaths(root map[string]any, rawPath string) ([]string, error) {
 
 	pointers := make([]string, 0, len(states))
 	for _, st := range states {
-		pointers = append(pointers, buildJSONPointer(st.pointer))
+		pointers = append(pointers, jsonpointer.Format(st.pointer))
 	}
 	return pointers, nil
 }
 
-func applySegment(state pathState, segment string) ([]pathState, error) {
+func applySegment(state pathState, segment, missing string, filters *celFilters) ([]pathState, error) {
 	current := []pathState{state}
 	remaining := segment
 
 	for len(remaining) > 0 {
+		current = dropAppended(current)
 		if strings.HasPrefix(remaining, "[") {
 			closeIdx := strings.Index(remaining, "]")
 			if closeIdx == -1 {
@@ -149,15 +299,21 @@ func applySegment(state pathState, segment string) ([]pathState, error) {
 			switch {
 			case strings.HasPrefix(content, "?(") && strings.HasSuffix(content, ")"):
 				expr := content[2 : len(content)-1]
-				current, err = applyFilter(current, expr)
+				current, err = applyFilter(current, expr, filters)
 			case content == "-":
 				current = applyDash(current)
+			case strings.Contains(content, ":"):
+				start, end, parseErr := parseSlice(content)
+				if parseErr != nil {
+					return nil, parseErr
+				}
+				current, err = applySlice(current, start, end)
 			default:
 				index, parseErr := strconv.Atoi(content)
 				if parseErr != nil {
 					return nil, fmt.Errorf("unsupported array index %q", content)
 				}
-				current, err = applyIndex(current, index)
+				current, err = applyIndex(current, index, missing)
 			}
 			if err != nil {
 				return nil, err
@@ -176,11 +332,16 @@ func applySegment(state pathState, segment string) ([]pathState, error) {
 				continue
 			}
 			if idx, err := strconv.Atoi(token); err == nil {
-				current, err = applyIndex(current, idx)
+				current, err = applyIndex(current, idx, missing)
 				if err != nil {
 					return nil, err
 				}
 			} else {
+				// Keys are escaped as in JSON pointers (a~1b is "a/b"); an invalid escape is taken
+				// literally.
+				if key, err := jsonpointer.Unescape(token); err == nil {
+					token = key
+				}
 				var err error
 				current, err = applyKey(current, token)
 				if err != nil {
@@ -217,24 +378,108 @@ func applyKey(states []pathState, key string) ([]pathState, error) {
 	return next, nil
 }
 
-func applyIndex(states []pathState, index int) ([]pathState, error) {
+func applyIndex(states []pathState, index int, missing string) ([]pathState, error) {
 	next := make([]pathState, 0, len(states))
 	for _, st := range states {
 		arr, ok := st.value.([]any)
 		if !ok {
+			if st.value == nil && missing != IfMissingError {
+				continue
+			}
 			return nil, fmt.Errorf("path segment expects an array, got %T", st.value)
 		}
-		if index < 0 || index >= len(arr) {
+		i := index
+		if i < 0 {
+			// Negative indices count from the end: -1 is the last element.
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			switch missing {
+			case IfMissingSkip:
+				continue
+			case IfMissingAppend:
+				next = append(next, pathState{pointer: appendPointer(st.pointer, "-"), appended: true})
+				continue
+			}
 			return nil, fmt.Errorf("array index %d out of bounds", index)
 		}
 		next = append(next, pathState{
-			pointer: appendPointer(st.pointer, strconv.Itoa(index)),
-			value:   arr[index],
+			pointer: appendPointer(st.pointer, strconv.Itoa(i)),
+			value:   arr[i],
 		})
 	}
 	return next, nil
 }
 
+// parseSlice parses a "start:end" range where either bound may be omitted or negative.
+func parseSlice(content string) (start, end *int, err error) {
+	startStr, endStr, _ := strings.Cut(content, ":")
+	parse := func(s string) (*int, error) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil, nil
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported slice bound %q in [%s]", s, content)
+		}
+		return &v, nil
+	}
+	if start, err = parse(startStr); err != nil {
+		return nil, nil, err
+	}
+	if end, err = parse(endStr); err != nil {
+		return nil, nil, err
+	}
+	return start, end, nil
+}
+
+// applySlice selects the half-open range [start, end) of each array. Negative bounds count from the
+// end and bounds are clamped to the array, so a slice never fails on short arrays.
+func applySlice(states []pathState, start, end *int) ([]pathState, error) {
+	next := []pathState{}
+	for _, st := range states {
+		arr, ok := st.value.([]any)
+		if !ok {
+			if st.value == nil {
+				continue
+			}
+			return nil, fmt.Errorf("slice segment expects an array, got %T", st.value)
+		}
+		from, to := sliceBound(start, 0, len(arr)), sliceBound(end, len(arr), len(arr))
+		for idx := from; idx < to; idx++ {
+			next = append(next, pathState{
+				pointer: appendPointer(st.pointer, strconv.Itoa(idx)),
+				value:   arr[idx],
+			})
+		}
+	}
+	return next, nil
+}
+
+func sliceBound(bound *int, fallback, length int) int {
+	if bound == nil {
+		return fallback
+	}
+	v := *bound
+	if v < 0 {
+		v += length
+	}
+	return min(max(v, 0), length)
+}
+
+// dropAppended removes states an IfMissingAppend index produced; a path continuing past them
+// addresses an element that does not exist yet, which is treated as a skip.
+func dropAppended(states []pathState) []pathState {
+	kept := states[:0:0]
+	for _, st := range states {
+		if !st.appended {
+			kept = append(kept, st)
+		}
+	}
+	return kept
+}
+
 func applyDash(states []pathState) []pathState {
 	next := make([]pathState, len(states))
 	for i, st := range states {
@@ -246,7 +491,7 @@ func applyDash(states []pathState) []pathState {
 	return next
 }
 
-func applyFilter(states []pathState, expr string) ([]pathState, error) {
+func applyFilter(states []pathState, expr string, filters *celFilters) ([]pathState, error) {
 	next := []pathState{}
 	for _, st := range states {
 		arr, ok := st.value.([]any)
@@ -254,7 +499,7 @@ func applyFilter(states []pathState, expr string) ([]pathState, error) {
 			continue
 		}
 		for idx, item := range arr {
-			match, err := matchesFilter(item, expr)
+			match, err := matchesFilter(item, expr, filters)
 			if err != nil {
 				return nil, err
 			}
@@ -269,31 +514,54 @@ func applyFilter(states []pathState, expr string) ([]pathState, error) {
 	return next, nil
 }
 
-func matchesFilter(item any, expr string) (bool, error) {
+func matchesFilter(item any, expr string, filters *celFilters) (bool, error) {
+	if strings.HasPrefix(expr, celFilterRef) {
+		return filters.match(expr, item)
+	}
 	matches := filterExpr.FindStringSubmatch(strings.TrimSpace(expr))
 	if len(matches) != 3 {
 		return false, fmt.Errorf("unsupported filter expression: %s", expr)
 	}
+	return fieldEquals(item, strings.Split(matches[1], "."), matches[2]), nil
+}
 
-	fieldPath := strings.Split(matches[1], ".")
-	expected := matches[2]
+// applyFieldFilter keeps the array elements whose field equals expected.
+func applyFieldFilter(states []pathState, fieldPath []string, expected string) ([]pathState, error) {
+	next := []pathState{}
+	for _, st := range states {
+		arr, ok := st.value.([]any)
+		if !ok {
+			continue
+		}
+		for idx, item := range arr {
+			if fieldEquals(item, fieldPath, expected) {
+				next = append(next, pathState{
+					pointer: appendPointer(st.pointer, strconv.Itoa(idx)),
+					value:   item,
+				})
+			}
+		}
+	}
+	return next, nil
+}
 
+func fieldEquals(item any, fieldPath []string, expected string) bool {
 	current := item
 	for _, segment := range fieldPath {
 		m, ok := current.(map[string]any)
 		if !ok {
-			return false, nil
+			return false
 		}
 		current, ok = m[segment]
 		if !ok {
-			return false, nil
+			return false
 		}
 	}
 
 	if current == nil {
-		return expected == "", nil
+		return expected == ""
 	}
-	return fmt.Sprintf("%v", current) == expected, nil
+	return fmt.Sprintf("%v", current) == expected
 }
 
 func splitRawPath(path string) []string {
@@ -314,71 +582,188 @@ func appendPointer(base []string, segment string) []string {
 	return next
 }
 
-func buildJSONPointer(segments []string) string {
-	if len(segments) == 0 {
-		return ""
-	}
-	var b strings.Builder
-	for _, seg := range segments {
-		b.WriteByte('/')
-		if seg == "-" {
-			b.WriteString(seg)
-		} else {
-			b.WriteString(escapePointerSegment(seg))
+// --- RFC6902 execution -----------------------------------------------------
+
+// applyJSONPatch applies one RFC 6902 operation in place. value is copied into the document, and
+// normalized to JSON types as if it had been decoded from the patch.
+func applyJSONPatch(target map[string]any, op, pointer string, value any) error {
+	if op != "remove" {
+		var err error
+		if value, err = jsonValue(value); err != nil {
+			return fmt.Errorf("failed to apply JSON patch: %w", err)
 		}
 	}
-	return b.String()
+	if err := applyPointerOp(target, op, pointer, value); err != nil {
+		return fmt.Errorf("failed to apply JSON patch: %w", err)
+	}
+	return nil
 }
 
-// --- RFC6902 execution -----------------------------------------------------
-
-func applyJSONPatch(target map[string]any, op, pointer string, value any) error {
-	ops := []map[string]any{
-		{
-			"op":   op,
-			"path": pointer,
-		},
+// applyTransfer applies a move or copy from the from pointer to every resolved pointer.
+func applyTransfer(target map[string]any, op, from string, resolved []string) error {
+	if _, err := jsonpointer.Resolve(target, from); err != nil {
+		return fmt.Errorf("failed to apply JSON patch: %w", err)
 	}
-	if op != "remove" {
-		ops[0]["value"] = value
+	for _, pointer := range resolved {
+		if err := applyMove(target, op, from, pointer); err != nil {
+			return fmt.Errorf("failed to apply JSON patch: %w", err)
+		}
 	}
+	return nil
+}
 
-	patchBytes, err := json.Marshal(ops)
+func applyMove(target map[string]any, op, from, pointer string) error {
+	value, err := jsonpointer.Resolve(target, from)
 	if err != nil {
-		return fmt.Errorf("failed to marshal patch: %w", err)
+		return err
+	}
+	if op == "copy" {
+		return applyPointerOp(target, "add", pointer, deepCopyValue(value))
+	}
+	if pointer == from {
+		return nil
+	}
+	if strings.HasPrefix(pointer, from+"/") {
+		return fmt.Errorf("cannot move %s into its own child %s", from, pointer)
 	}
+	if err := applyPointerOp(target, "remove", from, nil); err != nil {
+		return err
+	}
+	return applyPointerOp(target, "add", pointer, value)
+}
 
-	docBytes, err := json.Marshal(target)
+func applyPointerOp(target map[string]any, op, pointer string, value any) error {
+	if pointer == "" {
+		return applyRootOp(target, op, value)
+	}
+	if op == "test" {
+		existing, err := jsonpointer.Resolve(target, pointer)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(existing, value) {
+			return fmt.Errorf("testing value %s failed", pointer)
+		}
+		return nil
+	}
+	if op == "remove" {
+		return jsonpointer.Delete(target, pointer)
+	}
+	segments, err := jsonpointer.Parse(pointer)
 	if err != nil {
-		return fmt.Errorf("failed to marshal resource: %w", err)
+		return err
 	}
+	_, err = jsonpointer.Update(target, segments, func(parent any, last string) (any, error) {
+		switch op {
+		case "add":
+			return addChild(parent, last, value)
+		case "replace":
+			return replaceChild(parent, last, value)
+		default:
+			return nil, fmt.Errorf("unexpected operation %q", op)
+		}
+	})
+	return err
+}
 
-	patch, err := jsonpatch.DecodePatch(patchBytes)
-	if err != nil {
-		return fmt.Errorf("failed to decode JSON patch: %w", err)
+// applyRootOp handles the empty pointer, which addresses the whole resource.
+func applyRootOp(target map[string]any, op string, value any) error {
+	switch op {
+	case "test":
+		if !jsonEqual(target, value) {
+			return fmt.Errorf("testing value of the whole document failed")
+		}
+		return nil
+	case "add", "replace":
+		replacement, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("the whole document must be replaced by an object, got %T", value)
+		}
+		for key := range target {
+			delete(target, key)
+		}
+		for key, item := range replacement {
+			target[key] = item
+		}
+		return nil
+	default:
+		return fmt.Errorf("cannot %s the whole document", op)
 	}
+}
 
-	patched, err := patch.Apply(docBytes)
-	if err != nil {
-		return fmt.Errorf("failed to apply JSON patch: %w", err)
+func addChild(parent any, last string, value any) (any, error) {
+	switch container := parent.(type) {
+	case map[string]any:
+		container[last] = value
+		return container, nil
+	case []any:
+		if last == "-" {
+			return append(container, value), nil
+		}
+		index, err := jsonpointer.Index(container, last, true)
+		if err != nil {
+			return nil, err
+		}
+		inserted := make([]any, 0, len(container)+1)
+		inserted = append(inserted, container[:index]...)
+		inserted = append(inserted, value)
+		return append(inserted, container[index:]...), nil
+	default:
+		return nil, fmt.Errorf("cannot add %s to type %T", last, parent)
 	}
+}
 
-	var updated map[string]any
-	if err := json.Unmarshal(patched, &updated); err != nil {
-		return fmt.Errorf("failed to unmarshal patched document: %w", err)
+func replaceChild(parent any, last string, value any) (any, error) {
+	switch container := parent.(type) {
+	case map[string]any:
+		if _, ok := container[last]; !ok {
+			return nil, fmt.Errorf("replace target %s does not exist", last)
+		}
+		container[last] = value
+		return container, nil
+	case []any:
+		index, err := jsonpointer.Index(container, last, false)
+		if err != nil {
+			return nil, err
+		}
+		container[index] = value
+		return container, nil
+	default:
+		return nil, fmt.Errorf("cannot replace %s in type %T", last, parent)
 	}
+}
 
-	for k := range target {
-		delete(target, k)
+// jsonValue converts value to the types encoding/json decodes into, copying it in the process.
+func jsonValue(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch value: %w", err)
 	}
-	for k, v := range updated {
-		target[k] = v
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal patch value: %w", err)
 	}
-	return nil
+	return decoded, nil
+}
+
+// jsonEqual compares values by their JSON encoding, so 2 and 2.0 are equal as in RFC 6902.
+func jsonEqual(a, b any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
 }
 
 func ensureParentExists(root map[string]any, pointer string) error {
-	segments := splitPointer(pointer)
+	segments, err := jsonpointer.Parse(pointer)
+	if err != nil {
+		return err
+	}
 	if len(segments) == 0 {
 		return nil
 	}
@@ -420,7 +805,31 @@ func ensureParentExists(root map[string]any, pointer string) error {
 
 // --- Merge -----------------------------------------------------------------
 
-func mergeAtPointer(root map[string]any, pointer string, value map[string]any) error {
+// deepMerge is the merge function of the merge operation.
+func deepMerge(existing, value map[string]any) (map[string]any, error) {
+	if existing == nil {
+		return deepCopyMap(value), nil
+	}
+	return DeepMerge(existing, value), nil
+}
+
+// mergeAtPointer replaces the object at pointer with merge(existing, value). existing is nil when
+// the location holds no object; the empty pointer merges into root itself.
+func mergeAtPointer(root map[string]any, pointer string, value map[string]any, merge func(existing, value map[string]any) (map[string]any, error)) error {
+	if pointer == "" {
+		merged, err := merge(root, value)
+		if err != nil {
+			return err
+		}
+		for key := range root {
+			delete(root, key)
+		}
+		for key, item := range merged {
+			root[key] = item
+		}
+		return nil
+	}
+
 	parent, last, err := navigateToParent(root, pointer, true)
 	if err != nil {
 		return err
@@ -429,11 +838,11 @@ func mergeAtPointer(root map[string]any, pointer string, value map[string]any) e
 	switch container := parent.(type) {
 	case map[string]any:
 		existing, _ := container[last].(map[string]any)
-		if existing == nil {
-			container[last] = deepCopyMap(value)
-			return nil
+		merged, err := merge(existing, value)
+		if err != nil {
+			return err
 		}
-		container[last] = DeepMerge(existing, value)
+		container[last] = merged
 	case []any:
 		if last == "-" {
 			return fmt.Errorf("merge operation cannot target append position '-'")
@@ -446,11 +855,11 @@ func mergeAtPointer(root map[string]any, pointer string, value map[string]any) e
 			return fmt.Errorf("array index %d out of bounds for merge", index)
 		}
 		existing, _ := container[index].(map[string]any)
-		if existing == nil {
-			container[index] = deepCopyMap(value)
-			return nil
+		merged, err := merge(existing, value)
+		if err != nil {
+			return err
 		}
-		container[index] = DeepMerge(existing, value)
+		container[index] = merged
 	default:
 		return fmt.Errorf("merge parent must be object or array, got %T", parent)
 	}
@@ -458,7 +867,10 @@ func mergeAtPointer(root map[string]any, pointer string, value map[string]any) e
 }
 
 func navigateToParent(root map[string]any, pointer string, create bool) (any, string, error) {
-	segments := splitPointer(pointer)
+	segments, err := jsonpointer.Parse(pointer)
+	if err != nil {
+		return nil, "", err
+	}
 	if len(segments) == 0 {
 		return root, "", nil
 	}
@@ -511,35 +923,6 @@ func determineNextContainerType(segments []string, index int, last string) any {
 
 // --- Helpers ----------------------------------------------------------------
 
-func splitPointer(pointer string) []string {
-	if pointer == "" {
-		return []string{}
-	}
-	trimmed := strings.TrimPrefix(pointer, "/")
-	if trimmed == "" {
-		return []string{""}
-	}
-	parts := strings.Split(trimmed, "/")
-	for i, part := range parts {
-		if part != "-" {
-			parts[i] = unescapePointerSegment(part)
-		}
-	}
-	return parts
-}
-
-func escapePointerSegment(seg string) string {
-	seg = strings.ReplaceAll(seg, "~", "~0")
-	seg = strings.ReplaceAll(seg, "/", "~1")
-	return seg
-}
-
-func unescapePointerSegment(seg string) string {
-	seg = strings.ReplaceAll(seg, "~1", "/")
-	seg = strings.ReplaceAll(seg, "~0", "~")
-	return seg
-}
-
 func deepCopyMap(src map[string]any) map[string]any {
 	if src == nil {
 		return nil
@@ -558,6 +941,17 @@ func deepCopyMap(src map[string]any) map[string]any {
 	return result
 }
 
+func deepCopyValue(src any) any {
+	switch typed := src.(type) {
+	case map[string]any:
+		return deepCopyMap(typed)
+	case []any:
+		return deepCopySlice(typed)
+	default:
+		return typed
+	}
+}
+
 func deepCopySlice(src []any) []any {
 	if src == nil {
 		return nil
@@ -2,6 +2,7 @@ package patch
 
 import (
 	"encoding/json"
+	"strconv"
 	"testing"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
@@ -215,6 +216,269 @@ spec:
           env:
             - name: SHARED
               value: "true"
+`,
+		},
+		{
+			name: "ifMissing skip ignores out-of-bounds index and missing parent",
+			initial: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/template/spec/containers/3/image", Value: "app:v2", IfMissing: IfMissingSkip},
+				{Op: "remove", Path: "/spec/template/metadata/annotations/debug", IfMissing: IfMissingSkip},
+				{Op: "add", Path: "/spec/template/spec/initContainers/0/image", Value: "init:v1", IfMissing: IfMissingSkip},
+			},
+			want: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+`,
+		},
+		{
+			name: "ifMissing append turns out-of-bounds writes into appends",
+			initial: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "replace", Path: "/spec/template/spec/containers/1", Value: map[string]any{"name": "sidecar"}, IfMissing: IfMissingAppend},
+				{Op: "add", Path: "/spec/template/spec/containers/9", Value: map[string]any{"name": "logger"}, IfMissing: IfMissingAppend},
+				{Op: "replace", Path: "/spec/template/spec/containers/7/image", Value: "ignored", IfMissing: IfMissingAppend},
+			},
+			want: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+        - name: sidecar
+        - name: logger
+`,
+		},
+		{
+			name: "upsert replaces existing values and creates missing paths",
+			initial: `
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:v1
+        - name: sidecar
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "upsert", Path: "/spec/replicas", Value: 3},
+				{Op: "upsert", Path: "/spec/template/spec/containers/0", Value: map[string]any{"name": "app", "image": "app:v2"}},
+				{Op: "upsert", Path: "/spec/template/metadata/annotations/team", Value: "payments"},
+			},
+			want: `
+spec:
+  replicas: 3
+  template:
+    metadata:
+      annotations:
+        team: payments
+    spec:
+      containers:
+        - name: app
+          image: app:v2
+        - name: sidecar
+`,
+		},
+		{
+			name: "escaped keys in paths",
+			initial: `
+metadata:
+  annotations:
+    example.com/owner: platform
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "upsert", Path: "/spec/template/metadata/annotations/prometheus.io~1scrape", Value: "true"},
+				{Op: "copy", From: "/metadata/annotations/example.com~1owner", Path: "/metadata/labels/owner"},
+				{Op: "remove", Path: "/metadata/annotations/example.com~1owner"},
+			},
+			want: `
+metadata:
+  annotations: {}
+  labels:
+    owner: platform
+spec:
+  template:
+    metadata:
+      annotations:
+        prometheus.io/scrape: "true"
+`,
+		},
+		{
+			name: "strategicMerge merges lists by their merge keys",
+			initial: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:v1
+          env:
+            - name: LOG_LEVEL
+              value: info
+            - name: DEBUG
+              value: "true"
+          ports:
+            - containerPort: 8080
+          args: [serve]
+      volumes:
+        - name: tmp
+          emptyDir: {}
+`,
+			operations: []types.JSONPatchOperation{{
+				Op: "strategicMerge",
+				Value: map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+					"containers": []any{
+						map[string]any{
+							"name": "app",
+							"env": []any{
+								map[string]any{"name": "LOG_LEVEL", "value": "debug"},
+								map[string]any{"name": "DEBUG", "$patch": "delete"},
+								map[string]any{"name": "REGION", "value": "eu"},
+							},
+							"ports": []any{map[string]any{"containerPort": 9090, "name": "metrics"}},
+							"args":  []any{"serve", "--verbose"},
+						},
+						map[string]any{"name": "logger", "image": "fluent-bit:2"},
+					},
+					"volumes": []any{map[string]any{"name": "tmp", "emptyDir": nil, "$patch": "replace", "configMap": map[string]any{"name": "tmp"}}},
+				}}}},
+			}},
+			want: `
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: app:v1
+          env:
+            - name: LOG_LEVEL
+              value: debug
+            - name: REGION
+              value: eu
+          ports:
+            - containerPort: 8080
+            - containerPort: 9090
+              name: metrics
+          args: [serve, --verbose]
+        - name: logger
+          image: fluent-bit:2
+      volumes:
+        - name: tmp
+          configMap:
+            name: tmp
+`,
+		},
+		{
+			name: "strategicMerge at a path deletes null keys",
+			initial: `
+metadata:
+  labels:
+    app: web
+    legacy: "true"
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "strategicMerge", Path: "/metadata/labels", Value: map[string]any{"legacy": nil, "team": "payments"}},
+			},
+			want: `
+metadata:
+  labels:
+    app: web
+    team: payments
+`,
+		},
+		{
+			name: "remove a range of elements",
+			initial: `
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: a
+        - name: b
+        - name: c
+        - name: d
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "remove", Path: "/spec/template/spec/initContainers/[1:3]"},
+				{Op: "replace", Path: "/spec/template/spec/initContainers/[-1]/name", Value: "last"},
+			},
+			want: `
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: a
+        - name: last
+`,
+		},
+		{
+			name: "copy and move between locations",
+			initial: `
+metadata:
+  labels:
+    app: web
+  annotations:
+    legacy-owner: payments
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+        - name: sidecar
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "copy", From: "/metadata/labels", Path: "/spec/template/metadata/labels"},
+				{Op: "move", From: "/metadata/annotations/legacy-owner", Path: "/metadata/labels/owner"},
+				{Op: "move", From: "/spec/template/spec/containers/1", Path: "/spec/template/spec/containers/0"},
+			},
+			want: `
+metadata:
+  labels:
+    app: web
+    owner: payments
+  annotations: {}
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: sidecar
+        - name: app
+`,
+		},
+		{
+			name: "test compares numbers by value",
+			initial: `
+spec:
+  replicas: 2
+`,
+			operations: []types.JSONPatchOperation{
+				{Op: "test", Path: "/spec/replicas", Value: int64(2)},
+				{Op: "add", Path: "/spec/ports", Value: []string{"http"}},
+				{Op: "add", Path: "/spec/ports/0", Value: "metrics"},
+			},
+			want: `
+spec:
+  replicas: 2
+  ports: [metrics, http]
 `,
 		},
 	}
@@ -276,6 +540,22 @@ spec:
 	}
 }
 
+func TestApplyPatchIfMissingErrors(t *testing.T) {
+	t.Parallel()
+
+	render := func(v any, _ map[string]any) (any, error) {
+		return v, nil
+	}
+	resource := map[string]any{"items": []any{"a"}}
+
+	if err := ApplyOperation(resource, types.JSONPatchOperation{Op: "replace", Path: "/items/4", Value: "b"}, nil, render); err == nil {
+		t.Errorf("out-of-bounds index without ifMissing succeeded, want error")
+	}
+	if err := ApplyOperation(resource, types.JSONPatchOperation{Op: "replace", Path: "/items/0", Value: "b", IfMissing: "ignore"}, nil, render); err == nil {
+		t.Errorf("unknown ifMissing value succeeded, want error")
+	}
+}
+
 func cmpDiff(expected, actual map[string]any) string {
 	wantJSON, _ := json.Marshal(expected)
 	gotJSON, _ := json.Marshal(actual)
@@ -289,3 +569,50 @@ func cmpDiff(expected, actual map[string]any) string {
 	}
 	return ""
 }
+
+func TestApplyPatchValuesAreNotShared(t *testing.T) {
+	t.Parallel()
+
+	render := func(v any, _ map[string]any) (any, error) {
+		return v, nil
+	}
+	value := map[string]any{"name": "DEBUG"}
+	resource := map[string]any{"containers": []any{
+		map[string]any{"name": "app"},
+		map[string]any{"name": "sidecar"},
+	}}
+	op := types.JSONPatchOperation{Op: "add", Path: "/containers/[0:2]/env", Value: []any{value}}
+	if err := ApplyOperation(resource, op, nil, render); err != nil {
+		t.Fatalf("ApplyOperation() error = %v", err)
+	}
+
+	containers := resource["containers"].([]any)
+	containers[0].(map[string]any)["env"].([]any)[0].(map[string]any)["name"] = "CHANGED"
+	if got := containers[1].(map[string]any)["env"].([]any)[0].(map[string]any)["name"]; got != "DEBUG" {
+		t.Errorf("second container env name = %v, want DEBUG", got)
+	}
+	if value["name"] != "DEBUG" {
+		t.Errorf("patch value was modified: %v", value)
+	}
+}
+
+func BenchmarkApplyOperation(b *testing.B) {
+	render := func(v any, _ map[string]any) (any, error) {
+		return v, nil
+	}
+	containers := make([]any, 20)
+	for i := range containers {
+		containers[i] = map[string]any{"name": "c" + strconv.Itoa(i), "image": "app:v1", "env": []any{}}
+	}
+	op := types.JSONPatchOperation{Op: "add", Path: "/spec/template/spec/containers/[?(@.name=='c10')]/env/-", Value: map[string]any{"name": "A", "value": "1"}}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resource := map[string]any{"spec": map[string]any{"template": map[string]any{"spec": map[string]any{"containers": deepCopySlice(containers)}}}}
+		for j := 0; j < 50; j++ {
+			if err := ApplyOperation(resource, op, nil, render); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
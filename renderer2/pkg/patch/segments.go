@@ -0,0 +1,145 @@
+package patch
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/jsonpointer"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// ResolveAnchors validates the structured paths and ifMissing values of a patch spec and returns a copy whose operations
+// have every {anchor: name} segment replaced by the anchor's segments. Anchors may not reference
+// other anchors. Specs without structured paths are returned unchanged.
+func ResolveAnchors(spec types.PatchSpec) (types.PatchSpec, error) {
+	for name, segments := range spec.Anchors {
+		for i, segment := range segments {
+			if err := validateSegment(segment); err != nil {
+				return spec, fmt.Errorf("anchor %s segment %d: %w", name, i, err)
+			}
+			if segment.Anchor != "" {
+				return spec, fmt.Errorf("anchor %s segment %d: anchors cannot reference other anchors", name, i)
+			}
+		}
+	}
+
+	resolved := spec
+	resolved.Operations = make([]types.JSONPatchOperation, len(spec.Operations))
+	for i, op := range spec.Operations {
+		if op.Path != "" && len(op.Segments) > 0 {
+			return spec, fmt.Errorf("operation %d: path and segments are mutually exclusive", i)
+		}
+		if _, err := missingPolicy(op.IfMissing); err != nil {
+			return spec, fmt.Errorf("operation %d: %w", i, err)
+		}
+
+		var segments []types.PathSegment
+		for j, segment := range op.Segments {
+			if err := validateSegment(segment); err != nil {
+				return spec, fmt.Errorf("operation %d segment %d: %w", i, j, err)
+			}
+			if segment.Anchor == "" {
+				segments = append(segments, segment)
+				continue
+			}
+			anchor, ok := spec.Anchors[segment.Anchor]
+			if !ok {
+				return spec, fmt.Errorf("operation %d segment %d: unknown anchor %q", i, j, segment.Anchor)
+			}
+			segments = append(segments, anchor...)
+		}
+		for j, segment := range segments {
+			if segment.Append && j != len(segments)-1 {
+				return spec, fmt.Errorf("operation %d: append must be the last segment", i)
+			}
+		}
+
+		op.Segments = segments
+		resolved.Operations[i] = op
+	}
+	resolved.Anchors = nil
+	return resolved, nil
+}
+
+func validateSegment(segment types.PathSegment) error {
+	set := 0
+	if segment.Key != "" {
+		set++
+	}
+	if segment.Index != nil {
+		set++
+	}
+	if segment.Append {
+		set++
+	}
+	if segment.Filter != nil {
+		set++
+		if segment.Filter.Field == "" {
+			return fmt.Errorf("filter requires a field")
+		}
+	}
+	if segment.Anchor != "" {
+		set++
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of key, index, append, filter or anchor must be set")
+	}
+	return nil
+}
+
+// expandSegments resolves a structured path into concrete JSON pointers, rendering expressions in
+// keys and filter values against inputs.
+func expandSegments(root map[string]any, segments []types.PathSegment, missing string, inputs map[string]any, render func(any, map[string]any) (any, error)) ([]string, error) {
+	states := []pathState{{pointer: []string{}, value: root}}
+	for _, segment := range segments {
+		states = dropAppended(states)
+		var err error
+		switch {
+		case segment.Anchor != "":
+			return nil, fmt.Errorf("unresolved anchor %q", segment.Anchor)
+		case segment.Append:
+			states = applyDash(states)
+		case segment.Index != nil:
+			states, err = applyIndex(states, *segment.Index, missing)
+		case segment.Filter != nil:
+			var expected string
+			expected, err = renderString(segment.Filter.Equals, inputs, render)
+			if err == nil {
+				states, err = applyFieldFilter(states, strings.Split(segment.Filter.Field, "."), expected)
+			}
+		default:
+			var key string
+			key, err = renderString(segment.Key, inputs, render)
+			if err == nil {
+				states, err = applyKey(states, key)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(states) == 0 {
+			break
+		}
+	}
+
+	pointers := make([]string, 0, len(states))
+	for _, st := range states {
+		pointers = append(pointers, jsonpointer.Format(st.pointer))
+	}
+	return pointers, nil
+}
+
+func renderString(value string, inputs map[string]any, render func(any, map[string]any) (any, error)) (string, error) {
+	rendered, err := render(value, inputs)
+	if err != nil {
+		return "", fmt.Errorf("failed to evaluate path segment %q: %w", value, err)
+	}
+	switch typed := rendered.(type) {
+	case string:
+		return typed, nil
+	case int64, float64, bool:
+		return fmt.Sprintf("%v", typed), nil
+	default:
+		return "", fmt.Errorf("path segment %q must evaluate to a scalar, got %T", value, rendered)
+	}
+}
@@ -0,0 +1,148 @@
+package patch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+// substituteRender replaces ${name} with string inputs; enough to exercise segment rendering
+// without the CEL engine.
+func substituteRender(data any, inputs map[string]any) (any, error) {
+	str, ok := data.(string)
+	if !ok {
+		return data, nil
+	}
+	for key, value := range inputs {
+		str = strings.ReplaceAll(str, "${"+key+"}", value.(string))
+	}
+	return str, nil
+}
+
+func TestResolveAnchorsAndExpandSegments(t *testing.T) {
+	t.Parallel()
+
+	var spec types.PatchSpec
+	err := yaml.Unmarshal([]byte(`
+anchors:
+  container:
+    - spec
+    - template
+    - spec
+    - containers
+    - filter: {field: name, equals: "${container}"}
+operations:
+  - op: add
+    segments: [{anchor: container}, env, {append: true}]
+  - op: replace
+    segments: [{anchor: container}, ports, 0, containerPort]
+  - op: merge
+    segments: [metadata, annotations, "a/b~c"]
+`), &spec)
+	if err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+
+	resolved, err := ResolveAnchors(spec)
+	if err != nil {
+		t.Fatalf("ResolveAnchors() error = %v", err)
+	}
+	if resolved.Anchors != nil {
+		t.Errorf("resolved spec still has anchors: %v", resolved.Anchors)
+	}
+
+	root := map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{}},
+		"spec": map[string]any{"template": map[string]any{"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "sidecar"},
+				map[string]any{"name": "app", "ports": []any{map[string]any{"containerPort": 80}}},
+			},
+		}}},
+	}
+	inputs := map[string]any{"container": "app"}
+
+	want := [][]string{
+		{"/spec/template/spec/containers/1/env/-"},
+		{"/spec/template/spec/containers/1/ports/0/containerPort"},
+		{"/metadata/annotations/a~1b~0c"},
+	}
+	for i, op := range resolved.Operations {
+		got, err := expandSegments(root, op.Segments, IfMissingError, inputs, substituteRender)
+		if err != nil {
+			t.Fatalf("expandSegments(operation %d) error = %v", i, err)
+		}
+		if diff := cmp.Diff(want[i], got); diff != "" {
+			t.Errorf("operation %d pointers mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestResolveAnchorsErrors(t *testing.T) {
+	t.Parallel()
+
+	index := 0
+	tests := []struct {
+		name    string
+		spec    types.PatchSpec
+		wantErr string
+	}{
+		{
+			name: "path and segments",
+			spec: types.PatchSpec{Operations: []types.JSONPatchOperation{
+				{Op: "add", Path: "/a", Segments: []types.PathSegment{{Key: "a"}}},
+			}},
+			wantErr: "mutually exclusive",
+		},
+		{
+			name: "segment with two kinds",
+			spec: types.PatchSpec{Operations: []types.JSONPatchOperation{
+				{Op: "add", Segments: []types.PathSegment{{Key: "a", Index: &index}}},
+			}},
+			wantErr: "exactly one of",
+		},
+		{
+			name: "unknown anchor",
+			spec: types.PatchSpec{Operations: []types.JSONPatchOperation{
+				{Op: "add", Segments: []types.PathSegment{{Anchor: "missing"}}},
+			}},
+			wantErr: `unknown anchor "missing"`,
+		},
+		{
+			name: "nested anchor",
+			spec: types.PatchSpec{
+				Anchors: map[string][]types.PathSegment{"a": {{Anchor: "b"}}},
+			},
+			wantErr: "cannot reference other anchors",
+		},
+		{
+			name: "append before the end",
+			spec: types.PatchSpec{Operations: []types.JSONPatchOperation{
+				{Op: "add", Segments: []types.PathSegment{{Key: "list"}, {Append: true}, {Key: "name"}}},
+			}},
+			wantErr: "append must be the last segment",
+		},
+		{
+			name: "filter without field",
+			spec: types.PatchSpec{Operations: []types.JSONPatchOperation{
+				{Op: "add", Segments: []types.PathSegment{{Filter: &types.PathFilter{Equals: "x"}}}},
+			}},
+			wantErr: "filter requires a field",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ResolveAnchors(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ResolveAnchors() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package patch
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/jsonpointer"
+)
+
+// strategicMergeKeys maps list field names to the fields identifying their elements, following the
+// patchMergeKey tags of the Kubernetes core and apps APIs. Where a field name is shared by several
+// types (container ports use containerPort, service ports use port) the first key present in a
+// patch element is used. Lists not listed here are replaced as a whole.
+var strategicMergeKeys = map[string][]string{
+	"containers":                {"name"},
+	"initContainers":            {"name"},
+	"ephemeralContainers":       {"name"},
+	"env":                       {"name"},
+	"volumes":                   {"name"},
+	"volumeMounts":              {"mountPath"},
+	"volumeDevices":             {"devicePath"},
+	"ports":                     {"containerPort", "port"},
+	"imagePullSecrets":          {"name"},
+	"hostAliases":               {"ip"},
+	"topologySpreadConstraints": {"topologyKey"},
+	"resourceClaims":            {"name"},
+}
+
+// patchDirective is the key carrying strategic merge directives: "$patch: delete" on a list
+// element removes the matching element, "$patch: replace" on an object replaces it instead of
+// merging.
+const patchDirective = "$patch"
+
+// StrategicMerge merges value into existing with Kubernetes strategic-merge-patch semantics: objects
+// merge recursively, null deletes a key, lists with a merge key (containers, env, volumes, ports,
+// ...) merge element by element and other lists are replaced. existing is not modified.
+func StrategicMerge(existing, value map[string]any) (map[string]any, error) {
+	return strategicMergeMap("", existing, value)
+}
+
+func strategicMergeMap(path string, existing, value map[string]any) (map[string]any, error) {
+	switch directive := value[patchDirective]; directive {
+	case nil:
+	case "replace":
+		existing = nil
+	default:
+		return nil, fmt.Errorf("strategic merge at %s: unsupported %s directive %v on an object", displayPath(path), patchDirective, directive)
+	}
+
+	result := make(map[string]any, len(existing)+len(value))
+	for key, item := range existing {
+		result[key] = item
+	}
+	for key, item := range value {
+		if key == patchDirective {
+			continue
+		}
+		fieldPath := path + "/" + jsonpointer.Escape(key)
+		switch typed := item.(type) {
+		case nil:
+			delete(result, key)
+		case map[string]any:
+			current, _ := result[key].(map[string]any)
+			merged, err := strategicMergeMap(fieldPath, current, typed)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = merged
+		case []any:
+			current, _ := result[key].([]any)
+			merged, err := strategicMergeList(fieldPath, key, current, typed)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = merged
+		default:
+			result[key] = typed
+		}
+	}
+	return result, nil
+}
+
+func strategicMergeList(path, field string, existing, value []any) ([]any, error) {
+	mergeKey := listMergeKey(field, value)
+	if mergeKey == "" {
+		return deepCopySlice(value), nil
+	}
+
+	result := append([]any(nil), existing...)
+	for i, item := range value {
+		element, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("strategic merge at %s/%d: elements of %s must be objects", displayPath(path), i, field)
+		}
+		key, ok := element[mergeKey]
+		if !ok {
+			return nil, fmt.Errorf("strategic merge at %s/%d: element is missing merge key %s", displayPath(path), i, mergeKey)
+		}
+
+		index := -1
+		for j, candidate := range result {
+			if candidateMap, ok := candidate.(map[string]any); ok && reflect.DeepEqual(candidateMap[mergeKey], key) {
+				index = j
+				break
+			}
+		}
+
+		if element[patchDirective] == "delete" {
+			if index >= 0 {
+				result = append(result[:index], result[index+1:]...)
+			}
+			continue
+		}
+		var current map[string]any
+		if index >= 0 {
+			current, _ = result[index].(map[string]any)
+		}
+		merged, err := strategicMergeMap(fmt.Sprintf("%s/%d", path, i), current, element)
+		if err != nil {
+			return nil, err
+		}
+		if index >= 0 {
+			result[index] = merged
+		} else {
+			result = append(result, merged)
+		}
+	}
+	return result, nil
+}
+
+// listMergeKey returns the merge key of field that the patch elements use, or "" when the list is
+// replaced.
+func listMergeKey(field string, value []any) string {
+	keys := strategicMergeKeys[field]
+	for _, key := range keys {
+		for _, item := range value {
+			if element, ok := item.(map[string]any); ok {
+				if _, ok := element[key]; ok {
+					return key
+				}
+			}
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
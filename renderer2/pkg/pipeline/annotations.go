@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Annotations recognized on rendered resources. They configure downstream behaviour for a single
+// resource and are removed from the output so they never reach the cluster.
+const (
+	AnnotationPrune     = "platform.io/prune"
+	AnnotationApplyWave = "platform.io/apply-wave"
+	// AnnotationDependsOn lists resources, as comma-separated <kind>/<name>, that must be applied
+	// before this one. The pipeline sets it for the dependsOn of resource templates.
+	AnnotationDependsOn = "platform.io/depends-on"
+	// AnnotationApplyPolicy selects how the resource is applied (see ApplyPolicy). The pipeline
+	// sets it for the applyPolicy of resource templates.
+	AnnotationApplyPolicy = "platform.io/apply-policy"
+)
+
+// ApplyPolicy tells an applier, such as the operator, how to write a resource to the cluster.
+type ApplyPolicy string
+
+const (
+	// ApplyDefault leaves the choice to the applier.
+	ApplyDefault ApplyPolicy = ""
+	// ApplyServerSide server-side applies the resource and fails when another field manager owns
+	// a field it sets.
+	ApplyServerSide ApplyPolicy = "server-side"
+	// ApplyForceConflicts server-side applies the resource, taking over fields other field
+	// managers own.
+	ApplyForceConflicts ApplyPolicy = "force-conflicts"
+	// ApplyReplace replaces the whole object, creating it when it is missing. Fields the render
+	// does not set are reset, which suits resources such as CRDs whose schemas outgrow the size
+	// limit of the last-applied state.
+	ApplyReplace ApplyPolicy = "replace"
+	// ApplySkipIfExists creates the resource once and never updates it, for objects such as Jobs
+	// whose spec is immutable or secrets generated in the cluster.
+	ApplySkipIfExists ApplyPolicy = "skip-if-exists"
+)
+
+// ParseApplyPolicy returns the policy named s.
+func ParseApplyPolicy(s string) (ApplyPolicy, error) {
+	switch policy := ApplyPolicy(s); policy {
+	case ApplyServerSide, ApplyForceConflicts, ApplyReplace, ApplySkipIfExists:
+		return policy, nil
+	}
+	return ApplyDefault, fmt.Errorf("apply policy must be %s, %s, %s or %s, got %q", ApplyServerSide, ApplyForceConflicts, ApplyReplace, ApplySkipIfExists, s)
+}
+
+// ResourceOptions holds per-resource settings extracted from recognized annotations.
+type ResourceOptions struct {
+	// Prune reports whether the resource may be deleted when it disappears from the render output.
+	Prune bool
+	// ApplyWave orders application; lower waves are applied first. ExtractAll computes it from
+	// the dependency graph (see ApplyWaves); the apply-wave annotation sets a minimum.
+	ApplyWave int
+	// DependsOn lists the resources, as <kind>/<name>, this one explicitly depends on.
+	DependsOn []string
+	// ApplyPolicy selects how the resource is applied; ApplyDefault when it does not request one.
+	ApplyPolicy ApplyPolicy
+}
+
+// RenderedResource pairs a rendered object with the options its template requested.
+type RenderedResource struct {
+	Object  map[string]any
+	Options ResourceOptions
+	// Scope is the scope of the resource's kind, set by ApplyScopes.
+	Scope Scope
+}
+
+// ExtractResourceOptions removes recognized annotations from the resource and returns the options
+// they describe. Unrecognized annotations are left untouched.
+func ExtractResourceOptions(resource map[string]any) (ResourceOptions, error) {
+	opts := ResourceOptions{Prune: true}
+
+	metadata, ok := resource["metadata"].(map[string]any)
+	if !ok {
+		return opts, nil
+	}
+	annotations, ok := metadata["annotations"].(map[string]any)
+	if !ok {
+		return opts, nil
+	}
+
+	if value, ok := annotations[AnnotationPrune]; ok {
+		prune, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil {
+			return opts, fmt.Errorf("annotation %s must be \"true\" or \"false\", got %q", AnnotationPrune, fmt.Sprint(value))
+		}
+		opts.Prune = prune
+		delete(annotations, AnnotationPrune)
+	}
+	if value, ok := annotations[AnnotationApplyWave]; ok {
+		wave, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return opts, fmt.Errorf("annotation %s must be an integer, got %q", AnnotationApplyWave, fmt.Sprint(value))
+		}
+		opts.ApplyWave = wave
+		delete(annotations, AnnotationApplyWave)
+	}
+	if value, ok := annotations[AnnotationDependsOn]; ok {
+		for _, ref := range strings.Split(fmt.Sprint(value), ",") {
+			ref = strings.TrimSpace(ref)
+			kind, name, ok := strings.Cut(ref, "/")
+			if !ok || kind == "" || name == "" {
+				return opts, fmt.Errorf("annotation %s must list <kind>/<name> references, got %q", AnnotationDependsOn, ref)
+			}
+			opts.DependsOn = append(opts.DependsOn, ref)
+		}
+		delete(annotations, AnnotationDependsOn)
+	}
+	if value, ok := annotations[AnnotationApplyPolicy]; ok {
+		policy, err := ParseApplyPolicy(fmt.Sprint(value))
+		if err != nil {
+			return opts, fmt.Errorf("annotation %s: %w", AnnotationApplyPolicy, err)
+		}
+		opts.ApplyPolicy = policy
+		delete(annotations, AnnotationApplyPolicy)
+	}
+
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+	return opts, nil
+}
+
+// ExtractAll strips recognized annotations from every resource in place and assigns apply waves
+// (see ApplyWaves).
+func ExtractAll(resources []map[string]any) ([]RenderedResource, error) {
+	rendered := make([]RenderedResource, len(resources))
+	for i, resource := range resources {
+		opts, err := ExtractResourceOptions(resource)
+		if err != nil {
+			return nil, fmt.Errorf("resource %s: %w", ResourceName(resource), err)
+		}
+		rendered[i] = RenderedResource{Object: resource, Options: opts}
+	}
+	if err := ApplyWaves(rendered); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// ResourceName identifies a rendered resource as Kind/name in messages.
+func ResourceName(resource map[string]any) string {
+	kind, _ := resource["kind"].(string)
+	name := ""
+	if metadata, ok := resource["metadata"].(map[string]any); ok {
+		name, _ = metadata["name"].(string)
+	}
+	return kind + "/" + name
+}
@@ -0,0 +1,102 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtractResourceOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		annotations  map[string]any
+		want         ResourceOptions
+		wantMetadata map[string]any
+		wantErr      string
+	}{
+		{
+			name:         "defaults without annotations",
+			want:         ResourceOptions{Prune: true},
+			wantMetadata: map[string]any{"name": "app"},
+		},
+		{
+			name: "recognized annotations are removed",
+			annotations: map[string]any{
+				AnnotationPrune:     "false",
+				AnnotationApplyWave: "2",
+				"team":              "payments",
+			},
+			want: ResourceOptions{Prune: false, ApplyWave: 2},
+			wantMetadata: map[string]any{
+				"name":        "app",
+				"annotations": map[string]any{"team": "payments"},
+			},
+		},
+		{
+			name:         "empty annotations map is dropped",
+			annotations:  map[string]any{AnnotationApplyWave: "-1"},
+			want:         ResourceOptions{Prune: true, ApplyWave: -1},
+			wantMetadata: map[string]any{"name": "app"},
+		},
+		{
+			name:         "dependencies",
+			annotations:  map[string]any{AnnotationDependsOn: "Secret/db, Job/migrate"},
+			want:         ResourceOptions{Prune: true, DependsOn: []string{"Secret/db", "Job/migrate"}},
+			wantMetadata: map[string]any{"name": "app"},
+		},
+		{
+			name:        "invalid dependency",
+			annotations: map[string]any{AnnotationDependsOn: "db"},
+			wantErr:     `must list <kind>/<name> references, got "db"`,
+		},
+		{
+			name:         "apply policy",
+			annotations:  map[string]any{AnnotationApplyPolicy: "skip-if-exists"},
+			want:         ResourceOptions{Prune: true, ApplyPolicy: ApplySkipIfExists},
+			wantMetadata: map[string]any{"name": "app"},
+		},
+		{
+			name:        "invalid apply policy",
+			annotations: map[string]any{AnnotationApplyPolicy: "create"},
+			wantErr:     `apply policy must be server-side, force-conflicts, replace or skip-if-exists, got "create"`,
+		},
+		{
+			name:        "invalid wave",
+			annotations: map[string]any{AnnotationApplyWave: "first"},
+			wantErr:     "must be an integer",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			metadata := map[string]any{"name": "app"}
+			if tt.annotations != nil {
+				metadata["annotations"] = tt.annotations
+			}
+			resource := map[string]any{"kind": "Deployment", "metadata": metadata}
+
+			got, err := ExtractResourceOptions(resource)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExtractResourceOptions() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractResourceOptions() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Fatalf("options mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantMetadata, resource["metadata"]); diff != "" {
+				t.Fatalf("metadata mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/artifacts"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// RenderArtifacts renders the definition's artifact templates with the same inputs as its resources.
+func (r *RendererCoordinates) RenderArtifacts(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+) ([]artifacts.Artifact, error) {
+	if len(definition.Spec.Artifacts) == 0 {
+		return nil, nil
+	}
+	typed, definition, inputs, err := r.componentInputs(definition, component, envSettings, additionalCtx, workload, nil)
+	if err != nil {
+		return nil, err
+	}
+	return typed.renderArtifactTemplates("definition", definition.Spec.Artifacts, inputs)
+}
+
+// RenderAddonArtifacts renders an addon instance's artifact templates with the addon's inputs.
+func (r *RendererCoordinates) RenderAddonArtifacts(
+	addon *types.Addon,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+) ([]artifacts.Artifact, error) {
+	if len(addon.Spec.Artifacts) == 0 {
+		return nil, nil
+	}
+	typed, inputs, err := r.addonInputs(addon, addonInstance, component, envSettings, additionalCtx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return typed.renderArtifactTemplates(addon.Metadata.Name+"/"+addonInstance.InstanceID, addon.Spec.Artifacts, inputs)
+}
+
+func (r *RendererCoordinates) renderArtifactTemplates(owner string, templates []types.ArtifactTemplate, inputs map[string]any) ([]artifacts.Artifact, error) {
+	var result []artifacts.Artifact
+	for _, tmpl := range templates {
+		source := owner + "/" + tmpl.ID
+
+		include, err := r.shouldInclude(types.ResourceTemplate{IncludeWhen: tmpl.IncludeWhen}, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate includeWhen for artifact %s: %w", source, err)
+		}
+		if !include {
+			continue
+		}
+
+		if tmpl.ForEach == "" {
+			artifact, err := r.renderArtifact(source, tmpl, inputs)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, artifact)
+			continue
+		}
+
+		rendered, err := r.TemplateEngine.Render(tmpl.ForEach, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate forEach for artifact %s: %w", source, err)
+		}
+		items, ok := rendered.([]any)
+		if !ok {
+			return nil, fmt.Errorf("forEach expression for artifact %s must return an array, got %T", source, rendered)
+		}
+		varName := tmpl.Var
+		if varName == "" {
+			varName = "item"
+		}
+		for _, item := range items {
+			itemInputs := template.AcquireActivation(inputs)
+			itemInputs[varName] = item
+			artifact, err := r.renderArtifact(source, tmpl, itemInputs)
+			template.ReleaseActivation(itemInputs)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, artifact)
+		}
+	}
+	return result, nil
+}
+
+func (r *RendererCoordinates) renderArtifact(source string, tmpl types.ArtifactTemplate, inputs map[string]any) (artifacts.Artifact, error) {
+	renderedPath, err := r.TemplateEngine.Render(tmpl.Path, inputs)
+	if err != nil {
+		return artifacts.Artifact{}, fmt.Errorf("failed to render path of artifact %s: %w", source, err)
+	}
+	pathStr, ok := renderedPath.(string)
+	if !ok {
+		return artifacts.Artifact{}, fmt.Errorf("path of artifact %s must render to a string, got %T", source, renderedPath)
+	}
+	cleaned, err := artifacts.CleanPath(pathStr)
+	if err != nil {
+		return artifacts.Artifact{}, fmt.Errorf("artifact %s: %w", source, err)
+	}
+
+	content, err := r.TemplateEngine.Render(tmpl.Content, inputs)
+	if err != nil {
+		return artifacts.Artifact{}, fmt.Errorf("failed to render content of artifact %s: %w", source, err)
+	}
+	encoded, err := encodeArtifact(template.RemoveOmittedFields(content), tmpl.Format)
+	if err != nil {
+		return artifacts.Artifact{}, fmt.Errorf("artifact %s: %w", source, err)
+	}
+	return artifacts.Artifact{Path: cleaned, Content: encoded, Source: source}, nil
+}
+
+func encodeArtifact(content any, format string) ([]byte, error) {
+	if str, ok := content.(string); ok {
+		return []byte(str), nil
+	}
+
+	switch format {
+	case "", "json":
+		encoded, err := json.MarshalIndent(content, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content as JSON: %w", err)
+		}
+		return append(encoded, '\n'), nil
+	case "yaml":
+		encoded, err := yaml.Marshal(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode content as YAML: %w", err)
+		}
+		return encoded, nil
+	case "text":
+		return nil, fmt.Errorf("format text requires content to render to a string, got %T", content)
+	default:
+		return nil, fmt.Errorf("unsupported artifact format %q", format)
+	}
+}
@@ -1,19 +1,34 @@
 package pipeline
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/context"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/jsonpointer"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/patch"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
 	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+	"gopkg.in/yaml.v3"
 )
 
 // RendererCoordinates orchestrates generic rendering workflows that other controllers can consume.
+// Its fields must not change once rendering starts; renders may then run concurrently.
 type RendererCoordinates struct {
 	TemplateEngine *template.Engine
+	// Events receives notable occurrences such as rejected overrides; nil discards them.
+	Events events.Sink
+	// Hooks run before and after component renders, in order (see Hooks).
+	Hooks []Hooks
+	// Schemas caches converted definition and addon schemas; nil converts on every render.
+	Schemas *schema.Cache
 }
 
 // NewRenderer constructs a renderer using the provided CEL engine.
@@ -29,6 +44,67 @@ func (r *RendererCoordinates) RenderComponentResources(
 	additionalCtx *types.AdditionalContext,
 	workload map[string]any,
 ) ([]map[string]any, error) {
+	typed, definition, inputs, err := r.componentInputs(definition, component, envSettings, additionalCtx, workload, r.Events)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := typed.renderCommonMetadata(definition.Spec.CommonMetadata, inputs)
+	if err != nil {
+		return nil, err
+	}
+	results, err := typed.renderTemplateResults(definition.Spec.Resources, inputs, shared, nil)
+	if err != nil {
+		return nil, err
+	}
+	return FlattenTemplateResults(results), nil
+}
+
+// componentInputs resolves the definition version and assembles the CEL inputs for a Component,
+// returning a renderer that type checks spec against the definition schema. Rejected overrides are
+// reported to sink, which callers set to nil when rendering the same inputs a second time.
+func (r *RendererCoordinates) componentInputs(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+	sink events.Sink,
+) (*RendererCoordinates, *types.ComponentTypeDefinition, map[string]any, error) {
+	definition, component, envSettings, definitionSchema, inputs, err := r.componentContext(definition, component, envSettings, additionalCtx, workload, sink)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	typed, err := r.typed(definitionSchema)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to type definition schema: %w", err)
+	}
+	typed, err = typed.withFragments(definition.Spec.Fragments)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	hookCtx := &HookContext{Definition: definition, Component: component, EnvSettings: envSettings, Inputs: inputs}
+	if err := r.runPreRenderHooks(hookCtx); err != nil {
+		return nil, nil, nil, err
+	}
+	return typed, definition, hookCtx.Inputs, nil
+}
+
+// componentContext resolves the definition version, drops undeclared overrides and builds the
+// inputs of a Component with spec defaulted by the definition schema, before any hook runs. It
+// returns the resolved definition, component and envSettings and the definition schema.
+func (r *RendererCoordinates) componentContext(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+	sink events.Sink,
+) (*types.ComponentTypeDefinition, *types.Component, *types.EnvSettings, schema.Definition, map[string]any, error) {
+	definition, component, err := versioning.Prepare(r.TemplateEngine, definition, component)
+	if err != nil {
+		return nil, nil, nil, schema.Definition{}, nil, fmt.Errorf("failed to resolve definition version: %w", err)
+	}
+
 	definitionSchema := schema.Definition{
 		Types: definition.Spec.Schema.Types,
 		Schemas: []map[string]any{
@@ -37,13 +113,36 @@ func (r *RendererCoordinates) RenderComponentResources(
 		},
 	}
 
-	componentDefaults, err := schema.ExtractDefaults(definitionSchema)
+	componentDefaults, err := r.Schemas.ExtractDefaults(definitionSchema)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate component defaults: %w", err)
+		return nil, nil, nil, schema.Definition{}, nil, fmt.Errorf("failed to calculate component defaults: %w", err)
+	}
+
+	if envSettings != nil {
+		overrides, rejected := filterOverrides(envSettings.Spec.Overrides, definition.Spec.Schema.EnvOverrides)
+		for _, key := range rejected {
+			events.Warningf(sink, component, events.ReasonEnvOverrideRejected,
+				"EnvSettings %s overrides %q, which is not declared in envOverrides of %s", envSettings.Metadata.Name, key, definition.Metadata.Name)
+		}
+		if len(rejected) > 0 {
+			filtered := *envSettings
+			filtered.Spec.Overrides = overrides
+			envSettings = &filtered
+		}
+	}
+
+	r.warnDeprecated(sink, component, definitionSchema, "Component "+component.Metadata.Name, component.Spec.Parameters)
+	if envSettings != nil {
+		r.warnDeprecated(sink, component, definitionSchema, "EnvSettings "+envSettings.Metadata.Name, envSettings.Spec.Overrides)
 	}
 
 	inputs := context.BuildComponentContext(component, envSettings, additionalCtx, workload, componentDefaults)
-	return r.renderResourceTemplates(definition.Spec.Resources, inputs)
+	// Merging the defaults underneath the parameters misses the fields of objects the parameters
+	// set partially; spec is a fresh copy, so it is defaulted in place.
+	if err := r.Schemas.ApplyDefaults(definitionSchema, inputs["spec"].(map[string]any)); err != nil {
+		return nil, nil, nil, schema.Definition{}, nil, fmt.Errorf("failed to apply component defaults: %w", err)
+	}
+	return definition, component, envSettings, definitionSchema, inputs, nil
 }
 
 // ApplyAddon composes addon creates and patches against already rendered resources.
@@ -56,67 +155,295 @@ func (r *RendererCoordinates) ApplyAddon(
 	additionalCtx *types.AdditionalContext,
 	matcher patch.Matcher,
 ) ([]map[string]any, error) {
-	addonSchema := schema.Definition{
-		Types: addon.Spec.Schema.Types,
-		Schemas: []map[string]any{
-			addon.Spec.Schema.Parameters,
-			addon.Spec.Schema.EnvOverrides,
-		},
+	return r.ApplyAddonTracked(baseResources, addon, addonInstance, component, envSettings, additionalCtx, matcher, nil)
+}
+
+// ApplyAddonTracked behaves like ApplyAddon and, when provenance is non-nil, attributes every path
+// its creates set and its patches change to the addon instance so conflicts with earlier addons
+// are recorded.
+func (r *RendererCoordinates) ApplyAddonTracked(
+	baseResources []map[string]any,
+	addon *types.Addon,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	matcher patch.Matcher,
+	provenance *Provenance,
+) ([]map[string]any, error) {
+	var record func(before, after map[string]any)
+	if provenance != nil {
+		owner := AddonRef{Addon: addon.Metadata.Name, Instance: addonInstance.InstanceID}
+		record = func(before, after map[string]any) {
+			provenance.record(owner, before, after)
+		}
 	}
-	addonDefaults, err := schema.ExtractDefaults(addonSchema)
+
+	typed, inputs, err := r.addonInputs(addon, addonInstance, component, envSettings, additionalCtx, r.Events)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate defaults for addon %s: %w", addon.Metadata.Name, err)
+		return nil, err
 	}
 
-	inputs := context.BuildAddonContext(component, addonInstance, envSettings, additionalCtx, addonDefaults)
+	created, err := typed.renderCreateTemplates(addon.Metadata.Name+"/"+addonInstance.InstanceID, addon.Spec.Creates, inputs)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		// Every created resource, including each document of a multi-document create, is
+		// attributed to the instance, so later addons overwriting its values conflict with it.
+		for _, resource := range created {
+			record(nil, resource)
+		}
+	}
+	baseResources = append(baseResources, created...)
 
-	// Render creates
-	for _, createTemplate := range addon.Spec.Creates {
-		rendered, err := r.TemplateEngine.Render(createTemplate, inputs)
+	// Apply patches
+	for _, patchSpec := range addon.Spec.Patches {
+		if len(patchSpec.Anchors) > 0 {
+			// Addons loaded through the parser are already resolved; registered ones may not be.
+			patchSpec, err = patch.ResolveAnchors(patchSpec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid patch of addon %s: %w", addon.Metadata.Name, err)
+			}
+		}
+		matched, err := typed.applyPatchSpec(baseResources, patchSpec, inputs, matcher, record)
 		if err != nil {
-			return nil, fmt.Errorf("failed to render addon create template %s/%s: %w", addon.Metadata.Name, addonInstance.InstanceID, err)
+			return nil, fmt.Errorf("failed to apply addon patch: %w", err)
+		}
+		if matched == 0 && len(patchSpec.Operations) > 0 {
+			events.Warningf(r.Events, component, events.ReasonPatchMatchedNothing,
+				"patch of addon %s (instance %s) targeting %s matched no resources", addon.Metadata.Name, addonInstance.InstanceID, describeTarget(patchSpec.Target))
+		}
+	}
+
+	return baseResources, nil
+}
+
+// renderCreateTemplates renders an addon instance's creates, honoring includeWhen and forEach.
+func (r *RendererCoordinates) renderCreateTemplates(owner string, templates []types.CreateTemplate, inputs map[string]any) ([]map[string]any, error) {
+	var resources []map[string]any
+	for i, tmpl := range templates {
+		source := owner
+		if tmpl.ID != "" {
+			source += "/" + tmpl.ID
+		} else {
+			source += fmt.Sprintf("/create-%d", i)
 		}
 
-		renderedMap, ok := rendered.(map[string]any)
+		include, err := r.shouldInclude(types.ResourceTemplate{IncludeWhen: tmpl.IncludeWhen}, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate includeWhen for addon create %s: %w", source, err)
+		}
+		if !include {
+			continue
+		}
+
+		if tmpl.ForEach == "" {
+			created, err := r.renderCreate(source, tmpl, inputs)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, created...)
+			continue
+		}
+
+		rendered, err := r.TemplateEngine.Render(tmpl.ForEach, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to evaluate forEach for addon create %s: %w", source, err)
+		}
+		items, ok := rendered.([]any)
 		if !ok {
-			return nil, fmt.Errorf("addon create template must render to an object (addon %s)", addon.Metadata.Name)
+			return nil, fmt.Errorf("forEach expression for addon create %s must return an array, got %T", source, rendered)
+		}
+		varName := tmpl.Var
+		if varName == "" {
+			varName = "item"
 		}
+		for _, item := range items {
+			itemInputs := template.AcquireActivation(inputs)
+			itemInputs[varName] = item
+			created, err := r.renderCreate(source, tmpl, itemInputs)
+			template.ReleaseActivation(itemInputs)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, created...)
+		}
+	}
+	return resources, nil
+}
 
-		cleaned := template.RemoveOmittedFields(renderedMap).(map[string]any)
-		baseResources = append(baseResources, cleaned)
+// renderCreate renders one instance of an addon create. A template rendering to a list, or to a
+// string holding a multi-document YAML stream, creates one resource per element or document;
+// empty documents are skipped and every other one must be an object.
+func (r *RendererCoordinates) renderCreate(source string, tmpl types.CreateTemplate, inputs map[string]any) ([]map[string]any, error) {
+	rendered, err := r.TemplateEngine.Render(tmpl.Template, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render addon create template %s: %w", source, err)
 	}
 
-	// Apply patches
-	for _, patchSpec := range addon.Spec.Patches {
-		if err := r.applyPatchSpec(baseResources, patchSpec, inputs, matcher); err != nil {
-			return nil, fmt.Errorf("failed to apply addon patch: %w", err)
+	var documents []any
+	switch typed := template.RemoveOmittedFields(rendered).(type) {
+	case map[string]any:
+		return []map[string]any{typed}, nil
+	case []any:
+		documents = typed
+	case string:
+		documents, err = decodeDocuments(typed)
+		if err != nil {
+			return nil, fmt.Errorf("addon create template %s rendered invalid YAML: %w", source, err)
 		}
+	default:
+		return nil, fmt.Errorf("addon create template %s must render to an object, a list of objects or YAML documents, got %T", source, typed)
 	}
 
-	return baseResources, nil
+	resources := make([]map[string]any, 0, len(documents))
+	for i, document := range documents {
+		if document == nil {
+			continue
+		}
+		resource, ok := document.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("addon create template %s[%d] must render to an object, got %T", source, i, document)
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
+// decodeDocuments decodes every document of a YAML stream; empty documents decode to nil.
+func decodeDocuments(stream string) ([]any, error) {
+	decoder := yaml.NewDecoder(strings.NewReader(stream))
+	var documents []any
+	for {
+		var document any
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+}
+
+// addonInputs assembles the CEL inputs for an addon instance, dropping overrides its envOverrides
+// schema does not declare and reporting them to sink. The returned renderer type checks spec
+// against the addon schema.
+func (r *RendererCoordinates) addonInputs(
+	addon *types.Addon,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	sink events.Sink,
+) (*RendererCoordinates, map[string]any, error) {
+	addonSchema, inputs, err := r.addonContext(addon, addonInstance, component, envSettings, additionalCtx, sink)
+	if err != nil {
+		return nil, nil, err
+	}
+	typed, err := r.typed(addonSchema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to type schema of addon %s: %w", addon.Metadata.Name, err)
+	}
+	return typed, inputs, nil
 }
-func (r *RendererCoordinates) applyPatchSpec(resources []map[string]any, spec types.PatchSpec, inputs map[string]any, matcher patch.Matcher) error {
+
+// addonContext builds the inputs of an addon instance with spec defaulted by the addon schema and
+// returns that schema.
+func (r *RendererCoordinates) addonContext(
+	addon *types.Addon,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	sink events.Sink,
+) (schema.Definition, map[string]any, error) {
+	addonSchema := schema.Definition{
+		Types: addon.Spec.Schema.Types,
+		Schemas: []map[string]any{
+			addon.Spec.Schema.Parameters,
+			addon.Spec.Schema.EnvOverrides,
+		},
+	}
+	addonDefaults, err := r.Schemas.ExtractDefaults(addonSchema)
+	if err != nil {
+		return schema.Definition{}, nil, fmt.Errorf("failed to calculate defaults for addon %s: %w", addon.Metadata.Name, err)
+	}
+
+	if envSettings != nil {
+		if instanceOverrides, ok := envSettings.Spec.AddonOverrides[addonInstance.InstanceID]; ok {
+			overrides, rejected := filterOverrides(instanceOverrides, addon.Spec.Schema.EnvOverrides)
+			for _, key := range rejected {
+				events.Warningf(sink, component, events.ReasonEnvOverrideRejected,
+					"EnvSettings %s overrides %q for addon instance %s, which is not declared in envOverrides of %s",
+					envSettings.Metadata.Name, key, addonInstance.InstanceID, addon.Metadata.Name)
+			}
+			if len(rejected) > 0 {
+				filtered := *envSettings
+				filtered.Spec.AddonOverrides = make(map[string]map[string]any, len(envSettings.Spec.AddonOverrides))
+				for id, values := range envSettings.Spec.AddonOverrides {
+					filtered.Spec.AddonOverrides[id] = values
+				}
+				filtered.Spec.AddonOverrides[addonInstance.InstanceID] = overrides
+				envSettings = &filtered
+			}
+		}
+	}
+
+	r.warnDeprecated(sink, component, addonSchema, "Addon instance "+addonInstance.InstanceID, addonInstance.Config)
+	if envSettings != nil {
+		r.warnDeprecated(sink, component, addonSchema,
+			fmt.Sprintf("EnvSettings %s for addon instance %s", envSettings.Metadata.Name, addonInstance.InstanceID),
+			envSettings.Spec.AddonOverrides[addonInstance.InstanceID])
+	}
+
+	inputs := context.BuildAddonContext(component, addonInstance, envSettings, additionalCtx, addonDefaults)
+	if err := r.Schemas.ApplyDefaults(addonSchema, inputs["spec"].(map[string]any)); err != nil {
+		return schema.Definition{}, nil, fmt.Errorf("failed to apply defaults for addon %s: %w", addon.Metadata.Name, err)
+	}
+	return addonSchema, inputs, nil
+}
+
+// applyPatchSpec applies spec to the matching resources and returns how many it patched. A non-nil
+// record is called with a copy of each target taken before its operations and the target after.
+func (r *RendererCoordinates) applyPatchSpec(
+	resources []map[string]any,
+	spec types.PatchSpec,
+	inputs map[string]any,
+	matcher patch.Matcher,
+	record func(before, after map[string]any),
+) (int, error) {
 	targets := patch.FindTargetResources(resources, spec.Target, matcher)
 
 	if len(spec.Operations) == 0 {
-		return nil
+		return 0, nil
+	}
+	matched := 0
+
+	// Where clauses may inspect every resource of the render, e.g. to skip a Deployment when a
+	// StatefulSet exists. CEL cannot mutate the list, so it is shared rather than copied.
+	var allResources []any
+	if spec.Target.Where != "" {
+		allResources = make([]any, len(resources))
+		for i, resource := range resources {
+			allResources[i] = resource
+		}
 	}
 
-	// Helper to evaluate the where clause for a given target with provided inputs.
+	// The addon inputs may be shared with other goroutines rendering the same addon, so the
+	// per-target variables are bound on pooled copies rather than on inputs itself.
 	matchTarget := func(where string, target map[string]any, baseInputs map[string]any) (bool, error) {
 		if where == "" {
 			return true, nil
 		}
 
-		previous, had := baseInputs["resource"]
-		baseInputs["resource"] = target
-
-		result, err := r.TemplateEngine.Render(where, baseInputs)
-		if had {
-			baseInputs["resource"] = previous
-		} else {
-			delete(baseInputs, "resource")
-		}
+		scope := template.AcquireActivation(baseInputs)
+		scope["resource"] = target
+		scope["allResources"] = allResources
+		result, err := r.TemplateEngine.Render(where, scope)
+		template.ReleaseActivation(scope)
 
 		if err != nil {
 			if isMissingDataError(err) {
@@ -132,22 +459,20 @@ func (r *RendererCoordinates) applyPatchSpec(resources []map[string]any, spec ty
 	}
 
 	executeOperations := func(target map[string]any, baseInputs map[string]any) error {
-		previous, had := baseInputs["resource"]
-		baseInputs["resource"] = target
+		scope := template.AcquireActivation(baseInputs)
+		defer template.ReleaseActivation(scope)
+		scope["resource"] = target
+		var before map[string]any
+		if record != nil {
+			before = copyValue(target).(map[string]any)
+		}
 		for _, op := range spec.Operations {
-			if err := patch.ApplyOperation(target, op, baseInputs, r.TemplateEngine.Render); err != nil {
-				if had {
-					baseInputs["resource"] = previous
-				} else {
-					delete(baseInputs, "resource")
-				}
+			if err := patch.ApplyOperation(target, op, scope, r.TemplateEngine.Render); err != nil {
 				return err
 			}
 		}
-		if had {
-			baseInputs["resource"] = previous
-		} else {
-			delete(baseInputs, "resource")
+		if record != nil {
+			record(before, target)
 		}
 		return nil
 	}
@@ -156,12 +481,12 @@ func (r *RendererCoordinates) applyPatchSpec(resources []map[string]any, spec ty
 		// Evaluate iteration list
 		itemsRaw, err := r.TemplateEngine.Render(spec.ForEach, inputs)
 		if err != nil {
-			return fmt.Errorf("failed to evaluate patch forEach expression: %w", err)
+			return 0, fmt.Errorf("failed to evaluate patch forEach expression: %w", err)
 		}
 
 		items, ok := itemsRaw.([]any)
 		if !ok {
-			return fmt.Errorf("forEach expression must evaluate to an array, got %T", itemsRaw)
+			return 0, fmt.Errorf("forEach expression must evaluate to an array, got %T", itemsRaw)
 		}
 
 		varName := spec.Var
@@ -169,120 +494,225 @@ func (r *RendererCoordinates) applyPatchSpec(resources []map[string]any, spec ty
 			varName = "item"
 		}
 
-		previous, hadVar := inputs[varName]
 		for _, item := range items {
-			inputs[varName] = item
+			itemInputs := template.AcquireActivation(inputs)
+			itemInputs[varName] = item
 
 			for _, target := range targets {
-				match, err := matchTarget(spec.Target.Where, target, inputs)
+				match, err := matchTarget(spec.Target.Where, target, itemInputs)
 				if err != nil {
-					if hadVar {
-						inputs[varName] = previous
-					} else {
-						delete(inputs, varName)
-					}
-					return err
+					template.ReleaseActivation(itemInputs)
+					return 0, err
 				}
 				if !match {
 					continue
 				}
-				if err := executeOperations(target, inputs); err != nil {
-					if hadVar {
-						inputs[varName] = previous
-					} else {
-						delete(inputs, varName)
-					}
-					return err
+				if err := executeOperations(target, itemInputs); err != nil {
+					template.ReleaseActivation(itemInputs)
+					return 0, err
 				}
+				matched++
 			}
+			template.ReleaseActivation(itemInputs)
 		}
-		if hadVar {
-			inputs[varName] = previous
-		} else {
-			delete(inputs, varName)
-		}
-		return nil
+		return matched, nil
 	}
 
 	for _, target := range targets {
 		match, err := matchTarget(spec.Target.Where, target, inputs)
 		if err != nil {
-			return err
+			return 0, err
 		}
 		if !match {
 			continue
 		}
 		if err := executeOperations(target, inputs); err != nil {
-			return err
+			return 0, err
 		}
+		matched++
 	}
 
-	return nil
+	return matched, nil
 }
 
+// renderResourceTemplates renders templates, without commonMetadata, in dependency order and
+// returns their resources in declaration order.
 func (r *RendererCoordinates) renderResourceTemplates(templates []types.ResourceTemplate, inputs map[string]any) ([]map[string]any, error) {
-	var resources []map[string]any
+	results, err := r.renderTemplateResults(templates, inputs, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return FlattenTemplateResults(results), nil
+}
+
+// renderResourceTemplate renders the instances of one base resource template: none when
+// includeWhen is false, one per item with forEach, and one otherwise.
+func (r *RendererCoordinates) renderResourceTemplate(tmpl types.ResourceTemplate, inputs map[string]any) ([]map[string]any, error) {
+	include, err := r.shouldInclude(tmpl, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate includeWhen for resource %s: %w", tmpl.ID, err)
+	}
+	if !include {
+		return nil, nil
+	}
 
-	for _, tmpl := range templates {
-		include, err := r.shouldInclude(tmpl, inputs)
+	if tmpl.ForEach == "" {
+		resource, err := r.renderResource(tmpl, inputs)
 		if err != nil {
-			return nil, fmt.Errorf("failed to evaluate includeWhen for resource %s: %w", tmpl.ID, err)
-		}
-		if !include {
-			continue
+			return nil, err
 		}
+		return []map[string]any{resource}, nil
+	}
 
-		if tmpl.ForEach != "" {
-			rendered, err := r.TemplateEngine.Render(tmpl.ForEach, inputs)
-			if err != nil {
-				return nil, fmt.Errorf("failed to evaluate forEach for resource %s: %w", tmpl.ID, err)
-			}
+	rendered, err := r.TemplateEngine.Render(tmpl.ForEach, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate forEach for resource %s: %w", tmpl.ID, err)
+	}
 
-			items, ok := rendered.([]any)
-			if !ok {
-				return nil, fmt.Errorf("forEach expression for resource %s must return an array, got %T", tmpl.ID, rendered)
-			}
+	items, ok := rendered.([]any)
+	if !ok {
+		return nil, fmt.Errorf("forEach expression for resource %s must return an array, got %T", tmpl.ID, rendered)
+	}
 
-			varName := tmpl.Var
-			if varName == "" {
-				varName = "item"
-			}
+	varName := tmpl.Var
+	if varName == "" {
+		varName = "item"
+	}
 
-			for _, item := range items {
-				itemInputs := cloneMap(inputs)
-				itemInputs[varName] = item
+	resources := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		itemInputs := template.AcquireActivation(inputs)
+		itemInputs[varName] = item
 
-				resource, err := r.TemplateEngine.Render(tmpl.Template, itemInputs)
-				if err != nil {
-					return nil, fmt.Errorf("failed to render resource %s: %w", tmpl.ID, err)
-				}
+		resource, err := r.renderResource(tmpl, itemInputs)
+		template.ReleaseActivation(itemInputs)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
 
-				resourceMap, ok := resource.(map[string]any)
-				if !ok {
-					return nil, fmt.Errorf("resource template must render to an object: %s", tmpl.ID)
-				}
+// renderResource renders one instance of a base resource template and drops the fields whose
+// fieldsWhen condition is false.
+func (r *RendererCoordinates) renderResource(tmpl types.ResourceTemplate, inputs map[string]any) (map[string]any, error) {
+	resource, err := r.TemplateEngine.Render(tmpl.Template, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render resource %s: %w", tmpl.ID, err)
+	}
 
-				cleaned := template.RemoveOmittedFields(resourceMap).(map[string]any)
-				resources = append(resources, cleaned)
-			}
-			continue
-		}
+	resourceMap, ok := resource.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("resource template must render to an object: %s", tmpl.ID)
+	}
 
-		resource, err := r.TemplateEngine.Render(tmpl.Template, inputs)
-		if err != nil {
-			return nil, fmt.Errorf("failed to render resource %s: %w", tmpl.ID, err)
+	cleaned := template.RemoveOmittedFields(resourceMap).(map[string]any)
+	if err := r.dropFields(tmpl, cleaned, inputs); err != nil {
+		return nil, fmt.Errorf("failed to evaluate fieldsWhen for resource %s: %w", tmpl.ID, err)
+	}
+	return cleaned, nil
+}
+
+// renderCommonMetadata renders the definition's commonMetadata into labels and annotations keyed by
+// field; nil when the definition has none.
+func (r *RendererCoordinates) renderCommonMetadata(common types.CommonMetadata, inputs map[string]any) (map[string]any, error) {
+	tmpl := common.Template()
+	if tmpl == nil {
+		return nil, nil
+	}
+	rendered, err := r.TemplateEngine.Render(tmpl, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render commonMetadata: %w", err)
+	}
+	shared := template.RemoveOmittedFields(rendered).(map[string]any)
+	for field, values := range shared {
+		for key, value := range values.(map[string]any) {
+			if _, ok := value.(string); !ok {
+				return nil, fmt.Errorf("commonMetadata %s %s must render to a string, got %T", field, key, value)
+			}
 		}
+	}
+	return shared, nil
+}
 
-		resourceMap, ok := resource.(map[string]any)
+// mergeCommonMetadata merges rendered commonMetadata into the metadata of every resource. Labels
+// and annotations a resource template sets itself are kept.
+func mergeCommonMetadata(shared map[string]any, resources []map[string]any) {
+	if len(shared) == 0 {
+		return
+	}
+	for _, resource := range resources {
+		metadata, ok := resource["metadata"].(map[string]any)
 		if !ok {
-			return nil, fmt.Errorf("resource template must render to an object: %s", tmpl.ID)
+			metadata = map[string]any{}
+			resource["metadata"] = metadata
+		}
+		for field, values := range shared {
+			existing, ok := metadata[field].(map[string]any)
+			if !ok {
+				existing = map[string]any{}
+				metadata[field] = existing
+			}
+			for key, value := range values.(map[string]any) {
+				if _, set := existing[key]; !set {
+					existing[key] = value
+				}
+			}
 		}
+	}
+}
 
-		cleaned := template.RemoveOmittedFields(resourceMap).(map[string]any)
-		resources = append(resources, cleaned)
+// dropFields deletes the fields of resource whose fieldsWhen condition is false. Like includeWhen,
+// a condition reading missing data is false. Fields the render already omitted are skipped, and
+// fields are deleted from the last array element to the first, so dropping one element does not
+// shift the pointers of the others.
+func (r *RendererCoordinates) dropFields(tmpl types.ResourceTemplate, resource map[string]any, inputs map[string]any) error {
+	var dropped [][]string
+	for pointer, condition := range tmpl.FieldsWhen {
+		tokens, err := jsonpointer.Parse(pointer)
+		if err != nil {
+			return err
+		}
+		if len(tokens) == 0 {
+			return fmt.Errorf("fieldsWhen pointer must name a field, not the whole resource")
+		}
+		result, err := r.TemplateEngine.Render(condition, inputs)
+		if err != nil && !isMissingDataError(err) {
+			return fmt.Errorf("%s: %w", pointer, err)
+		}
+		keep, ok := result.(bool)
+		if err == nil && !ok {
+			return fmt.Errorf("%s: condition must evaluate to bool, got %T", pointer, result)
+		}
+		if !keep {
+			dropped = append(dropped, tokens)
+		}
 	}
+	sort.Slice(dropped, func(i, j int) bool { return comparePointers(dropped[i], dropped[j]) > 0 })
+	for _, tokens := range dropped {
+		if err := jsonpointer.Delete(resource, jsonpointer.Format(tokens)); err != nil && !errors.Is(err, jsonpointer.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
 
-	return resources, nil
+// comparePointers orders pointers token by token, comparing array indexes numerically; a pointer
+// sorts after its prefixes.
+func comparePointers(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		x, errX := strconv.Atoi(a[i])
+		y, errY := strconv.Atoi(b[i])
+		if errX == nil && errY == nil {
+			return x - y
+		}
+		return strings.Compare(a[i], b[i])
+	}
+	return len(a) - len(b)
 }
 
 func (r *RendererCoordinates) shouldInclude(tmpl types.ResourceTemplate, inputs map[string]any) (bool, error) {
@@ -306,12 +736,60 @@ func (r *RendererCoordinates) shouldInclude(tmpl types.ResourceTemplate, inputs
 	return include, nil
 }
 
-func cloneMap(src map[string]any) map[string]any {
-	result := make(map[string]any, len(src))
-	for key, value := range src {
-		result[key] = value
+// warnDeprecated emits a warning for every field values, written by source, sets although def
+// marks it deprecated. The schema already converted for the defaults, so it cannot fail here.
+func (r *RendererCoordinates) warnDeprecated(sink events.Sink, component *types.Component, def schema.Definition, source string, values map[string]any) {
+	if sink == nil || len(values) == 0 {
+		return
+	}
+	deprecations, err := r.Schemas.Deprecations(def, values)
+	if err != nil {
+		return
+	}
+	for _, d := range deprecations {
+		if d.Notice == "" {
+			events.Warningf(sink, component, events.ReasonDeprecatedField, "%s sets %s, which is deprecated", source, d.Path)
+			continue
+		}
+		events.Warningf(sink, component, events.ReasonDeprecatedField, "%s sets %s, which is deprecated: %s", source, d.Path, d.Notice)
+	}
+}
+
+// filterOverrides drops top-level override keys that the envOverrides schema does not declare.
+// Definitions without an envOverrides schema accept every override.
+func filterOverrides(overrides, declared map[string]any) (map[string]any, []string) {
+	if len(declared) == 0 || len(overrides) == 0 {
+		return overrides, nil
+	}
+
+	allowed := make(map[string]any, len(overrides))
+	var rejected []string
+	for key, value := range overrides {
+		if _, ok := declared[key]; !ok {
+			rejected = append(rejected, key)
+			continue
+		}
+		allowed[key] = value
+	}
+	sort.Strings(rejected)
+	return allowed, rejected
+}
+
+func describeTarget(target types.TargetSpec) string {
+	parts := []string{}
+	if target.Group != "" || target.Version != "" {
+		parts = append(parts, strings.Trim(target.Group+"/"+target.Version, "/"))
+	}
+	if target.Kind != "" {
+		parts = append(parts, target.Kind)
+	}
+	if target.Name != "" {
+		parts = append(parts, target.Name)
+	}
+	if len(parts) == 0 {
+		return "all resources"
 	}
-	return result
+	return strings.Join(parts, " ")
 }
 
 func isMissingDataError(err error) bool {
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestApplyPatchSpecSharedInputs applies one patch from many goroutines against the same inputs
+// map, which must be left untouched. Run with -race.
+func TestApplyPatchSpecSharedInputs(t *testing.T) {
+	t.Parallel()
+
+	r := NewRenderer(template.NewEngine())
+	spec := types.PatchSpec{
+		ForEach: "${spec.volumes}",
+		Var:     "volume",
+		Target: types.TargetSpec{
+			Kind:  "Deployment",
+			Where: `${resource.metadata.name == metadata.name}`,
+		},
+		Operations: []types.JSONPatchOperation{{
+			Op:    "add",
+			Path:  "/metadata/labels/${volume}",
+			Value: "${resource.metadata.name}",
+		}},
+	}
+	inputs := map[string]any{
+		"metadata": map[string]any{"name": "web"},
+		"spec":     map[string]any{"volumes": []any{"data", "logs"}},
+	}
+	want := map[string]any{
+		"metadata": map[string]any{"name": "web"},
+		"spec":     map[string]any{"volumes": []any{"data", "logs"}},
+	}
+
+	const workers = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resources := []map[string]any{{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web", "labels": map[string]any{}},
+			}}
+			matched, err := r.applyPatchSpec(resources, spec, inputs, nil, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if matched != 2 {
+				errs <- fmt.Errorf("matched = %d, want 2", matched)
+				return
+			}
+			labels := resources[0]["metadata"].(map[string]any)["labels"]
+			if diff := cmp.Diff(map[string]any{"data": "web", "logs": "web"}, labels); diff != "" {
+				errs <- fmt.Errorf("labels mismatch (-want +got):\n%s", diff)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if diff := cmp.Diff(want, inputs); diff != "" {
+		t.Errorf("inputs were modified (-want +got):\n%s", diff)
+	}
+}
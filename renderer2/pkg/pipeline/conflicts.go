@@ -0,0 +1,254 @@
+package pipeline
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/jsonpointer"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// ConflictMode controls what happens when two addon instances write different values to the same
+// path of a resource.
+type ConflictMode string
+
+const (
+	// ConflictIgnore lets the last addon win silently.
+	ConflictIgnore ConflictMode = ""
+	// ConflictWarn reports every conflict as an AddonConflict warning event.
+	ConflictWarn ConflictMode = "warn"
+	// ConflictFail fails the render with a *ConflictError.
+	ConflictFail ConflictMode = "error"
+)
+
+// ParseConflictMode converts a user-supplied mode; "ignore" and "" both disable detection.
+func ParseConflictMode(value string) (ConflictMode, error) {
+	switch value {
+	case "", "ignore":
+		return ConflictIgnore, nil
+	case string(ConflictWarn):
+		return ConflictWarn, nil
+	case string(ConflictFail):
+		return ConflictFail, nil
+	default:
+		return ConflictIgnore, fmt.Errorf("unknown conflict mode %q (want ignore, warn or error)", value)
+	}
+}
+
+// AddonRef identifies an addon instance.
+type AddonRef struct {
+	Addon    string
+	Instance string
+}
+
+func (a AddonRef) String() string {
+	return fmt.Sprintf("%s (instance %s)", a.Addon, a.Instance)
+}
+
+// Conflict records a second addon instance overwriting a value another one wrote. Values are nil
+// when the path was removed.
+type Conflict struct {
+	// Resource is "<kind> <namespace>/<name>", or "<kind> <name>" without namespace.
+	Resource    string
+	Path        string
+	First       AddonRef
+	FirstValue  any
+	Second      AddonRef
+	SecondValue any
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s %s: %s set %s, then %s set %s",
+		c.Resource, c.Path, c.First, describeValue(c.FirstValue), c.Second, describeValue(c.SecondValue))
+}
+
+// ConflictError lists every conflict of a render.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		lines[i] = conflict.String()
+	}
+	return fmt.Sprintf("%d addon conflict(s):\n  %s", len(e.Conflicts), strings.Join(lines, "\n  "))
+}
+
+// Provenance remembers which addon instance last wrote every path of every resource during one
+// render. It is not safe for concurrent use; create one per render.
+type Provenance struct {
+	writes    map[string]map[string]write
+	conflicts []Conflict
+}
+
+type write struct {
+	owner AddonRef
+	value any
+}
+
+// NewProvenance creates an empty tracker.
+func NewProvenance() *Provenance {
+	return &Provenance{writes: map[string]map[string]write{}}
+}
+
+// Conflicts returns the conflicts recorded so far, in detection order.
+func (p *Provenance) Conflicts() []Conflict {
+	return p.conflicts
+}
+
+// Report applies mode to the recorded conflicts: warnings go to sink, errors are returned.
+func (p *Provenance) Report(mode ConflictMode, sink events.Sink, component *types.Component) error {
+	if p == nil || len(p.conflicts) == 0 {
+		return nil
+	}
+	switch mode {
+	case ConflictWarn:
+		for _, conflict := range p.conflicts {
+			events.Warningf(sink, component, events.ReasonAddonConflict, "addon conflict on %s", conflict)
+		}
+	case ConflictFail:
+		return &ConflictError{Conflicts: append([]Conflict(nil), p.conflicts...)}
+	}
+	return nil
+}
+
+// record attributes the differences between before and after to owner.
+func (p *Provenance) record(owner AddonRef, before, after map[string]any) {
+	resource := describeResource(after)
+	writes := p.writes[resource]
+	if writes == nil {
+		writes = map[string]write{}
+		p.writes[resource] = writes
+	}
+
+	diffValues("", before, after, func(path string, value any) {
+		for _, existing := range relatedPaths(writes, path) {
+			previous := writes[existing]
+			// The write replaces whatever was recorded above or below it.
+			delete(writes, existing)
+			if previous.owner == owner || (existing == path && reflect.DeepEqual(previous.value, value)) {
+				continue
+			}
+			p.conflicts = append(p.conflicts, Conflict{
+				Resource:    resource,
+				Path:        existing,
+				First:       previous.owner,
+				FirstValue:  previous.value,
+				Second:      owner,
+				SecondValue: valueAt(after, existing),
+			})
+		}
+		writes[path] = write{owner: owner, value: copyValue(value)}
+	})
+}
+
+// relatedPaths returns the recorded paths equal to, above or below path, sorted.
+func relatedPaths(writes map[string]write, path string) []string {
+	var related []string
+	for existing := range writes {
+		if existing == path || strings.HasPrefix(existing, path+"/") || strings.HasPrefix(path, existing+"/") {
+			related = append(related, existing)
+		}
+	}
+	sort.Strings(related)
+	return related
+}
+
+// missing marks a value that did not exist before a patch.
+type missing struct{}
+
+// diffValues calls emit with the JSON pointer and new value of every leaf that differs between
+// before and after, and with nil for removed paths.
+func diffValues(path string, before, after any, emit func(path string, value any)) {
+	switch a := after.(type) {
+	case map[string]any:
+		b, _ := before.(map[string]any)
+		keys := make([]string, 0, len(a))
+		for key := range a {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			var old any = missing{}
+			if value, ok := b[key]; ok {
+				old = value
+			}
+			diffValues(path+"/"+jsonpointer.Escape(key), old, a[key], emit)
+		}
+		var removed []string
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				removed = append(removed, key)
+			}
+		}
+		sort.Strings(removed)
+		for _, key := range removed {
+			emit(path+"/"+jsonpointer.Escape(key), nil)
+		}
+	case []any:
+		b, _ := before.([]any)
+		for i, value := range a {
+			var old any = missing{}
+			if i < len(b) {
+				old = b[i]
+			}
+			diffValues(path+"/"+strconv.Itoa(i), old, value, emit)
+		}
+		for i := len(a); i < len(b); i++ {
+			emit(path+"/"+strconv.Itoa(i), nil)
+		}
+	default:
+		if _, ok := before.(missing); ok || !reflect.DeepEqual(before, after) {
+			emit(path, after)
+		}
+	}
+}
+
+// valueAt returns the value at a JSON pointer, or nil when it does not exist.
+func valueAt(obj any, pointer string) any {
+	value, err := jsonpointer.Resolve(obj, pointer)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+func describeResource(resource map[string]any) string {
+	metadata, _ := resource["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	if namespace, _ := metadata["namespace"].(string); namespace != "" {
+		name = namespace + "/" + name
+	}
+	return fmt.Sprintf("%v %s", resource["kind"], name)
+}
+
+func describeValue(value any) string {
+	if value == nil {
+		return "<removed>"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func copyValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, item := range typed {
+			result[key] = copyValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			result[i] = copyValue(item)
+		}
+		return result
+	default:
+		return typed
+	}
+}
@@ -0,0 +1,91 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProvenanceConflicts(t *testing.T) {
+	t.Parallel()
+
+	pvc := AddonRef{Addon: "pvc", Instance: "data"}
+	sidecar := AddonRef{Addon: "sidecar", Instance: "logs"}
+	type step struct {
+		owner AddonRef
+		patch func(resource map[string]any)
+	}
+	spec := func(resource map[string]any) map[string]any { return resource["spec"].(map[string]any) }
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  []Conflict
+	}{
+		{
+			name: "different values on one path",
+			steps: []step{
+				{pvc, func(r map[string]any) { spec(r)["strategy"] = "Recreate" }},
+				{sidecar, func(r map[string]any) { spec(r)["strategy"] = "RollingUpdate" }},
+			},
+			want: []Conflict{{
+				Resource: "Deployment prod/web", Path: "/spec/strategy",
+				First: pvc, FirstValue: "Recreate", Second: sidecar, SecondValue: "RollingUpdate",
+			}},
+		},
+		{
+			name: "same value, sibling paths and appends are not conflicts",
+			steps: []step{
+				{pvc, func(r map[string]any) {
+					spec(r)["strategy"] = "Recreate"
+					spec(r)["volumes"] = []any{map[string]any{"name": "data"}}
+				}},
+				{sidecar, func(r map[string]any) {
+					spec(r)["strategy"] = "Recreate"
+					spec(r)["paused"] = true
+					spec(r)["volumes"] = append(spec(r)["volumes"].([]any), map[string]any{"name": "logs"})
+				}},
+			},
+		},
+		{
+			name: "replacing a patched object",
+			steps: []step{
+				{pvc, func(r map[string]any) { spec(r)["volume"] = map[string]any{"name": "data"} }},
+				{sidecar, func(r map[string]any) { spec(r)["volume"] = "none" }},
+			},
+			want: []Conflict{{
+				Resource: "Deployment prod/web", Path: "/spec/volume/name",
+				First: pvc, FirstValue: "data", Second: sidecar, SecondValue: nil,
+			}},
+		},
+		{
+			name: "an addon may overwrite itself",
+			steps: []step{
+				{pvc, func(r map[string]any) { spec(r)["replicas"] = 2 }},
+				{pvc, func(r map[string]any) { spec(r)["replicas"] = 3 }},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resource := map[string]any{
+				"kind":     "Deployment",
+				"metadata": map[string]any{"name": "web", "namespace": "prod"},
+				"spec":     map[string]any{"replicas": 1},
+			}
+			provenance := NewProvenance()
+			for _, step := range tt.steps {
+				before := copyValue(resource).(map[string]any)
+				step.patch(resource)
+				provenance.record(step.owner, before, resource)
+			}
+			if diff := cmp.Diff(tt.want, provenance.Conflicts()); diff != "" {
+				t.Errorf("Conflicts() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderCreateTemplates(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		creates string
+		spec    map[string]any
+		want    []map[string]any
+	}{
+		{
+			name: "shorthand",
+			creates: `
+- apiVersion: v1
+  kind: PersistentVolumeClaim
+  metadata:
+    name: ${metadata.name}-data
+`,
+			want: []map[string]any{
+				{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-data"}},
+			},
+		},
+		{
+			name: "includeWhen false skips the create",
+			creates: `
+- id: pvc
+  includeWhen: ${spec.persistence}
+  template:
+    kind: PersistentVolumeClaim
+    metadata:
+      name: ${metadata.name}-data
+`,
+			spec: map[string]any{"persistence": false},
+		},
+		{
+			name: "includeWhen true renders the create",
+			creates: `
+- id: pvc
+  includeWhen: ${spec.persistence}
+  template:
+    kind: PersistentVolumeClaim
+    metadata:
+      name: ${metadata.name}-data
+`,
+			spec: map[string]any{"persistence": true},
+			want: []map[string]any{
+				{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-data"}},
+			},
+		},
+		{
+			name: "forEach with var",
+			creates: `
+- id: claims
+  forEach: ${spec.volumes}
+  var: volume
+  template:
+    kind: PersistentVolumeClaim
+    metadata:
+      name: ${metadata.name}-${volume}
+`,
+			spec: map[string]any{"volumes": []any{"data", "logs"}},
+			want: []map[string]any{
+				{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-data"}},
+				{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-logs"}},
+			},
+		},
+		{
+			name: "multi-document YAML string",
+			creates: `
+- id: rbac
+  template: |
+    apiVersion: v1
+    kind: ServiceAccount
+    metadata:
+      name: ${metadata.name}
+    ---
+    ---
+    apiVersion: rbac.authorization.k8s.io/v1
+    kind: RoleBinding
+    metadata:
+      name: ${metadata.name}-view
+`,
+			want: []map[string]any{
+				{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": map[string]any{"name": "web"}},
+				{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding", "metadata": map[string]any{"name": "web-view"}},
+			},
+		},
+		{
+			name: "list-producing expression",
+			creates: `
+- '${spec.queues.map(q, {"kind": "Queue", "metadata": {"name": metadata.name + "-" + q}})}'
+`,
+			spec: map[string]any{"queues": []any{"orders", "emails"}},
+			want: []map[string]any{
+				{"kind": "Queue", "metadata": map[string]any{"name": "web-orders"}},
+				{"kind": "Queue", "metadata": map[string]any{"name": "web-emails"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var creates []types.CreateTemplate
+			if err := yaml.Unmarshal([]byte(tt.creates), &creates); err != nil {
+				t.Fatalf("failed to decode creates: %v", err)
+			}
+			inputs := map[string]any{
+				"metadata": map[string]any{"name": "web"},
+				"spec":     tt.spec,
+			}
+			got, err := NewRenderer(template.NewEngine()).renderCreateTemplates("pvc/data", creates, inputs)
+			if err != nil {
+				t.Fatalf("renderCreateTemplates() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("renderCreateTemplates() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRenderCreateTemplatesRejectsNonObjects(t *testing.T) {
+	t.Parallel()
+
+	for creates, want := range map[string]string{
+		`- '${[{"kind": "Queue"}, "orders"]}'`: "addon create template pvc/data/create-0[1] must render to an object, got string",
+		"- \"kind: Queue\\n---\\n- a\\n\"":     "addon create template pvc/data/create-0[1] must render to an object, got []interface {}",
+		"- ${1}":                               "must render to an object, a list of objects or YAML documents, got int64",
+		"- 'kind: [Queue'":                     "addon create template pvc/data/create-0 rendered invalid YAML",
+	} {
+		var templates []types.CreateTemplate
+		if err := yaml.Unmarshal([]byte(creates), &templates); err != nil {
+			t.Fatalf("failed to decode %q: %v", creates, err)
+		}
+		_, err := NewRenderer(template.NewEngine()).renderCreateTemplates("pvc/data", templates, map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("renderCreateTemplates(%q) error = %v, want %q", creates, err, want)
+		}
+	}
+}
+
+func TestCreatesAreAttributedToTheirAddon(t *testing.T) {
+	t.Parallel()
+
+	var logging, tuning types.Addon
+	for doc, addon := range map[string]*types.Addon{
+		`
+metadata: {name: logging}
+spec:
+  creates:
+    - |
+      kind: ConfigMap
+      metadata: {name: fluent-bit}
+      data: {level: info}
+      ---
+      kind: ConfigMap
+      metadata: {name: parsers}
+`: &logging,
+		`
+metadata: {name: tuning}
+spec:
+  patches:
+    - target: {kind: ConfigMap, name: fluent-bit}
+      operations:
+        - {op: replace, path: /data/level, value: debug}
+`: &tuning,
+	} {
+		if err := yaml.Unmarshal([]byte(doc), addon); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewRenderer(template.NewEngine())
+	component := &types.Component{Metadata: types.Metadata{Name: "web"}}
+	provenance := NewProvenance()
+	resources, err := r.ApplyAddonTracked(nil, &logging, types.AddonInstance{Name: "logging", InstanceID: "a"}, component, nil, nil, nil, provenance)
+	if err != nil {
+		t.Fatalf("ApplyAddonTracked(logging) error = %v", err)
+	}
+	if len(resources) != 2 {
+		t.Fatalf("logging created %d resources, want 2", len(resources))
+	}
+	if _, err := r.ApplyAddonTracked(resources, &tuning, types.AddonInstance{Name: "tuning", InstanceID: "b"}, component, nil, nil, nil, provenance); err != nil {
+		t.Fatalf("ApplyAddonTracked(tuning) error = %v", err)
+	}
+	want := []Conflict{{
+		Resource: "ConfigMap fluent-bit", Path: "/data/level",
+		First: AddonRef{Addon: "logging", Instance: "a"}, FirstValue: "info",
+		Second: AddonRef{Addon: "tuning", Instance: "b"}, SecondValue: "debug",
+	}}
+	if diff := cmp.Diff(want, provenance.Conflicts()); diff != "" {
+		t.Errorf("Conflicts() mismatch (-want +got):\n%s", diff)
+	}
+}
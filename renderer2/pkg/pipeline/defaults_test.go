@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDefaultsFillProvidedListItems(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    types:
+      Container:
+        name: string
+        image: string | default=nginx
+        port: integer | default=8080
+        resources:
+          cpu: string | default=100m
+    parameters:
+      containers: '[]Container'
+  resources:
+    - id: deployment
+      template:
+        kind: Deployment
+        spec:
+          containers: ${spec.containers}
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	// As in the API server, objects an item omits without a default of their own stay absent.
+	component := &types.Component{Spec: types.ComponentSpec{Parameters: map[string]any{"containers": []any{
+		map[string]any{"name": "app", "image": "shop:1.2"},
+		map[string]any{"name": "proxy", "port": 9901, "resources": map[string]any{}},
+	}}}}
+
+	got, err := NewRenderer(template.NewEngine()).RenderComponentResources(&definition, component, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderComponentResources() error = %v", err)
+	}
+	want := []map[string]any{{"kind": "Deployment", "spec": map[string]any{"containers": []any{
+		map[string]any{"name": "app", "image": "shop:1.2", "port": int64(8080)},
+		map[string]any{"name": "proxy", "image": "nginx", "port": 9901, "resources": map[string]any{"cpu": "100m"}},
+	}}}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderComponentResources() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,148 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFieldsWhen(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resources string
+		spec      map[string]any
+		want      []map[string]any
+		wantErr   string
+	}{
+		{
+			name: "false conditions drop fields and true ones keep them",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    spec:
+      replicas: ${spec.replicas}
+      paused: true
+  fieldsWhen:
+    /spec/replicas: ${!spec.autoscaling}
+    /spec/paused: ${spec.autoscaling}
+`,
+			spec: map[string]any{"replicas": 2, "autoscaling": true},
+			want: []map[string]any{{"kind": "Deployment", "spec": map[string]any{"paused": true}}},
+		},
+		{
+			name: "missing data drops the field",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    metadata:
+      annotations:
+        team: ${spec.team}
+  fieldsWhen:
+    /metadata/annotations: ${spec.owner.notify}
+`,
+			spec: map[string]any{"team": "payments"},
+			want: []map[string]any{{"kind": "Deployment", "metadata": map[string]any{}}},
+		},
+		{
+			name: "array elements are dropped without shifting the others",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    containers:
+      - name: app
+      - name: debug
+      - name: proxy
+      - name: metrics
+  fieldsWhen:
+    /containers/1: ${spec.debug}
+    /containers/3: ${spec.metrics}
+    /containers/2: ${true}
+`,
+			spec: map[string]any{"debug": false, "metrics": false},
+			want: []map[string]any{{"kind": "Deployment", "containers": []any{
+				map[string]any{"name": "app"},
+				map[string]any{"name": "proxy"},
+			}}},
+		},
+		{
+			name: "nested and escaped pointers with forEach items",
+			resources: `
+- id: config
+  forEach: ${spec.names}
+  var: name
+  template:
+    kind: ConfigMap
+    metadata:
+      name: ${name}
+      annotations:
+        example.com/debug: "true"
+  fieldsWhen:
+    /metadata/annotations/example.com~1debug: ${name == "dev"}
+    /metadata/labels: ${false}
+`,
+			spec: map[string]any{"names": []any{"dev", "prod"}},
+			want: []map[string]any{
+				{"kind": "ConfigMap", "metadata": map[string]any{"name": "dev", "annotations": map[string]any{"example.com/debug": "true"}}},
+				{"kind": "ConfigMap", "metadata": map[string]any{"name": "prod", "annotations": map[string]any{}}},
+			},
+		},
+		{
+			name: "non-boolean condition",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+  fieldsWhen:
+    /kind: ${spec.replicas}
+`,
+			spec:    map[string]any{"replicas": 2},
+			wantErr: "failed to evaluate fieldsWhen for resource deployment: /kind: condition must evaluate to bool, got int64",
+		},
+		{
+			name: "invalid pointer",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+  fieldsWhen:
+    spec.replicas: ${false}
+`,
+			wantErr: `JSON pointer "spec.replicas" must start with /`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var templates []types.ResourceTemplate
+			if err := yaml.Unmarshal([]byte(tt.resources), &templates); err != nil {
+				t.Fatalf("yaml.Unmarshal() error = %v", err)
+			}
+			r := NewRenderer(template.NewEngine())
+			got, err := r.renderResourceTemplates(templates, map[string]any{"spec": tt.spec})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderResourceTemplates() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderResourceTemplates() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("resources mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestFragments(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      port: integer | default=8080
+  fragments:
+    container:
+      name: ${args.name}
+      ports:
+        - containerPort: ${args.port}
+      securityContext:
+        runAsNonRoot: true
+  resources:
+    - id: deployment
+      template:
+        kind: Deployment
+        spec:
+          containers:
+            - '${fragment("container", {"name": "app", "port": spec.port})}'
+    - id: job
+      template:
+        kind: Job
+        spec:
+          containers:
+            - '${fragment("container", {"name": "migrate", "port": spec.port + 1})}'
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	component := &types.Component{Metadata: types.Metadata{Name: "checkout"}, Spec: types.ComponentSpec{ComponentType: "web-app"}}
+
+	got, err := NewRenderer(template.NewEngine()).RenderComponentResources(&definition, component, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderComponentResources() error = %v", err)
+	}
+	container := func(name string, port int64) map[string]any {
+		return map[string]any{
+			"name":            name,
+			"ports":           []any{map[string]any{"containerPort": port}},
+			"securityContext": map[string]any{"runAsNonRoot": true},
+		}
+	}
+	want := []map[string]any{
+		{"kind": "Deployment", "spec": map[string]any{"containers": []any{container("app", 8080)}}},
+		{"kind": "Job", "spec": map[string]any{"containers": []any{container("migrate", 8081)}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderComponentResources() mismatch (-want +got):\n%s", diff)
+	}
+}
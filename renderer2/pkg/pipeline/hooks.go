@@ -0,0 +1,68 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// HookContext describes the render a hook participates in. Inputs holds the CEL inputs of the
+// component (metadata, spec, build, ...); pre-render hooks may mutate it in place.
+type HookContext struct {
+	Definition  *types.ComponentTypeDefinition
+	Component   *types.Component
+	EnvSettings *types.EnvSettings
+	Inputs      map[string]any
+}
+
+// Hooks are compiled-in extensions that run around a component render.
+//
+// Hooks run in registration order. PreRender runs after the component inputs are assembled and
+// before any template is evaluated; it runs for every pass that needs the inputs (resources and
+// artifacts), so it must be deterministic. PostRender runs once after all addons were applied and
+// returns the resources to pass to the next hook. The first error aborts the render and is
+// returned wrapped with the hook name; later hooks do not run.
+type Hooks interface {
+	Name() string
+	PreRender(ctx *HookContext) error
+	PostRender(ctx *HookContext, resources []map[string]any) ([]map[string]any, error)
+}
+
+// NopHooks implements Hooks with no-op methods so hooks only need to define the stages they use.
+type NopHooks struct{}
+
+func (NopHooks) PreRender(*HookContext) error { return nil }
+
+func (NopHooks) PostRender(_ *HookContext, resources []map[string]any) ([]map[string]any, error) {
+	return resources, nil
+}
+
+func (r *RendererCoordinates) runPreRenderHooks(ctx *HookContext) error {
+	for _, hook := range r.Hooks {
+		if err := hook.PreRender(ctx); err != nil {
+			return fmt.Errorf("pre-render hook %s failed: %w", hook.Name(), err)
+		}
+	}
+	return nil
+}
+
+// RunPostRenderHooks passes the fully rendered resources of a component through every registered hook.
+func (r *RendererCoordinates) RunPostRenderHooks(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	resources []map[string]any,
+) ([]map[string]any, error) {
+	if len(r.Hooks) == 0 {
+		return resources, nil
+	}
+	ctx := &HookContext{Definition: definition, Component: component, EnvSettings: envSettings}
+	for _, hook := range r.Hooks {
+		var err error
+		resources, err = hook.PostRender(ctx, resources)
+		if err != nil {
+			return nil, fmt.Errorf("post-render hook %s failed: %w", hook.Name(), err)
+		}
+	}
+	return resources, nil
+}
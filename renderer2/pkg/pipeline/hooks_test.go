@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type recordingHook struct {
+	NopHooks
+	name  string
+	calls *[]string
+	err   error
+}
+
+func (h recordingHook) Name() string { return h.name }
+
+func (h recordingHook) PreRender(ctx *HookContext) error {
+	*h.calls = append(*h.calls, "pre:"+h.name)
+	ctx.Inputs[h.name] = true
+	return h.err
+}
+
+func (h recordingHook) PostRender(_ *HookContext, resources []map[string]any) ([]map[string]any, error) {
+	*h.calls = append(*h.calls, "post:"+h.name)
+	if h.err != nil {
+		return nil, h.err
+	}
+	return append(resources, map[string]any{"kind": h.name}), nil
+}
+
+func TestHooksRunInOrder(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	r := &RendererCoordinates{Hooks: []Hooks{
+		recordingHook{name: "first", calls: &calls},
+		recordingHook{name: "second", calls: &calls},
+	}}
+
+	ctx := &HookContext{Inputs: map[string]any{}}
+	if err := r.runPreRenderHooks(ctx); err != nil {
+		t.Fatalf("runPreRenderHooks() error = %v", err)
+	}
+	resources, err := r.RunPostRenderHooks(nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RunPostRenderHooks() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]string{"pre:first", "pre:second", "post:first", "post:second"}, calls); diff != "" {
+		t.Errorf("call order mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]any{"first": true, "second": true}, ctx.Inputs); diff != "" {
+		t.Errorf("inputs mismatch (-want +got):\n%s", diff)
+	}
+	want := []map[string]any{{"kind": "first"}, {"kind": "second"}}
+	if diff := cmp.Diff(want, resources); diff != "" {
+		t.Errorf("resources mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestHookErrorStopsLaterHooks(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	var calls []string
+	r := &RendererCoordinates{Hooks: []Hooks{
+		recordingHook{name: "failing", calls: &calls, err: errBoom},
+		recordingHook{name: "after", calls: &calls},
+	}}
+
+	err := r.runPreRenderHooks(&HookContext{Inputs: map[string]any{}})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("runPreRenderHooks() error = %v, want %v", err, errBoom)
+	}
+	if _, err := r.RunPostRenderHooks(nil, nil, nil, nil); !errors.Is(err, errBoom) {
+		t.Fatalf("RunPostRenderHooks() error = %v, want %v", err, errBoom)
+	}
+	if diff := cmp.Diff([]string{"pre:failing", "post:failing"}, calls); diff != "" {
+		t.Errorf("call order mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// TemplateResult holds the resources rendered from a single resource template. Templates excluded
+// by includeWhen produce a result without resources so they can be skipped on re-render too.
+type TemplateResult struct {
+	ID        string
+	Resources []map[string]any
+}
+
+// RenderTemplates renders each resource template of the definition separately so the results can
+// later be refreshed with RerenderTemplates.
+func (r *RendererCoordinates) RenderTemplates(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+) ([]TemplateResult, error) {
+	return r.RerenderTemplates(nil, nil, definition, component, envSettings, additionalCtx, workload)
+}
+
+// RerenderTemplates re-renders only the templates whose expressions reference one of the changed
+// input paths (e.g. "spec.replicas" when only that env override changed) and reuses the previous
+// results for all others. A changed path affects templates reading it, any of its parents, or any
+// of its children. Templates without a previous result are always rendered, and so are templates
+// reading resources.<id> of a template rendered again.
+func (r *RendererCoordinates) RerenderTemplates(
+	previous []TemplateResult,
+	changed []string,
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+	additionalCtx *types.AdditionalContext,
+	workload map[string]any,
+) ([]TemplateResult, error) {
+	typed, definition, inputs, err := r.componentInputs(definition, component, envSettings, additionalCtx, workload, r.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	reusable := make(map[string]TemplateResult, len(previous))
+	for _, result := range previous {
+		reusable[result.ID] = result
+	}
+
+	// Reused results carry the common metadata they were rendered with, so a change it reads
+	// affects every template.
+	commonAffected, err := r.expressionsAffected([]any{definition.Spec.CommonMetadata.Template()}, changed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze commonMetadata: %w", err)
+	}
+
+	shared, err := typed.renderCommonMetadata(definition.Spec.CommonMetadata, inputs)
+	if err != nil {
+		return nil, err
+	}
+	return typed.renderTemplateResults(definition.Spec.Resources, inputs, shared, func(tmpl types.ResourceTemplate) (TemplateResult, bool, error) {
+		result, ok := reusable[tmpl.ID]
+		if !ok || commonAffected {
+			return TemplateResult{}, false, nil
+		}
+		affected, err := r.templateAffected(tmpl, changed)
+		if err != nil || affected {
+			return TemplateResult{}, false, err
+		}
+		return result, true, nil
+	})
+}
+
+// templateAffected reports whether any expression of the template (includeWhen, forEach,
+// fieldsWhen, or the template body) reads one of the changed paths.
+func (r *RendererCoordinates) templateAffected(tmpl types.ResourceTemplate, changed []string) (bool, error) {
+	expressions := []any{tmpl.IncludeWhen, tmpl.ForEach, tmpl.Template}
+	for _, condition := range tmpl.FieldsWhen {
+		expressions = append(expressions, condition)
+	}
+	affected, err := r.expressionsAffected(expressions, changed)
+	if err != nil {
+		return false, fmt.Errorf("failed to analyze resource %s: %w", tmpl.ID, err)
+	}
+	return affected, nil
+}
+
+// expressionsAffected reports whether the expressions read one of the changed paths.
+func (r *RendererCoordinates) expressionsAffected(expressions []any, changed []string) (bool, error) {
+	if len(changed) == 0 {
+		return false, nil
+	}
+	references, err := r.TemplateEngine.References(expressions)
+	if err != nil {
+		return false, err
+	}
+	for _, ref := range references {
+		for _, path := range changed {
+			if template.PathsOverlap(ref, path) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// FlattenTemplateResults concatenates the resources of all template results in order.
+func FlattenTemplateResults(results []TemplateResult) []map[string]any {
+	var resources []map[string]any
+	for _, result := range results {
+		resources = append(resources, result.Resources...)
+	}
+	return resources
+}
@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCommonMetadata(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      team: string
+    envOverrides:
+      replicas: integer | default=1
+  commonMetadata:
+    labels:
+      app: ${metadata.name}
+      tier: web
+    annotations:
+      example.com/team: ${spec.team}
+  resources:
+    - id: deployment
+      template:
+        kind: Deployment
+        metadata:
+          name: ${metadata.name}
+          labels:
+            tier: backend
+        spec:
+          replicas: ${spec.replicas}
+    - id: service
+      template:
+        kind: Service
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	component := &types.Component{Metadata: types.Metadata{Name: "checkout"}, Spec: types.ComponentSpec{ComponentType: "web-app", Parameters: map[string]any{"team": "payments"}}}
+	envSettings := &types.EnvSettings{Spec: types.EnvSettingsSpec{Overrides: map[string]any{"replicas": 2}}}
+
+	r := NewRenderer(template.NewEngine())
+	got, err := r.RenderComponentResources(&definition, component, envSettings, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderComponentResources() error = %v", err)
+	}
+	annotations := map[string]any{"example.com/team": "payments"}
+	want := []map[string]any{
+		{
+			"kind": "Deployment",
+			"metadata": map[string]any{
+				"name":        "checkout",
+				"labels":      map[string]any{"app": "checkout", "tier": "backend"},
+				"annotations": annotations,
+			},
+			"spec": map[string]any{"replicas": int64(2)},
+		},
+		{
+			"kind": "Service",
+			"metadata": map[string]any{
+				"labels":      map[string]any{"app": "checkout", "tier": "web"},
+				"annotations": annotations,
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("RenderComponentResources() mismatch (-want +got):\n%s", diff)
+	}
+
+	// A change read only by commonMetadata rerenders every template.
+	previous := []TemplateResult{{ID: "deployment", Resources: got[:1]}, {ID: "service", Resources: got[1:]}}
+	component.Spec.Parameters["team"] = "search"
+	results, err := r.RerenderTemplates(previous, []string{"spec.team"}, &definition, component, envSettings, nil, nil)
+	if err != nil {
+		t.Fatalf("RerenderTemplates() error = %v", err)
+	}
+	for _, resource := range FlattenTemplateResults(results) {
+		if team := resource["metadata"].(map[string]any)["annotations"].(map[string]any)["example.com/team"]; team != "search" {
+			t.Errorf("%s team annotation = %v, want search", resource["kind"], team)
+		}
+	}
+
+	definition.Spec.CommonMetadata.Labels["replicas"] = "${spec.replicas}"
+	if _, err := r.RenderComponentResources(&definition, component, envSettings, nil, nil); err == nil || !strings.Contains(err.Error(), "commonMetadata labels replicas must render to a string, got int64") {
+		t.Errorf("RenderComponentResources() with a non-string label error = %v", err)
+	}
+}
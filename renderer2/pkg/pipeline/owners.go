@@ -0,0 +1,156 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// OwnerMode controls how the owner and component recorded in EnvSettings are attached to rendered
+// resources.
+type OwnerMode string
+
+const (
+	// OwnerNone leaves rendered resources untouched.
+	OwnerNone OwnerMode = ""
+	// OwnerAnnotations records the owner and component as annotations.
+	OwnerAnnotations OwnerMode = "annotations"
+	// OwnerReferences emits metadata.ownerReferences for refs that carry a UID and falls back to
+	// annotations for the rest.
+	OwnerReferences OwnerMode = "references"
+)
+
+// Annotations written for owner refs that cannot become ownerReferences.
+const (
+	AnnotationOwner     = "openchoreo.dev/owner"
+	AnnotationComponent = "openchoreo.dev/component"
+)
+
+const (
+	componentAPIVersion = "openchoreo.dev/v1alpha1"
+	componentKind       = "Component"
+)
+
+// ParseOwnerMode converts a user-supplied mode; "none" and "" both disable owner wiring.
+func ParseOwnerMode(value string) (OwnerMode, error) {
+	switch value {
+	case "", "none":
+		return OwnerNone, nil
+	case string(OwnerAnnotations):
+		return OwnerAnnotations, nil
+	case string(OwnerReferences):
+		return OwnerReferences, nil
+	default:
+		return OwnerNone, fmt.Errorf("unknown owner mode %q (want none, annotations or references)", value)
+	}
+}
+
+// ApplyOwners attaches EnvSettings.spec.owner and spec.componentRef to every resource according to
+// mode. The componentRef defaults to the openchoreo Component kind; the owner needs an explicit
+// apiVersion and kind before it can become an ownerReference. Run it after ApplyScopes: a
+// cluster-scoped resource cannot be owned by a namespaced object, so it gets the annotation instead.
+func ApplyOwners(resources []RenderedResource, envSettings *types.EnvSettings, mode OwnerMode) error {
+	if mode == OwnerNone || envSettings == nil {
+		return nil
+	}
+	if mode != OwnerAnnotations && mode != OwnerReferences {
+		return fmt.Errorf("unknown owner mode %q", mode)
+	}
+
+	refs := []struct {
+		ref        *types.ComponentRef
+		annotation string
+		apiVersion string
+		kind       string
+		controller bool
+	}{
+		{envSettings.Spec.Owner, AnnotationOwner, "", "", false},
+		{envSettings.Spec.ComponentRef, AnnotationComponent, componentAPIVersion, componentKind, true},
+	}
+
+	for _, resource := range resources {
+		for _, r := range refs {
+			if r.ref == nil || r.ref.Name == "" {
+				continue
+			}
+			apiVersion := firstNonEmpty(r.ref.APIVersion, r.apiVersion)
+			kind := firstNonEmpty(r.ref.Kind, r.kind)
+			if mode == OwnerReferences && canOwn(r.ref, apiVersion, kind, resource) {
+				addOwnerReference(resource.Object, map[string]any{
+					"apiVersion": apiVersion,
+					"kind":       kind,
+					"name":       r.ref.Name,
+					"uid":        r.ref.UID,
+					"controller": r.controller,
+				})
+				continue
+			}
+			setAnnotation(resource.Object, r.annotation, refString(r.ref))
+		}
+	}
+	return nil
+}
+
+// canOwn reports whether ref can be expressed as an ownerReference on resource. Kubernetes rejects
+// owners without a UID, owners in another namespace and namespaced owners of cluster-scoped
+// resources (OwnerRefInvalidNamespace).
+func canOwn(ref *types.ComponentRef, apiVersion, kind string, resource RenderedResource) bool {
+	if ref.UID == "" || apiVersion == "" || kind == "" {
+		return false
+	}
+	if ref.Namespace == "" {
+		return true
+	}
+	if resource.Scope == ScopeCluster {
+		return false
+	}
+	metadata, _ := resource.Object["metadata"].(map[string]any)
+	namespace, _ := metadata["namespace"].(string)
+	return namespace == "" || namespace == ref.Namespace
+}
+
+func addOwnerReference(resource map[string]any, owner map[string]any) {
+	metadata := ensureMetadata(resource)
+	existing, _ := metadata["ownerReferences"].([]any)
+	for _, item := range existing {
+		if ref, ok := item.(map[string]any); ok && ref["uid"] == owner["uid"] {
+			return
+		}
+	}
+	metadata["ownerReferences"] = append(existing, owner)
+}
+
+func setAnnotation(resource map[string]any, key, value string) {
+	metadata := ensureMetadata(resource)
+	annotations, ok := metadata["annotations"].(map[string]any)
+	if !ok {
+		annotations = map[string]any{}
+		metadata["annotations"] = annotations
+	}
+	annotations[key] = value
+}
+
+func ensureMetadata(resource map[string]any) map[string]any {
+	metadata, ok := resource["metadata"].(map[string]any)
+	if !ok {
+		metadata = map[string]any{}
+		resource["metadata"] = metadata
+	}
+	return metadata
+}
+
+func refString(ref *types.ComponentRef) string {
+	if ref.Namespace == "" {
+		return ref.Name
+	}
+	return ref.Namespace + "/" + ref.Name
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
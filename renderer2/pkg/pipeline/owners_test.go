@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyOwners(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		mode         OwnerMode
+		spec         types.EnvSettingsSpec
+		namespace    string
+		scope        Scope
+		wantMetadata map[string]any
+	}{
+		{
+			name:         "disabled by default",
+			mode:         OwnerNone,
+			spec:         types.EnvSettingsSpec{ComponentRef: &types.ComponentRef{Name: "web", Namespace: "default"}},
+			wantMetadata: map[string]any{"name": "app"},
+		},
+		{
+			name: "annotations mode records both refs",
+			mode: OwnerAnnotations,
+			spec: types.EnvSettingsSpec{
+				Owner:        &types.ComponentRef{Name: "payments"},
+				ComponentRef: &types.ComponentRef{Name: "web", Namespace: "default", UID: "1234"},
+			},
+			wantMetadata: map[string]any{
+				"name": "app",
+				"annotations": map[string]any{
+					AnnotationOwner:     "payments",
+					AnnotationComponent: "default/web",
+				},
+			},
+		},
+		{
+			name: "references mode emits ownerReferences when a UID is supplied",
+			mode: OwnerReferences,
+			spec: types.EnvSettingsSpec{
+				Owner:        &types.ComponentRef{Name: "payments", UID: "abcd"},
+				ComponentRef: &types.ComponentRef{Name: "web", Namespace: "default", UID: "1234"},
+			},
+			namespace: "default",
+			wantMetadata: map[string]any{
+				"name":      "app",
+				"namespace": "default",
+				"annotations": map[string]any{
+					AnnotationOwner: "payments",
+				},
+				"ownerReferences": []any{
+					map[string]any{
+						"apiVersion": "openchoreo.dev/v1alpha1",
+						"kind":       "Component",
+						"name":       "web",
+						"uid":        "1234",
+						"controller": true,
+					},
+				},
+			},
+		},
+		{
+			name: "references mode falls back to annotations across namespaces",
+			mode: OwnerReferences,
+			spec: types.EnvSettingsSpec{
+				ComponentRef: &types.ComponentRef{Name: "web", Namespace: "default", UID: "1234"},
+			},
+			namespace: "prod",
+			wantMetadata: map[string]any{
+				"name":      "app",
+				"namespace": "prod",
+				"annotations": map[string]any{
+					AnnotationComponent: "default/web",
+				},
+			},
+		},
+		{
+			name: "references mode falls back to annotations for cluster-scoped resources",
+			mode: OwnerReferences,
+			spec: types.EnvSettingsSpec{
+				ComponentRef: &types.ComponentRef{Name: "web", Namespace: "default", UID: "1234"},
+			},
+			scope: ScopeCluster,
+			wantMetadata: map[string]any{
+				"name": "app",
+				"annotations": map[string]any{
+					AnnotationComponent: "default/web",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			metadata := map[string]any{"name": "app"}
+			if tt.namespace != "" {
+				metadata["namespace"] = tt.namespace
+			}
+			resource := map[string]any{"kind": "Deployment", "metadata": metadata}
+			envSettings := &types.EnvSettings{Spec: tt.spec}
+
+			if err := ApplyOwners([]RenderedResource{{Object: resource, Scope: tt.scope}}, envSettings, tt.mode); err != nil {
+				t.Fatalf("ApplyOwners() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantMetadata, resource["metadata"]); diff != "" {
+				t.Errorf("metadata mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseOwnerMode(t *testing.T) {
+	t.Parallel()
+
+	if mode, err := ParseOwnerMode("references"); err != nil || mode != OwnerReferences {
+		t.Errorf("ParseOwnerMode(references) = %q, %v", mode, err)
+	}
+	if _, err := ParseOwnerMode("labels"); err == nil {
+		t.Errorf("ParseOwnerMode(labels) succeeded, want error")
+	}
+}
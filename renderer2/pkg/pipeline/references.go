@@ -0,0 +1,260 @@
+package pipeline
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// resourcesVariable is the input under which base resource templates read the resources other
+// templates of the definition rendered, e.g. ${resources.service.metadata.name}. A template
+// without forEach is bound to its resource and a forEach template to the list of its instances;
+// templates excluded by includeWhen are absent.
+const resourcesVariable = "resources"
+
+// templateOrder returns the indices of templates in render order: every template renders after the
+// templates it reads through resources.<id>, and otherwise in declaration order. deps maps the ID
+// of each template to the IDs it reads; it is nil when no template reads resources, in which case
+// the variable is not bound at all. Reading resources as a whole depends on every other template.
+func (r *RendererCoordinates) templateOrder(templates []types.ResourceTemplate) ([]int, map[string][]string, error) {
+	ids := make(map[string]int, len(templates))
+	for _, tmpl := range templates {
+		ids[tmpl.ID]++
+	}
+
+	var deps map[string][]string
+	for _, tmpl := range templates {
+		if tmpl.ApplyPolicy != "" {
+			if _, err := ParseApplyPolicy(tmpl.ApplyPolicy); err != nil {
+				return nil, nil, fmt.Errorf("resource %s: %w", tmpl.ID, err)
+			}
+		}
+		for _, id := range tmpl.DependsOn {
+			switch {
+			case id == tmpl.ID:
+				return nil, nil, fmt.Errorf("resource %s depends on itself", tmpl.ID)
+			case ids[id] == 0:
+				return nil, nil, fmt.Errorf("resource %s depends on unknown resource %s", tmpl.ID, id)
+			}
+		}
+		expressions := []any{tmpl.IncludeWhen, tmpl.ForEach, tmpl.Template}
+		for _, condition := range tmpl.FieldsWhen {
+			expressions = append(expressions, condition)
+		}
+		references, err := r.TemplateEngine.References(expressions)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to analyze resource %s: %w", tmpl.ID, err)
+		}
+		read := map[string]bool{}
+		for _, ref := range references {
+			if ref != resourcesVariable && !strings.HasPrefix(ref, resourcesVariable+".") {
+				continue
+			}
+			id, _, _ := strings.Cut(strings.TrimPrefix(ref, resourcesVariable+"."), ".")
+			switch {
+			case ref == resourcesVariable:
+				for other := range ids {
+					if other != tmpl.ID {
+						read[other] = true
+					}
+				}
+			case id == tmpl.ID:
+				return nil, nil, fmt.Errorf("resource %s references itself through %s", tmpl.ID, ref)
+			case ids[id] == 0:
+				return nil, nil, fmt.Errorf("resource %s references unknown resource %s", tmpl.ID, id)
+			case ids[id] > 1:
+				return nil, nil, fmt.Errorf("resource %s references %s, which more than one template declares", tmpl.ID, id)
+			default:
+				read[id] = true
+			}
+		}
+		if len(read) == 0 {
+			continue
+		}
+		if deps == nil {
+			deps = map[string][]string{}
+		}
+		for id := range read {
+			deps[tmpl.ID] = append(deps[tmpl.ID], id)
+		}
+	}
+
+	order := make([]int, 0, len(templates))
+	done := make(map[string]bool, len(templates))
+	placed := make([]bool, len(templates))
+	for len(order) < len(templates) {
+		progressed := false
+		for i, tmpl := range templates {
+			if placed[i] || !allDone(deps[tmpl.ID], done) {
+				continue
+			}
+			order = append(order, i)
+			placed[i] = true
+			progressed = true
+			done[tmpl.ID] = true
+			break
+		}
+		if !progressed {
+			var cycle []string
+			for i, tmpl := range templates {
+				if !placed[i] {
+					cycle = append(cycle, tmpl.ID)
+				}
+			}
+			return nil, nil, fmt.Errorf("resources %s reference each other in a cycle", strings.Join(cycle, ", "))
+		}
+	}
+	return order, deps, nil
+}
+
+func allDone(ids []string, done map[string]bool) bool {
+	for _, id := range ids {
+		if !done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// renderTemplateResults renders templates in dependency order and returns their results in
+// declaration order. shared is the rendered commonMetadata, merged into each result before later
+// templates read it. reuse, when non-nil, returns a previous result to keep for a template; it is
+// only consulted when none of the templates the template reads was rendered again.
+func (r *RendererCoordinates) renderTemplateResults(
+	templates []types.ResourceTemplate,
+	inputs map[string]any,
+	shared map[string]any,
+	reuse func(types.ResourceTemplate) (TemplateResult, bool, error),
+) ([]TemplateResult, error) {
+	order, deps, err := r.templateOrder(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := inputs
+	var rendered map[string]any
+	if deps != nil {
+		rendered = map[string]any{}
+		scope = template.AcquireActivation(inputs)
+		defer template.ReleaseActivation(scope)
+		scope[resourcesVariable] = rendered
+	}
+
+	results := make([]TemplateResult, len(templates))
+	refreshed := map[string]bool{}
+	for _, i := range order {
+		tmpl := templates[i]
+		var (
+			result TemplateResult
+			reused bool
+		)
+		if reuse != nil && !anyRefreshed(deps[tmpl.ID], refreshed) {
+			if result, reused, err = reuse(tmpl); err != nil {
+				return nil, err
+			}
+		}
+		if !reused {
+			resources, err := r.renderResourceTemplate(tmpl, scope)
+			if err != nil {
+				return nil, err
+			}
+			mergeCommonMetadata(shared, resources)
+			result = TemplateResult{ID: tmpl.ID, Resources: resources}
+			refreshed[tmpl.ID] = true
+		}
+		results[i] = result
+
+		if rendered != nil {
+			bindRendered(rendered, tmpl, result.Resources)
+		}
+	}
+	annotateDependsOn(templates, results)
+	annotateApplyPolicy(templates, results)
+	return results, nil
+}
+
+// annotateDependsOn records the dependsOn of every template as the depends-on annotation of its
+// resources, naming the resources the listed templates rendered.
+func annotateDependsOn(templates []types.ResourceTemplate, results []TemplateResult) {
+	byID := map[string][]map[string]any{}
+	for _, result := range results {
+		byID[result.ID] = append(byID[result.ID], result.Resources...)
+	}
+	for i, tmpl := range templates {
+		var refs []string
+		for _, id := range tmpl.DependsOn {
+			for _, resource := range byID[id] {
+				refs = append(refs, ResourceName(resource))
+			}
+		}
+		if len(refs) == 0 {
+			continue
+		}
+		for _, resource := range results[i].Resources {
+			annotations := resourceAnnotations(resource)
+			existing := refs
+			if value, ok := annotations[AnnotationDependsOn].(string); ok && value != "" {
+				existing = append(strings.Split(value, ","), refs...)
+			}
+			annotations[AnnotationDependsOn] = strings.Join(slices.Compact(slices.Sorted(slices.Values(existing))), ",")
+		}
+	}
+}
+
+// annotateApplyPolicy records the applyPolicy of every template as the apply-policy annotation of
+// its resources, unless the template sets the annotation itself.
+func annotateApplyPolicy(templates []types.ResourceTemplate, results []TemplateResult) {
+	for i, tmpl := range templates {
+		if tmpl.ApplyPolicy == "" {
+			continue
+		}
+		for _, resource := range results[i].Resources {
+			annotations := resourceAnnotations(resource)
+			if _, ok := annotations[AnnotationApplyPolicy]; !ok {
+				annotations[AnnotationApplyPolicy] = tmpl.ApplyPolicy
+			}
+		}
+	}
+}
+
+// resourceAnnotations returns the annotations of resource, adding an empty map when it has none.
+func resourceAnnotations(resource map[string]any) map[string]any {
+	metadata, ok := resource["metadata"].(map[string]any)
+	if !ok {
+		metadata = map[string]any{}
+		resource["metadata"] = metadata
+	}
+	annotations, ok := metadata["annotations"].(map[string]any)
+	if !ok {
+		annotations = map[string]any{}
+		metadata["annotations"] = annotations
+	}
+	return annotations
+}
+
+func anyRefreshed(ids []string, refreshed map[string]bool) bool {
+	for _, id := range ids {
+		if refreshed[id] {
+			return true
+		}
+	}
+	return false
+}
+
+// bindRendered makes the resources of tmpl readable as resources.<id>.
+func bindRendered(rendered map[string]any, tmpl types.ResourceTemplate, resources []map[string]any) {
+	if tmpl.ForEach == "" {
+		if len(resources) == 1 {
+			rendered[tmpl.ID] = resources[0]
+		}
+		return
+	}
+	instances := make([]any, len(resources))
+	for i, resource := range resources {
+		instances[i] = resource
+	}
+	rendered[tmpl.ID] = instances
+}
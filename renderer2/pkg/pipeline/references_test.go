@@ -0,0 +1,183 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResourceReferences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resources string
+		spec      map[string]any
+		want      []map[string]any
+		wantErr   string
+	}{
+		{
+			name: "templates render after the resources they read",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    serviceName: ${resources.service.metadata.name}
+    volume: ${resources.pvc[1].metadata.name}
+    debug: '${has(resources.debug) ? "on" : "off"}'
+- id: service
+  template:
+    kind: Service
+    metadata:
+      name: ${spec.name}-svc
+- id: pvc
+  forEach: ${spec.disks}
+  var: disk
+  template:
+    kind: PersistentVolumeClaim
+    metadata:
+      name: ${spec.name}-${disk}
+- id: debug
+  includeWhen: ${false}
+  template:
+    kind: Pod
+`,
+			spec: map[string]any{"name": "web", "disks": []any{"data", "logs"}},
+			want: []map[string]any{
+				{"kind": "Deployment", "serviceName": "web-svc", "volume": "web-logs", "debug": "off"},
+				{"kind": "Service", "metadata": map[string]any{"name": "web-svc"}},
+				{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-data"}},
+				{"kind": "PersistentVolumeClaim", "metadata": map[string]any{"name": "web-logs"}},
+			},
+		},
+		{
+			name: "cycle",
+			resources: `
+- id: a
+  template:
+    name: ${resources.b.name}
+- id: b
+  template:
+    name: ${resources.a.name}
+- id: c
+  template:
+    name: c
+`,
+			wantErr: "resources a, b reference each other in a cycle",
+		},
+		{
+			name: "reading every resource",
+			resources: `
+- id: summary
+  template:
+    count: ${resources.size()}
+- id: a
+  template:
+    name: a
+`,
+			want: []map[string]any{{"count": int64(1)}, {"name": "a"}},
+		},
+		{
+			name: "self reference",
+			resources: `
+- id: a
+  includeWhen: ${has(resources.a)}
+  template:
+    name: a
+`,
+			wantErr: "resource a references itself through resources.a",
+		},
+		{
+			name: "unknown resource",
+			resources: `
+- id: a
+  template:
+    name: ${resources.servce.metadata.name}
+`,
+			wantErr: "resource a references unknown resource servce",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var templates []types.ResourceTemplate
+			if err := yaml.Unmarshal([]byte(tt.resources), &templates); err != nil {
+				t.Fatalf("yaml.Unmarshal() error = %v", err)
+			}
+			got, err := NewRenderer(template.NewEngine()).renderResourceTemplates(templates, map[string]any{"spec": tt.spec})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("renderResourceTemplates() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderResourceTemplates() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("resources mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestResourceReferencesRerender(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      port: integer | default=80
+      replicas: integer | default=1
+  commonMetadata:
+    labels:
+      app: ${metadata.name}
+  resources:
+    - id: deployment
+      template:
+        kind: Deployment
+        spec:
+          replicas: ${spec.replicas}
+          selector: ${resources.service.metadata.labels}
+          port: ${resources.service.spec.port}
+    - id: service
+      template:
+        kind: Service
+        spec:
+          port: ${spec.port}
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	component := &types.Component{Metadata: types.Metadata{Name: "checkout"}, Spec: types.ComponentSpec{ComponentType: "web-app", Parameters: map[string]any{}}}
+
+	r := NewRenderer(template.NewEngine())
+	results, err := r.RenderTemplates(&definition, component, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RenderTemplates() error = %v", err)
+	}
+	deployment := results[0].Resources[0]["spec"].(map[string]any)
+	if diff := cmp.Diff(map[string]any{"replicas": int64(1), "selector": map[string]any{"app": "checkout"}, "port": int64(80)}, deployment); diff != "" {
+		t.Errorf("deployment spec mismatch (-want +got):\n%s", diff)
+	}
+
+	// The deployment reads the service, so a change only the service reads renders both again.
+	component.Spec.Parameters["port"] = 8080
+	rerendered, err := r.RerenderTemplates(results, []string{"spec.port"}, &definition, component, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("RerenderTemplates() error = %v", err)
+	}
+	if port := rerendered[0].Resources[0]["spec"].(map[string]any)["port"]; port != int64(8080) {
+		t.Errorf("deployment port = %v, want 8080", port)
+	}
+}
@@ -0,0 +1,192 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// Scope tells whether the objects of a kind live in a namespace.
+type Scope string
+
+const (
+	// ScopeUnknown is the scope of kinds the scope table does not know.
+	ScopeUnknown    Scope = ""
+	ScopeNamespaced Scope = "Namespaced"
+	ScopeCluster    Scope = "Cluster"
+)
+
+// builtinScopes are the scopes of the built-in Kubernetes kinds, keyed like Scopes.
+var builtinScopes = func() map[string]Scope {
+	scopes := map[string]Scope{}
+	for scope, kinds := range map[Scope][]string{
+		ScopeCluster: {
+			"Namespace", "Node", "PersistentVolume", "ComponentStatus",
+			"CustomResourceDefinition.apiextensions.k8s.io",
+			"APIService.apiregistration.k8s.io",
+			"MutatingWebhookConfiguration.admissionregistration.k8s.io",
+			"ValidatingWebhookConfiguration.admissionregistration.k8s.io",
+			"ValidatingAdmissionPolicy.admissionregistration.k8s.io",
+			"ValidatingAdmissionPolicyBinding.admissionregistration.k8s.io",
+			"ClusterRole.rbac.authorization.k8s.io", "ClusterRoleBinding.rbac.authorization.k8s.io",
+			"StorageClass.storage.k8s.io", "CSIDriver.storage.k8s.io", "CSINode.storage.k8s.io",
+			"VolumeAttachment.storage.k8s.io",
+			"PriorityClass.scheduling.k8s.io",
+			"RuntimeClass.node.k8s.io",
+			"IngressClass.networking.k8s.io",
+			"CertificateSigningRequest.certificates.k8s.io",
+			"FlowSchema.flowcontrol.apiserver.k8s.io", "PriorityLevelConfiguration.flowcontrol.apiserver.k8s.io",
+		},
+		ScopeNamespaced: {
+			"Pod", "Service", "ConfigMap", "Secret", "ServiceAccount", "PersistentVolumeClaim", "Endpoints",
+			"Event", "LimitRange", "ResourceQuota", "PodTemplate", "ReplicationController",
+			"Deployment.apps", "StatefulSet.apps", "DaemonSet.apps", "ReplicaSet.apps", "ControllerRevision.apps",
+			"Job.batch", "CronJob.batch",
+			"HorizontalPodAutoscaler.autoscaling",
+			"PodDisruptionBudget.policy",
+			"Role.rbac.authorization.k8s.io", "RoleBinding.rbac.authorization.k8s.io",
+			"Ingress.networking.k8s.io", "NetworkPolicy.networking.k8s.io",
+			"EndpointSlice.discovery.k8s.io",
+			"Lease.coordination.k8s.io",
+			"CSIStorageCapacity.storage.k8s.io",
+			"Event.events.k8s.io",
+		},
+	} {
+		for _, kind := range kinds {
+			scopes[kind] = scope
+		}
+	}
+	return scopes
+}()
+
+// Scopes tells the scope of resource kinds: the built-in Kubernetes kinds and those of the
+// CustomResourceDefinitions added to it. Kinds are keyed as kubectl names them, Kind.group, or
+// Kind alone for the core group. A Scopes is safe for concurrent reads once it is set up.
+type Scopes struct {
+	kinds map[string]Scope
+}
+
+// NewScopes returns the scopes of the built-in Kubernetes kinds.
+func NewScopes() *Scopes {
+	kinds := make(map[string]Scope, len(builtinScopes))
+	for kind, scope := range builtinScopes {
+		kinds[kind] = scope
+	}
+	return &Scopes{kinds: kinds}
+}
+
+// Set records the scope of kind in group.
+func (s *Scopes) Set(group, kind string, scope Scope) {
+	s.kinds[scopeKey(group, kind)] = scope
+}
+
+// AddCRD records the scope of the kind a CustomResourceDefinition, decoded as a map, serves.
+func (s *Scopes) AddCRD(crd map[string]any) error {
+	group, kind, scope, err := crdScope(crd)
+	if err != nil {
+		return err
+	}
+	s.Set(group, kind, scope)
+	return nil
+}
+
+// Of returns the scope of resource's kind, ScopeUnknown for kinds s does not know.
+func (s *Scopes) Of(resource map[string]any) Scope {
+	if s == nil {
+		return ScopeUnknown
+	}
+	apiVersion, _ := resource["apiVersion"].(string)
+	kind, _ := resource["kind"].(string)
+	group := ""
+	if i := strings.LastIndex(apiVersion, "/"); i != -1 {
+		group = apiVersion[:i]
+	}
+	return s.kinds[scopeKey(group, kind)]
+}
+
+func scopeKey(group, kind string) string {
+	if group == "" {
+		return kind
+	}
+	return kind + "." + group
+}
+
+func crdScope(crd map[string]any) (group, kind string, scope Scope, err error) {
+	spec, _ := crd["spec"].(map[string]any)
+	names, _ := spec["names"].(map[string]any)
+	group, _ = spec["group"].(string)
+	kind, _ = names["kind"].(string)
+	value, _ := spec["scope"].(string)
+	if group == "" || kind == "" {
+		return "", "", "", fmt.Errorf("CustomResourceDefinition %s misses spec.group or spec.names.kind", crdName(crd))
+	}
+	switch Scope(value) {
+	case ScopeNamespaced, ScopeCluster:
+		return group, kind, Scope(value), nil
+	default:
+		return "", "", "", fmt.Errorf("CustomResourceDefinition %s has scope %q, want %s or %s", crdName(crd), value, ScopeNamespaced, ScopeCluster)
+	}
+}
+
+func crdName(crd map[string]any) string {
+	metadata, _ := crd["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+// withRenderedCRDs returns s extended with the CustomResourceDefinitions among resources, so a
+// render that creates a CRD and objects of its kind knows their scope. s is not modified.
+func (s *Scopes) withRenderedCRDs(resources []RenderedResource) *Scopes {
+	extended := s
+	for _, resource := range resources {
+		apiVersion, _ := resource.Object["apiVersion"].(string)
+		if resource.Object["kind"] != "CustomResourceDefinition" || !strings.HasPrefix(apiVersion, "apiextensions.k8s.io/") {
+			continue
+		}
+		group, kind, scope, err := crdScope(resource.Object)
+		if err != nil {
+			// The API server rejects the CRD itself; its kind stays unknown here.
+			continue
+		}
+		if extended == s {
+			extended = &Scopes{kinds: make(map[string]Scope, len(s.kinds)+1)}
+			for kind, scope := range s.kinds {
+				extended.kinds[kind] = scope
+			}
+		}
+		extended.Set(group, kind, scope)
+	}
+	return extended
+}
+
+// ApplyScopes records the scope of every resource, checks that cluster-scoped resources set no
+// namespace, warns about namespaced resources that set none, and moves cluster-scoped resources
+// ahead of the others, keeping their order otherwise, so appliers create them first. Kinds scopes
+// does not know are left where they are. CustomResourceDefinitions among resources add their kinds
+// to scopes for this render. A nil scopes does nothing.
+func ApplyScopes(resources []RenderedResource, scopes *Scopes, sink events.Sink, component *types.Component) error {
+	if scopes == nil {
+		return nil
+	}
+	scopes = scopes.withRenderedCRDs(resources)
+	for i := range resources {
+		resource := &resources[i]
+		resource.Scope = scopes.Of(resource.Object)
+		metadata, _ := resource.Object["metadata"].(map[string]any)
+		namespace, _ := metadata["namespace"].(string)
+		switch {
+		case resource.Scope == ScopeCluster && namespace != "":
+			return fmt.Errorf("resource %s is cluster-scoped but sets metadata.namespace %s", ResourceName(resource.Object), namespace)
+		case resource.Scope == ScopeNamespaced && namespace == "":
+			events.Warningf(sink, component, events.ReasonMissingNamespace,
+				"resource %s is namespaced but sets no metadata.namespace; it lands in the namespace it is applied to", ResourceName(resource.Object))
+		}
+	}
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Scope == ScopeCluster && resources[j].Scope != ScopeCluster
+	})
+	return nil
+}
@@ -0,0 +1,115 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func scopedObject(apiVersion, kind, name, namespace string) map[string]any {
+	metadata := map[string]any{"name": name}
+	if namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	return map[string]any{"apiVersion": apiVersion, "kind": kind, "metadata": metadata}
+}
+
+func widgetCRD(scope string) map[string]any {
+	crd := scopedObject("apiextensions.k8s.io/v1", "CustomResourceDefinition", "widgets.example.com", "")
+	crd["spec"] = map[string]any{
+		"group": "example.com",
+		"names": map[string]any{"kind": "Widget", "plural": "widgets"},
+		"scope": scope,
+	}
+	return crd
+}
+
+func TestScopesOf(t *testing.T) {
+	t.Parallel()
+
+	scopes := NewScopes()
+	if err := scopes.AddCRD(widgetCRD("Cluster")); err != nil {
+		t.Fatalf("AddCRD() error = %v", err)
+	}
+	tests := []struct {
+		apiVersion, kind string
+		want             Scope
+	}{
+		{apiVersion: "v1", kind: "Namespace", want: ScopeCluster},
+		{apiVersion: "v1", kind: "ConfigMap", want: ScopeNamespaced},
+		{apiVersion: "apps/v1", kind: "Deployment", want: ScopeNamespaced},
+		{apiVersion: "rbac.authorization.k8s.io/v1", kind: "ClusterRole", want: ScopeCluster},
+		{apiVersion: "example.com/v1", kind: "Widget", want: ScopeCluster},
+		{apiVersion: "other.com/v1", kind: "Widget", want: ScopeUnknown},
+		{apiVersion: "v1", kind: "Deployment", want: ScopeUnknown},
+	}
+	for _, tt := range tests {
+		if got := scopes.Of(scopedObject(tt.apiVersion, tt.kind, "x", "")); got != tt.want {
+			t.Errorf("Of(%s %s) = %q, want %q", tt.apiVersion, tt.kind, got, tt.want)
+		}
+	}
+
+	if err := scopes.AddCRD(widgetCRD("Global")); err == nil || err.Error() != `CustomResourceDefinition widgets.example.com has scope "Global", want Namespaced or Cluster` {
+		t.Errorf("AddCRD(Global) error = %v", err)
+	}
+}
+
+func TestApplyScopes(t *testing.T) {
+	t.Parallel()
+
+	component := &types.Component{Metadata: types.Metadata{Name: "web"}}
+	resources := []RenderedResource{
+		{Object: scopedObject("apps/v1", "Deployment", "web", "team-a")},
+		{Object: scopedObject("v1", "ConfigMap", "settings", "")},
+		{Object: scopedObject("example.com/v1", "Widget", "global", "")},
+		{Object: scopedObject("v1", "Namespace", "team-a", "")},
+		{Object: scopedObject("unknown.io/v1", "Gadget", "g", "")},
+		{Object: widgetCRD("Cluster")},
+	}
+	recorder := events.NewRecorder()
+	if err := ApplyScopes(resources, NewScopes(), recorder, component); err != nil {
+		t.Fatalf("ApplyScopes() error = %v", err)
+	}
+
+	var got []string
+	for _, resource := range resources {
+		got = append(got, ResourceName(resource.Object)+" "+string(resource.Scope))
+	}
+	want := []string{
+		"Widget/global Cluster",
+		"Namespace/team-a Cluster",
+		"CustomResourceDefinition/widgets.example.com Cluster",
+		"Deployment/web Namespaced",
+		"ConfigMap/settings Namespaced",
+		"Gadget/g ",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ApplyScopes() order mismatch (-want +got):\n%s", diff)
+	}
+
+	var warnings []string
+	for _, event := range recorder.Events() {
+		if event.Reason == events.ReasonMissingNamespace {
+			warnings = append(warnings, event.Message)
+		}
+	}
+	wantWarnings := []string{"resource ConfigMap/settings is namespaced but sets no metadata.namespace; it lands in the namespace it is applied to"}
+	if diff := cmp.Diff(wantWarnings, warnings); diff != "" {
+		t.Errorf("ApplyScopes() warnings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyScopesRejectsNamespacedClusterResources(t *testing.T) {
+	t.Parallel()
+
+	resources := []RenderedResource{{Object: scopedObject("rbac.authorization.k8s.io/v1", "ClusterRole", "reader", "team-a")}}
+	err := ApplyScopes(resources, NewScopes(), nil, &types.Component{})
+	if err == nil || err.Error() != "resource ClusterRole/reader is cluster-scoped but sets metadata.namespace team-a" {
+		t.Errorf("ApplyScopes() error = %v", err)
+	}
+	if err := ApplyScopes(resources, nil, nil, &types.Component{}); err != nil {
+		t.Errorf("ApplyScopes(nil scopes) error = %v", err)
+	}
+}
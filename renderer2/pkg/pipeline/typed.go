@@ -0,0 +1,84 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// typed returns a copy of r whose engine type checks spec against the parameter and envOverrides
+// schema of def, so a misspelled parameter such as spec.replcas fails to compile with an
+// "undefined field" error instead of being treated as missing data.
+func (r *RendererCoordinates) typed(def schema.Definition) (*RendererCoordinates, error) {
+	jsonSchema, err := r.Schemas.ToJSONSchema(def)
+	if err != nil {
+		return nil, err
+	}
+	specSchema := celSchema(jsonSchema)
+	if specSchema == nil {
+		return r, nil
+	}
+	engine, err := r.TemplateEngine.Typed(map[string]*template.Schema{"spec": specSchema})
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive typed engine: %w", err)
+	}
+	typed := *r
+	typed.TemplateEngine = engine
+	return &typed, nil
+}
+
+// withFragments returns a copy of r whose engine defines fragment() for the fragments of a
+// definition.
+func (r *RendererCoordinates) withFragments(fragments map[string]any) (*RendererCoordinates, error) {
+	if len(fragments) == 0 {
+		return r, nil
+	}
+	engine, err := r.TemplateEngine.Fragments(fragments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive fragment engine: %w", err)
+	}
+	derived := *r
+	derived.TemplateEngine = engine
+	return &derived, nil
+}
+
+// celSchema keeps the structure of an OpenAPI schema that expressions can be checked against.
+// Free-form objects and objects preserving unknown fields stay dyn.
+func celSchema(props *extv1.JSONSchemaProps) *template.Schema {
+	if props == nil || (props.XPreserveUnknownFields != nil && *props.XPreserveUnknownFields) {
+		return nil
+	}
+	switch props.Type {
+	case "object":
+		if props.AdditionalProperties != nil {
+			return &template.Schema{AdditionalProperties: elementSchema(props.AdditionalProperties.Schema)}
+		}
+		if len(props.Properties) == 0 {
+			return nil
+		}
+		properties := make(map[string]*template.Schema, len(props.Properties))
+		for name := range props.Properties {
+			field := props.Properties[name]
+			properties[name] = celSchema(&field)
+		}
+		return &template.Schema{Properties: properties}
+	case "array":
+		if props.Items == nil {
+			return nil
+		}
+		return &template.Schema{Items: elementSchema(props.Items.Schema)}
+	default:
+		return nil
+	}
+}
+
+// elementSchema returns the schema of list elements and map values, which must be non-nil for the
+// list or map itself to be typed.
+func elementSchema(props *extv1.JSONSchemaProps) *template.Schema {
+	if element := celSchema(props); element != nil {
+		return element
+	}
+	return &template.Schema{}
+}
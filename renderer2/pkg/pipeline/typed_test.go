@@ -0,0 +1,48 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCELSchema(t *testing.T) {
+	t.Parallel()
+
+	def := schema.Definition{
+		Types: map[string]any{
+			"EnvVar": map[string]any{"name": "string", "value": "string"},
+		},
+		Schemas: []map[string]any{
+			{
+				"replicas": "integer | default=1",
+				"env":      "[]EnvVar",
+				"labels":   "map<string>",
+				"extra":    "object",
+			},
+			{
+				"resources": map[string]any{"cpu": "string | default=100m"},
+			},
+		},
+	}
+	jsonSchema, err := schema.ToJSONSchema(def)
+	if err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+
+	want := &template.Schema{Properties: map[string]*template.Schema{
+		"replicas": nil,
+		"env": {Items: &template.Schema{Properties: map[string]*template.Schema{
+			"name":  nil,
+			"value": nil,
+		}}},
+		"labels":    {AdditionalProperties: &template.Schema{}},
+		"extra":     nil,
+		"resources": {Properties: map[string]*template.Schema{"cpu": nil}},
+	}}
+	if diff := cmp.Diff(want, celSchema(jsonSchema)); diff != "" {
+		t.Errorf("celSchema() mismatch (-want +got):\n%s", diff)
+	}
+}
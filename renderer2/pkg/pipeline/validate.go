@@ -0,0 +1,96 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// ValidateComponent checks the spec a Component renders with, its parameters over the schema
+// defaults with the declared EnvSettings overrides applied, against the definition schema.
+// envSettings may be nil. Nothing is rendered and no events are emitted.
+func (r *RendererCoordinates) ValidateComponent(
+	definition *types.ComponentTypeDefinition,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+) error {
+	return r.ValidateComponentUpdate(definition, nil, nil, component, envSettings)
+}
+
+// ValidateComponentUpdate is ValidateComponent for a Component, or EnvSettings, replacing a
+// previous revision. The spec is also checked against the spec oldComponent rendered with in
+// oldEnvSettings, so changes to immutable fields are rejected (see schema.ValidateUpdate). A nil
+// oldComponent validates component as new.
+func (r *RendererCoordinates) ValidateComponentUpdate(
+	definition *types.ComponentTypeDefinition,
+	oldComponent *types.Component,
+	oldEnvSettings *types.EnvSettings,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+) error {
+	var old map[string]any
+	if oldComponent != nil {
+		_, _, _, _, oldInputs, err := r.componentContext(definition, oldComponent, oldEnvSettings, nil, nil, nil)
+		if err != nil {
+			return fmt.Errorf("previous revision: %w", err)
+		}
+		old = oldInputs["spec"].(map[string]any)
+	}
+	definition, _, _, definitionSchema, inputs, err := r.componentContext(definition, component, envSettings, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.validateSpec(definitionSchema, old, inputs["spec"].(map[string]any)); err != nil {
+		return fmt.Errorf("spec does not match the schema of %s: %w", definition.Metadata.Name, err)
+	}
+	return nil
+}
+
+// ValidateAddon checks the spec an addon instance renders with against the addon schema, like
+// ValidateComponent.
+func (r *RendererCoordinates) ValidateAddon(
+	addon *types.Addon,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+) error {
+	return r.ValidateAddonUpdate(addon, nil, nil, nil, addonInstance, component, envSettings)
+}
+
+// ValidateAddonUpdate is ValidateAddon for an addon instance replacing oldInstance of
+// oldComponent, like ValidateComponentUpdate. A nil oldInstance validates addonInstance as new.
+func (r *RendererCoordinates) ValidateAddonUpdate(
+	addon *types.Addon,
+	oldInstance *types.AddonInstance,
+	oldComponent *types.Component,
+	oldEnvSettings *types.EnvSettings,
+	addonInstance types.AddonInstance,
+	component *types.Component,
+	envSettings *types.EnvSettings,
+) error {
+	var old map[string]any
+	if oldInstance != nil {
+		_, oldInputs, err := r.addonContext(addon, *oldInstance, oldComponent, oldEnvSettings, nil, nil)
+		if err != nil {
+			return fmt.Errorf("previous revision of addon instance %s: %w", oldInstance.InstanceID, err)
+		}
+		old = oldInputs["spec"].(map[string]any)
+	}
+	addonSchema, inputs, err := r.addonContext(addon, addonInstance, component, envSettings, nil, nil)
+	if err != nil {
+		return err
+	}
+	if err := r.validateSpec(addonSchema, old, inputs["spec"].(map[string]any)); err != nil {
+		return fmt.Errorf("addon instance %s does not match the schema of %s: %w", addonInstance.InstanceID, addon.Metadata.Name, err)
+	}
+	return nil
+}
+
+// validateSpec validates spec, and its transition from old unless old is nil.
+func (r *RendererCoordinates) validateSpec(def schema.Definition, old, spec map[string]any) error {
+	if old == nil {
+		return r.Schemas.Validate(def, spec)
+	}
+	return r.Schemas.ValidateUpdate(def, old, spec)
+}
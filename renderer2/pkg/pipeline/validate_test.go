@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestValidateComponent(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      image: string
+      port: integer | default=8080 minimum=1
+    envOverrides:
+      replicas: integer | default=1 minimum=0
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	var addon types.Addon
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: persistent-volume
+spec:
+  schema:
+    parameters:
+      mountPath: string
+    envOverrides:
+      size: string | default=1Gi pattern=^[0-9]+[MG]i$
+`), &addon); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		parameters map[string]any
+		config     map[string]any
+		overrides  map[string]any
+		addon      map[string]any
+		wantErr    string
+	}{
+		{
+			name:       "defaults fill the optional fields",
+			parameters: map[string]any{"image": "nginx"},
+			config:     map[string]any{"mountPath": "/data"},
+		},
+		{
+			name:       "missing parameter",
+			parameters: map[string]any{"port": 80},
+			config:     map[string]any{"mountPath": "/data"},
+			wantErr:    "spec does not match the schema of web-app: image: required value is missing",
+		},
+		{
+			name:       "invalid override",
+			parameters: map[string]any{"image": "nginx"},
+			config:     map[string]any{"mountPath": "/data"},
+			overrides:  map[string]any{"replicas": -1},
+			wantErr:    "spec does not match the schema of web-app: replicas: must be greater than or equal to 0",
+		},
+		{
+			name:       "undeclared overrides are dropped before validation",
+			parameters: map[string]any{"image": "nginx"},
+			config:     map[string]any{"mountPath": "/data"},
+			overrides:  map[string]any{"image": 1},
+		},
+		{
+			name:       "invalid addon override",
+			parameters: map[string]any{"image": "nginx"},
+			config:     map[string]any{"mountPath": "/data"},
+			addon:      map[string]any{"size": "lots"},
+			wantErr:    `addon instance data does not match the schema of persistent-volume: size: "lots" does not match pattern ^[0-9]+[MG]i$`,
+		},
+	}
+
+	r := NewRenderer(template.NewEngine())
+	for _, tt := range tests {
+		component := &types.Component{Metadata: types.Metadata{Name: "web"}, Spec: types.ComponentSpec{ComponentType: "web-app", Parameters: tt.parameters}}
+		instance := types.AddonInstance{Name: "persistent-volume", InstanceID: "data", Config: tt.config}
+		envSettings := &types.EnvSettings{Metadata: types.Metadata{Name: "web-dev"}, Spec: types.EnvSettingsSpec{
+			Overrides:      tt.overrides,
+			AddonOverrides: map[string]map[string]any{"data": tt.addon},
+		}}
+
+		err := r.ValidateComponent(&definition, component, envSettings)
+		if err == nil {
+			err = r.ValidateAddon(&addon, instance, component, envSettings)
+		}
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+			}
+		} else if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateComponentUpdate(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: database
+spec:
+  schema:
+    parameters:
+      engine: string | immutable=true
+    envOverrides:
+      storageClass: string | default=standard immutable=true
+      size: string | default=1Gi
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	component := func(engine string) *types.Component {
+		return &types.Component{Metadata: types.Metadata{Name: "orders"}, Spec: types.ComponentSpec{
+			ComponentType: "database", Parameters: map[string]any{"engine": engine},
+		}}
+	}
+	env := func(overrides map[string]any) *types.EnvSettings {
+		return &types.EnvSettings{Metadata: types.Metadata{Name: "orders-prod"}, Spec: types.EnvSettingsSpec{Overrides: overrides}}
+	}
+
+	tests := []struct {
+		name           string
+		old            *types.Component
+		oldEnvSettings *types.EnvSettings
+		envSettings    *types.EnvSettings
+		engine         string
+		wantErr        string
+	}{
+		{
+			name:        "new component",
+			envSettings: env(map[string]any{"storageClass": "fast"}),
+			engine:      "postgres",
+		},
+		{
+			name:           "mutable override changes",
+			old:            component("postgres"),
+			oldEnvSettings: env(map[string]any{"storageClass": "fast"}),
+			envSettings:    env(map[string]any{"storageClass": "fast", "size": "5Gi"}),
+			engine:         "postgres",
+		},
+		{
+			name:    "immutable parameter changes",
+			old:     component("postgres"),
+			engine:  "mysql",
+			wantErr: "spec does not match the schema of database: engine: field is immutable",
+		},
+		{
+			name:           "override changes an immutable default",
+			old:            component("postgres"),
+			oldEnvSettings: env(nil),
+			envSettings:    env(map[string]any{"storageClass": "fast"}),
+			engine:         "postgres",
+			wantErr:        "spec does not match the schema of database: storageClass: field is immutable",
+		},
+	}
+
+	r := NewRenderer(template.NewEngine())
+	for _, tt := range tests {
+		err := r.ValidateComponentUpdate(&definition, tt.old, tt.oldEnvSettings, component(tt.engine), tt.envSettings)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+			}
+		} else if err == nil || err.Error() != tt.wantErr {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeprecatedFieldWarnings(t *testing.T) {
+	t.Parallel()
+
+	var definition types.ComponentTypeDefinition
+	if err := yaml.Unmarshal([]byte(`
+metadata:
+  name: web-app
+spec:
+  schema:
+    parameters:
+      image: string
+      tag: 'string | default=latest deprecated="set the tag in image"'
+    envOverrides:
+      debug: boolean | default=false deprecated=true
+  resources:
+    - id: config
+      template:
+        kind: ConfigMap
+        data:
+          image: ${spec.image}
+`), &definition); err != nil {
+		t.Fatal(err)
+	}
+	component := &types.Component{Metadata: types.Metadata{Name: "web"}, Spec: types.ComponentSpec{
+		ComponentType: "web-app", Parameters: map[string]any{"image": "nginx", "tag": "1.27"},
+	}}
+	envSettings := &types.EnvSettings{Metadata: types.Metadata{Name: "web-dev"}, Spec: types.EnvSettingsSpec{
+		Overrides: map[string]any{"debug": true},
+	}}
+
+	recorder := events.NewRecorder()
+	r := NewRenderer(template.NewEngine())
+	r.Events = recorder
+	if _, err := r.RenderComponentResources(&definition, component, envSettings, nil, nil); err != nil {
+		t.Fatalf("RenderComponentResources() error = %v", err)
+	}
+	var got []string
+	for _, event := range recorder.Events() {
+		if event.Reason == events.ReasonDeprecatedField {
+			got = append(got, event.Message)
+		}
+	}
+	want := []string{
+		"Component web sets tag, which is deprecated: set the tag in image",
+		"EnvSettings web-dev sets debug, which is deprecated",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("deprecation warnings mismatch (-want +got):\n%s", diff)
+	}
+}
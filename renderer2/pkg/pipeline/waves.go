@@ -0,0 +1,133 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kindRanks orders built-in kinds the way they must be applied: namespaces and CRDs first, then
+// identities and RBAC, configuration and storage, services, workloads, and the resources that
+// refer to workloads. Kinds missing from the table, custom resources included, rank last.
+var kindRanks = func() map[string]int {
+	ranks := map[string]int{}
+	for rank, kinds := range [][]string{
+		{"Namespace"},
+		{"CustomResourceDefinition"},
+		{"PriorityClass", "StorageClass", "ResourceQuota", "LimitRange"},
+		{"ServiceAccount", "ClusterRole", "Role", "ClusterRoleBinding", "RoleBinding"},
+		{"Secret", "ConfigMap", "PersistentVolume", "PersistentVolumeClaim"},
+		{"Service"},
+		{"Pod", "ReplicaSet", "Deployment", "StatefulSet", "DaemonSet", "Job", "CronJob"},
+		{"HorizontalPodAutoscaler", "PodDisruptionBudget", "Ingress", "NetworkPolicy"},
+	} {
+		for _, kind := range kinds {
+			ranks[kind] = rank
+		}
+	}
+	return ranks
+}()
+
+func kindRank(resource map[string]any) int {
+	kind, _ := resource["kind"].(string)
+	if rank, ok := kindRanks[kind]; ok {
+		return rank
+	}
+	return len(kindRanks)
+}
+
+// ApplyWaves sets the apply wave of every resource from its dependencies: a resource depends on
+// every resource of a kind that ranks before its own (Namespaces, then CRDs, ..., then custom
+// resources) and on the resources listed in its DependsOn. Its wave is one more than the highest
+// wave it depends on, and at least the wave its apply-wave annotation requested, so waves can be
+// applied in ascending order like Argo CD sync waves. DependsOn naming a resource that was not
+// rendered, or dependencies forming a cycle, are errors.
+func ApplyWaves(resources []RenderedResource) error {
+	byName := make(map[string][]int, len(resources))
+	for i, resource := range resources {
+		name := ResourceName(resource.Object)
+		byName[name] = append(byName[name], i)
+	}
+	deps := make([][]int, len(resources))
+	for i, resource := range resources {
+		rank := kindRank(resource.Object)
+		for j, other := range resources {
+			if kindRank(other.Object) < rank {
+				deps[i] = append(deps[i], j)
+			}
+		}
+		for _, ref := range resource.Options.DependsOn {
+			matches, ok := byName[ref]
+			if !ok {
+				return fmt.Errorf("resource %s depends on %s, which is not rendered", ResourceName(resource.Object), ref)
+			}
+			deps[i] = append(deps[i], matches...)
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(resources))
+	var path []int
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != i {
+				start++
+			}
+			cycle := make([]string, 0, len(path)-start+1)
+			for _, j := range append(path[start:], i) {
+				cycle = append(cycle, ResourceName(resources[j].Object))
+			}
+			return fmt.Errorf("resources depend on each other in a cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[i] = visiting
+		path = append(path, i)
+		for _, j := range deps[i] {
+			if err := visit(j); err != nil {
+				return err
+			}
+			if wave := resources[j].Options.ApplyWave + 1; wave > resources[i].Options.ApplyWave {
+				resources[i].Options.ApplyWave = wave
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range resources {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnnotateApplyWaves sorts resources by apply wave, keeping their order within a wave, and records
+// each wave in annotation, e.g. argocd.argoproj.io/sync-wave, for appliers that read it.
+func AnnotateApplyWaves(resources []RenderedResource, annotation string) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		return resources[i].Options.ApplyWave < resources[j].Options.ApplyWave
+	})
+	for _, resource := range resources {
+		metadata, ok := resource.Object["metadata"].(map[string]any)
+		if !ok {
+			metadata = map[string]any{}
+			resource.Object["metadata"] = metadata
+		}
+		annotations, ok := metadata["annotations"].(map[string]any)
+		if !ok {
+			annotations = map[string]any{}
+			metadata["annotations"] = annotations
+		}
+		annotations[annotation] = strconv.Itoa(resource.Options.ApplyWave)
+	}
+}
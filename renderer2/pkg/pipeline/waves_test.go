@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v3"
+)
+
+func TestApplyWaves(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resources string
+		want      map[string]int
+		wantErr   string
+	}{
+		{
+			name: "kinds are ordered and absent kinds leave no gaps",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    metadata: {name: web}
+- id: widget
+  template:
+    kind: Widget
+    metadata: {name: web}
+- id: account
+  template:
+    kind: ServiceAccount
+    metadata: {name: web}
+- id: crd
+  template:
+    kind: CustomResourceDefinition
+    metadata: {name: widgets.example.com}
+- id: namespace
+  template:
+    kind: Namespace
+    metadata: {name: team}
+`,
+			want: map[string]int{
+				"Namespace/team": 0,
+				"CustomResourceDefinition/widgets.example.com": 1,
+				"ServiceAccount/web":                           2,
+				"Deployment/web":                               3,
+				"Widget/web":                                   4,
+			},
+		},
+		{
+			name: "dependsOn orders resources of the same kind and raises later waves",
+			resources: `
+- id: migrate
+  dependsOn: [db]
+  forEach: ${["a", "b"]}
+  var: step
+  template:
+    kind: Job
+    metadata: {name: 'migrate-${step}'}
+- id: db
+  template:
+    kind: StatefulSet
+    metadata: {name: db}
+- id: app
+  dependsOn: [migrate]
+  template:
+    kind: Deployment
+    metadata: {name: app}
+- id: hpa
+  template:
+    kind: HorizontalPodAutoscaler
+    metadata: {name: app}
+`,
+			want: map[string]int{
+				"StatefulSet/db":              0,
+				"Job/migrate-a":               1,
+				"Job/migrate-b":               1,
+				"Deployment/app":              2,
+				"HorizontalPodAutoscaler/app": 3,
+			},
+		},
+		{
+			name: "apply-wave annotations set a minimum wave",
+			resources: `
+- id: config
+  template:
+    kind: ConfigMap
+    metadata:
+      name: web
+      annotations:
+        platform.io/apply-wave: "5"
+- id: secret
+  template:
+    kind: Secret
+    metadata:
+      name: web
+      annotations:
+        platform.io/apply-wave: "-1"
+- id: deployment
+  template:
+    kind: Deployment
+    metadata: {name: web}
+`,
+			want: map[string]int{"ConfigMap/web": 5, "Secret/web": -1, "Deployment/web": 6},
+		},
+		{
+			name: "dependsOn against the kind order",
+			resources: `
+- id: namespace
+  dependsOn: [deployment]
+  template:
+    kind: Namespace
+    metadata: {name: team}
+- id: deployment
+  template:
+    kind: Deployment
+    metadata: {name: web}
+`,
+			wantErr: "resources depend on each other in a cycle: Namespace/team -> Deployment/web -> Namespace/team",
+		},
+		{
+			name: "unknown template",
+			resources: `
+- id: deployment
+  dependsOn: [databse]
+  template:
+    kind: Deployment
+`,
+			wantErr: "resource deployment depends on unknown resource databse",
+		},
+		{
+			name: "annotation naming a resource that was not rendered",
+			resources: `
+- id: deployment
+  template:
+    kind: Deployment
+    metadata:
+      name: web
+      annotations:
+        platform.io/depends-on: Secret/web
+`,
+			wantErr: "resource Deployment/web depends on Secret/web, which is not rendered",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var templates []types.ResourceTemplate
+			if err := yaml.Unmarshal([]byte(tt.resources), &templates); err != nil {
+				t.Fatalf("yaml.Unmarshal() error = %v", err)
+			}
+			resources, err := NewRenderer(template.NewEngine()).renderResourceTemplates(templates, map[string]any{})
+			var rendered []RenderedResource
+			if err == nil {
+				rendered, err = ExtractAll(resources)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ExtractAll() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ExtractAll() error = %v", err)
+			}
+			got := map[string]int{}
+			for _, resource := range rendered {
+				got[ResourceName(resource.Object)] = resource.Options.ApplyWave
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("waves mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAnnotateApplyWaves(t *testing.T) {
+	t.Parallel()
+
+	rendered := []RenderedResource{
+		{Object: map[string]any{"kind": "Deployment"}, Options: ResourceOptions{ApplyWave: 1}},
+		{Object: map[string]any{"kind": "Service", "metadata": map[string]any{"annotations": map[string]any{"team": "web"}}}},
+		{Object: map[string]any{"kind": "ConfigMap"}},
+	}
+	AnnotateApplyWaves(rendered, "argocd.argoproj.io/sync-wave")
+
+	want := []map[string]any{
+		{"kind": "Service", "metadata": map[string]any{"annotations": map[string]any{"team": "web", "argocd.argoproj.io/sync-wave": "0"}}},
+		{"kind": "ConfigMap", "metadata": map[string]any{"annotations": map[string]any{"argocd.argoproj.io/sync-wave": "0"}}},
+		{"kind": "Deployment", "metadata": map[string]any{"annotations": map[string]any{"argocd.argoproj.io/sync-wave": "1"}}},
+	}
+	got := make([]map[string]any, len(rendered))
+	for i, resource := range rendered {
+		got[i] = resource.Object
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("AnnotateApplyWaves() mismatch (-want +got):\n%s", diff)
+	}
+}
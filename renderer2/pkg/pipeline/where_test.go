@@ -0,0 +1,82 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+func TestWhereSeesAllResources(t *testing.T) {
+	t.Parallel()
+
+	// stateless labels the Deployment only when the render has no StatefulSet.
+	const stateless = `
+metadata:
+  name: stateless
+spec:
+  patches:
+    - target:
+        kind: Deployment
+        where: ${!allResources.exists(r, r.kind == "StatefulSet")}
+      operations:
+        - op: add
+          path: /metadata/labels/stateless
+          value: "true"
+`
+	const statefulSet = `
+metadata:
+  name: database
+spec:
+  creates:
+    - apiVersion: apps/v1
+      kind: StatefulSet
+      metadata:
+        name: ${metadata.name}-db
+`
+
+	tests := []struct {
+		name   string
+		base   []string
+		addons []string
+		want   bool
+	}{
+		{name: "no StatefulSet", base: []string{"Deployment"}, addons: []string{stateless}, want: true},
+		{name: "base StatefulSet", base: []string{"Deployment", "StatefulSet"}, addons: []string{stateless}},
+		{name: "StatefulSet created by an earlier addon", base: []string{"Deployment"}, addons: []string{statefulSet, stateless}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resources []map[string]any
+			for _, kind := range tt.base {
+				resources = append(resources, map[string]any{
+					"apiVersion": "apps/v1",
+					"kind":       kind,
+					"metadata":   map[string]any{"name": "web", "labels": map[string]any{}},
+				})
+			}
+			component := &types.Component{Metadata: types.Metadata{Name: "web"}}
+			r := NewRenderer(template.NewEngine())
+			for _, content := range tt.addons {
+				var addon types.Addon
+				if err := yaml.Unmarshal([]byte(content), &addon); err != nil {
+					t.Fatalf("yaml.Unmarshal() error = %v", err)
+				}
+				var err error
+				resources, err = r.ApplyAddon(resources, &addon, types.AddonInstance{Name: addon.Metadata.Name, InstanceID: "one"}, component, nil, nil, nil)
+				if err != nil {
+					t.Fatalf("ApplyAddon(%s) error = %v", addon.Metadata.Name, err)
+				}
+			}
+
+			labels := resources[0]["metadata"].(map[string]any)["labels"].(map[string]any)
+			if _, got := labels["stateless"]; got != tt.want {
+				t.Errorf("Deployment labelled = %v, want %v (labels %v)", got, tt.want, labels)
+			}
+		})
+	}
+}
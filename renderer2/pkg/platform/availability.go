@@ -0,0 +1,214 @@
+// Package platform holds opt-in transforms that apply platform-wide defaults to rendered
+// resources so individual definitions do not have to template them.
+package platform
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+const (
+	defaultMinReplicas       = 2
+	defaultMaxUnavailable    = "1"
+	defaultTopologyKey       = "topology.kubernetes.io/zone"
+	defaultMaxSkew           = 1
+	defaultWhenUnsatisfiable = "ScheduleAnyway"
+)
+
+// Availability is a post-render hook that adds a PodDisruptionBudget and topologySpreadConstraints
+// to Deployments and StatefulSets when the EnvSettings carry an availability section. Workloads
+// below the replica threshold, and anything the templates already cover, are left alone.
+type Availability struct {
+	pipeline.NopHooks
+}
+
+var _ pipeline.Hooks = Availability{}
+
+func (Availability) Name() string { return "availability" }
+
+func (Availability) PostRender(ctx *pipeline.HookContext, resources []map[string]any) ([]map[string]any, error) {
+	if ctx.EnvSettings == nil || ctx.EnvSettings.Spec.Availability == nil {
+		return resources, nil
+	}
+	settings := ctx.EnvSettings.Spec.Availability
+	minReplicas := settings.MinReplicas
+	if minReplicas <= 0 {
+		minReplicas = defaultMinReplicas
+	}
+
+	var budgets []map[string]any
+	for _, resource := range resources {
+		if !isWorkload(resource) {
+			continue
+		}
+		replicas, err := workloadReplicas(resource)
+		if err != nil {
+			return nil, err
+		}
+		if replicas < minReplicas {
+			continue
+		}
+		matchLabels, ok := nestedMap(resource, "spec", "selector", "matchLabels")
+		if !ok || len(matchLabels) == 0 {
+			continue
+		}
+
+		if settings.PodDisruptionBudget != nil && !hasBudget(resources, resource, matchLabels) {
+			budgets = append(budgets, podDisruptionBudget(resource, matchLabels, settings.PodDisruptionBudget))
+		}
+		if settings.TopologySpread != nil {
+			addTopologySpread(resource, matchLabels, settings.TopologySpread)
+		}
+	}
+	return append(resources, budgets...), nil
+}
+
+func podDisruptionBudget(workload, matchLabels map[string]any, policy *types.PodDisruptionPolicy) map[string]any {
+	metadata := map[string]any{"name": resourceMeta(workload, "name")}
+	if namespace := resourceMeta(workload, "namespace"); namespace != "" {
+		metadata["namespace"] = namespace
+	}
+	if labels, ok := nestedMap(workload, "metadata", "labels"); ok {
+		metadata["labels"] = copyMap(labels)
+	}
+
+	return map[string]any{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"metadata":   metadata,
+		"spec": map[string]any{
+			"maxUnavailable": intOrString(firstNonEmpty(policy.MaxUnavailable, defaultMaxUnavailable)),
+			"selector":       map[string]any{"matchLabels": copyMap(matchLabels)},
+		},
+	}
+}
+
+// addTopologySpread sets topologySpreadConstraints on the pod template unless the template already
+// declares its own.
+func addTopologySpread(workload, matchLabels map[string]any, policy *types.TopologySpreadPolicy) {
+	podSpec, ok := nestedMap(workload, "spec", "template", "spec")
+	if !ok {
+		return
+	}
+	if _, exists := podSpec["topologySpreadConstraints"]; exists {
+		return
+	}
+	maxSkew := policy.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+	podSpec["topologySpreadConstraints"] = []any{
+		map[string]any{
+			"maxSkew":           maxSkew,
+			"topologyKey":       firstNonEmpty(policy.TopologyKey, defaultTopologyKey),
+			"whenUnsatisfiable": firstNonEmpty(policy.WhenUnsatisfiable, defaultWhenUnsatisfiable),
+			"labelSelector":     map[string]any{"matchLabels": copyMap(matchLabels)},
+		},
+	}
+}
+
+// hasBudget reports whether the rendered output already contains a PodDisruptionBudget for the
+// workload, either by name or by selecting the same pods.
+func hasBudget(resources []map[string]any, workload, matchLabels map[string]any) bool {
+	for _, resource := range resources {
+		if resource["kind"] != "PodDisruptionBudget" {
+			continue
+		}
+		if resourceMeta(resource, "name") == resourceMeta(workload, "name") {
+			return true
+		}
+		if selector, ok := nestedMap(resource, "spec", "selector", "matchLabels"); ok && sameLabels(selector, matchLabels) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWorkload(resource map[string]any) bool {
+	apiVersion, _ := resource["apiVersion"].(string)
+	kind, _ := resource["kind"].(string)
+	return apiVersion == "apps/v1" && (kind == "Deployment" || kind == "StatefulSet")
+}
+
+// workloadReplicas returns spec.replicas, defaulting to 1 like the API server does.
+func workloadReplicas(resource map[string]any) (int, error) {
+	spec, _ := resource["spec"].(map[string]any)
+	value, ok := spec["replicas"]
+	if !ok || value == nil {
+		return 1, nil
+	}
+	switch v := value.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	case string:
+		replicas, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("%s %s: spec.replicas must be an integer, got %q", resource["kind"], resourceMeta(resource, "name"), v)
+		}
+		return replicas, nil
+	default:
+		return 0, fmt.Errorf("%s %s: spec.replicas must be an integer, got %T", resource["kind"], resourceMeta(resource, "name"), value)
+	}
+}
+
+func nestedMap(obj map[string]any, path ...string) (map[string]any, bool) {
+	current := obj
+	for _, key := range path {
+		next, ok := current[key].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+func resourceMeta(resource map[string]any, key string) string {
+	metadata, _ := resource["metadata"].(map[string]any)
+	value, _ := metadata[key].(string)
+	return value
+}
+
+func sameLabels(a, b map[string]any) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if fmt.Sprint(b[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func copyMap(src map[string]any) map[string]any {
+	result := make(map[string]any, len(src))
+	for key, value := range src {
+		result[key] = value
+	}
+	return result
+}
+
+// intOrString mirrors Kubernetes IntOrString: plain numbers become integers, percentages stay strings.
+func intOrString(value string) any {
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,109 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func deployment(name string, replicas any) map[string]any {
+	spec := map[string]any{
+		"selector": map[string]any{"matchLabels": map[string]any{"app": name}},
+		"template": map[string]any{"spec": map[string]any{}},
+	}
+	if replicas != nil {
+		spec["replicas"] = replicas
+	}
+	return map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": name, "namespace": "prod"},
+		"spec":       spec,
+	}
+}
+
+func TestAvailability(t *testing.T) {
+	t.Parallel()
+
+	settings := &types.AvailabilitySettings{
+		MinReplicas:         3,
+		PodDisruptionBudget: &types.PodDisruptionPolicy{MaxUnavailable: "25%"},
+		TopologySpread:      &types.TopologySpreadPolicy{},
+	}
+	ctx := &pipeline.HookContext{EnvSettings: &types.EnvSettings{Spec: types.EnvSettingsSpec{Availability: settings}}}
+
+	web := deployment("web", 3)
+	worker := deployment("worker", 1)
+	got, err := Availability{}.PostRender(ctx, []map[string]any{web, worker})
+	if err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+
+	want := []map[string]any{
+		web,
+		worker,
+		{
+			"apiVersion": "policy/v1",
+			"kind":       "PodDisruptionBudget",
+			"metadata":   map[string]any{"name": "web", "namespace": "prod"},
+			"spec": map[string]any{
+				"maxUnavailable": "25%",
+				"selector":       map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("resources mismatch (-want +got):\n%s", diff)
+	}
+
+	wantSpread := []any{
+		map[string]any{
+			"maxSkew":           1,
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "ScheduleAnyway",
+			"labelSelector":     map[string]any{"matchLabels": map[string]any{"app": "web"}},
+		},
+	}
+	podSpec, _ := nestedMap(web, "spec", "template", "spec")
+	if diff := cmp.Diff(wantSpread, podSpec["topologySpreadConstraints"]); diff != "" {
+		t.Errorf("topologySpreadConstraints mismatch (-want +got):\n%s", diff)
+	}
+	if podSpec, _ := nestedMap(worker, "spec", "template", "spec"); len(podSpec) != 0 {
+		t.Errorf("worker below threshold was modified: %v", podSpec)
+	}
+}
+
+func TestAvailabilityKeepsTemplatedBudget(t *testing.T) {
+	t.Parallel()
+
+	settings := &types.AvailabilitySettings{PodDisruptionBudget: &types.PodDisruptionPolicy{}}
+	ctx := &pipeline.HookContext{EnvSettings: &types.EnvSettings{Spec: types.EnvSettingsSpec{Availability: settings}}}
+	existing := map[string]any{
+		"apiVersion": "policy/v1",
+		"kind":       "PodDisruptionBudget",
+		"metadata":   map[string]any{"name": "web-pdb"},
+		"spec":       map[string]any{"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}}},
+	}
+
+	got, err := Availability{}.PostRender(ctx, []map[string]any{deployment("web", 2), existing})
+	if err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("PostRender() returned %d resources, want 2", len(got))
+	}
+}
+
+func TestAvailabilityDisabledWithoutSettings(t *testing.T) {
+	t.Parallel()
+
+	got, err := Availability{}.PostRender(&pipeline.HookContext{}, []map[string]any{deployment("web", 5)})
+	if err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+	if diff := cmp.Diff([]map[string]any{deployment("web", 5)}, got); diff != "" {
+		t.Errorf("resources mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,50 @@
+package platform
+
+import (
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+)
+
+// CommonLabels is a post-render hook that adds labels to every rendered resource and to the pod
+// templates of workloads. Labels the templates already set win, and selectors are never touched
+// because they are immutable on existing workloads.
+type CommonLabels struct {
+	pipeline.NopHooks
+	Labels map[string]string
+}
+
+var _ pipeline.Hooks = CommonLabels{}
+
+func (CommonLabels) Name() string { return "common-labels" }
+
+func (c CommonLabels) PostRender(_ *pipeline.HookContext, resources []map[string]any) ([]map[string]any, error) {
+	if len(c.Labels) == 0 {
+		return resources, nil
+	}
+	for _, resource := range resources {
+		c.apply(ensureMap(resource, "metadata"))
+		if template, ok := podTemplateOf(resource); ok {
+			c.apply(ensureMap(template, "metadata"))
+		}
+	}
+	return resources, nil
+}
+
+func (c CommonLabels) apply(metadata map[string]any) {
+	labels := ensureMap(metadata, "labels")
+	for key, value := range c.Labels {
+		if _, ok := labels[key]; !ok {
+			labels[key] = value
+		}
+	}
+}
+
+// podTemplateOf returns the pod template of the workload kinds that embed one.
+func podTemplateOf(resource map[string]any) (map[string]any, bool) {
+	switch resource["kind"] {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		return nestedMap(resource, "spec", "template")
+	case "CronJob":
+		return nestedMap(resource, "spec", "jobTemplate", "spec", "template")
+	}
+	return nil, false
+}
@@ -0,0 +1,44 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCommonLabels(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{
+		{
+			"kind":     "Deployment",
+			"metadata": map[string]any{"name": "web", "labels": map[string]any{"team": "payments"}},
+			"spec": map[string]any{
+				"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}},
+				"template": map[string]any{"metadata": map[string]any{"labels": map[string]any{"app": "web"}}},
+			},
+		},
+		{"kind": "Service", "metadata": map[string]any{"name": "web"}},
+	}
+
+	hook := CommonLabels{Labels: map[string]string{"team": "platform", "cost-center": "42"}}
+	got, err := hook.PostRender(nil, resources)
+	if err != nil {
+		t.Fatalf("PostRender() error = %v", err)
+	}
+
+	want := []map[string]any{
+		{
+			"kind":     "Deployment",
+			"metadata": map[string]any{"name": "web", "labels": map[string]any{"team": "payments", "cost-center": "42"}},
+			"spec": map[string]any{
+				"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}},
+				"template": map[string]any{"metadata": map[string]any{"labels": map[string]any{"app": "web", "team": "platform", "cost-center": "42"}}},
+			},
+		},
+		{"kind": "Service", "metadata": map[string]any{"name": "web", "labels": map[string]any{"team": "platform", "cost-center": "42"}}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("PostRender() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,197 @@
+package platform
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+)
+
+// Hardening rules enforced by Security. Definitions reference them in schema.securityExemptions.
+const (
+	RuleRunAsNonRoot   = "runAsNonRoot"
+	RuleCapabilities   = "capabilities"
+	RuleSeccompProfile = "seccompProfile"
+)
+
+// Security is a post-render hook that enforces the baseline Pod Security Standards on every pod
+// template: pods run as non-root, containers drop all capabilities, and the RuntimeDefault seccomp
+// profile applies. A definition may let Components opt out of a rule by mapping it to a boolean
+// parameter in schema.securityExemptions. Every change is reported to Events as a Normal event.
+type Security struct {
+	pipeline.NopHooks
+	// Events receives one SecurityContextHardened event per changed resource; nil discards them.
+	Events events.Sink
+}
+
+var _ pipeline.Hooks = Security{}
+
+func (Security) Name() string { return "security" }
+
+func (s Security) PostRender(ctx *pipeline.HookContext, resources []map[string]any) ([]map[string]any, error) {
+	exempt, err := exemptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range resources {
+		podSpec, ok := podSpecOf(resource)
+		if !ok {
+			continue
+		}
+		var changes []string
+		if !exempt[RuleRunAsNonRoot] {
+			changes = append(changes, hardenRunAsNonRoot(podSpec)...)
+		}
+		if !exempt[RuleCapabilities] {
+			changes = append(changes, hardenCapabilities(podSpec)...)
+		}
+		if !exempt[RuleSeccompProfile] {
+			changes = append(changes, hardenSeccomp(podSpec)...)
+		}
+		if len(changes) > 0 && ctx.Component != nil {
+			events.Normalf(s.Events, ctx.Component, events.ReasonSecurityHardened,
+				"%s %s: %s", resource["kind"], resourceMeta(resource, "name"), strings.Join(changes, "; "))
+		}
+	}
+	return resources, nil
+}
+
+// exemptions resolves which rules the Component opted out of. Exemption parameters are read from the
+// Component as written, so an unset parameter never exempts.
+func exemptions(ctx *pipeline.HookContext) (map[string]bool, error) {
+	exempt := map[string]bool{}
+	if ctx.Definition == nil || ctx.Component == nil {
+		return exempt, nil
+	}
+	declared := ctx.Definition.Spec.Schema.SecurityExemptions
+	rules := make([]string, 0, len(declared))
+	for rule := range declared {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+
+	for _, rule := range rules {
+		switch rule {
+		case RuleRunAsNonRoot, RuleCapabilities, RuleSeccompProfile:
+		default:
+			return nil, fmt.Errorf("unknown security exemption rule %q (want %s, %s or %s)",
+				rule, RuleRunAsNonRoot, RuleCapabilities, RuleSeccompProfile)
+		}
+		value, ok := lookupParameter(ctx.Component.Spec.Parameters, declared[rule])
+		if !ok {
+			continue
+		}
+		enabled, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("security exemption parameter %s must be a boolean, got %T", declared[rule], value)
+		}
+		exempt[rule] = enabled
+	}
+	return exempt, nil
+}
+
+func lookupParameter(parameters map[string]any, path string) (any, bool) {
+	var current any = parameters
+	for _, key := range strings.Split(strings.TrimPrefix(path, "spec."), ".") {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// podSpecOf returns the pod spec of Pods and of the workload kinds that embed a pod template.
+func podSpecOf(resource map[string]any) (map[string]any, bool) {
+	switch resource["kind"] {
+	case "Pod":
+		return nestedMap(resource, "spec")
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		return nestedMap(resource, "spec", "template", "spec")
+	case "CronJob":
+		return nestedMap(resource, "spec", "jobTemplate", "spec", "template", "spec")
+	}
+	return nil, false
+}
+
+func hardenRunAsNonRoot(podSpec map[string]any) []string {
+	var changes []string
+	if securityContext := ensureMap(podSpec, "securityContext"); securityContext["runAsNonRoot"] != true {
+		securityContext["runAsNonRoot"] = true
+		changes = append(changes, "set runAsNonRoot")
+	}
+	forEachContainer(podSpec, func(name string, container map[string]any) {
+		securityContext, ok := container["securityContext"].(map[string]any)
+		if !ok {
+			return
+		}
+		if value, set := securityContext["runAsNonRoot"]; set && value != true {
+			securityContext["runAsNonRoot"] = true
+			changes = append(changes, fmt.Sprintf("set runAsNonRoot on container %s", name))
+		}
+	})
+	return changes
+}
+
+func hardenCapabilities(podSpec map[string]any) []string {
+	var changes []string
+	forEachContainer(podSpec, func(name string, container map[string]any) {
+		capabilities := ensureMap(ensureMap(container, "securityContext"), "capabilities")
+		drop, _ := capabilities["drop"].([]any)
+		for _, capability := range drop {
+			if capability == "ALL" {
+				return
+			}
+		}
+		capabilities["drop"] = append(drop, "ALL")
+		changes = append(changes, fmt.Sprintf("dropped ALL capabilities on container %s", name))
+	})
+	return changes
+}
+
+func hardenSeccomp(podSpec map[string]any) []string {
+	var changes []string
+	securityContext := ensureMap(podSpec, "securityContext")
+	if profile, _ := securityContext["seccompProfile"].(map[string]any); profile == nil || profile["type"] == "Unconfined" {
+		securityContext["seccompProfile"] = map[string]any{"type": "RuntimeDefault"}
+		changes = append(changes, "set seccompProfile RuntimeDefault")
+	}
+	forEachContainer(podSpec, func(name string, container map[string]any) {
+		securityContext, _ := container["securityContext"].(map[string]any)
+		profile, _ := securityContext["seccompProfile"].(map[string]any)
+		if profile != nil && profile["type"] == "Unconfined" {
+			securityContext["seccompProfile"] = map[string]any{"type": "RuntimeDefault"}
+			changes = append(changes, fmt.Sprintf("set seccompProfile RuntimeDefault on container %s", name))
+		}
+	})
+	return changes
+}
+
+func forEachContainer(podSpec map[string]any, fn func(name string, container map[string]any)) {
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _ := podSpec[field].([]any)
+		for _, item := range containers {
+			container, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := container["name"].(string)
+			fn(name, container)
+		}
+	}
+}
+
+func ensureMap(parent map[string]any, key string) map[string]any {
+	child, ok := parent[key].(map[string]any)
+	if !ok {
+		child = map[string]any{}
+		parent[key] = child
+	}
+	return child
+}
@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/events"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSecurity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		parameters  map[string]any
+		podSpec     map[string]any
+		wantPodSpec map[string]any
+		wantEvents  []string
+	}{
+		{
+			name: "hardens an unconfigured pod",
+			podSpec: map[string]any{
+				"containers": []any{map[string]any{"name": "app"}},
+			},
+			wantPodSpec: map[string]any{
+				"securityContext": map[string]any{
+					"runAsNonRoot":   true,
+					"seccompProfile": map[string]any{"type": "RuntimeDefault"},
+				},
+				"containers": []any{
+					map[string]any{
+						"name": "app",
+						"securityContext": map[string]any{
+							"capabilities": map[string]any{"drop": []any{"ALL"}},
+						},
+					},
+				},
+			},
+			wantEvents: []string{"Deployment web: set runAsNonRoot; dropped ALL capabilities on container app; set seccompProfile RuntimeDefault"},
+		},
+		{
+			name: "compliant pod is left alone",
+			podSpec: map[string]any{
+				"securityContext": map[string]any{
+					"runAsNonRoot":   true,
+					"seccompProfile": map[string]any{"type": "Localhost"},
+				},
+				"containers": []any{
+					map[string]any{
+						"name": "app",
+						"securityContext": map[string]any{
+							"capabilities": map[string]any{"drop": []any{"ALL"}, "add": []any{"NET_BIND_SERVICE"}},
+						},
+					},
+				},
+			},
+			wantPodSpec: map[string]any{
+				"securityContext": map[string]any{
+					"runAsNonRoot":   true,
+					"seccompProfile": map[string]any{"type": "Localhost"},
+				},
+				"containers": []any{
+					map[string]any{
+						"name": "app",
+						"securityContext": map[string]any{
+							"capabilities": map[string]any{"drop": []any{"ALL"}, "add": []any{"NET_BIND_SERVICE"}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:       "exempted rule is skipped",
+			parameters: map[string]any{"security": map[string]any{"allowRoot": true}},
+			podSpec: map[string]any{
+				"securityContext": map[string]any{"runAsNonRoot": false},
+				"containers":      []any{map[string]any{"name": "app"}},
+			},
+			wantPodSpec: map[string]any{
+				"securityContext": map[string]any{
+					"runAsNonRoot":   false,
+					"seccompProfile": map[string]any{"type": "RuntimeDefault"},
+				},
+				"containers": []any{
+					map[string]any{
+						"name": "app",
+						"securityContext": map[string]any{
+							"capabilities": map[string]any{"drop": []any{"ALL"}},
+						},
+					},
+				},
+			},
+			wantEvents: []string{"Deployment web: dropped ALL capabilities on container app; set seccompProfile RuntimeDefault"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			definition := &types.ComponentTypeDefinition{}
+			definition.Spec.Schema.SecurityExemptions = map[string]string{RuleRunAsNonRoot: "spec.security.allowRoot"}
+			component := &types.Component{Metadata: types.Metadata{Name: "web"}}
+			component.Spec.Parameters = tt.parameters
+			resource := map[string]any{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]any{"name": "web"},
+				"spec":       map[string]any{"template": map[string]any{"spec": tt.podSpec}},
+			}
+
+			recorder := events.NewRecorder()
+			ctx := &pipeline.HookContext{Definition: definition, Component: component}
+			if _, err := (Security{Events: recorder}).PostRender(ctx, []map[string]any{resource}); err != nil {
+				t.Fatalf("PostRender() error = %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantPodSpec, tt.podSpec); diff != "" {
+				t.Errorf("pod spec mismatch (-want +got):\n%s", diff)
+			}
+			var messages []string
+			for _, event := range recorder.Events() {
+				messages = append(messages, event.Message)
+			}
+			if diff := cmp.Diff(tt.wantEvents, messages); diff != "" {
+				t.Errorf("events mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSecurityRejectsUnknownExemption(t *testing.T) {
+	t.Parallel()
+
+	definition := &types.ComponentTypeDefinition{}
+	definition.Spec.Schema.SecurityExemptions = map[string]string{"privileged": "spec.privileged"}
+	ctx := &pipeline.HookContext{Definition: definition, Component: &types.Component{}}
+
+	if _, err := (Security{}).PostRender(ctx, nil); err == nil {
+		t.Errorf("PostRender() with unknown exemption rule succeeded, want error")
+	}
+}
@@ -0,0 +1,197 @@
+package presets
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+var optionsType = cel.MapType(cel.StringType, cel.DynType)
+
+// Library registers the builders as CEL functions:
+//
+//	k8s.container(name, image[, opts])       -> Container
+//	k8s.probe(handler[, opts])               -> Probe
+//	k8s.httpGet(path, port)                  -> probe handler
+//	k8s.tcpSocket(port), k8s.grpc(port)      -> probe handler
+//	k8s.exec(command)                        -> probe handler
+//	k8s.volumeMount(name, mountPath[, opts]) -> VolumeMount
+//	k8s.servicePort(name, port[, opts])      -> ServicePort
+//	k8s.nodeSelector(platform)               -> nodeSelector
+//	k8s.tolerations(platform)                -> list of Tolerations
+//
+// Invalid arguments fail the expression with the builder's error.
+func Library() cel.EnvOption {
+	return cel.Lib(library{})
+}
+
+type library struct{}
+
+func (library) LibraryName() string { return "renderer2.presets" }
+
+func (library) ProgramOptions() []cel.ProgramOption { return nil }
+
+func (library) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Function("k8s.container",
+			cel.Overload("k8s_container_string_string", []*cel.Type{cel.StringType, cel.StringType}, optionsType,
+				cel.BinaryBinding(func(name, image ref.Val) ref.Val {
+					return result("k8s.container", func() (map[string]any, error) {
+						return Container(str(name), str(image), nil)
+					})
+				})),
+			cel.Overload("k8s_container_string_string_map", []*cel.Type{cel.StringType, cel.StringType, optionsType}, optionsType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return result("k8s.container", func() (map[string]any, error) {
+						return Container(str(args[0]), str(args[1]), nativeMap(args[2]))
+					})
+				})),
+		),
+		cel.Function("k8s.probe",
+			cel.Overload("k8s_probe_map", []*cel.Type{optionsType}, optionsType,
+				cel.UnaryBinding(func(handler ref.Val) ref.Val {
+					return result("k8s.probe", func() (map[string]any, error) {
+						return Probe(nativeMap(handler), nil)
+					})
+				})),
+			cel.Overload("k8s_probe_map_map", []*cel.Type{optionsType, optionsType}, optionsType,
+				cel.BinaryBinding(func(handler, opts ref.Val) ref.Val {
+					return result("k8s.probe", func() (map[string]any, error) {
+						return Probe(nativeMap(handler), nativeMap(opts))
+					})
+				})),
+		),
+		cel.Function("k8s.httpGet",
+			cel.Overload("k8s_http_get_string_dyn", []*cel.Type{cel.StringType, cel.DynType}, optionsType,
+				cel.BinaryBinding(func(path, port ref.Val) ref.Val {
+					return result("k8s.httpGet", func() (map[string]any, error) {
+						return HTTPGet(str(path), native(port))
+					})
+				})),
+		),
+		cel.Function("k8s.tcpSocket",
+			cel.Overload("k8s_tcp_socket_dyn", []*cel.Type{cel.DynType}, optionsType,
+				cel.UnaryBinding(func(port ref.Val) ref.Val {
+					return result("k8s.tcpSocket", func() (map[string]any, error) {
+						return TCPSocket(native(port))
+					})
+				})),
+		),
+		cel.Function("k8s.grpc",
+			cel.Overload("k8s_grpc_dyn", []*cel.Type{cel.DynType}, optionsType,
+				cel.UnaryBinding(func(port ref.Val) ref.Val {
+					return result("k8s.grpc", func() (map[string]any, error) {
+						return GRPC(native(port))
+					})
+				})),
+		),
+		cel.Function("k8s.exec",
+			cel.Overload("k8s_exec_list", []*cel.Type{cel.ListType(cel.DynType)}, optionsType,
+				cel.UnaryBinding(func(command ref.Val) ref.Val {
+					return result("k8s.exec", func() (map[string]any, error) {
+						list, _ := native(command).([]any)
+						return Exec(list)
+					})
+				})),
+		),
+		cel.Function("k8s.volumeMount",
+			cel.Overload("k8s_volume_mount_string_string", []*cel.Type{cel.StringType, cel.StringType}, optionsType,
+				cel.BinaryBinding(func(name, mountPath ref.Val) ref.Val {
+					return result("k8s.volumeMount", func() (map[string]any, error) {
+						return VolumeMount(str(name), str(mountPath), nil)
+					})
+				})),
+			cel.Overload("k8s_volume_mount_string_string_map", []*cel.Type{cel.StringType, cel.StringType, optionsType}, optionsType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return result("k8s.volumeMount", func() (map[string]any, error) {
+						return VolumeMount(str(args[0]), str(args[1]), nativeMap(args[2]))
+					})
+				})),
+		),
+		cel.Function("k8s.servicePort",
+			cel.Overload("k8s_service_port_string_dyn", []*cel.Type{cel.StringType, cel.DynType}, optionsType,
+				cel.BinaryBinding(func(name, port ref.Val) ref.Val {
+					return result("k8s.servicePort", func() (map[string]any, error) {
+						return ServicePort(str(name), native(port), nil)
+					})
+				})),
+			cel.Overload("k8s_service_port_string_dyn_map", []*cel.Type{cel.StringType, cel.DynType, optionsType}, optionsType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return result("k8s.servicePort", func() (map[string]any, error) {
+						return ServicePort(str(args[0]), native(args[1]), nativeMap(args[2]))
+					})
+				})),
+		),
+		cel.Function("k8s.nodeSelector",
+			cel.Overload("k8s_node_selector_map", []*cel.Type{optionsType}, optionsType,
+				cel.UnaryBinding(func(platform ref.Val) ref.Val {
+					return result("k8s.nodeSelector", func() (map[string]any, error) {
+						return NodeSelector(nativeMap(platform))
+					})
+				})),
+		),
+		cel.Function("k8s.tolerations",
+			cel.Overload("k8s_tolerations_map", []*cel.Type{optionsType}, cel.ListType(optionsType),
+				cel.UnaryBinding(func(platform ref.Val) ref.Val {
+					return result("k8s.tolerations", func() ([]any, error) {
+						return Tolerations(nativeMap(platform))
+					})
+				})),
+		),
+	}
+}
+
+// result converts a builder's output into a CEL value, or an error naming the function.
+func result[T any](function string, build func() (T, error)) ref.Val {
+	value, err := build()
+	if err != nil {
+		return types.NewErr("%s: %v", function, err)
+	}
+	return types.DefaultTypeAdapter.NativeToValue(value)
+}
+
+func str(value ref.Val) string {
+	s, _ := value.Value().(string)
+	return s
+}
+
+func nativeMap(value ref.Val) map[string]any {
+	m, _ := native(value).(map[string]any)
+	return m
+}
+
+// native converts CEL maps and lists, at any depth, into map[string]any and []any.
+func native(value any) any {
+	switch typed := value.(type) {
+	case ref.Val:
+		return native(typed.Value())
+	case map[ref.Val]ref.Val:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[fmt.Sprint(k.Value())] = native(v)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[k] = native(v)
+		}
+		return result
+	case []ref.Val:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = native(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = native(v)
+		}
+		return result
+	default:
+		return typed
+	}
+}
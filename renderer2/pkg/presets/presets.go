@@ -0,0 +1,334 @@
+// Package presets builds common Kubernetes template fragments (containers, probes, volume mounts,
+// service ports) from a few arguments, rejecting misspelled fields and invalid values that a raw
+// map would carry into the rendered output. The builders are exposed to templates as k8s.* CEL
+// functions (see Library).
+package presets
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dnsLabel matches container names and, up to 15 characters, port names.
+var dnsLabel = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+var (
+	containerFields = fieldSet("name", "image", "command", "args", "workingDir", "ports", "envFrom", "env",
+		"resources", "resizePolicy", "restartPolicy", "volumeMounts", "volumeDevices", "livenessProbe",
+		"readinessProbe", "startupProbe", "lifecycle", "terminationMessagePath", "terminationMessagePolicy",
+		"imagePullPolicy", "securityContext", "stdin", "stdinOnce", "tty")
+	probeFields = fieldSet("exec", "httpGet", "tcpSocket", "grpc", "initialDelaySeconds", "timeoutSeconds",
+		"periodSeconds", "successThreshold", "failureThreshold", "terminationGracePeriodSeconds")
+	probeHandlers    = []string{"exec", "grpc", "httpGet", "tcpSocket"}
+	httpGetFields    = fieldSet("path", "port", "host", "scheme", "httpHeaders")
+	volumeMountField = fieldSet("name", "readOnly", "recursiveReadOnly", "mountPath", "subPath",
+		"mountPropagation", "subPathExpr")
+	servicePortFields = fieldSet("name", "protocol", "appProtocol", "port", "targetPort", "nodePort")
+	platformFields    = fieldSet("os", "arch")
+)
+
+// Node labels the scheduler matches against the platform.
+const (
+	LabelOS   = "kubernetes.io/os"
+	LabelArch = "kubernetes.io/arch"
+)
+
+var (
+	knownOS    = []string{"linux", "windows"}
+	knownArchs = []string{"386", "amd64", "arm", "arm64", "ppc64le", "riscv64", "s390x"}
+)
+
+// Container returns a container with name and image, plus any other Container fields in opts.
+func Container(name, image string, opts map[string]any) (map[string]any, error) {
+	if !dnsLabel.MatchString(name) || len(name) > 63 {
+		return nil, fmt.Errorf("container name %q must be a DNS label", name)
+	}
+	if image == "" {
+		return nil, fmt.Errorf("container %s needs an image", name)
+	}
+	container, err := withOptions(map[string]any{"name": name, "image": image}, opts, containerFields)
+	if err != nil {
+		return nil, fmt.Errorf("container %s: %w", name, err)
+	}
+	if policy, ok := container["imagePullPolicy"]; ok {
+		if err := oneOf("imagePullPolicy", policy, "Always", "IfNotPresent", "Never"); err != nil {
+			return nil, fmt.Errorf("container %s: %w", name, err)
+		}
+	}
+	return container, nil
+}
+
+// HTTPGet returns a probe handler issuing GET path against port, a number or a named port.
+func HTTPGet(path string, port any) (map[string]any, error) {
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("httpGet path %q must start with /", path)
+	}
+	p, err := containerPort(port)
+	if err != nil {
+		return nil, fmt.Errorf("httpGet: %w", err)
+	}
+	return map[string]any{"httpGet": map[string]any{"path": path, "port": p}}, nil
+}
+
+// TCPSocket returns a probe handler opening a connection to port.
+func TCPSocket(port any) (map[string]any, error) {
+	p, err := containerPort(port)
+	if err != nil {
+		return nil, fmt.Errorf("tcpSocket: %w", err)
+	}
+	return map[string]any{"tcpSocket": map[string]any{"port": p}}, nil
+}
+
+// GRPC returns a probe handler calling the gRPC health service on port.
+func GRPC(port any) (map[string]any, error) {
+	p, err := containerPort(port)
+	if err != nil {
+		return nil, fmt.Errorf("grpc: %w", err)
+	}
+	if _, ok := p.(int64); !ok {
+		return nil, fmt.Errorf("grpc: port must be a number, got %q", p)
+	}
+	return map[string]any{"grpc": map[string]any{"port": p}}, nil
+}
+
+// Exec returns a probe handler running command in the container.
+func Exec(command []any) (map[string]any, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("exec needs a command")
+	}
+	for i, arg := range command {
+		if _, ok := arg.(string); !ok {
+			return nil, fmt.Errorf("exec: command[%d] must be a string, got %T", i, arg)
+		}
+	}
+	return map[string]any{"exec": map[string]any{"command": append([]any(nil), command...)}}, nil
+}
+
+// Probe returns a probe from exactly one handler (see HTTPGet, TCPSocket, GRPC and Exec) and the
+// timing fields in opts.
+func Probe(handler, opts map[string]any) (map[string]any, error) {
+	if err := checkFields(handler, probeFields); err != nil {
+		return nil, fmt.Errorf("probe: %w", err)
+	}
+	probe, err := withOptions(handler, opts, probeFields)
+	if err != nil {
+		return nil, fmt.Errorf("probe: %w", err)
+	}
+	var handlers []string
+	for _, name := range probeHandlers {
+		if _, ok := probe[name]; ok {
+			handlers = append(handlers, name)
+		}
+	}
+	if len(handlers) != 1 {
+		return nil, fmt.Errorf("probe needs exactly one of %s, got %d", strings.Join(probeHandlers, ", "), len(handlers))
+	}
+	if httpGet, ok := probe["httpGet"].(map[string]any); ok {
+		if err := checkFields(httpGet, httpGetFields); err != nil {
+			return nil, fmt.Errorf("probe httpGet: %w", err)
+		}
+	}
+	for _, field := range []string{"initialDelaySeconds", "timeoutSeconds", "periodSeconds", "successThreshold", "failureThreshold", "terminationGracePeriodSeconds"} {
+		value, ok := probe[field]
+		if !ok {
+			continue
+		}
+		n, ok := integer(value)
+		if !ok || n < 0 {
+			return nil, fmt.Errorf("probe %s must be a non-negative integer, got %v", field, value)
+		}
+		probe[field] = n
+	}
+	return probe, nil
+}
+
+// VolumeMount mounts the volume name at mountPath, with any other VolumeMount fields in opts.
+func VolumeMount(name, mountPath string, opts map[string]any) (map[string]any, error) {
+	if name == "" {
+		return nil, fmt.Errorf("volumeMount needs a volume name")
+	}
+	if !strings.HasPrefix(mountPath, "/") {
+		return nil, fmt.Errorf("volumeMount %s: mountPath %q must be absolute", name, mountPath)
+	}
+	mount, err := withOptions(map[string]any{"name": name, "mountPath": mountPath}, opts, volumeMountField)
+	if err != nil {
+		return nil, fmt.Errorf("volumeMount %s: %w", name, err)
+	}
+	if propagation, ok := mount["mountPropagation"]; ok {
+		if err := oneOf("mountPropagation", propagation, "None", "HostToContainer", "Bidirectional"); err != nil {
+			return nil, fmt.Errorf("volumeMount %s: %w", name, err)
+		}
+	}
+	return mount, nil
+}
+
+// ServicePort exposes port under name, targeting the same container port and TCP unless opts
+// sets targetPort or protocol.
+func ServicePort(name string, port any, opts map[string]any) (map[string]any, error) {
+	if name != "" && (!dnsLabel.MatchString(name) || len(name) > 15) {
+		return nil, fmt.Errorf("service port name %q must be at most 15 lowercase alphanumeric characters or '-'", name)
+	}
+	p, ok := integer(port)
+	if !ok || p < 1 || p > 65535 {
+		return nil, fmt.Errorf("service port %s: port must be a number between 1 and 65535, got %v", name, port)
+	}
+	servicePort := map[string]any{"port": p, "targetPort": p, "protocol": "TCP"}
+	if name != "" {
+		servicePort["name"] = name
+	}
+	servicePort, err := withOptions(servicePort, opts, servicePortFields)
+	if err != nil {
+		return nil, fmt.Errorf("service port %s: %w", name, err)
+	}
+	if err := oneOf("protocol", servicePort["protocol"], "TCP", "UDP", "SCTP"); err != nil {
+		return nil, fmt.Errorf("service port %s: %w", name, err)
+	}
+	if servicePort["targetPort"], err = containerPort(servicePort["targetPort"]); err != nil {
+		return nil, fmt.Errorf("service port %s: targetPort: %w", name, err)
+	}
+	return servicePort, nil
+}
+
+// NodeSelector pins pods to nodes of the platform's OS and architecture (the template `platform`
+// variable). Empty fields are left unconstrained.
+func NodeSelector(platform map[string]any) (map[string]any, error) {
+	labels, err := platformLabels(platform)
+	if err != nil {
+		return nil, fmt.Errorf("nodeSelector: %w", err)
+	}
+	selector := make(map[string]any, len(labels))
+	for _, label := range labels {
+		selector[label.key] = label.value
+	}
+	return selector, nil
+}
+
+// Tolerations tolerates the NoSchedule taints clusters put on node pools of a non-default OS or
+// architecture, e.g. kubernetes.io/arch=arm64, for the platform's OS and architecture.
+func Tolerations(platform map[string]any) ([]any, error) {
+	labels, err := platformLabels(platform)
+	if err != nil {
+		return nil, fmt.Errorf("tolerations: %w", err)
+	}
+	tolerations := make([]any, 0, len(labels))
+	for _, label := range labels {
+		tolerations = append(tolerations, map[string]any{
+			"key": label.key, "operator": "Equal", "value": label.value, "effect": "NoSchedule",
+		})
+	}
+	return tolerations, nil
+}
+
+// ValidatePlatform reports an OS or architecture NodeSelector and Tolerations would reject.
+func ValidatePlatform(os, arch string) error {
+	_, err := platformLabels(map[string]any{"os": os, "arch": arch})
+	return err
+}
+
+type nodeLabel struct{ key, value string }
+
+// platformLabels validates platform and returns its set fields as node labels, OS first.
+func platformLabels(platform map[string]any) ([]nodeLabel, error) {
+	if err := checkFields(platform, platformFields); err != nil {
+		return nil, err
+	}
+	var labels []nodeLabel
+	for _, field := range []struct {
+		name, label string
+		known       []string
+	}{
+		{"os", LabelOS, knownOS},
+		{"arch", LabelArch, knownArchs},
+	} {
+		value, ok := platform[field.name]
+		if !ok || value == "" {
+			continue
+		}
+		if err := oneOf(field.name, value, field.known...); err != nil {
+			return nil, err
+		}
+		labels = append(labels, nodeLabel{key: field.label, value: value.(string)})
+	}
+	return labels, nil
+}
+
+// withOptions copies base and overlays opts, rejecting keys outside allowed.
+func withOptions(base, opts map[string]any, allowed map[string]bool) (map[string]any, error) {
+	if err := checkFields(opts, allowed); err != nil {
+		return nil, err
+	}
+	result := make(map[string]any, len(base)+len(opts))
+	for key, value := range base {
+		result[key] = value
+	}
+	for key, value := range opts {
+		result[key] = value
+	}
+	return result, nil
+}
+
+func checkFields(value map[string]any, allowed map[string]bool) error {
+	var unknown []string
+	for key := range value {
+		if !allowed[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown field(s) %s", strings.Join(unknown, ", "))
+}
+
+// containerPort validates a port number or IANA service name.
+func containerPort(port any) (any, error) {
+	if name, ok := port.(string); ok {
+		if !dnsLabel.MatchString(name) || len(name) > 15 || !strings.ContainsAny(name, "abcdefghijklmnopqrstuvwxyz") {
+			return nil, fmt.Errorf("invalid port name %q", name)
+		}
+		return name, nil
+	}
+	p, ok := integer(port)
+	if !ok || p < 1 || p > 65535 {
+		return nil, fmt.Errorf("port must be a number between 1 and 65535 or a port name, got %v", port)
+	}
+	return p, nil
+}
+
+// integer accepts the integer types CEL and YAML produce, and whole floats from JSON.
+func integer(value any) (int64, bool) {
+	switch n := value.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= math.MaxInt64
+	case float64:
+		return int64(n), n == math.Trunc(n) && math.Abs(n) < 1<<53
+	default:
+		return 0, false
+	}
+}
+
+func oneOf(field string, value any, allowed ...string) error {
+	s, _ := value.(string)
+	for _, candidate := range allowed {
+		if s == candidate {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s must be one of %s, got %v", field, strings.Join(allowed, ", "), value)
+}
+
+func fieldSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
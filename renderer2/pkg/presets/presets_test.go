@@ -0,0 +1,215 @@
+package presets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBuilders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		build   func() (map[string]any, error)
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name: "container with options",
+			build: func() (map[string]any, error) {
+				return Container("app", "app:v1", map[string]any{"imagePullPolicy": "IfNotPresent", "args": []any{"serve"}})
+			},
+			want: map[string]any{"name": "app", "image": "app:v1", "imagePullPolicy": "IfNotPresent", "args": []any{"serve"}},
+		},
+		{
+			name: "container rejects misspelled field",
+			build: func() (map[string]any, error) {
+				return Container("app", "app:v1", map[string]any{"imagePullPolicyy": "Always", "argz": []any{}})
+			},
+			wantErr: "container app: unknown field(s) argz, imagePullPolicyy",
+		},
+		{
+			name:    "container rejects invalid name",
+			build:   func() (map[string]any, error) { return Container("App_1", "app:v1", nil) },
+			wantErr: `container name "App_1" must be a DNS label`,
+		},
+		{
+			name: "container rejects unknown pull policy",
+			build: func() (map[string]any, error) {
+				return Container("app", "app:v1", map[string]any{"imagePullPolicy": "Sometimes"})
+			},
+			wantErr: "imagePullPolicy must be one of Always, IfNotPresent, Never",
+		},
+		{
+			name: "http probe with timings",
+			build: func() (map[string]any, error) {
+				handler, err := HTTPGet("/healthz", int64(8080))
+				if err != nil {
+					return nil, err
+				}
+				return Probe(handler, map[string]any{"periodSeconds": float64(10)})
+			},
+			want: map[string]any{"httpGet": map[string]any{"path": "/healthz", "port": int64(8080)}, "periodSeconds": int64(10)},
+		},
+		{
+			name: "tcp probe on named port",
+			build: func() (map[string]any, error) {
+				handler, err := TCPSocket("http")
+				if err != nil {
+					return nil, err
+				}
+				return Probe(handler, nil)
+			},
+			want: map[string]any{"tcpSocket": map[string]any{"port": "http"}},
+		},
+		{
+			name: "probe rejects two handlers",
+			build: func() (map[string]any, error) {
+				return Probe(map[string]any{"exec": map[string]any{}, "grpc": map[string]any{}}, nil)
+			},
+			wantErr: "probe needs exactly one of exec, grpc, httpGet, tcpSocket, got 2",
+		},
+		{
+			name: "probe rejects negative timing",
+			build: func() (map[string]any, error) {
+				return Probe(map[string]any{"exec": map[string]any{"command": []any{"true"}}}, map[string]any{"timeoutSeconds": int64(-1)})
+			},
+			wantErr: "probe timeoutSeconds must be a non-negative integer",
+		},
+		{
+			name:    "grpc rejects named port",
+			build:   func() (map[string]any, error) { return GRPC("grpc") },
+			wantErr: `grpc: port must be a number, got "grpc"`,
+		},
+		{
+			name:    "http rejects out of range port",
+			build:   func() (map[string]any, error) { return HTTPGet("/", int64(70000)) },
+			wantErr: "httpGet: port must be a number between 1 and 65535 or a port name",
+		},
+		{
+			name:    "exec rejects non-string argument",
+			build:   func() (map[string]any, error) { return Exec([]any{"sh", int64(1)}) },
+			wantErr: "exec: command[1] must be a string",
+		},
+		{
+			name: "volume mount",
+			build: func() (map[string]any, error) {
+				return VolumeMount("config", "/etc/app", map[string]any{"readOnly": true})
+			},
+			want: map[string]any{"name": "config", "mountPath": "/etc/app", "readOnly": true},
+		},
+		{
+			name:    "volume mount rejects relative path",
+			build:   func() (map[string]any, error) { return VolumeMount("config", "etc/app", nil) },
+			wantErr: `volumeMount config: mountPath "etc/app" must be absolute`,
+		},
+		{
+			name:  "service port defaults",
+			build: func() (map[string]any, error) { return ServicePort("http", int64(80), nil) },
+			want:  map[string]any{"name": "http", "port": int64(80), "targetPort": int64(80), "protocol": "TCP"},
+		},
+		{
+			name: "service port with named target",
+			build: func() (map[string]any, error) {
+				return ServicePort("http", int64(80), map[string]any{"targetPort": "web"})
+			},
+			want: map[string]any{"name": "http", "port": int64(80), "targetPort": "web", "protocol": "TCP"},
+		},
+		{
+			name: "service port rejects unknown protocol",
+			build: func() (map[string]any, error) {
+				return ServicePort("dns", int64(53), map[string]any{"protocol": "ICMP"})
+			},
+			wantErr: "service port dns: protocol must be one of TCP, UDP, SCTP, got ICMP",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.build()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPlatform(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		platform        map[string]any
+		wantSelector    map[string]any
+		wantTolerations []any
+		wantErr         string
+	}{
+		{
+			name:            "unconstrained",
+			platform:        map[string]any{"os": "", "arch": ""},
+			wantSelector:    map[string]any{},
+			wantTolerations: []any{},
+		},
+		{
+			name:         "linux arm64",
+			platform:     map[string]any{"os": "linux", "arch": "arm64"},
+			wantSelector: map[string]any{LabelOS: "linux", LabelArch: "arm64"},
+			wantTolerations: []any{
+				map[string]any{"key": LabelOS, "operator": "Equal", "value": "linux", "effect": "NoSchedule"},
+				map[string]any{"key": LabelArch, "operator": "Equal", "value": "arm64", "effect": "NoSchedule"},
+			},
+		},
+		{
+			name:     "unknown architecture",
+			platform: map[string]any{"arch": "aarch64"},
+			wantErr:  "arch must be one of 386, amd64, arm, arm64, ppc64le, riscv64, s390x, got aarch64",
+		},
+		{
+			name:     "misspelled field",
+			platform: map[string]any{"architecture": "arm64"},
+			wantErr:  "unknown field(s) architecture",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			selector, err := NodeSelector(tt.platform)
+			tolerations, tolerationsErr := Tolerations(tt.platform)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NodeSelector() error = %v, want %q", err, tt.wantErr)
+				}
+				if tolerationsErr == nil || !strings.Contains(tolerationsErr.Error(), tt.wantErr) {
+					t.Errorf("Tolerations() error = %v, want %q", tolerationsErr, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || tolerationsErr != nil {
+				t.Fatalf("unexpected errors: %v, %v", err, tolerationsErr)
+			}
+			if diff := cmp.Diff(tt.wantSelector, selector); diff != "" {
+				t.Errorf("NodeSelector() mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantTolerations, tolerations); diff != "" {
+				t.Errorf("Tolerations() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,276 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+)
+
+var (
+	// ErrTenantNotFound is returned when a lookup names a tenant that was never added.
+	ErrTenantNotFound = errors.New("tenant not found")
+	// ErrNotFound is returned when a definition or addon is not registered for the tenant.
+	ErrNotFound = errors.New("not found")
+	// ErrLimitExceeded is returned when a registration would exceed the tenant's limits.
+	ErrLimitExceeded = errors.New("tenant limit exceeded")
+)
+
+// Limits bounds what a single tenant may store in a shared registry. Zero means unlimited. Every
+// release of a definition or addon counts against its limit.
+type Limits struct {
+	MaxDefinitions int
+	MaxAddons      int
+	// MaxCachedRenders caps the per-tenant render cache kept by the render service.
+	MaxCachedRenders int
+	// MaxConcurrentRenders caps the tenant's in-flight renders in the render service, overriding
+	// the server-wide default quota.
+	MaxConcurrentRenders int
+}
+
+// Tenant identifies an isolated consumer of a shared render service.
+type Tenant struct {
+	ID     string
+	Limits Limits
+}
+
+// Registry holds ComponentTypeDefinitions and Addons partitioned by tenant. Lookups are always
+// scoped to one tenant, so tenants can register the same names without seeing each other's data.
+type Registry struct {
+	mu      sync.RWMutex
+	tenants map[string]*Scope
+	schemas *schema.Cache
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{tenants: map[string]*Scope{}, schemas: schema.NewCache(0)}
+}
+
+// SchemaCache returns the converted schemas of registered definitions and addons, for renderers
+// serving this registry (see component.WithSchemaCache). Entries are keyed by content digest, so
+// tenants registering identical schemas share them without seeing each other's definitions.
+func (r *Registry) SchemaCache() *schema.Cache {
+	return r.schemas
+}
+
+// AddTenant registers a tenant. Adding an existing tenant updates its limits and keeps its data.
+func (r *Registry) AddTenant(tenant Tenant) *Scope {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if scope, ok := r.tenants[tenant.ID]; ok {
+		scope.mu.Lock()
+		scope.tenant = tenant
+		scope.mu.Unlock()
+		return scope
+	}
+	scope := &Scope{
+		tenant:      tenant,
+		definitions: map[string][]*types.ComponentTypeDefinition{},
+		addons:      map[string][]*types.Addon{},
+	}
+	r.tenants[tenant.ID] = scope
+	return scope
+}
+
+// RemoveTenant drops a tenant and everything registered for it.
+func (r *Registry) RemoveTenant(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.tenants, id)
+}
+
+// Tenant returns the scoped view for a tenant.
+func (r *Registry) Tenant(id string) (*Scope, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	scope, ok := r.tenants[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTenantNotFound, id)
+	}
+	return scope, nil
+}
+
+// Tenants lists registered tenant IDs in sorted order.
+func (r *Registry) Tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Scope is a single tenant's view of the registry. It keeps every release (spec.version) of a
+// definition or addon registered under the same name, and lookups resolve the release pins of
+// Components and addon instances to the highest matching release.
+type Scope struct {
+	mu          sync.RWMutex
+	tenant      Tenant
+	definitions map[string][]*types.ComponentTypeDefinition
+	addons      map[string][]*types.Addon
+	generation  uint64
+}
+
+// Tenant returns the tenant this scope belongs to.
+func (s *Scope) Tenant() Tenant {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tenant
+}
+
+// Generation increases on every registration so callers can invalidate derived caches.
+func (s *Scope) Generation() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generation
+}
+
+// RegisterDefinition stores a ComponentTypeDefinition under its metadata name, replacing the one
+// registered with the same spec.version.
+func (s *Scope) RegisterDefinition(ctd *types.ComponentTypeDefinition) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := ctd.Metadata.Name
+	if err := versioning.CheckRelease("definition "+name, ctd.Spec.Version); err != nil {
+		return err
+	}
+	releases := s.definitions[name]
+	i := releaseIndex(releases, ctd.Spec.Version, func(ctd *types.ComponentTypeDefinition) string { return ctd.Spec.Version })
+	if i == len(releases) && s.tenant.Limits.MaxDefinitions > 0 && countReleases(s.definitions) >= s.tenant.Limits.MaxDefinitions {
+		return fmt.Errorf("%w: tenant %s may register at most %d definitions", ErrLimitExceeded, s.tenant.ID, s.tenant.Limits.MaxDefinitions)
+	}
+	if i == len(releases) {
+		releases = append(releases, nil)
+	}
+	releases[i] = ctd
+	s.definitions[name] = releases
+	s.generation++
+	return nil
+}
+
+// RegisterAddon stores an Addon under its metadata name, replacing the one registered with the
+// same spec.version.
+func (s *Scope) RegisterAddon(addon *types.Addon) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := addon.Metadata.Name
+	if err := versioning.CheckRelease("addon "+name, addon.Spec.Version); err != nil {
+		return err
+	}
+	releases := s.addons[name]
+	i := releaseIndex(releases, addon.Spec.Version, func(addon *types.Addon) string { return addon.Spec.Version })
+	if i == len(releases) && s.tenant.Limits.MaxAddons > 0 && countReleases(s.addons) >= s.tenant.Limits.MaxAddons {
+		return fmt.Errorf("%w: tenant %s may register at most %d addons", ErrLimitExceeded, s.tenant.ID, s.tenant.Limits.MaxAddons)
+	}
+	if i == len(releases) {
+		releases = append(releases, nil)
+	}
+	releases[i] = addon
+	s.addons[name] = releases
+	s.generation++
+	return nil
+}
+
+// Definition looks up the highest release of a ComponentTypeDefinition by name within the tenant.
+func (s *Scope) Definition(name string) (*types.ComponentTypeDefinition, error) {
+	return s.DefinitionRelease(name, "")
+}
+
+// DefinitionRelease looks up the highest release of a ComponentTypeDefinition by name that
+// satisfies pin, a semantic version or range, within the tenant.
+func (s *Scope) DefinitionRelease(name, pin string) (*types.ComponentTypeDefinition, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	releases, ok := s.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: definition %s for tenant %s", ErrNotFound, name, s.tenant.ID)
+	}
+	return selectRelease("definition "+name, pin, releases, func(ctd *types.ComponentTypeDefinition) string { return ctd.Spec.Version })
+}
+
+// DefinitionFor resolves the componentType and componentTypeRelease of a Component.
+func (s *Scope) DefinitionFor(component *types.Component) (*types.ComponentTypeDefinition, error) {
+	return s.DefinitionRelease(component.Spec.ComponentType, component.Spec.ComponentTypeRelease)
+}
+
+// Addon looks up the highest release of an Addon by name within the tenant.
+func (s *Scope) Addon(name string) (*types.Addon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.addonRelease(name, "")
+}
+
+func (s *Scope) addonRelease(name, pin string) (*types.Addon, error) {
+	releases, ok := s.addons[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: addon %s for tenant %s", ErrNotFound, name, s.tenant.ID)
+	}
+	return selectRelease("addon "+name, pin, releases, func(addon *types.Addon) string { return addon.Spec.Version })
+}
+
+// AddonsFor resolves the addons referenced by a Component, keyed by name as expected by
+// component.Renderer. Each instance's version pin selects the highest matching release; instances
+// of one addon must agree on it.
+func (s *Scope) AddonsFor(component *types.Component) (map[string]*types.Addon, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	addons := make(map[string]*types.Addon, len(component.Spec.Addons))
+	for _, instance := range component.Spec.Addons {
+		addon, err := s.addonRelease(instance.Name, instance.Version)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := addons[instance.Name]; ok && other != addon {
+			return nil, fmt.Errorf("instances of addon %s resolve to releases %s and %s; a component renders one release of an addon",
+				instance.Name, other.Spec.Version, addon.Spec.Version)
+		}
+		addons[instance.Name] = addon
+	}
+	return addons, nil
+}
+
+// releaseIndex returns the index of the release in releases, or len(releases) when it is new.
+func releaseIndex[T any](releases []T, release string, version func(T) string) int {
+	for i, registered := range releases {
+		if version(registered) == release {
+			return i
+		}
+	}
+	return len(releases)
+}
+
+func countReleases[T any](byName map[string][]T) int {
+	n := 0
+	for _, releases := range byName {
+		n += len(releases)
+	}
+	return n
+}
+
+func selectRelease[T any](what, pin string, releases []T, version func(T) string) (T, error) {
+	versions := make([]string, len(releases))
+	for i, release := range releases {
+		versions[i] = version(release)
+	}
+	i, err := versioning.SelectRelease(what, pin, versions)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("%w: %w", ErrNotFound, err)
+	}
+	return releases[i], nil
+}
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+func definition(name string) *types.ComponentTypeDefinition {
+	return &types.ComponentTypeDefinition{Metadata: types.Metadata{Name: name}}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	t.Parallel()
+
+	reg := New()
+	acme := reg.AddTenant(Tenant{ID: "acme"})
+	globex := reg.AddTenant(Tenant{ID: "globex"})
+
+	if err := acme.RegisterDefinition(definition("web-app")); err != nil {
+		t.Fatalf("RegisterDefinition() error = %v", err)
+	}
+	if err := acme.RegisterAddon(&types.Addon{Metadata: types.Metadata{Name: "pvc"}}); err != nil {
+		t.Fatalf("RegisterAddon() error = %v", err)
+	}
+
+	if _, err := acme.Definition("web-app"); err != nil {
+		t.Fatalf("Definition() error = %v", err)
+	}
+	if _, err := globex.Definition("web-app"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Definition() from other tenant error = %v, want ErrNotFound", err)
+	}
+	if _, err := globex.Addon("pvc"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Addon() from other tenant error = %v, want ErrNotFound", err)
+	}
+	if _, err := reg.Tenant("initech"); !errors.Is(err, ErrTenantNotFound) {
+		t.Fatalf("Tenant() error = %v, want ErrTenantNotFound", err)
+	}
+}
+
+func TestTenantLimits(t *testing.T) {
+	t.Parallel()
+
+	reg := New()
+	scope := reg.AddTenant(Tenant{ID: "acme", Limits: Limits{MaxDefinitions: 1}})
+
+	if err := scope.RegisterDefinition(definition("web-app")); err != nil {
+		t.Fatalf("RegisterDefinition() error = %v", err)
+	}
+	before := scope.Generation()
+	// Replacing an existing definition does not count against the limit.
+	if err := scope.RegisterDefinition(definition("web-app")); err != nil {
+		t.Fatalf("RegisterDefinition() replace error = %v", err)
+	}
+	if scope.Generation() == before {
+		t.Fatalf("Generation() did not change after replacing a definition")
+	}
+	if err := scope.RegisterDefinition(definition("worker")); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("RegisterDefinition() error = %v, want ErrLimitExceeded", err)
+	}
+}
+
+func TestReleases(t *testing.T) {
+	t.Parallel()
+
+	scope := New().AddTenant(Tenant{ID: "acme", Limits: Limits{MaxDefinitions: 2}})
+	for _, release := range []string{"1.0.0", "1.1.0"} {
+		ctd := definition("web-app")
+		ctd.Spec.Version = release
+		if err := scope.RegisterDefinition(ctd); err != nil {
+			t.Fatalf("RegisterDefinition(%s) error = %v", release, err)
+		}
+		addon := &types.Addon{Metadata: types.Metadata{Name: "pvc"}, Spec: types.AddonSpec{Version: release}}
+		if err := scope.RegisterAddon(addon); err != nil {
+			t.Fatalf("RegisterAddon(%s) error = %v", release, err)
+		}
+	}
+	// Every release counts against the limit.
+	third := definition("web-app")
+	third.Spec.Version = "2.0.0"
+	if err := scope.RegisterDefinition(third); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("RegisterDefinition(2.0.0) error = %v, want ErrLimitExceeded", err)
+	}
+
+	if ctd, err := scope.Definition("web-app"); err != nil || ctd.Spec.Version != "1.1.0" {
+		t.Errorf("Definition() = %v, %v, want release 1.1.0", ctd, err)
+	}
+	component := &types.Component{Spec: types.ComponentSpec{
+		ComponentType:        "web-app",
+		ComponentTypeRelease: "<1.1.0",
+		Addons:               []types.AddonInstance{{Name: "pvc", InstanceID: "data", Version: "1.0.0"}},
+	}}
+	if ctd, err := scope.DefinitionFor(component); err != nil || ctd.Spec.Version != "1.0.0" {
+		t.Errorf("DefinitionFor() = %v, %v, want release 1.0.0", ctd, err)
+	}
+	if addons, err := scope.AddonsFor(component); err != nil || addons["pvc"].Spec.Version != "1.0.0" {
+		t.Errorf("AddonsFor() = %v, %v, want pvc 1.0.0", addons, err)
+	}
+
+	component.Spec.ComponentTypeRelease = ">=2.0.0"
+	if _, err := scope.DefinitionFor(component); !errors.Is(err, ErrNotFound) ||
+		err.Error() != "not found: definition web-app has no release matching >=2.0.0 (available: 1.0.0, 1.1.0)" {
+		t.Errorf("DefinitionFor(>=2.0.0) error = %v", err)
+	}
+}
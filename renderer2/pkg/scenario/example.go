@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// ExampleParameters builds a deterministic parameter set that exercises every field of the given
+// object schema. Each field takes its example= value, then its default, then its first enum value;
+// fields without any of them get a placeholder derived from their name and bounds, so the result
+// reads well in documentation.
+func ExampleParameters(schema *extv1.JSONSchemaProps) (map[string]any, error) {
+	value, err := exampleValue("", schema)
+	if err != nil {
+		return nil, err
+	}
+	params, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("parameter schema must describe an object, got %s", schema.Type)
+	}
+	return params, nil
+}
+
+func exampleValue(name string, schema *extv1.JSONSchemaProps) (any, error) {
+	for _, candidate := range []*extv1.JSON{schema.Example, schema.Default} {
+		if candidate == nil {
+			continue
+		}
+		var value any
+		if err := json.Unmarshal(candidate.Raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid example: %w", err)
+		}
+		return value, nil
+	}
+	if len(schema.Enum) > 0 {
+		var value any
+		if err := json.Unmarshal(schema.Enum[0].Raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid enum value: %w", err)
+		}
+		return value, nil
+	}
+
+	switch schema.Type {
+	case "object":
+		return exampleObject(name, schema)
+	case "array":
+		return exampleArray(name, schema)
+	case "string":
+		if schema.Pattern != "" {
+			return nil, fmt.Errorf("cannot generate a value for pattern %q without an example or default", schema.Pattern)
+		}
+		return exampleString(name, schema), nil
+	case "integer":
+		return exampleInteger(schema), nil
+	case "number":
+		return float64(exampleInteger(schema)), nil
+	case "boolean":
+		return true, nil
+	default:
+		return nil, nil
+	}
+}
+
+func exampleObject(name string, schema *extv1.JSONSchemaProps) (any, error) {
+	result := map[string]any{}
+
+	if len(schema.Properties) == 0 && schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		key := "example"
+		value, err := exampleValue(key, schema.AdditionalProperties.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		if value != nil {
+			result[key] = value
+		}
+		return result, nil
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		prop := schema.Properties[name]
+		value, err := exampleValue(name, &prop)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if value == nil {
+			continue
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+func exampleArray(name string, schema *extv1.JSONSchemaProps) (any, error) {
+	size := 1
+	if schema.MinItems != nil && int(*schema.MinItems) > size {
+		size = int(*schema.MinItems)
+	}
+	if schema.MaxItems != nil && int(*schema.MaxItems) < size {
+		size = int(*schema.MaxItems)
+	}
+
+	result := make([]any, 0, size)
+	if schema.Items == nil || schema.Items.Schema == nil {
+		return result, nil
+	}
+	for i := 0; i < size; i++ {
+		item, err := exampleValue(name, schema.Items.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// exampleString returns the field name, padded or truncated to satisfy length bounds.
+func exampleString(name string, schema *extv1.JSONSchemaProps) string {
+	value := name
+	if value == "" {
+		value = "example"
+	}
+	if schema.MinLength != nil {
+		for int64(len(value)) < *schema.MinLength {
+			value += "x"
+		}
+	}
+	if schema.MaxLength != nil && int64(len(value)) > *schema.MaxLength {
+		value = value[:*schema.MaxLength]
+	}
+	return value
+}
+
+// exampleInteger returns 1 unless the bounds exclude it, in which case the nearest bound wins.
+func exampleInteger(schema *extv1.JSONSchemaProps) int64 {
+	value := int64(1)
+	if schema.Minimum != nil && float64(value) <= *schema.Minimum {
+		value = int64(math.Ceil(*schema.Minimum))
+		if schema.ExclusiveMinimum && float64(value) == *schema.Minimum {
+			value++
+		}
+	}
+	if schema.Maximum != nil && float64(value) >= *schema.Maximum {
+		value = int64(math.Floor(*schema.Maximum))
+		if schema.ExclusiveMaximum && float64(value) == *schema.Maximum {
+			value--
+		}
+	}
+	return value
+}
@@ -0,0 +1,71 @@
+package scenario
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExampleParameters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		fields map[string]any
+		want   map[string]any
+	}{
+		{
+			name: "markers take precedence",
+			fields: map[string]any{
+				"image":    "string | example=nginx:1.27 default=nginx",
+				"replicas": "integer | default=2",
+				"tier":     "string | enum=gold,silver",
+			},
+			want: map[string]any{"image": "nginx:1.27", "replicas": float64(2), "tier": "gold"},
+		},
+		{
+			name: "placeholders respect bounds",
+			fields: map[string]any{
+				"name":    "string | minLength=6",
+				"port":    "integer | minimum=1024 maximum=65535",
+				"enabled": "boolean",
+			},
+			want: map[string]any{"name": "namexx", "port": int64(1024), "enabled": true},
+		},
+		{
+			name: "collections get one entry",
+			fields: map[string]any{
+				"args":   "[]string",
+				"labels": "map<string>",
+				"resources": map[string]any{
+					"cpu": "string | example=250m",
+				},
+			},
+			want: map[string]any{
+				"args":      []any{"args"},
+				"labels":    map[string]any{"example": "example"},
+				"resources": map[string]any{"cpu": "250m"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			jsonSchema, err := schema.ToJSONSchema(schema.Definition{Schemas: []map[string]any{tt.fields}})
+			if err != nil {
+				t.Fatalf("ToJSONSchema() error = %v", err)
+			}
+			got, err := ExampleParameters(jsonSchema)
+			if err != nil {
+				t.Fatalf("ExampleParameters() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ExampleParameters() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
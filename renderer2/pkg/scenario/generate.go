@@ -0,0 +1,262 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+const (
+	defaultMinInt  = 0
+	defaultMaxInt  = 10
+	maxArrayItems  = 3
+	maxMapEntries  = 2
+	maxStringChars = 12
+)
+
+// GenerateParameters builds a random parameter set that satisfies the given object schema:
+// required fields are always present, optional fields appear half of the time, enums, numeric
+// bounds, lengths and item counts are respected, and boundary values are favoured so that
+// unusual-but-valid inputs show up early.
+func GenerateParameters(schema *extv1.JSONSchemaProps, rng *rand.Rand) (map[string]any, error) {
+	value, err := generateValue(schema, rng, true)
+	if err != nil {
+		return nil, err
+	}
+	params, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("parameter schema must describe an object, got %s", schema.Type)
+	}
+	return params, nil
+}
+
+func generateValue(schema *extv1.JSONSchemaProps, rng *rand.Rand, root bool) (any, error) {
+	if len(schema.Enum) > 0 {
+		var value any
+		if err := json.Unmarshal(schema.Enum[rng.Intn(len(schema.Enum))].Raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid enum value: %w", err)
+		}
+		return value, nil
+	}
+	if schema.Default != nil && !root && rng.Intn(3) == 0 {
+		var value any
+		if err := json.Unmarshal(schema.Default.Raw, &value); err != nil {
+			return nil, fmt.Errorf("invalid default: %w", err)
+		}
+		return value, nil
+	}
+
+	switch schema.Type {
+	case "object":
+		return generateObject(schema, rng)
+	case "array":
+		return generateArray(schema, rng)
+	case "string":
+		return generateString(schema, rng)
+	case "integer":
+		return generateInteger(schema, rng), nil
+	case "number":
+		return generateNumber(schema, rng), nil
+	case "boolean":
+		return rng.Intn(2) == 1, nil
+	default:
+		return nil, nil
+	}
+}
+
+func generateObject(schema *extv1.JSONSchemaProps, rng *rand.Rand) (any, error) {
+	result := map[string]any{}
+
+	if len(schema.Properties) == 0 && schema.AdditionalProperties != nil && schema.AdditionalProperties.Schema != nil {
+		entries := rng.Intn(maxMapEntries + 1)
+		for i := 0; i < entries; i++ {
+			value, err := generateValue(schema.AdditionalProperties.Schema, rng, false)
+			if err != nil {
+				return nil, err
+			}
+			result[fmt.Sprintf("key%d", i)] = value
+		}
+		return result, nil
+	}
+
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	properties := schema.Properties
+	if len(schema.OneOf) > 0 {
+		// A union generates one of its variants: the branch adds its required fields and pins the
+		// discriminator.
+		branch := schema.OneOf[rng.Intn(len(schema.OneOf))]
+		for _, name := range branch.Required {
+			required[name] = true
+		}
+		properties = make(map[string]extv1.JSONSchemaProps, len(schema.Properties))
+		for name, prop := range schema.Properties {
+			if pinned := branch.Properties[name]; len(pinned.Enum) > 0 {
+				prop.Enum = pinned.Enum
+			}
+			properties[name] = prop
+		}
+	}
+
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if !required[name] && rng.Intn(2) == 0 {
+			continue
+		}
+		prop := properties[name]
+		value, err := generateValue(&prop, rng, false)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if value == nil {
+			continue
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+func generateArray(schema *extv1.JSONSchemaProps, rng *rand.Rand) (any, error) {
+	minItems, maxItems := 0, maxArrayItems
+	if schema.MinItems != nil {
+		minItems = int(*schema.MinItems)
+	}
+	if schema.MaxItems != nil && int(*schema.MaxItems) < maxItems {
+		maxItems = int(*schema.MaxItems)
+	}
+	if maxItems < minItems {
+		maxItems = minItems
+	}
+
+	size := minItems + rng.Intn(maxItems-minItems+1)
+	result := make([]any, 0, size)
+	if schema.Items == nil || schema.Items.Schema == nil {
+		return result, nil
+	}
+	for i := 0; i < size; i++ {
+		item, err := generateValue(schema.Items.Schema, rng, false)
+		if err != nil {
+			return nil, fmt.Errorf("[%d]: %w", i, err)
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+func generateString(schema *extv1.JSONSchemaProps, rng *rand.Rand) (any, error) {
+	if schema.Pattern != "" {
+		// Arbitrary regex generation is out of scope; fall back to author-provided values.
+		for _, candidate := range []*extv1.JSON{schema.Example, schema.Default} {
+			if candidate == nil {
+				continue
+			}
+			var value any
+			if err := json.Unmarshal(candidate.Raw, &value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		}
+		return nil, fmt.Errorf("cannot generate a value for pattern %q without an example or default", schema.Pattern)
+	}
+
+	minLen, maxLen := 1, maxStringChars
+	if schema.MinLength != nil {
+		minLen = int(*schema.MinLength)
+	}
+	if schema.MaxLength != nil && int(*schema.MaxLength) < maxLen {
+		maxLen = int(*schema.MaxLength)
+	}
+	if maxLen < minLen {
+		maxLen = minLen
+	}
+
+	length := boundaryInt(rng, minLen, maxLen)
+	const letters = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = letters[rng.Intn(len(letters))]
+	}
+	return string(b), nil
+}
+
+func generateInteger(schema *extv1.JSONSchemaProps, rng *rand.Rand) int64 {
+	lo, hi := int64(defaultMinInt), int64(defaultMaxInt)
+	if schema.Minimum != nil {
+		lo = int64(math.Ceil(*schema.Minimum))
+		if schema.ExclusiveMinimum && float64(lo) == *schema.Minimum {
+			lo++
+		}
+		if schema.Maximum == nil {
+			hi = lo + defaultMaxInt
+		}
+	}
+	if schema.Maximum != nil {
+		hi = int64(math.Floor(*schema.Maximum))
+		if schema.ExclusiveMaximum && float64(hi) == *schema.Maximum {
+			hi--
+		}
+		if schema.Minimum == nil {
+			lo = hi - defaultMaxInt
+		}
+	}
+	if hi < lo {
+		hi = lo
+	}
+
+	value := int64(boundaryInt(rng, int(lo), int(hi)))
+	if schema.MultipleOf != nil && *schema.MultipleOf >= 1 {
+		step := int64(*schema.MultipleOf)
+		value = (value / step) * step
+		if value < lo {
+			value += step
+		}
+	}
+	return value
+}
+
+func generateNumber(schema *extv1.JSONSchemaProps, rng *rand.Rand) float64 {
+	lo, hi := float64(defaultMinInt), float64(defaultMaxInt)
+	if schema.Minimum != nil {
+		lo = *schema.Minimum
+	}
+	if schema.Maximum != nil {
+		hi = *schema.Maximum
+	}
+	if hi < lo {
+		hi = lo
+	}
+	switch rng.Intn(4) {
+	case 0:
+		if !schema.ExclusiveMinimum {
+			return lo
+		}
+	case 1:
+		if !schema.ExclusiveMaximum {
+			return hi
+		}
+	}
+	return lo + rng.Float64()*(hi-lo)
+}
+
+// boundaryInt picks lo or hi a third of the time each and a uniform value otherwise.
+func boundaryInt(rng *rand.Rand, lo, hi int) int {
+	switch rng.Intn(3) {
+	case 0:
+		return lo
+	case 1:
+		return hi
+	default:
+		return lo + rng.Intn(hi-lo+1)
+	}
+}
@@ -0,0 +1,40 @@
+package scenario
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+)
+
+func TestGenerateParametersUnions(t *testing.T) {
+	t.Parallel()
+
+	def := schema.Definition{
+		Types: map[string]any{
+			"S3Config":  map[string]any{"type": "string | enum=s3", "bucket": "string", "region": "string | default=us-east-1"},
+			"GCSConfig": map[string]any{"type": "string | enum=gcs", "bucket": "string", "project": "string"},
+		},
+		Schemas: []map[string]any{{"storage": "oneOf<S3Config, GCSConfig> | discriminator=type"}},
+	}
+	jsonSchema, err := schema.ToJSONSchema(def)
+	if err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+
+	seen := map[any]bool{}
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		params, err := GenerateParameters(jsonSchema, rng)
+		if err != nil {
+			t.Fatalf("GenerateParameters() error = %v", err)
+		}
+		if err := schema.Validate(def, params); err != nil {
+			t.Fatalf("generated parameters %v are invalid: %v", params, err)
+		}
+		seen[params["storage"].(map[string]any)["type"]] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("generated variants %v, want both", seen)
+	}
+}
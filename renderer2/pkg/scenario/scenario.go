@@ -0,0 +1,102 @@
+package scenario
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// Policy inspects a rendered resource set and returns an error when it is not acceptable.
+type Policy func(resources []map[string]any) error
+
+// Inputs bundles everything besides the component parameters that a scenario renders with.
+type Inputs struct {
+	Definition        *types.ComponentTypeDefinition
+	Component         *types.Component
+	EnvSettings       *types.EnvSettings
+	Addons            map[string]*types.Addon
+	AdditionalContext *types.AdditionalContext
+}
+
+// Options configures a scenario matrix run.
+type Options struct {
+	Scenarios int
+	Seed      int64
+	Policies  []Policy
+}
+
+// Failure describes a generated parameter set that failed to render or violated a policy.
+type Failure struct {
+	Scenario   int
+	Parameters map[string]any
+	Err        error
+}
+
+func (f Failure) Error() string {
+	return fmt.Sprintf("scenario %d (parameters %v): %v", f.Scenario, f.Parameters, f.Err)
+}
+
+// Run renders the definition once per generated parameter set and returns every failure.
+// Component parameters from inputs are replaced wholesale by the generated ones.
+func Run(renderer *component.Renderer, inputs Inputs, opts Options) ([]Failure, error) {
+	paramSchema, err := schema.ToJSONSchema(schema.Definition{
+		Types: inputs.Definition.Spec.Schema.Types,
+		Schemas: []map[string]any{
+			inputs.Definition.Spec.Schema.Parameters,
+			inputs.Definition.Spec.Schema.EnvOverrides,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build parameter schema: %w", err)
+	}
+
+	scenarios := opts.Scenarios
+	if scenarios <= 0 {
+		scenarios = 50
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var failures []Failure
+	for i := 0; i < scenarios; i++ {
+		params, err := GenerateParameters(paramSchema, rng)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %d: failed to generate parameters: %w", i, err)
+		}
+
+		comp := *inputs.Component
+		comp.Spec.Parameters = params
+
+		resources, err := renderer.RenderAll(inputs.Definition, &comp, inputs.EnvSettings, inputs.Addons, inputs.AdditionalContext, nil)
+		if err != nil {
+			failures = append(failures, Failure{Scenario: i, Parameters: params, Err: err})
+			continue
+		}
+		for _, policy := range opts.Policies {
+			if err := policy(resources); err != nil {
+				failures = append(failures, Failure{Scenario: i, Parameters: params, Err: err})
+				break
+			}
+		}
+	}
+	return failures, nil
+}
+
+// RequireNamedResources is a Policy asserting that every resource has apiVersion, kind, and metadata.name.
+func RequireNamedResources(resources []map[string]any) error {
+	for i, resource := range resources {
+		if apiVersion, _ := resource["apiVersion"].(string); apiVersion == "" {
+			return fmt.Errorf("resource %d has no apiVersion", i)
+		}
+		if kind, _ := resource["kind"].(string); kind == "" {
+			return fmt.Errorf("resource %d has no kind", i)
+		}
+		metadata, _ := resource["metadata"].(map[string]any)
+		if name, _ := metadata["name"].(string); name == "" {
+			return fmt.Errorf("resource %d (%v) has no metadata.name", i, resource["kind"])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// DefaultCacheSize is the number of definitions a Cache from NewCache(0) keeps.
+const DefaultCacheSize = 256
+
+// Cache memoizes ToJSONSchema, ExtractDefaults and ApplyDefaults by the digest of a Definition's content, so
+// repeated renders of unchanged definitions skip the apiextensions conversion. It is a bounded
+// FIFO and safe for concurrent use. A nil *Cache converts on every call.
+type Cache struct {
+	mu      sync.Mutex
+	limit   int
+	order   []string
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	jsonSchema *extv1.JSONSchemaProps
+	defaults   map[string]any
+}
+
+// NewCache returns a cache holding up to limit definitions; zero uses DefaultCacheSize.
+func NewCache(limit int) *Cache {
+	if limit <= 0 {
+		limit = DefaultCacheSize
+	}
+	return &Cache{limit: limit, entries: map[string]*cacheEntry{}}
+}
+
+// Digest identifies a definition by its content.
+func Digest(def Definition) (string, error) {
+	data, err := json.Marshal(def)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash schema definition: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ToJSONSchema is ToJSONSchema backed by the cache. The result is a copy callers may modify.
+func (c *Cache) ToJSONSchema(def Definition) (*extv1.JSONSchemaProps, error) {
+	if c == nil {
+		return ToJSONSchema(def)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return nil, err
+	}
+	return entry.jsonSchema.DeepCopy(), nil
+}
+
+// ExtractDefaults is ExtractDefaults backed by the cache. The result is a copy callers may modify.
+func (c *Cache) ExtractDefaults(def Definition) (map[string]any, error) {
+	if c == nil {
+		return ExtractDefaults(def)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return nil, err
+	}
+	return deepCopyMap(entry.defaults), nil
+}
+
+// ApplyDefaults is ApplyDefaults backed by the cache.
+func (c *Cache) ApplyDefaults(def Definition, values map[string]any) error {
+	if c == nil {
+		return ApplyDefaults(def, values)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return err
+	}
+	return applyDefaults(values, entry.jsonSchema)
+}
+
+// Validate is Validate backed by the cache.
+func (c *Cache) Validate(def Definition, values map[string]any) error {
+	if c == nil {
+		return Validate(def, values)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return err
+	}
+	return validateValues(values, nil, entry.jsonSchema)
+}
+
+// ValidateUpdate is ValidateUpdate backed by the cache.
+func (c *Cache) ValidateUpdate(def Definition, old, values map[string]any) error {
+	if c == nil {
+		return ValidateUpdate(def, old, values)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return err
+	}
+	return validateValues(values, old, entry.jsonSchema)
+}
+
+// Deprecations is Deprecations backed by the cache.
+func (c *Cache) Deprecations(def Definition, values map[string]any) ([]Deprecation, error) {
+	if c == nil {
+		return Deprecations(def, values)
+	}
+	entry, err := c.entry(def)
+	if err != nil {
+		return nil, err
+	}
+	return deprecationsOf(values, entry.jsonSchema), nil
+}
+
+// Len reports the number of cached definitions.
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// entry returns the converted schema and defaults of def, converting on a miss. Conversions run
+// outside the lock; concurrent misses for the same definition may both convert.
+func (c *Cache) entry(def Definition) (*cacheEntry, error) {
+	key, err := Digest(def)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok {
+		return entry, nil
+	}
+
+	jsonSchema, err := ToJSONSchema(def)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := defaultsOf(jsonSchema)
+	if err != nil {
+		return nil, err
+	}
+	entry = &cacheEntry{jsonSchema: jsonSchema, defaults: defaults}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = entry
+	for len(c.order) > c.limit {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	return entry, nil
+}
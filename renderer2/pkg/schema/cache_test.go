@@ -0,0 +1,63 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCache(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{Schemas: []map[string]any{{"image": "string | default=nginx", "name": "string"}}}
+	cache := NewCache(1)
+
+	first, err := cache.ExtractDefaults(def)
+	if err != nil {
+		t.Fatalf("ExtractDefaults() error = %v", err)
+	}
+	first["image"] = "changed"
+	second, err := cache.ExtractDefaults(def)
+	if err != nil {
+		t.Fatalf("ExtractDefaults() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"image": "nginx"}, second); diff != "" {
+		t.Errorf("cached defaults mismatch (-want +got):\n%s", diff)
+	}
+
+	jsonSchema, err := cache.ToJSONSchema(def)
+	if err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+	uncached, err := ToJSONSchema(def)
+	if err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+	if diff := cmp.Diff(uncached, jsonSchema); diff != "" {
+		t.Errorf("cached schema mismatch (-want +got):\n%s", diff)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Errorf("Len() = %d, want 1", got)
+	}
+
+	other := Definition{Schemas: []map[string]any{{"tag": "string | default=latest"}}}
+	if _, err := cache.ToJSONSchema(other); err != nil {
+		t.Fatalf("ToJSONSchema() error = %v", err)
+	}
+	if got := cache.Len(); got != 1 {
+		t.Errorf("Len() after eviction = %d, want 1", got)
+	}
+}
+
+func TestNilCacheConverts(t *testing.T) {
+	t.Parallel()
+
+	var cache *Cache
+	defaults, err := cache.ExtractDefaults(Definition{Schemas: []map[string]any{{"image": "string | default=nginx"}}})
+	if err != nil {
+		t.Fatalf("ExtractDefaults() error = %v", err)
+	}
+	if diff := cmp.Diff(map[string]any{"image": "nginx"}, defaults); diff != "" {
+		t.Errorf("defaults mismatch (-want +got):\n%s", diff)
+	}
+}
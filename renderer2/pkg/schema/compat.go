@@ -0,0 +1,298 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// ChangeKind classifies a Change between two versions of a schema.
+type ChangeKind string
+
+const (
+	// ChangeFieldRemoved is a field the new schema no longer declares.
+	ChangeFieldRemoved ChangeKind = "FieldRemoved"
+	// ChangeFieldAdded is an optional field the new schema declares.
+	ChangeFieldAdded ChangeKind = "FieldAdded"
+	// ChangeFieldRequired is a field the new schema requires: a new field without a default or an
+	// optional field that lost its default.
+	ChangeFieldRequired ChangeKind = "FieldRequired"
+	// ChangeTypeChanged is a field whose type changed, e.g. from integer to string or from a map to
+	// an object with fixed fields.
+	ChangeTypeChanged ChangeKind = "TypeChanged"
+	// ChangeEnumTightened is an enum that no longer allows some values, or a field that gained one.
+	ChangeEnumTightened ChangeKind = "EnumTightened"
+	// ChangeEnumLoosened is an enum that allows new values.
+	ChangeEnumLoosened ChangeKind = "EnumLoosened"
+	// ChangeConstraintTightened is a bound, pattern, format or nullability that rejects values the
+	// old schema accepted.
+	ChangeConstraintTightened ChangeKind = "ConstraintTightened"
+	// ChangeRuleAdded is a validate rule the old schema did not have.
+	ChangeRuleAdded ChangeKind = "RuleAdded"
+	// ChangeDefaultChanged is a default that changed, which changes the output of every Component
+	// leaving the field unset.
+	ChangeDefaultChanged ChangeKind = "DefaultChanged"
+)
+
+// Change is a difference between two versions of a schema.
+type Change struct {
+	// Path is the field path, as in validation errors, with [*] standing for any item of a list or
+	// value of a map. It is empty for the root.
+	Path string     `json:"path"`
+	Kind ChangeKind `json:"kind"`
+	// Breaking is set when values valid against the old schema may be rejected by the new one.
+	Breaking bool   `json:"breaking"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	if c.Path == "" {
+		return c.Message
+	}
+	return c.Path + ": " + c.Message
+}
+
+// HasBreaking reports whether any of changes is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// CompareDefinitions converts both definitions and compares their schemas with Compare.
+func CompareDefinitions(old, new Definition) ([]Change, error) {
+	oldSchema, err := ToJSONSchema(old)
+	if err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	newSchema, err := ToJSONSchema(new)
+	if err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+	return Compare(oldSchema, newSchema), nil
+}
+
+// Compare returns the changes from old to new, depth first in field path order, so a definition
+// author can tell whether a new version of a schema still accepts the Components written against
+// the previous one. Fields below a changed type are not compared.
+func Compare(old, new *extv1.JSONSchemaProps) []Change {
+	var changes []Change
+	compareSchemas("", old, new, &changes)
+	return changes
+}
+
+func compareSchemas(path string, old, new *extv1.JSONSchemaProps, changes *[]Change) {
+	report := func(kind ChangeKind, breaking bool, format string, args ...any) {
+		*changes = append(*changes, Change{Path: path, Kind: kind, Breaking: breaking, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if old.Type != new.Type {
+		report(ChangeTypeChanged, true, "type changed from %s to %s", schemaTypeName(old), schemaTypeName(new))
+		return
+	}
+	oldMap, newMap := additionalSchema(old) != nil, additionalSchema(new) != nil
+	if oldMap != newMap {
+		report(ChangeTypeChanged, true, "changed from %s to %s", shapeName(old), shapeName(new))
+		return
+	}
+
+	compareEnums(old, new, report)
+	compareConstraints(old, new, report)
+	oldRules := map[string]bool{}
+	for _, rule := range old.XValidations {
+		oldRules[rule.Rule] = true
+	}
+	for _, rule := range new.XValidations {
+		if !oldRules[rule.Rule] {
+			report(ChangeRuleAdded, true, "new validate rule %q", rule.Rule)
+		}
+	}
+	if path != "" && !jsonEqual(old.Default, new.Default) {
+		report(ChangeDefaultChanged, false, "default changed from %s to %s", jsonText(old.Default), jsonText(new.Default))
+	}
+
+	compareProperties(path, old, new, changes)
+	if old.Items != nil && old.Items.Schema != nil && new.Items != nil && new.Items.Schema != nil {
+		compareSchemas(path+"[*]", old.Items.Schema, new.Items.Schema, changes)
+	}
+	if oldMap {
+		compareSchemas(path+"[*]", additionalSchema(old), additionalSchema(new), changes)
+	}
+}
+
+// compareProperties reports the fields removed, added or newly required, and compares the
+// fields both schemas declare.
+func compareProperties(path string, old, new *extv1.JSONSchemaProps, changes *[]Change) {
+	oldRequired, newRequired := map[string]bool{}, map[string]bool{}
+	for _, name := range old.Required {
+		oldRequired[name] = true
+	}
+	for _, name := range new.Required {
+		newRequired[name] = true
+	}
+
+	names := make([]string, 0, len(old.Properties)+len(new.Properties))
+	for name := range old.Properties {
+		names = append(names, name)
+	}
+	for name := range new.Properties {
+		if _, ok := old.Properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field := fieldPath(path, name)
+		oldProp, inOld := old.Properties[name]
+		newProp, inNew := new.Properties[name]
+		switch {
+		case !inNew:
+			*changes = append(*changes, Change{Path: field, Kind: ChangeFieldRemoved, Breaking: true, Message: "field removed"})
+		case !inOld && newRequired[name]:
+			*changes = append(*changes, Change{Path: field, Kind: ChangeFieldRequired, Breaking: true, Message: "new required field"})
+		case !inOld:
+			*changes = append(*changes, Change{Path: field, Kind: ChangeFieldAdded, Message: "field added"})
+		default:
+			if newRequired[name] && !oldRequired[name] {
+				*changes = append(*changes, Change{Path: field, Kind: ChangeFieldRequired, Breaking: true, Message: "field is now required"})
+			}
+			compareSchemas(field, &oldProp, &newProp, changes)
+		}
+	}
+}
+
+type reportFunc func(kind ChangeKind, breaking bool, format string, args ...any)
+
+func compareEnums(old, new *extv1.JSONSchemaProps, report reportFunc) {
+	if len(new.Enum) == 0 {
+		if len(old.Enum) > 0 {
+			report(ChangeEnumLoosened, false, "enum removed")
+		}
+		return
+	}
+	if len(old.Enum) == 0 {
+		report(ChangeEnumTightened, true, "now restricted to %s", enumText(new.Enum))
+		return
+	}
+	oldValues, newValues := enumSet(old.Enum), enumSet(new.Enum)
+	var removed, added []extv1.JSON
+	for _, value := range old.Enum {
+		if !newValues[string(value.Raw)] {
+			removed = append(removed, value)
+		}
+	}
+	for _, value := range new.Enum {
+		if !oldValues[string(value.Raw)] {
+			added = append(added, value)
+		}
+	}
+	if len(removed) > 0 {
+		report(ChangeEnumTightened, true, "enum no longer allows %s", enumText(removed))
+	}
+	if len(added) > 0 {
+		report(ChangeEnumLoosened, false, "enum now also allows %s", enumText(added))
+	}
+}
+
+// compareConstraints reports the constraints of new that reject values old accepted. Loosened
+// constraints are compatible and not reported.
+func compareConstraints(old, new *extv1.JSONSchemaProps, report reportFunc) {
+	tightened := func(format string, args ...any) {
+		report(ChangeConstraintTightened, true, format, args...)
+	}
+	if old.Nullable && !new.Nullable {
+		tightened("no longer nullable")
+	}
+	if new.Minimum != nil && (old.Minimum == nil || *new.Minimum > *old.Minimum ||
+		*new.Minimum == *old.Minimum && new.ExclusiveMinimum && !old.ExclusiveMinimum) {
+		tightened("minimum raised to %s", boundText(*new.Minimum, new.ExclusiveMinimum, ">"))
+	}
+	if new.Maximum != nil && (old.Maximum == nil || *new.Maximum < *old.Maximum ||
+		*new.Maximum == *old.Maximum && new.ExclusiveMaximum && !old.ExclusiveMaximum) {
+		tightened("maximum lowered to %s", boundText(*new.Maximum, new.ExclusiveMaximum, "<"))
+	}
+	if new.MultipleOf != nil && (old.MultipleOf == nil || *new.MultipleOf != *old.MultipleOf) {
+		tightened("multipleOf changed to %g", *new.MultipleOf)
+	}
+	compareMinimum("minLength", old.MinLength, new.MinLength, tightened)
+	compareMaximum("maxLength", old.MaxLength, new.MaxLength, tightened)
+	compareMinimum("minItems", old.MinItems, new.MinItems, tightened)
+	compareMaximum("maxItems", old.MaxItems, new.MaxItems, tightened)
+	compareMinimum("minProperties", old.MinProperties, new.MinProperties, tightened)
+	compareMaximum("maxProperties", old.MaxProperties, new.MaxProperties, tightened)
+	if new.Pattern != "" && new.Pattern != old.Pattern {
+		tightened("pattern changed to %q", new.Pattern)
+	}
+	if new.Format != "" && new.Format != old.Format {
+		tightened("format changed to %s", new.Format)
+	}
+	if new.UniqueItems && !old.UniqueItems {
+		tightened("items must be unique")
+	}
+}
+
+func compareMinimum(name string, old, new *int64, tightened func(string, ...any)) {
+	if new != nil && (old == nil || *new > *old) {
+		tightened("%s raised to %d", name, *new)
+	}
+}
+
+func compareMaximum(name string, old, new *int64, tightened func(string, ...any)) {
+	if new != nil && (old == nil || *new < *old) {
+		tightened("%s lowered to %d", name, *new)
+	}
+}
+
+func schemaTypeName(s *extv1.JSONSchemaProps) string {
+	if s.Type == "" {
+		return "any"
+	}
+	return s.Type
+}
+
+func shapeName(s *extv1.JSONSchemaProps) string {
+	if additionalSchema(s) != nil {
+		return "a map"
+	}
+	return "an object with fixed fields"
+}
+
+func boundText(bound float64, exclusive bool, op string) string {
+	if exclusive {
+		return fmt.Sprintf("%s %g", op, bound)
+	}
+	return fmt.Sprintf("%s= %g", op, bound)
+}
+
+func enumSet(values []extv1.JSON) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[string(value.Raw)] = true
+	}
+	return set
+}
+
+func enumText(values []extv1.JSON) string {
+	texts := make([]string, len(values))
+	for i, value := range values {
+		texts[i] = string(value.Raw)
+	}
+	return strings.Join(texts, ", ")
+}
+
+func jsonEqual(a, b *extv1.JSON) bool {
+	return jsonText(a) == jsonText(b)
+}
+
+func jsonText(value *extv1.JSON) string {
+	if value == nil || len(value.Raw) == 0 {
+		return "none"
+	}
+	return string(value.Raw)
+}
@@ -0,0 +1,134 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCompareDefinitions(t *testing.T) {
+	t.Parallel()
+
+	old := Definition{
+		Types: map[string]any{"Port": map[string]any{"name": "string", "port": "integer | minimum=1"}},
+		Schemas: []map[string]any{{
+			"image":    "string",
+			"tag":      "string | default=latest",
+			"replicas": "integer | default=1 maximum=10",
+			"mode":     "string | default=fast enum=fast,safe,slow",
+			"debug":    "boolean | default=false",
+			"ports":    "[]Port | default=[]",
+			"labels":   "map[string]string | default={}",
+			"timeout":  "string | default=30s",
+		}},
+	}
+
+	tests := []struct {
+		name     string
+		types    map[string]any
+		schemas  map[string]any
+		want     []Change
+		breaking bool
+	}{
+		{
+			name: "unchanged",
+			schemas: map[string]any{
+				"image":    "string",
+				"tag":      "string | default=latest",
+				"replicas": "integer | default=1 maximum=10",
+				"mode":     "string | default=fast enum=fast,safe,slow",
+				"debug":    "boolean | default=false",
+				"ports":    "[]Port | default=[]",
+				"labels":   "map[string]string | default={}",
+				"timeout":  "string | default=30s",
+			},
+		},
+		{
+			name: "compatible changes",
+			schemas: map[string]any{
+				"image":    "string",
+				"tag":      "string | default=stable",
+				"replicas": "integer | default=1 maximum=20",
+				"mode":     "string | default=fast enum=fast,safe,slow,auto",
+				"debug":    "boolean | default=false",
+				"ports":    "[]Port | default=[]",
+				"labels":   "map[string]string | default={}",
+				"timeout":  "string | default=30s",
+				"command":  "[]string | default=[]",
+			},
+			want: []Change{
+				{Path: "command", Kind: ChangeFieldAdded, Message: "field added"},
+				{Path: "mode", Kind: ChangeEnumLoosened, Message: `enum now also allows "auto"`},
+				{Path: "tag", Kind: ChangeDefaultChanged, Message: `default changed from "latest" to "stable"`},
+			},
+		},
+		{
+			name: "breaking changes",
+			schemas: map[string]any{
+				"image":    "string",
+				"tag":      "string",
+				"replicas": "integer | default=1 minimum=1 maximum=5",
+				"mode":     "string | default=fast enum=fast,safe",
+				"ports":    "[]Port | default=[] maxItems=4",
+				"labels":   "map[string]integer | default={}",
+				"timeout":  "integer | default=30",
+				"region":   "string",
+			},
+			want: []Change{
+				{Path: "debug", Kind: ChangeFieldRemoved, Breaking: true, Message: "field removed"},
+				{Path: "labels[*]", Kind: ChangeTypeChanged, Breaking: true, Message: "type changed from string to integer"},
+				{Path: "mode", Kind: ChangeEnumTightened, Breaking: true, Message: `enum no longer allows "slow"`},
+				{Path: "ports", Kind: ChangeConstraintTightened, Breaking: true, Message: "maxItems lowered to 4"},
+				{Path: "region", Kind: ChangeFieldRequired, Breaking: true, Message: "new required field"},
+				{Path: "replicas", Kind: ChangeConstraintTightened, Breaking: true, Message: "minimum raised to >= 1"},
+				{Path: "replicas", Kind: ChangeConstraintTightened, Breaking: true, Message: "maximum lowered to <= 5"},
+				{Path: "tag", Kind: ChangeFieldRequired, Breaking: true, Message: "field is now required"},
+				{Path: "tag", Kind: ChangeDefaultChanged, Message: `default changed from "latest" to none`},
+				{Path: "timeout", Kind: ChangeTypeChanged, Breaking: true, Message: "type changed from string to integer"},
+			},
+			breaking: true,
+		},
+		{
+			name: "nested fields",
+			types: map[string]any{"Port": map[string]any{
+				"name": "string", "port": "integer | minimum=1 maximum=65535", "protocol": "string | default=TCP",
+			}},
+			schemas: map[string]any{
+				"image":    "string",
+				"tag":      "string | default=latest",
+				"replicas": "integer | default=1 maximum=10",
+				"mode":     "string | default=fast enum=fast,safe,slow",
+				"debug":    "boolean | default=false",
+				"ports":    `[]Port | default=[] validate="self.all(p, p.port != 22)"`,
+				"labels":   "map[string]string | default={}",
+				"timeout":  "string | default=30s",
+			},
+			want: []Change{
+				{Path: "ports", Kind: ChangeRuleAdded, Breaking: true, Message: `new validate rule "self.all(p, p.port != 22)"`},
+				{Path: "ports[*].port", Kind: ChangeConstraintTightened, Breaking: true, Message: "maximum lowered to <= 65535"},
+				{Path: "ports[*].protocol", Kind: ChangeFieldAdded, Message: "field added"},
+			},
+			breaking: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			new := Definition{Types: old.Types, Schemas: []map[string]any{tt.schemas}}
+			if tt.types != nil {
+				new.Types = tt.types
+			}
+			got, err := CompareDefinitions(old, new)
+			if err != nil {
+				t.Fatalf("CompareDefinitions() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("CompareDefinitions() mismatch (-want +got):\n%s", diff)
+			}
+			if HasBreaking(got) != tt.breaking {
+				t.Errorf("HasBreaking() = %v, want %v", HasBreaking(got), tt.breaking)
+			}
+		})
+	}
+}
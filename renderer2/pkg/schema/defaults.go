@@ -0,0 +1,149 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// defaultsOf returns the default values a converted schema declares, applied to an empty object
+// the way the Kubernetes API server defaults custom resources. It walks the v1 schema directly
+// instead of converting it to a structural schema, which keeps the apiextensions server packages
+// out of the binary.
+func defaultsOf(props *extv1.JSONSchemaProps) (map[string]any, error) {
+	result := map[string]any{}
+	if err := applyDefaults(result, props); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyDefaults fills missing properties, and null values the schema does not allow, with their
+// defaults, then descends into properties, additionalProperties and array items. An object that
+// selects a oneOf branch, e.g. through a union discriminator, also gets the defaults of the branch.
+func applyDefaults(x any, s *extv1.JSONSchemaProps) error {
+	if s == nil {
+		return nil
+	}
+
+	switch x := x.(type) {
+	case map[string]any:
+		for key, prop := range s.Properties {
+			if value, found := x[key]; found && !nonNullableNull(value, &prop) {
+				continue
+			}
+			value, err := defaultValue(&prop)
+			if err != nil {
+				return fmt.Errorf("invalid default for %s: %w", key, err)
+			}
+			if value != nil {
+				x[key] = value
+			}
+		}
+		if branch, ok := selectedBranch(x, s.OneOf); ok {
+			for key, prop := range s.OneOf[branch].Properties {
+				if _, found := x[key]; found {
+					continue
+				}
+				value, err := defaultValue(&prop)
+				if err != nil {
+					return fmt.Errorf("invalid default for %s: %w", key, err)
+				}
+				if value != nil {
+					x[key] = value
+				}
+			}
+		}
+		additional := additionalSchema(s)
+		for key := range x {
+			if prop, found := s.Properties[key]; found {
+				if err := applyDefaults(x[key], &prop); err != nil {
+					return err
+				}
+				continue
+			}
+			if additional == nil {
+				continue
+			}
+			if nonNullableNull(x[key], additional) {
+				value, err := defaultValue(additional)
+				if err != nil {
+					return fmt.Errorf("invalid default for %s: %w", key, err)
+				}
+				x[key] = value
+			}
+			if err := applyDefaults(x[key], additional); err != nil {
+				return err
+			}
+		}
+	case []any:
+		var items *extv1.JSONSchemaProps
+		if s.Items != nil {
+			items = s.Items.Schema
+		}
+		for i := range x {
+			if nonNullableNull(x[i], items) {
+				value, err := defaultValue(items)
+				if err != nil {
+					return fmt.Errorf("invalid default for item %d: %w", i, err)
+				}
+				x[i] = value
+			}
+			if err := applyDefaults(x[i], items); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func nonNullableNull(x any, s *extv1.JSONSchemaProps) bool {
+	return x == nil && s != nil && !s.Nullable
+}
+
+func additionalSchema(s *extv1.JSONSchemaProps) *extv1.JSONSchemaProps {
+	if s.AdditionalProperties == nil {
+		return nil
+	}
+	return s.AdditionalProperties.Schema
+}
+
+// defaultValue decodes a fresh copy of the default of s, or nil without one. Integral numbers
+// decode to int64 and others to float64, as in the API server.
+func defaultValue(s *extv1.JSONSchemaProps) (any, error) {
+	if s.Default == nil || len(s.Default.Raw) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(s.Default.Raw))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return convertNumbers(value), nil
+}
+
+func convertNumbers(value any) any {
+	switch typed := value.(type) {
+	case json.Number:
+		if i, err := typed.Int64(); err == nil {
+			return i
+		}
+		f, _ := typed.Float64()
+		return f
+	case map[string]any:
+		for key, item := range typed {
+			typed[key] = convertNumbers(item)
+		}
+		return typed
+	case []any:
+		for i, item := range typed {
+			typed[i] = convertNumbers(item)
+		}
+		return typed
+	default:
+		return typed
+	}
+}
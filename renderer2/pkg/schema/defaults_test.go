@@ -0,0 +1,132 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apiextschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
+)
+
+// apiserverDefault applies the defaults of props to x with the apiextensions structural
+// defaulting that applyDefaults replaces.
+func apiserverDefault(t *testing.T, x any, props *extv1.JSONSchemaProps) {
+	t.Helper()
+
+	internal := new(apiext.JSONSchemaProps)
+	if err := extv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(props, internal, nil); err != nil {
+		t.Fatalf("failed to convert schema: %v", err)
+	}
+	structural, err := apiextschema.NewStructural(internal)
+	if err != nil {
+		t.Fatalf("failed to build structural schema: %v", err)
+	}
+	defaulting.Default(x, structural)
+}
+
+func TestDefaultsMatchAPIServer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		def  Definition
+	}{
+		{
+			name: "scalars of every type",
+			def: Definition{Schemas: []map[string]any{{
+				"replicas": "integer | default=2",
+				"ratio":    "number | default=0.5",
+				"whole":    "number | default=3",
+				"enabled":  "boolean | default=true",
+				"image":    "string | default=nginx",
+				"name":     "string",
+			}}},
+		},
+		{
+			name: "nested objects default only below defaulted parents",
+			def: Definition{
+				Types: map[string]any{
+					"Probe": map[string]any{"path": "string | default=/healthz", "port": "integer | default=8080"},
+				},
+				Schemas: []map[string]any{{
+					"liveness":  "Probe | default={}",
+					"readiness": "Probe",
+					"resources": map[string]any{"cpu": "string | default=100m"},
+				}},
+			},
+		},
+		{
+			name: "array and map defaults with item defaults",
+			def: Definition{
+				Types: map[string]any{
+					"Port": map[string]any{"name": "string", "protocol": "string | default=TCP"},
+				},
+				Schemas: []map[string]any{{
+					"ports":  "[]Port | default=[{\"name\":\"http\"},{\"name\":\"dns\",\"protocol\":\"UDP\"}]",
+					"labels": "map[string]string | default={\"app\":\"web\"}",
+					"empty":  "[]Port",
+				}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			props, err := ToJSONSchema(tt.def)
+			if err != nil {
+				t.Fatalf("ToJSONSchema() error = %v", err)
+			}
+			want := map[string]any{}
+			apiserverDefault(t, want, props)
+			got, err := defaultsOf(props)
+			if err != nil {
+				t.Fatalf("defaultsOf() error = %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("defaults mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyDefaultsMatchesAPIServerOnValues(t *testing.T) {
+	t.Parallel()
+
+	item := extv1.JSONSchemaProps{
+		Type:       "object",
+		Properties: map[string]extv1.JSONSchemaProps{"protocol": {Type: "string", Default: &extv1.JSON{Raw: []byte(`"TCP"`)}}},
+		Default:    &extv1.JSON{Raw: []byte(`{"protocol":"SCTP"}`)},
+	}
+	props := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			"ports":    {Type: "array", Items: &extv1.JSONSchemaPropsOrArray{Schema: &item}},
+			"image":    {Type: "string", Default: &extv1.JSON{Raw: []byte(`"nginx"`)}},
+			"optional": {Type: "string", Nullable: true, Default: &extv1.JSON{Raw: []byte(`"set"`)}},
+			"byName":   {Type: "object", AdditionalProperties: &extv1.JSONSchemaPropsOrBool{Allows: true, Schema: &item}},
+		},
+	}
+	value := func() map[string]any {
+		return map[string]any{
+			"ports":    []any{nil, map[string]any{}, map[string]any{"protocol": "UDP"}},
+			"image":    nil,
+			"optional": nil,
+			"byName":   map[string]any{"a": nil, "b": map[string]any{}},
+		}
+	}
+
+	want := value()
+	apiserverDefault(t, want, props)
+	got := value()
+	if err := applyDefaults(got, props); err != nil {
+		t.Fatalf("applyDefaults() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("defaults mismatch (-want +got):\n%s", diff)
+	}
+}
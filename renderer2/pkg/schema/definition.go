@@ -5,10 +5,7 @@ import (
 	"sort"
 
 	"github.com/chathurangada/cel_playground/renderer2/pkg/schemaextractor"
-	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
 	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	apiextschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
-	"k8s.io/apiextensions-apiserver/pkg/apiserver/schema/defaulting"
 )
 
 // Definition represents a schematized object assembled from one or more field maps.
@@ -43,20 +40,19 @@ func ExtractDefaults(def Definition) (map[string]any, error) {
 	if err != nil {
 		return nil, err
 	}
+	return defaultsOf(jsonSchemaV1)
+}
 
-	internal := new(apiext.JSONSchemaProps)
-	if err := extv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(jsonSchemaV1, internal, nil); err != nil {
-		return nil, fmt.Errorf("failed to convert schema: %w", err)
-	}
-
-	structural, err := apiextschema.NewStructural(internal)
+// ApplyDefaults fills the defaults of def into values in place. Unlike merging ExtractDefaults
+// underneath values, it also defaults the fields of objects, list items and map values that values
+// supplies partially, e.g. resources.requests.cpu when values holds resources: {requests: {}}, as
+// the API server does for custom resources.
+func ApplyDefaults(def Definition, values map[string]any) error {
+	jsonSchemaV1, err := ToJSONSchema(def)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build structural schema: %w", err)
+		return err
 	}
-
-	result := map[string]any{}
-	defaulting.Default(result, structural)
-	return result, nil
+	return applyDefaults(values, jsonSchemaV1)
 }
 
 func mergeFieldMaps(maps []map[string]any) map[string]any {
@@ -1,6 +1,10 @@
 package schema
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
 
 func TestExtractDefaults_ArrayFieldBehaviour(t *testing.T) {
 	def := Definition{
@@ -49,3 +53,41 @@ func TestExtractDefaults_ArrayFieldBehaviour(t *testing.T) {
 		t.Fatalf("unexpected array default: %v", got)
 	}
 }
+
+func TestApplyDefaults_PartialObjects(t *testing.T) {
+	def := Definition{
+		Types: map[string]any{
+			"Item": map[string]any{
+				"name": "string | default=default-name",
+			},
+		},
+		Schemas: []map[string]any{
+			{
+				"replicas": "integer | default=1",
+				"resources": map[string]any{
+					"requests": map[string]any{
+						"cpu":    "string | default=100m",
+						"memory": "string | default=256Mi",
+					},
+				},
+				"list": "[]Item",
+			},
+		},
+	}
+
+	values := map[string]any{
+		"resources": map[string]any{"requests": map[string]any{"memory": "1Gi"}},
+		"list":      []any{map[string]any{}},
+	}
+	if err := ApplyDefaults(def, values); err != nil {
+		t.Fatalf("ApplyDefaults returned error: %v", err)
+	}
+	want := map[string]any{
+		"replicas":  int64(1),
+		"resources": map[string]any{"requests": map[string]any{"cpu": "100m", "memory": "1Gi"}},
+		"list":      []any{map[string]any{"name": "default-name"}},
+	}
+	if diff := cmp.Diff(want, values); diff != "" {
+		t.Fatalf("defaulted values mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schemaextractor"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Deprecation is a field values set although the schema marks it deprecated.
+type Deprecation struct {
+	// Path is the field path, as in validation errors.
+	Path string
+	// Notice is the text of the deprecated marker, empty for deprecated=true.
+	Notice string
+}
+
+// Deprecations returns the fields of values that def marks deprecated, in field path order. Pass
+// the values a user wrote rather than the defaulted spec, so only fields they set are reported.
+func Deprecations(def Definition, values map[string]any) ([]Deprecation, error) {
+	jsonSchemaV1, err := ToJSONSchema(def)
+	if err != nil {
+		return nil, err
+	}
+	return deprecationsOf(values, jsonSchemaV1), nil
+}
+
+func deprecationsOf(values map[string]any, s *extv1.JSONSchemaProps) []Deprecation {
+	var found []Deprecation
+	collectDeprecations("", values, s, &found)
+	return found
+}
+
+func collectDeprecations(path string, x any, s *extv1.JSONSchemaProps, found *[]Deprecation) {
+	if s == nil {
+		return
+	}
+	if notice, ok := schemaextractor.DeprecationNotice(s); ok && path != "" {
+		*found = append(*found, Deprecation{Path: path, Notice: notice})
+	}
+	switch x := x.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		additional := additionalSchema(s)
+		for _, key := range keys {
+			if prop, ok := s.Properties[key]; ok {
+				collectDeprecations(fieldPath(path, key), x[key], &prop, found)
+			} else if additional != nil {
+				collectDeprecations(fieldPath(path, key), x[key], additional, found)
+			}
+		}
+	case []any:
+		if s.Items == nil {
+			return
+		}
+		for i, item := range x {
+			collectDeprecations(fmt.Sprintf("%s[%d]", path, i), item, s.Items.Schema, found)
+		}
+	}
+}
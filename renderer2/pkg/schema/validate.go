@@ -0,0 +1,446 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schemaextractor"
+	"github.com/google/cel-go/cel"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Validate checks values against the JSON schema of def the way the API server validates a custom
+// resource: types, required properties, enums, oneOf branches, numeric bounds, string lengths and patterns, and
+// item and property counts, and x-kubernetes-validations rules. Every violation is reported, prefixed with its field path, e.g.
+// "resources.limits.cpu: expected string, got integer". Fields the schema does not declare are
+// allowed. Transition rules, which read oldSelf, are skipped; see ValidateUpdate.
+func Validate(def Definition, values map[string]any) error {
+	jsonSchemaV1, err := ToJSONSchema(def)
+	if err != nil {
+		return err
+	}
+	return validateValues(values, nil, jsonSchemaV1)
+}
+
+// ValidateUpdate is Validate for values replacing old, such as the parameters of a Component
+// before and after an edit. It also evaluates transition rules, like those of immutable fields,
+// with oldSelf bound to the previous value of the field wherever old has one. Fields are
+// correlated by name and map values by key; list items have no previous value.
+func ValidateUpdate(def Definition, old, values map[string]any) error {
+	jsonSchemaV1, err := ToJSONSchema(def)
+	if err != nil {
+		return err
+	}
+	return validateValues(values, old, jsonSchemaV1)
+}
+
+// validateValues validates values, and the transition from old unless old is nil.
+func validateValues(values, old map[string]any, s *extv1.JSONSchemaProps) error {
+	var errs []error
+	validateValue("", values, old, old != nil, s, func(path, format string, args ...any) {
+		if path == "" {
+			path = "<root>"
+		}
+		errs = append(errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+	})
+	return errors.Join(errs...)
+}
+
+// validateValue reports every violation of s by x to fail. When hasOld is set, old is the previous
+// value of x and transition rules are evaluated against it. It walks the v1 schema directly, like
+// applyDefaults, to keep the apiextensions server packages out of the binary.
+func validateValue(path string, x, old any, hasOld bool, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	if s == nil || (s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields && s.Type == "") {
+		return
+	}
+	if x == nil {
+		if !s.Nullable && s.Type != "" {
+			fail(path, "must not be null")
+		}
+		return
+	}
+	if len(s.Enum) > 0 && !inEnum(x, s.Enum) {
+		fail(path, "unsupported value %v, want one of %s", x, enumList(s.Enum))
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := x.(map[string]any)
+		if !ok {
+			fail(path, "expected object, got %s", typeName(x))
+			return
+		}
+		validateObject(path, object, old, hasOld, s, fail)
+	case "array":
+		array, ok := x.([]any)
+		if !ok {
+			fail(path, "expected array, got %s", typeName(x))
+			return
+		}
+		validateArray(path, array, s, fail)
+	case "string":
+		str, ok := x.(string)
+		if !ok {
+			fail(path, "expected string, got %s", typeName(x))
+			return
+		}
+		validateString(path, str, s, fail)
+	case "integer":
+		number, integral, ok := numberValue(x)
+		if !ok || !integral {
+			fail(path, "expected integer, got %s", typeName(x))
+			return
+		}
+		validateNumber(path, number, s, fail)
+	case "number":
+		number, _, ok := numberValue(x)
+		if !ok {
+			fail(path, "expected number, got %s", typeName(x))
+			return
+		}
+		validateNumber(path, number, s, fail)
+	case "boolean":
+		if _, ok := x.(bool); !ok {
+			fail(path, "expected boolean, got %s", typeName(x))
+		}
+	}
+	if len(s.OneOf) > 0 {
+		validateOneOf(path, x, old, hasOld, s, fail)
+	}
+	for _, rule := range s.XValidations {
+		validateRule(path, x, old, hasOld, rule, fail)
+	}
+}
+
+// validateOneOf reports x unless it matches exactly one branch of s.OneOf. Branches are checked
+// with the type of s, as in a structural schema. When x selects a branch through its enum
+// properties, such as the discriminator of a union, the violations of that branch are reported
+// instead of a bare mismatch.
+func validateOneOf(path string, x, old any, hasOld bool, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	type violation struct{ path, message string }
+	violations := make([][]violation, len(s.OneOf))
+	matched := 0
+	for i := range s.OneOf {
+		branch := s.OneOf[i]
+		if branch.Type == "" {
+			branch.Type = s.Type
+		}
+		validateValue(path, x, old, hasOld, &branch, func(path, format string, args ...any) {
+			violations[i] = append(violations[i], violation{path, fmt.Sprintf(format, args...)})
+		})
+		if len(violations[i]) == 0 {
+			matched++
+		}
+	}
+	switch {
+	case matched == 1:
+	case matched > 1:
+		fail(path, "matches %d of the oneOf schemas, want exactly one", matched)
+	default:
+		branch, ok := selectedBranch(x, s.OneOf)
+		if !ok {
+			fail(path, "matches none of the oneOf schemas")
+			return
+		}
+		for _, v := range violations[branch] {
+			fail(v.path, "%s", v.message)
+		}
+	}
+}
+
+// selectedBranch returns the only branch of oneOf whose enum properties x sets to allowed values.
+// Branches without enum properties select nothing.
+func selectedBranch(x any, oneOf []extv1.JSONSchemaProps) (int, bool) {
+	object, ok := x.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	selected, count := 0, 0
+	for i, branch := range oneOf {
+		pinned, matches := false, true
+		for name, prop := range branch.Properties {
+			if len(prop.Enum) == 0 {
+				continue
+			}
+			pinned = true
+			if value, ok := object[name]; !ok || !inEnum(value, prop.Enum) {
+				matches = false
+			}
+		}
+		if pinned && matches {
+			selected = i
+			count++
+		}
+	}
+	return selected, count == 1
+}
+
+// rulePrograms caches compiled x-kubernetes-validations rules by their text.
+var rulePrograms sync.Map
+
+// compiledRule is a compiled rule and whether it is a transition rule, one that reads oldSelf.
+type compiledRule struct {
+	program    cel.Program
+	transition bool
+}
+
+var ruleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(schemaextractor.RuleEnvOptions()...)
+})
+
+// validateRule evaluates rule with self bound to x, as the API server does. A transition rule is
+// evaluated with oldSelf bound to old, and only when x has a previous value. A rule that fails or
+// cannot be evaluated is reported at its fieldPath, with its message when it has one.
+func validateRule(path string, x, old any, hasOld bool, rule extv1.ValidationRule, fail func(path, format string, args ...any)) {
+	compiled, err := ruleProgram(rule.Rule)
+	if err != nil {
+		fail(path, "invalid rule %q: %v", rule.Rule, err)
+		return
+	}
+	vars := map[string]any{"self": x}
+	if compiled.transition {
+		if !hasOld {
+			return
+		}
+		vars["oldSelf"] = old
+	}
+	out, _, err := compiled.program.Eval(vars)
+	if rule.FieldPath != "" {
+		path += rule.FieldPath
+		path = strings.TrimPrefix(path, ".")
+	}
+	switch {
+	case err != nil:
+		fail(path, "rule %q: %v", rule.Rule, err)
+	case out.Value() != true:
+		if _, ok := out.Value().(bool); !ok {
+			fail(path, "rule %q must evaluate to bool, got %s", rule.Rule, out.Type())
+			return
+		}
+		if rule.Message != "" {
+			fail(path, "%s", rule.Message)
+			return
+		}
+		fail(path, "failed rule: %s", rule.Rule)
+	}
+}
+
+func ruleProgram(rule string) (*compiledRule, error) {
+	if cached, ok := rulePrograms.Load(rule); ok {
+		return cached.(*compiledRule), nil
+	}
+	env, err := ruleEnv()
+	if err != nil {
+		return nil, err
+	}
+	checked, issues := env.Compile(rule)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := env.Program(checked)
+	if err != nil {
+		return nil, err
+	}
+	compiled := &compiledRule{program: program}
+	for _, ref := range checked.NativeRep().ReferenceMap() {
+		if ref.Name == "oldSelf" {
+			compiled.transition = true
+		}
+	}
+	rulePrograms.Store(rule, compiled)
+	return compiled, nil
+}
+
+func validateObject(path string, object map[string]any, old any, hasOld bool, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			fail(fieldPath(path, name), "required value is missing")
+		}
+	}
+	if s.MinProperties != nil && int64(len(object)) < *s.MinProperties {
+		fail(path, "must have at least %d properties, got %d", *s.MinProperties, len(object))
+	}
+	if s.MaxProperties != nil && int64(len(object)) > *s.MaxProperties {
+		fail(path, "must have at most %d properties, got %d", *s.MaxProperties, len(object))
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	additional := additionalSchema(s)
+	oldObject, _ := old.(map[string]any)
+	for _, key := range keys {
+		oldValue, found := oldObject[key]
+		found = found && hasOld
+		if prop, ok := s.Properties[key]; ok {
+			validateValue(fieldPath(path, key), object[key], oldValue, found, &prop, fail)
+		} else if additional != nil {
+			validateValue(fieldPath(path, key), object[key], oldValue, found, additional, fail)
+		}
+	}
+}
+
+func validateArray(path string, array []any, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	if s.MinItems != nil && int64(len(array)) < *s.MinItems {
+		fail(path, "must have at least %d items, got %d", *s.MinItems, len(array))
+	}
+	if s.MaxItems != nil && int64(len(array)) > *s.MaxItems {
+		fail(path, "must have at most %d items, got %d", *s.MaxItems, len(array))
+	}
+	if s.UniqueItems {
+		for i := range array {
+			for j := 0; j < i; j++ {
+				if reflect.DeepEqual(array[i], array[j]) {
+					fail(fmt.Sprintf("%s[%d]", path, i), "duplicates item %d", j)
+					break
+				}
+			}
+		}
+	}
+	var items *extv1.JSONSchemaProps
+	if s.Items != nil {
+		items = s.Items.Schema
+	}
+	for i, item := range array {
+		validateValue(fmt.Sprintf("%s[%d]", path, i), item, nil, false, items, fail)
+	}
+}
+
+func validateString(path, str string, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	length := int64(utf8.RuneCountInString(str))
+	if s.MinLength != nil && length < *s.MinLength {
+		fail(path, "must be at least %d characters long", *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		fail(path, "must be at most %d characters long", *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		pattern, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			fail(path, "schema pattern %q is invalid: %v", s.Pattern, err)
+		} else if !pattern.MatchString(str) {
+			fail(path, "%q does not match pattern %s", str, s.Pattern)
+		}
+	}
+}
+
+func validateNumber(path string, number float64, s *extv1.JSONSchemaProps, fail func(path, format string, args ...any)) {
+	if s.Minimum != nil {
+		if s.ExclusiveMinimum && number <= *s.Minimum {
+			fail(path, "must be greater than %v", *s.Minimum)
+		} else if number < *s.Minimum {
+			fail(path, "must be greater than or equal to %v", *s.Minimum)
+		}
+	}
+	if s.Maximum != nil {
+		if s.ExclusiveMaximum && number >= *s.Maximum {
+			fail(path, "must be less than %v", *s.Maximum)
+		} else if number > *s.Maximum {
+			fail(path, "must be less than or equal to %v", *s.Maximum)
+		}
+	}
+	if s.MultipleOf != nil && *s.MultipleOf != 0 {
+		if quotient := number / *s.MultipleOf; quotient != math.Trunc(quotient) {
+			fail(path, "must be a multiple of %v", *s.MultipleOf)
+		}
+	}
+}
+
+// numberValue converts the numeric types YAML and JSON decoders produce to float64 and reports
+// whether the value is integral.
+func numberValue(x any) (float64, bool, bool) {
+	switch n := x.(type) {
+	case int:
+		return float64(n), true, true
+	case int32:
+		return float64(n), true, true
+	case int64:
+		return float64(n), true, true
+	case uint64:
+		return float64(n), true, true
+	case float32:
+		return float64(n), float64(n) == math.Trunc(float64(n)), true
+	case float64:
+		return n, n == math.Trunc(n) && !math.IsInf(n, 0), true
+	case json.Number:
+		if _, err := n.Int64(); err == nil {
+			f, _ := n.Float64()
+			return f, true, true
+		}
+		f, err := n.Float64()
+		return f, false, err == nil
+	default:
+		return 0, false, false
+	}
+}
+
+// inEnum compares x with each allowed value by JSON encoding, so 2 and 2.0 match.
+func inEnum(x any, enum []extv1.JSON) bool {
+	encoded, err := json.Marshal(x)
+	if err != nil {
+		return false
+	}
+	var normalized any
+	if err := json.Unmarshal(encoded, &normalized); err != nil {
+		return false
+	}
+	for _, allowed := range enum {
+		var value any
+		if err := json.Unmarshal(allowed.Raw, &value); err == nil && reflect.DeepEqual(value, normalized) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumList(enum []extv1.JSON) string {
+	values := make([]string, len(enum))
+	for i, allowed := range enum {
+		values[i] = string(allowed.Raw)
+	}
+	return "[" + strings.Join(values, ", ") + "]"
+}
+
+func typeName(x any) string {
+	switch x.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	if _, integral, ok := numberValue(x); ok {
+		if integral {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", x)
+}
+
+// identifier matches keys a field path can show after a dot; others are quoted in brackets.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func fieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	if identifier.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
@@ -0,0 +1,339 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{
+		Types: map[string]any{
+			"Port": map[string]any{"name": "string", "port": "integer | minimum=1 maximum=65535"},
+		},
+		Schemas: []map[string]any{{
+			"replicas": "integer | default=1 minimum=0",
+			"ratio":    "number | default=0.5 maximum=1",
+			"image":    "string",
+			"mode":     "string | enum=fast,safe",
+			"name":     "string | pattern=^[a-z]+$ maxLength=8",
+			"enabled":  "boolean | default=false",
+			"ports":    "[]Port | default=[]",
+			"labels":   "map[string]string | default={}",
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		values map[string]any
+		want   []string
+	}{
+		{
+			name:   "valid values",
+			values: map[string]any{"image": "nginx", "replicas": 3, "ratio": 0.5, "mode": "fast", "name": "web", "ports": []any{map[string]any{"name": "http", "port": 80}}, "labels": map[string]any{"app": "web"}},
+		},
+		{
+			name:   "missing required field",
+			values: map[string]any{"mode": "safe", "name": "web"},
+			want:   []string{"image: required value is missing"},
+		},
+		{
+			name:   "type mismatches",
+			values: map[string]any{"image": 1, "mode": "fast", "name": "web", "replicas": "two", "enabled": "yes", "ratio": true},
+			want: []string{
+				"enabled: expected boolean, got string",
+				"image: expected string, got integer",
+				"ratio: expected number, got boolean",
+				"replicas: expected integer, got string",
+			},
+		},
+		{
+			name:   "fractional integer",
+			values: map[string]any{"image": "nginx", "mode": "fast", "name": "web", "replicas": 1.5},
+			want:   []string{"replicas: expected integer, got number"},
+		},
+		{
+			name:   "enum, bounds, pattern and length",
+			values: map[string]any{"image": "nginx", "mode": "slow", "name": "Web-Server", "replicas": -1, "ratio": 2},
+			want: []string{
+				`mode: unsupported value slow, want one of ["fast", "safe"]`,
+				`name: must be at most 8 characters long`,
+				`name: "Web-Server" does not match pattern ^[a-z]+$`,
+				"ratio: must be less than or equal to 1",
+				"replicas: must be greater than or equal to 0",
+			},
+		},
+		{
+			name: "nested paths",
+			values: map[string]any{"image": "nginx", "mode": "fast", "name": "web",
+				"ports":  []any{map[string]any{"name": "http", "port": 80}, map[string]any{"port": 70000}},
+				"labels": map[string]any{"example.com/team": 1}},
+			want: []string{
+				`labels["example.com/team"]: expected string, got integer`,
+				"ports[1].name: required value is missing",
+				"ports[1].port: must be less than or equal to 65535",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Validate(def, tt.values)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() succeeded, want %q", tt.want)
+			}
+			got := strings.Split(err.Error(), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() errors =\n%s\nwant\n%s", err, strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCacheValidate(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{Schemas: []map[string]any{{"replicas": "integer | minimum=1"}}}
+	for _, cache := range []*Cache{nil, NewCache(0)} {
+		if err := cache.Validate(def, map[string]any{"replicas": 2}); err != nil {
+			t.Errorf("Validate() error = %v", err)
+		}
+		if err := cache.Validate(def, map[string]any{"replicas": 0}); err == nil || err.Error() != "replicas: must be greater than or equal to 1" {
+			t.Errorf("Validate() error = %v, want the minimum violation", err)
+		}
+	}
+}
+
+func TestValidateRules(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{
+		Types: map[string]any{
+			"Port": map[string]any{"name": `string | validate="self.lowerAscii() == self"`, "port": "integer"},
+		},
+		Schemas: []map[string]any{{
+			"replicas":    `integer | default=1 validate="self <= spec.maxReplicas"`,
+			"maxReplicas": "integer | default=10",
+			"ports":       `[]Port | default=[] validate="self.all(p, p.port != 22)"`,
+			"autoscaling": map[string]any{
+				"minReplicas": `integer | default=1 validate="self <= spec.maxReplicas"`,
+			},
+		}},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		values map[string]any
+		want   []string
+	}{
+		{
+			name:   "valid values",
+			values: map[string]any{"replicas": 3, "maxReplicas": 5, "autoscaling": map[string]any{}, "ports": []any{map[string]any{"name": "http", "port": 80}}},
+		},
+		{
+			name: "violations",
+			values: map[string]any{"replicas": 6, "maxReplicas": 5, "autoscaling": map[string]any{"minReplicas": 8},
+				"ports": []any{map[string]any{"name": "SSH", "port": 22}}},
+			// Rules reading spec are evaluated on the parameters, after their fields, in field order.
+			want: []string{
+				"ports[0].name: failed rule: self.lowerAscii() == self",
+				"ports: failed rule: self.all(p, p.port != 22)",
+				"autoscaling.minReplicas: failed rule: self <= spec.maxReplicas",
+				"replicas: failed rule: self <= spec.maxReplicas",
+			},
+		},
+	} {
+		if err := ApplyDefaults(def, tt.values); err != nil {
+			t.Fatalf("ApplyDefaults() error = %v", err)
+		}
+		err := Validate(def, tt.values)
+		var got []string
+		if err != nil {
+			got = strings.Split(err.Error(), "\n")
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: Validate() errors =\n%v\nwant\n%s", tt.name, err, strings.Join(tt.want, "\n"))
+		}
+	}
+}
+
+func TestUnions(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{
+		Types: map[string]any{
+			"S3Config":  map[string]any{"type": "string | enum=s3", "bucket": "string", "region": "string | default=us-east-1"},
+			"GCSConfig": map[string]any{"type": "string | enum=gcs", "bucket": "string", "project": "string"},
+		},
+		Schemas: []map[string]any{{"storage": "oneOf<S3Config, GCSConfig> | discriminator=type"}},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		values map[string]any
+		// want is the storage object after defaulting.
+		want       map[string]any
+		wantErrors []string
+	}{
+		{
+			name:   "defaults of the selected variant",
+			values: map[string]any{"storage": map[string]any{"type": "s3", "bucket": "logs"}},
+			want:   map[string]any{"type": "s3", "bucket": "logs", "region": "us-east-1"},
+		},
+		{
+			name:   "no defaults of other variants",
+			values: map[string]any{"storage": map[string]any{"type": "gcs", "bucket": "logs", "project": "shop"}},
+			want:   map[string]any{"type": "gcs", "bucket": "logs", "project": "shop"},
+		},
+		{
+			name:       "violations of the selected variant",
+			values:     map[string]any{"storage": map[string]any{"type": "gcs", "bucket": "logs"}},
+			want:       map[string]any{"type": "gcs", "bucket": "logs"},
+			wantErrors: []string{"storage.project: required value is missing"},
+		},
+		{
+			name:   "unknown discriminator value",
+			values: map[string]any{"storage": map[string]any{"type": "azure", "bucket": "logs"}},
+			want:   map[string]any{"type": "azure", "bucket": "logs"},
+			wantErrors: []string{
+				`storage.type: unsupported value azure, want one of ["s3", "gcs"]`,
+				"storage: matches none of the oneOf schemas",
+			},
+		},
+		{
+			name:       "missing discriminator",
+			values:     map[string]any{"storage": map[string]any{"bucket": "logs"}},
+			want:       map[string]any{"bucket": "logs"},
+			wantErrors: []string{"storage.type: required value is missing", "storage: matches none of the oneOf schemas"},
+		},
+	} {
+		if err := ApplyDefaults(def, tt.values); err != nil {
+			t.Fatalf("%s: ApplyDefaults() error = %v", tt.name, err)
+		}
+		if diff := cmp.Diff(tt.want, tt.values["storage"]); diff != "" {
+			t.Errorf("%s: defaulted storage mismatch (-want +got):\n%s", tt.name, diff)
+		}
+		err := Validate(def, tt.values)
+		var got []string
+		if err != nil {
+			got = strings.Split(err.Error(), "\n")
+		}
+		if strings.Join(got, "\n") != strings.Join(tt.wantErrors, "\n") {
+			t.Errorf("%s: Validate() errors =\n%v\nwant\n%s", tt.name, err, strings.Join(tt.wantErrors, "\n"))
+		}
+	}
+}
+
+func TestValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{
+		Types: map[string]any{
+			"Volume": map[string]any{"size": "string", "storageClass": "string | default=standard immutable=true"},
+		},
+		Schemas: []map[string]any{{
+			"image":    "string",
+			"name":     "string | immutable=true",
+			"alias":    "string | required=false immutable=true",
+			"zone":     "string | default=a immutable=true",
+			"volume":   "Volume | default={}",
+			"volumes":  "map<Volume> | default={}",
+			"replicas": `integer | default=1 validate="self >= oldSelf / 2"`,
+		}},
+	}
+	old := map[string]any{
+		"image": "nginx:1", "name": "web", "alias": "www", "zone": "b", "replicas": 4,
+		"volume":  map[string]any{"size": "1Gi", "storageClass": "fast"},
+		"volumes": map[string]any{"data": map[string]any{"size": "1Gi", "storageClass": "fast"}},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		values map[string]any
+		want   []string
+	}{
+		{
+			name: "mutable fields change",
+			values: map[string]any{"image": "nginx:2", "name": "web", "alias": "www", "zone": "b", "replicas": 2,
+				"volume":  map[string]any{"size": "2Gi", "storageClass": "fast"},
+				"volumes": map[string]any{"data": map[string]any{"size": "2Gi", "storageClass": "fast"}, "logs": map[string]any{"size": "1Gi"}}},
+		},
+		{
+			name: "immutable fields change or are removed",
+			values: map[string]any{"image": "nginx:1", "name": "api", "replicas": 1,
+				"volume":  map[string]any{"size": "1Gi", "storageClass": "slow"},
+				"volumes": map[string]any{"data": map[string]any{"size": "1Gi", "storageClass": "slow"}}},
+			want: []string{
+				"name: field is immutable",
+				"replicas: failed rule: self >= oldSelf / 2",
+				"volume.storageClass: field is immutable",
+				"volumes.data.storageClass: field is immutable",
+				"zone: field is immutable",
+				"alias: field is immutable",
+			},
+		},
+	} {
+		if err := ApplyDefaults(def, tt.values); err != nil {
+			t.Fatalf("%s: ApplyDefaults() error = %v", tt.name, err)
+		}
+		for _, cache := range []*Cache{nil, NewCache(0)} {
+			err := cache.ValidateUpdate(def, old, tt.values)
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("%s: ValidateUpdate() errors =\n%v\nwant\n%s", tt.name, err, strings.Join(tt.want, "\n"))
+			}
+		}
+		// Without a previous value, transition rules are not evaluated.
+		if err := Validate(def, tt.values); err != nil {
+			t.Errorf("%s: Validate() error = %v", tt.name, err)
+		}
+	}
+}
+
+func TestDeprecations(t *testing.T) {
+	t.Parallel()
+
+	def := Definition{
+		Types: map[string]any{"Port": map[string]any{"port": "integer", "protocol": "string | default=TCP deprecated=true"}},
+		Schemas: []map[string]any{{
+			"image":    "string",
+			"tag":      `string | default=latest deprecated="set the tag in image"`,
+			"ports":    "[]Port | default=[]",
+			"settings": map[string]any{"debug": `boolean | default=false deprecated="use logLevel"`},
+		}},
+	}
+	values := map[string]any{
+		"image":    "nginx",
+		"tag":      "1.27",
+		"ports":    []any{map[string]any{"port": 80}, map[string]any{"port": 53, "protocol": "UDP"}},
+		"settings": map[string]any{},
+	}
+	want := []Deprecation{
+		{Path: "ports[1].protocol"},
+		{Path: "tag", Notice: "set the tag in image"},
+	}
+	for _, cache := range []*Cache{nil, NewCache(0)} {
+		got, err := cache.Deprecations(def, values)
+		if err != nil {
+			t.Fatalf("Deprecations() error = %v", err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Deprecations() mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package schemaextractor
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// oldSelfVariable is the variable transition rules read the previous value of a field through,
+// as in the API server, which evaluates them on updates only.
+const oldSelfVariable = "oldSelf"
+
+// immutableRule is the transition rule the immutable marker adds: once set, a field keeps its
+// value. The API server evaluates it only when the field had a previous value, so setting a field
+// for the first time is allowed; removing one is caught by the rule removalRules adds to the parent.
+var immutableRule = extv1.ValidationRule{Rule: "self == oldSelf", Message: "field is immutable"}
+
+// immutable parses the value of the immutable marker into schema.
+func immutable(schema *extv1.JSONSchemaProps, value string) error {
+	set, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if set && !isImmutable(schema) {
+		schema.XValidations = append(schema.XValidations, immutableRule)
+	}
+	return nil
+}
+
+func isImmutable(s *extv1.JSONSchemaProps) bool {
+	for _, rule := range s.XValidations {
+		if rule.Rule == immutableRule.Rule {
+			return true
+		}
+	}
+	return false
+}
+
+// removalRules returns the rules an object needs so its optional immutable properties cannot be
+// removed once set, a change the rule on the property itself never sees.
+func removalRules(s *extv1.JSONSchemaProps) []extv1.ValidationRule {
+	required := map[string]bool{}
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name, prop := range s.Properties {
+		if !required[name] && ruleField.MatchString(name) && isImmutable(&prop) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	rules := make([]extv1.ValidationRule, len(names))
+	for i, name := range names {
+		rules[i] = extv1.ValidationRule{
+			Rule:      fmt.Sprintf("!has(oldSelf.%s) || has(self.%s)", name, name),
+			Message:   immutableRule.Message,
+			FieldPath: "." + name,
+		}
+	}
+	return rules
+}
+
+// deprecated parses the value of the deprecated marker, true or a notice such as "use
+// spec.image", and reports whether the field is deprecated.
+func deprecated(value string) (string, bool, error) {
+	if set, err := strconv.ParseBool(value); err == nil {
+		return "", set, nil
+	}
+	notice, err := parseValueForType(value, "string")
+	if err != nil {
+		return "", false, err
+	}
+	if notice == "" {
+		return "", false, fmt.Errorf("empty notice")
+	}
+	return notice.(string), true, nil
+}
+
+// deprecate appends the deprecation paragraph DeprecationNotice reads back to description.
+func deprecate(description, notice string) string {
+	paragraph := "Deprecated."
+	if notice != "" {
+		paragraph = "Deprecated: " + notice
+	}
+	if description == "" {
+		return paragraph
+	}
+	return description + "\n\n" + paragraph
+}
+
+// DeprecationNotice reports whether the deprecated marker declared the field s describes, and the
+// notice it gave, which is empty for deprecated=true.
+func DeprecationNotice(s *extv1.JSONSchemaProps) (string, bool) {
+	paragraph := s.Description
+	if i := strings.LastIndex(paragraph, "\n\n"); i != -1 {
+		paragraph = paragraph[i+2:]
+	}
+	if paragraph == "Deprecated." {
+		return "", true
+	}
+	if notice, ok := strings.CutPrefix(paragraph, "Deprecated: "); ok {
+		return notice, true
+	}
+	return "", false
+}
+
+// checkTransitionRules rejects rules that read oldSelf inside list items, which have no previous
+// value to compare with: the API server only correlates the fields of objects and the values of
+// maps across an update.
+func checkTransitionRules(s *extv1.JSONSchemaProps, inList bool) error {
+	if inList {
+		for _, rule := range s.XValidations {
+			if !strings.Contains(rule.Rule, oldSelfVariable) {
+				continue
+			}
+			_, vars, err := parseRule(rule.Rule)
+			if err != nil {
+				return fmt.Errorf("invalid validate rule %q: %w", rule.Rule, err)
+			}
+			if vars[oldSelfVariable] {
+				return fmt.Errorf("validate rule %q reads %s inside a list, whose items have no previous value", rule.Rule, oldSelfVariable)
+			}
+		}
+	}
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if err := checkTransitionRules(&prop, inList); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		if err := checkTransitionRules(s.Items.Schema, true); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return checkTransitionRules(s.AdditionalProperties.Schema, inList)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package schemaextractor
+
+import (
+	"testing"
+)
+
+func TestLifecycleMarkers(t *testing.T) {
+	t.Parallel()
+
+	assertConvertedSchema(t, ``, `
+storageClass: 'string | default=standard immutable=true'
+volumeName: 'string | immutable=true'
+size: 'string | description=Size deprecated="use spec.storage.size"'
+legacy: 'boolean | default=false deprecated=true'
+`, `{
+  "type": "object",
+  "required": [
+    "size",
+    "volumeName"
+  ],
+  "properties": {
+    "legacy": {
+      "description": "Deprecated.",
+      "type": "boolean",
+      "default": false
+    },
+    "size": {
+      "description": "Size\n\nDeprecated: use spec.storage.size",
+      "type": "string"
+    },
+    "storageClass": {
+      "type": "string",
+      "default": "standard",
+      "x-kubernetes-validations": [
+        {
+          "rule": "self == oldSelf",
+          "message": "field is immutable"
+        }
+      ]
+    },
+    "volumeName": {
+      "type": "string",
+      "x-kubernetes-validations": [
+        {
+          "rule": "self == oldSelf",
+          "message": "field is immutable"
+        }
+      ]
+    }
+  },
+  "x-kubernetes-validations": [
+    {
+      "rule": "!has(oldSelf.storageClass) || has(self.storageClass)",
+      "message": "field is immutable",
+      "fieldPath": ".storageClass"
+    }
+  ]
+}`)
+
+	for schema, want := range map[string]string{
+		`string | immutable=yes`:                                 `field "x": invalid immutable value "yes"`,
+		`string | deprecated=""`:                                 `field "x": invalid deprecated value "\"\"": empty notice`,
+		`map<string> | immutable=true`:                           "",
+		`map<string> | validate="self.all(k, k in oldSelf)"`:     "",
+		`[]string | validate="oldSelf.all(s, s in self)"`:        "",
+		`integer | validate="self == oldSelf || self < spec.n"`:  `validate rule "self == oldSelf || self < spec.n" reads both spec and oldSelf`,
+		`[]integer | validate="self.all(i, i != spec.reserved)"`: "",
+	} {
+		_, err := NewConverter(nil).Convert(map[string]any{"x": schema})
+		if want == "" {
+			if err != nil {
+				t.Errorf("Convert(%q) error = %v", schema, err)
+			}
+			continue
+		}
+		if err == nil || len(err.Error()) < len(want) || err.Error()[:len(want)] != want {
+			t.Errorf("Convert(%q) error = %v, want prefix %q", schema, err, want)
+		}
+	}
+
+	_, err := NewConverter(map[string]any{"Port": map[string]any{"port": "integer | immutable=true"}}).
+		Convert(map[string]any{"ports": "[]Port"})
+	if want := `validate rule "self == oldSelf" reads oldSelf inside a list, whose items have no previous value`; err == nil || err.Error() != want {
+		t.Errorf("Convert() of an immutable list item field error = %v, want %q", err, want)
+	}
+}
+
+func TestDeprecationNotice(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		schema     string
+		wantNotice string
+		wantOK     bool
+	}{
+		{schema: "string | deprecated=true", wantOK: true},
+		{schema: `string | deprecated="use image"`, wantNotice: "use image", wantOK: true},
+		{schema: "string | deprecated=true description=Old", wantOK: true},
+		{schema: "string | deprecated=false"},
+		{schema: "string | description=Current"},
+	} {
+		s, _, _, err := NewConverter(nil).schemaFromString(tt.schema)
+		if err != nil {
+			t.Fatalf("schemaFromString(%q) error = %v", tt.schema, err)
+		}
+		notice, ok := DeprecationNotice(s)
+		if notice != tt.wantNotice || ok != tt.wantOK {
+			t.Errorf("DeprecationNotice(%q) = %q, %v, want %q, %v", tt.schema, notice, ok, tt.wantNotice, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package schemaextractor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// MarkerFunc implements a custom constraint marker such as `unit=millicores`. It receives the
+// schema of the field the marker is written on, with the field's type set and the markers before
+// it applied, and the text after "=". Handlers express their constraint in schema keywords
+// (pattern, enum, bounds, format, description), so it shows in the generated JSON Schema and CRDs
+// and is enforced wherever the schema is validated.
+type MarkerFunc func(schema *extv1.JSONSchemaProps, value string) error
+
+var (
+	markersMu sync.RWMutex
+	// markers holds the registered custom markers. A nil handler accepts the marker without
+	// changing the schema.
+	markers = map[string]MarkerFunc{
+		// UI hints for populating dropdowns; they do not constrain values.
+		"queryContainers": nil,
+		"queryResources":  nil,
+	}
+)
+
+// builtinMarkers are the markers applyConstraints handles itself.
+var builtinMarkers = map[string]bool{
+	"required": true, "default": true, "enum": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minItems": true, "maxItems": true, "uniqueItems": true, "minLength": true, "maxLength": true,
+	"minProperties": true, "maxProperties": true, "multipleOf": true,
+	"title": true, "description": true, "format": true, "example": true, "nullable": true, "validate": true,
+	"discriminator": true, "immutable": true, "deprecated": true,
+}
+
+// RegisterMarker adds a custom constraint marker. Register markers during program initialization:
+// converted schemas are cached by the content of their definition, so a marker registered later
+// does not reach schemas converted before. Built-in and already registered names are rejected.
+func RegisterMarker(name string, fn MarkerFunc) error {
+	if name == "" {
+		return fmt.Errorf("marker name must not be empty")
+	}
+	if fn == nil {
+		return fmt.Errorf("marker %s has no handler", name)
+	}
+	if builtinMarkers[name] {
+		return fmt.Errorf("marker %s is built in", name)
+	}
+	markersMu.Lock()
+	defer markersMu.Unlock()
+	if _, ok := markers[name]; ok {
+		return fmt.Errorf("marker %s is already registered", name)
+	}
+	markers[name] = fn
+	return nil
+}
+
+// Markers returns the names of the built-in and registered markers, sorted.
+func Markers() []string {
+	markersMu.RLock()
+	defer markersMu.RUnlock()
+	names := make([]string, 0, len(builtinMarkers)+len(markers))
+	for name := range builtinMarkers {
+		names = append(names, name)
+	}
+	for name := range markers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupMarker(name string) (MarkerFunc, bool) {
+	markersMu.RLock()
+	defer markersMu.RUnlock()
+	fn, ok := markers[name]
+	return fn, ok
+}
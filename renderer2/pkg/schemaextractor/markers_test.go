@@ -0,0 +1,92 @@
+package schemaextractor
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func init() {
+	// Markers live in a process-wide registry, so tests register them once with unique names.
+	if err := RegisterMarker("testUnit", func(schema *extv1.JSONSchemaProps, value string) error {
+		switch value {
+		case "millicores":
+			schema.Pattern = `^[0-9]+m$`
+		case "mebibytes":
+			schema.Pattern = `^[0-9]+Mi$`
+		default:
+			return fmt.Errorf("unsupported unit")
+		}
+		if schema.Type != "string" {
+			return fmt.Errorf("requires a string field, got %s", schema.Type)
+		}
+		schema.Description = "quantity in " + value
+		return nil
+	}); err != nil {
+		panic(err)
+	}
+}
+
+func TestCustomMarkers(t *testing.T) {
+	t.Parallel()
+
+	assertConvertedSchema(t, ``, `
+cpu: 'string | default=100m testUnit=millicores'
+container: 'string | queryContainers=true'
+`, `{
+  "type": "object",
+  "required": [
+    "container"
+  ],
+  "properties": {
+    "container": {
+      "type": "string"
+    },
+    "cpu": {
+      "description": "quantity in millicores",
+      "type": "string",
+      "default": "100m",
+      "pattern": "^[0-9]+m$"
+    }
+  }
+}`)
+
+	for schema, want := range map[string]string{
+		"integer | testUnit=millicores": `field "x": invalid testUnit "millicores": requires a string field, got integer`,
+		"string | testUnit=furlongs":    `field "x": invalid testUnit "furlongs": unsupported unit`,
+		"string | secretRef=true":       `field "x": unknown constraint marker "secretRef"`,
+	} {
+		_, err := NewConverter(nil).Convert(map[string]any{"x": schema})
+		if err == nil || err.Error() != want {
+			t.Errorf("Convert(%q) error = %v, want %q", schema, err, want)
+		}
+	}
+}
+
+func TestRegisterMarker(t *testing.T) {
+	t.Parallel()
+
+	noop := func(*extv1.JSONSchemaProps, string) error { return nil }
+	for name, want := range map[string]string{
+		"pattern":        "marker pattern is built in",
+		"testUnit":       "marker testUnit is already registered",
+		"queryResources": "marker queryResources is already registered",
+		"":               "marker name must not be empty",
+	} {
+		if err := RegisterMarker(name, noop); err == nil || err.Error() != want {
+			t.Errorf("RegisterMarker(%q) error = %v, want %q", name, err, want)
+		}
+	}
+	if err := RegisterMarker("testNil", nil); err == nil {
+		t.Error("RegisterMarker() with a nil handler succeeded")
+	}
+
+	names := Markers()
+	for _, name := range []string{"default", "queryContainers", "testUnit"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("Markers() = %v, missing %s", names, name)
+		}
+	}
+}
@@ -41,7 +41,17 @@ func (c *Converter) Convert(fields map[string]any) (*extv1.JSONSchemaProps, erro
 		}, nil
 	}
 
-	return c.buildObjectSchema(fields)
+	schema, err := c.buildObjectSchema(fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := hoistRootRules(schema, schema, nil, false); err != nil {
+		return nil, err
+	}
+	if err := checkTransitionRules(schema, false); err != nil {
+		return nil, err
+	}
+	return schema, nil
 }
 
 func (c *Converter) buildObjectSchema(fields map[string]any) (*extv1.JSONSchemaProps, error) {
@@ -82,6 +92,7 @@ func (c *Converter) buildObjectSchema(fields map[string]any) (*extv1.JSONSchemaP
 	if len(required) > 0 {
 		result.Required = required
 	}
+	result.XValidations = removalRules(result)
 	return result, nil
 }
 
@@ -110,7 +121,15 @@ func (c *Converter) schemaFromString(expr string) (*extv1.JSONSchemaProps, bool,
 		constraintExpr = strings.TrimSpace(expr[idx+1:])
 	}
 
-	schema, err := c.schemaFromType(typeExpr)
+	var (
+		schema *extv1.JSONSchemaProps
+		err    error
+	)
+	if isUnionType(typeExpr) {
+		schema, err = c.unionSchema(typeExpr, discriminatorOf(constraintExpr))
+	} else {
+		schema, err = c.schemaFromType(typeExpr)
+	}
 	if err != nil {
 		return nil, false, false, err
 	}
@@ -134,6 +153,10 @@ func (c *Converter) schemaFromType(typeExpr string) (*extv1.JSONSchemaProps, err
 		return &extv1.JSONSchemaProps{Type: "boolean"}, nil
 	case typeExpr == "object":
 		return &extv1.JSONSchemaProps{Type: "object"}, nil
+	case isUnionType(typeExpr):
+		// The discriminator is a constraint of the whole expression, which lists and maps do not
+		// pass down.
+		return nil, fmt.Errorf("%s must be declared as a named type to be used in a list or map", typeExpr)
 	case strings.HasPrefix(typeExpr, "[]"):
 		itemTypeExpr := strings.TrimSpace(typeExpr[2:])
 		items, err := c.schemaFromType(itemTypeExpr)
@@ -241,6 +264,7 @@ func applyConstraints(schema *extv1.JSONSchemaProps, constraintExpr, schemaType
 	tokens := tokenizeConstraints(constraintExpr)
 	var required bool
 	var hasRequired bool
+	var deprecation *string
 
 	for _, token := range tokens {
 		if !strings.Contains(token, "=") {
@@ -373,23 +397,66 @@ func applyConstraints(schema *extv1.JSONSchemaProps, constraintExpr, schemaType
 				return false, false, fmt.Errorf("failed to marshal example %#v: %w", parsed, err)
 			}
 			schema.Example = &extv1.JSON{Raw: raw}
+		case "validate":
+			rule, _, err := parseRule(value)
+			if err != nil {
+				return false, false, fmt.Errorf("invalid validate rule %s: %w", value, err)
+			}
+			schema.XValidations = append(schema.XValidations, extv1.ValidationRule{Rule: rule})
+		case "discriminator":
+			// unionSchema reads the discriminator of oneOf types.
+			if len(schema.OneOf) == 0 {
+				return false, false, fmt.Errorf("discriminator only applies to oneOf types")
+			}
 		case "nullable":
 			boolVal, err := strconv.ParseBool(value)
 			if err != nil {
 				return false, false, fmt.Errorf("invalid nullable value %q: %w", value, err)
 			}
 			schema.Nullable = boolVal
+		case "immutable":
+			if err := immutable(schema, value); err != nil {
+				return false, false, fmt.Errorf("invalid immutable value %q: %w", value, err)
+			}
+		case "deprecated":
+			notice, ok, err := deprecated(value)
+			if err != nil {
+				return false, false, fmt.Errorf("invalid deprecated value %q: %w", value, err)
+			}
+			deprecation = nil
+			if ok {
+				deprecation = &notice
+			}
 		default:
-			// Unknown markers are ignored for now. They can be handled by callers if needed.
+			handler, ok := lookupMarker(key)
+			if !ok {
+				return false, false, fmt.Errorf("unknown constraint marker %q", key)
+			}
+			if handler == nil {
+				continue
+			}
+			if err := handler(schema, value); err != nil {
+				return false, false, fmt.Errorf("invalid %s %q: %w", key, value, err)
+			}
 		}
 	}
 
+	if deprecation != nil {
+		schema.Description = deprecate(schema.Description, *deprecation)
+	}
 	return required, hasRequired, nil
 }
 
 func parseValueForType(value, schemaType string) (any, error) {
 	switch schemaType {
 	case "string":
+		// A double-quoted value such as default="" is a JSON string, so the empty string can be
+		// written at all.
+		if strings.HasPrefix(value, `"`) {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted, nil
+			}
+		}
 		return value, nil
 	case "integer":
 		if value == "" {
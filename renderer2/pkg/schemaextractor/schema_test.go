@@ -72,6 +72,7 @@ func TestConverter_DefaultRequiredBehaviour(t *testing.T) {
 	const schemaYAML = `
 mustProvide: string
 hasDefault: 'integer | default=5'
+emptyDefault: 'string | default=""'
 explicitOpt: 'boolean | required=false'
 `
 	const expected = `{
@@ -80,6 +81,10 @@ explicitOpt: 'boolean | required=false'
     "mustProvide"
   ],
   "properties": {
+    "emptyDefault": {
+      "type": "string",
+      "default": ""
+    },
     "explicitOpt": {
       "type": "boolean"
     },
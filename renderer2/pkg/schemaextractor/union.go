@@ -0,0 +1,134 @@
+package schemaextractor
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// unionPrefix starts a union type expression such as oneOf<S3Config, GCSConfig>.
+const unionPrefix = "oneOf<"
+
+func isUnionType(typeExpr string) bool {
+	return strings.HasPrefix(typeExpr, unionPrefix) && strings.HasSuffix(typeExpr, ">")
+}
+
+// discriminatorOf returns the value of the discriminator marker in constraintExpr.
+func discriminatorOf(constraintExpr string) string {
+	for _, token := range tokenizeConstraints(constraintExpr) {
+		if key, value, ok := strings.Cut(token, "="); ok && strings.TrimSpace(key) == "discriminator" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// unionSchema converts oneOf<A, B, ...> with the given discriminator into a structural schema: an
+// object declaring the properties of every variant, with one oneOf branch per variant that pins
+// the discriminator to the variant's value and requires the variant's required fields. Each
+// variant must be an object type declaring the discriminator as a string with a single enum value.
+//
+// Branches of a structural schema cannot declare types, so a field several variants declare must
+// be declared identically. Defaults of fields only some variants declare move into their branches,
+// where the renderer applies them once the discriminator selects the branch.
+func (c *Converter) unionSchema(typeExpr, discriminator string) (*extv1.JSONSchemaProps, error) {
+	names := splitAndTrim(typeExpr[len(unionPrefix):len(typeExpr)-1], ",")
+	if len(names) < 2 {
+		return nil, fmt.Errorf("%s needs at least two types", typeExpr)
+	}
+	if discriminator == "" {
+		return nil, fmt.Errorf("%s needs a discriminator=<field> constraint", typeExpr)
+	}
+
+	variants := make([]*extv1.JSONSchemaProps, len(names))
+	declaredBy := map[string][]int{}
+	valueOf := map[string]string{}
+	var values []extv1.JSON
+	for i, name := range names {
+		variant, err := c.schemaFromType(name)
+		if err != nil {
+			return nil, fmt.Errorf("oneOf variant %s: %w", name, err)
+		}
+		if variant.Type != "object" || len(variant.Properties) == 0 {
+			return nil, fmt.Errorf("oneOf variant %s must be an object type with properties", name)
+		}
+		if len(variant.XValidations) > 0 {
+			return nil, fmt.Errorf("oneOf variant %s cannot have validate rules of its own; declare them on its fields", name)
+		}
+		tag, ok := variant.Properties[discriminator]
+		if !ok || tag.Type != "string" || len(tag.Enum) != 1 {
+			return nil, fmt.Errorf("oneOf variant %s must declare %s as a string with a single enum value", name, discriminator)
+		}
+		value := string(tag.Enum[0].Raw)
+		if other, ok := valueOf[value]; ok {
+			return nil, fmt.Errorf("oneOf variants %s and %s share %s value %s", other, name, discriminator, value)
+		}
+		valueOf[value] = name
+		values = append(values, tag.Enum[0])
+		variants[i] = variant
+		for field := range variant.Properties {
+			if field != discriminator {
+				declaredBy[field] = append(declaredBy[field], i)
+			}
+		}
+	}
+
+	union := &extv1.JSONSchemaProps{
+		Type: "object",
+		Properties: map[string]extv1.JSONSchemaProps{
+			discriminator: {Type: "string", Enum: values},
+		},
+		Required: []string{discriminator},
+	}
+	branches := make([]extv1.JSONSchemaProps, len(variants))
+	for i, variant := range variants {
+		branches[i] = extv1.JSONSchemaProps{
+			Properties: map[string]extv1.JSONSchemaProps{
+				discriminator: {Enum: variant.Properties[discriminator].Enum},
+			},
+			Required: variant.Required,
+		}
+	}
+
+	fields := make([]string, 0, len(declaredBy))
+	for field := range declaredBy {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		declaring := declaredBy[field]
+		shared := len(declaring) == len(variants)
+		first := variants[declaring[0]].Properties[field]
+		for _, i := range declaring[1:] {
+			if !sameField(first, variants[i].Properties[field], shared) {
+				return nil, fmt.Errorf("oneOf variants %s and %s declare field %s differently", names[declaring[0]], names[i], field)
+			}
+		}
+		if shared {
+			union.Properties[field] = first
+			continue
+		}
+		prop := *first.DeepCopy()
+		prop.Default = nil
+		union.Properties[field] = prop
+		for _, i := range declaring {
+			if def := variants[i].Properties[field].Default; def != nil {
+				branches[i].Properties[field] = extv1.JSONSchemaProps{Default: def.DeepCopy()}
+			}
+		}
+	}
+	union.OneOf = branches
+	return union, nil
+}
+
+// sameField reports whether two variants declare a field alike. Fields only some variants declare
+// may differ in their defaults.
+func sameField(a, b extv1.JSONSchemaProps, compareDefaults bool) bool {
+	if !compareDefaults {
+		a.Default, b.Default = nil, nil
+	}
+	return reflect.DeepEqual(a, b)
+}
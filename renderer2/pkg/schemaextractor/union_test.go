@@ -0,0 +1,168 @@
+package schemaextractor
+
+import (
+	"strings"
+	"testing"
+)
+
+const storageTypes = `
+S3Config:
+  type: string | enum=s3
+  bucket: string
+  region: string | default=us-east-1
+  prefix: string | default=""
+GCSConfig:
+  type: string | enum=gcs
+  bucket: string
+  project: string
+  prefix: string | default=""
+`
+
+func TestUnionTypes(t *testing.T) {
+	t.Parallel()
+
+	assertConvertedSchema(t, storageTypes, `
+storage: oneOf<S3Config, GCSConfig> | discriminator=type
+`, `{
+  "type": "object",
+  "required": [
+    "storage"
+  ],
+  "properties": {
+    "storage": {
+      "type": "object",
+      "required": [
+        "type"
+      ],
+      "oneOf": [
+        {
+          "required": [
+            "bucket",
+            "type"
+          ],
+          "properties": {
+            "region": {
+              "default": "us-east-1"
+            },
+            "type": {
+              "enum": [
+                "s3"
+              ]
+            }
+          }
+        },
+        {
+          "required": [
+            "bucket",
+            "project",
+            "type"
+          ],
+          "properties": {
+            "type": {
+              "enum": [
+                "gcs"
+              ]
+            }
+          }
+        }
+      ],
+      "properties": {
+        "bucket": {
+          "type": "string"
+        },
+        "prefix": {
+          "type": "string",
+          "default": ""
+        },
+        "project": {
+          "type": "string"
+        },
+        "region": {
+          "type": "string"
+        },
+        "type": {
+          "type": "string",
+          "enum": [
+            "s3",
+            "gcs"
+          ]
+        }
+      }
+    }
+  }
+}`)
+}
+
+func TestUnionTypeErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		types   string
+		field   string
+		wantErr string
+	}{
+		{
+			name:    "missing discriminator",
+			field:   "oneOf<S3Config, GCSConfig>",
+			wantErr: "oneOf<S3Config, GCSConfig> needs a discriminator=<field> constraint",
+		},
+		{
+			name:    "single variant",
+			field:   "oneOf<S3Config> | discriminator=type",
+			wantErr: "oneOf<S3Config> needs at least two types",
+		},
+		{
+			name:    "variant without a discriminator value",
+			types:   "Local:\n  type: string\n  path: string\n",
+			field:   "oneOf<S3Config, Local> | discriminator=type",
+			wantErr: "oneOf variant Local must declare type as a string with a single enum value",
+		},
+		{
+			name:    "duplicate discriminator value",
+			types:   "MinIO:\n  type: string | enum=s3\n  endpoint: string\n",
+			field:   "oneOf<S3Config, MinIO> | discriminator=type",
+			wantErr: `oneOf variants S3Config and MinIO share type value "s3"`,
+		},
+		{
+			name:    "field declared differently",
+			types:   "Local:\n  type: string | enum=local\n  bucket: integer\n",
+			field:   "oneOf<S3Config, Local> | discriminator=type",
+			wantErr: "oneOf variants S3Config and Local declare field bucket differently",
+		},
+		{
+			name:    "scalar variant",
+			types:   "Path: string\n",
+			field:   "oneOf<S3Config, Path> | discriminator=type",
+			wantErr: "oneOf variant Path must be an object type with properties",
+		},
+		{
+			name:    "union inside a list",
+			field:   "[]oneOf<S3Config, GCSConfig> | discriminator=type",
+			wantErr: "oneOf<S3Config, GCSConfig> must be declared as a named type to be used in a list or map",
+		},
+		{
+			name:    "discriminator on another type",
+			field:   "S3Config | discriminator=type",
+			wantErr: "discriminator only applies to oneOf types",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			types := parseYAMLMap(t, storageTypes)
+			if tt.types != "" {
+				for name, value := range parseYAMLMap(t, tt.types) {
+					types[name] = value
+				}
+			}
+			_, err := NewConverter(types).Convert(map[string]any{"storage": tt.field})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Convert() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
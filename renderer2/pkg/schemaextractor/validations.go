@@ -0,0 +1,156 @@
+package schemaextractor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// rootVariable is the variable validate rules read the root of the parameters through, for
+// constraints across fields such as validate="self <= spec.maxReplicas".
+const rootVariable = "spec"
+
+// RuleEnvOptions returns the CEL environment x-kubernetes-validations rules are evaluated in:
+// self bound to the validated value and, in transition rules, oldSelf bound to its previous value,
+// with the string extensions and cel.bind the API server provides.
+func RuleEnvOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Variable("self", cel.DynType),
+		cel.Variable(oldSelfVariable, cel.DynType),
+		ext.Strings(),
+		ext.Bindings(),
+	}
+}
+
+// ruleEnv checks validate rules as written, which may also read spec.
+var ruleEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(append(RuleEnvOptions(), cel.Variable(rootVariable, cel.DynType))...)
+})
+
+// parseRule unquotes the value of a validate marker and compiles it, returning the rule and the
+// variables it reads.
+func parseRule(value string) (string, map[string]bool, error) {
+	rule := value
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid quoting: %w", err)
+		}
+		rule = unquoted
+	case len(value) >= 2 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'"):
+		rule = value[1 : len(value)-1]
+	}
+	if strings.TrimSpace(rule) == "" {
+		return "", nil, fmt.Errorf("empty rule")
+	}
+
+	env, err := ruleEnv()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+	checked, issues := env.Compile(rule)
+	if issues.Err() != nil {
+		return "", nil, issues.Err()
+	}
+	if checked.OutputType() != cel.BoolType && checked.OutputType() != cel.DynType {
+		return "", nil, fmt.Errorf("rule must evaluate to bool, got %s", checked.OutputType())
+	}
+	vars := map[string]bool{}
+	for _, ref := range checked.NativeRep().ReferenceMap() {
+		if ref.Name != "" {
+			vars[ref.Name] = true
+		}
+	}
+	return rule, vars, nil
+}
+
+// ruleField matches the property names a hoisted rule can select with a dot.
+var ruleField = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// hoistRootRules moves the rules that read spec from the fields they are written on to root, the
+// parameters object, where the API server evaluates them with self bound to the parameters. The
+// moved rule binds spec to the parameters and self to the field, and holds when the field is
+// absent. Rules inside lists and maps have no single field to bind and cannot read spec, and
+// rules reading spec cannot read oldSelf, which the parameters object has no field for.
+func hoistRootRules(root, s *extv1.JSONSchemaProps, path []string, nested bool) error {
+	kept := s.XValidations[:0]
+	for _, rule := range s.XValidations {
+		if rule.Rule == "" || !strings.Contains(rule.Rule, rootVariable) {
+			kept = append(kept, rule)
+			continue
+		}
+		_, vars, err := parseRule(rule.Rule)
+		if err != nil {
+			return fmt.Errorf("invalid validate rule %q: %w", rule.Rule, err)
+		}
+		if !vars[rootVariable] {
+			kept = append(kept, rule)
+			continue
+		}
+		if vars[oldSelfVariable] {
+			return fmt.Errorf("validate rule %q reads both %s and %s", rule.Rule, rootVariable, oldSelfVariable)
+		}
+		if nested {
+			return fmt.Errorf("validate rule %q reads %s inside a list or map", rule.Rule, rootVariable)
+		}
+		guards := make([]string, 0, len(path)+1)
+		for i, name := range path {
+			if !ruleField.MatchString(name) {
+				return fmt.Errorf("validate rule %q reads %s from field %q, which cannot be selected in a rule", rule.Rule, rootVariable, name)
+			}
+			guards = append(guards, "!has(self."+strings.Join(path[:i+1], ".")+")")
+		}
+		field := strings.Join(path, ".")
+		guards = append(guards, fmt.Sprintf("cel.bind(%s, self, cel.bind(self, %s.%s, %s))", rootVariable, rootVariable, field, rule.Rule))
+		root.XValidations = append(root.XValidations, extv1.ValidationRule{
+			Rule:      strings.Join(guards, " || "),
+			Message:   firstNonEmpty(rule.Message, "failed rule: "+rule.Rule),
+			FieldPath: "." + field,
+		})
+	}
+	if len(kept) == 0 {
+		kept = nil
+	}
+	s.XValidations = kept
+
+	names := make([]string, 0, len(s.Properties))
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop := s.Properties[name]
+		if err := hoistRootRules(root, &prop, append(path[:len(path):len(path)], name), nested); err != nil {
+			return err
+		}
+		s.Properties[name] = prop
+	}
+	if s.Items != nil && s.Items.Schema != nil {
+		if err := hoistRootRules(root, s.Items.Schema, path, true); err != nil {
+			return err
+		}
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		if err := hoistRootRules(root, s.AdditionalProperties.Schema, path, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package schemaextractor
+
+import (
+	"testing"
+)
+
+func TestValidateRules(t *testing.T) {
+	t.Parallel()
+
+	assertConvertedSchema(t, ``, `
+replicas: 'integer | default=1 validate="self <= spec.maxReplicas"'
+maxReplicas: 'integer | default=10'
+name: "string | validate='self.startsWith(\"web\")'"
+`, `{
+  "type": "object",
+  "required": [
+    "name"
+  ],
+  "properties": {
+    "maxReplicas": {
+      "type": "integer",
+      "default": 10
+    },
+    "name": {
+      "type": "string",
+      "x-kubernetes-validations": [
+        {
+          "rule": "self.startsWith(\"web\")"
+        }
+      ]
+    },
+    "replicas": {
+      "type": "integer",
+      "default": 1
+    }
+  },
+  "x-kubernetes-validations": [
+    {
+      "rule": "!has(self.replicas) || cel.bind(spec, self, cel.bind(self, spec.replicas, self \u003c= spec.maxReplicas))",
+      "message": "failed rule: self \u003c= spec.maxReplicas",
+      "fieldPath": ".replicas"
+    }
+  ]
+}`)
+
+	for schema, want := range map[string]string{
+		`integer | validate="self <"`:                               `field "x": invalid validate rule "self <": ERROR: <input>:1:7: Syntax error: mismatched input '<EOF>'`,
+		`integer | validate="self + 1"`:                             `field "x": invalid validate rule "self + 1": rule must evaluate to bool, got int`,
+		`[]integer | validate="self.size() < spec.n"`:               "",
+		`map<integer> | validate="self.all(k, k != spec.reserved)"`: "",
+	} {
+		_, err := NewConverter(nil).Convert(map[string]any{"x": schema})
+		if want == "" {
+			if err != nil {
+				t.Errorf("Convert(%q) error = %v", schema, err)
+			}
+			continue
+		}
+		if err == nil || len(err.Error()) < len(want) || err.Error()[:len(want)] != want {
+			t.Errorf("Convert(%q) error = %v, want prefix %q", schema, err, want)
+		}
+	}
+
+	_, err := NewConverter(map[string]any{"Port": map[string]any{"port": `integer | validate="self != spec.adminPort"`}}).
+		Convert(map[string]any{"ports": "[]Port"})
+	if want := `validate rule "self != spec.adminPort" reads spec inside a list or map`; err == nil || err.Error() != want {
+		t.Errorf("Convert() of a list item rule reading spec error = %v, want %q", err, want)
+	}
+}
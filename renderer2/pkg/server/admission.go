@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrOverloaded is returned when a render cannot be queued because the queue is full, or
+	// cannot start because the renders in flight use up the memory ceiling.
+	ErrOverloaded = errors.New("render service overloaded")
+	// ErrTooLarge is returned for a request, or its output, over a size ceiling. Like HTTP 413, it
+	// fails the same way when retried.
+	ErrTooLarge = errors.New("render too large")
+)
+
+// Priority orders queued renders. Higher priorities are admitted first.
+type Priority int
+
+const (
+	// PriorityBatch is for bulk and background renders.
+	PriorityBatch Priority = iota
+	// PriorityWebhook is for latency-sensitive admission webhook validation.
+	PriorityWebhook
+
+	numPriorities
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityBatch:
+		return "batch"
+	case PriorityWebhook:
+		return "webhook"
+	default:
+		return "unknown"
+	}
+}
+
+// Metrics is a point-in-time snapshot of admission state.
+type Metrics struct {
+	InFlight       int
+	Queued         map[Priority]int
+	TenantInFlight map[string]int
+	// MemoryInFlight is the approximate memory of the renders in flight: the encoded size of their
+	// requests, and of their output once rendered.
+	MemoryInFlight int64
+	Admitted       uint64
+	Rejected       uint64
+	Canceled       uint64
+	// TooLarge counts renders rejected with ErrTooLarge.
+	TooLarge uint64
+}
+
+type waiter struct {
+	tenant  string
+	quota   int
+	granted chan struct{}
+}
+
+// admission limits concurrent renders globally and per tenant, queueing the rest by priority.
+type admission struct {
+	mu             sync.Mutex
+	maxConcurrent  int
+	maxQueued      int
+	inFlight       int
+	tenantInFlight map[string]int
+	queues         [numPriorities][]*waiter
+	memoryCeiling  int64
+	memory         int64
+	admitted       uint64
+	rejected       uint64
+	canceled       uint64
+	tooLarge       uint64
+}
+
+func newAdmission(maxConcurrent, maxQueued int) *admission {
+	return &admission{
+		maxConcurrent:  maxConcurrent,
+		maxQueued:      maxQueued,
+		tenantInFlight: map[string]int{},
+	}
+}
+
+// acquire blocks until the render may start or ctx is done. quota is the tenant's in-flight limit;
+// zero means unlimited.
+func (a *admission) acquire(ctx context.Context, tenant string, priority Priority, quota int) error {
+	if priority < 0 || priority >= numPriorities {
+		priority = PriorityBatch
+	}
+
+	a.mu.Lock()
+	if a.queuedLocked() == 0 && a.canRunLocked(tenant, quota) {
+		a.startLocked(tenant)
+		a.mu.Unlock()
+		return nil
+	}
+	if a.maxQueued > 0 && a.queuedLocked() >= a.maxQueued {
+		a.rejected++
+		a.mu.Unlock()
+		return ErrOverloaded
+	}
+	w := &waiter{tenant: tenant, quota: quota, granted: make(chan struct{})}
+	a.queues[priority] = append(a.queues[priority], w)
+	// Other tenants' waiters may be parked on their quota while slots are free.
+	a.dispatchLocked()
+	a.mu.Unlock()
+
+	select {
+	case <-w.granted:
+		return nil
+	case <-ctx.Done():
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		select {
+		case <-w.granted:
+			// Granted concurrently with cancellation; hand the slot on.
+			a.finishLocked(tenant)
+		default:
+			a.removeLocked(priority, w)
+		}
+		a.canceled++
+		return ctx.Err()
+	}
+}
+
+func (a *admission) release(tenant string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.finishLocked(tenant)
+}
+
+func (a *admission) metrics() Metrics {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m := Metrics{
+		InFlight:       a.inFlight,
+		Queued:         make(map[Priority]int, numPriorities),
+		TenantInFlight: make(map[string]int, len(a.tenantInFlight)),
+		MemoryInFlight: a.memory,
+		Admitted:       a.admitted,
+		Rejected:       a.rejected,
+		Canceled:       a.canceled,
+		TooLarge:       a.tooLarge,
+	}
+	for p := range a.queues {
+		m.Queued[Priority(p)] = len(a.queues[p])
+	}
+	for tenant, n := range a.tenantInFlight {
+		m.TenantInFlight[tenant] = n
+	}
+	return m
+}
+
+// reserve accounts bytes of memory to an admitted render. Unless force is set, it fails, and
+// counts a rejection, when that would take the renders in flight over the memory ceiling.
+func (a *admission) reserve(bytes int64, force bool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !force && a.memoryCeiling > 0 && a.memory+bytes > a.memoryCeiling {
+		a.rejected++
+		return false
+	}
+	a.memory += bytes
+	return true
+}
+
+// unreserve returns memory reserve accounted.
+func (a *admission) unreserve(bytes int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.memory -= bytes
+}
+
+// rejectTooLarge counts a render rejected with ErrTooLarge.
+func (a *admission) rejectTooLarge() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tooLarge++
+}
+
+func (a *admission) canRunLocked(tenant string, quota int) bool {
+	if a.maxConcurrent > 0 && a.inFlight >= a.maxConcurrent {
+		return false
+	}
+	return quota <= 0 || a.tenantInFlight[tenant] < quota
+}
+
+func (a *admission) startLocked(tenant string) {
+	a.inFlight++
+	a.tenantInFlight[tenant]++
+	a.admitted++
+}
+
+func (a *admission) finishLocked(tenant string) {
+	a.inFlight--
+	if a.tenantInFlight[tenant]--; a.tenantInFlight[tenant] <= 0 {
+		delete(a.tenantInFlight, tenant)
+	}
+	a.dispatchLocked()
+}
+
+// dispatchLocked admits queued waiters, highest priority first, skipping tenants at their quota so
+// one tenant's backlog does not block others.
+func (a *admission) dispatchLocked() {
+	for p := numPriorities - 1; p >= 0; p-- {
+		queue := a.queues[p]
+		for i := 0; i < len(queue); {
+			if a.maxConcurrent > 0 && a.inFlight >= a.maxConcurrent {
+				a.queues[p] = queue
+				return
+			}
+			w := queue[i]
+			if !a.canRunLocked(w.tenant, w.quota) {
+				i++
+				continue
+			}
+			queue = append(queue[:i], queue[i+1:]...)
+			a.startLocked(w.tenant)
+			close(w.granted)
+		}
+		a.queues[p] = queue
+	}
+}
+
+func (a *admission) removeLocked(priority Priority, w *waiter) {
+	queue := a.queues[priority]
+	for i, candidate := range queue {
+		if candidate == w {
+			a.queues[priority] = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (a *admission) queuedLocked() int {
+	total := 0
+	for p := range a.queues {
+		total += len(a.queues[p])
+	}
+	return total
+}
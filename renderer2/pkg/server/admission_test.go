@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitQueued(t *testing.T, a *admission, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		a.mu.Lock()
+		queued := a.queuedLocked()
+		a.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d queued renders", n)
+}
+
+func TestAdmissionPrefersWebhook(t *testing.T) {
+	t.Parallel()
+
+	a := newAdmission(1, 0)
+	ctx := context.Background()
+	if err := a.acquire(ctx, "acme", PriorityBatch, 0); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	order := make(chan Priority, 2)
+	for _, p := range []Priority{PriorityBatch, PriorityWebhook} {
+		p := p
+		go func() {
+			if err := a.acquire(ctx, "acme", p, 0); err != nil {
+				t.Errorf("acquire(%s) error = %v", p, err)
+				return
+			}
+			order <- p
+			a.release("acme")
+		}()
+		waitQueued(t, a, int(p)+1)
+	}
+
+	a.release("acme")
+	if first := <-order; first != PriorityWebhook {
+		t.Fatalf("first admitted = %s, want webhook", first)
+	}
+	<-order
+}
+
+func TestAdmissionTenantQuota(t *testing.T) {
+	t.Parallel()
+
+	a := newAdmission(0, 0)
+	ctx := context.Background()
+	if err := a.acquire(ctx, "acme", PriorityBatch, 1); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- a.acquire(ctx, "acme", PriorityBatch, 1) }()
+	waitQueued(t, a, 1)
+
+	// Another tenant is not held back by acme's backlog.
+	if err := a.acquire(ctx, "globex", PriorityBatch, 1); err != nil {
+		t.Fatalf("acquire(globex) error = %v", err)
+	}
+
+	a.release("acme")
+	if err := <-blocked; err != nil {
+		t.Fatalf("queued acquire() error = %v", err)
+	}
+	if got := a.metrics().TenantInFlight["acme"]; got != 1 {
+		t.Fatalf("TenantInFlight[acme] = %d, want 1", got)
+	}
+}
+
+func TestAdmissionQueueFullAndCancel(t *testing.T) {
+	t.Parallel()
+
+	a := newAdmission(1, 1)
+	if err := a.acquire(context.Background(), "acme", PriorityBatch, 0); err != nil {
+		t.Fatalf("acquire() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() { canceled <- a.acquire(ctx, "acme", PriorityBatch, 0) }()
+	waitQueued(t, a, 1)
+
+	if err := a.acquire(context.Background(), "acme", PriorityWebhook, 0); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("acquire() error = %v, want ErrOverloaded", err)
+	}
+
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("acquire() error = %v, want context.Canceled", err)
+	}
+
+	m := a.metrics()
+	if m.InFlight != 1 || m.Rejected != 1 || m.Canceled != 1 || m.Queued[PriorityBatch] != 0 {
+		t.Fatalf("metrics = %+v", m)
+	}
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/codec"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// TenantMetadataKey is the gRPC request metadata naming the tenant of a call, read by
+// TenantFromMetadata.
+const TenantMetadataKey = "tenant-id"
+
+// TenantFunc returns the tenant a gRPC call is made on behalf of, e.g. from its credentials.
+type TenantFunc func(ctx context.Context) (string, error)
+
+// TenantFromMetadata reads the tenant from the tenant-id request metadata. It suits deployments
+// whose gateway authenticates callers and sets the metadata; others pass their own TenantFunc.
+func TenantFromMetadata(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(TenantMetadataKey); len(values) > 0 && values[0] != "" {
+		return values[0], nil
+	}
+	return "", fmt.Errorf("missing %s metadata", TenantMetadataKey)
+}
+
+// RenderServiceName is the gRPC service declared in render.proto.
+const RenderServiceName = "renderer.v1.RenderService"
+
+// RenderStreamMethod is the full method name of RenderService.RenderStream, for clients.
+const RenderStreamMethod = "/" + RenderServiceName + "/RenderStream"
+
+// renderService is the gRPC RenderService of a Server. Its messages are google.protobuf.Struct
+// values, so it needs no generated code.
+type renderService interface {
+	renderStream(request *structpb.Struct, stream grpc.ServerStream) error
+}
+
+// RenderServiceDesc describes RenderService for grpc.Server.RegisterService and for clients
+// opening its stream with grpc.ClientConn.NewStream.
+var RenderServiceDesc = grpc.ServiceDesc{
+	ServiceName: RenderServiceName,
+	HandlerType: (*renderService)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "RenderStream",
+		ServerStreams: true,
+		Handler: func(srv any, stream grpc.ServerStream) error {
+			request := new(structpb.Struct)
+			if err := stream.RecvMsg(request); err != nil {
+				return err
+			}
+			return srv.(renderService).renderStream(request, stream)
+		},
+	}},
+	Metadata: "render.proto",
+}
+
+// RegisterGRPC serves s as the RenderService of render.proto on gs. tenant identifies the tenant
+// of each call; nil uses TenantFromMetadata.
+func RegisterGRPC(gs grpc.ServiceRegistrar, s *Server, tenant TenantFunc) {
+	if tenant == nil {
+		tenant = TenantFromMetadata
+	}
+	gs.RegisterService(&RenderServiceDesc, &grpcService{server: s, tenant: tenant})
+}
+
+type grpcService struct {
+	server *Server
+	tenant TenantFunc
+}
+
+// renderStream decodes the requests, runs RenderStream and sends every Update as a Struct.
+func (g *grpcService) renderStream(request *structpb.Struct, stream grpc.ServerStream) error {
+	ctx := stream.Context()
+	tenantID, err := g.tenant(ctx)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	reqs, err := decodeStreamRequests(request)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	err = g.server.RenderStream(ctx, tenantID, reqs, func(update Update) error {
+		message, err := encodeUpdate(update)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		return stream.SendMsg(message)
+	})
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+// decodeStreamRequests reads the requests of a RenderStream call. Components and EnvSettings are
+// decoded like their YAML manifests, the additional context like its JSON file.
+func decodeStreamRequests(request *structpb.Struct) ([]Request, error) {
+	var wire struct {
+		Requests []struct {
+			Component         json.RawMessage `json:"component"`
+			EnvSettings       json.RawMessage `json:"envSettings"`
+			AdditionalContext json.RawMessage `json:"additionalContext"`
+			Workload          map[string]any  `json:"workload"`
+		} `json:"requests"`
+	}
+	data, err := request.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if len(wire.Requests) == 0 {
+		return nil, fmt.Errorf("request has no requests")
+	}
+	reqs := make([]Request, len(wire.Requests))
+	for i, in := range wire.Requests {
+		req := Request{Workload: in.Workload}
+		if in.Component != nil {
+			req.Component = &types.Component{}
+			if err := codec.Kubernetes.Unmarshal(in.Component, req.Component); err != nil {
+				return nil, fmt.Errorf("request %d: invalid component: %w", i, err)
+			}
+		}
+		if in.EnvSettings != nil {
+			req.EnvSettings = &types.EnvSettings{}
+			if err := codec.Kubernetes.Unmarshal(in.EnvSettings, req.EnvSettings); err != nil {
+				return nil, fmt.Errorf("request %d: invalid envSettings: %w", i, err)
+			}
+		}
+		if in.AdditionalContext != nil {
+			req.AdditionalContext = &types.AdditionalContext{}
+			if err := json.Unmarshal(in.AdditionalContext, req.AdditionalContext); err != nil {
+				return nil, fmt.Errorf("request %d: invalid additionalContext: %w", i, err)
+			}
+		}
+		reqs[i] = req
+	}
+	return reqs, nil
+}
+
+// encodeUpdate converts update to the Struct of its JSON encoding.
+func encodeUpdate(update Update) (*structpb.Struct, error) {
+	data, err := json.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+	message := &structpb.Struct{}
+	if err := message.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// dialRenderService serves s over an in-memory listener and returns a client connection to it.
+func dialRenderService(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	RegisterGRPC(gs, s, nil)
+	go gs.Serve(listener)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("grpc.NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// renderStream calls RenderStream with request and collects the updates until the stream ends.
+func renderStream(ctx context.Context, conn *grpc.ClientConn, request map[string]any) ([]map[string]any, error) {
+	message, err := structpb.NewStruct(request)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.NewStream(ctx, &RenderServiceDesc.Streams[0], RenderStreamMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(message); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var updates []map[string]any
+	for {
+		update := &structpb.Struct{}
+		err := stream.RecvMsg(update)
+		if errors.Is(err, io.EOF) {
+			return updates, nil
+		}
+		if err != nil {
+			return updates, err
+		}
+		updates = append(updates, update.AsMap())
+	}
+}
+
+func TestGRPCRenderStream(t *testing.T) {
+	t.Parallel()
+
+	conn := dialRenderService(t, newTestServer(t))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), TenantMetadataKey, "acme")
+	updates, err := renderStream(ctx, conn, map[string]any{
+		"requests": []any{
+			map[string]any{
+				"component": map[string]any{
+					"metadata": map[string]any{"name": "app"},
+					"spec": map[string]any{
+						"componentType": "web",
+						"parameters":    map[string]any{"image": "nginx"},
+						"addons":        []any{map[string]any{"name": "logging", "instanceId": "logs"}},
+					},
+				},
+				"envSettings": map[string]any{
+					"metadata": map[string]any{"name": "app-dev"},
+					"spec":     map[string]any{"overrides": map[string]any{"replicas": 2}},
+				},
+			},
+			map[string]any{
+				"component": map[string]any{
+					"metadata": map[string]any{"name": "job"},
+					"spec":     map[string]any{"componentType": "cron"},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("RenderStream() error = %v", err)
+	}
+
+	var got []string
+	var resources []any
+	for _, update := range updates {
+		got = append(got, update["type"].(string))
+		if update["type"] == string(UpdateRenderFinished) {
+			resources = update["resources"].([]any)
+		}
+	}
+	want := []string{
+		"RenderStarted", "Progress", "Progress", "Progress", "Progress", "Progress", "RenderFinished",
+		"RenderStarted", "RenderFailed",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("update types mismatch (-want +got):\n%s", diff)
+	}
+	wantDeployment := map[string]any{
+		"kind":     "Deployment",
+		"metadata": map[string]any{"name": "app"},
+		"spec":     map[string]any{"replicas": float64(2)},
+	}
+	if len(resources) != 2 || !cmp.Equal(wantDeployment, resources[0]) {
+		t.Errorf("RenderFinished resources = %v, want the Deployment and ConfigMap", resources)
+	}
+	if got := updates[len(updates)-1]["error"]; got != "not found: definition cron for tenant acme" {
+		t.Errorf("RenderFailed error = %v", got)
+	}
+}
+
+func TestGRPCRenderStreamErrors(t *testing.T) {
+	t.Parallel()
+
+	conn := dialRenderService(t, newTestServer(t))
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		request  map[string]any
+		wantCode codes.Code
+	}{
+		{
+			name:     "missing tenant",
+			ctx:      context.Background(),
+			request:  map[string]any{"requests": []any{map[string]any{}}},
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "no requests",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), TenantMetadataKey, "acme"),
+			request:  map[string]any{},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "invalid component",
+			ctx:      metadata.AppendToOutgoingContext(context.Background(), TenantMetadataKey, "acme"),
+			request:  map[string]any{"requests": []any{map[string]any{"component": map[string]any{"spec": "web"}}}},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			updates, err := renderStream(tt.ctx, conn, tt.request)
+			if got := status.Code(err); got != tt.wantCode || len(updates) != 0 {
+				t.Errorf("RenderStream() = %d updates, %v, want code %s", len(updates), err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/registry"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// Request carries the per-call inputs of a render. Definitions and addons are resolved from the
+// caller's tenant scope in the registry, never from the request itself.
+type Request struct {
+	Component         *types.Component
+	EnvSettings       *types.EnvSettings
+	AdditionalContext *types.AdditionalContext
+	Workload          map[string]any
+	// Priority decides queueing order when the server is at capacity. It does not affect output.
+	Priority Priority `json:"-"`
+}
+
+// Server renders Components for multiple tenants against a shared registry. Each tenant has its
+// own render cache, so cached output (which may embed secret references) is never served to
+// another tenant.
+type Server struct {
+	registry  *registry.Registry
+	renderer  *component.Renderer
+	admission *admission
+
+	maxConcurrent  int
+	maxQueued      int
+	tenantQuota    int
+	maxInputBytes  int64
+	maxOutputBytes int64
+	memoryCeiling  int64
+	usage          func(Usage)
+
+	mu     sync.Mutex
+	caches map[string]*renderCache
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMaxConcurrent caps renders running at once across all tenants. Zero means unlimited.
+func WithMaxConcurrent(n int) Option {
+	return func(s *Server) { s.maxConcurrent = n }
+}
+
+// WithMaxQueued caps renders waiting for admission; further requests fail with ErrOverloaded.
+// Zero means unbounded.
+func WithMaxQueued(n int) Option {
+	return func(s *Server) { s.maxQueued = n }
+}
+
+// WithTenantQuota sets the default per-tenant in-flight limit for tenants whose registry limits do
+// not set MaxConcurrentRenders. Zero means unlimited.
+func WithTenantQuota(n int) Option {
+	return func(s *Server) { s.tenantQuota = n }
+}
+
+// WithMaxInputBytes rejects requests whose JSON encoding is over n bytes with ErrTooLarge before
+// they are queued. Zero means unlimited.
+func WithMaxInputBytes(n int64) Option {
+	return func(s *Server) { s.maxInputBytes = n }
+}
+
+// WithMaxOutputBytes fails renders whose resources encode to over n bytes of JSON with
+// ErrTooLarge, so a pathological definition cannot flood callers or the render cache. Zero means
+// unlimited.
+func WithMaxOutputBytes(n int64) Option {
+	return func(s *Server) { s.maxOutputBytes = n }
+}
+
+// WithMemoryCeiling caps the approximate memory of the renders in flight, as reported in
+// Metrics.MemoryInFlight, at n bytes. A render that would take them over the ceiling fails with
+// ErrOverloaded once admitted, and one whose request alone is over it with ErrTooLarge. Zero means
+// unlimited.
+func WithMemoryCeiling(n int64) Option {
+	return func(s *Server) { s.memoryCeiling = n }
+}
+
+// WithUsageFunc reports the resource usage of every render the server completes to fn, e.g. to
+// meter tenants. fn must be safe for concurrent use.
+func WithUsageFunc(fn func(Usage)) Option {
+	return func(s *Server) { s.usage = fn }
+}
+
+// Usage accounts the resources a render used. Sizes are the lengths of JSON encodings, an
+// approximation of the memory the request and its output take.
+type Usage struct {
+	Tenant      string
+	InputBytes  int64
+	OutputBytes int64
+	// Cached is set when the tenant's render cache served the output.
+	Cached bool
+}
+
+// New creates a render server backed by the registry.
+func New(reg *registry.Registry, renderer *component.Renderer, opts ...Option) *Server {
+	s := &Server{
+		registry: reg,
+		renderer: renderer,
+		caches:   map[string]*renderCache{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.admission = newAdmission(s.maxConcurrent, s.maxQueued)
+	s.admission.memoryCeiling = s.memoryCeiling
+	return s
+}
+
+// Metrics returns a snapshot of admission counters and queue depths.
+func (s *Server) Metrics() Metrics {
+	return s.admission.metrics()
+}
+
+// Render renders a Component on behalf of a tenant.
+func (s *Server) Render(ctx context.Context, tenantID string, req Request) ([]map[string]any, error) {
+	resources, _, err := s.render(ctx, tenantID, req, nil)
+	return resources, err
+}
+
+// render renders req, reporting its steps to progress unless it is nil, and reports whether the
+// result came from the tenant's cache.
+func (s *Server) render(ctx context.Context, tenantID string, req Request, progress component.ProgressFunc) ([]map[string]any, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, err
+	}
+	if req.Component == nil {
+		return nil, false, fmt.Errorf("request has no component")
+	}
+
+	scope, err := s.registry.Tenant(tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+	definition, err := scope.DefinitionFor(req.Component)
+	if err != nil {
+		return nil, false, err
+	}
+	addons, err := scope.AddonsFor(req.Component)
+	if err != nil {
+		return nil, false, err
+	}
+
+	tenant := scope.Tenant()
+	cache := s.cacheFor(tenant)
+	key, inputBytes, err := cacheKey(scope.Generation(), req)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.maxInputBytes > 0 && inputBytes > s.maxInputBytes {
+		s.admission.rejectTooLarge()
+		return nil, false, fmt.Errorf("%w: request is %d bytes, over the limit of %d", ErrTooLarge, inputBytes, s.maxInputBytes)
+	}
+	if s.memoryCeiling > 0 && inputBytes > s.memoryCeiling {
+		s.admission.rejectTooLarge()
+		return nil, false, fmt.Errorf("%w: request is %d bytes, over the memory ceiling of %d", ErrTooLarge, inputBytes, s.memoryCeiling)
+	}
+	if cached, ok := cache.get(key); ok {
+		s.report(Usage{Tenant: tenant.ID, InputBytes: inputBytes, Cached: true}, cached)
+		return cached, true, nil
+	}
+
+	quota := s.tenantQuota
+	if tenant.Limits.MaxConcurrentRenders > 0 {
+		quota = tenant.Limits.MaxConcurrentRenders
+	}
+	if err := s.admission.acquire(ctx, tenant.ID, req.Priority, quota); err != nil {
+		return nil, false, err
+	}
+	defer s.admission.release(tenant.ID)
+	if !s.admission.reserve(inputBytes, false) {
+		return nil, false, fmt.Errorf("%w: renders in flight use up the memory ceiling", ErrOverloaded)
+	}
+	reserved := inputBytes
+	defer func() { s.admission.unreserve(reserved) }()
+
+	renderer := s.renderer
+	if progress != nil {
+		renderer = renderer.WithProgress(progress)
+	}
+	resources, err := renderer.RenderAll(definition, req.Component, req.EnvSettings, addons, req.AdditionalContext, req.Workload)
+	if err != nil {
+		return nil, false, err
+	}
+	if s.maxOutputBytes > 0 || s.memoryCeiling > 0 || s.usage != nil {
+		outputBytes, err := encodedSize(resources)
+		if err != nil {
+			return nil, false, err
+		}
+		// The output is held until the render returns, whether or not it is over the limit.
+		s.admission.reserve(outputBytes, true)
+		reserved += outputBytes
+		if s.maxOutputBytes > 0 && outputBytes > s.maxOutputBytes {
+			s.admission.rejectTooLarge()
+			return nil, false, fmt.Errorf("%w: output is %d bytes, over the limit of %d", ErrTooLarge, outputBytes, s.maxOutputBytes)
+		}
+		s.report(Usage{Tenant: tenant.ID, InputBytes: inputBytes, OutputBytes: outputBytes}, nil)
+	}
+	cache.put(key, resources)
+	return resources, false, nil
+}
+
+// report sends usage to the usage function, if any, measuring cached output when it is given.
+func (s *Server) report(usage Usage, cached []map[string]any) {
+	if s.usage == nil {
+		return
+	}
+	if cached != nil {
+		usage.OutputBytes, _ = encodedSize(cached)
+	}
+	s.usage(usage)
+}
+
+func encodedSize(resources []map[string]any) (int64, error) {
+	data, err := json.Marshal(resources)
+	if err != nil {
+		return 0, fmt.Errorf("failed to measure rendered resources: %w", err)
+	}
+	return int64(len(data)), nil
+}
+
+// ForgetTenant drops the tenant's render cache, e.g. after removing it from the registry.
+func (s *Server) ForgetTenant(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.caches, tenantID)
+}
+
+func (s *Server) cacheFor(tenant registry.Tenant) *renderCache {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cache, ok := s.caches[tenant.ID]
+	if !ok {
+		cache = &renderCache{entries: map[string][]map[string]any{}}
+		s.caches[tenant.ID] = cache
+	}
+	cache.setLimit(tenant.Limits.MaxCachedRenders)
+	return cache
+}
+
+// cacheKey returns the key req renders under and the size of its encoding.
+func cacheKey(generation uint64, req Request) (string, int64, error) {
+	payload, err := json.Marshal(struct {
+		Generation uint64
+		Request    Request
+	}{generation, req})
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to hash render request: %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), int64(len(payload)), nil
+}
+
+// renderCache is a bounded FIFO cache of rendered outputs. A limit of zero disables caching.
+type renderCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   []string
+	entries map[string][]map[string]any
+}
+
+func (c *renderCache) setLimit(limit int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.evict()
+}
+
+func (c *renderCache) get(key string) ([]map[string]any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resources, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return copyResources(resources), true
+}
+
+func (c *renderCache) put(key string, resources []map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limit <= 0 {
+		return
+	}
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = copyResources(resources)
+	c.evict()
+}
+
+func (c *renderCache) evict() {
+	for len(c.order) > max(c.limit, 0) {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+func copyResources(resources []map[string]any) []map[string]any {
+	result := make([]map[string]any, len(resources))
+	for i, resource := range resources {
+		result[i] = copyValue(resource).(map[string]any)
+	}
+	return result
+}
+
+func copyValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[k] = copyValue(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = copyValue(v)
+		}
+		return result
+	default:
+		return typed
+	}
+}
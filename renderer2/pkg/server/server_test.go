@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/registry"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// newTestServer serves tenant acme, which registers the web definition and the logging addon.
+func newTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+
+	var definition types.ComponentTypeDefinition
+	var addon types.Addon
+	for doc, out := range map[string]any{
+		`
+metadata: {name: web}
+spec:
+  schema:
+    parameters:
+      image: string
+    envOverrides:
+      replicas: integer | default=1
+  resources:
+    - id: deployment
+      template:
+        kind: Deployment
+        metadata: {name: '${metadata.name}'}
+        spec: {replicas: '${spec.replicas}'}
+`: &definition,
+		`
+metadata: {name: logging}
+spec:
+  creates:
+    - |
+      kind: ConfigMap
+      metadata: {name: fluent-bit, namespace: logging}
+`: &addon,
+	} {
+		if err := yaml.Unmarshal([]byte(doc), out); err != nil {
+			t.Fatal(err)
+		}
+	}
+	reg := registry.New()
+	scope := reg.AddTenant(registry.Tenant{ID: "acme", Limits: registry.Limits{MaxCachedRenders: 4}})
+	if err := scope.RegisterDefinition(&definition); err != nil {
+		t.Fatal(err)
+	}
+	if err := scope.RegisterAddon(&addon); err != nil {
+		t.Fatal(err)
+	}
+	return New(reg, component.NewRenderer(template.NewEngine(), nil), opts...)
+
+}
+
+// testComponent renders a Deployment with web and a ConfigMap with logging.
+func testComponent() *types.Component {
+	return &types.Component{Metadata: types.Metadata{Name: "app"}, Spec: types.ComponentSpec{
+		ComponentType: "web",
+		Parameters:    map[string]any{"image": "nginx"},
+		Addons:        []types.AddonInstance{{Name: "logging", InstanceID: "logs"}},
+	}}
+}
+
+func TestSizeCeilings(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	req := Request{Component: testComponent()}
+
+	var usage []Usage
+	s := newTestServer(t, WithUsageFunc(func(u Usage) { usage = append(usage, u) }))
+	for i := 0; i < 2; i++ {
+		if _, err := s.Render(ctx, "acme", req); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	}
+	if len(usage) != 2 || usage[0].InputBytes == 0 || usage[0].OutputBytes == 0 {
+		t.Fatalf("usage = %+v, want two renders with input and output sizes", usage)
+	}
+	if want := (Usage{Tenant: "acme", InputBytes: usage[0].InputBytes, OutputBytes: usage[0].OutputBytes, Cached: true}); usage[1] != want {
+		t.Errorf("usage of the cached render = %+v, want %+v", usage[1], want)
+	}
+	if m := s.Metrics(); m.MemoryInFlight != 0 {
+		t.Errorf("MemoryInFlight = %d after the renders returned, want 0", m.MemoryInFlight)
+	}
+	input, output := usage[0].InputBytes, usage[0].OutputBytes
+
+	for _, tt := range []struct {
+		name    string
+		opts    []Option
+		inUse   int64
+		wantErr error
+		wantMsg string
+	}{
+		{name: "request over the input limit", opts: []Option{WithMaxInputBytes(input - 1)}, wantErr: ErrTooLarge, wantMsg: "request is"},
+		{name: "request at the input limit", opts: []Option{WithMaxInputBytes(input)}},
+		{name: "output over the output limit", opts: []Option{WithMaxOutputBytes(output - 1)}, wantErr: ErrTooLarge, wantMsg: "output is"},
+		{name: "request over the memory ceiling", opts: []Option{WithMemoryCeiling(input - 1)}, wantErr: ErrTooLarge, wantMsg: "memory ceiling"},
+		{name: "memory ceiling used up by renders in flight", opts: []Option{WithMemoryCeiling(input * 2)}, inUse: input + 1, wantErr: ErrOverloaded},
+		{name: "memory ceiling with room", opts: []Option{WithMemoryCeiling(input * 2)}, inUse: input},
+	} {
+		s := newTestServer(t, tt.opts...)
+		s.admission.reserve(tt.inUse, true)
+		// The second attempt shows failed renders are not cached.
+		for attempt := 0; attempt < 2; attempt++ {
+			_, err := s.Render(ctx, "acme", req)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("%s: Render() error = %v", tt.name, err)
+				}
+				continue
+			}
+			if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("%s: Render() error = %v, want %v mentioning %q", tt.name, err, tt.wantErr, tt.wantMsg)
+			}
+		}
+		m := s.Metrics()
+		if m.MemoryInFlight != tt.inUse {
+			t.Errorf("%s: MemoryInFlight = %d, want %d", tt.name, m.MemoryInFlight, tt.inUse)
+		}
+		if tt.wantErr == ErrTooLarge && m.TooLarge != 2 {
+			t.Errorf("%s: TooLarge = %d, want 2", tt.name, m.TooLarge)
+		}
+	}
+}
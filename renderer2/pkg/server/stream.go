@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"sync"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+)
+
+// UpdateType tells what an Update reports.
+type UpdateType string
+
+const (
+	// UpdateRenderStarted starts the render of a request.
+	UpdateRenderStarted UpdateType = "RenderStarted"
+	// UpdateProgress reports a step of the render: a stage starting, a resource rendered, an addon
+	// applied or a warning.
+	UpdateProgress UpdateType = "Progress"
+	// UpdateRenderFinished ends a render with its resources.
+	UpdateRenderFinished UpdateType = "RenderFinished"
+	// UpdateRenderFailed ends a render with its error.
+	UpdateRenderFailed UpdateType = "RenderFailed"
+)
+
+// Update is a message of a render stream.
+type Update struct {
+	Type UpdateType `json:"type"`
+	// Request is the index of the request the update is about, and Environment the name of its
+	// EnvSettings, if any.
+	Request     int    `json:"request"`
+	Environment string `json:"environment,omitempty"`
+	// Progress is the step an UpdateProgress reports.
+	Progress *component.ProgressEvent `json:"progress,omitempty"`
+	// Resources are the output of an UpdateRenderFinished; Cached is set when the tenant's render
+	// cache served them, in which case no progress was reported.
+	Resources []map[string]any `json:"resources,omitempty"`
+	Cached    bool             `json:"cached,omitempty"`
+	// Error is the error of an UpdateRenderFailed.
+	Error string `json:"error,omitempty"`
+}
+
+// RenderStream renders reqs in order on behalf of a tenant, typically a Component in each of its
+// environments, and sends updates as every render progresses, so UIs can show live progress of a
+// long multi-environment render instead of waiting for a single response. Each render is admitted
+// and cached like Render.
+//
+// A failed render is reported with UpdateRenderFailed and the stream moves on to the next request.
+// RenderStream returns the first error of send, which ends the stream once the current render
+// finishes, or the error of ctx when it is done. send is called from one goroutine at a time.
+// RegisterGRPC serves RenderStream as a gRPC server stream.
+func (s *Server) RenderStream(ctx context.Context, tenantID string, reqs []Request, send func(Update) error) error {
+	for i, req := range reqs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		environment := ""
+		if req.EnvSettings != nil {
+			environment = req.EnvSettings.Metadata.Name
+		}
+		if err := send(Update{Type: UpdateRenderStarted, Request: i, Environment: environment}); err != nil {
+			return err
+		}
+
+		var (
+			mu      sync.Mutex
+			sendErr error
+		)
+		progress := func(event component.ProgressEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			if sendErr == nil {
+				sendErr = send(Update{Type: UpdateProgress, Request: i, Environment: environment, Progress: &event})
+			}
+		}
+		resources, cached, err := s.render(ctx, tenantID, req, progress)
+		if sendErr != nil {
+			return sendErr
+		}
+
+		update := Update{Type: UpdateRenderFinished, Request: i, Environment: environment, Resources: resources, Cached: cached}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			update = Update{Type: UpdateRenderFailed, Request: i, Environment: environment, Error: err.Error()}
+		}
+		if err := send(update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
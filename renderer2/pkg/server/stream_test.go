@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRenderStream(t *testing.T) {
+	t.Parallel()
+
+	s := newTestServer(t)
+
+	app := testComponent()
+	dev := &types.EnvSettings{Metadata: types.Metadata{Name: "app-dev"}, Spec: types.EnvSettingsSpec{
+		Overrides: map[string]any{"replicas": 2, "image": "nginx:dev"},
+	}}
+	reqs := []Request{
+		{Component: app, EnvSettings: dev},
+		{Component: &types.Component{Metadata: types.Metadata{Name: "job"}, Spec: types.ComponentSpec{ComponentType: "cron"}}},
+		{Component: app, EnvSettings: dev},
+	}
+
+	var got []Update
+	err := s.RenderStream(context.Background(), "acme", reqs, func(update Update) error {
+		if update.Type == UpdateRenderFinished && len(update.Resources) != 2 {
+			t.Errorf("request %d finished with %d resources, want 2", update.Request, len(update.Resources))
+		}
+		update.Resources = nil
+		got = append(got, update)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RenderStream() error = %v", err)
+	}
+	progress := func(event component.ProgressEvent) Update {
+		return Update{Type: UpdateProgress, Environment: "app-dev", Progress: &event}
+	}
+	want := []Update{
+		{Type: UpdateRenderStarted, Environment: "app-dev"},
+		progress(component.ProgressEvent{Type: component.ProgressStageStarted, Stage: component.StageBase}),
+		progress(component.ProgressEvent{Type: component.ProgressWarning, Reason: "EnvOverrideRejected",
+			Message: `EnvSettings app-dev overrides "image", which is not declared in envOverrides of web`}),
+		progress(component.ProgressEvent{Type: component.ProgressResourceRendered, Stage: component.StageBase, Resource: "Deployment/app"}),
+		progress(component.ProgressEvent{Type: component.ProgressStageStarted, Stage: "addon logging/logs"}),
+		progress(component.ProgressEvent{Type: component.ProgressResourceRendered, Stage: "addon logging/logs", Resource: "ConfigMap/fluent-bit"}),
+		progress(component.ProgressEvent{Type: component.ProgressAddonApplied, Stage: "addon logging/logs"}),
+		{Type: UpdateRenderFinished, Environment: "app-dev"},
+		{Type: UpdateRenderStarted, Request: 1},
+		{Type: UpdateRenderFailed, Request: 1, Error: "not found: definition cron for tenant acme"},
+		{Type: UpdateRenderStarted, Request: 2, Environment: "app-dev"},
+		{Type: UpdateRenderFinished, Request: 2, Environment: "app-dev", Cached: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("updates mismatch (-want +got):\n%s", diff)
+	}
+
+	sendErr := errors.New("client went away")
+	sent := 0
+	err = s.RenderStream(context.Background(), "acme", reqs, func(Update) error {
+		sent++
+		return sendErr
+	})
+	if !errors.Is(err, sendErr) || sent != 1 {
+		t.Errorf("RenderStream() with a failing send error = %v after %d sends, want %v after 1", err, sent, sendErr)
+	}
+}
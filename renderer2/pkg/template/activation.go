@@ -0,0 +1,26 @@
+package template
+
+import "sync"
+
+var activationPool = sync.Pool{
+	New: func() any {
+		return make(map[string]any, 16)
+	},
+}
+
+// AcquireActivation returns a pooled shallow copy of inputs for per-item evaluation (forEach loops),
+// avoiding a fresh map allocation per iteration. The map must be handed back via ReleaseActivation
+// once rendering with it has finished and must not be retained afterwards.
+func AcquireActivation(inputs map[string]any) map[string]any {
+	activation := activationPool.Get().(map[string]any)
+	for key, value := range inputs {
+		activation[key] = value
+	}
+	return activation
+}
+
+// ReleaseActivation clears an activation obtained from AcquireActivation and returns it to the pool.
+func ReleaseActivation(activation map[string]any) {
+	clear(activation)
+	activationPool.Put(activation)
+}
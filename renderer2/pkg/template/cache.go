@@ -0,0 +1,70 @@
+package template
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is the number of compiled programs and input environments an Engine keeps
+// unless WithCacheSize sets another size.
+const DefaultCacheSize = 1024
+
+// derivedEngineCacheSize is the number of engines derived through Typed or Fragments an Engine
+// keeps. Each derived engine has caches of its own, so it is kept well below DefaultCacheSize.
+const derivedEngineCacheSize = 64
+
+// lru is a size-limited cache that evicts the least recently used entry. It is safe for
+// concurrent use. The zero value must have its limit set before use.
+type lru[V any] struct {
+	mu      sync.Mutex
+	limit   int
+	order   list.List
+	entries map[string]*list.Element
+}
+
+type lruEntry[V any] struct {
+	key   string
+	value V
+}
+
+// get returns the value cached under key and marks it as recently used.
+func (c *lru[V]) get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[V]).value, true
+}
+
+// add caches value under key unless a value is cached already, evicting the least recently used
+// entries over the limit, and returns the cached value. Concurrent misses for the same key thus
+// settle on one value.
+func (c *lru[V]) add(key string, value V) V {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return element.Value.(*lruEntry[V]).value
+	}
+	if c.entries == nil {
+		c.entries = map[string]*list.Element{}
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[V]{key: key, value: value})
+	for c.order.Len() > c.limit {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[V]).key)
+	}
+	return value
+}
+
+// len reports the number of cached entries.
+func (c *lru[V]) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,30 @@
+package template
+
+import "testing"
+
+func TestLRUEvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	cache := &lru[int]{limit: 2}
+	cache.add("a", 1)
+	cache.add("b", 2)
+	if _, ok := cache.get("a"); !ok {
+		t.Fatalf("get(a) missed before eviction")
+	}
+	cache.add("c", 3)
+
+	if _, ok := cache.get("b"); ok {
+		t.Errorf("get(b) hit, want the least recently used entry evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if got, ok := cache.get(key); !ok || got != want {
+			t.Errorf("get(%s) = %d, %t, want %d", key, got, ok, want)
+		}
+	}
+	if got := cache.add("a", 10); got != 1 {
+		t.Errorf("add(a) of a cached key = %d, want the cached 1", got)
+	}
+	if got := cache.len(); got != 2 {
+		t.Errorf("len() = %d, want 2", got)
+	}
+}
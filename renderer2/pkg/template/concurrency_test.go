@@ -0,0 +1,81 @@
+package template
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// TestEngineConcurrentRender shares one Engine between goroutines the way a webhook server does.
+// Run with -race.
+func TestEngineConcurrentRender(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(
+		WithVariable("cluster", "prod-1"),
+		WithContextValue("region", func(inputs map[string]any) (any, error) {
+			return fmt.Sprintf("%v-region", inputs["env"]), nil
+		}),
+	)
+	data := map[string]any{
+		"name":    "${metadata.name}-${cluster}",
+		"region":  "${region}",
+		"doubled": "${spec.replicas * 2}",
+		"items":   `${spec.ports.map(p, p + 1)}`,
+	}
+
+	const workers = 16
+	const iterations = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			inputs := map[string]any{
+				"env":      fmt.Sprintf("env%d", w),
+				"metadata": map[string]any{"name": fmt.Sprintf("app%d", w)},
+				"spec":     map[string]any{"replicas": w, "ports": []any{w, w + 1}},
+			}
+			want := map[string]any{
+				"name":    fmt.Sprintf("app%d-prod-1", w),
+				"region":  fmt.Sprintf("env%d-region", w),
+				"doubled": fmt.Sprint(w * 2),
+				"items":   fmt.Sprint([]any{w + 1, w + 2}),
+			}
+			for i := 0; i < iterations; i++ {
+				got, err := engine.Render(data, inputs)
+				if err != nil {
+					errs <- err
+					return
+				}
+				result := got.(map[string]any)
+				normalized := map[string]any{
+					"name":    result["name"],
+					"region":  result["region"],
+					"doubled": fmt.Sprint(result["doubled"]),
+					"items":   fmt.Sprint(result["items"]),
+				}
+				if diff := cmp.Diff(want, normalized); diff != "" {
+					errs <- fmt.Errorf("worker %d render mismatch (-want +got):\n%s", w, diff)
+					return
+				}
+			}
+			if len(inputs) != 3 {
+				errs <- fmt.Errorf("worker %d inputs were modified: %v", w, inputs)
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	// Goroutines racing on a cold cache may each compile, but only one program per expression is kept.
+	if programs := engine.programs.len(); programs != 5 {
+		t.Errorf("cached programs = %d, want 5 (one per expression)", programs)
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/chathurangada/cel_playground/renderer2/pkg/presets"
 	"github.com/google/cel-go/cel"
 	"github.com/google/cel-go/common"
 	"github.com/google/cel-go/common/ast"
@@ -23,15 +28,78 @@ var omitSentinel = &omitValue{}
 const omitErrMsg = "__OC_RENDERER_OMIT__"
 
 // Engine evaluates CEL backed templates that can contain inline expressions, map keys, and nested structures.
-type Engine struct{}
+// An Engine is safe for concurrent use once constructed and is meant to be shared, e.g. as a package-level
+// singleton in a webhook server: Render never modifies its inputs and the environment and program caches are
+// synchronized. ContextValueFuncs and functions registered through options must be safe for concurrent use too.
+type Engine struct {
+	variables     map[string]any
+	contextValues map[string]ContextValueFunc
+	functions     []cel.EnvOption
+	functionNames []string
+
+	envOnce sync.Once
+	baseEnv *cel.Env
+	envErr  error
+
+	// envs caches the base environment extended with one set of input variables, keyed by
+	// variableKey; programs caches compiled programs keyed by variable set and expression text.
+	// Both keep the cacheSize most recently used entries, so a long-running service rendering
+	// many definitions does not hold every expression it has ever compiled.
+	cacheSize int
+	envs      lru[*cel.Env]
+	programs  lru[cel.Program]
+	compiles  atomic.Int64
+
+	// typed caches the engines derived through Typed, keyed by their encoded schemas. Typed
+	// engines point at their parent and declare the schema-typed inputs.
+	typed    lru[*Engine]
+	parent   *Engine
+	provider *schemaProvider
+	declared map[string]*types.Type
+
+	// fragmentEngines caches the engines derived through Fragments, keyed by their encoded
+	// fragments. Derived engines extend the environment of their parent with extensions.
+	fragmentEngines lru[*Engine]
+	extensions      []cel.EnvOption
+}
 
 // NewEngine creates a new CEL template engine.
-func NewEngine() *Engine {
-	return &Engine{}
+func NewEngine(opts ...EngineOption) *Engine {
+	e := &Engine{cacheSize: DefaultCacheSize}
+	for _, opt := range opts {
+		opt(e)
+	}
+	e.initCaches(e.cacheSize)
+	return e
+}
+
+// initCaches sets the limits of the engine's caches. Derived engines inherit the size of the
+// engine they are derived from.
+func (e *Engine) initCaches(size int) {
+	e.cacheSize = size
+	e.envs.limit = size
+	e.programs.limit = size
+	e.typed.limit = derivedEngineCacheSize
+	e.fragmentEngines.limit = derivedEngineCacheSize
 }
 
 // Render walks the provided structure and evaluates CEL expressions against the supplied inputs.
+// Variables and context values registered through EngineOptions are added to the inputs; keys
+// present in inputs take precedence.
 func (e *Engine) Render(data any, inputs map[string]any) (any, error) {
+	if len(e.variables) == 0 && len(e.contextValues) == 0 {
+		return e.render(data, inputs)
+	}
+
+	activation, err := e.activation(inputs)
+	if err != nil {
+		return nil, err
+	}
+	defer ReleaseActivation(activation)
+	return e.render(data, activation)
+}
+
+func (e *Engine) render(data any, inputs map[string]any) (any, error) {
 	switch v := data.(type) {
 	case string:
 		return e.renderString(v, inputs)
@@ -45,7 +113,7 @@ func (e *Engine) Render(data any, inputs map[string]any) (any, error) {
 				}
 			}
 
-			renderedValue, err := e.Render(value, inputs)
+			renderedValue, err := e.render(value, inputs)
 			if err != nil {
 				return nil, err
 			}
@@ -61,7 +129,7 @@ func (e *Engine) Render(data any, inputs map[string]any) (any, error) {
 	case []any:
 		result := make([]any, len(v))
 		for i, item := range v {
-			rendered, err := e.Render(item, inputs)
+			rendered, err := e.render(item, inputs)
 			if err != nil {
 				return nil, err
 			}
@@ -81,42 +149,43 @@ func (e *Engine) renderString(str string, inputs map[string]any) (any, error) {
 
 	trimmed := strings.TrimSpace(str)
 	if len(expressions) == 1 && expressions[0].fullExpr == trimmed {
-		result, err := evaluateCEL(expressions[0].innerExpr, inputs)
+		result, err := e.evaluate(expressions[0].innerExpr, inputs)
 		return normalizeCELResult(result, err)
 	}
 
 	rendered := str
 	for _, match := range expressions {
-		value, err := evaluateCEL(match.innerExpr, inputs)
+		value, err := e.evaluate(match.innerExpr, inputs)
 		if err != nil {
 			return nil, err
 		}
 
-		var replacement string
-		switch typed := value.(type) {
-		case string:
-			replacement = typed
-		case int64:
-			replacement = fmt.Sprintf("%d", typed)
-		case float64:
-			replacement = fmt.Sprintf("%g", typed)
-		case bool:
-			replacement = fmt.Sprintf("%t", typed)
-		default:
-			bytes, err := json.Marshal(typed)
-			if err != nil {
-				replacement = fmt.Sprintf("%v", typed)
-			} else {
-				replacement = string(bytes)
-			}
-		}
-
-		rendered = strings.Replace(rendered, match.fullExpr, replacement, 1)
+		rendered = strings.Replace(rendered, match.fullExpr, formatInterpolated(value), 1)
 	}
 
 	return rendered, nil
 }
 
+// formatInterpolated converts an expression result into the text spliced into a larger string.
+func formatInterpolated(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case int64:
+		return fmt.Sprintf("%d", typed)
+	case float64:
+		return fmt.Sprintf("%g", typed)
+	case bool:
+		return fmt.Sprintf("%t", typed)
+	default:
+		bytes, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprintf("%v", typed)
+		}
+		return string(bytes)
+	}
+}
+
 type celMatch struct {
 	fullExpr  string
 	innerExpr string
@@ -169,20 +238,10 @@ func normalizeCELResult(result any, err error) (any, error) {
 	return result, nil
 }
 
-func evaluateCEL(expression string, inputs map[string]any) (any, error) {
-	env, err := buildEnv(inputs)
-	if err != nil {
-		return nil, fmt.Errorf("failed to build CEL environment: %w", err)
-	}
-
-	ast, issues := env.Compile(expression)
-	if issues != nil && issues.Err() != nil {
-		return nil, fmt.Errorf("CEL compilation error: %v", issues.Err())
-	}
-
-	program, err := env.Program(ast)
+func (e *Engine) evaluate(expression string, inputs map[string]any) (any, error) {
+	program, err := e.program(expression, inputs)
 	if err != nil {
-		return nil, fmt.Errorf("CEL program creation error: %w", err)
+		return nil, err
 	}
 
 	result, _, err := program.Eval(inputs)
@@ -246,13 +305,78 @@ var sanitizeK8sResourceNameMacro = cel.GlobalVarArgMacro("sanitizeK8sResourceNam
 		}
 	})
 
-func buildEnv(inputs map[string]any) (*cel.Env, error) {
-	envOptions := []cel.EnvOption{
-		cel.OptionalTypes(),
+// program returns the compiled program for expression, compiling it on first use. Programs only
+// depend on the expression and the names of the input variables, so repeated renders (every
+// environment, stage and forEach item) reuse them. Failed compilations are not cached.
+func (e *Engine) program(expression string, inputs map[string]any) (cel.Program, error) {
+	vars := variableKey(inputs)
+	key := vars + "\x00" + expression
+	if cached, ok := e.programs.get(key); ok {
+		return cached, nil
+	}
+
+	env, err := e.inputEnv(vars, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("CEL compilation error: %v", issues.Err())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("CEL program creation error: %w", err)
+	}
+	e.compiles.Add(1)
+
+	return e.programs.add(key, program), nil
+}
+
+func (e *Engine) inputEnv(vars string, inputs map[string]any) (*cel.Env, error) {
+	if cached, ok := e.envs.get(vars); ok {
+		return cached, nil
+	}
+	env, err := e.buildEnv(inputs)
+	if err != nil {
+		return nil, err
+	}
+	return e.envs.add(vars, env), nil
+}
+
+// variableKey identifies the set of input names an environment declares.
+func variableKey(inputs map[string]any) string {
+	names := make([]string, 0, len(inputs))
+	for name := range inputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// buildEnv derives an environment declaring every input key as a variable from the engine's base
+// environment, so extension libraries and custom functions are registered only once. Inputs are
+// dyn unless the engine was derived through Typed.
+func (e *Engine) buildEnv(inputs map[string]any) (*cel.Env, error) {
+	baseEnv, err := e.environment()
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) == 0 {
+		return baseEnv, nil
 	}
 
+	variables := make([]cel.EnvOption, 0, len(inputs))
 	for key := range inputs {
-		envOptions = append(envOptions, cel.Variable(key, cel.DynType))
+		variables = append(variables, cel.Variable(key, e.variableType(key)))
+	}
+	return baseEnv.Extend(variables...)
+}
+
+func baseEnvOptions() []cel.EnvOption {
+	envOptions := []cel.EnvOption{
+		cel.OptionalTypes(),
 	}
 
 	envOptions = append(envOptions,
@@ -262,7 +386,10 @@ func buildEnv(inputs map[string]any) (*cel.Env, error) {
 		ext.Lists(),
 		ext.Sets(),
 		ext.TwoVarComprehensions(),
-		cel.Macros(sanitizeK8sResourceNameMacro),
+		cel.Macros(sanitizeK8sResourceNameMacro, standardLabelsMacro),
+		standardLabelsFunction,
+		hashFunction,
+		presets.Library(),
 		cel.Function("omit",
 			cel.Overload("omit", []*cel.Type{}, cel.DynType,
 				cel.FunctionBinding(func(values ...ref.Val) ref.Val {
@@ -325,8 +452,10 @@ func buildEnv(inputs map[string]any) (*cel.Env, error) {
 			),
 		),
 	)
+	envOptions = append(envOptions, yamlFunctions...)
+	envOptions = append(envOptions, quantityFunctions...)
 
-	return cel.NewEnv(envOptions...)
+	return envOptions
 }
 
 func convertCELValue(val ref.Val) any {
@@ -398,6 +527,11 @@ func convertCELValue(val ref.Val) any {
 		default:
 			return val.Value()
 		}
+	case quantityType:
+		q := val.(Quantity)
+		return q.String()
+	case types.DurationType:
+		return val.Value().(time.Duration).String()
 	default:
 		switch typed := val.Value().(type) {
 		case ref.Val:
@@ -143,6 +143,43 @@ name: ${sanitizeK8sResourceName(metadata.name, "-", spec.version)}
   "spec": {"version": "v2.0"}
 }`,
 			want: `name: paymentservicev20
+`,
+		},
+		{
+			name: "standardLabels from context",
+			template: `
+labels: ${standardLabels()}
+`,
+			inputs: `{
+  "metadata": {"name": "checkout", "namespace": "shop", "labels": {"app.kubernetes.io/part-of": "storefront"}},
+  "build": {"image": "registry.example.com:5000/shop/checkout:v1.4.2"},
+  "componentType": "deployment-component"
+}`,
+			want: `labels:
+  app.kubernetes.io/component: deployment-component
+  app.kubernetes.io/instance: checkout-shop
+  app.kubernetes.io/managed-by: openchoreo
+  app.kubernetes.io/name: checkout
+  app.kubernetes.io/part-of: storefront
+  app.kubernetes.io/version: v1.4.2
+`,
+		},
+		{
+			name: "standardLabels merged with custom labels",
+			template: `
+labels: '${merge(standardLabels(), {"tier": "web"})}'
+`,
+			inputs: `{
+  "metadata": {"name": "checkout", "namespace": ""},
+  "build": {"image": "checkout@sha256:abc"},
+  "componentType": "deployment-component"
+}`,
+			want: `labels:
+  app.kubernetes.io/component: deployment-component
+  app.kubernetes.io/instance: checkout
+  app.kubernetes.io/managed-by: openchoreo
+  app.kubernetes.io/name: checkout
+  tier: web
 `,
 		},
 	}
@@ -200,3 +237,96 @@ func compareYAML(expected, actual string) error {
 	}
 	return nil
 }
+
+func BenchmarkEngineRender(b *testing.B) {
+	engine := NewEngine()
+	tmpl := map[string]any{
+		"metadata": map[string]any{
+			"name":      "${metadata.name}-${item.name}",
+			"namespace": "${metadata.namespace}",
+		},
+		"spec": map[string]any{
+			"replicas": "${spec.replicas}",
+			"image":    "${build.image}",
+			"port":     "${item.port}",
+		},
+	}
+	inputs := map[string]any{
+		"metadata": map[string]any{"name": "checkout", "namespace": "shop"},
+		"spec":     map[string]any{"replicas": int64(3)},
+		"build":    map[string]any{"image": "registry/checkout:v1"},
+	}
+	item := map[string]any{"name": "http", "port": int64(8080)}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		activation := AcquireActivation(inputs)
+		activation["item"] = item
+		if _, err := engine.Render(tmpl, activation); err != nil {
+			b.Fatal(err)
+		}
+		ReleaseActivation(activation)
+	}
+}
+
+func TestEngineProgramCache(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	data := map[string]any{
+		"name":     "${metadata.name}-svc",
+		"replicas": "${spec.replicas * 2}",
+	}
+	inputs := map[string]any{
+		"metadata": map[string]any{"name": "web"},
+		"spec":     map[string]any{"replicas": 2},
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := engine.Render(data, inputs); err != nil {
+			t.Fatalf("Render() error = %v", err)
+		}
+	}
+	if got := engine.compiles.Load(); got != 2 {
+		t.Errorf("compiles after repeated renders = %d, want 2", got)
+	}
+
+	inputs["item"] = map[string]any{}
+	if _, err := engine.Render(data, inputs); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got := engine.compiles.Load(); got != 4 {
+		t.Errorf("compiles after adding a variable = %d, want 4", got)
+	}
+}
+
+func TestEngineProgramCacheLimit(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(WithCacheSize(2))
+	inputs := map[string]any{"spec": map[string]any{"replicas": 2}}
+	for _, expression := range []string{"${spec.replicas}", "${spec.replicas + 1}", "${spec.replicas + 2}"} {
+		if _, err := engine.Render(expression, inputs); err != nil {
+			t.Fatalf("Render(%s) error = %v", expression, err)
+		}
+	}
+	if got := engine.programs.len(); got != 2 {
+		t.Errorf("cached programs = %d, want the limit of 2", got)
+	}
+
+	if _, err := engine.Render("${spec.replicas}", inputs); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got := engine.compiles.Load(); got != 4 {
+		t.Errorf("compiles after rendering an evicted expression = %d, want 4", got)
+	}
+
+	typed, err := engine.Typed(map[string]*Schema{"spec": {Properties: map[string]*Schema{"replicas": {}}}})
+	if err != nil {
+		t.Fatalf("Typed() error = %v", err)
+	}
+	if typed.programs.limit != 2 {
+		t.Errorf("typed engine cache limit = %d, want the parent's 2", typed.programs.limit)
+	}
+}
@@ -0,0 +1,90 @@
+package template
+
+import (
+	"strings"
+)
+
+// FoldConstants pre-evaluates expressions that reference no variables (e.g. `${1024 * 1024}` or
+// `${["a", "b"].join(",")}`) and replaces them with their values, so renders skip them entirely.
+// Expressions that reference inputs, fail to evaluate, or call omit() are left untouched and
+// handled at render time as usual.
+func FoldConstants(data any) (any, error) {
+	switch v := data.(type) {
+	case string:
+		return foldString(v), nil
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, value := range v {
+			foldedKey := key
+			if keyValue, ok := foldString(key).(string); ok {
+				foldedKey = keyValue
+			}
+			folded, err := FoldConstants(value)
+			if err != nil {
+				return nil, err
+			}
+			result[foldedKey] = folded
+		}
+		return result, nil
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			folded, err := FoldConstants(item)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = folded
+		}
+		return result, nil
+	default:
+		return v, nil
+	}
+}
+
+func foldString(str string) any {
+	expressions := findCELExpressions(str)
+	if len(expressions) == 0 {
+		return str
+	}
+
+	trimmed := strings.TrimSpace(str)
+	if len(expressions) == 1 && expressions[0].fullExpr == trimmed {
+		value, ok := evaluateConstant(expressions[0].innerExpr)
+		if !ok {
+			return str
+		}
+		return value
+	}
+
+	folded := str
+	for _, match := range expressions {
+		value, ok := evaluateConstant(match.innerExpr)
+		if !ok {
+			continue
+		}
+		replacement := formatInterpolated(value)
+		if strings.Contains(replacement, "${") {
+			continue
+		}
+		folded = strings.Replace(folded, match.fullExpr, replacement, 1)
+	}
+	return folded
+}
+
+// constantEngine has no registered variables or functions, so expressions that depend on engine
+// options fail to compile and are left for render time.
+var constantEngine = NewEngine()
+
+// evaluateConstant evaluates an expression in an environment without variables. Compilation fails
+// for any expression that references an input, which is exactly the set that must not be folded.
+func evaluateConstant(expression string) (any, bool) {
+	value, err := constantEngine.evaluate(expression, nil)
+	if err != nil || value == omitSentinel {
+		return nil, false
+	}
+	if str, ok := value.(string); ok && strings.Contains(str, "${") {
+		// Folding would turn literal text into a new expression on the next render.
+		return nil, false
+	}
+	return value, true
+}
@@ -0,0 +1,43 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFoldConstants(t *testing.T) {
+	t.Parallel()
+
+	input := map[string]any{
+		"bytes":      "${1024 * 1024}",
+		"joined":     `${["a", "b"].join(",")}`,
+		"mixed":      "${metadata.name}-${2 + 3}",
+		"dynamic":    "${spec.replicas}",
+		"optional":   "${omit()}",
+		"failing":    "${1 / 0}",
+		"literal":    "plain",
+		"${\"key\"}": "value",
+		"nested":     []any{map[string]any{"size": "${10 * 2}Gi"}},
+	}
+
+	got, err := FoldConstants(input)
+	if err != nil {
+		t.Fatalf("FoldConstants() error = %v", err)
+	}
+
+	want := map[string]any{
+		"bytes":    int64(1048576),
+		"joined":   "a,b",
+		"mixed":    "${metadata.name}-5",
+		"dynamic":  "${spec.replicas}",
+		"optional": "${omit()}",
+		"failing":  "${1 / 0}",
+		"literal":  "plain",
+		"key":      "value",
+		"nested":   []any{map[string]any{"size": "20Gi"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("folded template mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/parser"
+)
+
+var (
+	formatEnvOnce sync.Once
+	formatEnv     *cel.Env
+	formatEnvErr  error
+)
+
+// FormatString rewrites every ${...} expression in str to the canonical CEL spelling: single spaces
+// around operators, double-quoted strings and no redundant parentheses. A string that is one
+// expression wraps after && and || once a line passes width columns; expressions interpolated into
+// a larger string are never wrapped. Macros are kept as written.
+func FormatString(str string, width int) (string, error) {
+	matches := findCELExpressions(str)
+	if len(matches) == 0 {
+		return str, nil
+	}
+
+	standalone := len(matches) == 1 && matches[0].fullExpr == strings.TrimSpace(str)
+	if !standalone || width <= 0 {
+		width = math.MaxInt32
+	}
+
+	formatted := str
+	for _, match := range matches {
+		expression, err := FormatExpression(match.innerExpr, width)
+		if err != nil {
+			return "", err
+		}
+		formatted = strings.Replace(formatted, match.fullExpr, "${"+expression+"}", 1)
+	}
+	if standalone {
+		formatted = strings.TrimSpace(formatted)
+	}
+	return formatted, nil
+}
+
+// FormatExpression parses a single CEL expression and prints it back in canonical form, wrapping
+// after && and || at width columns.
+func FormatExpression(expression string, width int) (string, error) {
+	formatEnvOnce.Do(func() {
+		formatEnv, formatEnvErr = cel.NewEnv(append(baseEnvOptions(), cel.EnableMacroCallTracking())...)
+	})
+	if formatEnvErr != nil {
+		return "", formatEnvErr
+	}
+
+	ast, issues := formatEnv.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return "", fmt.Errorf("failed to parse expression %q: %w", expression, issues.Err())
+	}
+	native := ast.NativeRep()
+	formatted, err := parser.Unparse(native.Expr(), native.SourceInfo(), parser.WrapOnColumn(width))
+	if err != nil {
+		return "", fmt.Errorf("failed to format expression %q: %w", expression, err)
+	}
+	return formatted, nil
+}
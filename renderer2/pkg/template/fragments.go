@@ -0,0 +1,77 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// fragmentFunctionName is declared by engines derived through Fragments. Registries reserve it so
+// a custom function cannot collide with it.
+const fragmentFunctionName = "fragment"
+
+// Fragments returns an engine that shares the options, environment and schemas of e and also
+// defines fragment(name) and fragment(name, args). The function renders the named fragment, a
+// template snippet such as a container securityContext, with args bound to the variable args and
+// returns the result. Fragment bodies are rendered by e, so they see args and the engine's
+// registered variables but not the inputs of the calling expression, and cannot call fragment
+// themselves. Engines are cached by fragments, so deriving one per render is cheap.
+func (e *Engine) Fragments(fragments map[string]any) (*Engine, error) {
+	if len(fragments) == 0 {
+		return e, nil
+	}
+
+	key, err := json.Marshal(fragments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fragments: %w", err)
+	}
+	if cached, ok := e.fragmentEngines.get(string(key)); ok {
+		return cached, nil
+	}
+
+	child := &Engine{
+		variables:     e.variables,
+		contextValues: e.contextValues,
+		functions:     e.functions,
+		functionNames: e.functionNames,
+		parent:        e,
+		declared:      e.declared,
+		extensions:    []cel.EnvOption{fragmentFunction(e, fragments)},
+	}
+	child.initCaches(e.cacheSize)
+	return e.fragmentEngines.add(string(key), child), nil
+}
+
+func fragmentFunction(renderer *Engine, fragments map[string]any) cel.EnvOption {
+	render := func(nameVal ref.Val, args any) ref.Val {
+		name := string(nameVal.(types.String))
+		body, ok := fragments[name]
+		if !ok {
+			return types.NewErr("fragment %s is not defined", name)
+		}
+		rendered, err := renderer.Render(body, map[string]any{"args": args})
+		if err != nil {
+			return types.NewErr("fragment %s: %v", name, err)
+		}
+		if rendered == omitSentinel {
+			// A fragment rendering to omit() omits the field calling it.
+			return types.NewErr(omitErrMsg)
+		}
+		return types.DefaultTypeAdapter.NativeToValue(RemoveOmittedFields(rendered))
+	}
+	return cel.Function(fragmentFunctionName,
+		cel.Overload("fragment_string", []*cel.Type{cel.StringType}, cel.DynType,
+			cel.UnaryBinding(func(name ref.Val) ref.Val {
+				return render(name, map[string]any{})
+			}),
+		),
+		cel.Overload("fragment_string_dyn", []*cel.Type{cel.StringType, cel.DynType}, cel.DynType,
+			cel.BinaryBinding(func(name, args ref.Val) ref.Val {
+				return render(name, convertCELValue(args))
+			}),
+		),
+	)
+}
@@ -0,0 +1,130 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFragments(t *testing.T) {
+	t.Parallel()
+
+	fragments := map[string]any{
+		"securityContext": map[string]any{
+			"runAsNonRoot": true,
+			"runAsUser":    "${args.user}",
+			"capabilities": map[string]any{"drop": []any{"ALL"}},
+		},
+		"probe": map[string]any{
+			"httpGet":       map[string]any{"path": "/healthz", "port": "${args.port}"},
+			"periodSeconds": "${has(args.period) ? args.period : omit()}",
+		},
+		"restricted": "${true}",
+		"nested":     `${fragment("restricted")}`,
+		"hidden":     "${omit()}",
+	}
+	engine, err := NewEngine().Fragments(fragments)
+	if err != nil {
+		t.Fatalf("Fragments() error = %v", err)
+	}
+	if again, _ := NewEngine().Fragments(nil); again == nil {
+		t.Fatal("Fragments(nil) returned nil")
+	}
+
+	tests := []struct {
+		name     string
+		template any
+		want     any
+		wantErr  string
+	}{
+		{
+			name:     "fragment with args",
+			template: map[string]any{"securityContext": `${fragment("securityContext", {"user": spec.user})}`},
+			want: map[string]any{"securityContext": map[string]any{
+				"runAsNonRoot": true,
+				"runAsUser":    int64(1000),
+				"capabilities": map[string]any{"drop": []any{"ALL"}},
+			}},
+		},
+		{
+			name:     "omitted fields inside a fragment",
+			template: `${fragment("probe", {"port": 8080})}`,
+			want:     map[string]any{"httpGet": map[string]any{"path": "/healthz", "port": int64(8080)}},
+		},
+		{
+			name:     "fragment without args",
+			template: `${fragment("restricted") && spec.user > 0}`,
+			want:     true,
+		},
+		{
+			name:     "fragment rendering to omit()",
+			template: map[string]any{"kept": "yes", "dropped": `${fragment("hidden")}`},
+			want:     map[string]any{"kept": "yes"},
+		},
+		{
+			name:     "undefined fragment",
+			template: `${fragment("sidecar")}`,
+			wantErr:  "fragment sidecar is not defined",
+		},
+		{
+			name:     "fragments cannot call fragment",
+			template: `${fragment("nested")}`,
+			wantErr:  "undeclared reference to 'fragment'",
+		},
+		{
+			name:     "fragments do not see the caller's inputs",
+			template: `${fragment("securityContext")}`,
+			wantErr:  "no such key: user",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := engine.Render(tt.template, map[string]any{"spec": map[string]any{"user": int64(1000)}})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, RemoveOmittedFields(got)); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFragmentsOnTypedEngines(t *testing.T) {
+	t.Parallel()
+
+	root := NewEngine()
+	typed, err := root.Typed(map[string]*Schema{"spec": {Properties: map[string]*Schema{"replicas": {}}}})
+	if err != nil {
+		t.Fatalf("Typed() error = %v", err)
+	}
+	engine, err := typed.Fragments(map[string]any{"replicas": "${args.count}"})
+	if err != nil {
+		t.Fatalf("Fragments() error = %v", err)
+	}
+	if cached, _ := typed.Fragments(map[string]any{"replicas": "${args.count}"}); cached != engine {
+		t.Error("Fragments() did not reuse the engine derived for the same fragments")
+	}
+
+	got, err := engine.Render(`${fragment("replicas", {"count": spec.replicas})}`, map[string]any{"spec": map[string]any{"replicas": int64(3)}})
+	if err != nil || got != int64(3) {
+		t.Errorf("Render() = %v, %v, want 3", got, err)
+	}
+	if _, err := engine.Render(`${fragment("replicas", {"count": spec.replcas})}`, map[string]any{"spec": map[string]any{"replicas": int64(3)}}); err == nil || !strings.Contains(err.Error(), "undefined field 'replcas'") {
+		t.Errorf("Render() of a misspelled field error = %v, want an undefined field", err)
+	}
+	if retyped, _ := engine.Typed(nil); retyped != root {
+		t.Error("Typed() of a fragment engine did not return the root engine")
+	}
+}
@@ -0,0 +1,70 @@
+package template
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/common/decls"
+)
+
+// Function describes a function or macro expressions rendered by an Engine can call.
+type Function struct {
+	Name string `json:"name" yaml:"name"`
+	// Signatures lists one entry per overload, e.g. "string.lowerAscii() -> string" for member
+	// functions and "merge(map(string, dyn), map(string, dyn)) -> map(string, dyn)" for global
+	// ones. Macros are listed as "has(...) (macro)".
+	Signatures []string `json:"signatures" yaml:"signatures"`
+}
+
+// Functions lists the functions and macros of the engine's environment, including those registered
+// through WithFunction, sorted by name. Operators such as _+_, -_ and indexing, and internal helpers
+// such as cel.@mapInsert are left out.
+func (e *Engine) Functions() ([]Function, error) {
+	env, err := e.environment()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+
+	byName := map[string][]string{}
+	for name, decl := range env.Functions() {
+		if isOperator(name) || strings.HasPrefix(name, "-") || strings.Contains(name, "@") {
+			continue
+		}
+		for _, overload := range decl.OverloadDecls() {
+			byName[name] = append(byName[name], signature(name, overload))
+		}
+	}
+	for _, macro := range env.Macros() {
+		name := macro.Function()
+		sig := name + "(...) (macro)"
+		if macro.IsReceiverStyle() {
+			sig = "<target>." + sig
+		}
+		if !slices.Contains(byName[name], sig) {
+			byName[name] = append(byName[name], sig)
+		}
+	}
+
+	functions := make([]Function, 0, len(byName))
+	for name, signatures := range byName {
+		sort.Strings(signatures)
+		functions = append(functions, Function{Name: name, Signatures: signatures})
+	}
+	sort.Slice(functions, func(i, j int) bool { return functions[i].Name < functions[j].Name })
+	return functions, nil
+}
+
+func signature(name string, overload *decls.OverloadDecl) string {
+	args := overload.ArgTypes()
+	params := make([]string, 0, len(args))
+	for _, arg := range args {
+		params = append(params, arg.String())
+	}
+	result := overload.ResultType().String()
+	if overload.IsMemberFunction() && len(params) > 0 {
+		return fmt.Sprintf("%s.%s(%s) -> %s", params[0], name, strings.Join(params[1:], ", "), result)
+	}
+	return fmt.Sprintf("%s(%s) -> %s", name, strings.Join(params, ", "), result)
+}
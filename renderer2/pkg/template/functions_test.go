@@ -0,0 +1,48 @@
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+func TestFunctions(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(WithFunction("shout",
+		cel.Overload("shout_string", []*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val { return types.String(strings.ToUpper(arg.Value().(string))) }),
+		),
+	))
+	functions, err := engine.Functions()
+	if err != nil {
+		t.Fatalf("Functions: %v", err)
+	}
+
+	signatures := map[string][]string{}
+	for _, fn := range functions {
+		signatures[fn.Name] = fn.Signatures
+	}
+	for name, want := range map[string]string{
+		"shout":      "shout(string) -> string",
+		"omit":       "omit() -> dyn",
+		"lowerAscii": "string.lowerAscii() -> string",
+		"has":        "has(...) (macro)",
+	} {
+		if !slices.Contains(signatures[name], want) {
+			t.Errorf("signatures of %s = %v, want %q among them", name, signatures[name], want)
+		}
+	}
+	for _, operator := range []string{"_+_", "-_", "!_", "@in", "cel.@mapInsert"} {
+		if _, ok := signatures[operator]; ok {
+			t.Errorf("operator %s listed as a function", operator)
+		}
+	}
+	if !slices.IsSortedFunc(functions, func(a, b Function) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Error("functions are not sorted by name")
+	}
+}
@@ -0,0 +1,36 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// hashFunction returns the sha256 hex digest of a string, or of a map encoded as JSON with sorted
+// keys, so `checksum/config: ${hash(configurations)}` changes exactly when the configuration does.
+var hashFunction = cel.Function("hash",
+	cel.Overload("hash_string", []*cel.Type{cel.StringType}, cel.StringType,
+		cel.UnaryBinding(func(arg ref.Val) ref.Val {
+			return types.String(sha256Hex([]byte(arg.Value().(string))))
+		}),
+	),
+	cel.Overload("hash_map", []*cel.Type{cel.MapType(cel.DynType, cel.DynType)}, cel.StringType,
+		cel.UnaryBinding(func(arg ref.Val) ref.Val {
+			// encoding/json sorts map keys, which makes the encoding independent of map order.
+			data, err := json.Marshal(convertCELValue(arg))
+			if err != nil {
+				return types.NewErr("hash: %v", err)
+			}
+			return types.String(sha256Hex(data))
+		}),
+	),
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
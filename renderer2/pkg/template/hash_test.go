@@ -0,0 +1,39 @@
+package template
+
+import "testing"
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	inputs := map[string]any{
+		"content": "port: 8080\n",
+		"config":  map[string]any{"b": int64(2), "a": "x", "nested": map[string]any{"z": true, "y": []any{1, 2}}},
+		"same":    map[string]any{"nested": map[string]any{"y": []any{1, 2}, "z": true}, "a": "x", "b": int64(2)},
+		"changed": map[string]any{"b": int64(3), "a": "x", "nested": map[string]any{"z": true, "y": []any{1, 2}}},
+	}
+	render := func(expression string) string {
+		t.Helper()
+		got, err := engine.Render("${"+expression+"}", inputs)
+		if err != nil {
+			t.Fatalf("Render(%s) error = %v", expression, err)
+		}
+		return got.(string)
+	}
+
+	if got, want := render("hash(content)"), sha256Hex([]byte("port: 8080\n")); got != want {
+		t.Errorf("hash(content) = %s, want %s", got, want)
+	}
+	if got, want := render("hash(config)"), sha256Hex([]byte(`{"a":"x","b":2,"nested":{"y":[1,2],"z":true}}`)); got != want {
+		t.Errorf("hash(config) = %s, want %s", got, want)
+	}
+	if render("hash(config)") != render("hash(same)") {
+		t.Error("hash() of equal maps differs")
+	}
+	if render("hash(config)") == render("hash(changed)") {
+		t.Error("hash() of different maps is equal")
+	}
+	if got, want := render(`hash({"b": 2, "a": "x"})`), sha256Hex([]byte(`{"a":"x","b":2}`)); got != want {
+		t.Errorf("hash() of a map literal = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,133 @@
+package template
+
+import (
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/parser"
+)
+
+const (
+	labelName      = "app.kubernetes.io/name"
+	labelInstance  = "app.kubernetes.io/instance"
+	labelVersion   = "app.kubernetes.io/version"
+	labelComponent = "app.kubernetes.io/component"
+	labelPartOf    = "app.kubernetes.io/part-of"
+	labelManagedBy = "app.kubernetes.io/managed-by"
+
+	managedByValue = "openchoreo"
+	maxLabelValue  = 63
+)
+
+// standardLabelsMacro rewrites `standardLabels()` into a call over the metadata, build, and
+// componentType context variables so templates don't have to pass them explicitly.
+var standardLabelsMacro = cel.GlobalMacro("standardLabels", 0,
+	func(eh parser.ExprHelper, target ast.Expr, args []ast.Expr) (ast.Expr, *common.Error) {
+		return eh.NewCall("standardLabels",
+			eh.NewIdent("metadata"),
+			eh.NewIdent("build"),
+			eh.NewIdent("componentType"),
+		), nil
+	})
+
+var standardLabelsFunction = cel.Function("standardLabels",
+	cel.Overload("standard_labels_dyn_dyn_dyn", []*cel.Type{cel.DynType, cel.DynType, cel.DynType}, cel.MapType(cel.StringType, cel.StringType),
+		cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+			metadata := nativeMap(args[0].Value())
+			build := nativeMap(args[1].Value())
+			componentType, _ := args[2].Value().(string)
+			return types.DefaultTypeAdapter.NativeToValue(standardLabels(metadata, build, componentType))
+		}),
+	),
+)
+
+// standardLabels builds the recommended app.kubernetes.io/* labels. Labels whose source is empty
+// are left out; an explicit part-of label on the Component is carried over.
+func standardLabels(metadata, build map[string]any, componentType string) map[string]any {
+	labels := map[string]any{
+		labelManagedBy: managedByValue,
+	}
+	set := func(key, value string) {
+		if value = labelValue(value); value != "" {
+			labels[key] = value
+		}
+	}
+
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	set(labelName, name)
+	if namespace != "" {
+		set(labelInstance, name+"-"+namespace)
+	} else {
+		set(labelInstance, name)
+	}
+	set(labelComponent, componentType)
+
+	image, _ := build["image"].(string)
+	set(labelVersion, imageTag(image))
+
+	if partOf, ok := nativeMap(metadata["labels"])[labelPartOf].(string); ok {
+		set(labelPartOf, partOf)
+	}
+	return labels
+}
+
+// imageTag returns the tag of an image reference, ignoring digests and registry ports.
+func imageTag(image string) string {
+	if at := strings.Index(image, "@"); at != -1 {
+		image = image[:at]
+	}
+	lastSlash := strings.LastIndex(image, "/")
+	if colon := strings.LastIndex(image, ":"); colon > lastSlash {
+		return image[colon+1:]
+	}
+	return ""
+}
+
+// labelValue trims a value to the Kubernetes label limits: at most 63 characters from
+// [A-Za-z0-9._-], starting and ending with an alphanumeric character.
+func labelValue(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	cleaned := b.String()
+	if len(cleaned) > maxLabelValue {
+		cleaned = cleaned[:maxLabelValue]
+	}
+	return strings.Trim(cleaned, "-_.")
+}
+
+func nativeMap(value any) map[string]any {
+	switch typed := value.(type) {
+	case map[string]any:
+		return typed
+	case map[string]string:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[k] = v
+		}
+		return result
+	case map[ref.Val]ref.Val:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			if key, ok := k.Value().(string); ok {
+				result[key] = v.Value()
+			}
+		}
+		return result
+	case ref.Val:
+		return nativeMap(typed.Value())
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,99 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+)
+
+// EngineOption customizes an Engine created by NewEngine.
+type EngineOption func(*Engine)
+
+// ContextValueFunc computes the value of a context variable once per Render call. It receives the
+// caller's inputs so values can be derived from them (for example a region looked up from the
+// environment name).
+type ContextValueFunc func(inputs map[string]any) (any, error)
+
+// WithVariable makes a fixed value available to every expression under the given name, e.g.
+// `cluster` or `tenant` for an engine serving a single cluster.
+func WithVariable(name string, value any) EngineOption {
+	return func(e *Engine) {
+		if e.variables == nil {
+			e.variables = map[string]any{}
+		}
+		e.variables[name] = value
+	}
+}
+
+// WithContextValue registers a variable whose value is computed per Render call.
+func WithContextValue(name string, fn ContextValueFunc) EngineOption {
+	return func(e *Engine) {
+		if e.contextValues == nil {
+			e.contextValues = map[string]ContextValueFunc{}
+		}
+		e.contextValues[name] = fn
+	}
+}
+
+// WithFunction registers an additional CEL function with the engine's environment.
+func WithFunction(name string, overloads ...cel.FunctionOpt) EngineOption {
+	return func(e *Engine) {
+		e.functions = append(e.functions, cel.Function(name, overloads...))
+		e.functionNames = append(e.functionNames, name)
+	}
+}
+
+// WithCacheSize sets the number of compiled programs and input environments the engine keeps;
+// the least recently used are compiled again when needed. Zero or less uses DefaultCacheSize.
+func WithCacheSize(n int) EngineOption {
+	return func(e *Engine) {
+		if n <= 0 {
+			n = DefaultCacheSize
+		}
+		e.cacheSize = n
+	}
+}
+
+// FunctionNames returns the names of the functions registered through WithFunction, sorted.
+func (e *Engine) FunctionNames() []string {
+	names := append([]string(nil), e.functionNames...)
+	sort.Strings(names)
+	return names
+}
+
+// activation layers registered variables and context values underneath the caller's inputs.
+func (e *Engine) activation(inputs map[string]any) (map[string]any, error) {
+	activation := AcquireActivation(e.variables)
+	for name, fn := range e.contextValues {
+		if _, ok := inputs[name]; ok {
+			continue
+		}
+		value, err := fn(inputs)
+		if err != nil {
+			ReleaseActivation(activation)
+			return nil, fmt.Errorf("failed to compute context value %s: %w", name, err)
+		}
+		activation[name] = value
+	}
+	for key, value := range inputs {
+		activation[key] = value
+	}
+	return activation, nil
+}
+
+// VariableNames returns the names of the variables registered through WithVariable and
+// WithContextValue, sorted.
+func (e *Engine) VariableNames() []string {
+	names := make([]string, 0, len(e.variables)+len(e.contextValues))
+	for name := range e.variables {
+		names = append(names, name)
+	}
+	for name := range e.contextValues {
+		if _, ok := e.variables[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
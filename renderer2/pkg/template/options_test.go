@@ -0,0 +1,90 @@
+package template
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+func TestEngineOptions(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(
+		WithVariable("cluster", map[string]any{"name": "prod-eu-1"}),
+		WithVariable("tenant", "acme"),
+		WithContextValue("region", func(inputs map[string]any) (any, error) {
+			env, _ := inputs["environment"].(string)
+			if strings.HasSuffix(env, "-eu") {
+				return "eu-west-1", nil
+			}
+			return "us-east-1", nil
+		}),
+		WithFunction("shout",
+			cel.Overload("shout_string", []*cel.Type{cel.StringType}, cel.StringType,
+				cel.UnaryBinding(func(arg ref.Val) ref.Val {
+					return types.String(strings.ToUpper(arg.Value().(string)))
+				}),
+			),
+		),
+	)
+
+	tests := []struct {
+		name     string
+		template string
+		inputs   map[string]any
+		want     any
+	}{
+		{
+			name:     "static variable",
+			template: "${cluster.name}/${tenant}",
+			want:     "prod-eu-1/acme",
+		},
+		{
+			name:     "context value derived from inputs",
+			template: "${region}",
+			inputs:   map[string]any{"environment": "prod-eu"},
+			want:     "eu-west-1",
+		},
+		{
+			name:     "inputs take precedence",
+			template: "${tenant}-${region}",
+			inputs:   map[string]any{"tenant": "globex", "region": "ap-south-1"},
+			want:     "globex-ap-south-1",
+		},
+		{
+			name:     "custom function",
+			template: "${shout(tenant)}",
+			want:     "ACME",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := engine.Render(tt.template, tt.inputs)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Render() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineContextValueError(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine(WithContextValue("region", func(map[string]any) (any, error) {
+		return nil, fmt.Errorf("lookup failed")
+	}))
+	if _, err := engine.Render("${region}", nil); err == nil || !strings.Contains(err.Error(), "lookup failed") {
+		t.Fatalf("Render() error = %v, want context value error", err)
+	}
+}
@@ -0,0 +1,164 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"gopkg.in/inf.v0"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// quantityType is the CEL type of Kubernetes resource quantities such as 500m or 1Gi. CEL's
+// arithmetic and comparison operators dispatch on the traits of their left operand, so the type
+// declares the ones Quantity implements.
+var quantityType = cel.ObjectType("quantity", traits.AdderType, traits.SubtractorType, traits.MultiplierType, traits.ComparerType)
+
+// Quantity is a Kubernetes resource quantity as a CEL value. Rendered results hold its canonical
+// string, e.g. `${quantity(spec.memory) * 2}` renders 512Mi as "1Gi".
+type Quantity struct {
+	resource.Quantity
+}
+
+// ConvertToNative implements ref.Val.
+func (q Quantity) ConvertToNative(typeDesc reflect.Type) (any, error) {
+	switch typeDesc {
+	case reflect.TypeOf(resource.Quantity{}):
+		return q.Quantity, nil
+	case reflect.TypeOf(""):
+		return q.String(), nil
+	}
+	return nil, fmt.Errorf("type conversion error from quantity to %v", typeDesc)
+}
+
+// ConvertToType implements ref.Val.
+func (q Quantity) ConvertToType(typeVal ref.Type) ref.Val {
+	switch typeVal {
+	case quantityType:
+		return q
+	case types.StringType:
+		return types.String(q.String())
+	case types.TypeType:
+		return quantityType
+	}
+	return types.NewErr("type conversion error from quantity to %s", typeVal)
+}
+
+// Equal implements ref.Val; quantities are equal when they denote the same amount, so 1Gi equals
+// 1024Mi.
+func (q Quantity) Equal(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.False
+	}
+	return types.Bool(q.Cmp(o.Quantity) == 0)
+}
+
+// Type implements ref.Val.
+func (q Quantity) Type() ref.Type {
+	return quantityType
+}
+
+// Value implements ref.Val.
+func (q Quantity) Value() any {
+	return q.Quantity
+}
+
+// Add implements traits.Adder.
+func (q Quantity) Add(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	sum := q.DeepCopy()
+	sum.Add(o.Quantity)
+	return Quantity{sum}
+}
+
+// Subtract implements traits.Subtractor.
+func (q Quantity) Subtract(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	difference := q.DeepCopy()
+	difference.Sub(o.Quantity)
+	return Quantity{difference}
+}
+
+// Multiply implements traits.Multiplier, scaling the quantity by an int or double and keeping its
+// format (binary, decimal or exponent).
+func (q Quantity) Multiply(other ref.Val) ref.Val {
+	var factor *inf.Dec
+	switch o := other.(type) {
+	case types.Int:
+		factor = inf.NewDec(int64(o), 0)
+	case types.Double:
+		var ok bool
+		if factor, ok = new(inf.Dec).SetString(strconv.FormatFloat(float64(o), 'f', -1, 64)); !ok {
+			return types.NewErr("quantity: cannot scale by %v", o)
+		}
+	default:
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	amount := q.DeepCopy()
+	product := new(inf.Dec).Mul(amount.AsDec(), factor)
+	return Quantity{*resource.NewDecimalQuantity(*product, q.Format)}
+}
+
+// Compare implements traits.Comparer.
+func (q Quantity) Compare(other ref.Val) ref.Val {
+	o, ok := other.(Quantity)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(other)
+	}
+	return types.Int(q.Cmp(o.Quantity))
+}
+
+// quantityFunctions parse quantities and declare the operators Quantity implements, so templates
+// can derive e.g. a memory limit from a request with `${quantity(spec.memory) * 2}`. The operator
+// overloads have no bindings of their own; CEL calls the Quantity methods.
+var quantityFunctions = []cel.EnvOption{
+	cel.Function("quantity",
+		cel.Overload("quantity_string", []*cel.Type{cel.StringType}, quantityType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				q, err := resource.ParseQuantity(string(arg.(types.String)))
+				if err != nil {
+					return types.NewErr("quantity: %v", err)
+				}
+				return Quantity{q}
+			}),
+		),
+	),
+	cel.Function("isQuantity",
+		cel.Overload("is_quantity_string", []*cel.Type{cel.StringType}, cel.BoolType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				_, err := resource.ParseQuantity(string(arg.(types.String)))
+				return types.Bool(err == nil)
+			}),
+		),
+	),
+	cel.Function("string",
+		cel.Overload("string_quantity", []*cel.Type{quantityType}, cel.StringType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				q := arg.(Quantity)
+				return types.String(q.String())
+			}),
+		),
+	),
+	cel.Function(operators.Add, cel.Overload("add_quantity_quantity", []*cel.Type{quantityType, quantityType}, quantityType)),
+	cel.Function(operators.Subtract, cel.Overload("subtract_quantity_quantity", []*cel.Type{quantityType, quantityType}, quantityType)),
+	cel.Function(operators.Multiply,
+		cel.Overload("multiply_quantity_int", []*cel.Type{quantityType, cel.IntType}, quantityType),
+		cel.Overload("multiply_quantity_double", []*cel.Type{quantityType, cel.DoubleType}, quantityType),
+	),
+	cel.Function(operators.Less, cel.Overload("less_quantity_quantity", []*cel.Type{quantityType, quantityType}, cel.BoolType)),
+	cel.Function(operators.LessEquals, cel.Overload("less_equals_quantity_quantity", []*cel.Type{quantityType, quantityType}, cel.BoolType)),
+	cel.Function(operators.Greater, cel.Overload("greater_quantity_quantity", []*cel.Type{quantityType, quantityType}, cel.BoolType)),
+	cel.Function(operators.GreaterEquals, cel.Overload("greater_equals_quantity_quantity", []*cel.Type{quantityType, quantityType}, cel.BoolType)),
+}
@@ -0,0 +1,53 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuantityFunctions(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	inputs := map[string]any{"spec": map[string]any{"memory": "512Mi", "cpu": "250m", "window": "90s"}}
+
+	tests := []struct {
+		expression string
+		want       any
+		wantErr    string
+	}{
+		{expression: `quantity(spec.memory) * 2`, want: "1Gi"},
+		{expression: `quantity(spec.cpu) * 1.5`, want: "375m"},
+		{expression: `quantity(spec.cpu) + quantity("1")`, want: "1250m"},
+		{expression: `quantity("2Gi") - quantity(spec.memory)`, want: "1536Mi"},
+		{expression: `quantity("1Gi") == quantity("1024Mi")`, want: true},
+		{expression: `quantity(spec.cpu) < quantity("0.5")`, want: true},
+		{expression: `quantity(spec.memory) >= quantity("1G")`, want: false},
+		{expression: `string(quantity("1000m"))`, want: "1"},
+		{expression: `isQuantity(spec.memory) && !isQuantity("lots")`, want: true},
+		{expression: `duration(spec.window) + duration("30s")`, want: "2m0s"},
+		{expression: `(duration(spec.window) + duration("1h")).getSeconds()`, want: int64(3690)},
+		{expression: `duration(spec.window) > duration("1m")`, want: true},
+		{expression: `quantity("lots")`, wantErr: "quantity: quantities must match the regular expression"},
+		{expression: `quantity(spec.cpu) * "2"`, wantErr: "found no matching overload"},
+	}
+
+	for _, tt := range tests {
+		got, err := engine.Render("${"+tt.expression+"}", inputs)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.expression, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.expression, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", tt.expression, diff)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/common/ast"
+	"github.com/google/cel-go/common/operators"
+	"github.com/google/cel-go/common/types"
+)
+
+// References returns the sorted input paths (e.g. "spec.replicas", "build.image") read by every
+// expression in a template, including expressions in map keys. A path stops at the deepest
+// statically known segment: `spec.ports[i].port` and `spec.ports.map(p, p.port)` both reference
+// "spec.ports". Variables bound inside the expression (comprehension and macro variables) are not
+// reported.
+func (e *Engine) References(data any) ([]string, error) {
+	paths := map[string]bool{}
+	if err := e.collectReferences(data, paths); err != nil {
+		return nil, err
+	}
+	return sortedPaths(paths), nil
+}
+
+func (e *Engine) collectReferences(data any, paths map[string]bool) error {
+	switch v := data.(type) {
+	case string:
+		for _, match := range findCELExpressions(v) {
+			if err := e.expressionReferences(match.innerExpr, paths); err != nil {
+				return err
+			}
+		}
+	case map[string]any:
+		for key, value := range v {
+			if err := e.collectReferences(key, paths); err != nil {
+				return err
+			}
+			if err := e.collectReferences(value, paths); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := e.collectReferences(item, paths); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Analysis describes the inputs and functions a single CEL expression depends on.
+type Analysis struct {
+	// References are the input paths read by the expression, sorted.
+	References []string
+	// Variables are the top-level inputs read by the expression (metadata, spec, build, ...), sorted.
+	Variables []string
+	// Functions are the functions called after macro expansion (operators excluded), sorted.
+	Functions []string
+}
+
+// Analyze parses a raw CEL expression (without the surrounding ${}) and reports what it reads.
+// Only parsing is performed, so the expression may reference inputs that are not known yet.
+func (e *Engine) Analyze(expression string) (*Analysis, error) {
+	paths := map[string]bool{}
+	functions := map[string]bool{}
+	if err := e.analyze(expression, paths, functions); err != nil {
+		return nil, err
+	}
+
+	variables := map[string]bool{}
+	for path := range paths {
+		root, _, _ := strings.Cut(path, ".")
+		variables[root] = true
+	}
+	return &Analysis{
+		References: sortedPaths(paths),
+		Variables:  sortedPaths(variables),
+		Functions:  sortedPaths(functions),
+	}, nil
+}
+
+// Reads reports whether the expression reads path, one of its parents, or one of its children.
+func (a *Analysis) Reads(path string) bool {
+	for _, ref := range a.References {
+		if PathsOverlap(ref, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Engine) expressionReferences(expression string, paths map[string]bool) error {
+	return e.analyze(expression, paths, nil)
+}
+
+func (e *Engine) analyze(expression string, paths, functions map[string]bool) error {
+	env, err := e.buildEnv(nil)
+	if err != nil {
+		return fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+	parsed, issues := env.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return fmt.Errorf("CEL parse error in %q: %v", expression, issues.Err())
+	}
+	w := &referenceWalker{paths: paths, functions: functions, qualified: map[string]bool{}}
+	for name := range env.Functions() {
+		if strings.Contains(name, ".") {
+			w.qualified[name] = true
+		}
+	}
+	w.walk(parsed.NativeRep().Expr(), nil)
+	return nil
+}
+
+// referenceWalker records the input paths and, when functions is non-nil, the functions used by
+// an expression.
+type referenceWalker struct {
+	paths     map[string]bool
+	functions map[string]bool
+	// qualified holds namespaced function names such as k8s.probe, which parse as member calls.
+	qualified map[string]bool
+}
+
+// walk visits expr. bound holds variables introduced by enclosing comprehensions.
+func (w *referenceWalker) walk(expr ast.Expr, bound map[string]bool) {
+	if root, segments, ok := staticPath(expr); ok {
+		if !bound[root] {
+			w.paths[strings.Join(append([]string{root}, segments...), ".")] = true
+		}
+		return
+	}
+
+	switch expr.Kind() {
+	case ast.SelectKind:
+		w.walk(expr.AsSelect().Operand(), bound)
+	case ast.CallKind:
+		call := expr.AsCall()
+		name, target := call.FunctionName(), call.IsMemberFunction()
+		if target {
+			if root, segments, ok := staticPath(call.Target()); ok && !bound[root] {
+				if qualified := strings.Join(append([]string{root}, segments...), ".") + "." + name; w.qualified[qualified] {
+					name, target = qualified, false
+				}
+			}
+		}
+		if w.functions != nil && !isOperator(name) {
+			w.functions[name] = true
+		}
+		if target {
+			w.walk(call.Target(), bound)
+		}
+		for _, arg := range call.Args() {
+			w.walk(arg, bound)
+		}
+	case ast.ListKind:
+		for _, element := range expr.AsList().Elements() {
+			w.walk(element, bound)
+		}
+	case ast.MapKind:
+		for _, entry := range expr.AsMap().Entries() {
+			w.walk(entry.AsMapEntry().Key(), bound)
+			w.walk(entry.AsMapEntry().Value(), bound)
+		}
+	case ast.StructKind:
+		for _, field := range expr.AsStruct().Fields() {
+			w.walk(field.AsStructField().Value(), bound)
+		}
+	case ast.ComprehensionKind:
+		comprehension := expr.AsComprehension()
+		w.walk(comprehension.IterRange(), bound)
+		w.walk(comprehension.AccuInit(), bound)
+
+		inner := make(map[string]bool, len(bound)+3)
+		for name := range bound {
+			inner[name] = true
+		}
+		inner[comprehension.IterVar()] = true
+		if comprehension.HasIterVar2() {
+			inner[comprehension.IterVar2()] = true
+		}
+		inner[comprehension.AccuVar()] = true
+		w.walk(comprehension.LoopCondition(), inner)
+		w.walk(comprehension.LoopStep(), inner)
+		w.walk(comprehension.Result(), inner)
+	}
+}
+
+// staticPath resolves identifier/field/constant-key chains such as spec.resources["limits"].cpu.
+func staticPath(expr ast.Expr) (string, []string, bool) {
+	switch expr.Kind() {
+	case ast.IdentKind:
+		return expr.AsIdent(), nil, true
+	case ast.SelectKind:
+		sel := expr.AsSelect()
+		root, segments, ok := staticPath(sel.Operand())
+		if !ok {
+			return "", nil, false
+		}
+		return root, append(segments, sel.FieldName()), true
+	case ast.CallKind:
+		call := expr.AsCall()
+		switch call.FunctionName() {
+		case operators.Index, operators.OptIndex, operators.OptSelect:
+		default:
+			return "", nil, false
+		}
+		args := call.Args()
+		if len(args) != 2 || args[1].Kind() != ast.LiteralKind {
+			return "", nil, false
+		}
+		key, ok := args[1].AsLiteral().(types.String)
+		if !ok {
+			return "", nil, false
+		}
+		root, segments, ok := staticPath(args[0])
+		if !ok {
+			return "", nil, false
+		}
+		return root, append(segments, string(key)), true
+	}
+	return "", nil, false
+}
+
+// isOperator reports whether a call is an operator (_+_, !_, _[_]) or a parser-internal helper
+// such as @not_strictly_false rather than a named function.
+func isOperator(name string) bool {
+	return strings.HasPrefix(name, "_") || strings.HasPrefix(name, "!") || strings.HasPrefix(name, "@")
+}
+
+func sortedPaths(paths map[string]bool) []string {
+	result := make([]string, 0, len(paths))
+	for path := range paths {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// PathsOverlap reports whether two input paths refer to the same data: equal, or one is a parent
+// of the other ("spec" overlaps "spec.replicas", "spec.replica" does not).
+func PathsOverlap(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return a == b || strings.HasPrefix(b, a+".")
+}
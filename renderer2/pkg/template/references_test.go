@@ -0,0 +1,121 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReferences(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+
+	tests := []struct {
+		name     string
+		template any
+		want     []string
+	}{
+		{
+			name:     "field selections",
+			template: "${spec.replicas}",
+			want:     []string{"spec.replicas"},
+		},
+		{
+			name:     "interpolated string and map key",
+			template: map[string]any{"${metadata.name}-svc": "${build.image}:${spec.tag}"},
+			want:     []string{"build.image", "metadata.name", "spec.tag"},
+		},
+		{
+			name:     "constant string index extends the path",
+			template: `${spec.resources["limits"].cpu}`,
+			want:     []string{"spec.resources.limits.cpu"},
+		},
+		{
+			name:     "dynamic index stops the path",
+			template: "${spec.ports[spec.primary].port}",
+			want:     []string{"spec.ports", "spec.primary"},
+		},
+		{
+			name:     "comprehension variables are not references",
+			template: "${spec.ports.map(p, p.port + spec.offset)}",
+			want:     []string{"spec.offset", "spec.ports"},
+		},
+		{
+			name:     "has() and member functions",
+			template: "${has(spec.debug) && metadata.labels.exists(k, k == 'team')}",
+			want:     []string{"metadata.labels", "spec.debug"},
+		},
+		{
+			name:     "macros expand to their inputs",
+			template: "${standardLabels()}",
+			want:     []string{"build", "componentType", "metadata"},
+		},
+		{
+			name:     "plain text",
+			template: []any{"static", 42},
+			want:     []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := engine.References(tt.template)
+			if err != nil {
+				t.Fatalf("References() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("References() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPathsOverlap(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"spec", "spec.replicas", true},
+		{"spec.replicas", "spec", true},
+		{"spec.replicas", "spec.replicas", true},
+		{"spec.replica", "spec.replicas", false},
+		{"build.image", "spec.image", false},
+	}
+	for _, tt := range tests {
+		if got := PathsOverlap(tt.a, tt.b); got != tt.want {
+			t.Errorf("PathsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+
+	got, err := engine.Analyze(`spec.items.filter(i, i.enabled).map(i, sanitizeK8sResourceName(metadata.name, i.name)).size() > 0 && !has(spec.debug)`)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	want := &Analysis{
+		References: []string{"metadata.name", "spec.debug", "spec.items"},
+		Variables:  []string{"metadata", "spec"},
+		Functions:  []string{"sanitizeK8sResourceName", "size"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Analyze() mismatch (-want +got):\n%s", diff)
+	}
+	if !got.Reads("spec") || !got.Reads("spec.items.0") || got.Reads("build.image") {
+		t.Errorf("Reads() does not match References %v", got.References)
+	}
+
+	if _, err := engine.Analyze("spec.("); err == nil {
+		t.Errorf("Analyze() of invalid expression error = nil, want parse error")
+	}
+}
@@ -0,0 +1,118 @@
+package template
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Registry holds custom CEL functions a platform team defines once, e.g. hashSuffix() or
+// toQuantity(), and installs into every engine it builds with WithRegistry. EnvOptions installs
+// the same functions into a plain cel-go environment, so tools that do not render through an
+// Engine evaluate expressions the same way. A Registry is safe for concurrent use.
+type Registry struct {
+	mu        sync.RWMutex
+	functions map[string][]cel.FunctionOpt
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{functions: map[string][]cel.FunctionOpt{}}
+}
+
+// builtinNames are the functions and macros every engine defines before custom functions are
+// added, and fragment, which engines derived through Fragments define.
+var builtinNames = sync.OnceValues(func() (map[string]bool, error) {
+	env, err := cel.NewEnv(baseEnvOptions()...)
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{fragmentFunctionName: true}
+	for name := range env.Functions() {
+		names[name] = true
+	}
+	for _, macro := range env.Macros() {
+		names[macro.Function()] = true
+	}
+	return names, nil
+})
+
+// Register adds a function with its overloads. A name registered twice or defined by the engine
+// itself (omit, merge, join, ...) is an error, so one team cannot shadow another's functions.
+// Overload IDs are checked when an engine is built.
+func (r *Registry) Register(name string, overloads ...cel.FunctionOpt) error {
+	if name == "" {
+		return fmt.Errorf("function name must not be empty")
+	}
+	if len(overloads) == 0 {
+		return fmt.Errorf("function %s has no overloads", name)
+	}
+	builtins, err := builtinNames()
+	if err != nil {
+		return fmt.Errorf("failed to build CEL environment: %w", err)
+	}
+	if builtins[name] {
+		return fmt.Errorf("function %s is built into the engine", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.functions[name]; ok {
+		return fmt.Errorf("function %s is already registered", name)
+	}
+	r.functions[name] = overloads
+	return nil
+}
+
+// MustRegister is Register for package initialization; it panics on error.
+func (r *Registry) MustRegister(name string, overloads ...cel.FunctionOpt) {
+	if err := r.Register(name, overloads...); err != nil {
+		panic(err)
+	}
+}
+
+// Names returns the names of the registered functions, sorted.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+// EnvOptions returns the registered functions as cel-go environment options, in name order.
+func (r *Registry) EnvOptions() []cel.EnvOption {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	options := make([]cel.EnvOption, 0, len(r.functions))
+	for _, name := range r.namesLocked() {
+		options = append(options, cel.Function(name, r.functions[name]...))
+	}
+	return options
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.functions))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithRegistry registers every function of registry with the engine, as WithFunction does. It
+// takes the functions registered at the time it is called; later registrations do not reach the
+// engine, whose compiled expressions are cached.
+func WithRegistry(registry *Registry) EngineOption {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	options := make([]EngineOption, 0, len(registry.functions))
+	for _, name := range registry.namesLocked() {
+		options = append(options, WithFunction(name, registry.functions[name]...))
+	}
+	return func(e *Engine) {
+		for _, opt := range options {
+			opt(e)
+		}
+	}
+}
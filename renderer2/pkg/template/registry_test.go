@@ -0,0 +1,102 @@
+package template
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/go-cmp/cmp"
+)
+
+func hashSuffix() []cel.FunctionOpt {
+	return []cel.FunctionOpt{
+		cel.Overload("hash_suffix_string", []*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val {
+				sum := sha256.Sum256([]byte(arg.Value().(string)))
+				return types.String(arg.Value().(string) + "-" + hex.EncodeToString(sum[:])[:6])
+			}),
+		),
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	if err := registry.Register("hashSuffix", hashSuffix()...); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	registry.MustRegister("twice",
+		cel.Overload("twice_int", []*cel.Type{cel.IntType}, cel.IntType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val { return arg.(types.Int) * 2 }),
+		),
+	)
+
+	for _, tt := range []struct {
+		name    string
+		opts    []cel.FunctionOpt
+		wantErr string
+	}{
+		{name: "hashSuffix", opts: hashSuffix(), wantErr: "function hashSuffix is already registered"},
+		{name: "omit", opts: hashSuffix(), wantErr: "function omit is built into the engine"},
+		{name: "join", opts: hashSuffix(), wantErr: "function join is built into the engine"},
+		{name: "has", opts: hashSuffix(), wantErr: "function has is built into the engine"},
+		{name: "fragment", opts: hashSuffix(), wantErr: "function fragment is built into the engine"},
+		{name: "empty", wantErr: "function empty has no overloads"},
+	} {
+		if err := registry.Register(tt.name, tt.opts...); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("Register(%s) error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+	if diff := cmp.Diff([]string{"hashSuffix", "twice"}, registry.Names()); diff != "" {
+		t.Errorf("Names() mismatch (-want +got):\n%s", diff)
+	}
+
+	engine := NewEngine(WithRegistry(registry))
+	registry.MustRegister("late",
+		cel.Overload("late_string", []*cel.Type{cel.StringType}, cel.StringType,
+			cel.UnaryBinding(func(arg ref.Val) ref.Val { return arg }),
+		),
+	)
+	got, err := engine.Render(map[string]any{"name": "${hashSuffix(name)}", "replicas": "${twice(2)}"}, map[string]any{"name": "web"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := map[string]any{"name": "web-" + sha256Prefix("web"), "replicas": int64(4)}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"hashSuffix", "twice"}, engine.FunctionNames()); diff != "" {
+		t.Errorf("FunctionNames() mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := engine.Render("${late(name)}", map[string]any{"name": "web"}); err == nil || !strings.Contains(err.Error(), "late") {
+		t.Errorf("Render() of a function registered after NewEngine error = %v, want an undeclared reference", err)
+	}
+
+	// The same functions work in a plain cel-go environment.
+	env, err := cel.NewEnv(append([]cel.EnvOption{cel.Variable("name", cel.StringType)}, registry.EnvOptions()...)...)
+	if err != nil {
+		t.Fatalf("cel.NewEnv() error = %v", err)
+	}
+	ast, issues := env.Compile("hashSuffix(name)")
+	if issues.Err() != nil {
+		t.Fatalf("Compile() error = %v", issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		t.Fatalf("Program() error = %v", err)
+	}
+	out, _, err := program.Eval(map[string]any{"name": "web"})
+	if err != nil || out.Value() != "web-"+sha256Prefix("web") {
+		t.Errorf("Eval() = %v, %v", out, err)
+	}
+}
+
+func sha256Prefix(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])[:6]
+}
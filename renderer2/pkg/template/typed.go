@@ -0,0 +1,170 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// Schema describes the shape of an input variable so that expressions reading it are type checked:
+// selecting a field an object does not declare, e.g. spec.replcas, fails at compile time instead of
+// surfacing at render time as a "no such key" error that callers may treat as missing data.
+// Scalars stay dyn because YAML numbers decode as int or float depending on how they are written.
+type Schema struct {
+	// Properties lists the fields of an object. An object without properties is dyn unless
+	// AdditionalProperties is set.
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	// Items is the element schema of a list.
+	Items *Schema `json:"items,omitempty"`
+	// AdditionalProperties is the value schema of a map with arbitrary keys.
+	AdditionalProperties *Schema `json:"additionalProperties,omitempty"`
+}
+
+// typeNamePrefix keeps schema object type names out of the identifier namespace; the checker
+// resolves qualified names such as spec.ingress against type names before field selection.
+const typeNamePrefix = "$"
+
+// Typed returns an engine that shares the options and base environment of e but declares the named
+// inputs with the given schemas instead of dyn. Typed engines are cached by schema, so deriving one
+// per render is cheap. Calling Typed on a typed engine replaces its schemas; fragments are dropped
+// too, so derive them through Fragments after Typed.
+func (e *Engine) Typed(schemas map[string]*Schema) (*Engine, error) {
+	root := e
+	for root.parent != nil {
+		root = root.parent
+	}
+	if len(schemas) == 0 {
+		return root, nil
+	}
+
+	key, err := json.Marshal(schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode input schemas: %w", err)
+	}
+	if cached, ok := root.typed.get(string(key)); ok {
+		return cached, nil
+	}
+
+	provider := &schemaProvider{objects: map[string]map[string]*types.Type{}}
+	declared := make(map[string]*types.Type, len(schemas))
+	for name, schema := range schemas {
+		declared[name] = provider.declare(name, schema)
+	}
+	child := &Engine{
+		variables:     root.variables,
+		contextValues: root.contextValues,
+		functions:     root.functions,
+		functionNames: root.functionNames,
+		parent:        root,
+		provider:      provider,
+		declared:      declared,
+	}
+	child.initCaches(root.cacheSize)
+	return root.typed.add(string(key), child), nil
+}
+
+// environment returns the environment every input environment of e extends. Derived engines extend
+// the environment of their parent with their schema types or extensions.
+func (e *Engine) environment() (*cel.Env, error) {
+	e.envOnce.Do(func() {
+		if e.parent == nil {
+			e.baseEnv, e.envErr = cel.NewEnv(append(baseEnvOptions(), e.functions...)...)
+			return
+		}
+		parentEnv, err := e.parent.environment()
+		if err != nil {
+			e.envErr = err
+			return
+		}
+		options := append([]cel.EnvOption(nil), e.extensions...)
+		if e.provider != nil {
+			e.provider.Provider = parentEnv.CELTypeProvider()
+			options = append(options, cel.CustomTypeProvider(e.provider))
+		}
+		e.baseEnv, e.envErr = parentEnv.Extend(options...)
+	})
+	return e.baseEnv, e.envErr
+}
+
+// variableType returns the declared type of an input, dyn unless a schema was given for it.
+func (e *Engine) variableType(name string) *types.Type {
+	if declared, ok := e.declared[name]; ok {
+		return declared
+	}
+	return types.DynType
+}
+
+// schemaProvider resolves the object types derived from schemas and delegates everything else to
+// the provider of the parent environment.
+type schemaProvider struct {
+	types.Provider
+	objects map[string]map[string]*types.Type
+}
+
+// declare converts schema into a CEL type, registering an object type for every object with
+// properties. path names the type after the input path it describes.
+func (p *schemaProvider) declare(path string, schema *Schema) *types.Type {
+	switch {
+	case schema == nil:
+		return types.DynType
+	case len(schema.Properties) > 0:
+		fields := make(map[string]*types.Type, len(schema.Properties))
+		for name, field := range schema.Properties {
+			fields[name] = p.declare(path+"."+name, field)
+		}
+		typeName := typeNamePrefix + path
+		p.objects[typeName] = fields
+		return types.NewObjectType(typeName)
+	case schema.Items != nil:
+		return types.NewListType(p.declare(path+"[]", schema.Items))
+	case schema.AdditionalProperties != nil:
+		return types.NewMapType(types.StringType, p.declare(path+"[*]", schema.AdditionalProperties))
+	default:
+		return types.DynType
+	}
+}
+
+func (p *schemaProvider) FindStructType(structType string) (*types.Type, bool) {
+	if _, ok := p.objects[structType]; ok {
+		return types.NewTypeTypeWithParam(types.NewObjectType(structType)), true
+	}
+	return p.Provider.FindStructType(structType)
+}
+
+func (p *schemaProvider) FindStructFieldNames(structType string) ([]string, bool) {
+	fields, ok := p.objects[structType]
+	if !ok {
+		return p.Provider.FindStructFieldNames(structType)
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// FindStructFieldType leaves IsSet and GetFrom unset so that, at evaluation time, fields are read
+// from the plain maps the inputs hold.
+func (p *schemaProvider) FindStructFieldType(structType, fieldName string) (*types.FieldType, bool) {
+	fields, ok := p.objects[structType]
+	if !ok {
+		return p.Provider.FindStructFieldType(structType, fieldName)
+	}
+	fieldType, ok := fields[fieldName]
+	if !ok {
+		return nil, false
+	}
+	return &types.FieldType{Type: fieldType}, true
+}
+
+func (p *schemaProvider) NewValue(structType string, fields map[string]ref.Val) ref.Val {
+	if _, ok := p.objects[structType]; ok {
+		return types.NewErr("schema type %s cannot be constructed", structType)
+	}
+	return p.Provider.NewValue(structType, fields)
+}
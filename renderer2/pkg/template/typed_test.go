@@ -0,0 +1,88 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTypedEngine(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	typed, err := engine.Typed(map[string]*Schema{
+		"spec": {Properties: map[string]*Schema{
+			"replicas": {},
+			"ingress":  {Properties: map[string]*Schema{"host": {}}},
+			"ports":    {Items: &Schema{Properties: map[string]*Schema{"port": {}}}},
+			"labels":   {AdditionalProperties: &Schema{}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Typed() error = %v", err)
+	}
+	inputs := map[string]any{
+		"metadata": map[string]any{"name": "web"},
+		"spec": map[string]any{
+			"replicas": int64(2),
+			"ports":    []any{map[string]any{"port": int64(80)}},
+			"labels":   map[string]any{"team": "a"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    any
+		wantErr string
+	}{
+		{name: "declared field", expr: "${spec.replicas}", want: int64(2)},
+		{name: "absent optional object", expr: `${has(spec.ingress) ? spec.ingress.host : "none"}`, want: "none"},
+		{name: "list elements", expr: "${spec.ports.map(p, p.port)}", want: []any{int64(80)}},
+		{name: "map keys are free", expr: "${spec.labels.team}", want: "a"},
+		{name: "untyped inputs stay dyn", expr: "${metadata.anything.goes}", wantErr: "no such key"},
+		{name: "misspelled field", expr: "${spec.replcas}", wantErr: "undefined field 'replcas'"},
+		{name: "misspelled nested field", expr: "${spec.ports.map(p, p.prot)}", wantErr: "undefined field 'prot'"},
+		{name: "misspelled field in has", expr: "${has(spec.ingres)}", wantErr: "undefined field 'ingres'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := typed.Render(tt.expr, inputs)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Render() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Render() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	again, err := engine.Typed(map[string]*Schema{
+		"spec": {Properties: map[string]*Schema{
+			"replicas": {},
+			"ingress":  {Properties: map[string]*Schema{"host": {}}},
+			"ports":    {Items: &Schema{Properties: map[string]*Schema{"port": {}}}},
+			"labels":   {AdditionalProperties: &Schema{}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Typed() error = %v", err)
+	}
+	if again != typed {
+		t.Errorf("Typed() with an equal schema returned a new engine")
+	}
+	if _, err := engine.Render("${spec.replcas}", inputs); err == nil || strings.Contains(err.Error(), "undefined field") {
+		t.Errorf("untyped engine Render() error = %v, want a runtime error", err)
+	}
+}
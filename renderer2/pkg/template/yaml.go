@@ -0,0 +1,58 @@
+package template
+
+import (
+	"bytes"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlFunctions convert between values and YAML text, e.g. to embed structured parameters as a
+// ConfigMap file with `application.yaml: ${toYaml(spec.config)}` or to read a YAML snippet from a
+// configuration file with `${fromYaml(configurations.files[0].content).server.port}`. Without
+// them, a map interpolated into a string is written as JSON.
+var yamlFunctions = []cel.EnvOption{
+	cel.Function("toYaml",
+		cel.Overload("to_yaml_dyn", []*cel.Type{cel.DynType}, cel.StringType,
+			cel.UnaryBinding(func(value ref.Val) ref.Val {
+				return toYaml(value, 2)
+			}),
+		),
+		cel.Overload("to_yaml_dyn_int", []*cel.Type{cel.DynType, cel.IntType}, cel.StringType,
+			cel.BinaryBinding(func(value, indent ref.Val) ref.Val {
+				return toYaml(value, int(indent.(types.Int)))
+			}),
+		),
+	),
+	cel.Function("fromYaml",
+		cel.Overload("from_yaml_string", []*cel.Type{cel.StringType}, cel.DynType,
+			cel.UnaryBinding(func(text ref.Val) ref.Val {
+				var value any
+				if err := yaml.Unmarshal([]byte(text.(types.String)), &value); err != nil {
+					return types.NewErr("fromYaml: %v", err)
+				}
+				return types.DefaultTypeAdapter.NativeToValue(value)
+			}),
+		),
+	),
+}
+
+// toYaml encodes value as a YAML document with sorted keys, indenting nested blocks by indent
+// spaces. The document ends with a newline.
+func toYaml(value ref.Val, indent int) ref.Val {
+	if indent < 1 || indent > 8 {
+		return types.NewErr("toYaml: indent must be between 1 and 8, got %d", indent)
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(indent)
+	if err := encoder.Encode(convertCELValue(value)); err != nil {
+		return types.NewErr("toYaml: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return types.NewErr("toYaml: %v", err)
+	}
+	return types.String(buf.String())
+}
@@ -0,0 +1,81 @@
+package template
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestYamlFunctions(t *testing.T) {
+	t.Parallel()
+
+	engine := NewEngine()
+	inputs := map[string]any{
+		"config": map[string]any{
+			"server": map[string]any{"port": int64(8080), "hosts": []any{"a", "b"}},
+			"debug":  false,
+		},
+		"file": "server:\n  port: 8080\n  hosts: [a, b]\nratio: 0.5\n",
+	}
+
+	tests := []struct {
+		name     string
+		template any
+		want     any
+		wantErr  string
+	}{
+		{
+			name:     "toYaml with the default indent",
+			template: "${toYaml(config)}",
+			want:     "debug: false\nserver:\n  hosts:\n    - a\n    - b\n  port: 8080\n",
+		},
+		{
+			name:     "toYaml with a custom indent",
+			template: "${toYaml(config.server, 4)}",
+			want:     "hosts:\n    - a\n    - b\nport: 8080\n",
+		},
+		{
+			name:     "toYaml inside a larger string",
+			template: "# generated\n${toYaml({'level': 'info'})}",
+			want:     "# generated\nlevel: info\n",
+		},
+		{
+			name:     "fromYaml fields",
+			template: map[string]any{"port": "${fromYaml(file).server.port}", "hosts": "${fromYaml(file).server.hosts}", "ratio": "${fromYaml(file).ratio}"},
+			want:     map[string]any{"port": int64(8080), "hosts": []any{"a", "b"}, "ratio": 0.5},
+		},
+		{
+			name:     "round trip",
+			template: "${fromYaml(toYaml(config)) == config}",
+			want:     true,
+		},
+		{
+			name:     "invalid indent",
+			template: "${toYaml(config, 0)}",
+			wantErr:  "toYaml: indent must be between 1 and 8, got 0",
+		},
+		{
+			name:     "invalid YAML",
+			template: "${fromYaml('a: [')}",
+			wantErr:  "fromYaml: yaml:",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := engine.Render(tt.template, inputs)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: mismatch (-want +got):\n%s", tt.name, diff)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package testutil
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// ContextOptions controls the shape of a fabricated AdditionalContext. Negative counts mean
+// "none"; zero counts are chosen randomly (0-3) from the seed.
+type ContextOptions struct {
+	ComponentName   string
+	Environment     string
+	ConfigEnvs      int
+	ConfigFiles     int
+	SecretEnvs      int
+	SecretFiles     int
+	DisableBuild    bool
+	ImageRegistries []string
+}
+
+var (
+	envNames = []string{
+		"APP_ENV", "LOG_LEVEL", "MAX_CONNECTIONS", "CACHE_TTL", "FEATURE_FLAGS", "HTTP_PORT",
+		"REQUEST_TIMEOUT", "WORKER_COUNT", "REGION", "TRACING_ENABLED",
+	}
+	envValues = []string{"production", "info", "debug", "100", "30s", "8080", "true", "false", "us-east-1", "5"}
+
+	secretEnvNames = []string{"DATABASE_PASSWORD", "API_KEY", "JWT_SECRET", "REDIS_PASSWORD", "OAUTH_CLIENT_SECRET", "SMTP_PASSWORD"}
+	secretStores   = []string{"db-credentials", "api-secrets", "auth", "cache", "mail"}
+
+	configFiles = []struct {
+		name    string
+		path    string
+		content string
+	}{
+		{"app-config", "/etc/app/config.yaml", "database:\n  host: localhost\n  port: 5432\n"},
+		{"feature-flags", "/etc/app/feature-flags.json", "{\"featureA\": true, \"featureB\": false}"},
+		{"nginx-conf", "/etc/nginx/nginx.conf", "server {\n  listen 8080;\n}\n"},
+		{"logging", "/etc/app/logging.properties", "level=INFO\nformat=json\n"},
+	}
+	secretFiles = []struct {
+		name string
+		path string
+	}{
+		{"tls-cert", "/etc/secrets/tls.crt"},
+		{"tls-key", "/etc/secrets/tls.key"},
+		{"ca-bundle", "/etc/secrets/ca.pem"},
+		{"service-account", "/etc/secrets/sa.json"},
+	}
+
+	defaultRegistries = []string{"gcr.io/my-project", "ghcr.io/acme", "registry.example.com/team", "docker.io/library"}
+	environments      = []string{"development", "staging", "production"}
+)
+
+// FakeAdditionalContext fabricates a realistic AdditionalContext (pod selectors, configurations,
+// secrets, build image) deterministically from seed, so template tests do not need hand-written
+// additional_context.json fixtures for every scenario.
+func FakeAdditionalContext(seed int64, opts ContextOptions) *types.AdditionalContext {
+	rng := rand.New(rand.NewSource(seed))
+
+	name := opts.ComponentName
+	if name == "" {
+		name = fmt.Sprintf("service-%04d", rng.Intn(10000))
+	}
+	environment := opts.Environment
+	if environment == "" {
+		environment = pick(rng, environments)
+	}
+	registries := opts.ImageRegistries
+	if len(registries) == 0 {
+		registries = defaultRegistries
+	}
+
+	ctx := &types.AdditionalContext{
+		PodSelectors: map[string]string{
+			"openchoreo.io/component-id": fmt.Sprintf("%s-%05d", name, rng.Intn(100000)),
+			"openchoreo.io/project-id":   fmt.Sprintf("project-%05d", rng.Intn(100000)),
+			"openchoreo.io/environment":  environment,
+		},
+	}
+
+	if !opts.DisableBuild {
+		ctx.Build.Image = fmt.Sprintf("%s/%s:v%d.%d.%d", pick(rng, registries), name, rng.Intn(3), rng.Intn(20), rng.Intn(10))
+	}
+
+	for _, idx := range sample(rng, len(envNames), count(rng, opts.ConfigEnvs)) {
+		ctx.Configurations.Envs = append(ctx.Configurations.Envs, types.NameValuePair{
+			Name:  envNames[idx],
+			Value: pick(rng, envValues),
+		})
+	}
+	for _, idx := range sample(rng, len(configFiles), count(rng, opts.ConfigFiles)) {
+		file := configFiles[idx]
+		ctx.Configurations.Files = append(ctx.Configurations.Files, types.ConfigurationFile{
+			Name:      file.name,
+			MountPath: file.path,
+			Content:   file.content,
+		})
+	}
+	for _, idx := range sample(rng, len(secretEnvNames), count(rng, opts.SecretEnvs)) {
+		envName := secretEnvNames[idx]
+		ctx.Secrets.Envs = append(ctx.Secrets.Envs, types.SecretEnv{
+			Name:     envName,
+			ValueRef: pick(rng, secretStores) + "/" + strings.ToLower(strings.ReplaceAll(envName, "_", "-")),
+		})
+	}
+	for _, idx := range sample(rng, len(secretFiles), count(rng, opts.SecretFiles)) {
+		file := secretFiles[idx]
+		ctx.Secrets.Files = append(ctx.Secrets.Files, types.SecretFile{
+			Name:      file.name,
+			MountPath: file.path,
+			ValueRef:  fmt.Sprintf("r%04d", rng.Intn(10000)),
+		})
+	}
+
+	return ctx
+}
+
+func count(rng *rand.Rand, requested int) int {
+	switch {
+	case requested < 0:
+		return 0
+	case requested == 0:
+		return rng.Intn(4)
+	default:
+		return requested
+	}
+}
+
+// sample picks n distinct indexes from [0, size) in a stable order.
+func sample(rng *rand.Rand, size, n int) []int {
+	if n > size {
+		n = size
+	}
+	return rng.Perm(size)[:n]
+}
+
+func pick(rng *rand.Rand, values []string) string {
+	return values[rng.Intn(len(values))]
+}
@@ -0,0 +1,42 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFakeAdditionalContextIsSeedStable(t *testing.T) {
+	t.Parallel()
+
+	opts := ContextOptions{ComponentName: "checkout", ConfigEnvs: 3, SecretFiles: 2}
+	first := FakeAdditionalContext(42, opts)
+	second := FakeAdditionalContext(42, opts)
+	if diff := cmp.Diff(first, second); diff != "" {
+		t.Fatalf("same seed produced different contexts (-first +second):\n%s", diff)
+	}
+
+	if len(first.Configurations.Envs) != 3 || len(first.Secrets.Files) != 2 {
+		t.Fatalf("requested counts not honoured: %+v", first)
+	}
+	if first.Build.Image == "" {
+		t.Fatalf("expected a build image")
+	}
+
+	other := FakeAdditionalContext(7, opts)
+	if cmp.Equal(first, other) {
+		t.Fatalf("different seeds produced identical contexts")
+	}
+}
+
+func TestFakeAdditionalContextNegativeCountsDisableSections(t *testing.T) {
+	t.Parallel()
+
+	ctx := FakeAdditionalContext(1, ContextOptions{ConfigEnvs: -1, ConfigFiles: -1, SecretEnvs: -1, SecretFiles: -1, DisableBuild: true})
+	if len(ctx.Configurations.Envs)+len(ctx.Configurations.Files)+len(ctx.Secrets.Envs)+len(ctx.Secrets.Files) != 0 {
+		t.Fatalf("expected empty configurations and secrets, got %+v", ctx)
+	}
+	if ctx.Build.Image != "" {
+		t.Fatalf("expected no build image, got %q", ctx.Build.Image)
+	}
+}
@@ -0,0 +1,196 @@
+// Package transport is the HTTP layer shared by loaders that fetch from registries and other
+// remote sources. It adds per-attempt timeouts and retries with exponential backoff so transient
+// failures do not fail renders.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Options configures retries. Zero fields take the DefaultOptions values.
+type Options struct {
+	// Attempts is the total number of tries per request, including the first; 1 disables retries.
+	Attempts int `yaml:"attempts,omitempty"`
+	// InitialBackoff is the wait before the first retry; it doubles for every further retry.
+	InitialBackoff time.Duration `yaml:"initialBackoff,omitempty"`
+	// MaxBackoff caps the wait between attempts, including waits requested through Retry-After.
+	MaxBackoff time.Duration `yaml:"maxBackoff,omitempty"`
+	// Timeout bounds every attempt, including reading the response body.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// DefaultOptions tries every request up to four times over roughly four seconds.
+func DefaultOptions() Options {
+	return Options{Attempts: 4, InitialBackoff: 500 * time.Millisecond, MaxBackoff: 5 * time.Second, Timeout: 30 * time.Second}
+}
+
+func (o Options) withDefaults() Options {
+	defaults := DefaultOptions()
+	if o.Attempts <= 0 {
+		o.Attempts = defaults.Attempts
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = defaults.InitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = defaults.MaxBackoff
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaults.Timeout
+	}
+	return o
+}
+
+// NewClient returns an HTTP client that retries through Retry.
+func NewClient(opts Options) *http.Client {
+	return &http.Client{Transport: &Retry{Base: http.DefaultTransport, Options: opts}}
+}
+
+// Retry is an http.RoundTripper that retries connection errors, timeouts, 429 and 5xx responses
+// (except 501) with exponential backoff and jitter, honoring Retry-After. Requests whose body
+// cannot be replayed are sent once.
+type Retry struct {
+	// Base sends the requests; nil uses http.DefaultTransport.
+	Base    http.RoundTripper
+	Options Options
+	// sleep waits between attempts; tests replace it.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+var _ http.RoundTripper = (*Retry)(nil)
+
+// RoundTrip implements http.RoundTripper.
+func (t *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	opts := t.Options.withDefaults()
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	sleep := t.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	attempts := opts.Attempts
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq, cancel, err := prepareAttempt(req, opts.Timeout, attempt)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := base.RoundTrip(attemptReq)
+		if attempt >= attempts || req.Context().Err() != nil || !retryable(resp, err) {
+			if err != nil {
+				cancel()
+				if attempt > 1 {
+					return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+				}
+				return nil, err
+			}
+			resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+			return resp, nil
+		}
+
+		wait := backoff(opts, attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = min(after, opts.MaxBackoff)
+			}
+			// Drain so the connection can be reused.
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		cancel()
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// prepareAttempt clones req with a per-attempt deadline and, for retries, a fresh body.
+func prepareAttempt(req *http.Request, timeout time.Duration, attempt int) (*http.Request, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	attemptReq := req.Clone(ctx)
+	if attempt > 1 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, fmt.Errorf("failed to replay request body: %w", err)
+		}
+		attemptReq.Body = body
+	}
+	return attemptReq, cancel, nil
+}
+
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		// Errors of the caller's own context are checked by RoundTrip; everything else, including
+		// the per-attempt deadline, is worth another try.
+		return !errors.Is(err, context.Canceled)
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusNotImplemented:
+		return false
+	default:
+		return resp.StatusCode >= 500
+	}
+}
+
+// backoff returns InitialBackoff doubled per previous retry, capped at MaxBackoff, with up to 20%
+// jitter so parallel CI jobs do not retry in lockstep.
+func backoff(opts Options, attempt int) time.Duration {
+	wait := opts.InitialBackoff
+	for i := 1; i < attempt && wait < opts.MaxBackoff; i++ {
+		wait *= 2
+	}
+	wait = min(wait, opts.MaxBackoff)
+	return wait - time.Duration(rand.Int63n(int64(wait)/5+1))
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelBody releases the attempt's context once the caller closes the response body.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
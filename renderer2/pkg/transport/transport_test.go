@@ -0,0 +1,146 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		body         io.Reader
+		wantStatus   int
+		wantAttempts int32
+		wantWaits    []time.Duration
+	}{
+		{name: "success", statuses: []int{200}, wantStatus: 200, wantAttempts: 1},
+		{name: "recovers from 503 and 429", statuses: []int{503, 429, 200}, wantStatus: 200, wantAttempts: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "client errors are final", statuses: []int{404, 200}, wantStatus: 404, wantAttempts: 1},
+		{name: "not implemented is final", statuses: []int{501, 200}, wantStatus: 501, wantAttempts: 1},
+		{name: "gives up after attempts", statuses: []int{502, 502, 502, 200}, wantStatus: 502, wantAttempts: 3,
+			wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "bodies that cannot be replayed are sent once", statuses: []int{503, 200}, body: io.MultiReader(strings.NewReader("x")),
+			wantStatus: 503, wantAttempts: 1},
+		{name: "replayable bodies are retried", statuses: []int{503, 200}, body: strings.NewReader("x"),
+			wantStatus: 200, wantAttempts: 2, wantWaits: []time.Duration{time.Second}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if r.Body != nil {
+					if body, _ := io.ReadAll(r.Body); tt.body != nil && string(body) != "x" {
+						t.Errorf("attempt %d sent body %q, want x", n, body)
+					}
+				}
+				w.WriteHeader(tt.statuses[n-1])
+			}))
+			defer server.Close()
+
+			var waits []time.Duration
+			retry := &Retry{
+				Options: Options{Attempts: 3, InitialBackoff: time.Second, MaxBackoff: 10 * time.Second},
+				sleep: func(_ context.Context, d time.Duration) error {
+					waits = append(waits, d)
+					return nil
+				},
+			}
+			req, err := http.NewRequest(http.MethodPost, server.URL, tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := (&http.Client{Transport: retry}).Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			// Waits carry up to 20% jitter below the nominal backoff.
+			if len(waits) != len(tt.wantWaits) {
+				t.Fatalf("waits = %v, want about %v", waits, tt.wantWaits)
+			}
+			for i, wait := range waits {
+				if wait > tt.wantWaits[i] || wait < tt.wantWaits[i]*4/5 {
+					t.Errorf("wait %d = %v, want within 20%% below %v", i, wait, tt.wantWaits[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	retry := &Retry{
+		Options: Options{MaxBackoff: 30 * time.Second},
+		sleep: func(_ context.Context, d time.Duration) error {
+			waits = append(waits, d)
+			return nil
+		},
+	}
+	resp, err := (&http.Client{Transport: retry}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if diff := cmp.Diff([]time.Duration{30 * time.Second}, waits); diff != "" {
+		t.Errorf("waits mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestRetryStopsWhenContextIsDone(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	retry := &Retry{sleep: func(ctx context.Context, _ time.Duration) error {
+		cancel()
+		return ctx.Err()
+	}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&http.Client{Transport: retry}).Do(req); err == nil {
+		t.Fatalf("Do() succeeded after the context was cancelled, want error")
+	}
+}
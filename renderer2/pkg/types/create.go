@@ -0,0 +1,77 @@
+package types
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CreateTemplate is a resource an addon adds to the render. Like ResourceTemplate it can be
+// conditional (IncludeWhen) or repeated per item (ForEach, bound to Var, default "item"). In YAML a
+// create without a template key, or with apiVersion or kind, is shorthand for {template: ...}.
+type CreateTemplate struct {
+	ID          string `yaml:"id,omitempty"`
+	IncludeWhen string `yaml:"includeWhen,omitempty"`
+	ForEach     string `yaml:"forEach,omitempty"`
+	Var         string `yaml:"var,omitempty"`
+	// Template is an object template or a single expression rendering to an object.
+	Template any `yaml:"template"`
+}
+
+// UnmarshalYAML accepts the shorthand in addition to the mapping form.
+func (c *CreateTemplate) UnmarshalYAML(node *yaml.Node) error {
+	if !isCreateWrapper(node) {
+		var template any
+		if err := node.Decode(&template); err != nil {
+			return err
+		}
+		*c = CreateTemplate{Template: template}
+		return nil
+	}
+
+	type plain CreateTemplate
+	var decoded plain
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	*c = CreateTemplate(decoded)
+	return nil
+}
+
+// MarshalYAML writes the shorthand when only Template is set.
+func (c CreateTemplate) MarshalYAML() (any, error) {
+	if c.isShorthand() {
+		return c.Template, nil
+	}
+	type plain CreateTemplate
+	return plain(c), nil
+}
+
+// MarshalJSON mirrors MarshalYAML so digests of addons using the shorthand do not change.
+func (c CreateTemplate) MarshalJSON() ([]byte, error) {
+	if c.isShorthand() {
+		return json.Marshal(c.Template)
+	}
+	type plain CreateTemplate
+	return json.Marshal(plain(c))
+}
+
+func (c CreateTemplate) isShorthand() bool {
+	return c.ID == "" && c.IncludeWhen == "" && c.ForEach == "" && c.Var == ""
+}
+
+func isCreateWrapper(node *yaml.Node) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	wrapper := false
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		switch node.Content[i].Value {
+		case "apiVersion", "kind":
+			return false
+		case "template":
+			wrapper = true
+		}
+	}
+	return wrapper
+}
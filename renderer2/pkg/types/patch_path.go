@@ -0,0 +1,54 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PathSegment is one element of a structured patch path. Exactly one field is set. In YAML a plain
+// string is shorthand for {key: ...} and a plain integer for {index: ...}.
+type PathSegment struct {
+	// Key selects an object field. It may contain ${...} expressions.
+	Key string `yaml:"key,omitempty"`
+	// Index selects an array element.
+	Index *int `yaml:"index,omitempty"`
+	// Append targets the end of an array (JSON Patch "-").
+	Append bool `yaml:"append,omitempty"`
+	// Filter selects every array element whose field equals a value.
+	Filter *PathFilter `yaml:"filter,omitempty"`
+	// Anchor splices in the segments of a named anchor declared on the PatchSpec.
+	Anchor string `yaml:"anchor,omitempty"`
+}
+
+// PathFilter matches array elements whose (dotted) Field equals Equals. Equals may contain ${...}
+// expressions.
+type PathFilter struct {
+	Field  string `yaml:"field"`
+	Equals string `yaml:"equals"`
+}
+
+// UnmarshalYAML accepts the scalar shorthands in addition to the mapping form.
+func (s *PathSegment) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		if node.Tag == "!!int" {
+			index, err := strconv.Atoi(node.Value)
+			if err != nil {
+				return fmt.Errorf("invalid path index %q: %w", node.Value, err)
+			}
+			*s = PathSegment{Index: &index}
+			return nil
+		}
+		*s = PathSegment{Key: node.Value}
+		return nil
+	}
+
+	type plain PathSegment
+	var decoded plain
+	if err := node.Decode(&decoded); err != nil {
+		return err
+	}
+	*s = PathSegment(decoded)
+	return nil
+}
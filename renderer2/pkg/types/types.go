@@ -17,15 +17,71 @@ type ComponentTypeDefinition struct {
 }
 
 type ComponentTypeDefinitionSpec struct {
-	WorkloadType string             `yaml:"workloadType"`
-	Schema       Schema             `yaml:"schema"`
-	Resources    []ResourceTemplate `yaml:"resources"`
+	// Version is the semantic version of this release of the definition; Components pin it with
+	// componentTypeRelease. It is unrelated to Versions, the parameter schemas a release serves.
+	Version      string `yaml:"version,omitempty"`
+	WorkloadType string `yaml:"workloadType"`
+	Schema       Schema `yaml:"schema"`
+	// CommonMetadata is merged into the metadata of every base resource before addons apply.
+	CommonMetadata CommonMetadata `yaml:"commonMetadata,omitempty"`
+	// Fragments are named template snippets resource templates render with fragment(name, args).
+	Fragments map[string]any      `yaml:"fragments,omitempty"`
+	Resources []ResourceTemplate  `yaml:"resources"`
+	Artifacts []ArtifactTemplate  `yaml:"artifacts,omitempty"`
+	Versions  []DefinitionVersion `yaml:"versions,omitempty"`
+}
+
+// CommonMetadata holds label and annotation templates shared by all resources of a definition.
+// Values may contain expressions; keys a resource template sets itself take precedence.
+type CommonMetadata struct {
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Template returns the labels and annotations as a metadata template, or nil when there are none.
+func (m CommonMetadata) Template() map[string]any {
+	tmpl := map[string]any{}
+	for field, values := range map[string]map[string]string{"labels": m.Labels, "annotations": m.Annotations} {
+		if len(values) == 0 {
+			continue
+		}
+		entries := make(map[string]any, len(values))
+		for key, value := range values {
+			entries[key] = value
+		}
+		tmpl[field] = entries
+	}
+	if len(tmpl) == 0 {
+		return nil
+	}
+	return tmpl
+}
+
+// DefinitionVersion declares one schema version of a ComponentTypeDefinition. Exactly one version
+// is the storage version; Components written against other versions are converted into it.
+type DefinitionVersion struct {
+	Name        string              `yaml:"name"`
+	Served      bool                `yaml:"served"`
+	Storage     bool                `yaml:"storage,omitempty"`
+	Schema      Schema              `yaml:"schema"`
+	Resources   []ResourceTemplate  `yaml:"resources,omitempty"`
+	Conversions []VersionConversion `yaml:"conversions,omitempty"`
+}
+
+// VersionConversion maps parameters written against another version into the enclosing version.
+// Parameters is a template rendered with the source parameters bound to `spec`.
+type VersionConversion struct {
+	From       string         `yaml:"from"`
+	Parameters map[string]any `yaml:"parameters"`
 }
 
 type Schema struct {
 	Types        map[string]any `yaml:"types,omitempty"`
 	Parameters   map[string]any `yaml:"parameters,omitempty"`
 	EnvOverrides map[string]any `yaml:"envOverrides,omitempty"`
+	// SecurityExemptions maps a hardening rule (runAsNonRoot, capabilities, seccompProfile) to the
+	// boolean parameter path a Component sets to opt out of it.
+	SecurityExemptions map[string]string `yaml:"securityExemptions,omitempty"`
 }
 
 type ResourceTemplate struct {
@@ -34,6 +90,27 @@ type ResourceTemplate struct {
 	ForEach     string         `yaml:"forEach,omitempty"`
 	Var         string         `yaml:"var,omitempty"`
 	Template    map[string]any `yaml:"template"`
+	// FieldsWhen maps JSON pointers into the rendered resource to conditions; a field whose
+	// condition is false is dropped after rendering, e.g. "/spec/replicas": ${!spec.autoscaling}.
+	FieldsWhen map[string]string `yaml:"fieldsWhen,omitempty"`
+	// DependsOn names templates whose resources must be applied before the resources of this one.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// ApplyPolicy selects how appliers write the resources of this template to the cluster:
+	// server-side, force-conflicts, replace or skip-if-exists (see pipeline.ApplyPolicy).
+	ApplyPolicy string `yaml:"applyPolicy,omitempty"`
+}
+
+// ArtifactTemplate renders a non-Kubernetes file (nginx.conf, dashboard JSON, ...) that is written
+// alongside the manifests. Path and Content may contain expressions. String content is written as
+// rendered; structured content is encoded according to Format ("json" by default, or "yaml").
+type ArtifactTemplate struct {
+	ID          string `yaml:"id"`
+	IncludeWhen string `yaml:"includeWhen,omitempty"`
+	ForEach     string `yaml:"forEach,omitempty"`
+	Var         string `yaml:"var,omitempty"`
+	Path        string `yaml:"path"`
+	Content     any    `yaml:"content"`
+	Format      string `yaml:"format,omitempty"`
 }
 
 // Addon augments rendered workloads with additional resources or patches.
@@ -45,18 +122,36 @@ type Addon struct {
 }
 
 type AddonSpec struct {
-	DisplayName   string      `yaml:"displayName,omitempty"`
-	Schema        Schema      `yaml:"schema"`
-	Creates       []any       `yaml:"creates,omitempty"`
-	Patches       []PatchSpec `yaml:"patches,omitempty"`
-	Documentation string      `yaml:"documentation,omitempty"`
+	// Version is the semantic version of this release of the addon; instances pin it with version.
+	Version       string             `yaml:"version,omitempty"`
+	DisplayName   string             `yaml:"displayName,omitempty"`
+	Schema        Schema             `yaml:"schema"`
+	Creates       []CreateTemplate   `yaml:"creates,omitempty"`
+	Patches       []PatchSpec        `yaml:"patches,omitempty"`
+	Artifacts     []ArtifactTemplate `yaml:"artifacts,omitempty"`
+	Documentation string             `yaml:"documentation,omitempty"`
+	// DependsOn names addons that must be applied before this one when a Component attaches both.
+	DependsOn []string `yaml:"dependsOn,omitempty"`
+	// Stability is the addon's release channel; empty means stable.
+	Stability Stability `yaml:"stability,omitempty"`
 }
 
+// Stability is the release channel of an addon.
+type Stability string
+
+const (
+	StabilityAlpha  Stability = "alpha"
+	StabilityBeta   Stability = "beta"
+	StabilityStable Stability = "stable"
+)
+
 type PatchSpec struct {
-	ForEach    string               `yaml:"forEach,omitempty"`
-	Var        string               `yaml:"var,omitempty"`
-	Target     TargetSpec           `yaml:"target"`
-	Operations []JSONPatchOperation `yaml:"operations"`
+	ForEach string     `yaml:"forEach,omitempty"`
+	Var     string     `yaml:"var,omitempty"`
+	Target  TargetSpec `yaml:"target"`
+	// Anchors name path prefixes that operations reuse through {anchor: name} segments.
+	Anchors    map[string][]PathSegment `yaml:"anchors,omitempty"`
+	Operations []JSONPatchOperation     `yaml:"operations"`
 }
 
 type TargetSpec struct {
@@ -68,9 +163,15 @@ type TargetSpec struct {
 }
 
 type JSONPatchOperation struct {
-	Op    string `yaml:"op"`
-	Path  string `yaml:"path"`
-	Value any    `yaml:"value,omitempty"`
+	Op   string `yaml:"op"`
+	Path string `yaml:"path,omitempty"`
+	// Segments is the structured alternative to Path; exactly one of them is set.
+	Segments []PathSegment `yaml:"segments,omitempty"`
+	Value    any           `yaml:"value,omitempty"`
+	// From is the JSON pointer move and copy read from.
+	From string `yaml:"from,omitempty"`
+	// IfMissing governs out-of-bounds indices and missing parents: error (default), skip, or append.
+	IfMissing string `yaml:"ifMissing,omitempty"`
 }
 
 type Component struct {
@@ -81,16 +182,21 @@ type Component struct {
 }
 
 type ComponentSpec struct {
-	ComponentType string          `yaml:"componentType"`
-	Parameters    map[string]any  `yaml:"parameters,omitempty"`
-	Addons        []AddonInstance `yaml:"addons,omitempty"`
-	Build         BuildSpec       `yaml:"build,omitempty"`
+	ComponentType        string `yaml:"componentType"`
+	ComponentTypeVersion string `yaml:"componentTypeVersion,omitempty"`
+	// ComponentTypeRelease pins the definition's spec.version to a semantic version or range.
+	ComponentTypeRelease string          `yaml:"componentTypeRelease,omitempty"`
+	Parameters           map[string]any  `yaml:"parameters,omitempty"`
+	Addons               []AddonInstance `yaml:"addons,omitempty"`
+	Build                BuildSpec       `yaml:"build,omitempty"`
 }
 
 type AddonInstance struct {
-	Name       string         `yaml:"name"`
-	InstanceID string         `yaml:"instanceId"`
-	Config     map[string]any `yaml:"config,omitempty"`
+	Name       string `yaml:"name"`
+	InstanceID string `yaml:"instanceId"`
+	// Version pins the addon's spec.version to a semantic version or range.
+	Version string         `yaml:"version,omitempty"`
+	Config  map[string]any `yaml:"config,omitempty"`
 }
 
 type BuildSpec struct {
@@ -118,6 +224,36 @@ type EnvSettingsSpec struct {
 	AddonOverrides map[string]map[string]any `yaml:"addonOverrides,omitempty"`
 	Owner          *ComponentRef             `yaml:"owner,omitempty"`
 	ComponentRef   *ComponentRef             `yaml:"componentRef,omitempty"`
+	Availability   *AvailabilitySettings     `yaml:"availability,omitempty"`
+	Platform       *PlatformSettings         `yaml:"platform,omitempty"`
+}
+
+// PlatformSettings describes the nodes of the target cluster, exposed to templates as `platform`
+// so one definition can pick per-architecture images and scheduling constraints. Empty fields mean
+// unconstrained.
+type PlatformSettings struct {
+	// OS is a kubernetes.io/os value such as linux or windows.
+	OS string `yaml:"os,omitempty"`
+	// Arch is a kubernetes.io/arch value such as amd64 or arm64.
+	Arch string `yaml:"arch,omitempty"`
+}
+
+// AvailabilitySettings opts an environment into platform-managed PodDisruptionBudgets and
+// topology spread constraints for workloads running at least MinReplicas replicas.
+type AvailabilitySettings struct {
+	MinReplicas         int                   `yaml:"minReplicas,omitempty"`
+	PodDisruptionBudget *PodDisruptionPolicy  `yaml:"podDisruptionBudget,omitempty"`
+	TopologySpread      *TopologySpreadPolicy `yaml:"topologySpread,omitempty"`
+}
+
+type PodDisruptionPolicy struct {
+	MaxUnavailable string `yaml:"maxUnavailable,omitempty"`
+}
+
+type TopologySpreadPolicy struct {
+	TopologyKey       string `yaml:"topologyKey,omitempty"`
+	MaxSkew           int    `yaml:"maxSkew,omitempty"`
+	WhenUnsatisfiable string `yaml:"whenUnsatisfiable,omitempty"`
 }
 
 type AdditionalContext struct {
@@ -166,6 +302,10 @@ type SecretFile struct {
 type ComponentRef struct {
 	Name      string `yaml:"name"`
 	Namespace string `yaml:"namespace,omitempty"`
+	// APIVersion, Kind and UID are only needed to emit real ownerReferences.
+	APIVersion string `yaml:"apiVersion,omitempty"`
+	Kind       string `yaml:"kind,omitempty"`
+	UID        string `yaml:"uid,omitempty"`
 }
 
 type Workload struct {
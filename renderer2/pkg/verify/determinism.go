@@ -0,0 +1,117 @@
+package verify
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/diff"
+	"gopkg.in/yaml.v3"
+)
+
+// RenderFunc renders one pass of the inputs under test. run is the zero-based attempt number;
+// perturb should be applied to map-shaped inputs (parameters, overrides) before rendering.
+type RenderFunc func(run int, perturb func(any) any) ([]map[string]any, error)
+
+// Options configures the determinism check.
+type Options struct {
+	// Runs is the number of renders to compare (minimum 2).
+	Runs int
+	// Shuffle makes perturb rebuild input maps with a random key insertion order so that code
+	// depending on map iteration order sees a different layout on each run.
+	Shuffle bool
+	// Seed drives the shuffle; zero uses a fixed default so failures are reproducible.
+	Seed int64
+}
+
+// NondeterminismError reports the first run whose output differed from the initial render.
+type NondeterminismError struct {
+	Run  int
+	Diff string
+}
+
+func (e *NondeterminismError) Error() string {
+	return fmt.Sprintf("render output of run %d differs from run 0:\n%s", e.Run, e.Diff)
+}
+
+// Determinism renders the same inputs repeatedly and asserts byte-identical serialized output.
+func Determinism(render RenderFunc, opts Options) error {
+	if opts.Runs < 2 {
+		opts.Runs = 2
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = 1
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var baseline []byte
+	for run := 0; run < opts.Runs; run++ {
+		perturb := func(v any) any { return v }
+		if opts.Shuffle {
+			perturb = func(v any) any { return ShuffleMaps(v, rng) }
+		}
+
+		resources, err := render(run, perturb)
+		if err != nil {
+			return fmt.Errorf("run %d failed: %w", run, err)
+		}
+
+		output, err := Serialize(resources)
+		if err != nil {
+			return fmt.Errorf("run %d: %w", run, err)
+		}
+
+		if run == 0 {
+			baseline = output
+			continue
+		}
+		if !bytes.Equal(baseline, output) {
+			return &NondeterminismError{
+				Run:  run,
+				Diff: diff.Lines(string(baseline), string(output), 3),
+			}
+		}
+	}
+	return nil
+}
+
+// Serialize encodes resources as a multi-document YAML stream.
+func Serialize(resources []map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	for _, resource := range resources {
+		if err := encoder.Encode(resource); err != nil {
+			return nil, fmt.Errorf("failed to encode resource: %w", err)
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ShuffleMaps returns a deep copy of v whose maps were populated in a random key order.
+func ShuffleMaps(v any, rng *rand.Rand) any {
+	switch typed := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(typed))
+		for k := range typed {
+			keys = append(keys, k)
+		}
+		rng.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		result := make(map[string]any, len(typed))
+		for _, k := range keys {
+			result[k] = ShuffleMaps(typed[k], rng)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, item := range typed {
+			result[i] = ShuffleMaps(item, rng)
+		}
+		return result
+	default:
+		return typed
+	}
+}
@@ -0,0 +1,44 @@
+package verify
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDeterminismPassesForStableRender(t *testing.T) {
+	t.Parallel()
+
+	render := func(_ int, perturb func(any) any) ([]map[string]any, error) {
+		params := perturb(map[string]any{"b": "2", "a": "1", "c": map[string]any{"z": 1, "y": 2}}).(map[string]any)
+		return []map[string]any{{"kind": "ConfigMap", "data": params}}, nil
+	}
+
+	if err := Determinism(render, Options{Runs: 5, Shuffle: true}); err != nil {
+		t.Fatalf("Determinism() error = %v", err)
+	}
+}
+
+func TestDeterminismReportsDiff(t *testing.T) {
+	t.Parallel()
+
+	render := func(run int, _ func(any) any) ([]map[string]any, error) {
+		value := "stable"
+		if run == 2 {
+			value = "drifted"
+		}
+		return []map[string]any{{"kind": "ConfigMap", "data": map[string]any{"key": value}}}, nil
+	}
+
+	err := Determinism(render, Options{Runs: 3})
+	var nondeterministic *NondeterminismError
+	if !errors.As(err, &nondeterministic) {
+		t.Fatalf("expected NondeterminismError, got %v", err)
+	}
+	if nondeterministic.Run != 2 {
+		t.Fatalf("Run = %d, want 2", nondeterministic.Run)
+	}
+	if !strings.Contains(nondeterministic.Diff, "-    key: stable") || !strings.Contains(nondeterministic.Diff, "+    key: drifted") {
+		t.Fatalf("unexpected diff:\n%s", nondeterministic.Diff)
+	}
+}
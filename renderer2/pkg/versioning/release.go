@@ -0,0 +1,129 @@
+package versioning
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// A release is the semantic version in spec.version of a ComponentTypeDefinition or Addon. Unlike
+// spec.versions, which name the parameter schemas a definition serves, releases number the
+// definition itself so several of them can be loaded side by side. Components pin a release or
+// range with spec.componentTypeRelease and addon instances with version, using the range syntax
+// of github.com/blang/semver, e.g. "1.4.2", ">=1.2.0 <2.0.0" or ">=1.0.0 !=1.3.0".
+
+// ParseRelease parses a spec.version. The empty release is unversioned and parses as 0.0.0.
+func ParseRelease(release string) (semver.Version, error) {
+	if release == "" {
+		return semver.Version{}, nil
+	}
+	return semver.ParseTolerant(release)
+}
+
+// CheckRelease validates the spec.version of a definition or addon, what naming it in errors.
+func CheckRelease(what, release string) error {
+	if _, err := ParseRelease(release); err != nil {
+		return fmt.Errorf("%s has invalid spec.version %q: %w", what, release, err)
+	}
+	return nil
+}
+
+// MatchRelease reports whether release satisfies pin. An empty pin accepts every release; an
+// unversioned release satisfies no other pin.
+func MatchRelease(pin, release string) (bool, error) {
+	if pin == "" {
+		return true, nil
+	}
+	constraint, err := semver.ParseRange(pin)
+	if err != nil {
+		return false, fmt.Errorf("invalid release pin %q: %w", pin, err)
+	}
+	if release == "" {
+		return false, nil
+	}
+	version, err := ParseRelease(release)
+	if err != nil {
+		return false, err
+	}
+	return constraint(version), nil
+}
+
+// SelectRelease returns the index of the highest of releases that satisfies pin, what naming the
+// definition or addon in errors. Unversioned releases rank below every versioned one. Prereleases
+// are skipped unless pin names a prerelease, or pin is empty and there is nothing else.
+func SelectRelease(what, pin string, releases []string) (int, error) {
+	best, err := selectRelease(pin, releases, strings.Contains(pin, "-"))
+	if err == nil && best == -1 && pin == "" {
+		best, err = selectRelease(pin, releases, true)
+	}
+	if err != nil {
+		return -1, fmt.Errorf("%s: %w", what, err)
+	}
+	if best == -1 {
+		return -1, fmt.Errorf("%s has no release matching %s (available: %s)", what, pin, describeReleases(releases))
+	}
+	return best, nil
+}
+
+func selectRelease(pin string, releases []string, prereleases bool) (int, error) {
+	best := -1
+	var bestVersion semver.Version
+	for i, release := range releases {
+		ok, err := MatchRelease(pin, release)
+		if err != nil {
+			return -1, err
+		}
+		version, _ := ParseRelease(release)
+		if !ok || (len(version.Pre) > 0 && !prereleases) {
+			continue
+		}
+		if best == -1 || version.GT(bestVersion) {
+			best, bestVersion = i, version
+		}
+	}
+	return best, nil
+}
+
+// CheckDefinitionRelease fails when component pins a release of its definition that ctd is not.
+func CheckDefinitionRelease(ctd *types.ComponentTypeDefinition, component *types.Component) error {
+	pin := component.Spec.ComponentTypeRelease
+	ok, err := MatchRelease(pin, ctd.Spec.Version)
+	if err != nil {
+		return fmt.Errorf("component %s: %w", component.Metadata.Name, err)
+	}
+	if !ok {
+		return fmt.Errorf("component %s requires %s %s, got %s", component.Metadata.Name, ctd.Metadata.Name, pin, describeReleases([]string{ctd.Spec.Version}))
+	}
+	return nil
+}
+
+// CheckAddonRelease fails when instance pins a release of its addon that addon is not.
+func CheckAddonRelease(addon *types.Addon, instance types.AddonInstance) error {
+	ok, err := MatchRelease(instance.Version, addon.Spec.Version)
+	if err != nil {
+		return fmt.Errorf("addon instance %s: %w", instance.InstanceID, err)
+	}
+	if !ok {
+		return fmt.Errorf("addon instance %s requires %s %s, got %s", instance.InstanceID, addon.Metadata.Name, instance.Version, describeReleases([]string{addon.Spec.Version}))
+	}
+	return nil
+}
+
+// describeReleases lists releases in ascending order for error messages.
+func describeReleases(releases []string) string {
+	sorted := append([]string(nil), releases...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := ParseRelease(sorted[i])
+		b, _ := ParseRelease(sorted[j])
+		return a.LT(b)
+	})
+	for i, release := range sorted {
+		if release == "" {
+			sorted[i] = "unversioned"
+		}
+	}
+	return strings.Join(sorted, ", ")
+}
@@ -0,0 +1,81 @@
+package versioning
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+func TestSelectRelease(t *testing.T) {
+	t.Parallel()
+
+	releases := []string{"1.4.0", "", "2.0.0-rc.1", "1.10.2", "2.1.0"}
+	tests := []struct {
+		pin     string
+		want    string
+		wantErr string
+	}{
+		{pin: "", want: "2.1.0"},
+		{pin: "1.4.0", want: "1.4.0"},
+		{pin: ">=1.0.0 <2.0.0", want: "1.10.2"},
+		{pin: ">=1.0.0 !=1.10.2 <2.0.0", want: "1.4.0"},
+		{pin: "<1.5.0 || >=2.1.0", want: "2.1.0"},
+		{pin: ">=2.0.0-rc.0 <2.1.0", want: "2.0.0-rc.1"},
+		{pin: ">=3.0.0", wantErr: "definition web has no release matching >=3.0.0 (available: unversioned, 1.4.0, 1.10.2, 2.0.0-rc.1, 2.1.0)"},
+		{pin: "latest", wantErr: `definition web: invalid release pin "latest"`},
+	}
+	for _, tt := range tests {
+		i, err := SelectRelease("definition web", tt.pin, releases)
+		if tt.wantErr != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("SelectRelease(%q) error = %v, want %q", tt.pin, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("SelectRelease(%q) error = %v", tt.pin, err)
+		}
+		if releases[i] != tt.want {
+			t.Errorf("SelectRelease(%q) = %s, want %s", tt.pin, releases[i], tt.want)
+		}
+	}
+
+	if i, err := SelectRelease("addon pvc", "", []string{""}); err != nil || i != 0 {
+		t.Errorf("SelectRelease() of an unversioned addon = %d, %v, want 0", i, err)
+	}
+	if i, err := SelectRelease("addon pvc", "", []string{"0.9.0-beta.1"}); err != nil || i != 0 {
+		t.Errorf("SelectRelease() of a single prerelease = %d, %v, want 0", i, err)
+	}
+}
+
+func TestPrepareChecksRelease(t *testing.T) {
+	t.Parallel()
+
+	ctd := &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec:     types.ComponentTypeDefinitionSpec{Version: "1.4.0"},
+	}
+	tests := []struct {
+		pin     string
+		version string
+		wantErr string
+	}{
+		{pin: ">=1.2.0 <2.0.0", version: "1.4.0"},
+		{pin: ">=2.0.0", version: "1.4.0", wantErr: "component api requires web >=2.0.0, got 1.4.0"},
+		{pin: "1.0.0", wantErr: "component api requires web 1.0.0, got unversioned"},
+		{version: ""},
+	}
+	for _, tt := range tests {
+		ctd.Spec.Version = tt.version
+		component := &types.Component{Metadata: types.Metadata{Name: "api"}, Spec: types.ComponentSpec{ComponentTypeRelease: tt.pin}}
+		_, _, err := Prepare(template.NewEngine(), ctd, component)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("Prepare(%q, %q) error = %v", tt.pin, tt.version, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("Prepare(%q, %q) error = %v, want %q", tt.pin, tt.version, err, tt.wantErr)
+		}
+	}
+}
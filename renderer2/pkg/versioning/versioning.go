@@ -0,0 +1,225 @@
+package versioning
+
+import (
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// StorageVersion returns the version marked as storage, or nil when the definition is unversioned.
+func StorageVersion(ctd *types.ComponentTypeDefinition) (*types.DefinitionVersion, error) {
+	if len(ctd.Spec.Versions) == 0 {
+		return nil, nil
+	}
+
+	var storage *types.DefinitionVersion
+	for i := range ctd.Spec.Versions {
+		version := &ctd.Spec.Versions[i]
+		if !version.Storage {
+			continue
+		}
+		if storage != nil {
+			return nil, fmt.Errorf("definition %s declares multiple storage versions (%s, %s)", ctd.Metadata.Name, storage.Name, version.Name)
+		}
+		storage = version
+	}
+	if storage == nil {
+		return nil, fmt.Errorf("definition %s declares versions but none is marked storage", ctd.Metadata.Name)
+	}
+	return storage, nil
+}
+
+// FindVersion looks up a declared version by name.
+func FindVersion(ctd *types.ComponentTypeDefinition, name string) (*types.DefinitionVersion, bool) {
+	for i := range ctd.Spec.Versions {
+		if ctd.Spec.Versions[i].Name == name {
+			return &ctd.Spec.Versions[i], true
+		}
+	}
+	return nil, false
+}
+
+// ResolveVersion returns a flattened copy of the definition whose Schema and Resources come from
+// the named version. An empty name selects the storage version. Unversioned definitions are
+// returned unchanged.
+func ResolveVersion(ctd *types.ComponentTypeDefinition, name string) (*types.ComponentTypeDefinition, error) {
+	if len(ctd.Spec.Versions) == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("definition %s does not declare versions (requested %s)", ctd.Metadata.Name, name)
+		}
+		return ctd, nil
+	}
+
+	var version *types.DefinitionVersion
+	if name == "" {
+		storage, err := StorageVersion(ctd)
+		if err != nil {
+			return nil, err
+		}
+		version = storage
+	} else {
+		found, ok := FindVersion(ctd, name)
+		if !ok {
+			return nil, fmt.Errorf("definition %s has no version %s", ctd.Metadata.Name, name)
+		}
+		version = found
+	}
+	if !version.Served {
+		return nil, fmt.Errorf("version %s of definition %s is not served", version.Name, ctd.Metadata.Name)
+	}
+
+	resolved := *ctd
+	resolved.Spec.Schema = version.Schema
+	if len(version.Resources) > 0 {
+		resolved.Spec.Resources = version.Resources
+	}
+	resolved.Spec.Versions = nil
+	return &resolved, nil
+}
+
+// ConvertParameters converts parameters written against version `from` into version `to` using the
+// conversions declared on the target version. When no direct conversion exists the storage version
+// is used as a hub (from → storage → to).
+func ConvertParameters(engine *template.Engine, ctd *types.ComponentTypeDefinition, from, to string, params map[string]any) (map[string]any, error) {
+	if from == to {
+		return params, nil
+	}
+
+	if converted, ok, err := convertDirect(engine, ctd, from, to, params); ok || err != nil {
+		return converted, err
+	}
+
+	storage, err := StorageVersion(ctd)
+	if err != nil {
+		return nil, err
+	}
+	if storage == nil || storage.Name == from || storage.Name == to {
+		return nil, fmt.Errorf("definition %s has no conversion from %s to %s", ctd.Metadata.Name, from, to)
+	}
+
+	hub, ok, err := convertDirect(engine, ctd, from, storage.Name, params)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("definition %s has no conversion from %s to %s", ctd.Metadata.Name, from, storage.Name)
+	}
+	converted, ok, err := convertDirect(engine, ctd, storage.Name, to, hub)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("definition %s has no conversion from %s to %s", ctd.Metadata.Name, storage.Name, to)
+	}
+	return converted, nil
+}
+
+// FirstHop returns the conversion that is applied first when converting from one version to another:
+// the direct conversion when declared, otherwise the conversion into the storage version.
+func FirstHop(ctd *types.ComponentTypeDefinition, from, to string) (*types.VersionConversion, bool) {
+	if conversion, ok := findConversion(ctd, from, to); ok {
+		return conversion, true
+	}
+	storage, err := StorageVersion(ctd)
+	if err != nil || storage == nil {
+		return nil, false
+	}
+	return findConversion(ctd, from, storage.Name)
+}
+
+func findConversion(ctd *types.ComponentTypeDefinition, from, to string) (*types.VersionConversion, bool) {
+	target, ok := FindVersion(ctd, to)
+	if !ok {
+		return nil, false
+	}
+	for i := range target.Conversions {
+		if target.Conversions[i].From == from {
+			return &target.Conversions[i], true
+		}
+	}
+	return nil, false
+}
+
+func convertDirect(engine *template.Engine, ctd *types.ComponentTypeDefinition, from, to string, params map[string]any) (map[string]any, bool, error) {
+	if _, ok := FindVersion(ctd, to); !ok {
+		return nil, false, fmt.Errorf("definition %s has no version %s", ctd.Metadata.Name, to)
+	}
+
+	conversion, ok := findConversion(ctd, from, to)
+	if !ok {
+		return nil, false, nil
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+	rendered, err := engine.Render(conversion.Parameters, map[string]any{"spec": params})
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to convert parameters from %s to %s: %w", from, to, err)
+	}
+	renderedMap, ok := template.RemoveOmittedFields(rendered).(map[string]any)
+	if !ok {
+		return nil, true, fmt.Errorf("conversion from %s to %s must render to an object", from, to)
+	}
+	return renderedMap, true, nil
+}
+
+// RenderVersion returns the name of the version Prepare renders component with, or "" for
+// unversioned definitions.
+func RenderVersion(ctd *types.ComponentTypeDefinition, component *types.Component) (string, error) {
+	if len(ctd.Spec.Versions) == 0 {
+		return "", nil
+	}
+	if requested := component.Spec.ComponentTypeVersion; requested != "" {
+		if version, ok := FindVersion(ctd, requested); ok && version.Served {
+			return requested, nil
+		}
+	}
+	storage, err := StorageVersion(ctd)
+	if err != nil {
+		return "", err
+	}
+	return storage.Name, nil
+}
+
+// Prepare resolves the definition version a Component renders against. Components pinned to a
+// served version render with it directly; Components pinned to an unserved or removed version have
+// their parameters converted into the storage version, which is then used for rendering. Components
+// whose componentTypeRelease ctd does not satisfy are rejected.
+func Prepare(engine *template.Engine, ctd *types.ComponentTypeDefinition, component *types.Component) (*types.ComponentTypeDefinition, *types.Component, error) {
+	if err := CheckDefinitionRelease(ctd, component); err != nil {
+		return nil, nil, err
+	}
+	if len(ctd.Spec.Versions) == 0 {
+		return ctd, component, nil
+	}
+
+	requested := component.Spec.ComponentTypeVersion
+	if requested != "" {
+		if version, ok := FindVersion(ctd, requested); ok && version.Served {
+			resolved, err := ResolveVersion(ctd, requested)
+			return resolved, component, err
+		}
+	}
+
+	storage, err := StorageVersion(ctd)
+	if err != nil {
+		return nil, nil, err
+	}
+	resolved, err := ResolveVersion(ctd, storage.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if requested == "" || requested == storage.Name {
+		return resolved, component, nil
+	}
+
+	params, err := ConvertParameters(engine, ctd, requested, storage.Name, component.Spec.Parameters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("component %s: %w", component.Metadata.Name, err)
+	}
+	converted := *component
+	converted.Spec.Parameters = params
+	converted.Spec.ComponentTypeVersion = storage.Name
+	return resolved, &converted, nil
+}
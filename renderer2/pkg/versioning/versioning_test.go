@@ -0,0 +1,122 @@
+package versioning
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func versionedDefinition() *types.ComponentTypeDefinition {
+	return &types.ComponentTypeDefinition{
+		Metadata: types.Metadata{Name: "web"},
+		Spec: types.ComponentTypeDefinitionSpec{
+			Versions: []types.DefinitionVersion{
+				{
+					Name:   "v1",
+					Served: false,
+					Schema: types.Schema{Parameters: map[string]any{"count": "integer"}},
+				},
+				{
+					Name:    "v2",
+					Served:  true,
+					Storage: true,
+					Schema:  types.Schema{Parameters: map[string]any{"replicas": "integer"}},
+					Resources: []types.ResourceTemplate{
+						{ID: "deployment", Template: map[string]any{"kind": "Deployment"}},
+					},
+					Conversions: []types.VersionConversion{
+						{From: "v1", Parameters: map[string]any{"replicas": "${spec.count}"}},
+					},
+				},
+				{
+					Name:   "v3",
+					Served: true,
+					Schema: types.Schema{Parameters: map[string]any{"scale": map[string]any{"replicas": "integer"}}},
+					Conversions: []types.VersionConversion{
+						{From: "v2", Parameters: map[string]any{"scale": map[string]any{"replicas": "${spec.replicas}"}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPrepareConvertsUnservedVersionToStorage(t *testing.T) {
+	t.Parallel()
+
+	ctd := versionedDefinition()
+	component := &types.Component{
+		Metadata: types.Metadata{Name: "checkout"},
+		Spec: types.ComponentSpec{
+			ComponentTypeVersion: "v1",
+			Parameters:           map[string]any{"count": int64(3)},
+		},
+	}
+
+	resolved, converted, err := Prepare(template.NewEngine(), ctd, component)
+	if err != nil {
+		t.Fatalf("Prepare() error = %v", err)
+	}
+	if len(resolved.Spec.Resources) != 1 || resolved.Spec.Resources[0].ID != "deployment" {
+		t.Fatalf("expected storage version resources, got %+v", resolved.Spec.Resources)
+	}
+	if diff := cmp.Diff(map[string]any{"replicas": int64(3)}, converted.Spec.Parameters); diff != "" {
+		t.Fatalf("converted parameters mismatch (-want +got):\n%s", diff)
+	}
+	if component.Spec.ComponentTypeVersion != "v1" {
+		t.Fatalf("Prepare must not mutate the input component")
+	}
+}
+
+func TestConvertParametersThroughStorageHub(t *testing.T) {
+	t.Parallel()
+
+	got, err := ConvertParameters(template.NewEngine(), versionedDefinition(), "v1", "v3", map[string]any{"count": int64(2)})
+	if err != nil {
+		t.Fatalf("ConvertParameters() error = %v", err)
+	}
+	want := map[string]any{"scale": map[string]any{"replicas": int64(2)}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("converted parameters mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestResolveVersionRejectsUnserved(t *testing.T) {
+	t.Parallel()
+
+	if _, err := ResolveVersion(versionedDefinition(), "v1"); err == nil {
+		t.Fatalf("expected error for unserved version")
+	}
+}
+
+func TestRenderVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+	}{
+		{name: "served version", requested: "v3", want: "v3"},
+		{name: "unserved version renders with storage", requested: "v1", want: "v2"},
+		{name: "unpinned renders with storage", want: "v2"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			component := &types.Component{Spec: types.ComponentSpec{ComponentTypeVersion: tt.requested}}
+			got, err := RenderVersion(versionedDefinition(), component)
+			if err != nil {
+				t.Fatalf("RenderVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/scenario"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"gopkg.in/yaml.v3"
+)
+
+// runPreview renders a definition once to show representative output. With -with-examples the
+// Component is synthesized from the schema's example= markers, so definitions can be reviewed
+// and documented before any real Component uses them.
+func runPreview(args []string) error {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	withExamples := fs.Bool("with-examples", false, "synthesize the Component, and the config of every addon in -addons-dir, from schema examples")
+	name := fs.String("name", "example", "name of the synthesized Component")
+	envSettings := fs.String("env-settings", "", "EnvSettings to render with")
+	out := fs.String("out", "", "write the rendered resources here instead of stdout")
+	componentOut := fs.String("component-out", "", "write the synthesized Component to this file")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *withExamples == (*inputs.component != "") {
+		return fmt.Errorf("exactly one of -with-examples and -component is required")
+	}
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+
+	var componentDef *types.Component
+	var addons map[string]*types.Addon
+	if *withExamples {
+		if addons, err = inputs.loadAddons(nil); err != nil {
+			return fmt.Errorf("failed to load addons: %w", err)
+		}
+		if componentDef, err = exampleComponent(*name, ctd, addons); err != nil {
+			return err
+		}
+		if *componentOut != "" {
+			if err := writeYAML(*componentOut, componentDef); err != nil {
+				return fmt.Errorf("failed to write example component: %w", err)
+			}
+		}
+	} else {
+		if componentDef, err = inputs.loadComponent(); err != nil {
+			return fmt.Errorf("failed to load component: %w", err)
+		}
+		if addons, err = inputs.loadAddons(componentDef); err != nil {
+			return fmt.Errorf("failed to load addons: %w", err)
+		}
+	}
+
+	var settings *types.EnvSettings
+	if *envSettings != "" {
+		if settings, err = parser.LoadEnvSettings(*envSettings, inputs.parseOptions()...); err != nil {
+			return fmt.Errorf("failed to load env settings: %w", err)
+		}
+	}
+
+	renderer := component.NewRenderer(template.NewEngine(observability.EngineOptions()...), nil,
+		component.WithEventSink(warningLogger{}),
+	)
+	resources, err := renderer.RenderAll(ctd, componentDef, settings, addons, inputs.loadAdditionalContext(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", ctd.Metadata.Name, err)
+	}
+
+	if *out != "" {
+		if err := writeOutput(resources, *out, outputOptions{format: "yaml"}); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		fmt.Printf("Preview of %s written to %s (%d resources)\n", ctd.Metadata.Name, *out, len(resources))
+		return nil
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	defer encoder.Close()
+	for _, resource := range resources {
+		if err := encoder.Encode(resource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exampleComponent builds a Component of the definition whose parameters, and whose instance of
+// every addon, come from schema examples.
+func exampleComponent(name string, ctd *types.ComponentTypeDefinition, addons map[string]*types.Addon) (*types.Component, error) {
+	ctdSchema, err := parser.GenerateJSONSchema(ctd)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema in %s: %w", ctd.Metadata.Name, err)
+	}
+	params, err := scenario.ExampleParameters(ctdSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to synthesize parameters for %s: %w", ctd.Metadata.Name, err)
+	}
+
+	addonNames := make([]string, 0, len(addons))
+	for addonName := range addons {
+		addonNames = append(addonNames, addonName)
+	}
+	sort.Strings(addonNames)
+
+	instances := make([]types.AddonInstance, 0, len(addonNames))
+	for _, addonName := range addonNames {
+		addonSchema, err := parser.GenerateAddonJSONSchema(addons[addonName])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema in addon %s: %w", addonName, err)
+		}
+		config, err := scenario.ExampleParameters(addonSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to synthesize config for addon %s: %w", addonName, err)
+		}
+		instances = append(instances, types.AddonInstance{Name: addonName, InstanceID: addonName, Config: config})
+	}
+
+	return &types.Component{
+		APIVersion: ctd.APIVersion,
+		Kind:       "Component",
+		Metadata:   types.Metadata{Name: name},
+		Spec: types.ComponentSpec{
+			ComponentType: ctd.Metadata.Name,
+			Parameters:    params,
+			Addons:        instances,
+		},
+	}, nil
+}
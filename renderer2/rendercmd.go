@@ -0,0 +1,526 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/artifacts"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/audit"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/diff"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/export"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/format"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/images"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/lock"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/pipeline"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/platform"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/presets"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/transport"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/verify"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+)
+
+// runRender renders a Component without EnvSettings and for every -env, writing one file per
+// addon stage to <output-dir>/<env>/.
+func runRender(args []string) (err error) {
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	var envs envFlag
+	fs.Var(&envs, "env", "EnvSettings to render as name=path; repeatable")
+	outputDir := fs.String("output-dir", "output", "directory receiving one subdirectory per environment")
+	outputFormat := fs.String("format", "yaml", "output format: yaml, terraform (HCL) or terraform-json")
+	crossplaneDir := fs.String("crossplane-dir", "", "write a Crossplane XRD and Composition for the definition into this directory")
+	backstagePath := fs.String("backstage", "", "write Backstage catalog-info entities for the fully rendered component to this file")
+	verifyRuns := fs.Int("verify-runs", 0, "render every stage this many times and fail on nondeterministic output")
+	verifyShuffle := fs.Bool("verify-shuffle", false, "shuffle input map insertion order between verification runs")
+	hardenSecurity := fs.Bool("harden-security", false, "enforce baseline Pod Security Standards on rendered pod templates")
+	imageLock := fs.String("image-lock", "", "pin container images to digests recorded in this lockfile, resolving new tags from their registries")
+	imageOffline := fs.Bool("image-offline", false, "with -image-lock, fail on images missing from the lockfile instead of contacting registries")
+	lockPath := fs.String("lockfile", "", "record definition, addon, function and image versions in this lockfile (e.g. "+lock.FileName+")")
+	frozen := fs.Bool("frozen", false, "with -lockfile, refuse to render when inputs drifted from the lockfile and resolve images from it only")
+	ownerRefs := fs.String("owner-refs", "none", "attach the EnvSettings owner and componentRef: none, annotations or references")
+	waveAnnotation := fs.String("apply-wave-annotation", "", "order resources by apply wave and record each wave in this annotation, e.g. argocd.argoproj.io/sync-wave")
+	crds := fs.String("crds", "", "CustomResourceDefinition file or directory telling the scope of custom kinds, so their namespaces are checked")
+	addonConflicts := fs.String("addon-conflicts", "ignore", "when addons overwrite each other's patched values: ignore, warn or error")
+	allowAddons := fs.String("allow-addons", "", "comma-separated addons accepted in every environment regardless of their stability")
+	stdout := fs.String("o", "", "write to stdout instead of -output-dir: - or yaml for multi-document YAML, json for a v1 List")
+	singleFile := fs.Bool("single-file", false, "write every environment and stage to <output-dir>/"+singleFileName+" instead of one file per stage")
+	keyOrder := fs.String("key-order", string(format.KeyOrderSorted), "order of YAML mapping keys: sorted or kubernetes (apiVersion, kind, metadata, name, ... first)")
+	targetOS := fs.String("os", "", "target node OS exposed to templates as platform.os, overriding spec.platform of every environment")
+	targetArch := fs.String("arch", "", "target node architecture exposed to templates as platform.arch, overriding spec.platform of every environment")
+	watch := fs.Bool("watch", false, "keep running and re-render the environments affected by every change to the input files")
+	header := fs.String("header", string(headerNone), "prepend a provenance comment to every output file: none, timestamped or deterministic")
+	auditLog := fs.String("audit-log", "", "append a JSON line describing this invocation (actor, input and output digests, duration, error) to this file")
+	configPath := fs.String("config", "", "platform configuration file; defaults to $"+config.EnvVar+", then every "+config.FileName+" up to the repository root")
+	summaryFormat := fs.String("summary", summaryText, "format of the closing summary: text, or json for one JSON object with the resources and checksum of every stage")
+	verbosityFlags := registerVerbosityFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	level, err := verbosityFlags.level()
+	if err != nil {
+		return err
+	}
+	platformConfig, err := config.Resolve(*configPath, ".")
+	if err != nil {
+		return fmt.Errorf("failed to load platform configuration: %w", err)
+	}
+	if err := applyConfigFlags(fs, platformConfig); err != nil {
+		return err
+	}
+	switch {
+	case *summaryFormat != summaryText && *summaryFormat != summaryJSON:
+		return fmt.Errorf("invalid -summary %q (want text or json)", *summaryFormat)
+	case *stdout != "" && *singleFile:
+		return fmt.Errorf("-o and -single-file are mutually exclusive")
+	case *stdout != "" && *stdout != "-" && *stdout != "yaml" && *stdout != "json":
+		return fmt.Errorf("invalid -o %q (want -, yaml or json)", *stdout)
+	case (*stdout != "" || *singleFile) && *outputFormat != "yaml":
+		return fmt.Errorf("-o and -single-file write YAML or JSON manifests; -format %s is not supported with them", *outputFormat)
+	case *watch && (*stdout != "" || *singleFile):
+		return fmt.Errorf("-watch rewrites the files under -output-dir and cannot be combined with -o or -single-file")
+	case *watch && *auditLog != "":
+		return fmt.Errorf("-watch renders repeatedly and cannot be combined with -audit-log")
+	}
+	// Status lines must not mix with manifests streamed to stdout. progress receives them at the
+	// default verbosity and diagnostics with -v.
+	status := io.Writer(os.Stdout)
+	if *stdout != "" {
+		status = os.Stderr
+	}
+	progress := level.at(verbosityNormal, status)
+	diagnostics := level.at(verbosityVerbose, status)
+	events := warningLogger{verbose: level >= verbosityVerbose}
+	if len(platformConfig.Sources) > 0 {
+		fmt.Fprintf(progress, "Using platform configuration %s\n", strings.Join(platformConfig.Sources, ", "))
+	}
+	var invocation *audit.Invocation
+	if *auditLog != "" {
+		invocation = audit.Start(&audit.FileSink{Path: *auditLog}, "render", args)
+		defer func() {
+			if auditErr := invocation.Finish(err); auditErr != nil && err == nil {
+				err = auditErr
+			}
+		}()
+	}
+
+	order, err := format.ParseKeyOrder(*keyOrder)
+	if err != nil {
+		return fmt.Errorf("invalid -key-order: %w", err)
+	}
+	headerMode, err := parseHeaderMode(*header)
+	if err != nil {
+		return fmt.Errorf("invalid -header: %w", err)
+	}
+	renderedAt, err := headerMode.renderTime()
+	if err != nil {
+		return err
+	}
+	ownerMode, err := pipeline.ParseOwnerMode(*ownerRefs)
+	if err != nil {
+		return fmt.Errorf("invalid -owner-refs: %w", err)
+	}
+	conflictMode, err := pipeline.ParseConflictMode(*addonConflicts)
+	if err != nil {
+		return fmt.Errorf("invalid -addon-conflicts: %w", err)
+	}
+	targetPlatform := types.PlatformSettings{OS: *targetOS, Arch: *targetArch}
+	if err := presets.ValidatePlatform(targetPlatform.OS, targetPlatform.Arch); err != nil {
+		return fmt.Errorf("invalid -os or -arch: %w", err)
+	}
+	stabilityPolicy := platformConfig.StabilityPolicy()
+	if *allowAddons != "" {
+		stabilityPolicy.Allow[component.AnyEnvironment] = append(stabilityPolicy.Allow[component.AnyEnvironment], strings.Split(*allowAddons, ",")...)
+	}
+
+	engine := template.NewEngine(observability.EngineOptions()...)
+	hooks := []pipeline.Hooks{platform.Availability{}}
+	if *hardenSecurity {
+		hooks = append(hooks, platform.Security{Events: events})
+	}
+	registryResolver := &images.RegistryResolver{
+		Client:    transport.NewClient(platformConfig.Remote),
+		PlainHTTP: platformConfig.Registries.PlainHTTP,
+	}
+	var imageLockfile *images.Lockfile
+	if *imageLock != "" {
+		imageLockfile, err = images.LoadLockfile(*imageLock)
+		if err != nil {
+			return fmt.Errorf("failed to load image lockfile: %w", err)
+		}
+		var fallback images.Resolver
+		if !*imageOffline {
+			fallback = registryResolver
+		}
+		hooks = append(hooks, images.Pinner{Resolver: images.NewLockedResolver(imageLockfile, fallback)})
+	}
+	var platformLock *lock.File
+	switch {
+	case *lockPath != "" && *imageLock != "":
+		return fmt.Errorf("-lockfile and -image-lock are mutually exclusive; -lockfile also pins images")
+	case *lockPath != "":
+		platformLock, err = lock.Load(*lockPath)
+		if lock.IsNotExist(err) && !*frozen {
+			platformLock, err = &lock.File{Images: map[string]string{}}, nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load lockfile: %w", err)
+		}
+		var fallback images.Resolver
+		if !*frozen {
+			fallback = registryResolver
+		}
+		imageResolver := images.NewLockedResolver(&images.Lockfile{Images: platformLock.Images}, fallback)
+		hooks = append(hooks, images.Pinner{Resolver: imageResolver})
+	case *frozen:
+		return fmt.Errorf("-frozen requires -lockfile")
+	}
+	hooks = append(hooks, platformConfig.Hooks()...)
+	scopes, err := loadScopes(*crds, inputs.yaml.codec)
+	if err != nil {
+		return fmt.Errorf("invalid -crds: %w", err)
+	}
+	renderer := component.NewRenderer(engine, nil,
+		component.WithEventSink(events),
+		component.WithOwnerMode(ownerMode),
+		component.WithConflictMode(conflictMode),
+		component.WithStabilityPolicy(stabilityPolicy),
+		component.WithHooks(hooks...),
+		component.WithApplyWaveAnnotation(*waveAnnotation),
+		component.WithScopes(scopes),
+	)
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	componentDef, err := inputs.loadComponent()
+	if err != nil {
+		return fmt.Errorf("failed to load component: %w", err)
+	}
+	addons, err := inputs.loadAddons(componentDef)
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+	additionalCtx := inputs.loadAdditionalContext()
+	if err := auditInputs(invocation, ctd, componentDef, addons, additionalCtx); err != nil {
+		return err
+	}
+
+	if platformLock != nil {
+		current, err := lock.Build(ctd, addons, engine.FunctionNames())
+		if err != nil {
+			return fmt.Errorf("failed to describe render inputs: %w", err)
+		}
+		if *frozen {
+			if drift := lock.Drift(platformLock, current); len(drift) > 0 {
+				return fmt.Errorf("inputs drifted from %s:\n  %s", *lockPath, strings.Join(drift, "\n  "))
+			}
+		}
+		current.Images = platformLock.Images
+		platformLock = current
+	}
+
+	if *crossplaneDir != "" {
+		addonList := make([]*types.Addon, 0, len(addons))
+		for _, addon := range addons {
+			addonList = append(addonList, addon)
+		}
+		if err := writeCrossplane(ctd, addonList, *crossplaneDir, progress); err != nil {
+			return fmt.Errorf("failed to export crossplane composition: %w", err)
+		}
+	}
+
+	envConfigs := []envConfig{{name: "no-env", settings: withPlatform(nil, targetPlatform)}}
+	for _, env := range envs {
+		settings, err := parser.LoadEnvSettings(env.path, inputs.parseOptions()...)
+		if err != nil {
+			return fmt.Errorf("failed to load env settings %s: %w", env.name, err)
+		}
+		settings = withPlatform(settings, targetPlatform)
+		if err := invocation.Input("env/"+env.name, settings); err != nil {
+			return err
+		}
+		envConfigs = append(envConfigs, envConfig{name: env.name, settings: settings})
+	}
+
+	stages, err := generateStages(componentDef, addons)
+	if err != nil {
+		return fmt.Errorf("failed to order addons: %w", err)
+	}
+	definitionVersion, err := versioning.RenderVersion(ctd, componentDef)
+	if err != nil {
+		return err
+	}
+	reportInputs(diagnostics, ctd, definitionVersion, componentDef, stages)
+	if level >= verbosityDebug {
+		if err := printSchemas(status, ctd, addons); err != nil {
+			return err
+		}
+	}
+	summary := &renderSummary{}
+
+	opts := outputOptions{format: *outputFormat, keyOrder: order}
+	var sink outputSink = dirSink{dir: *outputDir, opts: opts}
+	switch {
+	case *stdout == "json":
+		sink = &jsonSink{w: os.Stdout}
+	case *stdout != "":
+		sink = &streamSink{w: os.Stdout, name: "stdout", opts: opts}
+	case *singleFile:
+		if err := os.MkdirAll(*outputDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output dir %s: %w", *outputDir, err)
+		}
+		path := filepath.Join(*outputDir, singleFileName)
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		defer file.Close()
+		sink = &streamSink{w: file, file: file, name: normalize.Path(path), opts: opts}
+	}
+
+	// renderEnv writes every stage of env to sink and returns the encoded last stage, the
+	// environment's complete output, so -watch can diff it between renders.
+	renderEnv := func(env envConfig, summary *renderSummary) ([]byte, error) {
+		envOutput := filepath.Join(*outputDir, env.name)
+		if *stdout == "" {
+			if err := os.RemoveAll(envOutput); err != nil {
+				return nil, fmt.Errorf("failed to clean output dir %s: %w", envOutput, err)
+			}
+			if err := os.MkdirAll(envOutput, 0755); err != nil {
+				return nil, fmt.Errorf("failed to create output dir %s: %w", envOutput, err)
+			}
+		}
+
+		fmt.Fprintf(progress, "\nRendering for environment: %s\n", env.name)
+		rendered, err := renderer.RenderArtifacts(ctd, componentDef, env.settings, addons, additionalCtx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render artifacts: %w", err)
+		}
+		if len(rendered) > 0 && *stdout != "" {
+			fmt.Fprintf(progress, "  skipped %d artifacts: artifacts are only written to -output-dir\n", len(rendered))
+		} else if len(rendered) > 0 {
+			artifactDir := filepath.Join(envOutput, "artifacts")
+			if err := artifacts.WriteAll(artifacts.DirSink{Root: artifactDir}, rendered); err != nil {
+				return nil, fmt.Errorf("failed to write artifacts: %w", err)
+			}
+			fmt.Fprintf(progress, "  wrote %d artifacts to %s\n", len(rendered), artifactDir)
+		}
+		var final bytes.Buffer
+		for i, stage := range stages {
+			started := time.Now()
+			resources, err := renderer.RenderWithAddonLimit(ctd, componentDef, env.settings, addons, additionalCtx, nil, stage.AddonCount)
+			if err != nil {
+				return nil, fmt.Errorf("failed to render stage %s: %w", stage.Name, err)
+			}
+			fmt.Fprintf(diagnostics, "  rendered %s in %s\n", stage.Name, time.Since(started).Round(time.Microsecond))
+
+			if *verifyRuns > 0 {
+				settings, addonCount := env.settings, stage.AddonCount
+				err := verify.Determinism(func(_ int, perturb func(any) any) ([]map[string]any, error) {
+					shuffled := *componentDef
+					shuffled.Spec.Parameters, _ = perturb(componentDef.Spec.Parameters).(map[string]any)
+					return renderer.RenderWithAddonLimit(ctd, &shuffled, settings, addons, additionalCtx, nil, addonCount)
+				}, verify.Options{Runs: *verifyRuns, Shuffle: *verifyShuffle})
+				if err != nil {
+					return nil, fmt.Errorf("determinism check failed for %s/%s: %w", env.name, stage.Name, err)
+				}
+			}
+
+			var headerComment string
+			if headerMode != headerNone {
+				h := outputHeader{
+					Definition:        ctd.Metadata.Name,
+					DefinitionVersion: definitionVersion,
+					Component:         componentDef.Metadata.Name,
+					Stage:             stage.Name,
+					RenderedAt:        renderedAt,
+				}
+				if env.settings != nil {
+					h.Environment = env.name
+				}
+				headerComment = h.comment()
+			}
+			location, err := sink.write(env.name, stage.Name, headerComment, resources)
+			if err != nil {
+				return nil, fmt.Errorf("failed to write output: %w", err)
+			}
+			stats, err := summary.add(env.name, stage.Name, resources)
+			if err != nil {
+				return nil, fmt.Errorf("failed to summarize output: %w", err)
+			}
+			fmt.Fprintf(progress, "  wrote %s: %s\n", location, stats)
+			if *watch && i == len(stages)-1 {
+				if err := encodeOutput(&final, resources, opts); err != nil {
+					return nil, fmt.Errorf("failed to encode output: %w", err)
+				}
+			}
+		}
+		return final.Bytes(), nil
+	}
+	// The JSON summary is a result and is written even with -q.
+	writeSummary := func(summary *renderSummary) error {
+		w := progress
+		if *summaryFormat == summaryJSON {
+			w = status
+		}
+		if err := summary.write(w, *summaryFormat); err != nil {
+			return fmt.Errorf("failed to write summary: %w", err)
+		}
+		return nil
+	}
+	outputs := map[string][]byte{}
+	for _, env := range envConfigs {
+		output, err := renderEnv(env, summary)
+		if err != nil {
+			return err
+		}
+		outputs[env.name] = output
+	}
+	if err := sink.close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	if *backstagePath != "" {
+		resources, err := renderer.RenderAll(ctd, componentDef, nil, addons, additionalCtx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to render component for Backstage export: %w", err)
+		}
+		entities := export.ToBackstage(ctd, componentDef, resources, export.BackstageOptions{})
+		if err := writeOutput(entities, *backstagePath, outputOptions{format: "yaml", keyOrder: order}); err != nil {
+			return fmt.Errorf("failed to write Backstage catalog: %w", err)
+		}
+		fmt.Fprintf(progress, "\nBackstage catalog written to %s (%d entities)\n", *backstagePath, len(entities))
+	}
+
+	if imageLockfile != nil {
+		if err := imageLockfile.Save(*imageLock); err != nil {
+			return fmt.Errorf("failed to save image lockfile: %w", err)
+		}
+		fmt.Fprintf(progress, "\nImage lockfile written to %s (%d images)\n", *imageLock, len(imageLockfile.Images))
+	}
+
+	if platformLock != nil && !*frozen {
+		if err := platformLock.Save(*lockPath); err != nil {
+			return fmt.Errorf("failed to save lockfile: %w", err)
+		}
+		fmt.Fprintf(progress, "\nLockfile written to %s\n", *lockPath)
+	}
+
+	invocation.Output(summary.resourceCount(), "sha256:"+summary.checksum())
+	if err := writeSummary(summary); err != nil {
+		return err
+	}
+	fmt.Fprintln(progress, "\n✅ rendering complete using renderer2")
+	if !*watch {
+		return nil
+	}
+
+	envPaths := map[string]string{}
+	watched := []string{*inputs.definition, *inputs.component, *inputs.addonsDir, *inputs.additionalContext, *inputs.bundle}
+	for _, env := range envs {
+		envPaths[env.name] = env.path
+		watched = append(watched, env.path)
+	}
+	return watchInputs(watched, watchInterval, progress, func(changed []string) error {
+		shared, changedEnvs := affectedEnvironments(changed, envs)
+		var err error
+		if shared {
+			if ctd, err = inputs.loadDefinition(); err != nil {
+				return fmt.Errorf("failed to load component type definition: %w", err)
+			}
+			if componentDef, err = inputs.loadComponent(); err != nil {
+				return fmt.Errorf("failed to load component: %w", err)
+			}
+			if addons, err = inputs.loadAddons(componentDef); err != nil {
+				return fmt.Errorf("failed to load addons: %w", err)
+			}
+			additionalCtx = inputs.loadAdditionalContext()
+			if stages, err = generateStages(componentDef, addons); err != nil {
+				return fmt.Errorf("failed to order addons: %w", err)
+			}
+			if definitionVersion, err = versioning.RenderVersion(ctd, componentDef); err != nil {
+				return err
+			}
+		}
+		summary := &renderSummary{}
+		for i, env := range envConfigs {
+			if !shared && !changedEnvs[env.name] {
+				continue
+			}
+			if changedEnvs[env.name] {
+				settings, err := parser.LoadEnvSettings(envPaths[env.name], inputs.parseOptions()...)
+				if err != nil {
+					return fmt.Errorf("failed to load env settings %s: %w", env.name, err)
+				}
+				envConfigs[i].settings = withPlatform(settings, targetPlatform)
+			}
+			output, err := renderEnv(envConfigs[i], summary)
+			if err != nil {
+				return err
+			}
+			if changes := diff.Lines(string(outputs[env.name]), string(output), 3); changes != "" {
+				fmt.Fprintf(progress, "\nChanges in %s:\n%s", env.name, changes)
+			} else {
+				fmt.Fprintf(progress, "  no changes in %s\n", env.name)
+			}
+			outputs[env.name] = output
+		}
+		return writeSummary(summary)
+	})
+}
+
+// withPlatform overrides the platform of settings with the fields of target that are set. Without
+// settings it returns nil unless target sets a field.
+func withPlatform(settings *types.EnvSettings, target types.PlatformSettings) *types.EnvSettings {
+	if target == (types.PlatformSettings{}) {
+		return settings
+	}
+	if settings == nil {
+		settings = &types.EnvSettings{}
+	}
+	platform := types.PlatformSettings{}
+	if settings.Spec.Platform != nil {
+		platform = *settings.Spec.Platform
+	}
+	if target.OS != "" {
+		platform.OS = target.OS
+	}
+	if target.Arch != "" {
+		platform.Arch = target.Arch
+	}
+	settings.Spec.Platform = &platform
+	return settings
+}
+
+// auditInputs records digests of the render inputs shared by every environment.
+func auditInputs(invocation *audit.Invocation, ctd *types.ComponentTypeDefinition, comp *types.Component, addons map[string]*types.Addon, additionalCtx *types.AdditionalContext) error {
+	if err := invocation.Input("definition", ctd); err != nil {
+		return err
+	}
+	if err := invocation.Input("component", comp); err != nil {
+		return err
+	}
+	for name, addon := range addons {
+		if err := invocation.Input("addon/"+name, addon); err != nil {
+			return err
+		}
+	}
+	if additionalCtx == nil {
+		return nil
+	}
+	return invocation.Input("context", additionalCtx)
+}
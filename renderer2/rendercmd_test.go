@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWithPlatform(t *testing.T) {
+	t.Parallel()
+
+	arm := &types.EnvSettings{Spec: types.EnvSettingsSpec{Environment: "dev", Platform: &types.PlatformSettings{OS: "linux", Arch: "arm64"}}}
+	tests := []struct {
+		name     string
+		settings *types.EnvSettings
+		target   types.PlatformSettings
+		want     *types.EnvSettings
+	}{
+		{name: "no flags without settings"},
+		{name: "no flags keeps settings", settings: arm, want: arm},
+		{
+			name:   "flags without settings",
+			target: types.PlatformSettings{Arch: "amd64"},
+			want:   &types.EnvSettings{Spec: types.EnvSettingsSpec{Platform: &types.PlatformSettings{Arch: "amd64"}}},
+		},
+		{
+			name:     "flags override set fields only",
+			settings: &types.EnvSettings{Spec: types.EnvSettingsSpec{Environment: "dev", Platform: &types.PlatformSettings{OS: "linux", Arch: "arm64"}}},
+			target:   types.PlatformSettings{Arch: "amd64"},
+			want:     &types.EnvSettings{Spec: types.EnvSettingsSpec{Environment: "dev", Platform: &types.PlatformSettings{OS: "linux", Arch: "amd64"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, withPlatform(tt.settings, tt.target)); diff != "" {
+				t.Errorf("withPlatform() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
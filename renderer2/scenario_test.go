@@ -0,0 +1,49 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/scenario"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/testutil"
+)
+
+func TestExampleDefinitionScenarioMatrix(t *testing.T) {
+	examplesDir := "examples"
+
+	ctd, err := parser.LoadComponentTypeDefinition(filepath.Join(examplesDir, "component-type-definitions", "deployment-component.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load component type definition: %v", err)
+	}
+	componentDef, err := parser.LoadComponent(filepath.Join(examplesDir, "components", "example-component.yaml"))
+	if err != nil {
+		t.Fatalf("failed to load component: %v", err)
+	}
+	addons, err := parser.LoadAddons(filepath.Join(examplesDir, "addons"), nil)
+	if err != nil {
+		t.Fatalf("failed to load addons: %v", err)
+	}
+
+	renderer := component.NewRenderer(template.NewEngine(), nil)
+	for seed := int64(1); seed <= 3; seed++ {
+		failures, err := scenario.Run(renderer, scenario.Inputs{
+			Definition:        ctd,
+			Component:         componentDef,
+			Addons:            addons,
+			AdditionalContext: testutil.FakeAdditionalContext(seed, testutil.ContextOptions{ComponentName: componentDef.Metadata.Name}),
+		}, scenario.Options{
+			Scenarios: 20,
+			Seed:      seed,
+			Policies:  []scenario.Policy{scenario.RequireNamedResources},
+		})
+		if err != nil {
+			t.Fatalf("scenario run failed: %v", err)
+		}
+		for _, failure := range failures {
+			t.Errorf("seed %d: %v", seed, failure)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/schema"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/versioning"
+	"gopkg.in/yaml.v3"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// runSchema writes the JSON Schema of the definition and of every addon in -addons-dir, or
+// dispatches schema diff.
+func runSchema(args []string) error {
+	if len(args) > 0 && args[0] == "diff" {
+		return runSchemaDiff(args[1:])
+	}
+	fs := flag.NewFlagSet("schema", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	outputDir := fs.String("output-dir", "schemas", "directory receiving one <name>-schema.json per definition and addon")
+	verbosityFlags := registerVerbosityFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	level, err := verbosityFlags.level()
+	if err != nil {
+		return err
+	}
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	addons, err := inputs.loadReferencedAddons()
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+	out := parser.SchemaOutput{
+		Progress: level.at(verbosityNormal, os.Stdout),
+		Schemas:  level.at(verbosityDebug, os.Stdout),
+	}
+	if err := parser.ValidateSchemas(ctd, addons, *outputDir, out); err != nil {
+		return fmt.Errorf("schema validation failed: %w", err)
+	}
+	return nil
+}
+
+// runSchemaDiff compares the schemas of two versions of a definition or addon and reports the
+// changes that break Components written against the old one.
+func runSchemaDiff(args []string) error {
+	fs := flag.NewFlagSet("schema diff", flag.ExitOnError)
+	oldVersion := fs.String("old-version", "", "version of the old definition to compare; defaults to its storage version")
+	newVersion := fs.String("new-version", "", "version of the new definition to compare; defaults to its storage version")
+	format := fs.String("format", "text", "output format: text or json")
+	exitCode := fs.Bool("exit-code", false, "exit with an error when any change is breaking")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return fmt.Errorf("usage: renderer2 schema diff [flags] <old.yaml> <new.yaml>")
+	}
+
+	oldKind, oldSchema, err := loadSchemaOf(fs.Arg(0), *oldVersion)
+	if err != nil {
+		return err
+	}
+	newKind, newSchema, err := loadSchemaOf(fs.Arg(1), *newVersion)
+	if err != nil {
+		return err
+	}
+	if oldKind != newKind {
+		return fmt.Errorf("cannot compare a %s with a %s", oldKind, newKind)
+	}
+	changes := schema.Compare(oldSchema, newSchema)
+
+	switch *format {
+	case "text":
+		printSchemaChanges(changes)
+	case "json":
+		if changes == nil {
+			changes = []schema.Change{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changes); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	if *exitCode && schema.HasBreaking(changes) {
+		breaking := 0
+		for _, change := range changes {
+			if change.Breaking {
+				breaking++
+			}
+		}
+		return fmt.Errorf("%d breaking change(s)", breaking)
+	}
+	return nil
+}
+
+// loadSchemaOf reads a ComponentTypeDefinition or Addon and converts its schema, resolving version
+// for definitions.
+func loadSchemaOf(path, version string) (string, *extv1.JSONSchemaProps, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var header struct {
+		Kind string `yaml:"kind"`
+	}
+	if err := yaml.Unmarshal(content, &header); err != nil {
+		return "", nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var converted *extv1.JSONSchemaProps
+	switch header.Kind {
+	case "ComponentTypeDefinition":
+		ctd, err := parser.ParseComponentTypeDefinition(path, content)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if ctd, err = versioning.ResolveVersion(ctd, version); err != nil {
+			return "", nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if converted, err = parser.GenerateJSONSchema(ctd); err != nil {
+			return "", nil, fmt.Errorf("%s: failed to convert schema: %w", path, err)
+		}
+	case "Addon":
+		if version != "" {
+			return "", nil, fmt.Errorf("%s: addons are not versioned", path)
+		}
+		addon, err := parser.ParseAddon(path, content)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if converted, err = parser.GenerateAddonJSONSchema(addon); err != nil {
+			return "", nil, fmt.Errorf("%s: failed to convert schema: %w", path, err)
+		}
+	default:
+		return "", nil, fmt.Errorf("%s: kind %q has no schema (want ComponentTypeDefinition or Addon)", path, header.Kind)
+	}
+	return header.Kind, converted, nil
+}
+
+// printSchemaChanges lists breaking changes first, then compatible ones.
+func printSchemaChanges(changes []schema.Change) {
+	if len(changes) == 0 {
+		fmt.Println("no schema changes")
+		return
+	}
+	for _, breaking := range []bool{true, false} {
+		title := "Compatible changes:"
+		if breaking {
+			title = "Breaking changes:"
+		}
+		printed := false
+		for _, change := range changes {
+			if change.Breaking != breaking {
+				continue
+			}
+			if !printed {
+				fmt.Println(title)
+				printed = true
+			}
+			fmt.Printf("  %s\n", change)
+		}
+	}
+}
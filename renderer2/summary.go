@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/normalize"
+)
+
+const (
+	summaryText = "text"
+	summaryJSON = "json"
+)
+
+// stageSummary describes the resources rendered for one environment and stage.
+type stageSummary struct {
+	Env      string         `json:"env"`
+	Stage    string         `json:"stage"`
+	Count    int            `json:"resources"`
+	Kinds    map[string]int `json:"kinds"`
+	Checksum string         `json:"checksum"`
+}
+
+// renderSummary collects stage summaries so CI logs show when, and by how much, output changed.
+type renderSummary struct {
+	stages []stageSummary
+}
+
+// add records the resources of a stage and returns its summary.
+func (s *renderSummary) add(env, stage string, resources []map[string]any) (stageSummary, error) {
+	checksum, err := resourcesChecksum(resources)
+	if err != nil {
+		return stageSummary{}, fmt.Errorf("failed to checksum %s/%s: %w", env, stage, err)
+	}
+	kinds := map[string]int{}
+	for _, resource := range resources {
+		kind, _ := resource["kind"].(string)
+		if kind == "" {
+			kind = "<unknown>"
+		}
+		kinds[kind]++
+	}
+	summary := stageSummary{Env: env, Stage: stage, Count: len(resources), Kinds: kinds, Checksum: checksum}
+	s.stages = append(s.stages, summary)
+	return summary, nil
+}
+
+// checksum combines the stage checksums in render order.
+func (s *renderSummary) checksum() string {
+	h := sha256.New()
+	for _, stage := range s.stages {
+		fmt.Fprintf(h, "%s/%s=%s\n", stage.Env, stage.Stage, stage.Checksum)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// write reports the summary in format: a closing line for "text", or for "json" a single JSON
+// object with the total resource count, the combined checksum and every stage.
+func (s *renderSummary) write(w io.Writer, format string) error {
+	if format != summaryJSON {
+		_, err := fmt.Fprintf(w, "\nRendered %d resources in %d stages, checksum sha256:%s\n", s.resourceCount(), len(s.stages), s.checksum())
+		return err
+	}
+	stages := s.stages
+	if stages == nil {
+		stages = []stageSummary{}
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Resources int            `json:"resources"`
+		Checksum  string         `json:"checksum"`
+		Stages    []stageSummary `json:"stages"`
+	}{s.resourceCount(), "sha256:" + s.checksum(), stages})
+}
+
+func (s *renderSummary) resourceCount() int {
+	total := 0
+	for _, stage := range s.stages {
+		total += stage.Count
+	}
+	return total
+}
+
+// String renders the stage as "3 resources (2 Deployment, 1 Service) sha256:0123456789ab".
+func (s stageSummary) String() string {
+	return fmt.Sprintf("%d resources (%s) sha256:%s", s.Count, formatKinds(s.Kinds), shortChecksum(s.Checksum))
+}
+
+// formatKinds lists kinds by descending count, then by name.
+func formatKinds(kinds map[string]int) string {
+	if len(kinds) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		names = append(names, kind)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if kinds[names[i]] != kinds[names[j]] {
+			return kinds[names[i]] > kinds[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, kind := range names {
+		parts[i] = fmt.Sprintf("%d %s", kinds[kind], kind)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// resourcesChecksum hashes the JSON encoding of the normalized resources, which sorts map keys and
+// is therefore stable across renders and platforms.
+func resourcesChecksum(resources []map[string]any) (string, error) {
+	data, err := json.Marshal(normalize.Resources(resources))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func shortChecksum(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
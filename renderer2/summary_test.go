@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestRenderSummary(t *testing.T) {
+	t.Parallel()
+
+	resources := []map[string]any{
+		{"kind": "Service", "metadata": map[string]any{"name": "web"}},
+		{"kind": "Deployment", "metadata": map[string]any{"name": "web"}},
+		{"kind": "Deployment", "metadata": map[string]any{"name": "worker"}},
+	}
+
+	var first renderSummary
+	stats, err := first.add("dev", "stage-1-base", resources)
+	if err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if got := stats.String(); !strings.HasPrefix(got, "3 resources (2 Deployment, 1 Service) sha256:") {
+		t.Errorf("String() = %q", got)
+	}
+
+	var same renderSummary
+	if _, err := same.add("dev", "stage-1-base", resources); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if first.checksum() != same.checksum() {
+		t.Errorf("checksum differs for identical output")
+	}
+
+	var changed renderSummary
+	if _, err := changed.add("dev", "stage-1-base", resources[:2]); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if first.checksum() == changed.checksum() {
+		t.Errorf("checksum did not change when a resource was dropped")
+	}
+	if got := changed.resourceCount(); got != 2 {
+		t.Errorf("resourceCount() = %d, want 2", got)
+	}
+}
+
+func TestRenderSummaryWrite(t *testing.T) {
+	t.Parallel()
+
+	var summary renderSummary
+	if _, err := summary.add("dev", "stage-1-base", []map[string]any{{"kind": "Deployment"}, {"kind": "Service"}}); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := summary.write(&text, summaryText); err != nil {
+		t.Fatalf("write(text) error = %v", err)
+	}
+	if want := "\nRendered 2 resources in 1 stages, checksum sha256:" + summary.checksum() + "\n"; text.String() != want {
+		t.Errorf("write(text) = %q, want %q", text.String(), want)
+	}
+
+	var out bytes.Buffer
+	if err := summary.write(&out, summaryJSON); err != nil {
+		t.Fatalf("write(json) error = %v", err)
+	}
+	var got struct {
+		Resources int
+		Checksum  string
+		Stages    []map[string]any
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("write(json) = %s, not JSON: %v", out.String(), err)
+	}
+	if got.Resources != 2 || got.Checksum != "sha256:"+summary.checksum() || len(got.Stages) != 1 || got.Stages[0]["stage"] != "stage-1-base" {
+		t.Errorf("write(json) = %s", out.String())
+	}
+}
+
+func TestVerbosityLevel(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		args    []string
+		want    verbosity
+		wantErr bool
+	}{
+		{args: nil, want: verbosityNormal},
+		{args: []string{"-q"}, want: verbosityQuiet},
+		{args: []string{"-v"}, want: verbosityVerbose},
+		{args: []string{"-v", "-vv"}, want: verbosityDebug},
+		{args: []string{"-q", "-vv"}, wantErr: true},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags := registerVerbosityFlags(fs)
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatalf("Parse(%v) error = %v", tt.args, err)
+		}
+		got, err := flags.level()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("level(%v) = %v, %v, want %v", tt.args, got, err, tt.want)
+		}
+	}
+}
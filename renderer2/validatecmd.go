@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/component"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/observability"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/template"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// runValidate checks that the definition and addon schemas convert to JSON Schema and, with
+// -component, that every stage renders for every -env without writing anything. With -previous,
+// the component is also validated as an update of that revision, rejecting changes to immutable
+// fields.
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	inputs := registerInputFlags(fs)
+	var envs envFlag
+	fs.Var(&envs, "env", "EnvSettings to render as name=path; repeatable")
+	previous := fs.String("previous", "", "previous revision of -component; changes to its immutable fields are rejected")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctd, err := inputs.loadDefinition()
+	if err != nil {
+		return fmt.Errorf("failed to load component type definition: %w", err)
+	}
+	var componentDef, previousDef *types.Component
+	if *inputs.component != "" {
+		if componentDef, err = inputs.loadComponent(); err != nil {
+			return fmt.Errorf("failed to load component: %w", err)
+		}
+	}
+	if *previous != "" {
+		if componentDef == nil {
+			return fmt.Errorf("-previous requires -component")
+		}
+		if previousDef, err = parser.LoadComponent(*previous, inputs.parseOptions()...); err != nil {
+			return fmt.Errorf("failed to load previous component: %w", err)
+		}
+	}
+	addons, err := inputs.loadAddons(componentDef)
+	if err != nil {
+		return fmt.Errorf("failed to load addons: %w", err)
+	}
+
+	if _, err := parser.GenerateJSONSchema(ctd); err != nil {
+		return fmt.Errorf("invalid schema in %s: %w", ctd.Metadata.Name, err)
+	}
+	for name, addon := range addons {
+		if _, err := parser.GenerateAddonJSONSchema(addon); err != nil {
+			return fmt.Errorf("invalid schema in addon %s: %w", name, err)
+		}
+	}
+
+	if componentDef != nil {
+		settings := []*types.EnvSettings{nil}
+		for _, env := range envs {
+			loaded, err := parser.LoadEnvSettings(env.path, inputs.parseOptions()...)
+			if err != nil {
+				return fmt.Errorf("failed to load env settings %s: %w", env.name, err)
+			}
+			settings = append(settings, loaded)
+		}
+		renderer := component.NewRenderer(template.NewEngine(observability.EngineOptions()...), nil)
+		additionalCtx := inputs.loadAdditionalContext()
+		for i, envSettings := range settings {
+			name := "no-env"
+			if i > 0 {
+				name = envs[i-1].name
+			}
+			if previousDef != nil {
+				if err := renderer.ValidateUpdate(ctd, previousDef, envSettings, componentDef, envSettings, addons); err != nil {
+					return fmt.Errorf("invalid update for environment %s: %w", name, err)
+				}
+			}
+			if _, err := renderer.RenderAll(ctd, componentDef, envSettings, addons, additionalCtx, nil); err != nil {
+				return fmt.Errorf("failed to render for environment %s: %w", name, err)
+			}
+		}
+	}
+
+	fmt.Printf("✅ %s and %d addon(s) are valid\n", ctd.Metadata.Name, len(addons))
+	return nil
+}
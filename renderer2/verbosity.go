@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/parser"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// verbosity is the amount of reporting selected with -q, -v and -vv. Results, errors and warnings
+// are reported at every level.
+type verbosity int
+
+const (
+	// verbosityQuiet (-q) reports nothing else.
+	verbosityQuiet verbosity = iota
+	// verbosityNormal reports progress per environment and stage, and the summary.
+	verbosityNormal
+	// verbosityVerbose (-v) adds diagnostics: Normal events such as hardened security contexts,
+	// the resolved inputs and stage timings.
+	verbosityVerbose
+	// verbosityDebug (-vv) adds the JSON Schemas of the definition and addons.
+	verbosityDebug
+)
+
+// verbosityFlags are the -q, -v and -vv flags of a command.
+type verbosityFlags struct {
+	quiet, verbose, debug *bool
+}
+
+func registerVerbosityFlags(fs *flag.FlagSet) verbosityFlags {
+	return verbosityFlags{
+		quiet:   fs.Bool("q", false, "report only results, warnings and errors"),
+		verbose: fs.Bool("v", false, "also report diagnostics such as the resolved inputs and stage timings"),
+		debug:   fs.Bool("vv", false, "like -v, and also print the JSON Schema of the definition and every addon"),
+	}
+}
+
+func (f verbosityFlags) level() (verbosity, error) {
+	switch {
+	case *f.quiet && (*f.verbose || *f.debug):
+		return 0, fmt.Errorf("-q cannot be combined with -v or -vv")
+	case *f.quiet:
+		return verbosityQuiet, nil
+	case *f.debug:
+		return verbosityDebug, nil
+	case *f.verbose:
+		return verbosityVerbose, nil
+	default:
+		return verbosityNormal, nil
+	}
+}
+
+// at returns w when v includes level, and a writer discarding everything otherwise.
+func (v verbosity) at(level verbosity, w io.Writer) io.Writer {
+	if v < level {
+		return io.Discard
+	}
+	return w
+}
+
+// reportInputs describes the resolved render inputs for -v.
+func reportInputs(w io.Writer, ctd *types.ComponentTypeDefinition, definitionVersion string, comp *types.Component, stages []types.Stage) {
+	definition := ctd.Metadata.Name
+	if definitionVersion != "" {
+		definition += " " + definitionVersion
+	}
+	names := make([]string, len(stages))
+	for i, stage := range stages {
+		names[i] = stage.Name
+	}
+	fmt.Fprintf(w, "Rendering component %s of %s in stages %s\n", comp.Metadata.Name, definition, strings.Join(names, ", "))
+}
+
+// printSchemas writes the JSON Schema of the definition and of every addon, in name order, for -vv.
+func printSchemas(w io.Writer, ctd *types.ComponentTypeDefinition, addons map[string]*types.Addon) error {
+	definitionSchema, err := parser.GenerateJSONSchema(ctd)
+	if err != nil {
+		return fmt.Errorf("failed to generate schema of %s: %w", ctd.Metadata.Name, err)
+	}
+	if err := parser.PrintSchema(w, ctd.Metadata.Name, definitionSchema); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(addons))
+	for name := range addons {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addonSchema, err := parser.GenerateAddonJSONSchema(addons[name])
+		if err != nil {
+			return fmt.Errorf("failed to generate schema of addon %s: %w", name, err)
+		}
+		if err := parser.PrintSchema(w, name, addonSchema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/types"
+)
+
+// watchInterval is how often -watch checks the input files for changes.
+const watchInterval = 500 * time.Millisecond
+
+// envConfig is an environment to render; settings is nil for no-env.
+type envConfig struct {
+	name     string
+	settings *types.EnvSettings
+}
+
+// fileStamp identifies one version of a watched file by the SHA-256 of its content.
+type fileStamp [sha256.Size]byte
+
+// watchInputs polls paths (files, or directories watched recursively) every interval until
+// interrupted, calling rerender with the files that changed. Errors from rerender are reported and
+// watching continues, so a half-saved input does not end the session.
+//
+// Polling is deliberate. Editors save by writing a temporary file and renaming it over the input,
+// which drops a per-file inotify watch, and file systems shared into containers or VMs often deliver
+// no events at all; handling both with fsnotify means re-adding watches and rescanning anyway, for a
+// new dependency. The inputs are a handful of small YAML files, so hashing them twice a second is
+// cheap, and comparing content rather than mtime and size catches same-size edits within the
+// mtime granularity while ignoring saves that change nothing.
+func watchInputs(paths []string, interval time.Duration, progress io.Writer, rerender func(changed []string) error) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	stamps, err := stampFiles(paths)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "\nWatching %d files for changes (Ctrl-C to stop)\n", len(stamps))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := stampFiles(paths)
+		if err != nil {
+			fmt.Fprintf(progress, "warning: %v\n", err)
+			continue
+		}
+		changed := changedFiles(stamps, current)
+		stamps = current
+		if len(changed) == 0 {
+			continue
+		}
+		fmt.Fprintf(progress, "\nChanged: %s\n", strings.Join(changed, ", "))
+		if err := rerender(changed); err != nil {
+			fmt.Fprintf(progress, "❌ %v\n", err)
+		}
+	}
+}
+
+// stampFiles hashes every file under paths. Empty paths are skipped, and missing files are left out
+// so that editors replacing a file show up as a change. A path that does not exist is expanded as a
+// glob, such as a -definition pattern, so files it starts to match are picked up too.
+func stampFiles(paths []string) (map[string]fileStamp, error) {
+	stamps := map[string]fileStamp{}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		roots := []string{filepath.Clean(path)}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			if matches, _ := filepath.Glob(path); len(matches) > 0 {
+				roots = matches
+			}
+		}
+		for _, root := range roots {
+			err := filepath.WalkDir(root, func(file string, entry fs.DirEntry, err error) error {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				if err != nil || entry.IsDir() {
+					return err
+				}
+				content, err := os.ReadFile(file)
+				if os.IsNotExist(err) {
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				stamps[file] = sha256.Sum256(content)
+				return nil
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+			}
+		}
+	}
+	return stamps, nil
+}
+
+// changedFiles returns the sorted files added, removed or modified between two stamps.
+func changedFiles(before, after map[string]fileStamp) []string {
+	var changed []string
+	for file, stamp := range after {
+		if previous, ok := before[file]; !ok || previous != stamp {
+			changed = append(changed, file)
+		}
+	}
+	for file := range before {
+		if _, ok := after[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// affectedEnvironments reports whether changed includes an input shared by every environment (the
+// definition, Component, addons or additional context), and otherwise which -env EnvSettings
+// changed.
+func affectedEnvironments(changed []string, envs envFlag) (bool, map[string]bool) {
+	byPath := map[string][]string{}
+	for _, env := range envs {
+		path := filepath.Clean(env.path)
+		byPath[path] = append(byPath[path], env.name)
+	}
+	shared := false
+	names := map[string]bool{}
+	for _, file := range changed {
+		envNames, ok := byPath[filepath.Clean(file)]
+		if !ok {
+			shared = true
+			continue
+		}
+		for _, name := range envNames {
+			names[name] = true
+		}
+	}
+	return shared, names
+}
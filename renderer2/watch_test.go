@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangedFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	component := filepath.Join(dir, "component.yaml")
+	addon := filepath.Join(dir, "addons", "pvc.yaml")
+	removed := filepath.Join(dir, "addons", "sidecar.yaml")
+	for _, file := range []string{component, addon, removed} {
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte("a"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	paths := []string{component, filepath.Join(dir, "addons"), "", filepath.Join(dir, "missing.json")}
+	before, err := stampFiles(paths)
+	if err != nil {
+		t.Fatalf("stampFiles() error = %v", err)
+	}
+	if len(before) != 3 {
+		t.Fatalf("stampFiles() = %v, want 3 files", before)
+	}
+
+	info, err := os.Stat(component)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A same-size edit that keeps the modification time is still a change.
+	if err := os.WriteFile(component, []byte("b"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(component, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	// Saving the same content again is not.
+	if err := os.WriteFile(addon, []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(addon, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+	after, err := stampFiles(paths)
+	if err != nil {
+		t.Fatalf("stampFiles() error = %v", err)
+	}
+
+	if diff := cmp.Diff([]string{removed, component}, changedFiles(before, after)); diff != "" {
+		t.Errorf("changedFiles() mismatch (-want +got):\n%s", diff)
+	}
+	if got := changedFiles(after, after); len(got) != 0 {
+		t.Errorf("changedFiles() of unchanged stamps = %v, want none", got)
+	}
+}
+
+func TestAffectedEnvironments(t *testing.T) {
+	t.Parallel()
+
+	envs := envFlag{{name: "dev", path: "envs/dev.yaml"}, {name: "prod", path: "./envs/prod.yaml"}}
+	tests := []struct {
+		name       string
+		changed    []string
+		wantShared bool
+		wantEnvs   map[string]bool
+	}{
+		{
+			name:     "env settings",
+			changed:  []string{"envs/prod.yaml"},
+			wantEnvs: map[string]bool{"prod": true},
+		},
+		{
+			name:       "shared input",
+			changed:    []string{"component.yaml", "envs/dev.yaml"},
+			wantShared: true,
+			wantEnvs:   map[string]bool{"dev": true},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			shared, got := affectedEnvironments(tt.changed, envs)
+			if shared != tt.wantShared {
+				t.Errorf("shared = %v, want %v", shared, tt.wantShared)
+			}
+			if diff := cmp.Diff(tt.wantEnvs, got); diff != "" {
+				t.Errorf("environments mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
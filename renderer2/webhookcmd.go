@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/chathurangada/cel_playground/renderer2/pkg/config"
+	"github.com/chathurangada/cel_playground/renderer2/pkg/operator"
+)
+
+// runWebhook serves a validating admission webhook for Component and EnvSettings custom resources
+// over HTTPS until it is interrupted or terminated.
+func runWebhook(args []string) error {
+	fs := flag.NewFlagSet("webhook", flag.ExitOnError)
+	addr := fs.String("addr", ":8443", "address to listen on")
+	certFile := fs.String("tls-cert", "", "TLS certificate file (required)")
+	keyFile := fs.String("tls-key", "", "TLS private key file (required)")
+	hardenSecurity := fs.Bool("harden-security", false, "enforce baseline Pod Security Standards on rendered pod templates, as the operator does")
+	configPath := fs.String("config", "", "platform configuration file; defaults to $"+config.EnvVar+", then every "+config.FileName+" up to the repository root")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *certFile == "" || *keyFile == "" {
+		return fmt.Errorf("-tls-cert and -tls-key are required: the API server only calls webhooks over HTTPS")
+	}
+	platformConfig, err := config.Resolve(*configPath, ".")
+	if err != nil {
+		return fmt.Errorf("failed to load platform configuration: %w", err)
+	}
+	if err := applyConfigFlags(fs, platformConfig); err != nil {
+		return err
+	}
+
+	client, err := operator.InCluster(platformConfig.Remote)
+	if err != nil {
+		return err
+	}
+	op := operator.New(client, clusterRenderer(platformConfig, *hardenSecurity))
+	mux := http.NewServeMux()
+	mux.Handle("/validate", op.Webhook())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdown)
+	}()
+	log.Printf("validating %s Components and EnvSettings on https://%s/validate", operator.APIVersion, *addr)
+	if err := server.ListenAndServeTLS(*certFile, *keyFile); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}